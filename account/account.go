@@ -0,0 +1,228 @@
+// Package account keeps the local user store synchronized with Auth0. Users
+// are saved when Auth0 reports a registration or login and by a periodic
+// sync that also removes users deleted from Auth0 along with their
+// portfolios. Users can also verify their email address with the service by
+// following a signed link, rather than relying on Auth0's flag. Every change
+// is recorded in the user audit log.
+package account
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"main/auth0"
+	"main/logging"
+	"main/repository"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Actions recorded in the user audit log
+const (
+	ActionCreated           = "created"
+	ActionUpdated           = "updated"
+	ActionDeleted           = "deleted"
+	ActionPortfoliosRemoved = "portfolios_removed"
+)
+
+// SourceSync the periodic sync as the source of an audited change
+const SourceSync = "sync"
+
+// ErrNoUsers Auth0 reported no users; local users are not removed since this
+// is almost certainly a misconfiguration
+var ErrNoUsers = errors.New("Auth0 returned no users")
+
+// SyncResult the changes made by Sync
+type SyncResult struct {
+	Created           int `json:"created"`
+	Updated           int `json:"updated"`
+	Unchanged         int `json:"unchanged"`
+	Deleted           int `json:"deleted"`
+	PortfoliosRemoved int `json:"portfoliosRemoved"`
+	Failed            int `json:"failed"`
+}
+
+// FromAuth0 the local user for an Auth0 account. Users without a Tiingo token
+// are stored with an empty token.
+func FromAuth0(u *auth0.User) *repository.User {
+	tiingoToken, _ := u.TiingoToken()
+	return &repository.User{
+		ID:          u.UserID,
+		Name:        u.Name,
+		Email:       u.Email,
+		Verified:    u.EmailVerified,
+		TiingoToken: tiingoToken,
+		Synced:      time.Now(),
+	}
+}
+
+// Save store an Auth0 account and audit the change if the user is new or any
+// of their details changed. source names what reported the account, e.g.
+// post-login. Returns the audited action or "" if nothing changed.
+func Save(ctx context.Context, u *auth0.User, source string) (string, error) {
+	if u.UserID == "" {
+		return "", errors.New("user_id is required")
+	}
+
+	user := FromAuth0(u)
+	stored, err := repository.Users.Get(ctx, user.ID)
+	if err != nil && err != sql.ErrNoRows {
+		// a user that cannot be read, e.g. because the token key changed,
+		// is replaced
+		log.WithFields(log.Fields{
+			logging.FieldFunction: "account/account.go:Save",
+			logging.FieldUserID:   user.ID,
+			logging.FieldError:    err,
+		}).Warn("Could not read stored user; replacing it")
+	}
+
+	action := ActionCreated
+	changed := []string{}
+	if stored != nil {
+		changed = changes(stored, user)
+		action = ActionUpdated
+		if len(changed) == 0 {
+			action = ""
+		}
+	}
+
+	if err := repository.Users.SaveUser(ctx, user); err != nil {
+		return "", err
+	}
+
+	if action != "" {
+		audit(ctx, user.ID, action, map[string]interface{}{
+			"source":  source,
+			"changed": changed,
+		})
+	}
+
+	return action, nil
+}
+
+// SyncAuth0 retrieve every account from Auth0 and Sync them
+func SyncAuth0(ctx context.Context) (*SyncResult, error) {
+	users, err := auth0.ListUsers()
+	if err != nil {
+		return nil, err
+	}
+	return Sync(ctx, users)
+}
+
+// Sync save users, which must be every account in Auth0, and remove the
+// stored users and portfolios of accounts that no longer exist
+func Sync(ctx context.Context, users []auth0.User) (*SyncResult, error) {
+	if len(users) == 0 {
+		return nil, ErrNoUsers
+	}
+
+	result := &SyncResult{}
+	exists := make(map[string]bool, len(users))
+	for ii := range users {
+		exists[users[ii].UserID] = true
+
+		action, err := Save(ctx, &users[ii], SourceSync)
+		switch {
+		case err != nil:
+			log.WithFields(log.Fields{
+				logging.FieldFunction: "account/account.go:Sync",
+				logging.FieldUserID:   users[ii].UserID,
+				logging.FieldError:    err,
+			}).Error("Could not save user")
+			result.Failed++
+		case action == ActionCreated:
+			result.Created++
+		case action == ActionUpdated:
+			result.Updated++
+		default:
+			result.Unchanged++
+		}
+	}
+
+	stored, err := repository.Users.ListIDs(ctx)
+	if err != nil {
+		return result, err
+	}
+	for _, userID := range stored {
+		if exists[userID] {
+			continue
+		}
+		if err := repository.Users.Delete(ctx, userID); err != nil {
+			log.WithFields(log.Fields{
+				logging.FieldFunction: "account/account.go:Sync",
+				logging.FieldUserID:   userID,
+				logging.FieldError:    err,
+			}).Error("Could not delete user")
+			result.Failed++
+			continue
+		}
+		audit(ctx, userID, ActionDeleted, map[string]interface{}{"source": SourceSync})
+		result.Deleted++
+	}
+
+	// portfolios of users that no longer exist are orphaned, whether or not
+	// the user was stored locally
+	owners, err := repository.Portfolios.OwnerIDs(ctx)
+	if err != nil {
+		return result, err
+	}
+	for _, userID := range owners {
+		if exists[userID] {
+			continue
+		}
+		removed, err := repository.Portfolios.DeleteByUser(ctx, userID)
+		if err != nil {
+			log.WithFields(log.Fields{
+				logging.FieldFunction: "account/account.go:Sync",
+				logging.FieldUserID:   userID,
+				logging.FieldError:    err,
+			}).Error("Could not remove orphaned portfolios")
+			result.Failed++
+			continue
+		}
+		audit(ctx, userID, ActionPortfoliosRemoved, map[string]interface{}{
+			"source": SourceSync,
+			"count":  removed,
+		})
+		result.PortfoliosRemoved += int(removed)
+	}
+
+	return result, nil
+}
+
+// changes names of the details that differ between the stored and current
+// user. The Tiingo token is compared but never recorded.
+func changes(stored *repository.User, current *repository.User) []string {
+	changed := []string{}
+	if stored.Name != current.Name {
+		changed = append(changed, "name")
+	}
+	if stored.Email != current.Email {
+		changed = append(changed, "email")
+	}
+	if stored.Verified != current.Verified {
+		changed = append(changed, "email_verified")
+	}
+	if stored.TiingoToken != current.TiingoToken {
+		changed = append(changed, "tiingo_token")
+	}
+	return changed
+}
+
+func audit(ctx context.Context, userID string, action string, detail map[string]interface{}) {
+	log.WithFields(log.Fields{
+		logging.FieldUserID: userID,
+		"Action":            action,
+		"Detail":            detail,
+	}).Info("User changed")
+
+	if err := repository.Users.Audit(ctx, userID, action, detail); err != nil {
+		log.WithFields(log.Fields{
+			logging.FieldFunction: "account/account.go:audit",
+			logging.FieldUserID:   userID,
+			"Action":              action,
+			logging.FieldError:    err,
+		}).Error("Could not record user audit")
+	}
+}
@@ -0,0 +1,220 @@
+package account_test
+
+import (
+	"context"
+	"database/sql"
+	"main/account"
+	"main/auth0"
+	"main/preferences"
+	"main/repository"
+	"sort"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type auditEntry struct {
+	UserID string
+	Action string
+	Detail interface{}
+}
+
+// fakeUsers an in-memory user store; methods not used by account panic
+type fakeUsers struct {
+	repository.UserRepo
+	users map[string]repository.User
+	audit []auditEntry
+}
+
+func (f *fakeUsers) Get(ctx context.Context, userID string) (*repository.User, error) {
+	u, ok := f.users[userID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &u, nil
+}
+
+func (f *fakeUsers) SaveUser(ctx context.Context, u *repository.User) error {
+	f.users[u.ID] = *u
+	return nil
+}
+
+func (f *fakeUsers) ListIDs(ctx context.Context) ([]string, error) {
+	ids := []string{}
+	for id := range f.users {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func (f *fakeUsers) Delete(ctx context.Context, userID string) error {
+	delete(f.users, userID)
+	return nil
+}
+
+func (f *fakeUsers) Audit(ctx context.Context, userID string, action string, detail interface{}) error {
+	f.audit = append(f.audit, auditEntry{UserID: userID, Action: action, Detail: detail})
+	return nil
+}
+
+func (f *fakeUsers) MarkVerified(ctx context.Context, userID string, email string, verified time.Time) error {
+	u, ok := f.users[userID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	u.VerifiedEmail = email
+	u.VerifiedAt = verified.Unix()
+	f.users[userID] = u
+	return nil
+}
+
+func (f *fakeUsers) SetVerificationSent(ctx context.Context, userID string, sent time.Time) error {
+	u, ok := f.users[userID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	u.VerificationSent = sent.Unix()
+	f.users[userID] = u
+	return nil
+}
+
+func (f *fakeUsers) Preferences(ctx context.Context, userID string) (*preferences.Preferences, error) {
+	prefs := preferences.Default()
+	prefs.UserID = userID
+	return &prefs, nil
+}
+
+// fakePortfolios the owners of portfolios and how many each owns
+type fakePortfolios struct {
+	repository.PortfolioRepo
+	owned map[string]int64
+}
+
+func (f *fakePortfolios) OwnerIDs(ctx context.Context) ([]string, error) {
+	ids := []string{}
+	for id := range f.owned {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func (f *fakePortfolios) DeleteByUser(ctx context.Context, userID string) (int64, error) {
+	n := f.owned[userID]
+	delete(f.owned, userID)
+	return n, nil
+}
+
+var _ = Describe("Account", func() {
+	var (
+		ctx        context.Context
+		users      *fakeUsers
+		portfolios *fakePortfolios
+	)
+
+	auth0User := func(id string, email string) auth0.User {
+		return auth0.User{
+			UserID:        id,
+			Name:          "Test User",
+			Email:         email,
+			EmailVerified: true,
+			UserMetaData:  map[string]interface{}{"tiingo_token": "token-" + id},
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		users = &fakeUsers{users: make(map[string]repository.User)}
+		portfolios = &fakePortfolios{owned: make(map[string]int64)}
+		repository.Users = users
+		repository.Portfolios = portfolios
+	})
+
+	Describe("When saving a user reported by Auth0", func() {
+		It("should create and audit a new user", func() {
+			u := auth0User("auth0|1", "one@example.com")
+			action, err := account.Save(ctx, &u, "post-registration")
+			Expect(err).To(BeNil())
+			Expect(action).To(Equal(account.ActionCreated))
+
+			Expect(users.users).To(HaveKey("auth0|1"))
+			Expect(users.users["auth0|1"].TiingoToken).To(Equal("token-auth0|1"))
+			Expect(users.audit).To(HaveLen(1))
+			Expect(users.audit[0].Action).To(Equal(account.ActionCreated))
+		})
+
+		It("should only audit changed details", func() {
+			u := auth0User("auth0|1", "one@example.com")
+			_, err := account.Save(ctx, &u, "post-registration")
+			Expect(err).To(BeNil())
+
+			action, err := account.Save(ctx, &u, "post-login")
+			Expect(err).To(BeNil())
+			Expect(action).To(Equal(""))
+			Expect(users.audit).To(HaveLen(1))
+
+			u.Email = "new@example.com"
+			action, err = account.Save(ctx, &u, "post-login")
+			Expect(err).To(BeNil())
+			Expect(action).To(Equal(account.ActionUpdated))
+			Expect(users.audit).To(HaveLen(2))
+			Expect(users.audit[1].Detail).To(HaveKeyWithValue("changed", []string{"email"}))
+			Expect(users.users["auth0|1"].Email).To(Equal("new@example.com"))
+		})
+
+		It("should require a user id", func() {
+			u := auth0User("", "one@example.com")
+			_, err := account.Save(ctx, &u, "post-login")
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	Describe("When synchronizing with Auth0", func() {
+		It("should remove deleted users and orphaned portfolios", func() {
+			users.users["auth0|1"] = repository.User{ID: "auth0|1", Name: "Test User", Email: "one@example.com", Verified: true, TiingoToken: "token-auth0|1"}
+			users.users["auth0|gone"] = repository.User{ID: "auth0|gone"}
+			portfolios.owned["auth0|1"] = 2
+			portfolios.owned["auth0|gone"] = 3
+			portfolios.owned["auth0|never-stored"] = 1
+
+			result, err := account.Sync(ctx, []auth0.User{
+				auth0User("auth0|1", "one@example.com"),
+				auth0User("auth0|2", "two@example.com"),
+			})
+			Expect(err).To(BeNil())
+			Expect(*result).To(Equal(account.SyncResult{
+				Created:           1,
+				Unchanged:         1,
+				Deleted:           1,
+				PortfoliosRemoved: 4,
+			}))
+
+			Expect(users.users).To(HaveKey("auth0|2"))
+			Expect(users.users).NotTo(HaveKey("auth0|gone"))
+			Expect(portfolios.owned).To(Equal(map[string]int64{"auth0|1": 2}))
+
+			actions := []string{}
+			for _, entry := range users.audit {
+				actions = append(actions, entry.UserID+" "+entry.Action)
+			}
+			Expect(actions).To(ConsistOf(
+				"auth0|2 created",
+				"auth0|gone deleted",
+				"auth0|gone portfolios_removed",
+				"auth0|never-stored portfolios_removed",
+			))
+		})
+
+		It("should not remove anything if Auth0 returns no users", func() {
+			users.users["auth0|1"] = repository.User{ID: "auth0|1"}
+			portfolios.owned["auth0|1"] = 2
+
+			_, err := account.Sync(ctx, []auth0.User{})
+			Expect(err).To(MatchError(account.ErrNoUsers))
+			Expect(users.users).To(HaveKey("auth0|1"))
+			Expect(portfolios.owned).To(HaveKey("auth0|1"))
+		})
+	})
+})
@@ -0,0 +1,301 @@
+package account
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"main/locale"
+	"main/logging"
+	"main/notification"
+	"main/preferences"
+	"main/repository"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sendgrid/sendgrid-go"
+	"github.com/sendgrid/sendgrid-go/helpers/mail"
+	log "github.com/sirupsen/logrus"
+)
+
+// Actions recorded in the user audit log by email verification
+const (
+	ActionVerificationSent = "verification_sent"
+	ActionEmailVerified    = "email_verified"
+)
+
+// VerificationLifetime how long a verification link is valid
+const VerificationLifetime = 72 * time.Hour
+
+// ResendInterval minimum time between verification emails to a user
+const ResendInterval = 5 * time.Minute
+
+var (
+	// ErrVerificationNotConfigured EMAIL_VERIFICATION_URL or
+	// EMAIL_VERIFICATION_SECRET is not set
+	ErrVerificationNotConfigured = errors.New("email verification is not configured")
+
+	// ErrAlreadyVerified the user's email address is already verified
+	ErrAlreadyVerified = errors.New("email address is already verified")
+
+	// ErrNoEmail the user has no email address to verify
+	ErrNoEmail = errors.New("user has no email address")
+
+	// ErrResendTooSoon a verification email was sent less than ResendInterval
+	// ago
+	ErrResendTooSoon = errors.New("a verification email was sent recently")
+
+	// ErrVerificationExpired the verification link is past its expiry
+	ErrVerificationExpired = errors.New("verification link has expired")
+
+	// ErrEmailChanged the user's email address changed after the
+	// verification link was sent
+	ErrEmailChanged = errors.New("email address changed since the verification link was sent")
+)
+
+// Verification a request to verify that a user owns an email address,
+// carried in a signed link
+type Verification struct {
+	UserID  string `json:"u"`
+	Email   string `json:"e"`
+	Expires int64  `json:"x"`
+}
+
+// Token sign the verification with secret so it can be embedded in a link
+// and verified without the user logging in
+func (v *Verification) Token(secret string) (string, error) {
+	if secret == "" {
+		return "", ErrVerificationNotConfigured
+	}
+	js, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(js)
+	return payload + "." + signVerification(payload, secret), nil
+}
+
+// URL link to base that verifies the email address when followed
+func (v *Verification) URL(base string, secret string) (string, error) {
+	token, err := v.Token(secret)
+	if err != nil {
+		return "", err
+	}
+	return base + "?token=" + url.QueryEscape(token), nil
+}
+
+// ParseVerification verify the signature and expiry of a verification token
+// and return the verification it carries
+func ParseVerification(token string, secret string, now time.Time) (Verification, error) {
+	v := Verification{}
+	if secret == "" {
+		return v, ErrVerificationNotConfigured
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return v, errors.New("malformed verification token")
+	}
+	if !hmac.Equal([]byte(parts[1]), []byte(signVerification(parts[0], secret))) {
+		return v, errors.New("invalid verification token signature")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return v, errors.New("malformed verification token")
+	}
+	if err := json.Unmarshal(payload, &v); err != nil || v.UserID == "" || v.Email == "" {
+		return v, errors.New("malformed verification token")
+	}
+	if now.Unix() >= v.Expires {
+		return v, ErrVerificationExpired
+	}
+
+	return v, nil
+}
+
+func signVerification(payload string, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Verifier sends verification emails and records the addresses users verify
+// by following them
+type Verifier struct {
+	// BaseURL the endpoint verification links point at, e.g.
+	// https://api.example.com/v1/verification/confirm
+	BaseURL string
+	Secret  string
+
+	// Deliver sends a SendGrid v3 mail request and returns the ids of the
+	// sent messages
+	Deliver func(message []byte) ([]string, error)
+
+	// Now returns the current time; defaults to time.Now
+	Now func() time.Time
+}
+
+// NewVerifier a verifier configured by EMAIL_VERIFICATION_URL and
+// EMAIL_VERIFICATION_SECRET that sends email with SendGrid
+func NewVerifier() (*Verifier, error) {
+	v := &Verifier{
+		BaseURL: os.Getenv("EMAIL_VERIFICATION_URL"),
+		Secret:  os.Getenv("EMAIL_VERIFICATION_SECRET"),
+		Deliver: sendGrid,
+		Now:     time.Now,
+	}
+	if v.BaseURL == "" || v.Secret == "" {
+		return nil, ErrVerificationNotConfigured
+	}
+	return v, nil
+}
+
+// Send email userID a link that verifies their current email address. Users
+// that are already verified, or were sent a link less than ResendInterval
+// ago, are not sent another.
+func (v *Verifier) Send(ctx context.Context, userID string) error {
+	u, err := repository.Users.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if u.Email == "" {
+		return ErrNoEmail
+	}
+	if u.EmailVerified() {
+		return ErrAlreadyVerified
+	}
+	now := v.Now()
+	if u.VerificationSent != 0 && now.Sub(time.Unix(u.VerificationSent, 0)) < ResendInterval {
+		return ErrResendTooSoon
+	}
+
+	verification := Verification{
+		UserID:  u.ID,
+		Email:   u.Email,
+		Expires: now.Add(VerificationLifetime).Unix(),
+	}
+	link, err := verification.URL(v.BaseURL, v.Secret)
+	if err != nil {
+		return err
+	}
+
+	templates, err := repository.Notifications.Templates(ctx)
+	if err != nil {
+		return err
+	}
+	prefs, err := repository.Users.Preferences(ctx, u.ID)
+	if err != nil {
+		return err
+	}
+	message, err := verificationEmail(u, link, templates, prefs.Localization())
+	if err != nil {
+		return err
+	}
+
+	messageIDs, err := v.Deliver(message)
+	if err != nil {
+		return err
+	}
+
+	if err := repository.Users.SetVerificationSent(ctx, u.ID, now); err != nil {
+		return err
+	}
+	for _, messageID := range messageIDs {
+		sent := repository.SentNotification{
+			MessageID: messageID,
+			UserID:    u.ID,
+			Email:     u.Email,
+			Kind:      notification.KindVerification,
+		}
+		if err := repository.Notifications.RecordSent(ctx, &sent); err != nil {
+			log.WithFields(log.Fields{
+				logging.FieldFunction: "account/verification.go:Send",
+				logging.FieldUserID:   u.ID,
+				logging.FieldError:    err,
+			}).Warn("Could not record sent verification email")
+		}
+	}
+	audit(ctx, u.ID, ActionVerificationSent, map[string]interface{}{"expires": verification.Expires})
+
+	return nil
+}
+
+// Verify record that the user of a verification link owns the email address
+// it was sent to. The link must be for the user's current address.
+func (v *Verifier) Verify(ctx context.Context, token string) (*repository.User, error) {
+	now := v.Now()
+	verification, err := ParseVerification(token, v.Secret, now)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := repository.Users.Get(ctx, verification.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(u.Email, verification.Email) {
+		return nil, ErrEmailChanged
+	}
+	if strings.EqualFold(u.VerifiedEmail, u.Email) {
+		return u, nil
+	}
+
+	if err := repository.Users.MarkVerified(ctx, u.ID, u.Email, now); err != nil {
+		return nil, err
+	}
+	u.VerifiedEmail = u.Email
+	u.VerifiedAt = now.Unix()
+	audit(ctx, u.ID, ActionEmailVerified, map[string]interface{}{})
+
+	return u, nil
+}
+
+// verificationEmail a SendGrid v3 mail request asking u to follow link,
+// rendered with the verification template for the user's locale
+func verificationEmail(u *repository.User, link string, templates []notification.Template, loc *locale.Locale) ([]byte, error) {
+	m := mail.NewV3Mail()
+	m.SetFrom(mail.NewEmail("Penny Vault", "notify@pennyvault.com"))
+
+	person := mail.NewPersonalization()
+	person.AddTos(mail.NewEmail(u.Name, u.Email))
+	person.SetDynamicTemplateData("name", u.Name)
+	person.SetDynamicTemplateData("email", u.Email)
+	person.SetDynamicTemplateData("verifyUrl", link)
+
+	t := notification.SelectTemplate(templates, notification.KindVerification, preferences.ChannelEmail, "", loc.Tag)
+	if t.SendGridTemplateID != "" {
+		m.SetTemplateID(t.SendGridTemplateID)
+	} else {
+		subject, body, err := t.Render(person.DynamicTemplateData, loc)
+		if err != nil {
+			return nil, err
+		}
+		m.Subject = subject
+		m.AddContent(mail.NewContent("text/html", body))
+		person.DynamicTemplateData = nil
+	}
+
+	m.AddPersonalizations(person)
+	return mail.GetRequestBody(m), nil
+}
+
+// sendGrid send a mail request with the key in SENDGRID_API_KEY
+func sendGrid(message []byte) ([]string, error) {
+	request := sendgrid.GetRequest(os.Getenv("SENDGRID_API_KEY"), "/v3/mail/send", "https://api.sendgrid.com")
+	request.Method = "POST"
+	request.Body = message
+
+	response, err := sendgrid.API(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode >= 300 {
+		return nil, errors.New("SendGrid rejected the verification email: " + response.Body)
+	}
+	return response.Headers["X-Message-Id"], nil
+}
@@ -0,0 +1,151 @@
+package account_test
+
+import (
+	"context"
+	"encoding/json"
+	"main/account"
+	"main/notification"
+	"main/repository"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// fakeNotifications records sent notifications; no templates are configured
+type fakeNotifications struct {
+	repository.NotificationRepo
+	sent []repository.SentNotification
+}
+
+func (f *fakeNotifications) RecordSent(ctx context.Context, sent *repository.SentNotification) error {
+	f.sent = append(f.sent, *sent)
+	return nil
+}
+
+func (f *fakeNotifications) Templates(ctx context.Context) ([]notification.Template, error) {
+	return []notification.Template{}, nil
+}
+
+var _ = Describe("Verification", func() {
+	var (
+		ctx           context.Context
+		users         *fakeUsers
+		notifications *fakeNotifications
+		verifier      *account.Verifier
+		now           time.Time
+		delivered     [][]byte
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		now = time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+		delivered = [][]byte{}
+
+		users = &fakeUsers{users: map[string]repository.User{
+			"auth0|1": {ID: "auth0|1", Name: "Test User", Email: "one@example.com"},
+		}}
+		notifications = &fakeNotifications{}
+		repository.Users = users
+		repository.Notifications = notifications
+
+		verifier = &account.Verifier{
+			BaseURL: "https://api.example.com/v1/verification/confirm",
+			Secret:  "secret",
+			Deliver: func(message []byte) ([]string, error) {
+				delivered = append(delivered, message)
+				return []string{"message-1"}, nil
+			},
+			Now: func() time.Time { return now },
+		}
+	})
+
+	// link the verification link of the last delivered email
+	link := func() string {
+		Expect(delivered).ToNot(BeEmpty())
+		msg := map[string]interface{}{}
+		Expect(json.Unmarshal(delivered[len(delivered)-1], &msg)).To(Succeed())
+		content := msg["content"].([]interface{})[0].(map[string]interface{})["value"].(string)
+		start := strings.Index(content, "token=")
+		Expect(start).To(BeNumerically(">", 0))
+		end := strings.Index(content[start:], `"`)
+		return content[start+len("token=") : start+end]
+	}
+
+	Describe("When signing verification tokens", func() {
+		It("should round trip the verification", func() {
+			v := account.Verification{UserID: "auth0|1", Email: "one@example.com", Expires: now.Add(time.Hour).Unix()}
+			token, err := v.Token("secret")
+			Expect(err).To(BeNil())
+
+			parsed, err := account.ParseVerification(token, "secret", now)
+			Expect(err).To(BeNil())
+			Expect(parsed).To(Equal(v))
+		})
+
+		It("should reject expired and tampered tokens", func() {
+			v := account.Verification{UserID: "auth0|1", Email: "one@example.com", Expires: now.Unix()}
+			token, err := v.Token("secret")
+			Expect(err).To(BeNil())
+
+			_, err = account.ParseVerification(token, "secret", now)
+			Expect(err).To(Equal(account.ErrVerificationExpired))
+			_, err = account.ParseVerification(token, "other", now.Add(-time.Hour))
+			Expect(err).To(HaveOccurred())
+			_, err = v.Token("")
+			Expect(err).To(Equal(account.ErrVerificationNotConfigured))
+		})
+	})
+
+	Describe("When sending a verification email", func() {
+		It("should email a link that verifies the address", func() {
+			Expect(verifier.Send(ctx, "auth0|1")).To(Succeed())
+			Expect(delivered).To(HaveLen(1))
+			Expect(string(delivered[0])).To(ContainSubstring("one@example.com"))
+			Expect(users.users["auth0|1"].VerificationSent).To(Equal(now.Unix()))
+			Expect(notifications.sent).To(HaveLen(1))
+			Expect(notifications.sent[0].Kind).To(Equal(notification.KindVerification))
+			Expect(users.audit[0].Action).To(Equal(account.ActionVerificationSent))
+
+			u, err := verifier.Verify(ctx, link())
+			Expect(err).To(BeNil())
+			Expect(u.EmailVerified()).To(BeTrue())
+			Expect(users.users["auth0|1"].VerifiedEmail).To(Equal("one@example.com"))
+			Expect(users.audit[1].Action).To(Equal(account.ActionEmailVerified))
+
+			Expect(verifier.Send(ctx, "auth0|1")).To(Equal(account.ErrAlreadyVerified))
+		})
+
+		It("should rate limit resends", func() {
+			Expect(verifier.Send(ctx, "auth0|1")).To(Succeed())
+			now = now.Add(time.Minute)
+			Expect(verifier.Send(ctx, "auth0|1")).To(Equal(account.ErrResendTooSoon))
+			now = now.Add(account.ResendInterval)
+			Expect(verifier.Send(ctx, "auth0|1")).To(Succeed())
+			Expect(delivered).To(HaveLen(2))
+		})
+
+		It("should not verify an address the user no longer has", func() {
+			Expect(verifier.Send(ctx, "auth0|1")).To(Succeed())
+			u := users.users["auth0|1"]
+			u.Email = "new@example.com"
+			users.users["auth0|1"] = u
+
+			_, err := verifier.Verify(ctx, link())
+			Expect(err).To(Equal(account.ErrEmailChanged))
+			Expect(users.users["auth0|1"].VerifiedEmail).To(Equal(""))
+		})
+	})
+
+	Describe("When checking whether an email address is verified", func() {
+		It("should require verification of the current address", func() {
+			u := repository.User{Email: "One@example.com", VerifiedEmail: "one@example.com"}
+			Expect(u.EmailVerified()).To(BeTrue())
+			u.Email = "new@example.com"
+			Expect(u.EmailVerified()).To(BeFalse())
+			u.Verified = true
+			Expect(u.EmailVerified()).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,213 @@
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"main/data"
+	"main/logging"
+	"main/portfolio"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rocketlaunchr/dataframe-go"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// KindDrawdown triggers when the portfolio's current draw down from its
+	// peak value exceeds the threshold (e.g. 0.10 for 10%)
+	KindDrawdown = "drawdown"
+
+	// KindPriceChange triggers when the price of ticker changes by more than
+	// threshold over the lookback period. A negative threshold (e.g. -0.05)
+	// triggers on a fall, a positive threshold on a rise.
+	KindPriceChange = "price_change"
+)
+
+// Rule a user defined alert rule
+type Rule struct {
+	ID            uuid.UUID  `json:"id"`
+	UserID        string     `json:"-"`
+	PortfolioID   *uuid.UUID `json:"portfolioId"`
+	Kind          string     `json:"kind"`
+	Ticker        string     `json:"ticker"`
+	Threshold     float64    `json:"threshold"`
+	LookbackDays  int        `json:"lookbackDays"`
+	Active        bool       `json:"active"`
+	Triggered     bool       `json:"triggered"`
+	LastTriggered *time.Time `json:"lastTriggered"`
+}
+
+// Trigger result of evaluating a rule on a given date
+type Trigger struct {
+	Rule    *Rule
+	Date    time.Time
+	Value   float64
+	Message string
+}
+
+// Validate check that the rule is well formed
+func (r *Rule) Validate() error {
+	switch r.Kind {
+	case KindDrawdown:
+		if r.PortfolioID == nil {
+			return errors.New("drawdown alerts require a portfolio")
+		}
+		if r.Threshold <= 0 || r.Threshold >= 1 {
+			return errors.New("drawdown threshold must be between 0 and 1")
+		}
+	case KindPriceChange:
+		if r.Ticker == "" {
+			return errors.New("price change alerts require a ticker")
+		}
+		if r.Threshold == 0 {
+			return errors.New("price change threshold must be non-zero")
+		}
+		if r.LookbackDays <= 0 {
+			return errors.New("price change alerts require a positive lookback")
+		}
+	default:
+		return fmt.Errorf("unknown alert kind '%s'", r.Kind)
+	}
+	return nil
+}
+
+// CurrentDrawDown the loss of the most recent measurement relative to the
+// peak value observed so far, expressed as a negative fraction
+func CurrentDrawDown(perf *portfolio.Performance) float64 {
+	if len(perf.Measurements) == 0 {
+		return 0
+	}
+
+	var peak float64
+	for _, m := range perf.Measurements {
+		peak = math.Max(peak, m.Value)
+	}
+
+	if peak <= 0 {
+		return 0
+	}
+
+	last := perf.Measurements[len(perf.Measurements)-1].Value
+	return last/peak - 1.0
+}
+
+// PriceChange percent change between the first and last value of the
+// symbol's series in the dataframe
+func PriceChange(df *dataframe.DataFrame, symbol string) (float64, error) {
+	idx, err := df.NameToColumn(symbol)
+	if err != nil {
+		return 0, err
+	}
+
+	series := df.Series[idx]
+	nrows := series.NRows()
+	if nrows < 2 {
+		return 0, errors.New("not enough price data to compute change")
+	}
+
+	first, ok := series.Value(0).(float64)
+	if !ok || math.IsNaN(first) || first == 0 {
+		return 0, errors.New("invalid starting price")
+	}
+	last, ok := series.Value(nrows - 1).(float64)
+	if !ok || math.IsNaN(last) {
+		return 0, errors.New("invalid ending price")
+	}
+
+	return last/first - 1.0, nil
+}
+
+// EvaluateDrawDown check a drawdown rule against the portfolio performance
+func (r *Rule) EvaluateDrawDown(forDate time.Time, perf *portfolio.Performance) *Trigger {
+	dd := CurrentDrawDown(perf)
+	if dd > -r.Threshold {
+		return nil
+	}
+
+	return &Trigger{
+		Rule:    r,
+		Date:    forDate,
+		Value:   dd,
+		Message: fmt.Sprintf("Portfolio draw down of %.2f%% exceeds %.2f%%", dd*-100, r.Threshold*100),
+	}
+}
+
+// EvaluatePriceChange check a price change rule using daily data from manager
+func (r *Rule) EvaluatePriceChange(forDate time.Time, manager *data.Manager) (*Trigger, error) {
+	symbol := strings.ToUpper(r.Ticker)
+	manager.Begin = forDate.AddDate(0, 0, -r.LookbackDays)
+	manager.End = forDate
+	manager.Frequency = data.FrequencyDaily
+
+	df, err := manager.GetData(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	change, err := PriceChange(df, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	if (r.Threshold < 0 && change > r.Threshold) || (r.Threshold > 0 && change < r.Threshold) {
+		return nil, nil
+	}
+
+	direction := "rose"
+	if change < 0 {
+		direction = "fell"
+	}
+
+	return &Trigger{
+		Rule:    r,
+		Date:    forDate,
+		Value:   change,
+		Message: fmt.Sprintf("%s %s %.2f%% over the last %d days", symbol, direction, math.Abs(change)*100, r.LookbackDays),
+	}, nil
+}
+
+// Evaluate check the rule on forDate. perf may be nil for rules that are not
+// attached to a portfolio. Only returns a trigger when the rule transitions
+// from not triggered to triggered so that users are not notified every night
+// while a condition persists.
+func (r *Rule) Evaluate(forDate time.Time, perf *portfolio.Performance, manager *data.Manager) (*Trigger, error) {
+	if !r.Active {
+		return nil, nil
+	}
+
+	var trigger *Trigger
+	var err error
+
+	switch r.Kind {
+	case KindDrawdown:
+		if perf == nil {
+			return nil, errors.New("drawdown alert evaluated without portfolio performance")
+		}
+		trigger = r.EvaluateDrawDown(forDate, perf)
+	case KindPriceChange:
+		trigger, err = r.EvaluatePriceChange(forDate, manager)
+		if err != nil {
+			log.WithFields(log.Fields{
+				logging.FieldFunction: "alert/alert.go:Evaluate",
+				"AlertID":             r.ID,
+				"Ticker":              r.Ticker,
+				logging.FieldError:    err,
+			}).Warn("Could not evaluate price change alert")
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown alert kind '%s'", r.Kind)
+	}
+
+	wasTriggered := r.Triggered
+	r.Triggered = trigger != nil
+	if trigger == nil || wasTriggered {
+		return nil, nil
+	}
+
+	r.LastTriggered = &forDate
+	return trigger, nil
+}
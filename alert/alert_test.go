@@ -0,0 +1,111 @@
+package alert_test
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/rocketlaunchr/dataframe-go"
+
+	"main/alert"
+	"main/data"
+	"main/portfolio"
+)
+
+var _ = Describe("Alert", func() {
+	var (
+		perf portfolio.Performance
+		rule alert.Rule
+	)
+
+	BeforeEach(func() {
+		perf = portfolio.Performance{
+			Measurements: []portfolio.PerformanceMeasurement{
+				{Value: 10000},
+				{Value: 12000},
+				{Value: 11500},
+				{Value: 10500},
+			},
+		}
+
+		portfolioID := uuid.New()
+		rule = alert.Rule{
+			PortfolioID: &portfolioID,
+			Kind:        alert.KindDrawdown,
+			Threshold:   0.10,
+			Active:      true,
+		}
+	})
+
+	Describe("When given a performance struct", func() {
+		It("should compute the current draw down", func() {
+			Expect(alert.CurrentDrawDown(&perf)).Should(BeNumerically("~", -0.125, 1e-6))
+		})
+
+		It("should trigger when the draw down exceeds the threshold", func() {
+			trigger, err := rule.Evaluate(time.Now(), &perf, nil)
+			Expect(err).To(BeNil())
+			Expect(trigger).NotTo(BeNil())
+			Expect(trigger.Value).Should(BeNumerically("~", -0.125, 1e-6))
+			Expect(rule.Triggered).To(BeTrue())
+			Expect(rule.LastTriggered).NotTo(BeNil())
+		})
+
+		It("should only trigger once while the condition persists", func() {
+			trigger, err := rule.Evaluate(time.Now(), &perf, nil)
+			Expect(err).To(BeNil())
+			Expect(trigger).NotTo(BeNil())
+
+			trigger, err = rule.Evaluate(time.Now(), &perf, nil)
+			Expect(err).To(BeNil())
+			Expect(trigger).To(BeNil())
+			Expect(rule.Triggered).To(BeTrue())
+		})
+
+		It("should not trigger when the draw down is within the threshold", func() {
+			rule.Threshold = 0.15
+			trigger, err := rule.Evaluate(time.Now(), &perf, nil)
+			Expect(err).To(BeNil())
+			Expect(trigger).To(BeNil())
+			Expect(rule.Triggered).To(BeFalse())
+		})
+
+		It("should not trigger inactive alerts", func() {
+			rule.Active = false
+			trigger, err := rule.Evaluate(time.Now(), &perf, nil)
+			Expect(err).To(BeNil())
+			Expect(trigger).To(BeNil())
+		})
+	})
+
+	Describe("When given a price series", func() {
+		It("should compute the price change", func() {
+			dates := dataframe.NewSeriesTime(data.DateIdx, &dataframe.SeriesInit{Size: 3}, []time.Time{
+				time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2021, time.March, 2, 0, 0, 0, 0, time.UTC),
+				time.Date(2021, time.March, 3, 0, 0, 0, 0, time.UTC),
+			})
+			prices := dataframe.NewSeriesFloat64("VUSTX", &dataframe.SeriesInit{Size: 3}, []float64{20.0, 19.5, 18.8})
+			df := dataframe.NewDataFrame(dates, prices)
+
+			change, err := alert.PriceChange(df, "VUSTX")
+			Expect(err).To(BeNil())
+			Expect(change).Should(BeNumerically("~", -0.06, 1e-6))
+		})
+	})
+
+	Describe("When validating rules", func() {
+		It("should require a ticker for price change alerts", func() {
+			r := alert.Rule{Kind: alert.KindPriceChange, Threshold: -0.05, LookbackDays: 7}
+			Expect(r.Validate()).NotTo(BeNil())
+			r.Ticker = "VUSTX"
+			Expect(r.Validate()).To(BeNil())
+		})
+
+		It("should reject unknown kinds", func() {
+			r := alert.Rule{Kind: "unknown"}
+			Expect(r.Validate()).NotTo(BeNil())
+		})
+	})
+})
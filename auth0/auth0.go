@@ -0,0 +1,267 @@
+// Package auth0 is a client of the Auth0 Management API, which stores the
+// accounts of pv-api users
+package auth0
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"main/logging"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Token an Auth0 Management API access token
+type Token struct {
+	AccessToken string `json:"access_token"`
+	Scope       string `json:"scope"`
+	ExpiresIn   int    `json:"expires_in"`
+	TokenType   string `json:"token_type"`
+}
+
+// User an Auth0 user account
+type User struct {
+	UserID        string                 `json:"user_id"`
+	Name          string                 `json:"name"`
+	Email         string                 `json:"email"`
+	EmailVerified bool                   `json:"email_verified"`
+	UserMetaData  map[string]interface{} `json:"user_metadata"`
+}
+
+// TiingoToken the user's Tiingo API token stored in their user metadata
+func (u *User) TiingoToken() (string, error) {
+	v, ok := u.UserMetaData["tiingo_token"]
+	if !ok {
+		return "", errors.New("tiingo token missing")
+	}
+	tiingoToken, ok := v.(string)
+	if !ok {
+		return "", errors.New("tiingo token invalid type")
+	}
+	return tiingoToken, nil
+}
+
+// ManagementToken request an access token for the Auth0 Management API using
+// the client credentials in AUTH0_DOMAIN, AUTH0_CLIENT_ID, and AUTH0_SECRET
+func ManagementToken() (string, error) {
+	domain := os.Getenv("AUTH0_DOMAIN")
+	clientID := os.Getenv("AUTH0_CLIENT_ID")
+	secret := os.Getenv("AUTH0_SECRET")
+
+	url := fmt.Sprintf("%s/oauth/token", domain)
+	bodyStr := fmt.Sprintf(`grant_type=client_credentials&client_id=%s&client_secret=%s&audience=%s/api/v2/`, clientID, secret, domain)
+	body := strings.NewReader(bodyStr)
+	req, err := http.NewRequest("POST", url, body)
+	if err != nil {
+		log.WithFields(
+			log.Fields{
+				"Domain":           domain,
+				"ClientId":         clientID,
+				logging.FieldError: err,
+			}).Error("Cannot build Auth0 Management API access token request")
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.WithFields(
+			log.Fields{
+				"Domain":           domain,
+				"ClientId":         clientID,
+				logging.FieldError: err,
+			}).Error("Cannot get Auth0 Management API access token request")
+		return "", err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		// NOTE: It's save to ignore the err return here
+		// as we are just formatting an error message for the
+		// already errored out HTTP response
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		log.WithFields(
+			log.Fields{
+				"Domain":     domain,
+				"ClientId":   clientID,
+				"StatusCode": resp.StatusCode,
+				"Headers":    resp.Header,
+				"Body":       respBody,
+			}).Error("Cannot get Auth0 Management API access token request")
+		return "", errors.New("Cannot get Auth0 Management API access token request")
+	}
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		log.WithFields(
+			log.Fields{
+				"Domain":     domain,
+				"ClientId":   clientID,
+				"StatusCode": resp.StatusCode,
+				"Headers":    resp.Header,
+				"Body":       respBody,
+			}).Error("Failed to read body response when retrieving Auth0 Management API access token")
+		return "", errors.New("Cannot get Auth0 Management API access token request")
+	}
+
+	managementToken := &Token{}
+	err = json.Unmarshal(respBody, managementToken)
+	if err != nil {
+		log.WithFields(
+			log.Fields{
+				"Domain":     domain,
+				"ClientId":   clientID,
+				"StatusCode": resp.StatusCode,
+				"Headers":    resp.Header,
+				"Body":       respBody,
+			}).Error("Failed to convert json Auth0 Management API access token")
+		return "", errors.New("Cannot get Auth0 Management API access token request")
+	}
+
+	return managementToken.AccessToken, nil
+}
+
+// GetUser retrieve the account of userID
+func GetUser(userID string) (*User, error) {
+	log.WithFields(log.Fields{
+		logging.FieldUserID: userID,
+	}).Info("Requesting user from auth0")
+
+	domain := os.Getenv("AUTH0_DOMAIN")
+	token, err := ManagementToken()
+	if err != nil {
+		return nil, err
+	}
+
+	encodedUserID := url.QueryEscape(userID)
+	url := fmt.Sprintf("%s/api/v2/users/%s", domain, encodedUserID)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"Domain":            domain,
+			logging.FieldUserID: userID,
+			logging.FieldError:  err,
+		}).Error("Could not create Auth0 user request")
+		return nil, err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"Domain":            domain,
+			logging.FieldUserID: userID,
+			logging.FieldError:  err,
+		}).Error("User account request failed")
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		log.WithFields(log.Fields{
+			"Domain":            domain,
+			logging.FieldUserID: userID,
+			logging.FieldError:  err,
+			"Body":              string(respBody),
+		}).Error("User account request failed")
+		return nil, errors.New("User account request failed")
+	}
+
+	respBody, _ := ioutil.ReadAll(resp.Body)
+	u := &User{}
+	err = json.Unmarshal(respBody, u)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"Domain":            domain,
+			logging.FieldUserID: userID,
+			logging.FieldError:  err,
+			"Body":              string(respBody),
+		}).Error("Could not decode user response")
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// usersPage a page of the Auth0 user list
+type usersPage struct {
+	Start int    `json:"start"`
+	Limit int    `json:"limit"`
+	Total int    `json:"total"`
+	Users []User `json:"users"`
+}
+
+// usersPerPage the maximum page size of the Auth0 user list
+const usersPerPage = 100
+
+// ListUsers retrieve every user account. An error is returned unless all of
+// the accounts Auth0 reports were retrieved.
+func ListUsers() ([]User, error) {
+	domain := os.Getenv("AUTH0_DOMAIN")
+	token, err := ManagementToken()
+	if err != nil {
+		return nil, err
+	}
+
+	users := []User{}
+	for page := 0; ; page++ {
+		url := fmt.Sprintf("%s/api/v2/users?page=%d&per_page=%d&include_totals=true", domain, page, usersPerPage)
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"Domain":           domain,
+				"Page":             page,
+				logging.FieldError: err,
+			}).Error("User list request failed")
+			return nil, err
+		}
+
+		respBody, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode >= 400 {
+			log.WithFields(log.Fields{
+				"Domain":     domain,
+				"Page":       page,
+				"StatusCode": resp.StatusCode,
+				"Body":       string(respBody),
+			}).Error("User list request failed")
+			return nil, errors.New("User list request failed")
+		}
+
+		result := usersPage{}
+		if err := json.Unmarshal(respBody, &result); err != nil {
+			log.WithFields(log.Fields{
+				"Domain":           domain,
+				"Page":             page,
+				logging.FieldError: err,
+				"Body":             string(respBody),
+			}).Error("Could not decode user list response")
+			return nil, err
+		}
+
+		users = append(users, result.Users...)
+		if len(result.Users) == 0 || len(users) >= result.Total {
+			if len(users) != result.Total {
+				return nil, fmt.Errorf("retrieved %d of %d users", len(users), result.Total)
+			}
+			return users, nil
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package auth0_test
+
+import (
+	"fmt"
+	"main/auth0"
+	"os"
+
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Auth0", func() {
+	const domain = "https://pennyvault.test"
+
+	page := func(total int, ids ...string) string {
+		users := ""
+		for ii, id := range ids {
+			if ii > 0 {
+				users += ","
+			}
+			users += fmt.Sprintf(`{"user_id": %q, "email": "%s@example.com", "email_verified": true, "user_metadata": {"tiingo_token": "token"}}`, id, id)
+		}
+		return fmt.Sprintf(`{"start": 0, "limit": 100, "total": %d, "users": [%s]}`, total, users)
+	}
+
+	BeforeEach(func() {
+		os.Setenv("AUTH0_DOMAIN", domain)
+		httpmock.Activate()
+		httpmock.RegisterResponder("POST", domain+"/oauth/token",
+			httpmock.NewStringResponder(200, `{"access_token": "management", "token_type": "Bearer"}`))
+	})
+
+	AfterEach(func() {
+		httpmock.DeactivateAndReset()
+	})
+
+	Describe("When listing users", func() {
+		It("should request every page", func() {
+			httpmock.RegisterResponderWithQuery("GET", domain+"/api/v2/users", "page=0&per_page=100&include_totals=true",
+				httpmock.NewStringResponder(200, page(3, "a", "b")))
+			httpmock.RegisterResponderWithQuery("GET", domain+"/api/v2/users", "page=1&per_page=100&include_totals=true",
+				httpmock.NewStringResponder(200, page(3, "c")))
+
+			users, err := auth0.ListUsers()
+			Expect(err).To(BeNil())
+			Expect(users).To(HaveLen(3))
+			Expect(users[2].UserID).To(Equal("c"))
+
+			token, err := users[0].TiingoToken()
+			Expect(err).To(BeNil())
+			Expect(token).To(Equal("token"))
+		})
+
+		It("should fail if fewer users are returned than reported", func() {
+			httpmock.RegisterResponderWithQuery("GET", domain+"/api/v2/users", "page=0&per_page=100&include_totals=true",
+				httpmock.NewStringResponder(200, page(3, "a", "b")))
+			httpmock.RegisterResponderWithQuery("GET", domain+"/api/v2/users", "page=1&per_page=100&include_totals=true",
+				httpmock.NewStringResponder(200, page(3)))
+
+			_, err := auth0.ListUsers()
+			Expect(err).To(MatchError("retrieved 2 of 3 users"))
+		})
+
+		It("should fail when Auth0 returns an error", func() {
+			httpmock.RegisterResponder("GET", domain+"/api/v2/users",
+				httpmock.NewStringResponder(429, `{"error": "Too Many Requests"}`))
+
+			_, err := auth0.ListUsers()
+			Expect(err).NotTo(BeNil())
+		})
+	})
+})
@@ -0,0 +1,267 @@
+package benchmark
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"main/data"
+	"main/dfextras"
+	"main/portfolio"
+	"main/strategies"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+const (
+	// KindStatic a fixed mix of securities rebalanced every period
+	KindStatic = "static"
+
+	// KindStrategy the portfolio produced by running a strategy
+	KindStrategy = "strategy"
+)
+
+// Benchmark a portfolio that other portfolios are compared against
+type Benchmark struct {
+	ID          string             `json:"id"`
+	UserID      string             `json:"-"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Kind        string             `json:"kind"`
+	Allocation  map[string]float64 `json:"allocation,omitempty"`
+	Strategy    string             `json:"strategy,omitempty"`
+	Arguments   json.RawMessage    `json:"arguments,omitempty"`
+	BuiltIn     bool               `json:"builtIn"`
+
+	// RebalanceBands of a static benchmark; without bands the allocation is
+	// rebalanced every period
+	RebalanceBands *portfolio.RebalanceBands `json:"rebalanceBands,omitempty"`
+}
+
+// DefaultID benchmark used when a portfolio has none attached
+const DefaultID = "sp500"
+
+// Presets built-in benchmarks available to all users
+var Presets = []Benchmark{
+	{
+		ID:          "sp500",
+		Name:        "S&P 500",
+		Description: "Vanguard 500 Index Fund (VFINX)",
+		Kind:        KindStatic,
+		Allocation:  map[string]float64{"VFINX": 1.0},
+		BuiltIn:     true,
+	},
+	{
+		ID:          "60-40",
+		Name:        "60/40",
+		Description: "60% S&P 500 (VFINX) and 40% US aggregate bonds (VBMFX) rebalanced monthly",
+		Kind:        KindStatic,
+		Allocation:  map[string]float64{"VFINX": 0.6, "VBMFX": 0.4},
+		BuiltIn:     true,
+	},
+	{
+		ID:          "agg",
+		Name:        "US Aggregate Bonds",
+		Description: "Vanguard Total Bond Market Index Fund (VBMFX) which tracks the same index as AGG",
+		Kind:        KindStatic,
+		Allocation:  map[string]float64{"VBMFX": 1.0},
+		BuiltIn:     true,
+	},
+}
+
+// Preset return the built-in benchmark with the given id
+func Preset(id string) (Benchmark, bool) {
+	for _, b := range Presets {
+		if b.ID == id {
+			return b, true
+		}
+	}
+	return Benchmark{}, false
+}
+
+// Validate check that the benchmark is well formed
+func (b *Benchmark) Validate() error {
+	if b.Name == "" {
+		return errors.New("benchmarks require a name")
+	}
+
+	switch b.Kind {
+	case KindStatic:
+		if len(b.Allocation) == 0 {
+			return errors.New("static benchmarks require an allocation")
+		}
+		var total float64
+		normalized := make(map[string]float64, len(b.Allocation))
+		for ticker, weight := range b.Allocation {
+			if weight <= 0 {
+				return fmt.Errorf("weight of %s must be positive", ticker)
+			}
+			total += weight
+			normalized[strings.ToUpper(ticker)] = weight
+		}
+		if math.Abs(total-1.0) > 1.0e-6 {
+			return fmt.Errorf("allocation must sum to 1.0, it is %.4f", total)
+		}
+		b.Allocation = normalized
+		if b.RebalanceBands != nil && (b.RebalanceBands.Absolute < 0 || b.RebalanceBands.Relative < 0) {
+			return errors.New("rebalance bands must not be negative")
+		}
+	case KindStrategy:
+		if _, ok := strategies.StrategyMap[b.Strategy]; !ok {
+			return fmt.Errorf("strategy '%s' not found", b.Strategy)
+		}
+		b.RebalanceBands = nil
+	default:
+		return fmt.Errorf("unknown benchmark kind '%s'", b.Kind)
+	}
+
+	return nil
+}
+
+// Compute build the benchmark portfolio over the date range configured on
+// manager
+func (b *Benchmark) Compute(manager *data.Manager) (*portfolio.Portfolio, error) {
+	switch b.Kind {
+	case KindStatic:
+		return b.computeStatic(manager)
+	case KindStrategy:
+		strat, ok := strategies.StrategyMap[b.Strategy]
+		if !ok {
+			return nil, fmt.Errorf("strategy '%s' not found", b.Strategy)
+		}
+
+		params := map[string]json.RawMessage{}
+		if len(b.Arguments) > 0 {
+			if err := json.Unmarshal(b.Arguments, &params); err != nil {
+				return nil, err
+			}
+		}
+
+		stratObject, err := strat.Factory(params)
+		if err != nil {
+			return nil, err
+		}
+		return stratObject.Compute(manager)
+	}
+
+	return nil, fmt.Errorf("unknown benchmark kind '%s'", b.Kind)
+}
+
+// computeStatic invest in the allocation and rebalance back to it on every
+// date that prices are available for all securities, or only when a holding
+// drifts outside the benchmark's rebalance bands
+func (b *Benchmark) computeStatic(manager *data.Manager) (*portfolio.Portfolio, error) {
+	tickers := make([]string, 0, len(b.Allocation))
+	for ticker := range b.Allocation {
+		tickers = append(tickers, ticker)
+	}
+	sort.Strings(tickers)
+
+	var dates []time.Time
+	if len(tickers) == 1 {
+		// a single security is bought and held
+		dates = []time.Time{manager.Begin}
+	} else {
+		prices, errs := manager.GetMultipleData(tickers...)
+		if len(errs) > 0 {
+			return nil, errors.New("failed to download benchmark prices")
+		}
+
+		dfs := make([]*dataframe.DataFrame, 0, len(prices))
+		for _, df := range prices {
+			dfs = append(dfs, df)
+		}
+
+		merged, err := dfextras.Merge(context.TODO(), data.DateIdx, dfs...)
+		if err != nil {
+			return nil, err
+		}
+		dfextras.DropNA(context.TODO(), merged, dataframe.FilterOptions{
+			InPlace: true,
+		})
+
+		dateIdx, err := merged.NameToColumn(data.DateIdx)
+		if err != nil {
+			return nil, err
+		}
+		iterator := merged.Series[dateIdx].ValuesIterator()
+		for {
+			row, val, _ := iterator()
+			if row == nil {
+				break
+			}
+			dates = append(dates, val.(time.Time))
+		}
+	}
+
+	if len(dates) == 0 {
+		return nil, errors.New("no prices available for benchmark")
+	}
+
+	targets := make([]interface{}, len(dates))
+	for ii := range dates {
+		target := make(map[string]float64, len(b.Allocation))
+		for ticker, weight := range b.Allocation {
+			target[ticker] = weight
+		}
+		targets[ii] = target
+	}
+
+	dateSeries := dataframe.NewSeriesTime(data.DateIdx, &dataframe.SeriesInit{Size: len(dates)}, dates)
+	targetSeries := dataframe.NewSeriesMixed(portfolio.TickerName, &dataframe.SeriesInit{Size: len(dates)}, targets...)
+
+	p := portfolio.NewPortfolio(b.Name, manager)
+	if b.RebalanceBands != nil {
+		p.RebalanceBands = *b.RebalanceBands
+	}
+	if err := p.TargetPortfolio(portfolio.DefaultInitialCapital, dataframe.NewDataFrame(dateSeries, targetSeries)); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// PerformanceFor compute the benchmark over the same period and at the same
+// resolution as perf
+func (b *Benchmark) PerformanceFor(perf *portfolio.Performance, manager *data.Manager) (*portfolio.Performance, error) {
+	begin := time.Unix(perf.PeriodStart, 0).UTC()
+	end := time.Unix(perf.PeriodEnd, 0).UTC()
+
+	manager.Begin = begin
+	manager.End = end
+	manager.Frequency = data.FrequencyMonthly
+
+	p, err := b.Compute(manager)
+	if err != nil {
+		return nil, err
+	}
+
+	// measure the benchmark at the same resolution and scale so returns
+	// and values are aligned
+	p.Resolution = perf.Resolution
+	p.Normalize = perf.Normalized
+
+	benchPerf, err := p.CalculatePerformance(end)
+	if err != nil {
+		return nil, err
+	}
+	return &benchPerf, nil
+}
+
+// Compare compute the benchmark over the same period as perf and return the
+// performance of perf relative to it
+func (b *Benchmark) Compare(perf *portfolio.Performance, manager *data.Manager) (portfolio.RelativeMetrics, error) {
+	benchPerf, err := b.PerformanceFor(perf, manager)
+	if err != nil {
+		return portfolio.RelativeMetrics{}, err
+	}
+
+	metrics := perf.RelativeTo(benchPerf)
+	metrics.BenchmarkID = b.ID
+	metrics.BenchmarkName = b.Name
+	return metrics, nil
+}
@@ -0,0 +1,85 @@
+package benchmark_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"main/benchmark"
+	"main/portfolio"
+)
+
+var _ = Describe("Benchmark", func() {
+	Describe("When looking up presets", func() {
+		It("should find the default benchmark", func() {
+			b, ok := benchmark.Preset(benchmark.DefaultID)
+			Expect(ok).To(BeTrue())
+			Expect(b.BuiltIn).To(BeTrue())
+			Expect(b.Allocation).To(HaveKeyWithValue("VFINX", 1.0))
+		})
+
+		It("should not find unknown benchmarks", func() {
+			_, ok := benchmark.Preset("unknown")
+			Expect(ok).To(BeFalse())
+		})
+
+		It("should have valid presets", func() {
+			for _, b := range benchmark.Presets {
+				Expect(b.Validate()).To(Succeed())
+			}
+		})
+	})
+
+	Describe("When validating a static benchmark", func() {
+		It("should normalize tickers", func() {
+			b := benchmark.Benchmark{
+				Name:       "Permanent",
+				Kind:       benchmark.KindStatic,
+				Allocation: map[string]float64{"vti": 0.5, "VUSTX": 0.5},
+			}
+			Expect(b.Validate()).To(Succeed())
+			Expect(b.Allocation).To(HaveKey("VTI"))
+		})
+
+		It("should require weights that sum to 1", func() {
+			b := benchmark.Benchmark{
+				Name:       "Bad",
+				Kind:       benchmark.KindStatic,
+				Allocation: map[string]float64{"VTI": 0.5, "VUSTX": 0.4},
+			}
+			Expect(b.Validate()).NotTo(Succeed())
+		})
+
+		It("should reject negative weights", func() {
+			b := benchmark.Benchmark{
+				Name:       "Bad",
+				Kind:       benchmark.KindStatic,
+				Allocation: map[string]float64{"VTI": 1.5, "VUSTX": -0.5},
+			}
+			Expect(b.Validate()).NotTo(Succeed())
+		})
+
+		It("should reject negative rebalance bands", func() {
+			b := benchmark.Benchmark{
+				Name:           "Bad",
+				Kind:           benchmark.KindStatic,
+				Allocation:     map[string]float64{"VTI": 0.6, "VUSTX": 0.4},
+				RebalanceBands: &portfolio.RebalanceBands{Absolute: -5},
+			}
+			Expect(b.Validate()).NotTo(Succeed())
+
+			b.RebalanceBands = &portfolio.RebalanceBands{Absolute: 5, Relative: 25}
+			Expect(b.Validate()).To(Succeed())
+		})
+	})
+
+	Describe("When validating a strategy benchmark", func() {
+		It("should require a known strategy", func() {
+			b := benchmark.Benchmark{
+				Name:     "Unknown",
+				Kind:     benchmark.KindStrategy,
+				Strategy: "not-a-strategy",
+			}
+			Expect(b.Validate()).NotTo(Succeed())
+		})
+	})
+})
@@ -0,0 +1,156 @@
+// Package billing determines what a user is entitled to from their
+// subscription. Users without a subscription are on the free plan, which
+// limits the number of portfolios, how far back strategies are tested, and
+// how often notifications are sent. Subscriptions are managed in Stripe and
+// updated from its webhook events.
+package billing
+
+import (
+	"fmt"
+	"main/notification"
+	"strings"
+	"time"
+)
+
+// Plans a user may subscribe to
+const (
+	PlanFree = "free"
+	PlanPro  = "pro"
+)
+
+// Statuses of a Stripe subscription
+const (
+	StatusTrialing   = "trialing"
+	StatusActive     = "active"
+	StatusPastDue    = "past_due"
+	StatusCanceled   = "canceled"
+	StatusUnpaid     = "unpaid"
+	StatusIncomplete = "incomplete"
+)
+
+// GracePeriod how long after the end of its billing period a past due
+// subscription keeps its plan while payment is retried
+const GracePeriod = 7 * 24 * time.Hour
+
+// Limits what a plan entitles a user to. Zero limits are unlimited.
+type Limits struct {
+	Plan             string   `json:"plan"`
+	MaxPortfolios    int      `json:"maxPortfolios"`
+	MaxBacktestYears int      `json:"maxBacktestYears"`
+	Notifications    []string `json:"notifications"`
+}
+
+// Plans the limits of each plan by name
+var Plans = map[string]Limits{
+	PlanFree: {
+		Plan:             PlanFree,
+		MaxPortfolios:    3,
+		MaxBacktestYears: 10,
+		Notifications:    []string{"weekly", "monthly", "annually"},
+	},
+	PlanPro: {
+		Plan:          PlanPro,
+		Notifications: []string{"daily", "weekly", "monthly", "annually"},
+	},
+}
+
+// Subscription a user's Stripe subscription. EventCreated is the creation
+// time of the Stripe event it was last updated from, so events delivered
+// out of order do not overwrite newer state.
+type Subscription struct {
+	UserID           string `json:"-"`
+	Plan             string `json:"plan"`
+	Status           string `json:"status"`
+	TrialEnds        int64  `json:"trialEnds,omitempty"`
+	CurrentPeriodEnd int64  `json:"currentPeriodEnd,omitempty"`
+	CustomerID       string `json:"-"`
+	SubscriptionID   string `json:"-"`
+	EventCreated     int64  `json:"-"`
+}
+
+// Entitlements the limits sub grants at now. Users without a subscription,
+// or whose subscription lapsed, are entitled to the free plan; past due
+// subscriptions keep their plan for GracePeriod after the billing period.
+func Entitlements(sub *Subscription, now time.Time) Limits {
+	if sub == nil {
+		return Plans[PlanFree]
+	}
+	limits, ok := Plans[sub.Plan]
+	if !ok {
+		return Plans[PlanFree]
+	}
+
+	switch sub.Status {
+	case StatusActive:
+		return limits
+	case StatusTrialing:
+		if sub.TrialEnds == 0 || now.Unix() < sub.TrialEnds {
+			return limits
+		}
+	case StatusPastDue:
+		if now.Before(time.Unix(sub.CurrentPeriodEnd, 0).Add(GracePeriod)) {
+			return limits
+		}
+	}
+	return Plans[PlanFree]
+}
+
+// LimitError a request exceeds the limits of the user's plan
+type LimitError struct {
+	Plan    string
+	Message string
+}
+
+func (e *LimitError) Error() string {
+	return e.Message + "; upgrade your plan to remove the limit"
+}
+
+// CheckPortfolios verify the user may have one more portfolio than count
+func (l *Limits) CheckPortfolios(count int) error {
+	if l.MaxPortfolios > 0 && count >= l.MaxPortfolios {
+		return &LimitError{Plan: l.Plan, Message: fmt.Sprintf("the %s plan is limited to %d portfolios", l.Plan, l.MaxPortfolios)}
+	}
+	return nil
+}
+
+// EarliestStart the earliest date a backtest ending at end may start; zero
+// if backtests are unlimited
+func (l *Limits) EarliestStart(end time.Time) time.Time {
+	if l.MaxBacktestYears <= 0 {
+		return time.Time{}
+	}
+	return end.AddDate(-l.MaxBacktestYears, 0, 0)
+}
+
+// CheckBacktest verify a backtest from start to end is within the plan
+func (l *Limits) CheckBacktest(start time.Time, end time.Time) error {
+	if earliest := l.EarliestStart(end); start.Before(earliest) {
+		return &LimitError{Plan: l.Plan, Message: fmt.Sprintf("the %s plan is limited to backtests of %d years", l.Plan, l.MaxBacktestYears)}
+	}
+	return nil
+}
+
+// NotificationMask the notification frequency flags the plan allows
+func (l *Limits) NotificationMask() int {
+	mask := 0
+	for _, frequency := range l.Notifications {
+		mask |= notification.Frequencies[frequency]
+	}
+	return mask
+}
+
+// AllowedNotifications clear the flags of frequencies the plan does not
+// allow from notifications
+func (l *Limits) AllowedNotifications(notifications int) int {
+	all := notification.Daily | notification.Weekly | notification.Monthly | notification.Annually
+	return notifications &^ (all &^ l.NotificationMask())
+}
+
+// CheckNotifications verify every frequency enabled in notifications is
+// allowed by the plan
+func (l *Limits) CheckNotifications(notifications int) error {
+	if l.AllowedNotifications(notifications) != notifications {
+		return &LimitError{Plan: l.Plan, Message: fmt.Sprintf("the %s plan only sends %s notifications", l.Plan, strings.Join(l.Notifications, ", "))}
+	}
+	return nil
+}
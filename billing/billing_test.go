@@ -0,0 +1,80 @@
+package billing_test
+
+import (
+	"main/billing"
+	"main/notification"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Billing", func() {
+	var now time.Time
+
+	BeforeEach(func() {
+		now = time.Date(2021, time.June, 1, 0, 0, 0, 0, time.UTC)
+	})
+
+	Describe("When determining entitlements", func() {
+		It("should put users without a subscription on the free plan", func() {
+			Expect(billing.Entitlements(nil, now).Plan).To(Equal(billing.PlanFree))
+		})
+
+		It("should entitle active subscriptions and trials to their plan", func() {
+			sub := &billing.Subscription{Plan: billing.PlanPro, Status: billing.StatusActive}
+			Expect(billing.Entitlements(sub, now).Plan).To(Equal(billing.PlanPro))
+
+			sub.Status = billing.StatusTrialing
+			sub.TrialEnds = now.AddDate(0, 0, 1).Unix()
+			Expect(billing.Entitlements(sub, now).Plan).To(Equal(billing.PlanPro))
+			sub.TrialEnds = now.Unix()
+			Expect(billing.Entitlements(sub, now).Plan).To(Equal(billing.PlanFree))
+		})
+
+		It("should keep past due subscriptions on their plan during the grace period", func() {
+			sub := &billing.Subscription{Plan: billing.PlanPro, Status: billing.StatusPastDue, CurrentPeriodEnd: now.AddDate(0, 0, -3).Unix()}
+			Expect(billing.Entitlements(sub, now).Plan).To(Equal(billing.PlanPro))
+			Expect(billing.Entitlements(sub, now.AddDate(0, 0, 5)).Plan).To(Equal(billing.PlanFree))
+
+			sub.Status = billing.StatusCanceled
+			Expect(billing.Entitlements(sub, now).Plan).To(Equal(billing.PlanFree))
+		})
+	})
+
+	Describe("When checking the limits of the free plan", func() {
+		var limits billing.Limits
+
+		BeforeEach(func() {
+			limits = billing.Plans[billing.PlanFree]
+		})
+
+		It("should limit the number of portfolios", func() {
+			Expect(limits.CheckPortfolios(2)).To(Succeed())
+			err := limits.CheckPortfolios(3)
+			Expect(err).To(BeAssignableToTypeOf(&billing.LimitError{}))
+			Expect(err.Error()).To(ContainSubstring("limited to 3 portfolios"))
+		})
+
+		It("should limit the length of backtests", func() {
+			Expect(limits.EarliestStart(now)).To(Equal(time.Date(2011, time.June, 1, 0, 0, 0, 0, time.UTC)))
+			Expect(limits.CheckBacktest(now.AddDate(-10, 0, 0), now)).To(Succeed())
+			Expect(limits.CheckBacktest(now.AddDate(-10, 0, -1), now)).To(HaveOccurred())
+		})
+
+		It("should not allow daily notifications", func() {
+			notifications := notification.Daily | notification.Monthly | 1
+			Expect(limits.AllowedNotifications(notifications)).To(Equal(notification.Monthly | 1))
+			Expect(limits.CheckNotifications(notifications)).To(HaveOccurred())
+			Expect(limits.CheckNotifications(notification.Weekly)).To(Succeed())
+		})
+
+		It("should not limit the pro plan", func() {
+			pro := billing.Plans[billing.PlanPro]
+			Expect(pro.CheckPortfolios(1000)).To(Succeed())
+			Expect(pro.EarliestStart(now).IsZero()).To(BeTrue())
+			Expect(pro.CheckBacktest(time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC), now)).To(Succeed())
+			Expect(pro.CheckNotifications(notification.Daily | notification.Annually)).To(Succeed())
+		})
+	})
+})
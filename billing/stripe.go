@@ -0,0 +1,163 @@
+package billing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Stripe events that change a subscription
+const (
+	EventSubscriptionCreated = "customer.subscription.created"
+	EventSubscriptionUpdated = "customer.subscription.updated"
+	EventSubscriptionDeleted = "customer.subscription.deleted"
+)
+
+// WebhookTolerance how old a signed Stripe webhook request may be
+const WebhookTolerance = 5 * time.Minute
+
+// ErrNoUser the subscription does not name the user it is for in its
+// user_id metadata
+var ErrNoUser = errors.New("subscription has no user_id metadata")
+
+// ErrUnknownPlan neither the plan metadata of the subscription nor the
+// lookup key of its price names a plan
+var ErrUnknownPlan = errors.New("subscription is not to a known plan")
+
+// StripeEvent a Stripe webhook event
+type StripeEvent struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Created int64  `json:"created"`
+	Data    struct {
+		Object json.RawMessage `json:"object"`
+	} `json:"data"`
+}
+
+// stripeSubscription the fields of a Stripe subscription object that
+// determine the user's plan
+type stripeSubscription struct {
+	ID               string            `json:"id"`
+	Customer         string            `json:"customer"`
+	Status           string            `json:"status"`
+	TrialEnd         int64             `json:"trial_end"`
+	CurrentPeriodEnd int64             `json:"current_period_end"`
+	Metadata         map[string]string `json:"metadata"`
+	Items            struct {
+		Data []struct {
+			Price struct {
+				LookupKey string `json:"lookup_key"`
+			} `json:"price"`
+		} `json:"data"`
+	} `json:"items"`
+}
+
+// ParseStripeEvent parse the body of a Stripe webhook request
+func ParseStripeEvent(body []byte) (StripeEvent, error) {
+	event := StripeEvent{}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return event, err
+	}
+	if event.ID == "" || event.Type == "" {
+		return event, errors.New("malformed Stripe event")
+	}
+	return event, nil
+}
+
+// IsSubscription true if the event changes a subscription
+func (e *StripeEvent) IsSubscription() bool {
+	switch e.Type {
+	case EventSubscriptionCreated, EventSubscriptionUpdated, EventSubscriptionDeleted:
+		return true
+	}
+	return false
+}
+
+// Subscription the subscription a subscription event reports. The plan is
+// the plan metadata of the subscription or the lookup key of its price;
+// subscriptions to unknown prices, e.g. a misconfigured or test price, are
+// rejected with ErrUnknownPlan rather than granting a plan.
+func (e *StripeEvent) Subscription() (*Subscription, error) {
+	if !e.IsSubscription() {
+		return nil, errors.New("event is not about a subscription")
+	}
+	obj := stripeSubscription{}
+	if err := json.Unmarshal(e.Data.Object, &obj); err != nil {
+		return nil, err
+	}
+	if obj.Metadata["user_id"] == "" {
+		return nil, ErrNoUser
+	}
+
+	sub := &Subscription{
+		UserID:           obj.Metadata["user_id"],
+		Plan:             obj.Metadata["plan"],
+		Status:           obj.Status,
+		TrialEnds:        obj.TrialEnd,
+		CurrentPeriodEnd: obj.CurrentPeriodEnd,
+		CustomerID:       obj.Customer,
+		SubscriptionID:   obj.ID,
+		EventCreated:     e.Created,
+	}
+	if _, ok := Plans[sub.Plan]; !ok && len(obj.Items.Data) > 0 {
+		sub.Plan = obj.Items.Data[0].Price.LookupKey
+	}
+	if _, ok := Plans[sub.Plan]; !ok {
+		return nil, ErrUnknownPlan
+	}
+	if e.Type == EventSubscriptionDeleted {
+		sub.Status = StatusCanceled
+	}
+	return sub, nil
+}
+
+// VerifyStripeWebhook check the Stripe-Signature header of a webhook request
+// against the endpoint's signing secret. Requests signed more than
+// WebhookTolerance before now are rejected to prevent replays.
+func VerifyStripeWebhook(secret string, header string, body []byte, now time.Time) error {
+	if secret == "" {
+		return errors.New("Stripe webhook secret is not configured")
+	}
+
+	var timestamp string
+	signatures := []string{}
+	for _, part := range strings.Split(header, ",") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "t":
+			timestamp = kv[1]
+		case "v1":
+			signatures = append(signatures, kv[1])
+		}
+	}
+	if timestamp == "" || len(signatures) == 0 {
+		return errors.New("malformed webhook signature")
+	}
+
+	signed, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("malformed webhook signature")
+	}
+	if age := now.Sub(time.Unix(signed, 0)); age > WebhookTolerance || age < -WebhookTolerance {
+		return errors.New("webhook signature timestamp is outside the tolerance")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			return nil
+		}
+	}
+	return errors.New("invalid webhook signature")
+}
@@ -0,0 +1,106 @@
+package billing_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"main/billing"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// stripeSignature the Stripe-Signature header of body signed at t
+func stripeSignature(secret string, t time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("%d.", t.Unix())))
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", t.Unix(), hex.EncodeToString(mac.Sum(nil)))
+}
+
+var _ = Describe("Stripe", func() {
+	var (
+		now  time.Time
+		body []byte
+	)
+
+	BeforeEach(func() {
+		now = time.Date(2021, time.June, 1, 12, 0, 0, 0, time.UTC)
+		body = []byte(`{
+			"id": "evt_1",
+			"type": "customer.subscription.updated",
+			"created": 1622548800,
+			"data": {"object": {
+				"id": "sub_1",
+				"customer": "cus_1",
+				"status": "trialing",
+				"trial_end": 1623758400,
+				"current_period_end": 1623758400,
+				"metadata": {"user_id": "auth0|1"},
+				"items": {"data": [{"price": {"lookup_key": "pro"}}]}
+			}}
+		}`)
+	})
+
+	Describe("When verifying webhook signatures", func() {
+		It("should accept requests signed with the secret", func() {
+			Expect(billing.VerifyStripeWebhook("whsec", stripeSignature("whsec", now, body), body, now)).To(Succeed())
+		})
+
+		It("should reject other secrets, altered bodies, and old requests", func() {
+			Expect(billing.VerifyStripeWebhook("whsec", stripeSignature("other", now, body), body, now)).To(HaveOccurred())
+			Expect(billing.VerifyStripeWebhook("whsec", stripeSignature("whsec", now, body), append(body, ' '), now)).To(HaveOccurred())
+			Expect(billing.VerifyStripeWebhook("whsec", stripeSignature("whsec", now.Add(-10*time.Minute), body), body, now)).To(HaveOccurred())
+			Expect(billing.VerifyStripeWebhook("", stripeSignature("", now, body), body, now)).To(HaveOccurred())
+		})
+	})
+
+	Describe("When reading subscription events", func() {
+		It("should return the subscription of the user", func() {
+			event, err := billing.ParseStripeEvent(body)
+			Expect(err).To(BeNil())
+			Expect(event.IsSubscription()).To(BeTrue())
+
+			sub, err := event.Subscription()
+			Expect(err).To(BeNil())
+			Expect(sub.UserID).To(Equal("auth0|1"))
+			Expect(sub.Plan).To(Equal(billing.PlanPro))
+			Expect(sub.Status).To(Equal(billing.StatusTrialing))
+			Expect(sub.TrialEnds).To(Equal(int64(1623758400)))
+			Expect(sub.CustomerID).To(Equal("cus_1"))
+			Expect(sub.EventCreated).To(Equal(int64(1622548800)))
+		})
+
+		It("should cancel deleted subscriptions", func() {
+			event, err := billing.ParseStripeEvent(body)
+			Expect(err).To(BeNil())
+			event.Type = billing.EventSubscriptionDeleted
+
+			sub, err := event.Subscription()
+			Expect(err).To(BeNil())
+			Expect(sub.Status).To(Equal(billing.StatusCanceled))
+			Expect(billing.Entitlements(sub, now).Plan).To(Equal(billing.PlanFree))
+		})
+
+		It("should reject subscriptions to unknown prices", func() {
+			event, err := billing.ParseStripeEvent([]byte(`{"id": "evt_3", "type": "customer.subscription.created", "data": {"object": {
+				"id": "sub_3",
+				"status": "active",
+				"metadata": {"user_id": "auth0|1"},
+				"items": {"data": [{"price": {"lookup_key": "test_price"}}]}
+			}}}`))
+			Expect(err).To(BeNil())
+			_, err = event.Subscription()
+			Expect(err).To(Equal(billing.ErrUnknownPlan))
+		})
+
+		It("should require the user id metadata", func() {
+			event, err := billing.ParseStripeEvent([]byte(`{"id": "evt_2", "type": "customer.subscription.created", "data": {"object": {"id": "sub_2"}}}`))
+			Expect(err).To(BeNil())
+			_, err = event.Subscription()
+			Expect(err).To(Equal(billing.ErrNoUser))
+		})
+	})
+})
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"main/alert"
+	"main/data"
+	"main/logging"
+	"main/notification"
+	"main/portfolio"
+	"main/preferences"
+	"main/repository"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sendgrid/sendgrid-go/helpers/mail"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// getAlerts load all active alerts grouped by portfolio. Alerts that are not
+// attached to a portfolio are stored under uuid.Nil
+func getAlerts() map[uuid.UUID][]*alert.Rule {
+	ret := make(map[uuid.UUID][]*alert.Rule)
+	alerts, err := repository.Alerts.Active(context.Background())
+	if err != nil {
+		log.WithFields(log.Fields{
+			logging.FieldFunction: "cmd/notifier/alerts.go:getAlerts",
+			logging.FieldError:    err,
+		}).Error("Database query error in notifier")
+		return ret
+	}
+
+	for _, a := range alerts {
+		key := uuid.Nil
+		if a.PortfolioID != nil {
+			key = *a.PortfolioID
+		}
+		ret[key] = append(ret[key], a)
+	}
+
+	return ret
+}
+
+func updateAlertState(a *alert.Rule) {
+	if err := repository.Alerts.SetState(context.Background(), a); err != nil {
+		log.WithFields(log.Fields{
+			logging.FieldFunction: "cmd/notifier/alerts.go:updateAlertState",
+			"AlertID":             a.ID,
+			logging.FieldError:    err,
+		}).Error("Could not update alert state")
+	}
+}
+
+// processAlerts evaluate alerts and send an email for each newly triggered
+// rule. s and perf are nil for alerts not attached to a portfolio
+func processAlerts(forDate time.Time, alerts []*alert.Rule, s *savedStrategy, perf *portfolio.Performance) {
+	for _, a := range alerts {
+		u, err := getUser(a.UserID)
+		if err != nil {
+			continue
+		}
+
+		manager := data.NewManager(map[string]string{
+			"tiingo": u.TiingoToken,
+		})
+
+		trigger, err := a.Evaluate(forDate, perf, &manager)
+		if err != nil {
+			continue
+		}
+		updateAlertState(a)
+
+		if trigger == nil || !getPreferences(a.UserID).Notify(preferences.ChannelEmail) || suppressed(u) {
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			"AlertID":           a.ID,
+			logging.FieldUserID: u.ID,
+			"Message":           trigger.Message,
+		}).Info("Alert triggered")
+
+		message, err := buildAlertEmail(forDate, trigger, s, u)
+		if err != nil {
+			continue
+		}
+
+		statusCode, messageIDs, err := sendEmail(message)
+		if err != nil {
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			logging.FieldFunction: "cmd/notifier/alerts.go:processAlerts",
+			"StatusCode":          statusCode,
+			"MessageID":           messageIDs,
+			"AlertID":             a.ID,
+			logging.FieldUserID:   u.ID,
+			"UserEmail":           u.Email,
+		}).Infof("Sent alert email to %s", u.Email)
+		notificationSent(messageIDs, u, a.PortfolioID, notification.KindAlert, "")
+	}
+}
+
+func buildAlertEmail(forDate time.Time, trigger *alert.Trigger, s *savedStrategy, to *User) ([]byte, error) {
+	if !to.Verified {
+		log.WithFields(log.Fields{
+			logging.FieldFunction: "cmd/notifier/alerts.go:buildAlertEmail",
+			logging.FieldUserID:   to.ID,
+		}).Warn("Refusing to send email to unverified email address")
+		return nil, errors.New("Refusing to send email to unverified email address")
+	}
+
+	m := mail.NewV3Mail()
+	m.SetFrom(mail.NewEmail("Penny Vault", "notify@pennyvault.com"))
+
+	person := mail.NewPersonalization()
+	person.AddTos(mail.NewEmail(to.Name, to.Email))
+
+	if s != nil {
+		person.SetDynamicTemplateData("portfolioName", s.Name)
+	}
+	loc := getPreferences(to.ID).Localization()
+	person.SetDynamicTemplateData("forDate", loc.FormatDate(forDate))
+	person.SetDynamicTemplateData("alertKind", loc.T("alert."+trigger.Rule.Kind))
+	person.SetDynamicTemplateData("ticker", trigger.Rule.Ticker)
+	person.SetDynamicTemplateData("value", loc.FormatPercent(trigger.Value))
+	person.SetDynamicTemplateData("message", trigger.Message)
+
+	if err := applyTemplate(m, person, notification.KindAlert, "", loc); err != nil {
+		return nil, err
+	}
+
+	m.AddPersonalizations(person)
+	return mail.GetRequestBody(m), nil
+}
@@ -1,32 +1,20 @@
 package main
 
 import (
-	"encoding/json"
-	"errors"
-	"fmt"
-	"io/ioutil"
-	"net/http"
-	"net/url"
-	"os"
-	"strings"
+	"context"
+	"database/sql"
+	"main/account"
+	"main/auth0"
+	"main/logging"
+	"main/repository"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 )
 
-type Token struct {
-	AccessToken string `json:"access_token"`
-	Scope       string `json:"scope"`
-	ExpiresIn   int    `json:"expires_in"`
-	TokenType   string `json:"token_type"`
-}
-
-type Auth0User struct {
-	UserID        string                 `json:"user_id"`
-	Name          string                 `json:"name"`
-	Email         string                 `json:"email"`
-	EmailVerified bool                   `json:"email_verified"`
-	UserMetaData  map[string]interface{} `json:"user_metadata"`
-}
+// userRefreshInterval age after which a stored user is requested from Auth0
+// again
+const userRefreshInterval = 7 * 24 * time.Hour
 
 type User struct {
 	ID          string
@@ -38,179 +26,115 @@ type User struct {
 
 var userMap map[string]User = make(map[string]User)
 
-func getToken() (string, error) {
-	domain := os.Getenv("AUTH0_DOMAIN")
-	clientID := os.Getenv("AUTH0_CLIENT_ID")
-	secret := os.Getenv("AUTH0_SECRET")
-
-	url := fmt.Sprintf("%s/oauth/token", domain)
-	bodyStr := fmt.Sprintf(`grant_type=client_credentials&client_id=%s&client_secret=%s&audience=%s/api/v2/`, clientID, secret, domain)
-	body := strings.NewReader(bodyStr)
-	req, err := http.NewRequest("POST", url, body)
-	if err != nil {
-		log.WithFields(
-			log.Fields{
-				"Domain":   domain,
-				"ClientId": clientID,
-				"Error":    err,
-			}).Error("Cannot build Auth0 Management API access token request")
-		return "", err
-	}
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		log.WithFields(
-			log.Fields{
-				"Domain":   domain,
-				"ClientId": clientID,
-				"Error":    err,
-			}).Error("Cannot get Auth0 Management API access token request")
-		return "", err
+// getUser retrieve a user from the local user store. Users that have not been
+// stored, or were stored more than userRefreshInterval ago, are requested
+// from Auth0 and saved; if Auth0 is unavailable a stored user is used as is.
+// Users synchronized without a Tiingo token are always requested again.
+func getUser(userID string) (*User, error) {
+	// Check if user is already in cache
+	if u, ok := userMap[userID]; ok {
+		return &u, nil
 	}
 
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		// NOTE: It's save to ignore the err return here
-		// as we are just formatting an error message for the
-		// already errored out HTTP response
-		respBody, _ := ioutil.ReadAll(resp.Body)
-		log.WithFields(
-			log.Fields{
-				"Domain":     domain,
-				"ClientId":   clientID,
-				"StatusCode": resp.StatusCode,
-				"Headers":    resp.Header,
-				"Body":       respBody,
-			}).Error("Cannot get Auth0 Management API access token request")
-		return "", errors.New("Cannot get Auth0 Management API access token request")
+	ctx := context.Background()
+	stored, err := repository.Users.Get(ctx, userID)
+	if err != nil && err != sql.ErrNoRows {
+		log.WithFields(log.Fields{
+			logging.FieldFunction: "cmd/notifier/auth0.go:getUser",
+			logging.FieldUserID:   userID,
+			logging.FieldError:    err,
+		}).Warn("Could not read stored user")
 	}
 
-	respBody, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		log.WithFields(
-			log.Fields{
-				"Domain":     domain,
-				"ClientId":   clientID,
-				"StatusCode": resp.StatusCode,
-				"Headers":    resp.Header,
-				"Body":       respBody,
-			}).Error("Failed to read body response when retrieving Auth0 Management API access token")
-		return "", errors.New("Cannot get Auth0 Management API access token request")
+	// verification with the service is kept when a user is refreshed from
+	// Auth0
+	var verifiedEmail string
+	if stored != nil {
+		verifiedEmail = stored.VerifiedEmail
 	}
 
-	managementToken := &Token{}
-	err = json.Unmarshal(respBody, managementToken)
-	if err != nil {
-		log.WithFields(
-			log.Fields{
-				"Domain":     domain,
-				"ClientId":   clientID,
-				"StatusCode": resp.StatusCode,
-				"Headers":    resp.Header,
-				"Body":       respBody,
-			}).Error("Failed to convert json Auth0 Management API access token")
-		return "", errors.New("Cannot get Auth0 Management API access token request")
+	if stored != nil && stored.TiingoToken == "" {
+		stored = nil
 	}
 
-	return managementToken.AccessToken, nil
-}
-
-func getUser(userID string) (*User, error) {
-	// Check if user is already in cache
-	if u, ok := userMap[userID]; ok {
-		return &u, nil
+	if stored != nil && time.Since(stored.Synced) < userRefreshInterval {
+		return cacheUser(stored), nil
 	}
 
-	log.WithFields(log.Fields{
-		"UserId": userID,
-	}).Info("Requesting user from auth0")
-
-	// User hasn't been loaded yet, request from identity provider
-	domain := os.Getenv("AUTH0_DOMAIN")
-	token, err := getToken()
+	remote, err := fetchUser(userID)
 	if err != nil {
+		if stored != nil {
+			log.WithFields(log.Fields{
+				logging.FieldFunction: "cmd/notifier/auth0.go:getUser",
+				logging.FieldUserID:   userID,
+				"Synced":              stored.Synced,
+			}).Warn("Could not refresh user from Auth0; using stored user")
+			return cacheUser(stored), nil
+		}
 		return nil, err
 	}
 
-	encodedUserID := url.QueryEscape(userID)
-	url := fmt.Sprintf("%s/api/v2/users/%s", domain, encodedUserID)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
+	remote.VerifiedEmail = verifiedEmail
+	if err := repository.Users.SaveUser(ctx, remote); err != nil {
 		log.WithFields(log.Fields{
-			"Domain": domain,
-			"UserId": userID,
-			"Error":  err,
-		}).Error("Could not create Auth0 user request")
-		return nil, err
+			logging.FieldFunction: "cmd/notifier/auth0.go:getUser",
+			logging.FieldUserID:   userID,
+			logging.FieldError:    err,
+		}).Warn("Could not store user")
 	}
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
 
-	resp, err := http.DefaultClient.Do(req)
+	return cacheUser(remote), nil
+}
+
+// fetchUser request a user from Auth0
+func fetchUser(userID string) (*repository.User, error) {
+	auth0User, err := auth0.GetUser(userID)
 	if err != nil {
-		log.WithFields(log.Fields{
-			"Domain": domain,
-			"UserId": userID,
-			"Error":  err,
-		}).Error("User account request failed")
 		return nil, err
 	}
 
-	defer resp.Body.Close()
-	if resp.StatusCode >= 400 {
-		respBody, _ := ioutil.ReadAll(resp.Body)
-		log.WithFields(log.Fields{
-			"Domain": domain,
-			"UserId": userID,
-			"Error":  err,
-			"Body":   string(respBody),
-		}).Error("User account request failed")
-		return nil, errors.New("User account request failed")
-	}
-
-	respBody, _ := ioutil.ReadAll(resp.Body)
-	auth0User := Auth0User{}
-	err = json.Unmarshal(respBody, &auth0User)
-	if err != nil {
+	if _, err := auth0User.TiingoToken(); err != nil {
 		log.WithFields(log.Fields{
-			"Domain": domain,
-			"UserId": userID,
-			"Error":  err,
-			"Body":   string(respBody),
+			logging.FieldFunction: "cmd/notifier/auth0.go:fetchUser",
+			logging.FieldUserID:   userID,
+			logging.FieldError:    err,
 		}).Error("Could not decode user response")
+		return nil, err
 	}
 
-	u := User{
-		ID:       userID,
-		Name:     auth0User.Name,
-		Email:    auth0User.Email,
-		Verified: auth0User.EmailVerified,
-	}
+	return account.FromAuth0(auth0User), nil
+}
 
-	if v, ok := auth0User.UserMetaData["tiingo_token"]; ok {
-		if tiingoToken, ok := v.(string); ok {
-			u.TiingoToken = tiingoToken
-		} else {
-			log.WithFields(log.Fields{
-				"Domain": domain,
-				"UserId": userID,
-				"Error":  err,
-				"Body":   string(respBody),
-			}).Error("Could not decode user response - tiingo token invalid type")
-			return nil, errors.New("Could not decode user response - tiingo token invalid type")
-		}
-	} else {
+// syncUsers synchronize the local user store with Auth0 before portfolios are
+// processed so users are read from the database
+func syncUsers() {
+	result, err := account.SyncAuth0(context.Background())
+	if err != nil {
 		log.WithFields(log.Fields{
-			"Domain": domain,
-			"UserId": userID,
-			"Error":  err,
-			"Body":   string(respBody),
-		}).Error("Could not decode user response - tiingo token missing")
-		return nil, errors.New("Could not decode user response - tiingo token missing")
+			logging.FieldFunction: "cmd/notifier/auth0.go:syncUsers",
+			logging.FieldError:    err,
+		}).Error("Could not synchronize users with Auth0")
+		return
 	}
 
-	userMap[userID] = u
+	log.WithFields(log.Fields{
+		"Created":           result.Created,
+		"Updated":           result.Updated,
+		"Unchanged":         result.Unchanged,
+		"Deleted":           result.Deleted,
+		"PortfoliosRemoved": result.PortfoliosRemoved,
+		"Failed":            result.Failed,
+	}).Info("Synchronized users with Auth0")
+}
 
-	return &u, nil
+func cacheUser(stored *repository.User) *User {
+	u := User{
+		ID:          stored.ID,
+		Name:        stored.Name,
+		Email:       stored.Email,
+		Verified:    stored.EmailVerified(),
+		TiingoToken: stored.TiingoToken,
+	}
+	userMap[u.ID] = u
+	return &u
 }
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"main/billing"
+	"main/logging"
+	"main/repository"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var limitsMap map[string]billing.Limits = make(map[string]billing.Limits)
+
+// getLimits the limits of the plan userID is entitled to. Users whose
+// subscription cannot be read are not limited so a database error does not
+// stop their notifications.
+func getLimits(userID string) billing.Limits {
+	if limits, ok := limitsMap[userID]; ok {
+		return limits
+	}
+
+	var limits billing.Limits
+	sub, err := repository.Subscriptions.Get(context.Background(), userID)
+	switch {
+	case err == sql.ErrNoRows:
+		limits = billing.Entitlements(nil, time.Now())
+	case err != nil:
+		log.WithFields(log.Fields{
+			logging.FieldFunction: "cmd/notifier/billing.go:getLimits",
+			logging.FieldUserID:   userID,
+			logging.FieldError:    err,
+		}).Warn("Could not load subscription, not limiting notifications")
+		limits = billing.Plans[billing.PlanPro]
+	default:
+		limits = billing.Entitlements(sub, time.Now())
+	}
+
+	limitsMap[userID] = limits
+	return limits
+}
+
+// entitledNotifications the frequencies enabled in notifications that the
+// plan of userID allows
+func entitledNotifications(userID string, notifications int) int {
+	limits := getLimits(userID)
+	return limits.AllowedNotifications(notifications)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"main/alert"
+	"main/database"
+	"main/events"
+	"main/logging"
+	"main/portfolio"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// bus domain events published by the notifier. Events are stored so
+// webhooks and the API can consume them without recomputing portfolios.
+var bus *events.PostgresBus
+
+// portfolios of each user collected from portfolio.updated events for the
+// household digest
+var households = make(map[string][]portfolio.HouseholdMember)
+var householdNotifications = make(map[string]int)
+
+// setupEventBus subscribe per-portfolio notifications, alerts, the household
+// digest, history revision notices and notification history to the events
+// published during the run
+func setupEventBus(forDate time.Time, savedPortfolios []*savedStrategy, alerts map[uuid.UUID][]*alert.Rule) {
+	bus = events.NewPostgresBus(database.Conn)
+
+	byID := make(map[uuid.UUID]*savedStrategy, len(savedPortfolios))
+	for _, s := range savedPortfolios {
+		byID[s.ID] = s
+	}
+
+	// the computed portfolio is only attached to events published by this
+	// process; events replayed from the database are ignored
+	updated := func(e *events.Event) (*savedStrategy, *events.PortfolioUpdated, error) {
+		u, ok := e.Value.(*events.PortfolioUpdated)
+		if !ok || u.Performance == nil {
+			return nil, nil, errors.New("portfolio.updated event has no performance attached")
+		}
+		s, ok := byID[u.PortfolioID]
+		if !ok {
+			return nil, nil, errors.New("portfolio.updated event for unknown portfolio")
+		}
+		return s, u, nil
+	}
+
+	bus.Subscribe(events.TopicPortfolioUpdated, func(e *events.Event) error {
+		s, u, err := updated(e)
+		if err != nil {
+			return err
+		}
+		processNotifications(forDate, s, u.Portfolio, u.Performance)
+		return nil
+	})
+
+	bus.Subscribe(events.TopicPortfolioUpdated, func(e *events.Event) error {
+		s, u, err := updated(e)
+		if err != nil {
+			return err
+		}
+		processAlerts(forDate, alerts[s.ID], s, u.Performance)
+		return nil
+	})
+
+	bus.Subscribe(events.TopicPortfolioUpdated, func(e *events.Event) error {
+		s, u, err := updated(e)
+		if err != nil {
+			return err
+		}
+		for _, userID := range recipients(s) {
+			households[userID] = append(households[userID], portfolio.HouseholdMember{
+				ID:          s.ID.String(),
+				Name:        s.Name,
+				Performance: u.Performance,
+			})
+			householdNotifications[userID] |= s.Notifications
+		}
+		return nil
+	})
+
+	bus.Subscribe(events.TopicHistoryRevised, func(e *events.Event) error {
+		revised, ok := e.Value.(*events.HistoryRevised)
+		if !ok {
+			return errors.New("portfolio.history_revised event has no revision attached")
+		}
+		s, ok := byID[revised.PortfolioID]
+		if !ok {
+			return errors.New("portfolio.history_revised event for unknown portfolio")
+		}
+		processRevision(forDate, s, &revised.Revision)
+		return nil
+	})
+
+	bus.Subscribe(events.TopicNotificationSent, recordNotification)
+	bus.Subscribe(events.TopicNotificationSent, countEmail)
+}
+
+// portfolioUpdated publish portfolio.updated, and signal.changed if the
+// strategy's holdings changed, for a recomputed portfolio
+func portfolioUpdated(forDate time.Time, s *savedStrategy, p *portfolio.Portfolio, perf *portfolio.Performance) {
+	publish(events.TopicPortfolioUpdated, &events.PortfolioUpdated{
+		PortfolioID:        s.ID,
+		UserID:             s.UserID,
+		Through:            forDate,
+		YTDReturn:          perf.YTDReturn,
+		CagrSinceInception: perf.CagrSinceInception,
+		CurrentAsset:       perf.CurrentAsset,
+		Portfolio:          p,
+		Performance:        perf,
+	})
+
+	if previous, current, changed := events.SignalChange(perf); changed {
+		publish(events.TopicSignalChanged, &events.SignalChanged{
+			PortfolioID: s.ID,
+			UserID:      s.UserID,
+			Date:        forDate,
+			Previous:    previous,
+			Current:     current,
+		})
+	}
+}
+
+// publish send an event on the bus, logging failures
+func publish(topic string, payload interface{}) {
+	if err := bus.Publish(topic, payload); err != nil {
+		log.WithFields(log.Fields{
+			logging.FieldFunction: "cmd/notifier/events.go:publish",
+			"Topic":               topic,
+			logging.FieldError:    err,
+		}).Error("Could not publish event")
+	}
+}
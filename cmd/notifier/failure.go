@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"main/logging"
+	"main/reporting"
+	"main/repository"
+	"main/run"
+	"main/util"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Stages of updating a portfolio recorded with its failures
+const (
+	stageCompute     = "compute"
+	stagePerformance = "performance"
+	stageWorker      = "worker"
+	stageUpdate      = "update"
+)
+
+// stageError an error in a stage of updating a portfolio
+type stageError struct {
+	stage string
+	err   error
+}
+
+func (e *stageError) Error() string {
+	return e.err.Error()
+}
+
+func (e *stageError) Unwrap() error {
+	return e.err
+}
+
+// inStage call fn, converting a panic into a util.PanicError, and attribute
+// any error to stage
+func inStage(stage string, fn func() error) error {
+	if err := util.Recover(stage, fn); err != nil {
+		return &stageError{stage: stage, err: err}
+	}
+	return nil
+}
+
+// recordFailure log why a portfolio could not be updated and store it in the
+// portfolio_failure table so the run can continue with the next portfolio
+func recordFailure(forDate time.Time, s *savedStrategy, err error) {
+	failure := &repository.UpdateFailure{
+		PortfolioID: s.ID,
+		Through:     forDate,
+		Stage:       stageCompute,
+		Error:       err.Error(),
+	}
+
+	var se *stageError
+	if errors.As(err, &se) {
+		failure.Stage = se.stage
+	}
+	var pe *util.PanicError
+	if errors.As(err, &pe) {
+		failure.Stack = pe.Stack
+		reporting.Report(err, reporting.Context{
+			UserID:      s.UserID,
+			PortfolioID: s.ID.String(),
+			Strategy:    s.Strategy,
+			Stage:       failure.Stage,
+		})
+	}
+
+	logger := log.WithFields(log.Fields{
+		logging.FieldFunction:  "cmd/notifier/failure.go:recordFailure",
+		logging.FieldPortfolio: s.ID,
+		logging.FieldStrategy:  s.Strategy,
+		"Stage":                failure.Stage,
+		logging.FieldError:     err,
+	})
+	if failure.Stack != "" {
+		logger = logger.WithField("Stack", failure.Stack)
+	}
+	logger.Error("Could not update portfolio")
+
+	if nightly != nil {
+		nightly.Failed(run.Failure{
+			PortfolioID: s.ID,
+			Strategy:    s.Strategy,
+			Stage:       failure.Stage,
+			Error:       failure.Error,
+		})
+	}
+
+	if err := repository.Measurements.RecordFailure(context.Background(), failure); err != nil {
+		logger.WithField("RecordError", err).Error("Could not record portfolio failure")
+	}
+}
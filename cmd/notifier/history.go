@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"main/events"
+	"main/logging"
+	"main/repository"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// notificationSent publish a notification.sent event for an email accepted
+// by SendGrid. portfolioID is nil for notifications that are not about a
+// single portfolio.
+func notificationSent(messageIDs []string, to *User, portfolioID *uuid.UUID, kind string, frequency string) {
+	publish(events.TopicNotificationSent, &events.NotificationSent{
+		MessageIDs:  messageIDs,
+		UserID:      to.ID,
+		Email:       to.Email,
+		PortfolioID: portfolioID,
+		Kind:        kind,
+		Frequency:   frequency,
+	})
+}
+
+// recordNotification add a sent email to the notification history so
+// delivery events received from SendGrid can be matched to it
+func recordNotification(e *events.Event) error {
+	sent := events.NotificationSent{}
+	if err := e.Decode(&sent); err != nil {
+		return err
+	}
+
+	for _, messageID := range sent.MessageIDs {
+		err := repository.Notifications.RecordSent(context.Background(), &repository.SentNotification{
+			MessageID:   messageID,
+			UserID:      sent.UserID,
+			Email:       sent.Email,
+			PortfolioID: sent.PortfolioID,
+			Kind:        sent.Kind,
+			Frequency:   sent.Frequency,
+		})
+		if err != nil {
+			log.WithFields(log.Fields{
+				logging.FieldFunction: "cmd/notifier/history.go:recordNotification",
+				"MessageID":           messageID,
+				logging.FieldUserID:   sent.UserID,
+				logging.FieldError:    err,
+			}).Error("Could not record notification history")
+		}
+	}
+
+	return nil
+}
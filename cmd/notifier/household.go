@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"main/data"
+	"main/logging"
+	"main/notification"
+	"main/portfolio"
+	"main/preferences"
+	"time"
+
+	"github.com/sendgrid/sendgrid-go/helpers/mail"
+	log "github.com/sirupsen/logrus"
+)
+
+// processHouseholdDigest send a digest combining all of a user's portfolios.
+// The combined equity curve is measured monthly so the digest is only sent
+// for monthly and annual notifications, and only to users with more than
+// one portfolio or who prefer the digest to per-portfolio emails.
+func processHouseholdDigest(forDate time.Time, userID string, members []portfolio.HouseholdMember, notifications int) {
+	prefs := getPreferences(userID)
+	if !prefs.Notify(preferences.ChannelEmail) {
+		return
+	}
+	if len(members) == 0 || (len(members) < 2 && prefs.Digest != preferences.DigestHousehold) {
+		return
+	}
+
+	u, err := getUser(userID)
+	if err != nil {
+		return
+	}
+	if suppressed(u) {
+		return
+	}
+
+	manager := data.NewManager(map[string]string{
+		"tiingo": u.TiingoToken,
+	})
+
+	report := portfolio.Household(members)
+	for _, freq := range notificationFrequencies(forDate, entitledNotifications(userID, notifications), &manager) {
+		if freq != "Monthly" && freq != "Annually" {
+			continue
+		}
+
+		log.Infof("Send %s household digest for user %s", freq, userID)
+		message, err := buildHouseholdEmail(forDate, freq, &report, members, u)
+		if err != nil {
+			continue
+		}
+
+		statusCode, messageIDs, err := sendEmail(message)
+		if err != nil {
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			logging.FieldFunction: "cmd/notifier/household.go:processHouseholdDigest",
+			"StatusCode":          statusCode,
+			"MessageID":           messageIDs,
+			logging.FieldUserID:   u.ID,
+			"UserEmail":           u.Email,
+		}).Infof("Sent %s household digest to %s", freq, u.Email)
+		notificationSent(messageIDs, u, nil, notification.KindHousehold, freq)
+	}
+}
+
+func buildHouseholdEmail(forDate time.Time, frequency string, report *portfolio.HouseholdReport, members []portfolio.HouseholdMember, to *User) ([]byte, error) {
+	if !to.Verified {
+		log.WithFields(log.Fields{
+			logging.FieldFunction: "cmd/notifier/household.go:buildHouseholdEmail",
+			logging.FieldUserID:   to.ID,
+		}).Warn("Refusing to send email to unverified email address")
+		return nil, errors.New("Refusing to send email to unverified email address")
+	}
+
+	from := User{
+		Name:  "Penny Vault",
+		Email: "notify@pennyvault.com",
+	}
+
+	m := mail.NewV3Mail()
+
+	e := mail.NewEmail(from.Name, from.Email)
+	m.SetFrom(e)
+
+	person := mail.NewPersonalization()
+	tos := []*mail.Email{
+		mail.NewEmail(to.Name, to.Email),
+	}
+	person.AddTos(tos...)
+
+	loc := getPreferences(to.ID).Localization()
+
+	names := make([]string, len(members))
+	for ii, member := range members {
+		names[ii] = member.Name
+	}
+
+	perf := &report.Performance
+	var totalValue float64
+	if len(perf.Measurements) > 0 {
+		totalValue = perf.Measurements[len(perf.Measurements)-1].Value
+	}
+
+	periodReturn := perf.YTDReturn
+	if frequency == "Monthly" {
+		periodReturn = perf.OneMonthReturn(forDate)
+	}
+
+	holdings := make([]map[string]string, 0, len(report.Holdings))
+	for _, h := range report.Holdings {
+		holdings = append(holdings, map[string]string{
+			"ticker":  h.Ticker,
+			"percent": loc.FormatShare(h.Percent),
+		})
+	}
+
+	person.SetDynamicTemplateData("portfolios", names)
+	person.SetDynamicTemplateData("frequency", loc.T(frequency))
+	person.SetDynamicTemplateData("forDate", loc.FormatDate(forDate))
+	person.SetDynamicTemplateData("totalValue", loc.FormatCurrency(totalValue, "USD"))
+	person.SetDynamicTemplateData("periodReturn", loc.FormatPercent(periodReturn))
+	person.SetDynamicTemplateData("ytdReturn", loc.FormatPercent(perf.YTDReturn))
+	person.SetDynamicTemplateData("overlap", loc.FormatShare(report.OverlapPercent))
+	person.SetDynamicTemplateData("holdings", holdings)
+
+	if err := applyTemplate(m, person, notification.KindHousehold, frequency, loc); err != nil {
+		return nil, err
+	}
+	scheduleDelivery(m, forDate, to)
+
+	m.AddPersonalizations(person)
+	return mail.GetRequestBody(m), nil
+}
@@ -1,14 +1,23 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
 	"main/data"
 	"main/database"
+	"main/events"
+	"main/locale"
+	"main/logging"
+	"main/notification"
 	"main/portfolio"
+	"main/preferences"
+	"main/reporting"
+	"main/repository"
 	"main/strategies"
+	"main/telemetry"
 	"os"
 	"strings"
 	"time"
@@ -25,70 +34,63 @@ import (
 )
 
 const (
-	daily    = 0x00000010
-	weekly   = 0x00000100
-	monthly  = 0x00001000
-	annually = 0x00010000
+	daily    = notification.Daily
+	weekly   = notification.Weekly
+	monthly  = notification.Monthly
+	annually = notification.Annually
 )
 
 type savedStrategy struct {
-	ID            uuid.UUID
-	UserID        string
-	Name          string
-	Strategy      string
-	Arguments     types.JSONText
-	StartDate     int64
-	Notifications int
+	ID              uuid.UUID
+	UserID          string
+	Name            string
+	Strategy        string
+	Arguments       types.JSONText
+	StrategyVersion string
+	StartDate       int64
+	Notifications   int
+
+	// OrgID organization that owns the portfolio; its members are notified
+	// instead of UserID
+	OrgID *uuid.UUID
 }
 
 var disableSend bool = false
 
 func getSavedPortfolios(startDate time.Time) []*savedStrategy {
-	ret := []*savedStrategy{}
-	portfolioSQL := `SELECT id, userid, name, strategy_shortcode, arguments, extract(epoch from start_date)::int as start_date, notifications FROM portfolio WHERE start_date <= $1`
-	rows, err := database.Conn.Query(portfolioSQL, startDate)
+	portfolios, err := repository.Portfolios.ListStartedBy(context.Background(), startDate)
 	if err != nil {
 		log.Fatalf("Database query error in notifier: %s", err)
 	}
 
-	for rows.Next() {
-		p := savedStrategy{}
-		err := rows.Scan(&p.ID, &p.UserID, &p.Name, &p.Strategy, &p.Arguments, &p.StartDate, &p.Notifications)
-		if err != nil {
-			log.Fatalf("Database query error in notifier: %s", err)
+	ret := make([]*savedStrategy, 0, len(portfolios))
+	for _, p := range portfolios {
+		if reg := strategies.Registered(p.Strategy); !reg.Enabled {
+			log.WithFields(log.Fields{
+				logging.FieldPortfolio: p.ID,
+				logging.FieldStrategy:  p.Strategy,
+			}).Warn("Skipping portfolio of disabled strategy")
+			continue
 		}
-		ret = append(ret, &p)
+		ret = append(ret, &savedStrategy{
+			ID:              p.ID,
+			UserID:          p.UserID,
+			Name:            p.Name,
+			Strategy:        p.Strategy,
+			Arguments:       p.Arguments,
+			StrategyVersion: p.StrategyVersion,
+			StartDate:       p.StartDate,
+			Notifications:   p.Notifications,
+			OrgID:           p.OrgID,
+		})
 	}
 
 	return ret
 }
 
-func updateSavedPortfolioPerformanceMetrics(s *savedStrategy, perf *portfolio.Performance) {
-	updateSQL := `UPDATE portfolio SET ytd_return=$1, cagr_since_inception=$2 WHERE id=$3`
-	_, err := database.Conn.Query(updateSQL, perf.YTDReturn, perf.CagrSinceInception, s.ID)
-	if err != nil {
-		log.WithFields(log.Fields{
-			"Portfolio":            s.ID,
-			"YTDReturn":            perf.YTDReturn,
-			"CagrSinceInception":   perf.CagrSinceInception,
-			"PerformanceStartDate": time.Unix(perf.PeriodStart, 0),
-			"PerformanceEndDate":   time.Unix(perf.PeriodEnd, 0),
-			"Error":                err,
-		}).Error("Could not update portfolio performance metrics")
-	}
-
-	log.WithFields(log.Fields{
-		"Portfolio":            s.ID,
-		"YTDReturn":            perf.YTDReturn,
-		"CagrSinceInception":   perf.CagrSinceInception,
-		"PerformanceStartDate": time.Unix(perf.PeriodStart, 0),
-		"PerformanceEndDate":   time.Unix(perf.PeriodEnd, 0),
-	}).Info("Calculated portfolio performance")
-}
-
 func computePortfolioPerformance(p *savedStrategy, through time.Time) (*portfolio.Portfolio, error) {
 	log.WithFields(log.Fields{
-		"Portfolio": p.ID,
+		logging.FieldPortfolio: p.ID,
 	}).Info("Computing portfolio performance")
 
 	u, err := getUser(p.UserID)
@@ -102,8 +104,15 @@ func computePortfolioPerformance(p *savedStrategy, through time.Time) (*portfoli
 	manager.Begin = time.Unix(p.StartDate, 0)
 	manager.End = through
 	manager.Frequency = data.FrequencyMonthly
+	if priceCache != nil {
+		manager.UseCache(priceCache)
+	}
 
 	if strategy, ok := strategies.StrategyMap[p.Strategy]; ok {
+		if err := migrateSavedArguments(p, &strategy); err != nil {
+			return nil, err
+		}
+
 		params := map[string]json.RawMessage{}
 		if err := json.Unmarshal(p.Arguments, &params); err != nil {
 			log.Println(err)
@@ -126,8 +135,8 @@ func computePortfolioPerformance(p *savedStrategy, through time.Time) (*portfoli
 	}
 
 	log.WithFields(log.Fields{
-		"Portfolio": p.ID,
-		"Strategy":  p.Strategy,
+		logging.FieldPortfolio: p.ID,
+		logging.FieldStrategy:  p.Strategy,
 	}).Error("Portfolio strategy not found")
 	return nil, errors.New("Strategy not found")
 }
@@ -164,39 +173,65 @@ func lastTradingDayOfYear(today time.Time, manager *data.Manager) bool {
 	return datesEqual(today, lastDay)
 }
 
-func processNotifications(forDate time.Time, s *savedStrategy, p *portfolio.Portfolio, perf *portfolio.Performance) {
-	u, err := getUser(s.UserID)
-	if err != nil {
-		return
-	}
-
+// notificationFrequencies list the notification frequencies enabled in
+// notifications that are due on forDate
+func notificationFrequencies(forDate time.Time, notifications int, manager *data.Manager) []string {
 	toSend := []string{}
 
-	manager := data.NewManager(map[string]string{
-		"tiingo": u.TiingoToken,
-	})
-	manager.Begin = time.Unix(s.StartDate, 0)
-
-	if (s.Notifications & daily) == daily {
+	if (notifications & daily) == daily {
 		toSend = append(toSend, "Daily")
 	}
-	if (s.Notifications & weekly) == weekly {
+	if (notifications & weekly) == weekly {
 		// only send on Friday
-		if lastTradingDayOfWeek(forDate, &manager) {
+		if lastTradingDayOfWeek(forDate, manager) {
 			toSend = append(toSend, "Weekly")
 		}
 	}
-	if (s.Notifications & monthly) == monthly {
-		if lastTradingDayOfMonth(forDate, &manager) {
+	if (notifications & monthly) == monthly {
+		if lastTradingDayOfMonth(forDate, manager) {
 			toSend = append(toSend, "Monthly")
 		}
 	}
-	if (s.Notifications & annually) == annually {
-		if lastTradingDayOfYear(forDate, &manager) {
+	if (notifications & annually) == annually {
+		if lastTradingDayOfYear(forDate, manager) {
 			toSend = append(toSend, "Annually")
 		}
 	}
 
+	return toSend
+}
+
+// processNotifications email the notifications of s that are due on forDate
+// to each of its recipients
+func processNotifications(forDate time.Time, s *savedStrategy, p *portfolio.Portfolio, perf *portfolio.Performance) {
+	for _, userID := range recipients(s) {
+		notifyRecipient(forDate, userID, s, p, perf)
+	}
+}
+
+func notifyRecipient(forDate time.Time, userID string, s *savedStrategy, p *portfolio.Portfolio, perf *portfolio.Performance) {
+	// users that prefer a household digest do not receive per-portfolio emails
+	prefs := getPreferences(userID)
+	if !prefs.Notify(preferences.ChannelEmail) || prefs.Digest == preferences.DigestHousehold {
+		return
+	}
+
+	u, err := getUser(userID)
+	if err != nil {
+		return
+	}
+
+	manager := data.NewManager(map[string]string{
+		"tiingo": u.TiingoToken,
+	})
+	manager.Begin = time.Unix(s.StartDate, 0)
+
+	if suppressed(u) {
+		return
+	}
+
+	// frequencies enabled under a plan the user no longer has are skipped
+	toSend := notificationFrequencies(forDate, entitledNotifications(userID, s.Notifications), &manager)
 	for _, freq := range toSend {
 		log.Infof("Send %s notification for portfolio %s", freq, s.ID)
 		message, err := buildEmail(forDate, freq, s, p, perf, u)
@@ -210,18 +245,19 @@ func processNotifications(forDate time.Time, s *savedStrategy, p *portfolio.Port
 		}
 
 		log.WithFields(log.Fields{
-			"Function":   "cmd/notifier/main.go:processNotifications",
-			"StatusCode": statusCode,
-			"MessageID":  messageIDs,
-			"Portfolio":  s.ID,
-			"UserId":     u.ID,
-			"UserEmail":  u.Email,
+			logging.FieldFunction:  "cmd/notifier/main.go:notifyRecipient",
+			"StatusCode":           statusCode,
+			"MessageID":            messageIDs,
+			logging.FieldPortfolio: s.ID,
+			logging.FieldUserID:    u.ID,
+			"UserEmail":            u.Email,
 		}).Infof("Sent %s email to %s", freq, u.Email)
+		notificationSent(messageIDs, u, &s.ID, notification.KindPortfolio, freq)
 	}
 }
 
 func periodReturn(forDate time.Time, frequency string, p *portfolio.Portfolio,
-	perf *portfolio.Performance) string {
+	perf *portfolio.Performance, loc *locale.Locale) string {
 	var ret float64
 	switch frequency {
 	case "Daily":
@@ -233,24 +269,7 @@ func periodReturn(forDate time.Time, frequency string, p *portfolio.Portfolio,
 	case "Annually":
 		ret = perf.YTDReturn
 	}
-	return formatReturn(ret)
-}
-
-func formatDate(forDate time.Time) string {
-	dateStr := forDate.Format("2 Jan 2006")
-	dateStr = strings.ToUpper(dateStr)
-	if forDate.Day() < 10 {
-		dateStr = fmt.Sprintf("0%s", dateStr)
-	}
-	return dateStr
-}
-
-func formatReturn(ret float64) string {
-	sign := "+"
-	if ret < 0 {
-		sign = ""
-	}
-	return fmt.Sprintf("%s%.2f%%", sign, ret*100)
+	return loc.FormatPercent(ret)
 }
 
 // Email utilizing dynamic transactional templates
@@ -260,8 +279,8 @@ func buildEmail(forDate time.Time, frequency string, s *savedStrategy,
 	p *portfolio.Portfolio, perf *portfolio.Performance, to *User) ([]byte, error) {
 	if !to.Verified {
 		log.WithFields(log.Fields{
-			"Function": "cmd/notifier/main.go:sendEmail",
-			"UserId":   to.ID,
+			logging.FieldFunction: "cmd/notifier/main.go:sendEmail",
+			logging.FieldUserID:   to.ID,
 		}).Warn("Refusing to send email to unverified email address")
 		return nil, errors.New("Refusing to send email to unverified email address")
 	}
@@ -276,9 +295,6 @@ func buildEmail(forDate time.Time, frequency string, s *savedStrategy,
 	e := mail.NewEmail(from.Name, from.Email)
 	m.SetFrom(e)
 
-	// TODO - figure out best place to encode this -- hardcoded value here is probably not the best
-	m.SetTemplateID("d-69e0989795c24f348959cf399024bd54")
-
 	person := mail.NewPersonalization()
 	tos := []*mail.Email{
 		mail.NewEmail(to.Name, to.Email),
@@ -290,17 +306,60 @@ func buildEmail(forDate time.Time, frequency string, s *savedStrategy,
 		person.SetDynamicTemplateData("strategy", strat.Name)
 	}
 
-	person.SetDynamicTemplateData("frequency", frequency)
-	person.SetDynamicTemplateData("forDate", formatDate(forDate))
+	loc := getPreferences(to.ID).Localization()
+	person.SetDynamicTemplateData("frequency", loc.T(frequency))
+	person.SetDynamicTemplateData("forDate", loc.FormatDate(forDate))
 	person.SetDynamicTemplateData("currentAsset", perf.CurrentAsset)
 
-	person.SetDynamicTemplateData("periodReturn", periodReturn(forDate, frequency, p, perf))
-	person.SetDynamicTemplateData("ytdReturn", formatReturn(perf.YTDReturn))
+	person.SetDynamicTemplateData("periodReturn", periodReturn(forDate, frequency, p, perf, loc))
+	person.SetDynamicTemplateData("ytdReturn", loc.FormatPercent(perf.YTDReturn))
+
+	unsubscribe := notification.Unsubscribe{
+		PortfolioID: s.ID,
+		Frequency:   strings.ToLower(frequency),
+	}
+	if err := setUnsubscribeLinks(m, person, &unsubscribe); err != nil {
+		log.WithFields(log.Fields{
+			logging.FieldFunction: "cmd/notifier/main.go:buildEmail",
+			logging.FieldUserID:   to.ID,
+			logging.FieldError:    err,
+		}).Warn("Sending email without unsubscribe links")
+	}
+
+	if err := applyTemplate(m, person, notification.KindPortfolio, frequency, loc); err != nil {
+		return nil, err
+	}
+	scheduleDelivery(m, forDate, to)
 
 	m.AddPersonalizations(person)
 	return mail.GetRequestBody(m), nil
 }
 
+// setUnsubscribeLinks add signed links to the email that unsubscribe from
+// notifications or manage the portfolio's notification preferences. The links
+// point at UNSUBSCRIBE_URL, e.g. https://api.example.com/v1/unsubscribe
+func setUnsubscribeLinks(m *mail.SGMailV3, person *mail.Personalization, unsubscribe *notification.Unsubscribe) error {
+	base := os.Getenv("UNSUBSCRIBE_URL")
+	if base == "" {
+		return errors.New("UNSUBSCRIBE_URL is not set")
+	}
+	secret := os.Getenv("UNSUBSCRIBE_SECRET")
+
+	unsubscribeURL, err := unsubscribe.URL(base, secret)
+	if err != nil {
+		return err
+	}
+	preferencesURL, err := unsubscribe.URL(base+"/preferences", secret)
+	if err != nil {
+		return err
+	}
+
+	person.SetDynamicTemplateData("unsubscribeUrl", unsubscribeURL)
+	person.SetDynamicTemplateData("preferencesUrl", preferencesURL)
+	m.SetHeader("List-Unsubscribe", fmt.Sprintf("<%s>", unsubscribeURL))
+	return nil
+}
+
 func sendEmail(message []byte) (statusCode int, messageID []string, err error) {
 	// if we are testing then disableSend is set
 	if disableSend {
@@ -338,12 +397,18 @@ func main() {
 	testFlag := flag.Bool("test", false, "test the notifier and don't send notifications")
 	limitFlag := flag.Int("limit", 0, "limit the number of portfolios to process")
 	dateFlag := flag.String("date", "-1", "date to run notifier for")
+	forceFlag := flag.Bool("force", false, "recompute portfolios that were already updated for the date")
+	syncUsersFlag := flag.Bool("sync-users", true, "synchronize users with Auth0 and remove portfolios of deleted users")
+	workersFlag := flag.Bool("workers", false, "compute portfolios with cmd/worker instead of in the notifier")
+	prefetchFlag := flag.Bool("prefetch", true, "download prices of all tickers referenced by saved portfolios once before computing them")
+	workerTimeoutFlag := flag.Duration("worker-timeout", 2*time.Hour, "how long to wait for workers to compute all portfolios")
 	flag.Parse()
 
 	var forDate time.Time
 	if *dateFlag == "-1" {
-		tz, _ := time.LoadLocation("America/New_York")
-		forDate = time.Now().In(tz).AddDate(0, 0, -1)
+		// the most recent trading day that has closed
+		tz, _ := time.LoadLocation(notification.MarketTimezone)
+		forDate = notification.Yesterday(time.Now(), tz)
 	} else {
 		var err error
 		forDate, err = time.Parse("2006-01-02", *dateFlag)
@@ -370,28 +435,161 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	repository.Initialize(database.Conn)
+
+	// warnings and errors are stored for support when LOG_DATABASE_LEVEL is set
+	logSink, err := logging.PersistFromEnv(repository.Logs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if logSink != nil {
+		defer logSink.Close()
+	}
+
+	// panics are sent to Sentry when SENTRY_DSN is set
+	if err := reporting.Configure(); err != nil {
+		log.Fatal(err)
+	}
+	defer reporting.Flush(10 * time.Second)
 
+	// data provider and database telemetry is exported when
+	// OTEL_EXPORTER_OTLP_ENDPOINT is set
+	exporter, err := telemetry.Configure("pvapi-notifier")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if exporter != nil {
+		defer exporter.Shutdown()
+	}
+
+	if err := data.ConfigureHTTP(data.HTTPConfigFromEnv()); err != nil {
+		log.Fatal(err)
+	}
 	data.InitializeDataManager()
 	log.Info("Initialized data framework")
 
+	// usage of other processes is read so updates can be deferred when a
+	// user's quota is nearly exhausted
+	usageCtx, stopUsageSync := context.WithCancel(context.Background())
+	go data.Usage.SyncEvery(usageCtx, repository.Usage, time.Minute)
+
 	strategies.IntializeStrategyMap()
 	log.Info("Initialized strategy map")
 
+	// strategies deployed as Go plugins
+	if err := strategies.LoadPlugins(os.Getenv("STRATEGY_PLUGIN_DIR")); err != nil {
+		log.Fatal(err)
+	}
+
+	// portfolios of disabled strategies are not updated
+	if err := strategies.LoadRegistrations(context.Background(), repository.Strategies); err != nil {
+		log.Fatal(err)
+	}
+
+	// users are read from the local store rather than requested from Auth0
+	if *syncUsersFlag {
+		syncUsers()
+	}
+
+	// deleted portfolios can be restored until they are purged
+	purgeTrash()
+
+	// addresses that bounced or reported spam are not sent email
+	syncSuppressions()
+	loadSuppressions()
+
+	// templates configured in the database, otherwise built-in templates are used
+	loadTemplates()
+
+	// get a list of all alerts
+	alerts := getAlerts()
+
 	// get a list of all portfolios
 	savedPortfolios := getSavedPortfolios(forDate)
 	log.WithFields(log.Fields{
 		"NumPortfolios": len(savedPortfolios),
 	}).Info("Got saved portfolios")
+
+	// notifications, alerts and the household digest are sent by subscribers
+	// to portfolio.updated
+	setupEventBus(forDate, savedPortfolios, alerts)
+
+	// portfolios computed by workers download their own prices
+	if *prefetchFlag && !*workersFlag {
+		priceCache = prefetchPrices(savedPortfolios, forDate)
+	}
+
+	started := time.Now()
+	startRunReport(forDate, started, len(savedPortfolios))
+
+	compute := computeFunc(computeLocally)
+	if *workersFlag {
+		compute = enqueuePortfolios(savedPortfolios, forDate, *workerTimeoutFlag)
+	}
+
+	succeeded, failed, deferred := 0, 0, 0
 	for ii, s := range savedPortfolios {
-		p, err := computePortfolioPerformance(s, forDate)
+		p, perf, err := compute(s, forDate)
+		if errors.Is(err, errQuotaDeferred) {
+			deferred++
+			nightly.Deferred()
+			continue
+		}
+		if err != nil {
+			recordFailure(forDate, s, err)
+			failed++
+			continue
+		}
+		var updated bool
+		err = inStage(stageUpdate, func() (err error) {
+			updated, err = updateSavedPortfolio(s, perf, p.Signals, forDate, *forceFlag)
+			return err
+		})
 		if err != nil {
+			recordFailure(forDate, s, err)
+			failed++
 			continue
 		}
-		perf, err := p.CalculatePerformance(forDate)
-		updateSavedPortfolioPerformanceMetrics(s, &perf)
-		processNotifications(forDate, s, p, &perf)
+		if !updated {
+			continue
+		}
+		succeeded++
+		nightly.Succeeded()
+		portfolioUpdated(forDate, s, p, perf)
+
 		if *limitFlag != 0 && *limitFlag >= ii {
 			break
 		}
 	}
+
+	// alerts that are not attached to a portfolio
+	processAlerts(forDate, alerts[uuid.Nil], nil, nil)
+
+	for userID, members := range households {
+		processHouseholdDigest(forDate, userID, members, householdNotifications[userID])
+	}
+
+	if deferred > 0 {
+		log.WithFields(log.Fields{
+			"NumDeferred": deferred,
+		}).Warn("Deferred portfolio updates to the next run")
+	}
+
+	stopUsageSync()
+	if err := data.Usage.Sync(context.Background(), repository.Usage); err != nil {
+		log.WithFields(log.Fields{
+			logging.FieldError: err,
+		}).Warn("Could not store provider usage")
+	}
+
+	completeRunReport()
+
+	publish(events.TopicJobCompleted, &events.JobCompleted{
+		Job:       "notifier",
+		ForDate:   forDate,
+		Started:   started,
+		Completed: time.Now(),
+		Succeeded: succeeded,
+		Failed:    failed,
+	})
 }
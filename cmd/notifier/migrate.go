@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"main/logging"
+	"main/repository"
+	"main/strategies"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// migrateSavedArguments upgrade the arguments of a portfolio saved for an
+// earlier version of its strategy and record the migration so users can see
+// which version their portfolio runs.
+func migrateSavedArguments(s *savedStrategy, strategy *strategies.StrategyInfo) error {
+	if s.StrategyVersion == strategy.Version {
+		return nil
+	}
+
+	logger := log.WithFields(log.Fields{
+		logging.FieldFunction:  "cmd/notifier/migrate.go:migrateSavedArguments",
+		logging.FieldPortfolio: s.ID,
+		logging.FieldStrategy:  s.Strategy,
+		"FromVersion":          s.StrategyVersion,
+		"ToVersion":            strategy.Version,
+	})
+
+	params := map[string]json.RawMessage{}
+	if err := json.Unmarshal(s.Arguments, &params); err != nil {
+		logger.WithField(logging.FieldError, err).Error("Could not parse saved arguments")
+		return err
+	}
+
+	migrated, _, err := strategy.MigrateArguments(s.StrategyVersion, params)
+	if err != nil {
+		logger.WithField(logging.FieldError, err).Error("Could not migrate saved arguments")
+		return err
+	}
+
+	newArguments, err := json.Marshal(migrated)
+	if err != nil {
+		return err
+	}
+
+	m := repository.StrategyMigration{
+		PortfolioID:  s.ID,
+		Strategy:     s.Strategy,
+		FromVersion:  s.StrategyVersion,
+		ToVersion:    strategy.Version,
+		OldArguments: s.Arguments,
+		NewArguments: newArguments,
+	}
+	err = repository.Transaction(context.Background(), func(r *repository.Repositories) error {
+		return r.Portfolios.Migrate(context.Background(), &m)
+	})
+	if err != nil {
+		logger.WithField(logging.FieldError, err).Error("Could not migrate saved arguments")
+		return err
+	}
+
+	logger.Info("Migrated saved arguments")
+	s.Arguments = newArguments
+	s.StrategyVersion = strategy.Version
+	return nil
+}
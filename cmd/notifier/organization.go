@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"main/logging"
+	"main/organization"
+	"main/repository"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+var membersMap map[uuid.UUID][]*organization.Member = make(map[uuid.UUID][]*organization.Member)
+
+// recipients the ids of the users notified about s: the members of the
+// organization that owns it that want notifications, or the user that
+// created it. If the members cannot be read the creator is notified.
+func recipients(s *savedStrategy) []string {
+	if s.OrgID == nil {
+		return []string{s.UserID}
+	}
+
+	members, ok := membersMap[*s.OrgID]
+	if !ok {
+		var err error
+		members, err = repository.Organizations.Members(context.Background(), *s.OrgID)
+		if err != nil {
+			log.WithFields(log.Fields{
+				logging.FieldFunction:  "cmd/notifier/organization.go:recipients",
+				logging.FieldPortfolio: s.ID,
+				"Organization":         *s.OrgID,
+				logging.FieldError:     err,
+			}).Warn("Could not load organization members, notifying the portfolio's creator")
+			return []string{s.UserID}
+		}
+		membersMap[*s.OrgID] = members
+	}
+
+	return organization.Recipients(members)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"main/logging"
+	"main/preferences"
+	"main/repository"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var preferencesMap map[string]preferences.Preferences = make(map[string]preferences.Preferences)
+
+// getPreferences retrieve the notification preferences of userID. Users
+// that have not saved preferences, or whose preferences cannot be read,
+// receive the defaults.
+func getPreferences(userID string) *preferences.Preferences {
+	if prefs, ok := preferencesMap[userID]; ok {
+		return &prefs
+	}
+
+	prefs, err := repository.Users.Preferences(context.Background(), userID)
+	if err != nil {
+		log.WithFields(log.Fields{
+			logging.FieldFunction: "cmd/notifier/preferences.go:getPreferences",
+			logging.FieldUserID:   userID,
+			logging.FieldError:    err,
+		}).Warn("Could not load user preferences, using defaults")
+		defaults := preferences.Default()
+		defaults.UserID = userID
+		prefs = &defaults
+	}
+
+	preferencesMap[userID] = *prefs
+	return prefs
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"main/data"
+	"main/logging"
+	"main/strategies"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// prefetchLookbackYears history downloaded before the earliest portfolio
+// start date; covers the lookback periods of the strategies
+const prefetchLookbackYears = 2
+
+// priceCache end of day data shared by the portfolios computed by this run;
+// nil when prices are not prefetched
+var priceCache *data.PriceCache
+
+// prefetchPrices download the end of day data of every ticker referenced by
+// the arguments of the saved portfolios once, rather than once per portfolio.
+// Each ticker is downloaded with the Tiingo token of the first user whose
+// portfolio references it. Data that isn't prefetched, such as the risk free
+// rate, is added to the cache the first time a portfolio requests it.
+func prefetchPrices(savedPortfolios []*savedStrategy, forDate time.Time) *data.PriceCache {
+	if len(savedPortfolios) == 0 {
+		return nil
+	}
+
+	begin := time.Unix(savedPortfolios[0].StartDate, 0)
+	seen := map[string]bool{}
+	tickersByUser := map[string][]string{}
+	for _, s := range savedPortfolios {
+		if start := time.Unix(s.StartDate, 0); start.Before(begin) {
+			begin = start
+		}
+
+		tickers, err := savedTickers(s)
+		if err != nil {
+			log.WithFields(log.Fields{
+				logging.FieldFunction:  "cmd/notifier/prefetch.go:prefetchPrices",
+				logging.FieldPortfolio: s.ID,
+				logging.FieldError:     err,
+			}).Warn("Could not read tickers of saved portfolio")
+			continue
+		}
+
+		for _, ticker := range tickers {
+			ticker = strings.ToUpper(ticker)
+			if ticker == data.CashSymbol || seen[ticker] {
+				continue
+			}
+			seen[ticker] = true
+			tickersByUser[s.UserID] = append(tickersByUser[s.UserID], ticker)
+		}
+	}
+
+	cache := data.NewPriceCache(begin.AddDate(-prefetchLookbackYears, 0, 0), forDate)
+
+	userIDs := make([]string, 0, len(tickersByUser))
+	for userID := range tickersByUser {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Strings(userIDs)
+
+	started := time.Now()
+	for _, userID := range userIDs {
+		u, err := getUser(userID)
+		if err != nil {
+			continue
+		}
+		if data.Usage.NearQuota("tiingo", u.TiingoToken) {
+			log.WithFields(log.Fields{
+				logging.FieldFunction: "cmd/notifier/prefetch.go:prefetchPrices",
+				logging.FieldUserID:   userID,
+			}).Warn("Skipped prefetch; Tiingo quota nearly exhausted")
+			continue
+		}
+
+		manager := data.NewManager(map[string]string{
+			"tiingo": u.TiingoToken,
+		})
+		manager.UseCache(cache)
+
+		// strategies signal monthly and performance is measured daily
+		for _, frequency := range []string{data.FrequencyMonthly, data.FrequencyDaily} {
+			manager.Frequency = frequency
+			for _, err := range manager.Prefetch(tickersByUser[userID]...) {
+				log.WithFields(log.Fields{
+					logging.FieldFunction: "cmd/notifier/prefetch.go:prefetchPrices",
+					logging.FieldUserID:   userID,
+					"Frequency":           frequency,
+					logging.FieldError:    err,
+				}).Warn("Could not prefetch prices")
+			}
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"NumTickers": len(seen),
+		"Begin":      cache.Begin.Format("2006-01-02"),
+		"End":        cache.End.Format("2006-01-02"),
+		"Duration":   time.Since(started),
+	}).Info("Prefetched prices of saved portfolios")
+
+	return cache
+}
+
+// savedTickers tickers named by the arguments of a saved portfolio; saved
+// arguments of an older strategy version are migrated in memory
+func savedTickers(s *savedStrategy) ([]string, error) {
+	strategy, ok := strategies.StrategyMap[s.Strategy]
+	if !ok {
+		return nil, nil
+	}
+
+	params := map[string]json.RawMessage{}
+	if err := json.Unmarshal(s.Arguments, &params); err != nil {
+		return nil, err
+	}
+
+	if s.StrategyVersion != strategy.Version {
+		migrated, _, err := strategy.MigrateArguments(s.StrategyVersion, params)
+		if err != nil {
+			return nil, err
+		}
+		params = migrated
+	}
+
+	return strategy.Tickers(params)
+}
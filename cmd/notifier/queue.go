@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"main/data"
+	"main/logging"
+	"main/portfolio"
+	"main/queue"
+	"main/repository"
+	"main/strategies"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// workerPollInterval how often the status of enqueued jobs is checked
+const workerPollInterval = 5 * time.Second
+
+// computeFunc compute a saved portfolio and its performance through forDate
+type computeFunc func(s *savedStrategy, forDate time.Time) (*portfolio.Portfolio, *portfolio.Performance, error)
+
+// computeLocally compute portfolios in the notifier process. A computation
+// that panics returns an error so the remaining portfolios are processed.
+func computeLocally(s *savedStrategy, forDate time.Time) (*portfolio.Portfolio, *portfolio.Performance, error) {
+	if quotaDeferred(s) {
+		return nil, nil, errQuotaDeferred
+	}
+
+	var p *portfolio.Portfolio
+	err := inStage(stageCompute, func() (err error) {
+		p, err = computePortfolioPerformance(s, forDate)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var perf portfolio.Performance
+	err = inStage(stagePerformance, func() (err error) {
+		perf, err = p.CalculatePerformance(forDate)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, &perf, nil
+}
+
+// enqueuePortfolios add a portfolio.compute job for each portfolio to the work
+// queue and return a computeFunc that waits for the job of a portfolio to
+// finish. Saved arguments are migrated before the jobs are enqueued.
+func enqueuePortfolios(savedPortfolios []*savedStrategy, forDate time.Time, timeout time.Duration) computeFunc {
+	q := repository.Jobs
+	jobs := make(map[uuid.UUID]uuid.UUID, len(savedPortfolios))
+	deferred := map[uuid.UUID]bool{}
+
+	for _, s := range savedPortfolios {
+		logger := log.WithFields(log.Fields{
+			logging.FieldFunction:  "cmd/notifier/queue.go:enqueuePortfolios",
+			logging.FieldPortfolio: s.ID,
+		})
+
+		if quotaDeferred(s) {
+			deferred[s.ID] = true
+			continue
+		}
+
+		if strategy, ok := strategies.StrategyMap[s.Strategy]; ok {
+			if err := migrateSavedArguments(s, &strategy); err != nil {
+				continue
+			}
+		}
+
+		job, err := q.Enqueue(queue.KindComputePortfolio, "", &queue.ComputePortfolio{
+			PortfolioID: s.ID,
+			UserID:      s.UserID,
+			Through:     forDate,
+		})
+		if err != nil {
+			logger.WithField(logging.FieldError, err).Error("Could not enqueue portfolio")
+			continue
+		}
+		jobs[s.ID] = job.ID
+	}
+
+	log.WithFields(log.Fields{
+		"NumJobs": len(jobs),
+	}).Info("Enqueued portfolios for workers")
+
+	deadline := time.Now().Add(timeout)
+	return func(s *savedStrategy, forDate time.Time) (*portfolio.Portfolio, *portfolio.Performance, error) {
+		if deferred[s.ID] {
+			return nil, nil, errQuotaDeferred
+		}
+		jobID, ok := jobs[s.ID]
+		if !ok {
+			return nil, nil, errors.New("portfolio was not enqueued")
+		}
+
+		job, err := waitForJob(q, jobID, deadline)
+		if err != nil {
+			log.WithFields(log.Fields{
+				logging.FieldPortfolio: s.ID,
+				"JobID":                jobID,
+				logging.FieldError:     err,
+			}).Error("Worker could not compute portfolio")
+			return nil, nil, &stageError{stage: stageWorker, err: err}
+		}
+
+		computed := queue.ComputedPortfolio{}
+		if err := json.Unmarshal(job.Result, &computed); err != nil {
+			return nil, nil, err
+		}
+
+		// the portfolio is rebuilt from its transactions and signals so
+		// notifications can value it on any date
+		u, err := getUser(s.UserID)
+		if err != nil {
+			return nil, nil, err
+		}
+		manager := data.NewManager(map[string]string{
+			"tiingo": u.TiingoToken,
+		})
+		p, err := portfolio.NewPortfolioFromTransactions(s.Name, &manager, computed.Transactions)
+		if err != nil {
+			return nil, nil, err
+		}
+		p.Signals = computed.Signals
+		return &p, &computed.Performance, nil
+	}
+}
+
+// waitForJob poll the queue until the job succeeds, fails, or the deadline
+// passes
+func waitForJob(q queue.Queue, id uuid.UUID, deadline time.Time) (*queue.Job, error) {
+	for {
+		job, err := q.Get(id, "")
+		if err != nil {
+			return nil, err
+		}
+
+		switch job.Status {
+		case queue.StatusSucceeded:
+			return job, nil
+		case queue.StatusFailed:
+			return nil, fmt.Errorf("job failed: %s", job.Error)
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("job %s did not finish before the deadline", job.Status)
+		}
+		time.Sleep(workerPollInterval)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"main/data"
+	"main/events"
+	"main/logging"
+	"main/repository"
+	"main/run"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// nightly report of this run of the notifier
+var nightly *run.Recorder
+
+// startRunReport store a report of the run as running, so a run that crashes
+// is visible on the admin dashboard
+func startRunReport(forDate time.Time, started time.Time, numPortfolios int) {
+	nightly = run.NewRecorder("notifier", forDate, started, numPortfolios)
+	saveRunReport(nightly.Report())
+}
+
+// completeRunReport store the final report of the run
+func completeRunReport() {
+	report := nightly.Complete(time.Now(), data.Usage.Made())
+	saveRunReport(report)
+
+	log.WithFields(log.Fields{
+		"Run":        report.ID,
+		"Succeeded":  report.Succeeded,
+		"Failed":     report.Failed,
+		"Deferred":   report.Deferred,
+		"EmailsSent": report.EmailsSent,
+		"Duration":   report.Duration,
+	}).Info("Notifier run completed")
+}
+
+// countEmail count an email sent during the run
+func countEmail(e *events.Event) error {
+	if nightly != nil {
+		nightly.EmailsSent(1)
+	}
+	return nil
+}
+
+func saveRunReport(report *run.Report) {
+	if err := repository.Runs.SaveRun(context.Background(), report); err != nil {
+		log.WithFields(log.Fields{
+			logging.FieldFunction: "cmd/notifier/report.go:saveRunReport",
+			"Run":                 report.ID,
+			logging.FieldError:    err,
+		}).Error("Could not save run report")
+	}
+}
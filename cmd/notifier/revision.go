@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"main/locale"
+	"main/logging"
+	"main/notification"
+	"main/portfolio"
+	"main/preferences"
+	"time"
+
+	"github.com/sendgrid/sendgrid-go/helpers/mail"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxRevisionRows changes listed in a history revision email; the remainder
+// are counted
+const maxRevisionRows = 25
+
+// processRevision tell the recipients of a portfolio's notifications that
+// recomputing it changed its stored history. Sent whenever history changes,
+// independent of the portfolio's notification frequencies.
+func processRevision(forDate time.Time, s *savedStrategy, revision *portfolio.HistoryRevision) {
+	for _, userID := range recipients(s) {
+		notifyRevision(forDate, userID, s, revision)
+	}
+}
+
+func notifyRevision(forDate time.Time, userID string, s *savedStrategy, revision *portfolio.HistoryRevision) {
+	prefs := getPreferences(userID)
+	if !prefs.Notify(preferences.ChannelEmail) {
+		return
+	}
+
+	u, err := getUser(userID)
+	if err != nil {
+		return
+	}
+	if suppressed(u) {
+		return
+	}
+
+	message, err := buildRevisionEmail(forDate, s, revision, u)
+	if err != nil {
+		return
+	}
+
+	statusCode, messageIDs, err := sendEmail(message)
+	if err != nil {
+		return
+	}
+
+	log.WithFields(log.Fields{
+		logging.FieldFunction:  "cmd/notifier/revision.go:notifyRevision",
+		"StatusCode":           statusCode,
+		"MessageID":            messageIDs,
+		logging.FieldPortfolio: s.ID,
+		logging.FieldUserID:    u.ID,
+		"UserEmail":            u.Email,
+	}).Infof("Sent history revision to %s", u.Email)
+	notificationSent(messageIDs, u, &s.ID, notification.KindRevision, "")
+}
+
+func buildRevisionEmail(forDate time.Time, s *savedStrategy, revision *portfolio.HistoryRevision, to *User) ([]byte, error) {
+	if !to.Verified {
+		log.WithFields(log.Fields{
+			logging.FieldFunction: "cmd/notifier/revision.go:buildRevisionEmail",
+			logging.FieldUserID:   to.ID,
+		}).Warn("Refusing to send email to unverified email address")
+		return nil, errors.New("Refusing to send email to unverified email address")
+	}
+
+	from := User{
+		Name:  "Penny Vault",
+		Email: "notify@pennyvault.com",
+	}
+
+	m := mail.NewV3Mail()
+
+	e := mail.NewEmail(from.Name, from.Email)
+	m.SetFrom(e)
+
+	person := mail.NewPersonalization()
+	tos := []*mail.Email{
+		mail.NewEmail(to.Name, to.Email),
+	}
+	person.AddTos(tos...)
+
+	loc := getPreferences(to.ID).Localization()
+
+	changes := revision.Changes
+	if len(changes) > maxRevisionRows {
+		changes = changes[:maxRevisionRows]
+	}
+	rows := make([]map[string]string, len(changes))
+	for ii, change := range changes {
+		rows[ii] = map[string]string{
+			"date":     loc.FormatDate(change.Date),
+			"ticker":   change.Ticker,
+			"kind":     change.Kind,
+			"oldValue": revisionValue(change.OldTotalValue, change.Change != portfolio.HistoryAdded, loc),
+			"newValue": revisionValue(change.NewTotalValue, change.Change != portfolio.HistoryRemoved, loc),
+		}
+	}
+
+	person.SetDynamicTemplateData("portfolioName", s.Name)
+	person.SetDynamicTemplateData("forDate", loc.FormatDate(forDate))
+	person.SetDynamicTemplateData("numChanges", len(revision.Changes))
+	person.SetDynamicTemplateData("numDates", len(revision.Dates))
+	person.SetDynamicTemplateData("changes", rows)
+	person.SetDynamicTemplateData("moreChanges", len(revision.Changes)-len(changes))
+
+	if err := applyTemplate(m, person, notification.KindRevision, "", loc); err != nil {
+		return nil, err
+	}
+	scheduleDelivery(m, forDate, to)
+
+	m.AddPersonalizations(person)
+	return mail.GetRequestBody(m), nil
+}
+
+// revisionValue format a transaction value for the revision email; values
+// of transactions that don't exist on one side are shown as a dash
+func revisionValue(value float64, exists bool, loc *locale.Locale) string {
+	if !exists {
+		return "-"
+	}
+	return loc.FormatCurrency(value, "USD")
+}
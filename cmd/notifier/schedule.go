@@ -0,0 +1,44 @@
+package main
+
+import (
+	"main/logging"
+	"main/notification"
+	"time"
+
+	"github.com/sendgrid/sendgrid-go/helpers/mail"
+	log "github.com/sirupsen/logrus"
+)
+
+// userTimezone the timezone of userID, or the market timezone if the user's
+// timezone is not known
+func userTimezone(userID string) *time.Location {
+	prefs := getPreferences(userID)
+	tz, err := time.LoadLocation(prefs.Timezone)
+	if err != nil {
+		log.WithFields(log.Fields{
+			logging.FieldFunction: "cmd/notifier/schedule.go:userTimezone",
+			logging.FieldUserID:   userID,
+			"Timezone":            prefs.Timezone,
+			logging.FieldError:    err,
+		}).Warn("Unknown timezone, using market timezone")
+		tz, _ = time.LoadLocation(notification.MarketTimezone)
+	}
+	return tz
+}
+
+// scheduleDelivery have SendGrid deliver a notification about forDate at the
+// start of the user's local delivery window rather than when the notifier
+// runs, so users outside the US are not emailed overnight
+func scheduleDelivery(m *mail.SGMailV3, forDate time.Time, to *User) {
+	now := time.Now()
+	tz := userTimezone(to.ID)
+	deliver := notification.DeliveryTime(forDate, now, tz)
+	if deliver.After(now) {
+		log.WithFields(log.Fields{
+			logging.FieldUserID: to.ID,
+			"Timezone":          tz.String(),
+			"SendAt":            deliver,
+		}).Info("Scheduling email for user's delivery window")
+		m.SetSendAt(int(deliver.Unix()))
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"main/logging"
+	"main/repository"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sendgrid/sendgrid-go"
+	log "github.com/sirupsen/logrus"
+)
+
+// sendgridSuppression entry of a SendGrid bounce or spam report list
+type sendgridSuppression struct {
+	Email   string `json:"email"`
+	Reason  string `json:"reason"`
+	Created int64  `json:"created"`
+}
+
+// suppressionLists SendGrid suppression lists and the reason recorded for
+// addresses on them
+var suppressionLists = map[string]string{
+	"/v3/suppression/bounces":      "bounce",
+	"/v3/suppression/spam_reports": "spam_report",
+}
+
+var suppressedEmails map[string]bool = make(map[string]bool)
+
+// syncSuppressions copy the addresses on SendGrid's bounce and spam report
+// lists to the email_suppression table
+func syncSuppressions() {
+	apiKey := os.Getenv("SENDGRID_API_KEY")
+	if apiKey == "" {
+		log.Warn("SENDGRID_API_KEY is not set, skipping suppression list sync")
+		return
+	}
+
+	for endpoint, reason := range suppressionLists {
+		request := sendgrid.GetRequest(apiKey, endpoint, "https://api.sendgrid.com")
+		request.Method = "GET"
+
+		response, err := sendgrid.API(request)
+		if err != nil || response.StatusCode >= 400 {
+			log.WithFields(log.Fields{
+				logging.FieldFunction: "cmd/notifier/suppression.go:syncSuppressions",
+				"Endpoint":            endpoint,
+				logging.FieldError:    err,
+			}).Error("Could not download SendGrid suppression list")
+			continue
+		}
+
+		entries := []sendgridSuppression{}
+		if err := json.Unmarshal([]byte(response.Body), &entries); err != nil {
+			log.WithFields(log.Fields{
+				logging.FieldFunction: "cmd/notifier/suppression.go:syncSuppressions",
+				"Endpoint":            endpoint,
+				logging.FieldError:    err,
+			}).Error("Could not parse SendGrid suppression list")
+			continue
+		}
+
+		for _, entry := range entries {
+			suppressEmail(entry.Email, reason, entry.Reason, time.Unix(entry.Created, 0))
+		}
+	}
+}
+
+// suppressEmail record that email must not receive further messages
+func suppressEmail(email string, reason string, detail string, created time.Time) {
+	err := repository.Users.SuppressEmail(context.Background(), email, reason, detail, created)
+	if err != nil {
+		log.WithFields(log.Fields{
+			logging.FieldFunction: "cmd/notifier/suppression.go:suppressEmail",
+			"Email":               email,
+			logging.FieldError:    err,
+		}).Error("Could not record email suppression")
+	}
+}
+
+// loadSuppressions read the suppressed addresses from the database
+func loadSuppressions() {
+	emails, err := repository.Users.SuppressedEmails(context.Background())
+	if err != nil {
+		log.Fatalf("Database query error in notifier: %s", err)
+	}
+
+	for _, email := range emails {
+		suppressedEmails[email] = true
+	}
+}
+
+// suppressed true if email bounced or reported a message as spam
+func suppressed(to *User) bool {
+	if suppressedEmails[strings.ToLower(to.Email)] {
+		log.WithFields(log.Fields{
+			logging.FieldUserID: to.ID,
+			"UserEmail":         to.Email,
+		}).Warn("Not sending email to suppressed address")
+		return true
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"main/locale"
+	"main/logging"
+	"main/notification"
+	"main/preferences"
+	"main/repository"
+
+	"github.com/sendgrid/sendgrid-go/helpers/mail"
+	log "github.com/sirupsen/logrus"
+)
+
+var notificationTemplates []notification.Template
+
+// loadTemplates read the notification templates configured in the database
+func loadTemplates() {
+	templates, err := repository.Notifications.Templates(context.Background())
+	if err != nil {
+		log.Fatalf("Database query error in notifier: %s", err)
+	}
+	notificationTemplates = templates
+}
+
+// applyTemplate render an email with the template configured for kind,
+// frequency, and the user's locale. SendGrid templates are rendered by SendGrid from the
+// personalization's dynamic template data; inline templates are rendered
+// locally into the subject and HTML content of the message.
+func applyTemplate(m *mail.SGMailV3, person *mail.Personalization, kind string, frequency string, loc *locale.Locale) error {
+	t := notification.SelectTemplate(notificationTemplates, kind, preferences.ChannelEmail, frequency, loc.Tag)
+	if t.SendGridTemplateID != "" {
+		m.SetTemplateID(t.SendGridTemplateID)
+		return nil
+	}
+
+	subject, body, err := t.Render(person.DynamicTemplateData, loc)
+	if err != nil {
+		log.WithFields(log.Fields{
+			logging.FieldFunction: "cmd/notifier/template.go:applyTemplate",
+			"Kind":                kind,
+			"Locale":              loc.Tag,
+			logging.FieldError:    err,
+		}).Error("Could not render notification template")
+		return err
+	}
+
+	m.Subject = subject
+	m.AddContent(mail.NewContent("text/html", body))
+	person.DynamicTemplateData = nil
+	return nil
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"main/logging"
+	"main/repository"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// purgeTrash permanently remove portfolios that have been in the trash longer
+// than repository.TrashRetention
+func purgeTrash() {
+	cutoff := time.Now().UTC().Add(-repository.TrashRetention)
+	purged, err := repository.Portfolios.Purge(context.Background(), cutoff)
+	if err != nil {
+		log.WithFields(log.Fields{
+			logging.FieldFunction: "cmd/notifier/trash.go:purgeTrash",
+			logging.FieldError:    err,
+		}).Error("Could not purge deleted portfolios")
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"Purged": purged,
+		"Cutoff": cutoff,
+	}).Info("Purged deleted portfolios")
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"main/events"
+	"main/logging"
+	"main/portfolio"
+	"main/repository"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const updateCompleted = repository.UpdateCompleted
+
+// errAlreadyUpdated rolls back the transaction of an update that completed
+// in a prior run
+var errAlreadyUpdated = errors.New("portfolio already updated through date")
+
+// updateSavedPortfolio persist the performance metrics, transactions, and
+// strategy signals of a portfolio inside a single database transaction. Each update is recorded in
+// the portfolio_update ledger keyed by (portfolio, throughDate); if the same
+// update already completed it is skipped unless force is set. Changes to the
+// stored history are saved as a revision and published so the user is told
+// about them. Returns true if the update was applied and notifications
+// should be sent.
+func updateSavedPortfolio(s *savedStrategy, perf *portfolio.Performance, signals []portfolio.Signal, through time.Time, force bool) (bool, error) {
+	ctx := context.Background()
+	logger := log.WithFields(log.Fields{
+		logging.FieldFunction:  "cmd/notifier/update.go:updateSavedPortfolio",
+		logging.FieldPortfolio: s.ID,
+		"ThroughDate":          through.Format("2006-01-02"),
+	})
+
+	numTransactions, numSignals := 0, 0
+	var revision portfolio.HistoryRevision
+	err := repository.Transaction(ctx, func(r *repository.Repositories) error {
+		// lock the ledger row so concurrent runs of the notifier wait on each other
+		status, err := r.Measurements.LockUpdate(ctx, s.ID, through)
+		if err != nil {
+			logger.WithField(logging.FieldError, err).Error("Could not lock portfolio update ledger entry")
+			return err
+		}
+		if status == updateCompleted && !force {
+			return errAlreadyUpdated
+		}
+
+		metrics, err := updateMetricsState(ctx, r.Measurements, s, perf, force)
+		if err != nil {
+			logger.WithField(logging.FieldError, err).Error("Could not update portfolio metrics state")
+			return err
+		}
+
+		if err := r.Measurements.SaveMetrics(ctx, s.ID, perf, metrics); err != nil {
+			logger.WithField(logging.FieldError, err).Error("Could not update portfolio performance metrics")
+			return err
+		}
+
+		// compare with the stored history before it is replaced
+		stored, err := r.Measurements.ListTransactions(ctx, s.ID)
+		if err != nil {
+			logger.WithField(logging.FieldError, err).Error("Could not load stored portfolio transactions")
+			return err
+		}
+		revision = portfolio.DiffHistory(stored, perf.Transactions)
+		if !revision.Empty() {
+			if err := r.Measurements.SaveRevision(ctx, s.ID, through, &revision); err != nil {
+				logger.WithField(logging.FieldError, err).Error("Could not save portfolio history revision")
+				return err
+			}
+		}
+
+		numTransactions, err = r.Measurements.SaveTransactions(ctx, s.ID, perf.Transactions, through)
+		if err != nil {
+			logger.WithField(logging.FieldError, err).Error("Could not save portfolio transactions")
+			return err
+		}
+
+		numSignals, err = r.Measurements.SaveSignals(ctx, s.ID, signals, through)
+		if err != nil {
+			logger.WithField(logging.FieldError, err).Error("Could not save portfolio signals")
+			return err
+		}
+
+		if err := r.Measurements.CompleteUpdate(ctx, s.ID, through, perf, numTransactions); err != nil {
+			logger.WithField(logging.FieldError, err).Error("Could not complete portfolio update ledger entry")
+			return err
+		}
+
+		return nil
+	})
+
+	if err == errAlreadyUpdated {
+		logger.Info("Portfolio already updated through date; skipping")
+		return false, nil
+	}
+	if err != nil {
+		logger.WithField(logging.FieldError, err).Error("Could not commit portfolio update")
+		return false, err
+	}
+
+	logger.WithFields(log.Fields{
+		"YTDReturn":            perf.YTDReturn,
+		"CagrSinceInception":   perf.CagrSinceInception,
+		"NumTransactions":      numTransactions,
+		"NumSignals":           numSignals,
+		"PerformanceStartDate": time.Unix(perf.PeriodStart, 0),
+		"PerformanceEndDate":   time.Unix(perf.PeriodEnd, 0),
+	}).Info("Calculated portfolio performance")
+
+	if !revision.Empty() {
+		logger.WithFields(log.Fields{
+			"NumChanges": len(revision.Changes),
+			"NumDates":   len(revision.Dates),
+		}).Warn("Recomputed history differs from stored history")
+		publish(events.TopicHistoryRevised, &events.HistoryRevised{
+			PortfolioID: s.ID,
+			UserID:      s.UserID,
+			Through:     through,
+			Revision:    revision,
+		})
+	}
+
+	return true, nil
+}
+
+// updateMetricsState load the persisted running metrics of the portfolio and
+// add the measurements taken since the last update. The state is rebuilt from
+// the full history when forced or when it was computed at a different
+// resolution.
+func updateMetricsState(ctx context.Context, measurements repository.MeasurementRepo, s *savedStrategy, perf *portfolio.Performance, force bool) (*portfolio.StreamingMetrics, error) {
+	state, err := measurements.MetricsState(ctx, s.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := portfolio.NewStreamingMetrics(perf.Resolution)
+	if len(state) > 0 && !force {
+		restored := &portfolio.StreamingMetrics{}
+		if err := json.Unmarshal(state, restored); err != nil {
+			log.WithFields(log.Fields{
+				logging.FieldPortfolio: s.ID,
+				logging.FieldError:     err,
+			}).Warn("Could not parse persisted metrics state; rebuilding")
+		} else if restored.Resolution == perf.Resolution {
+			metrics = restored
+		}
+	}
+
+	added := metrics.UpdateFrom(perf)
+	log.WithFields(log.Fields{
+		logging.FieldPortfolio: s.ID,
+		"Measurements":         added,
+	}).Debug("Updated portfolio metrics state")
+
+	return metrics, nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"main/data"
+	"main/logging"
+	"main/repository"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// errQuotaDeferred the portfolio was not updated because its owner's data
+// provider quota is nearly exhausted; it is updated by the next run
+var errQuotaDeferred = errors.New("deferred until the data provider quota resets")
+
+// quotaDeferred true if updating s must wait because the Tiingo quota of the
+// user who owns it is nearly exhausted. Updating saved portfolios is low
+// priority; the remaining quota is left for the user's own requests.
+func quotaDeferred(s *savedStrategy) bool {
+	u, err := getUser(s.UserID)
+	if err != nil {
+		return false
+	}
+
+	if err := data.Usage.Refresh(context.Background(), repository.Usage, u.TiingoToken); err != nil {
+		log.WithFields(log.Fields{
+			logging.FieldFunction: "cmd/notifier/usage.go:quotaDeferred",
+			logging.FieldUserID:   s.UserID,
+			logging.FieldError:    err,
+		}).Warn("Could not read provider usage")
+	}
+	if !data.Usage.NearQuota("tiingo", u.TiingoToken) {
+		return false
+	}
+
+	hourCalls, dayCalls := data.Usage.Calls("tiingo", u.TiingoToken)
+	log.WithFields(log.Fields{
+		logging.FieldFunction:  "cmd/notifier/usage.go:quotaDeferred",
+		logging.FieldPortfolio: s.ID,
+		logging.FieldUserID:    s.UserID,
+		"HourCalls":            hourCalls,
+		"DayCalls":             dayCalls,
+	}).Warn("Deferred portfolio update; Tiingo quota nearly exhausted")
+	return true
+}
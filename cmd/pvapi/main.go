@@ -10,16 +10,25 @@
 package main
 
 import (
+	"context"
 	"main/data"
 	"main/database"
 	"main/jwks"
+	"main/logging"
 	"main/loki"
 	"main/middleware"
+	"main/reporting"
+	"main/repository"
 	"main/router"
+	"main/rpc"
 	"main/strategies"
+	"main/telemetry"
+	"net"
 	"os"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/compress"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
@@ -44,6 +53,10 @@ func setupLogging() {
 // @license.name Commercial
 // @BasePath /
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "new-strategy" {
+		os.Exit(newStrategy(os.Args[2:]))
+	}
+
 	setupLogging()
 	log.Info("Logging configured")
 
@@ -56,13 +69,38 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	repository.Initialize(database.Conn)
+
+	// warnings and errors are stored for support when LOG_DATABASE_LEVEL is set
+	if _, err := logging.PersistFromEnv(repository.Logs); err != nil {
+		log.Fatal(err)
+	}
+
+	// panics and server errors are sent to Sentry when SENTRY_DSN is set
+	if err := reporting.Configure(); err != nil {
+		log.Fatal(err)
+	}
+
+	// data provider and database telemetry is exported when
+	// OTEL_EXPORTER_OTLP_ENDPOINT is set
+	if _, err := telemetry.Configure("pvapi"); err != nil {
+		log.Fatal(err)
+	}
 
 	// Initialize data framework
+	if err := data.ConfigureHTTP(data.HTTPConfigFromEnv()); err != nil {
+		log.Fatal(err)
+	}
 	data.InitializeDataManager()
 	log.Info("Initialized data framework")
 
+	// share provider usage with the notifier and workers
+	go data.Usage.SyncEvery(context.Background(), repository.Usage, time.Minute)
+
 	// Create new Fiber instance
-	app := fiber.New()
+	app := fiber.New(fiber.Config{
+		ErrorHandler: middleware.ErrorHandler,
+	})
 
 	// Configure CORS
 	corsConfig := cors.Config{
@@ -72,9 +110,15 @@ func main() {
 	}
 	app.Use(cors.New(corsConfig))
 
+	// Compress responses with brotli or gzip as accepted by the client
+	app.Use(compress.New())
+
 	// Setup logging middleware
 	app.Use(middleware.NewLogger())
 
+	// Report panics instead of crashing the server
+	app.Use(middleware.Recover())
+
 	// Configure authentication
 	signingKeys := jwks.LoadJWKS()
 
@@ -84,6 +128,33 @@ func main() {
 	// initialize strategies
 	strategies.IntializeStrategyMap()
 
+	// strategies deployed as Go plugins
+	if err := strategies.LoadPlugins(os.Getenv("STRATEGY_PLUGIN_DIR")); err != nil {
+		log.Fatal(err)
+	}
+
+	// strategies disabled or soft launched by administrators
+	if err := strategies.LoadRegistrations(context.Background(), repository.Strategies); err != nil {
+		log.Fatal(err)
+	}
+	go strategies.LoadRegistrationsEvery(context.Background(), repository.Strategies, strategies.RegistrationInterval)
+
+	// Serve the strategy service over gRPC for internal callers
+	if grpcPort := os.Getenv("GRPC_PORT"); grpcPort != "" {
+		token := os.Getenv("GRPC_TOKEN")
+		if token == "" {
+			log.Fatal("GRPC_TOKEN must be set when GRPC_PORT is set")
+		}
+		lis, err := net.Listen("tcp", ":"+grpcPort)
+		if err != nil {
+			log.Fatal(err)
+		}
+		go func() {
+			log.Fatal(rpc.NewServer(token).Serve(lis))
+		}()
+		log.Infof("gRPC server listening on port %s", grpcPort)
+	}
+
 	// Get the PORT from heroku env
 	port := os.Getenv("PORT")
 
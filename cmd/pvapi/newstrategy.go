@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"main/scaffold"
+	"os"
+	"path/filepath"
+)
+
+// newStrategy scaffold a strategy in the strategies package:
+//
+//	pvapi new-strategy -name "Sector Rotation" -shortcode sr
+//
+// Returns the exit code of the command.
+func newStrategy(args []string) int {
+	flags := flag.NewFlagSet("new-strategy", flag.ContinueOnError)
+	name := flags.String("name", "", "name of the strategy, e.g. \"Sector Rotation\"")
+	shortcode := flags.String("shortcode", "", "short lowercase identifier of the strategy, e.g. sr")
+	description := flags.String("description", "", "one sentence description of the strategy")
+	source := flags.String("source", "", "URL describing the strategy")
+	dir := flags.String("dir", "strategies", "directory of the strategies package")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *name == "" || *shortcode == "" {
+		fmt.Fprintln(os.Stderr, "new-strategy: -name and -shortcode are required")
+		flags.Usage()
+		return 2
+	}
+
+	s, err := scaffold.New(*name, *shortcode, *description, *source)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "new-strategy: %s\n", err)
+		return 1
+	}
+
+	files, err := scaffold.Generate(*dir, s)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "new-strategy: %s\n", err)
+		return 1
+	}
+
+	for _, fn := range files {
+		fmt.Printf("wrote %s\n", fn)
+	}
+	fmt.Printf("\nNext, implement the signal in %s and record its golden results:\n", filepath.Join(*dir, s.Shortcode+".go"))
+	fmt.Println("\tgo test ./strategies -update")
+	return 0
+}
@@ -0,0 +1,144 @@
+// Worker runs strategy computations enqueued by the API and the notifier.
+// Any number of workers may run against the same database; each job is
+// claimed by exactly one of them.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"main/data"
+	"main/database"
+	"main/logging"
+	"main/queue"
+	"main/reporting"
+	"main/repository"
+	"main/strategies"
+	"main/telemetry"
+	"main/worker"
+	"os"
+	"os/signal"
+	"runtime"
+	"sync"
+	"syscall"
+	"time"
+
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/github"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// requeueStale periodically return jobs whose worker stopped responding to
+// the queue
+func requeueStale(ctx context.Context, q queue.Queue, stale time.Duration) {
+	ticker := time.NewTicker(stale / 2)
+	defer ticker.Stop()
+
+	for {
+		n, err := q.Requeue(time.Now().Add(-stale))
+		if err != nil {
+			log.WithFields(log.Fields{
+				logging.FieldFunction: "cmd/worker/main.go:requeueStale",
+				logging.FieldError:    err,
+			}).Error("Could not requeue stale jobs")
+		} else if n > 0 {
+			log.WithFields(log.Fields{
+				"NumJobs": n,
+			}).Warn("Requeued jobs of unresponsive workers")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func main() {
+	hostname, _ := os.Hostname()
+	nameFlag := flag.String("name", fmt.Sprintf("%s-%d", hostname, os.Getpid()), "name recorded on claimed jobs")
+	concurrencyFlag := flag.Int("concurrency", runtime.NumCPU(), "number of jobs to run at once")
+	pollFlag := flag.Duration("poll", time.Second, "how long to wait before polling an empty queue again")
+	staleFlag := flag.Duration("stale", 30*time.Minute, "requeue jobs that have been running longer than this")
+	flag.Parse()
+
+	// setup database
+	err := database.SetupDatabaseMigrations()
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = database.Connect()
+	if err != nil {
+		log.Fatal(err)
+	}
+	repository.Initialize(database.Conn)
+
+	// warnings and errors are stored for support when LOG_DATABASE_LEVEL is set
+	if _, err := logging.PersistFromEnv(repository.Logs); err != nil {
+		log.Fatal(err)
+	}
+
+	// panicking jobs are sent to Sentry when SENTRY_DSN is set
+	if err := reporting.Configure(); err != nil {
+		log.Fatal(err)
+	}
+	defer reporting.Flush(10 * time.Second)
+
+	// data provider and database telemetry is exported when
+	// OTEL_EXPORTER_OTLP_ENDPOINT is set
+	exporter, err := telemetry.Configure("pvapi-worker")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if exporter != nil {
+		defer exporter.Shutdown()
+	}
+
+	if err := data.ConfigureHTTP(data.HTTPConfigFromEnv()); err != nil {
+		log.Fatal(err)
+	}
+	data.InitializeDataManager()
+	log.Info("Initialized data framework")
+
+	strategies.IntializeStrategyMap()
+	log.Info("Initialized strategy map")
+
+	// strategies deployed as Go plugins
+	if err := strategies.LoadPlugins(os.Getenv("STRATEGY_PLUGIN_DIR")); err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-signals
+		log.Info("Stopping after the running jobs finish")
+		cancel()
+	}()
+
+	q := repository.Jobs
+	go requeueStale(ctx, q, *staleFlag)
+	go data.Usage.SyncEvery(ctx, repository.Usage, time.Minute)
+
+	var wg sync.WaitGroup
+	for ii := 0; ii < *concurrencyFlag; ii++ {
+		w := worker.New(q, fmt.Sprintf("%s/%d", *nameFlag, ii))
+		w.PollInterval = *pollFlag
+		worker.RegisterStrategyHandlers(w)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.Run(ctx)
+		}()
+	}
+
+	log.WithFields(log.Fields{
+		"Name":        *nameFlag,
+		"Concurrency": *concurrencyFlag,
+	}).Info("Worker started")
+	wg.Wait()
+}
@@ -0,0 +1,186 @@
+// Package community summarizes, by strategy, the metrics of portfolios whose
+// users opted in to sharing them without identifying the portfolios or users
+package community
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// MinPortfolios fewest portfolios the returns of a strategy are summarized
+// for, so the returns of individual portfolios cannot be inferred
+const MinPortfolios = 5
+
+// Keys the leaderboard is sorted by
+const (
+	SortPopularity = "popularity"
+	SortYTD        = "ytd"
+	SortCAGR       = "cagr"
+)
+
+// Sample the metrics of a shared portfolio
+type Sample struct {
+	Strategy           string
+	YTDReturn          sql.NullFloat64
+	CAGRSinceInception sql.NullFloat64
+}
+
+// Distribution percentiles of a metric across the portfolios of a strategy.
+// The extremes are left out as they are the returns of single portfolios.
+type Distribution struct {
+	Count  int     `json:"count"`
+	P10    float64 `json:"p10"`
+	P25    float64 `json:"p25"`
+	Median float64 `json:"median"`
+	P75    float64 `json:"p75"`
+	P90    float64 `json:"p90"`
+
+	values []float64
+}
+
+// Stats the community statistics of a strategy
+type Stats struct {
+	Strategy   string `json:"strategy"`
+	Portfolios int    `json:"portfolios"`
+
+	// Popularity share of all shared portfolios that run the strategy
+	Popularity float64 `json:"popularity"`
+
+	// YTDReturn and CAGRSinceInception are only included for strategies
+	// with at least MinPortfolios portfolios
+	YTDReturn          *Distribution `json:"ytd_return,omitempty"`
+	CAGRSinceInception *Distribution `json:"cagr_since_inception,omitempty"`
+}
+
+// Comparison how the metrics of a portfolio compare to the community
+// statistics of its strategy; percentiles are omitted when the strategy has
+// too few shared portfolios or the portfolio has not been computed
+type Comparison struct {
+	Stats
+	YTDReturnPercentile          *float64 `json:"ytd_return_percentile,omitempty"`
+	CAGRSinceInceptionPercentile *float64 `json:"cagr_since_inception_percentile,omitempty"`
+}
+
+// Summarize the statistics of each strategy in samples, most popular first
+func Summarize(samples []Sample) []*Stats {
+	byStrategy := make(map[string]*Stats)
+	ytd := make(map[string][]float64)
+	cagr := make(map[string][]float64)
+	for _, s := range samples {
+		stats, ok := byStrategy[s.Strategy]
+		if !ok {
+			stats = &Stats{Strategy: s.Strategy}
+			byStrategy[s.Strategy] = stats
+		}
+		stats.Portfolios++
+		if valid(s.YTDReturn) {
+			ytd[s.Strategy] = append(ytd[s.Strategy], s.YTDReturn.Float64)
+		}
+		if valid(s.CAGRSinceInception) {
+			cagr[s.Strategy] = append(cagr[s.Strategy], s.CAGRSinceInception.Float64)
+		}
+	}
+
+	summary := make([]*Stats, 0, len(byStrategy))
+	for strategy, stats := range byStrategy {
+		stats.Popularity = float64(stats.Portfolios) / float64(len(samples))
+		stats.YTDReturn = describe(ytd[strategy])
+		stats.CAGRSinceInception = describe(cagr[strategy])
+		summary = append(summary, stats)
+	}
+	sort.Slice(summary, func(i, j int) bool {
+		if summary[i].Portfolios != summary[j].Portfolios {
+			return summary[i].Portfolios > summary[j].Portfolios
+		}
+		return summary[i].Strategy < summary[j].Strategy
+	})
+	return summary
+}
+
+// Leaderboard order stats by key, one of popularity, ytd, or cagr; the
+// return keys sort by median and strategies too small to summarize are last
+func Leaderboard(stats []*Stats, key string) error {
+	var median func(s *Stats) *Distribution
+	switch key {
+	case "", SortPopularity:
+		return nil
+	case SortYTD:
+		median = func(s *Stats) *Distribution { return s.YTDReturn }
+	case SortCAGR:
+		median = func(s *Stats) *Distribution { return s.CAGRSinceInception }
+	default:
+		return fmt.Errorf("sort must be one of %s, %s, or %s", SortPopularity, SortYTD, SortCAGR)
+	}
+
+	sort.SliceStable(stats, func(i, j int) bool {
+		a, b := median(stats[i]), median(stats[j])
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return a.Median > b.Median
+	})
+	return nil
+}
+
+// Compare the metrics of a portfolio with the statistics of its strategy
+func (s *Stats) Compare(ytd sql.NullFloat64, cagr sql.NullFloat64) *Comparison {
+	return &Comparison{
+		Stats:                        *s,
+		YTDReturnPercentile:          s.YTDReturn.rank(ytd),
+		CAGRSinceInceptionPercentile: s.CAGRSinceInception.rank(cagr),
+	}
+}
+
+// rank the percentage of the distribution's values below value, counting
+// equal values as half below; nil if either is missing
+func (d *Distribution) rank(value sql.NullFloat64) *float64 {
+	if d == nil || !valid(value) {
+		return nil
+	}
+	below := 0.0
+	for _, v := range d.values {
+		switch {
+		case v < value.Float64:
+			below++
+		case v == value.Float64:
+			below += 0.5
+		}
+	}
+	rank := below / float64(len(d.values)) * 100
+	return &rank
+}
+
+// describe the distribution of values; nil if there are too few to keep the
+// values of individual portfolios private
+func describe(values []float64) *Distribution {
+	if len(values) < MinPortfolios {
+		return nil
+	}
+	sort.Float64s(values)
+	return &Distribution{
+		Count:  len(values),
+		P10:    percentile(values, 10),
+		P25:    percentile(values, 25),
+		Median: percentile(values, 50),
+		P75:    percentile(values, 75),
+		P90:    percentile(values, 90),
+		values: values,
+	}
+}
+
+// percentile the p-th percentile of sorted values, interpolating between the
+// closest ranks
+func percentile(sorted []float64, p float64) float64 {
+	pos := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	frac := pos - float64(lower)
+	return sorted[lower] + (sorted[upper]-sorted[lower])*frac
+}
+
+// valid true if the metric has been computed and is a number
+func valid(v sql.NullFloat64) bool {
+	return v.Valid && !math.IsNaN(v.Float64) && !math.IsInf(v.Float64, 0)
+}
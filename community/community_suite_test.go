@@ -0,0 +1,13 @@
+package community_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestCommunity(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Community Suite")
+}
@@ -0,0 +1,91 @@
+package community_test
+
+import (
+	"database/sql"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"main/community"
+)
+
+func sample(strategy string, ytd float64, cagr float64) community.Sample {
+	return community.Sample{
+		Strategy:           strategy,
+		YTDReturn:          sql.NullFloat64{Float64: ytd, Valid: true},
+		CAGRSinceInception: sql.NullFloat64{Float64: cagr, Valid: true},
+	}
+}
+
+var _ = Describe("Community", func() {
+	var samples []community.Sample
+
+	BeforeEach(func() {
+		samples = []community.Sample{
+			sample("adm", 0.05, 0.10),
+			sample("adm", 0.01, 0.08),
+			sample("adm", 0.03, 0.12),
+			sample("adm", 0.02, 0.09),
+			sample("adm", 0.04, 0.11),
+			sample("daa", 0.10, 0.20),
+			sample("daa", 0.20, 0.30),
+			{Strategy: "adm"},
+		}
+	})
+
+	Describe("When summarizing samples", func() {
+		It("should order strategies by popularity", func() {
+			stats := community.Summarize(samples)
+			Expect(stats).To(HaveLen(2))
+			Expect(stats[0].Strategy).To(Equal("adm"))
+			Expect(stats[0].Portfolios).To(Equal(6))
+			Expect(stats[0].Popularity).To(BeNumerically("~", 0.75))
+			Expect(stats[1].Strategy).To(Equal("daa"))
+			Expect(stats[1].Popularity).To(BeNumerically("~", 0.25))
+		})
+
+		It("should describe the distribution of computed metrics", func() {
+			stats := community.Summarize(samples)
+			Expect(stats[0].YTDReturn).NotTo(BeNil())
+			Expect(stats[0].YTDReturn.Count).To(Equal(5))
+			Expect(stats[0].YTDReturn.Median).To(BeNumerically("~", 0.03))
+			Expect(stats[0].YTDReturn.P25).To(BeNumerically("~", 0.02))
+			Expect(stats[0].YTDReturn.P10).To(BeNumerically("~", 0.014))
+			Expect(stats[0].CAGRSinceInception.Median).To(BeNumerically("~", 0.10))
+		})
+
+		It("should not describe strategies with too few portfolios", func() {
+			stats := community.Summarize(samples)
+			Expect(stats[1].YTDReturn).To(BeNil())
+			Expect(stats[1].CAGRSinceInception).To(BeNil())
+		})
+	})
+
+	Describe("When sorting the leaderboard", func() {
+		It("should put strategies without a distribution last", func() {
+			stats := community.Summarize(samples)
+			Expect(community.Leaderboard(stats, community.SortCAGR)).To(Succeed())
+			Expect(stats[0].Strategy).To(Equal("adm"))
+		})
+
+		It("should reject unknown keys", func() {
+			Expect(community.Leaderboard(nil, "sharpe")).NotTo(Succeed())
+		})
+	})
+
+	Describe("When comparing a portfolio", func() {
+		It("should rank its metrics", func() {
+			stats := community.Summarize(samples)
+			cmp := stats[0].Compare(sql.NullFloat64{Float64: 0.045, Valid: true}, sql.NullFloat64{Float64: 0.10, Valid: true})
+			Expect(*cmp.YTDReturnPercentile).To(BeNumerically("~", 80))
+			Expect(*cmp.CAGRSinceInceptionPercentile).To(BeNumerically("~", 50))
+		})
+
+		It("should not rank against too few portfolios", func() {
+			stats := community.Summarize(samples)
+			cmp := stats[1].Compare(sql.NullFloat64{Float64: 0.15, Valid: true}, sql.NullFloat64{})
+			Expect(cmp.YTDReturnPercentile).To(BeNil())
+			Expect(cmp.CAGRSinceInceptionPercentile).To(BeNil())
+		})
+	})
+})
@@ -0,0 +1,23 @@
+package data
+
+const (
+	// AssetTypeStock stocks and exchange traded funds
+	AssetTypeStock      = "stock"
+	AssetTypeMutualFund = "mutualFund"
+	AssetTypeRate       = "rate"
+	AssetTypeCrypto     = "crypto"
+	AssetTypeFutures    = "futures"
+	AssetTypeSynthetic  = "synthetic"
+	AssetTypeFactor     = "factor"
+	AssetTypeCash       = "cash"
+)
+
+// AssetType classify a symbol by the kind of asset it refers to; see
+// ResolveSymbol. Symbols that cannot be resolved are considered stocks.
+func AssetType(symbol string) string {
+	resolved, err := ResolveSymbol(symbol)
+	if err != nil {
+		return AssetTypeStock
+	}
+	return resolved.AssetType
+}
@@ -0,0 +1,117 @@
+package data
+
+import (
+	"context"
+	"main/dfextras"
+	"sync"
+	"time"
+
+	dataframe "github.com/rocketlaunchr/dataframe-go"
+)
+
+// PriceCache data downloaded once for the period between Begin and End and
+// shared by every manager that uses the cache, e.g. by all portfolios
+// computed by a nightly run. Requests within the period are served from the
+// cache; each symbol, metric, and frequency is downloaded on first use.
+type PriceCache struct {
+	Begin time.Time
+	End   time.Time
+
+	mu      sync.Mutex
+	entries map[priceCacheKey]*priceCacheEntry
+}
+
+type priceCacheKey struct {
+	symbol    string
+	metric    string
+	frequency string
+}
+
+type priceCacheEntry struct {
+	once      sync.Once
+	df        *dataframe.DataFrame
+	retrieved time.Time
+	err       error
+}
+
+// NewPriceCache create an empty cache for the period between begin and end
+func NewPriceCache(begin time.Time, end time.Time) *PriceCache {
+	return &PriceCache{
+		Begin:   begin,
+		End:     end,
+		entries: make(map[priceCacheKey]*priceCacheEntry),
+	}
+}
+
+// Len number of symbol, metric, and frequency combinations in the cache
+func (c *PriceCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// covers true if a request for the period between begin and end can be
+// served from the cache. Periods longer than a day end on the last trading
+// day requested, so those requests must end with the cache.
+func (c *PriceCache) covers(begin time.Time, end time.Time, frequency string) bool {
+	if begin.Before(c.Begin) || end.After(c.End) {
+		return false
+	}
+	return frequency == FrequencyDaily || end.Equal(c.End)
+}
+
+// get the rows of the cached data between begin and end, downloading the
+// full period of the cache with load if it isn't cached yet. Callers
+// receive a copy they are free to modify and the time the data was
+// retrieved from its provider.
+func (c *PriceCache) get(symbol string, metric string, frequency string, begin time.Time, end time.Time, load func() (*dataframe.DataFrame, time.Time, error)) (*dataframe.DataFrame, time.Time, error) {
+	key := priceCacheKey{symbol: symbol, metric: metric, frequency: frequency}
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &priceCacheEntry{}
+		c.entries[key] = entry
+	}
+	c.mu.Unlock()
+	recordCache(cacheMemory, ok)
+
+	entry.once.Do(func() {
+		entry.df, entry.retrieved, entry.err = load()
+	})
+	if entry.err != nil {
+		return nil, time.Time{}, entry.err
+	}
+
+	dateIdx, err := entry.df.NameToColumn(DateIdx)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	df := entry.df.Copy()
+	if _, err := dfextras.TimeTrim(context.TODO(), df, dateIdx, begin, end, true); err != nil {
+		return nil, time.Time{}, err
+	}
+	return df, entry.retrieved, nil
+}
+
+// UseCache serve requests from cache when they fall within its period
+func (m *Manager) UseCache(cache *PriceCache) {
+	m.cache = cache
+}
+
+// Prefetch download symbols for the period of the manager's cache at the
+// manager's frequency so later requests are served from the cache
+func (m *Manager) Prefetch(symbols ...string) []error {
+	if m.cache == nil {
+		return nil
+	}
+
+	origBegin, origEnd := m.Begin, m.End
+	defer func() {
+		m.Begin, m.End = origBegin, origEnd
+	}()
+
+	m.Begin, m.End = m.cache.Begin, m.cache.End
+	_, errs := m.GetMultipleData(symbols...)
+	return errs
+}
@@ -0,0 +1,88 @@
+package data_test
+
+import (
+	"io/ioutil"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	dataframe "github.com/rocketlaunchr/dataframe-go"
+
+	"main/data"
+)
+
+var _ = Describe("PriceCache", func() {
+	var (
+		dataProxy data.Manager
+		cache     *data.PriceCache
+	)
+
+	BeforeEach(func() {
+		content, err := ioutil.ReadFile("testdata/VFINX.csv")
+		if err != nil {
+			panic(err)
+		}
+		httpmock.RegisterResponder("GET", "https://api.tiingo.com/tiingo/daily/VFINX/prices?startDate=1980-01-01&endDate=2021-01-01&format=csv&resampleFreq=Monthly&token=TEST",
+			httpmock.NewBytesResponder(200, content))
+		httpmock.RegisterResponder("GET", "https://api.tiingo.com/tiingo/daily/VFINX/prices?startDate=1990-01-01&endDate=2000-01-01&format=csv&resampleFreq=Monthly&token=TEST",
+			httpmock.NewBytesResponder(200, content))
+
+		cache = data.NewPriceCache(time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC), time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC))
+		dataProxy = data.NewManager(map[string]string{
+			"tiingo": "TEST",
+		})
+		dataProxy.UseCache(cache)
+		dataProxy.Frequency = data.FrequencyMonthly
+	})
+
+	firstDate := func(df *dataframe.DataFrame) time.Time {
+		return df.Row(0, false, dataframe.SeriesName)[data.DateIdx].(time.Time)
+	}
+
+	Describe("When prefetching", func() {
+		It("should download each ticker once", func() {
+			errs := dataProxy.Prefetch("VFINX")
+			Expect(errs).To(BeEmpty())
+			Expect(cache.Len()).To(Equal(1))
+
+			dataProxy.Begin = time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC)
+			dataProxy.End = time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+			df, err := dataProxy.GetData("VFINX")
+			Expect(err).To(BeNil())
+			Expect(firstDate(df)).To(Equal(time.Date(1990, time.January, 31, 0, 0, 0, 0, time.UTC)))
+
+			dataProxy.Begin = time.Date(1995, time.January, 1, 0, 0, 0, 0, time.UTC)
+			df, err = dataProxy.GetData("vfinx")
+			Expect(err).To(BeNil())
+			Expect(firstDate(df)).To(Equal(time.Date(1995, time.January, 31, 0, 0, 0, 0, time.UTC)))
+
+			Expect(httpmock.GetTotalCallCount()).To(Equal(1))
+		})
+
+		It("should give each caller its own copy", func() {
+			dataProxy.Begin = time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC)
+			dataProxy.End = time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+			df, err := dataProxy.GetData("VFINX")
+			Expect(err).To(BeNil())
+			nrows := df.NRows()
+			df.Append(nil, time.Date(2021, time.February, 1, 0, 0, 0, 0, time.UTC), 1.0)
+
+			df, err = dataProxy.GetData("VFINX")
+			Expect(err).To(BeNil())
+			Expect(df.NRows()).To(Equal(nrows))
+			Expect(httpmock.GetTotalCallCount()).To(Equal(1))
+		})
+	})
+
+	Describe("When requesting a period the cache doesn't cover", func() {
+		It("should download it directly", func() {
+			dataProxy.Begin = time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC)
+			dataProxy.End = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+			_, err := dataProxy.GetData("VFINX")
+			Expect(err).To(BeNil())
+			Expect(cache.Len()).To(Equal(0))
+			Expect(httpmock.GetCallCountInfo()["GET https://api.tiingo.com/tiingo/daily/VFINX/prices?startDate=1990-01-01&endDate=2000-01-01&format=csv&resampleFreq=Monthly&token=TEST"]).To(Equal(1))
+		})
+	})
+})
@@ -0,0 +1,229 @@
+package data
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"main/logging"
+	"math"
+	"strings"
+	"time"
+
+	dataframe "github.com/rocketlaunchr/dataframe-go"
+	log "github.com/sirupsen/logrus"
+)
+
+// calendarSymbol security whose trading days define the market calendar
+const calendarSymbol = "SPY"
+
+type tiingoCrypto struct {
+	apikey string
+}
+
+type tiingoCryptoPrice struct {
+	Date   time.Time `json:"date"`
+	Open   float64   `json:"open"`
+	High   float64   `json:"high"`
+	Low    float64   `json:"low"`
+	Close  float64   `json:"close"`
+	Volume float64   `json:"volume"`
+}
+
+type tiingoCryptoResponse struct {
+	Ticker    string              `json:"ticker"`
+	PriceData []tiingoCryptoPrice `json:"priceData"`
+}
+
+// NewTiingoCrypto Create a new Tiingo crypto currency data provider
+func NewTiingoCrypto(key string) tiingoCrypto {
+	return tiingoCrypto{
+		apikey: key,
+	}
+}
+
+// Provider functions
+
+func (t tiingoCrypto) DataType() string {
+	return "crypto"
+}
+
+// GetDataForPeriod daily closes of a crypto currency pair such as BTCUSD.
+// Crypto currencies trade every day of the week; periods longer than a day
+// end on the last calendar day with a price. Crypto prices are not
+// adjusted, so adjusted metrics are the same as their unadjusted
+// counterparts.
+func (t tiingoCrypto) GetDataForPeriod(symbol string, metric string, frequency string, begin time.Time, end time.Time) (*dataframe.DataFrame, error) {
+	validFrequencies := map[string]bool{
+		FrequencyDaily:   true,
+		FrequencyWeekly:  true,
+		FrequencyMonthly: true,
+		FrequencyAnnualy: true,
+	}
+
+	if _, ok := validFrequencies[frequency]; !ok {
+		log.WithFields(log.Fields{
+			"Frequency": frequency,
+			"Symbol":    symbol,
+			"Metric":    metric,
+		}).Debug("Invalid frequency provided")
+		return nil, fmt.Errorf("invalid frequency '%s'", frequency)
+	}
+
+	var url string
+	nullTime := time.Time{}
+	if begin == nullTime || end == nullTime {
+		url = fmt.Sprintf("%s/tiingo/crypto/prices?tickers=%s&resampleFreq=1day&token=%s", tiingoAPI, strings.ToLower(symbol), t.apikey)
+	} else {
+		url = fmt.Sprintf("%s/tiingo/crypto/prices?tickers=%s&startDate=%s&endDate=%s&resampleFreq=1day&token=%s", tiingoAPI, strings.ToLower(symbol), begin.Format("2006-01-02"), end.Format("2006-01-02"), t.apikey)
+	}
+
+	resp, err := providerGet("tiingo", t.apikey, url)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"Symbol":           symbol,
+			"Metric":           metric,
+			"Frequency":        frequency,
+			"StartTime":        begin.String(),
+			"EndTime":          end.String(),
+			logging.FieldError: err,
+		}).Debug("Failed to load crypto prices")
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"Symbol":           symbol,
+			"Metric":           metric,
+			"Frequency":        frequency,
+			logging.FieldError: err,
+			"StatusCode":       resp.StatusCode,
+		}).Debug("Failed to load crypto prices -- reading body failed")
+		return nil, err
+	}
+
+	if resp.StatusCode >= 400 {
+		log.WithFields(log.Fields{
+			"Symbol":     symbol,
+			"Metric":     metric,
+			"Frequency":  frequency,
+			"Body":       string(body),
+			"StatusCode": resp.StatusCode,
+		}).Debug("Failed to load crypto prices")
+		return nil, fmt.Errorf("HTTP request returned invalid status code: %d", resp.StatusCode)
+	}
+
+	jsonResp := []tiingoCryptoResponse{}
+	if err := json.Unmarshal(body, &jsonResp); err != nil {
+		log.WithFields(log.Fields{
+			"Symbol":           symbol,
+			"Body":             string(body),
+			logging.FieldError: err,
+		}).Debug("Failed to parse crypto prices")
+		return nil, err
+	}
+	if len(jsonResp) == 0 || len(jsonResp[0].PriceData) == 0 {
+		return nil, fmt.Errorf("no crypto prices for symbol: %s", symbol)
+	}
+
+	var value func(p *tiingoCryptoPrice) float64
+	switch metric {
+	case MetricOpen, MetricAdjustedOpen:
+		value = func(p *tiingoCryptoPrice) float64 { return p.Open }
+	case MetricHigh, MetricAdjustedHigh:
+		value = func(p *tiingoCryptoPrice) float64 { return p.High }
+	case MetricLow, MetricAdjustedLow:
+		value = func(p *tiingoCryptoPrice) float64 { return p.Low }
+	case MetricClose, MetricAdjustedClose:
+		value = func(p *tiingoCryptoPrice) float64 { return p.Close }
+	case MetricVolume:
+		value = func(p *tiingoCryptoPrice) float64 { return p.Volume }
+	case MetricSplitFactor:
+		value = func(p *tiingoCryptoPrice) float64 { return 1.0 }
+	case MetricDividendCash:
+		value = func(p *tiingoCryptoPrice) float64 { return 0.0 }
+	default:
+		return nil, errors.New("Un-supported metric")
+	}
+
+	prices := jsonResp[0].PriceData
+	dates := make([]time.Time, len(prices))
+	vals := make([]float64, len(prices))
+	for ii := range prices {
+		year, month, day := prices[ii].Date.UTC().Date()
+		dates[ii] = time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+		vals[ii] = value(&prices[ii])
+	}
+
+	return newPeriodFrame(symbol, dates, vals, frequency), nil
+}
+
+// newPeriodFrame dataframe of daily values of symbol that keeps the last
+// value in each period at frequency
+func newPeriodFrame(symbol string, dates []time.Time, vals []float64, frequency string) *dataframe.DataFrame {
+	periodDates := make([]interface{}, 0, len(dates))
+	periodVals := make([]interface{}, 0, len(vals))
+	for ii := range dates {
+		if len(periodDates) > 0 && samePeriod(periodDates[len(periodDates)-1].(time.Time), dates[ii], frequency) {
+			periodDates[len(periodDates)-1] = dates[ii]
+			periodVals[len(periodVals)-1] = vals[ii]
+			continue
+		}
+		periodDates = append(periodDates, dates[ii])
+		periodVals = append(periodVals, vals[ii])
+	}
+
+	return dataframe.NewDataFrame(
+		dataframe.NewSeriesTime(DateIdx, &dataframe.SeriesInit{Capacity: len(periodDates)}, periodDates...),
+		dataframe.NewSeriesFloat64(symbol, &dataframe.SeriesInit{Capacity: len(periodVals)}, periodVals...),
+	)
+}
+
+// alignToCalendar sample a series that is priced every day of the week on
+// the dates of calendar, using the last value on or before each date.
+// Calendar dates before the first value are dropped.
+func alignToCalendar(df *dataframe.DataFrame, symbol string, calendar *dataframe.DataFrame) (*dataframe.DataFrame, error) {
+	dateIdx, err := df.NameToColumn(DateIdx)
+	if err != nil {
+		return nil, err
+	}
+	valueIdx, err := df.NameToColumn(symbol)
+	if err != nil {
+		return nil, err
+	}
+	calendarIdx, err := calendar.NameToColumn(DateIdx)
+	if err != nil {
+		return nil, err
+	}
+
+	dateSeries := df.Series[dateIdx]
+	valueSeries := df.Series[valueIdx]
+	calendarSeries := calendar.Series[calendarIdx]
+
+	dates := make([]interface{}, 0, calendarSeries.NRows())
+	vals := make([]interface{}, 0, calendarSeries.NRows())
+	row := -1
+	for ii := 0; ii < calendarSeries.NRows(); ii++ {
+		tradingDay := calendarSeries.Value(ii).(time.Time)
+		for row+1 < dateSeries.NRows() && !dateSeries.Value(row+1).(time.Time).After(tradingDay) {
+			row++
+		}
+		if row < 0 {
+			continue
+		}
+
+		val, ok := valueSeries.Value(row).(float64)
+		if !ok {
+			val = math.NaN()
+		}
+		dates = append(dates, tradingDay)
+		vals = append(vals, val)
+	}
+
+	return dataframe.NewDataFrame(
+		dataframe.NewSeriesTime(DateIdx, &dataframe.SeriesInit{Capacity: len(dates)}, dates...),
+		dataframe.NewSeriesFloat64(symbol, &dataframe.SeriesInit{Capacity: len(vals)}, vals...),
+	), nil
+}
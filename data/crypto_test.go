@@ -0,0 +1,109 @@
+package data_test
+
+import (
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	dataframe "github.com/rocketlaunchr/dataframe-go"
+
+	"main/data"
+)
+
+const cryptoPrices = `[{"ticker":"btcusd","baseCurrency":"btc","quoteCurrency":"usd","priceData":[
+	{"date":"2021-01-27T00:00:00+00:00","open":32500,"high":32600,"low":29300,"close":30400,"volume":1000},
+	{"date":"2021-01-28T00:00:00+00:00","open":30400,"high":33800,"low":30000,"close":33400,"volume":1000},
+	{"date":"2021-01-29T00:00:00+00:00","open":33400,"high":38500,"low":31900,"close":34300,"volume":1000},
+	{"date":"2021-01-30T00:00:00+00:00","open":34300,"high":34900,"low":32800,"close":34300,"volume":1000},
+	{"date":"2021-01-31T00:00:00+00:00","open":34300,"high":34300,"low":32200,"close":33100,"volume":1000},
+	{"date":"2021-02-01T00:00:00+00:00","open":33100,"high":34700,"low":32300,"close":33500,"volume":1000},
+	{"date":"2021-02-02T00:00:00+00:00","open":33500,"high":35900,"low":33400,"close":35500,"volume":1000}
+]}]`
+
+const calendarHeader = "date,close,high,low,open,volume,adjClose,adjHigh,adjLow,adjOpen,adjVolume,divCash,splitFactor\n"
+
+var _ = Describe("Crypto", func() {
+	var (
+		dataProxy data.Manager
+	)
+
+	BeforeEach(func() {
+		httpmock.RegisterResponder("GET", "https://api.tiingo.com/tiingo/crypto/prices?tickers=btcusd&startDate=2021-01-27&endDate=2021-02-02&resampleFreq=1day&token=TEST",
+			httpmock.NewStringResponder(200, cryptoPrices))
+		httpmock.RegisterResponder("GET", "https://api.tiingo.com/tiingo/daily/SPY/prices?startDate=2021-01-27&endDate=2021-02-02&format=csv&resampleFreq=Daily&token=TEST",
+			httpmock.NewStringResponder(200, calendarHeader+
+				"2021-01-27,374.41,380.33,372.01,380.22,0,374.41,380.33,372.01,380.22,0,0.0,1.0\n"+
+				"2021-01-28,377.63,381.93,375.89,376.36,0,377.63,381.93,375.89,376.36,0,0.0,1.0\n"+
+				"2021-01-29,370.07,376.98,368.27,375.63,0,370.07,376.98,368.27,375.63,0,0.0,1.0\n"+
+				"2021-02-01,376.23,377.34,370.38,373.72,0,376.23,377.34,370.38,373.72,0,0.0,1.0\n"+
+				"2021-02-02,381.55,383.22,376.32,379.65,0,381.55,383.22,376.32,379.65,0,0.0,1.0\n"))
+		httpmock.RegisterResponder("GET", "https://api.tiingo.com/tiingo/daily/SPY/prices?startDate=2021-01-27&endDate=2021-02-02&format=csv&resampleFreq=Monthly&token=TEST",
+			httpmock.NewStringResponder(200, calendarHeader+
+				"2021-01-29,370.07,376.98,368.27,375.63,0,370.07,376.98,368.27,375.63,0,0.0,1.0\n"+
+				"2021-02-02,381.55,383.22,376.32,379.65,0,381.55,383.22,376.32,379.65,0,0.0,1.0\n"))
+
+		dataProxy = data.NewManager(map[string]string{
+			"tiingo": "TEST",
+		})
+		dataProxy.Begin = time.Date(2021, time.January, 27, 0, 0, 0, 0, time.UTC)
+		dataProxy.End = time.Date(2021, time.February, 2, 0, 0, 0, 0, time.UTC)
+	})
+
+	values := func(df *dataframe.DataFrame) ([]time.Time, []float64) {
+		dates := []time.Time{}
+		vals := []float64{}
+		for ii := 0; ii < df.NRows(); ii++ {
+			row := df.Row(ii, false, dataframe.SeriesName)
+			dates = append(dates, row[data.DateIdx].(time.Time))
+			vals = append(vals, row["BTCUSD"].(float64))
+		}
+		return dates, vals
+	}
+
+	Describe("When requesting daily prices", func() {
+		It("should only report the trading days of the stock market", func() {
+			dataProxy.Frequency = data.FrequencyDaily
+			df, err := dataProxy.GetData("$CRYPTO.BTCUSD")
+			Expect(err).To(BeNil())
+
+			dates, vals := values(df)
+			Expect(dates).To(Equal([]time.Time{
+				time.Date(2021, time.January, 27, 0, 0, 0, 0, time.UTC),
+				time.Date(2021, time.January, 28, 0, 0, 0, 0, time.UTC),
+				time.Date(2021, time.January, 29, 0, 0, 0, 0, time.UTC),
+				time.Date(2021, time.February, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2021, time.February, 2, 0, 0, 0, 0, time.UTC),
+			}))
+			Expect(vals).To(Equal([]float64{30400, 33400, 34300, 33500, 35500}))
+		})
+	})
+
+	Describe("When requesting monthly prices", func() {
+		It("should end each month on the last trading day", func() {
+			dataProxy.Frequency = data.FrequencyMonthly
+			df, err := dataProxy.GetData("$CRYPTO.BTCUSD")
+			Expect(err).To(BeNil())
+
+			dates, vals := values(df)
+			Expect(dates).To(Equal([]time.Time{
+				time.Date(2021, time.January, 29, 0, 0, 0, 0, time.UTC),
+				time.Date(2021, time.February, 2, 0, 0, 0, 0, time.UTC),
+			}))
+			Expect(vals).To(Equal([]float64{34300, 35500}))
+		})
+
+		It("should end each month on the last calendar day without a calendar", func() {
+			provider := data.NewTiingoCrypto("TEST")
+			df, err := provider.GetDataForPeriod("BTCUSD", data.MetricAdjustedClose, data.FrequencyMonthly, dataProxy.Begin, dataProxy.End)
+			Expect(err).To(BeNil())
+
+			dates, vals := values(df)
+			Expect(dates).To(Equal([]time.Time{
+				time.Date(2021, time.January, 31, 0, 0, 0, 0, time.UTC),
+				time.Date(2021, time.February, 2, 0, 0, 0, 0, time.UTC),
+			}))
+			Expect(vals).To(Equal([]float64{33100, 35500}))
+		})
+	})
+})
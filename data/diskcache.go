@@ -0,0 +1,266 @@
+package data
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"main/dfextras"
+	"main/logging"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	dataframe "github.com/rocketlaunchr/dataframe-go"
+	log "github.com/sirupsen/logrus"
+)
+
+// DiskCache data saved to CSV files under Dir so repeated runs, such as
+// backtests from the command line or the test suite, only download it once
+// and can then run offline. Each file holds one symbol, metric, and
+// frequency for the period it was downloaded for; requests within that
+// period are served from the file. Cached data is never refreshed, remove
+// the files to download it again.
+type DiskCache struct {
+	Dir string
+}
+
+// diskCacheDateFormat format of the period in the names of cache files
+const diskCacheDateFormat = "20060102"
+
+// NewDiskCache create a cache storing its files under dir
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &DiskCache{Dir: dir}, nil
+}
+
+// diskCacheFromEnv the cache configured by DATA_CACHE_DIR, or nil if it is
+// not set
+func diskCacheFromEnv() *DiskCache {
+	dir := os.Getenv("DATA_CACHE_DIR")
+	if dir == "" {
+		return nil
+	}
+
+	cache, err := NewDiskCache(dir)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"Dir":              dir,
+			logging.FieldError: err,
+		}).Warn("Cannot use data cache directory")
+		return nil
+	}
+	return cache
+}
+
+// UseDiskCache serve requests from the files of cache, downloading and
+// saving data that isn't cached yet
+func (m *Manager) UseDiskCache(cache *DiskCache) {
+	m.diskCache = cache
+}
+
+// prefix of the names of the files holding symbol, metric, and frequency
+func (c *DiskCache) prefix(kind string, symbol string, metric string, frequency string) string {
+	name := strings.NewReplacer("/", "-", "\\", "-").Replace(symbol)
+	return filepath.Join(c.Dir, kind, fmt.Sprintf("%s_%s_%s_", name, metric, frequency))
+}
+
+// find the file covering the period between begin and end, if any. As with
+// the price cache, periods longer than a day must end with the file.
+func (c *DiskCache) find(prefix string, frequency string, begin time.Time, end time.Time) string {
+	matches, _ := filepath.Glob(prefix + "*.csv")
+	for _, match := range matches {
+		period := strings.Split(strings.TrimSuffix(strings.TrimPrefix(match, prefix), ".csv"), "_")
+		if len(period) != 2 {
+			continue
+		}
+		fileBegin, err := time.Parse(diskCacheDateFormat, period[0])
+		if err != nil {
+			continue
+		}
+		fileEnd, err := time.Parse(diskCacheDateFormat, period[1])
+		if err != nil {
+			continue
+		}
+
+		day := func(t time.Time) time.Time {
+			return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		}
+		if day(begin).Before(fileBegin) || day(end).After(fileEnd) {
+			continue
+		}
+		if frequency != FrequencyDaily && !day(end).Equal(fileEnd) {
+			continue
+		}
+		return match
+	}
+	return ""
+}
+
+// get the data of symbol between begin and end from its file, downloading
+// it with load and saving it if it isn't cached. Returns the data and when
+// it was retrieved from its provider, i.e. when its file was written.
+func (c *DiskCache) get(kind string, symbol string, metric string, frequency string, begin time.Time, end time.Time, load func() (*dataframe.DataFrame, error)) (*dataframe.DataFrame, time.Time, error) {
+	prefix := c.prefix(kind, symbol, metric, frequency)
+	if fn := c.find(prefix, frequency, begin, end); fn != "" {
+		df, retrieved, err := readFrameFile(fn)
+		if err == nil {
+			recordCache(cacheDisk, true)
+			df, err = trimFrame(df, begin, end)
+			return df, retrieved, err
+		}
+		log.WithFields(log.Fields{
+			"File":             fn,
+			logging.FieldError: err,
+		}).Warn("Cannot read cached data; downloading it again")
+	}
+
+	recordCache(cacheDisk, false)
+	df, err := load()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	retrieved := time.Now()
+
+	fn := fmt.Sprintf("%s%s_%s.csv", prefix, begin.Format(diskCacheDateFormat), end.Format(diskCacheDateFormat))
+	if err := writeFrameFile(fn, df); err != nil {
+		log.WithFields(log.Fields{
+			"File":             fn,
+			logging.FieldError: err,
+		}).Warn("Cannot cache data")
+	}
+	return df, retrieved, nil
+}
+
+// trimFrame rows of df between begin and end
+func trimFrame(df *dataframe.DataFrame, begin time.Time, end time.Time) (*dataframe.DataFrame, error) {
+	dateIdx, err := df.NameToColumn(DateIdx)
+	if err != nil {
+		return nil, err
+	}
+	if begin.IsZero() && end.IsZero() {
+		return df, nil
+	}
+	if end.IsZero() {
+		end = time.Now()
+	}
+	if _, err := dfextras.TimeTrim(context.TODO(), df, dateIdx, begin, end, true); err != nil {
+		return nil, err
+	}
+	return df, nil
+}
+
+// writeFrameFile save df, a date column followed by float64 columns, to fn.
+// The file is written under a temporary name and renamed so concurrent
+// readers never see a partial file.
+func writeFrameFile(fn string, df *dataframe.DataFrame) error {
+	if err := os.MkdirAll(filepath.Dir(fn), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(fn), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := writeFrameCSV(tmp, df); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), fn)
+}
+
+func writeFrameCSV(w io.Writer, df *dataframe.DataFrame) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(df.Names()); err != nil {
+		return err
+	}
+
+	record := make([]string, len(df.Series))
+	for row := 0; row < df.NRows(); row++ {
+		for ii, series := range df.Series {
+			switch s := series.(type) {
+			case *dataframe.SeriesTime:
+				if s.Values[row] == nil {
+					return fmt.Errorf("missing date in row %d", row)
+				}
+				record[ii] = s.Values[row].Format(time.RFC3339)
+			case *dataframe.SeriesFloat64:
+				record[ii] = strconv.FormatFloat(s.Values[row], 'g', -1, 64)
+			default:
+				return fmt.Errorf("cannot cache %s: unsupported series type %s", series.Name(), series.Type())
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// readFrameFile load a frame saved by writeFrameFile and the time the file
+// was written
+func readFrameFile(fn string) (*dataframe.DataFrame, time.Time, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	reader := csv.NewReader(f)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if len(header) == 0 || header[0] != DateIdx {
+		return nil, time.Time{}, fmt.Errorf("%s does not start with a %s column", fn, DateIdx)
+	}
+
+	dates := []time.Time{}
+	values := make([][]float64, len(header))
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+
+		date, err := time.Parse(time.RFC3339, record[0])
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		dates = append(dates, date)
+		for ii := 1; ii < len(record); ii++ {
+			v, err := strconv.ParseFloat(record[ii], 64)
+			if err != nil {
+				v = math.NaN()
+			}
+			values[ii] = append(values[ii], v)
+		}
+	}
+
+	series := []dataframe.Series{dataframe.NewSeriesTime(DateIdx, &dataframe.SeriesInit{Capacity: len(dates)}, dates)}
+	for ii := 1; ii < len(header); ii++ {
+		series = append(series, dataframe.NewSeriesFloat64(header[ii], &dataframe.SeriesInit{Capacity: len(values[ii])}, values[ii]))
+	}
+	return dataframe.NewDataFrame(series...), info.ModTime(), nil
+}
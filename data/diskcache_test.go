@@ -0,0 +1,124 @@
+package data_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	dataframe "github.com/rocketlaunchr/dataframe-go"
+
+	"main/data"
+)
+
+var _ = Describe("DiskCache", func() {
+	var (
+		dir   string
+		cache *data.DiskCache
+	)
+
+	BeforeEach(func() {
+		content, err := ioutil.ReadFile("testdata/VFINX.csv")
+		if err != nil {
+			panic(err)
+		}
+		httpmock.RegisterResponder("GET", "https://api.tiingo.com/tiingo/daily/VFINX/prices?startDate=1980-01-01&endDate=2021-01-01&format=csv&resampleFreq=Monthly&token=TEST",
+			httpmock.NewBytesResponder(200, content))
+
+		dir, err = ioutil.TempDir("", "pvdata")
+		Expect(err).To(BeNil())
+		cache, err = data.NewDiskCache(dir)
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	newManager := func(begin time.Time) data.Manager {
+		manager := data.NewManager(map[string]string{
+			"tiingo": "TEST",
+		})
+		manager.UseDiskCache(cache)
+		manager.Begin = begin
+		manager.End = time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+		manager.Frequency = data.FrequencyMonthly
+		return manager
+	}
+
+	firstRow := func(df *dataframe.DataFrame) map[interface{}]interface{} {
+		return df.Row(0, false, dataframe.SeriesName)
+	}
+
+	It("should serve later runs from the saved files", func() {
+		manager := newManager(time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC))
+		downloaded, err := manager.GetData("VFINX")
+		Expect(err).To(BeNil())
+		Expect(httpmock.GetTotalCallCount()).To(Equal(1))
+
+		files, _ := filepath.Glob(filepath.Join(dir, "security", "*.csv"))
+		Expect(files).To(HaveLen(1))
+
+		// run offline
+		httpmock.Reset()
+		manager = newManager(time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC))
+		cached, err := manager.GetData("VFINX")
+		Expect(err).To(BeNil())
+		Expect(httpmock.GetTotalCallCount()).To(Equal(0))
+
+		// the test data continues past the end of the period
+		inPeriod := 0
+		for _, date := range downloaded.Series[0].(*dataframe.SeriesTime).Values {
+			if !date.After(manager.End) {
+				inPeriod++
+			}
+		}
+		Expect(cached.Names()).To(Equal(downloaded.Names()))
+		Expect(cached.NRows()).To(Equal(inPeriod))
+		Expect(firstRow(cached)).To(Equal(firstRow(downloaded)))
+	})
+
+	It("should report when cached data was downloaded", func() {
+		manager := newManager(time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC))
+		_, err := manager.GetData("VFINX")
+		Expect(err).To(BeNil())
+
+		files, _ := filepath.Glob(filepath.Join(dir, "security", "*.csv"))
+		Expect(files).To(HaveLen(1))
+		downloaded := time.Date(2021, time.January, 4, 12, 0, 0, 0, time.UTC)
+		Expect(os.Chtimes(files[0], downloaded, downloaded)).To(Succeed())
+
+		manager = newManager(time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC))
+		_, err = manager.GetData("VFINX")
+		Expect(err).To(BeNil())
+		Expect(manager.Snapshots()["VFINX"].Equal(downloaded)).To(BeTrue())
+	})
+
+	It("should serve periods within a saved file", func() {
+		manager := newManager(time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC))
+		_, err := manager.GetData("VFINX")
+		Expect(err).To(BeNil())
+
+		httpmock.Reset()
+		manager = newManager(time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC))
+		df, err := manager.GetData("VFINX")
+		Expect(err).To(BeNil())
+		Expect(httpmock.GetTotalCallCount()).To(Equal(0))
+		Expect(firstRow(df)[data.DateIdx]).To(Equal(time.Date(1990, time.January, 31, 0, 0, 0, 0, time.UTC)))
+	})
+
+	It("should download periods outside of the saved files", func() {
+		manager := newManager(time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC))
+		_, err := manager.GetData("VFINX")
+		Expect(err).To(BeNil())
+
+		manager = newManager(time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC))
+		_, err = manager.GetData("VFINX")
+
+		// the period is not mocked
+		Expect(err).NotTo(BeNil())
+	})
+})
@@ -0,0 +1,223 @@
+package data
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	dataframe "github.com/rocketlaunchr/dataframe-go"
+)
+
+var famaFrenchURL = "https://mba.tuck.dartmouth.edu/pages/faculty/ken.french/ftp"
+
+// Fama-French factors served by the $FF namespace, e.g. $FF.SMB
+const (
+	FactorMarket     = "MKT-RF"
+	FactorSize       = "SMB"
+	FactorValue      = "HML"
+	FactorProfitMkt  = "RMW"
+	FactorInvestment = "CMA"
+	FactorMomentum   = "MOM"
+	FactorRiskFree   = "RF"
+)
+
+// famaFrenchDatasets zip file of the Ken French data library containing
+// each factor; the 5 factor file also includes the 3 factors but over a
+// shorter history
+var famaFrenchDatasets = map[string]string{
+	FactorMarket:     "F-F_Research_Data_Factors_CSV.zip",
+	FactorSize:       "F-F_Research_Data_Factors_CSV.zip",
+	FactorValue:      "F-F_Research_Data_Factors_CSV.zip",
+	FactorRiskFree:   "F-F_Research_Data_Factors_CSV.zip",
+	FactorProfitMkt:  "F-F_Research_Data_5_Factors_2x3_CSV.zip",
+	FactorInvestment: "F-F_Research_Data_5_Factors_2x3_CSV.zip",
+	FactorMomentum:   "F-F_Momentum_Factor_CSV.zip",
+}
+
+// famaFrenchColumns column headers of the datasets that differ from the
+// factor's name
+var famaFrenchColumns = map[string]string{
+	FactorMarket:   "Mkt-RF",
+	FactorMomentum: "Mom",
+}
+
+type famaFrench struct {
+	mu       *sync.Mutex
+	datasets map[string]map[string][]factorObservation
+}
+
+// factorObservation monthly return of a factor as a fraction
+type factorObservation struct {
+	date time.Time
+	ret  float64
+}
+
+// NewFamaFrench Create a data provider of the monthly Fama-French factor
+// returns published in the Ken French data library. Each dataset is
+// downloaded once per provider.
+func NewFamaFrench() famaFrench {
+	return famaFrench{
+		mu:       &sync.Mutex{},
+		datasets: make(map[string]map[string][]factorObservation),
+	}
+}
+
+// Provider functions
+
+func (f famaFrench) DataType() string {
+	return "factor"
+}
+
+// GetDataForPeriod monthly returns of a factor between begin and end dated
+// the last day of each month. Returns are fractions, not percentages.
+func (f famaFrench) GetDataForPeriod(symbol string, metric string, frequency string, begin time.Time, end time.Time) (*dataframe.DataFrame, error) {
+	dataset, ok := famaFrenchDatasets[symbol]
+	if !ok {
+		return nil, fmt.Errorf("unknown Fama-French factor: %s", symbol)
+	}
+	if frequency != FrequencyMonthly {
+		return nil, errors.New("Fama-French factors are only available monthly")
+	}
+
+	factors, err := f.dataset(dataset)
+	if err != nil {
+		return nil, err
+	}
+
+	column := symbol
+	if name, ok := famaFrenchColumns[symbol]; ok {
+		column = name
+	}
+	observations, ok := factors[column]
+	if !ok {
+		return nil, fmt.Errorf("%s is missing from %s", symbol, dataset)
+	}
+
+	dates := []interface{}{}
+	vals := []interface{}{}
+	for _, obs := range observations {
+		if (!begin.IsZero() && obs.date.Before(begin)) || (!end.IsZero() && obs.date.After(end)) {
+			continue
+		}
+		dates = append(dates, obs.date)
+		vals = append(vals, obs.ret)
+	}
+
+	return dataframe.NewDataFrame(
+		dataframe.NewSeriesTime(DateIdx, &dataframe.SeriesInit{Capacity: len(dates)}, dates...),
+		dataframe.NewSeriesFloat64(symbol, &dataframe.SeriesInit{Capacity: len(vals)}, vals...),
+	), nil
+}
+
+// dataset download and parse a zip file of the data library, or return
+// the copy parsed earlier
+func (f famaFrench) dataset(name string) (map[string][]factorObservation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if factors, ok := f.datasets[name]; ok {
+		return factors, nil
+	}
+
+	resp, err := providerGet("famafrench", "", fmt.Sprintf("%s/%s", famaFrenchURL, name))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("HTTP request returned invalid status code: %d", resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return nil, err
+	}
+	if len(archive.File) == 0 {
+		return nil, fmt.Errorf("%s is empty", name)
+	}
+	csv, err := archive.File[0].Open()
+	if err != nil {
+		return nil, err
+	}
+	defer csv.Close()
+
+	contents, err := ioutil.ReadAll(csv)
+	if err != nil {
+		return nil, err
+	}
+
+	factors, err := parseFamaFrench(contents)
+	if err != nil {
+		return nil, err
+	}
+	f.datasets[name] = factors
+	return factors, nil
+}
+
+// parseFamaFrench parse the monthly table of a Ken French data library CSV.
+// The files start with a description, followed by a header row beginning
+// with a comma and rows of YYYYMM dates and percent returns; the annual
+// table after the monthly one is ignored. Returns are keyed by the column
+// header and converted to fractions.
+func parseFamaFrench(contents []byte) (map[string][]factorObservation, error) {
+	var columns []string
+	factors := make(map[string][]factorObservation)
+
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if columns == nil {
+			if strings.HasPrefix(line, ",") {
+				columns = strings.Split(line, ",")
+				for ii := range columns {
+					columns[ii] = strings.TrimSpace(columns[ii])
+				}
+			}
+			continue
+		}
+
+		fields := strings.Split(line, ",")
+		date := strings.TrimSpace(fields[0])
+		if len(date) != 6 {
+			// end of the monthly table
+			break
+		}
+		month, err := time.Parse("200601", date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %s: %w", date, err)
+		}
+		// dated the last day of the month
+		month = month.AddDate(0, 1, -1)
+
+		for ii := 1; ii < len(fields) && ii < len(columns); ii++ {
+			ret, err := strconv.ParseFloat(strings.TrimSpace(fields[ii]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s return on %s: %w", columns[ii], date, err)
+			}
+			factors[columns[ii]] = append(factors[columns[ii]], factorObservation{
+				date: month,
+				ret:  ret / 100.0,
+			})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(factors) == 0 {
+		return nil, errors.New("no monthly factor returns found")
+	}
+	return factors, nil
+}
@@ -0,0 +1,86 @@
+package data_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	dataframe "github.com/rocketlaunchr/dataframe-go"
+
+	"main/data"
+)
+
+var _ = Describe("FamaFrench", func() {
+	var (
+		dataProxy data.Manager
+	)
+
+	const url = "https://mba.tuck.dartmouth.edu/pages/faculty/ken.french/ftp/F-F_Research_Data_Factors_CSV.zip"
+
+	// zipped copy of contents as published in the Ken French data library
+	zipped := func(contents string) []byte {
+		var buf bytes.Buffer
+		archive := zip.NewWriter(&buf)
+		f, err := archive.Create("F-F_Research_Data_Factors.CSV")
+		Expect(err).To(BeNil())
+		_, err = f.Write([]byte(contents))
+		Expect(err).To(BeNil())
+		Expect(archive.Close()).To(Succeed())
+		return buf.Bytes()
+	}
+
+	BeforeEach(func() {
+		dataProxy = data.NewManager(map[string]string{})
+		dataProxy.Begin = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+		dataProxy.End = time.Date(2020, time.December, 31, 0, 0, 0, 0, time.UTC)
+		dataProxy.Frequency = data.FrequencyMonthly
+	})
+
+	Describe("When downloading factors", func() {
+		It("should parse the monthly returns as fractions", func() {
+			httpmock.RegisterResponder("GET", url, httpmock.NewBytesResponder(200, zipped(
+				"This file was created by CMPT_ME_BEME_RETS using the 202012 CRSP database.\r\n"+
+					"The 1-month TBill return is from Ibbotson and Associates, Inc.\r\n"+
+					"\r\n"+
+					",Mkt-RF,SMB,HML,RF\r\n"+
+					"201912,   2.77,   0.68,   1.78,   0.14\r\n"+
+					"202001,  -0.11,  -3.12,  -6.25,   0.13\r\n"+
+					"202002,  -8.13,   1.07,  -4.00,   0.12\r\n"+
+					"\r\n"+
+					" Annual Factors: January-December \r\n"+
+					",Mkt-RF,SMB,HML,RF\r\n"+
+					"  2019,  28.28,  -6.14, -10.34,   2.15\r\n")))
+
+			df, err := dataProxy.GetData("$FF.MKT-RF")
+			Expect(err).To(BeNil())
+			Expect(df.NRows()).To(Equal(2))
+
+			row := df.Row(0, false, dataframe.SeriesName)
+			Expect(row[data.DateIdx]).To(Equal(time.Date(2020, time.January, 31, 0, 0, 0, 0, time.UTC)))
+			Expect(row["MKT-RF"]).Should(BeNumerically("~", -0.0011, 1e-12))
+
+			df, err = dataProxy.GetData("$FF.RF")
+			Expect(err).To(BeNil())
+			row = df.Row(1, false, dataframe.SeriesName)
+			Expect(row[data.DateIdx]).To(Equal(time.Date(2020, time.February, 29, 0, 0, 0, 0, time.UTC)))
+			Expect(row["RF"]).Should(BeNumerically("~", 0.0012, 1e-12))
+
+			// both factors are read from the same download
+			Expect(httpmock.GetCallCountInfo()["GET "+url]).To(Equal(1))
+		})
+
+		It("should reject unknown factors", func() {
+			_, err := dataProxy.GetData("$FF.XYZ")
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("should only provide monthly returns", func() {
+			dataProxy.Frequency = data.FrequencyDaily
+			_, err := dataProxy.GetData("$FF.SMB")
+			Expect(err).NotTo(BeNil())
+		})
+	})
+})
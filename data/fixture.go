@@ -0,0 +1,124 @@
+package data
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FixtureTransport an http.RoundTripper for tests that replays provider
+// responses saved as fixture files under Dir. When Record is set, requests
+// are sent to the provider instead and successful responses are saved, so
+// fixtures can be refreshed rather than edited by hand whenever a request
+// changes. Fixtures are named by the host, path, and sorted query of the
+// request, without the token and the Ignore parameters, e.g. end dates
+// that change every day.
+type FixtureTransport struct {
+	Dir    string
+	Record bool
+	Ignore []string
+
+	// Next transport requests are sent with when recording; defaults to the
+	// transport the http package started with
+	Next http.RoundTripper
+}
+
+// realTransport the default transport before tests replace it with a mock
+var realTransport = http.DefaultTransport
+
+// NewFixtureTransport a transport replaying the fixtures under dir, or
+// recording them when DATA_FIXTURES is set to record. Recorded tiingo
+// requests use the token in TIINGO_TOKEN rather than the test's token.
+func NewFixtureTransport(dir string, ignore ...string) *FixtureTransport {
+	return &FixtureTransport{
+		Dir:    dir,
+		Record: os.Getenv("DATA_FIXTURES") == "record",
+		Ignore: ignore,
+	}
+}
+
+// FixturePath file the response to req is saved in
+func (t *FixtureTransport) FixturePath(req *http.Request) string {
+	query := req.URL.Query()
+	query.Del("token")
+	for _, param := range t.Ignore {
+		query.Del(param)
+	}
+
+	name := query.Encode()
+	if name == "" {
+		name = "index"
+	}
+	return filepath.Join(t.Dir, req.URL.Host, filepath.FromSlash(req.URL.Path), name)
+}
+
+// RoundTrip replay or record the response to req. With httpmock it can be
+// used as a responder, e.g. httpmock.RegisterNoResponder(t.RoundTrip).
+func (t *FixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	fn := t.FixturePath(req)
+	if t.Record {
+		return t.record(req, fn)
+	}
+
+	body, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return nil, fmt.Errorf("no fixture for %s %s%s (run with DATA_FIXTURES=record to record it): %w", req.Method, req.URL.Host, req.URL.Path, err)
+	}
+	return fixtureResponse(req, http.StatusOK, body), nil
+}
+
+// record send req to the provider and save a successful response to fn
+func (t *FixtureTransport) record(req *http.Request, fn string) (*http.Response, error) {
+	next := t.Next
+	if next == nil {
+		next = realTransport
+	}
+
+	if token := os.Getenv("TIINGO_TOKEN"); token != "" && strings.Contains(req.URL.Host, "tiingo.com") {
+		query := req.URL.Query()
+		query.Set("token", token)
+		u := *req.URL
+		u.RawQuery = query.Encode()
+		req = req.Clone(req.Context())
+		req.URL = &u
+	}
+
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 300 {
+		if err := os.MkdirAll(filepath.Dir(fn), 0755); err != nil {
+			return nil, err
+		}
+		if err := ioutil.WriteFile(fn, body, 0644); err != nil {
+			return nil, err
+		}
+	}
+	return fixtureResponse(req, resp.StatusCode, body), nil
+}
+
+func fixtureResponse(req *http.Request, status int, body []byte) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{},
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
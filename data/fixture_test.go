@@ -0,0 +1,100 @@
+package data_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"main/data"
+)
+
+// stubTransport responds to every request with body and remembers the
+// requests it was sent
+type stubTransport struct {
+	status   int
+	body     string
+	requests []*http.Request
+}
+
+func (t *stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests = append(t.requests, req)
+	return &http.Response{
+		StatusCode: t.status,
+		Body:       ioutil.NopCloser(bytes.NewReader([]byte(t.body))),
+		Request:    req,
+	}, nil
+}
+
+var _ = Describe("FixtureTransport", func() {
+	var (
+		dir      string
+		fixtures *data.FixtureTransport
+	)
+
+	const url = "https://api.tiingo.com/tiingo/daily/XYZ/prices?startDate=2021-01-01&endDate=2021-02-01&format=csv&resampleFreq=Daily&token=TEST"
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "fixtures")
+		Expect(err).To(BeNil())
+		fixtures = data.NewFixtureTransport(dir, "endDate")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+		os.Unsetenv("TIINGO_TOKEN")
+	})
+
+	get := func(url string) (*http.Response, error) {
+		client := &http.Client{Transport: fixtures}
+		return client.Get(url)
+	}
+
+	It("should name fixtures by the request without the token or ignored parameters", func() {
+		req, _ := http.NewRequest("GET", url, nil)
+		Expect(fixtures.FixturePath(req)).To(Equal(filepath.Join(dir, "api.tiingo.com", "tiingo", "daily", "XYZ", "prices", "format=csv&resampleFreq=Daily&startDate=2021-01-01")))
+	})
+
+	It("should fail requests without a fixture", func() {
+		_, err := get(url)
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("should replay recorded responses", func() {
+		stub := &stubTransport{status: 200, body: "date,close\n2021-01-04,10.0\n"}
+		os.Setenv("TIINGO_TOKEN", "REAL")
+		fixtures.Record = true
+		fixtures.Next = stub
+
+		resp, err := get(url)
+		Expect(err).To(BeNil())
+		Expect(resp.StatusCode).To(Equal(200))
+		Expect(stub.requests).To(HaveLen(1))
+		Expect(stub.requests[0].URL.Query().Get("token")).To(Equal("REAL"))
+
+		fixtures.Record = false
+		resp, err = get("https://api.tiingo.com/tiingo/daily/XYZ/prices?startDate=2021-01-01&endDate=2021-03-01&format=csv&resampleFreq=Daily&token=TEST")
+		Expect(err).To(BeNil())
+		body, _ := ioutil.ReadAll(resp.Body)
+		Expect(string(body)).To(Equal(stub.body))
+		Expect(stub.requests).To(HaveLen(1))
+	})
+
+	It("should not record failed responses", func() {
+		fixtures.Record = true
+		fixtures.Next = &stubTransport{status: 404, body: "not found"}
+
+		resp, err := get(url)
+		Expect(err).To(BeNil())
+		Expect(resp.StatusCode).To(Equal(404))
+
+		req, _ := http.NewRequest("GET", url, nil)
+		_, err = os.Stat(fixtures.FixturePath(req))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+})
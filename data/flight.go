@@ -0,0 +1,71 @@
+package data
+
+import (
+	"sync"
+	"time"
+
+	dataframe "github.com/rocketlaunchr/dataframe-go"
+)
+
+// downloads in progress, shared by every manager so portfolios computed at
+// the same time wait for one download of the data they have in common
+var downloads = &flightGroup{calls: make(map[flightKey]*flightCall)}
+
+type flightKey struct {
+	kind      string
+	symbol    string
+	metric    string
+	frequency string
+	begin     int64
+	end       int64
+}
+
+type flightCall struct {
+	wg        sync.WaitGroup
+	df        *dataframe.DataFrame
+	retrieved time.Time
+	err       error
+	dups      int
+}
+
+// flightGroup coalesces identical concurrent downloads
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[flightKey]*flightCall
+}
+
+// do download with load unless an identical download is in progress, in
+// which case wait for it and share its result. When the result is shared
+// each caller receives a copy it is free to modify. Returns the data and
+// when it was retrieved from its provider.
+func (g *flightGroup) do(key flightKey, load func() (*dataframe.DataFrame, time.Time, error)) (*dataframe.DataFrame, time.Time, error) {
+	g.mu.Lock()
+	if call, ok := g.calls[key]; ok {
+		call.dups++
+		g.mu.Unlock()
+		call.wg.Wait()
+		if call.err != nil {
+			return nil, time.Time{}, call.err
+		}
+		return call.df.Copy(), call.retrieved, nil
+	}
+
+	call := &flightCall{}
+	call.wg.Add(1)
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	call.df, call.retrieved, call.err = load()
+
+	// no one can join once the call is removed
+	g.mu.Lock()
+	delete(g.calls, key)
+	shared := call.dups > 0
+	g.mu.Unlock()
+	call.wg.Done()
+
+	if call.err != nil || !shared {
+		return call.df, call.retrieved, call.err
+	}
+	return call.df.Copy(), call.retrieved, nil
+}
@@ -0,0 +1,76 @@
+package data_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	dataframe "github.com/rocketlaunchr/dataframe-go"
+
+	"main/data"
+)
+
+var _ = Describe("Concurrent downloads", func() {
+	BeforeEach(func() {
+		content, err := ioutil.ReadFile("testdata/VFINX.csv")
+		if err != nil {
+			panic(err)
+		}
+
+		// slow enough that every manager asks before the first download ends
+		httpmock.RegisterResponder("GET", "https://api.tiingo.com/tiingo/daily/VFINX/prices?startDate=1980-01-01&endDate=2021-01-01&format=csv&resampleFreq=Monthly&token=TEST",
+			func(req *http.Request) (*http.Response, error) {
+				time.Sleep(100 * time.Millisecond)
+				return httpmock.NewBytesResponse(200, content), nil
+			})
+	})
+
+	newManager := func() data.Manager {
+		manager := data.NewManager(map[string]string{
+			"tiingo": "TEST",
+		})
+		manager.Begin = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+		manager.End = time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+		manager.Frequency = data.FrequencyMonthly
+		return manager
+	}
+
+	It("should share one download between identical requests", func() {
+		results := make([]*dataframe.DataFrame, 5)
+		var wg sync.WaitGroup
+		for ii := range results {
+			wg.Add(1)
+			go func(ii int) {
+				defer GinkgoRecover()
+				defer wg.Done()
+				manager := newManager()
+				df, err := manager.GetData("VFINX")
+				Expect(err).To(BeNil())
+				results[ii] = df
+			}(ii)
+		}
+		wg.Wait()
+
+		Expect(httpmock.GetTotalCallCount()).To(Equal(1))
+
+		// each caller has its own copy
+		results[0].Series[1].Rename("CHANGED")
+		for _, df := range results[1:] {
+			Expect(df.Names()).To(Equal([]string{data.DateIdx, "VFINX"}))
+			Expect(df.NRows()).To(Equal(results[0].NRows()))
+		}
+	})
+
+	It("should download again once the first download is done", func() {
+		manager := newManager()
+		_, err := manager.GetData("VFINX")
+		Expect(err).To(BeNil())
+		_, err = manager.GetData("VFINX")
+		Expect(err).To(BeNil())
+		Expect(httpmock.GetTotalCallCount()).To(Equal(2))
+	})
+})
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"io/ioutil"
 	"math"
-	"net/http"
 	"strconv"
 	"time"
 
@@ -36,17 +35,17 @@ func (f fred) GetDataForPeriod(symbol string, frequency string,
 	url := fmt.Sprintf("%s/graph/fredgraph.csv?mode=fred&id=%s&cosd=%s&coed=%s&fq=%s&fam=avg", fredURL, symbol, begin.Format("2006-01-02"), end.Format("2006-01-02"), frequency)
 	//log.Printf("Download from FRED: %s\n", url)
 
-	resp, err := http.Get(url)
+	resp, err := providerGet("fred", "", url)
 
 	if err != nil {
 		return nil, err
 	}
 
+	defer resp.Body.Close()
 	if resp.StatusCode >= 400 {
 		return nil, fmt.Errorf("HTTP request returned invalid status code: %d", resp.StatusCode)
 	}
 
-	defer resp.Body.Close()
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
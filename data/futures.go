@@ -0,0 +1,239 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	dataframe "github.com/rocketlaunchr/dataframe-go"
+)
+
+// futuresMonthCodes exchange letter codes of contract months
+var futuresMonthCodes = map[time.Month]byte{
+	time.January:   'F',
+	time.February:  'G',
+	time.March:     'H',
+	time.April:     'J',
+	time.May:       'K',
+	time.June:      'M',
+	time.July:      'N',
+	time.August:    'Q',
+	time.September: 'U',
+	time.October:   'V',
+	time.November:  'X',
+	time.December:  'Z',
+}
+
+var quarterlyContracts = []time.Month{time.March, time.June, time.September, time.December}
+
+// RollSchedule which contracts of a futures market a continuous series holds
+// and when it moves to the next one
+type RollSchedule struct {
+	// Root symbol of the market, e.g. ES
+	Root string
+
+	// Months contract months that are traded, in calendar order
+	Months []time.Month
+
+	// RollMonthOffset month the position is rolled in relative to the
+	// contract month; -1 rolls in the month before the contract month
+	RollMonthOffset int
+
+	// RollDay day of the roll month the series moves to the next contract
+	RollDay int
+}
+
+// RollSchedules built-in roll schedules keyed by root symbol
+var RollSchedules = map[string]RollSchedule{
+	// equity indexes expire on the third Friday of the contract month
+	"ES": {Root: "ES", Months: quarterlyContracts, RollMonthOffset: 0, RollDay: 10},
+	"NQ": {Root: "NQ", Months: quarterlyContracts, RollMonthOffset: 0, RollDay: 10},
+
+	// treasuries enter their delivery period at the start of the contract
+	// month
+	"ZN": {Root: "ZN", Months: quarterlyContracts, RollMonthOffset: -1, RollDay: 25},
+	"ZB": {Root: "ZB", Months: quarterlyContracts, RollMonthOffset: -1, RollDay: 25},
+
+	"GC": {Root: "GC", Months: []time.Month{time.February, time.April, time.June, time.August, time.October, time.December}, RollMonthOffset: -1, RollDay: 25},
+
+	// crude oil expires around the 20th of the month before delivery
+	"CL": {Root: "CL", Months: []time.Month{time.January, time.February, time.March, time.April, time.May, time.June, time.July, time.August, time.September, time.October, time.November, time.December}, RollMonthOffset: -1, RollDay: 10},
+}
+
+// ContractPeriod a contract and the dates a continuous series holds it;
+// Start is inclusive and End, the roll date, is exclusive
+type ContractPeriod struct {
+	Contract string
+	Start    time.Time
+	End      time.Time
+}
+
+// ContractSymbol symbol of the contract of a market expiring in month of
+// year, e.g. ESH21
+func ContractSymbol(root string, month time.Month, year int) string {
+	return fmt.Sprintf("%s%c%02d", root, futuresMonthCodes[month], year%100)
+}
+
+// Periods contracts held by a continuous series between begin and end, in
+// order. The first period starts at begin and the last ends after end.
+func (s RollSchedule) Periods(begin time.Time, end time.Time) []ContractPeriod {
+	type roll struct {
+		contract string
+		date     time.Time
+	}
+
+	rolls := []roll{}
+	for year := begin.Year() - 1; year <= end.Year()+1; year++ {
+		for _, month := range s.Months {
+			rolls = append(rolls, roll{
+				contract: ContractSymbol(s.Root, month, year),
+				date:     time.Date(year, month+time.Month(s.RollMonthOffset), s.RollDay, 0, 0, 0, 0, time.UTC),
+			})
+		}
+	}
+	sort.Slice(rolls, func(i, j int) bool {
+		return rolls[i].date.Before(rolls[j].date)
+	})
+
+	periods := []ContractPeriod{}
+	start := begin
+	for _, r := range rolls {
+		if !r.date.After(begin) {
+			continue
+		}
+		periods = append(periods, ContractPeriod{
+			Contract: r.contract,
+			Start:    start,
+			End:      r.date,
+		})
+		if r.date.After(end) {
+			break
+		}
+		start = r.date
+	}
+
+	return periods
+}
+
+type continuousFutures struct {
+	contracts Provider
+}
+
+// NewContinuousFutures Create a data provider of back-adjusted continuous
+// futures series for the markets in RollSchedules. Daily closes of
+// individual contracts, e.g. ESH21, are requested from contracts.
+func NewContinuousFutures(contracts Provider) continuousFutures {
+	return continuousFutures{
+		contracts: contracts,
+	}
+}
+
+// Provider functions
+
+func (f continuousFutures) DataType() string {
+	return "futures"
+}
+
+// GetDataForPeriod continuous series of the market with the given root
+// symbol. Contracts are rolled according to the market's RollSchedule and
+// earlier contracts are back-adjusted by the price gap on each roll date so
+// returns are not distorted by the roll. Back-adjusted prices can be
+// negative far in the past.
+func (f continuousFutures) GetDataForPeriod(symbol string, metric string, frequency string, begin time.Time, end time.Time) (*dataframe.DataFrame, error) {
+	schedule, ok := RollSchedules[symbol]
+	if !ok {
+		return nil, fmt.Errorf("no roll schedule for futures market: %s", symbol)
+	}
+	if metric != MetricClose && metric != MetricAdjustedClose {
+		return nil, errors.New("Un-supported metric")
+	}
+	if begin.IsZero() || end.IsZero() {
+		return nil, errors.New("continuous futures require a begin and end date")
+	}
+
+	dates := []time.Time{}
+	vals := []float64{}
+	var prevDates []time.Time
+	var prevVals []float64
+	for _, period := range schedule.Periods(begin, end) {
+		// the contract is requested through its roll date to measure the gap
+		// to the next contract
+		df, err := f.contracts.GetDataForPeriod(period.Contract, MetricClose, FrequencyDaily, period.Start, period.End)
+		if err != nil {
+			return nil, err
+		}
+		contractDates, contractVals, err := seriesValues(df, period.Contract)
+		if err != nil {
+			return nil, err
+		}
+
+		// back-adjust everything held so far by the gap between the contracts
+		if prevDates != nil {
+			gap := math.NaN()
+			for ii := range contractDates {
+				if !contractDates[ii].Before(period.Start) {
+					gap = contractVals[ii] - rollPrice(prevDates, prevVals, contractDates[ii])
+					break
+				}
+			}
+			if math.IsNaN(gap) {
+				return nil, fmt.Errorf("no price for contract %s on roll date", period.Contract)
+			}
+			for ii := range vals {
+				vals[ii] += gap
+			}
+		}
+
+		for ii := range contractDates {
+			d := contractDates[ii]
+			if d.Before(period.Start) || !d.Before(period.End) || d.After(end) {
+				continue
+			}
+			dates = append(dates, d)
+			vals = append(vals, contractVals[ii])
+		}
+		prevDates, prevVals = contractDates, contractVals
+	}
+
+	return newPeriodFrame(symbol, dates, vals, frequency), nil
+}
+
+// rollPrice price of the expiring contract on the roll date, or its last
+// price before it
+func rollPrice(dates []time.Time, vals []float64, rollDate time.Time) float64 {
+	price := math.NaN()
+	for ii := range dates {
+		if dates[ii].After(rollDate) {
+			break
+		}
+		price = vals[ii]
+	}
+	return price
+}
+
+// seriesValues dates and values of symbol in df
+func seriesValues(df *dataframe.DataFrame, symbol string) ([]time.Time, []float64, error) {
+	dateIdx, err := df.NameToColumn(DateIdx)
+	if err != nil {
+		return nil, nil, err
+	}
+	valueIdx, err := df.NameToColumn(symbol)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nrows := df.NRows()
+	dates := make([]time.Time, 0, nrows)
+	vals := make([]float64, 0, nrows)
+	for ii := 0; ii < nrows; ii++ {
+		val, ok := df.Series[valueIdx].Value(ii).(float64)
+		if !ok || math.IsNaN(val) {
+			continue
+		}
+		dates = append(dates, df.Series[dateIdx].Value(ii).(time.Time))
+		vals = append(vals, val)
+	}
+	return dates, vals, nil
+}
@@ -0,0 +1,92 @@
+package data_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	dataframe "github.com/rocketlaunchr/dataframe-go"
+
+	"main/data"
+)
+
+// contractPrices serves daily closes of individual futures contracts
+type contractPrices map[string]map[time.Time]float64
+
+func (c contractPrices) DataType() string {
+	return "contracts"
+}
+
+func (c contractPrices) GetDataForPeriod(symbol string, metric string, frequency string, begin time.Time, end time.Time) (*dataframe.DataFrame, error) {
+	dates := []interface{}{}
+	vals := []interface{}{}
+	for day := begin; !day.After(end); day = day.AddDate(0, 0, 1) {
+		if val, ok := c[symbol][day]; ok {
+			dates = append(dates, day)
+			vals = append(vals, val)
+		}
+	}
+	return dataframe.NewDataFrame(
+		dataframe.NewSeriesTime(data.DateIdx, nil, dates...),
+		dataframe.NewSeriesFloat64(symbol, nil, vals...),
+	), nil
+}
+
+var _ = Describe("Futures", func() {
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2021, month, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	Describe("When scheduling rolls", func() {
+		It("should hold the front contract until its roll date", func() {
+			periods := data.RollSchedules["ES"].Periods(day(time.February, 1), day(time.July, 1))
+			Expect(periods).To(Equal([]data.ContractPeriod{
+				{Contract: "ESH21", Start: day(time.February, 1), End: day(time.March, 10)},
+				{Contract: "ESM21", Start: day(time.March, 10), End: day(time.June, 10)},
+				{Contract: "ESU21", Start: day(time.June, 10), End: day(time.September, 10)},
+			}))
+		})
+
+		It("should roll in the month before the contract month", func() {
+			periods := data.RollSchedules["ZN"].Periods(day(time.February, 1), day(time.March, 1))
+			Expect(periods).To(HaveLen(2))
+			Expect(periods[0].Contract).To(Equal("ZNH21"))
+			Expect(periods[0].End).To(Equal(day(time.February, 25)))
+			Expect(periods[1].Contract).To(Equal("ZNM21"))
+		})
+	})
+
+	Describe("When building a continuous series", func() {
+		var (
+			dataProxy data.Manager
+		)
+
+		BeforeEach(func() {
+			dataProxy = data.NewManager(map[string]string{})
+			dataProxy.RegisterDataProvider(data.NewContinuousFutures(contractPrices{
+				"ESH21": {day(time.March, 8): 100, day(time.March, 9): 101, day(time.March, 10): 102, day(time.March, 11): 103},
+				"ESM21": {day(time.March, 10): 99, day(time.March, 11): 100, day(time.March, 12): 101},
+			}))
+			dataProxy.Begin = day(time.March, 8)
+			dataProxy.End = day(time.March, 12)
+			dataProxy.Frequency = data.FrequencyDaily
+		})
+
+		It("should back-adjust prices by the gap on the roll date", func() {
+			df, err := dataProxy.GetData("$FUT.ES")
+			Expect(err).To(BeNil())
+
+			vals := []float64{}
+			for ii := 0; ii < df.NRows(); ii++ {
+				row := df.Row(ii, false, dataframe.SeriesName)
+				vals = append(vals, row["ES"].(float64))
+			}
+			Expect(vals).To(Equal([]float64{97, 98, 99, 100, 101}))
+		})
+
+		It("should reject markets without a roll schedule", func() {
+			_, err := dataProxy.GetData("$FUT.XX")
+			Expect(err).NotTo(BeNil())
+		})
+	})
+})
@@ -0,0 +1,134 @@
+package data
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// HTTPConfig settings of the client every data provider uses to download
+// data
+type HTTPConfig struct {
+	// Timeout limit on a whole request, including reading the response
+	Timeout time.Duration
+
+	// DialTimeout limit on establishing a connection
+	DialTimeout time.Duration
+
+	// IdleConnTimeout how long an unused keep-alive connection is kept open
+	IdleConnTimeout time.Duration
+
+	// MaxIdleConnsPerHost keep-alive connections kept open to each provider
+	MaxIdleConnsPerHost int
+
+	// MaxConnsPerHost limit on connections to each provider; requests beyond
+	// it wait for a connection. 0 means no limit.
+	MaxConnsPerHost int
+
+	// Proxy URL of the proxy requests are sent through; when empty the
+	// HTTP_PROXY and HTTPS_PROXY environment variables are used
+	Proxy string
+}
+
+// DefaultHTTPConfig settings used unless ConfigureHTTP is called
+var DefaultHTTPConfig = HTTPConfig{
+	Timeout:             time.Minute,
+	DialTimeout:         10 * time.Second,
+	IdleConnTimeout:     90 * time.Second,
+	MaxIdleConnsPerHost: 32,
+	MaxConnsPerHost:     64,
+}
+
+// httpClient client shared by all providers so connections are reused.
+// Until ConfigureHTTP is called it uses the default transport with the
+// default timeout.
+var httpClient = &http.Client{Timeout: DefaultHTTPConfig.Timeout}
+
+// HTTPConfigFromEnv DefaultHTTPConfig overridden by the environment:
+// DATA_HTTP_TIMEOUT and DATA_HTTP_DIAL_TIMEOUT (durations such as 30s),
+// DATA_HTTP_MAX_IDLE_CONNS_PER_HOST, DATA_HTTP_MAX_CONNS_PER_HOST, and
+// DATA_HTTP_PROXY. Invalid values are logged and ignored.
+func HTTPConfigFromEnv() HTTPConfig {
+	cfg := DefaultHTTPConfig
+	cfg.Timeout = envDuration("DATA_HTTP_TIMEOUT", cfg.Timeout)
+	cfg.DialTimeout = envDuration("DATA_HTTP_DIAL_TIMEOUT", cfg.DialTimeout)
+	cfg.MaxIdleConnsPerHost = envInt("DATA_HTTP_MAX_IDLE_CONNS_PER_HOST", cfg.MaxIdleConnsPerHost)
+	cfg.MaxConnsPerHost = envInt("DATA_HTTP_MAX_CONNS_PER_HOST", cfg.MaxConnsPerHost)
+	cfg.Proxy = os.Getenv("DATA_HTTP_PROXY")
+	return cfg
+}
+
+func envDuration(name string, def time.Duration) time.Duration {
+	val := os.Getenv(name)
+	if val == "" {
+		return def
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil || d <= 0 {
+		log.Warnf("%s must be a positive duration, using %s", name, def)
+		return def
+	}
+	return d
+}
+
+func envInt(name string, def int) int {
+	val := os.Getenv(name)
+	if val == "" {
+		return def
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil || n < 0 {
+		log.Warnf("%s must be a non-negative integer, using %d", name, def)
+		return def
+	}
+	return n
+}
+
+// NewHTTPClient create a client with its own connection pool configured by
+// cfg
+func NewHTTPClient(cfg HTTPConfig) (*http.Client, error) {
+	proxy := http.ProxyFromEnvironment
+	if cfg.Proxy != "" {
+		proxyURL, err := url.Parse(cfg.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	transport := &http.Transport{
+		Proxy: proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   cfg.DialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       cfg.MaxConnsPerHost,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   cfg.Timeout,
+	}, nil
+}
+
+// ConfigureHTTP use a client configured by cfg for all providers. Call it
+// before downloading any data.
+func ConfigureHTTP(cfg HTTPConfig) error {
+	client, err := NewHTTPClient(cfg)
+	if err != nil {
+		return err
+	}
+	httpClient = client
+	return nil
+}
@@ -0,0 +1,77 @@
+package data_test
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"main/data"
+)
+
+var _ = Describe("HTTP", func() {
+	envVars := []string{"DATA_HTTP_TIMEOUT", "DATA_HTTP_DIAL_TIMEOUT", "DATA_HTTP_MAX_IDLE_CONNS_PER_HOST", "DATA_HTTP_MAX_CONNS_PER_HOST", "DATA_HTTP_PROXY"}
+
+	AfterEach(func() {
+		for _, name := range envVars {
+			os.Unsetenv(name)
+		}
+	})
+
+	Describe("When reading the configuration", func() {
+		It("should use the defaults", func() {
+			Expect(data.HTTPConfigFromEnv()).To(Equal(data.DefaultHTTPConfig))
+		})
+
+		It("should override the defaults with the environment", func() {
+			os.Setenv("DATA_HTTP_TIMEOUT", "30s")
+			os.Setenv("DATA_HTTP_MAX_CONNS_PER_HOST", "8")
+			os.Setenv("DATA_HTTP_PROXY", "http://proxy.local:3128")
+
+			cfg := data.HTTPConfigFromEnv()
+			Expect(cfg.Timeout).To(Equal(30 * time.Second))
+			Expect(cfg.MaxConnsPerHost).To(Equal(8))
+			Expect(cfg.Proxy).To(Equal("http://proxy.local:3128"))
+			Expect(cfg.DialTimeout).To(Equal(data.DefaultHTTPConfig.DialTimeout))
+		})
+
+		It("should ignore invalid values", func() {
+			os.Setenv("DATA_HTTP_TIMEOUT", "forever")
+			os.Setenv("DATA_HTTP_MAX_IDLE_CONNS_PER_HOST", "-1")
+
+			cfg := data.HTTPConfigFromEnv()
+			Expect(cfg.Timeout).To(Equal(data.DefaultHTTPConfig.Timeout))
+			Expect(cfg.MaxIdleConnsPerHost).To(Equal(data.DefaultHTTPConfig.MaxIdleConnsPerHost))
+		})
+	})
+
+	Describe("When creating a client", func() {
+		It("should apply the configuration", func() {
+			cfg := data.DefaultHTTPConfig
+			cfg.Proxy = "http://proxy.local:3128"
+
+			client, err := data.NewHTTPClient(cfg)
+			Expect(err).To(BeNil())
+			Expect(client.Timeout).To(Equal(time.Minute))
+
+			transport := client.Transport.(*http.Transport)
+			Expect(transport.MaxConnsPerHost).To(Equal(64))
+			Expect(transport.MaxIdleConnsPerHost).To(Equal(32))
+
+			req, _ := http.NewRequest("GET", "https://api.tiingo.com", nil)
+			proxy, err := transport.Proxy(req)
+			Expect(err).To(BeNil())
+			Expect(proxy.Host).To(Equal("proxy.local:3128"))
+		})
+
+		It("should reject an invalid proxy", func() {
+			cfg := data.DefaultHTTPConfig
+			cfg.Proxy = "://proxy"
+
+			_, err := data.NewHTTPClient(cfg)
+			Expect(err).NotTo(BeNil())
+		})
+	})
+})
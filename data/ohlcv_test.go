@@ -0,0 +1,79 @@
+package data_test
+
+import (
+	"io/ioutil"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	dataframe "github.com/rocketlaunchr/dataframe-go"
+
+	"main/data"
+)
+
+var _ = Describe("OHLCV", func() {
+	var (
+		dataProxy data.Manager
+	)
+
+	BeforeEach(func() {
+		content, err := ioutil.ReadFile("testdata/VFINX.csv")
+		if err != nil {
+			panic(err)
+		}
+		httpmock.RegisterResponder("GET", "https://api.tiingo.com/tiingo/daily/VFINX/prices?startDate=1980-01-01&endDate=2021-01-01&format=csv&resampleFreq=Monthly&token=TEST",
+			httpmock.NewBytesResponder(200, content))
+
+		dataProxy = data.NewManager(map[string]string{
+			"tiingo": "TEST",
+		})
+		dataProxy.Begin = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+		dataProxy.End = time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+		dataProxy.Frequency = data.FrequencyMonthly
+	})
+
+	firstRow := func(df *dataframe.DataFrame) map[interface{}]interface{} {
+		return df.Row(0, false, dataframe.SeriesName)
+	}
+
+	It("should return every metric of a security", func() {
+		df, err := dataProxy.GetOHLCV("VFINX")
+		Expect(err).To(BeNil())
+		Expect(df.Names()).To(Equal(append([]string{data.DateIdx}, data.OHLCVMetrics...)))
+
+		row := firstRow(df)
+		Expect(row[data.DateIdx]).To(Equal(time.Date(1980, time.January, 31, 0, 0, 0, 0, time.UTC)))
+		Expect(row[data.MetricOpen]).To(Equal(14.34))
+		Expect(row[data.MetricClose]).To(Equal(15.51))
+		Expect(row[data.MetricAdjustedClose]).Should(BeNumerically("~", 5.1017, 1e-4))
+		Expect(httpmock.GetTotalCallCount()).To(Equal(1))
+	})
+
+	It("should return the requested metrics from a single download", func() {
+		df, err := dataProxy.GetMetrics("VFINX", data.MetricHigh, data.MetricLow, data.MetricHigh)
+		Expect(err).To(BeNil())
+		Expect(df.Names()).To(Equal([]string{data.DateIdx, data.MetricHigh, data.MetricLow}))
+
+		row := firstRow(df)
+		Expect(row[data.MetricHigh]).To(Equal(15.65))
+		Expect(row[data.MetricLow]).To(Equal(14.27))
+		Expect(httpmock.GetTotalCallCount()).To(Equal(1))
+	})
+
+	It("should reject unknown metrics", func() {
+		_, err := dataProxy.GetMetrics("VFINX", "Sentiment")
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("should share a cached download", func() {
+		cache := data.NewPriceCache(dataProxy.Begin, dataProxy.End)
+		dataProxy.UseCache(cache)
+
+		_, err := dataProxy.GetMetrics("VFINX", data.MetricClose)
+		Expect(err).To(BeNil())
+		_, err = dataProxy.GetMetrics("VFINX", data.MetricVolume)
+		Expect(err).To(BeNil())
+		Expect(httpmock.GetTotalCallCount()).To(Equal(1))
+	})
+})
@@ -1,7 +1,8 @@
 package data
 
 import (
-	"errors"
+	"fmt"
+	"main/logging"
 	"math"
 	"strings"
 	"time"
@@ -16,6 +17,12 @@ type Provider interface {
 	GetDataForPeriod(symbol string, metric string, frequency string, begin time.Time, end time.Time) (*dataframe.DataFrame, error)
 }
 
+// MultiMetricProvider a provider that returns every metric of a symbol in
+// a single request
+type MultiMetricProvider interface {
+	GetOHLCVForPeriod(symbol string, frequency string, begin time.Time, end time.Time) (*dataframe.DataFrame, error)
+}
+
 type DateProvider interface {
 	LastTradingDayOfWeek(t time.Time) (time.Time, error)
 	LastTradingDayOfMonth(t time.Time) (time.Time, error)
@@ -43,17 +50,45 @@ const (
 	MetricAdjustedLow   = "AdjustedLow"
 	MetricAdjustedHigh  = "AdjustedHigh"
 	MetricAdjustedClose = "AdjustedClose"
+	MetricSplitFactor   = "SplitFactor"
+	MetricDividendCash  = "DividendCash"
 )
 
-// Manager data manager type
+// metricOHLCV metric of cached frames holding every metric of a symbol
+const metricOHLCV = "OHLCV"
+
+// OHLCVMetrics metrics returned by GetOHLCV, in column order
+var OHLCVMetrics = []string{
+	MetricOpen,
+	MetricHigh,
+	MetricLow,
+	MetricClose,
+	MetricVolume,
+	MetricAdjustedOpen,
+	MetricAdjustedHigh,
+	MetricAdjustedLow,
+	MetricAdjustedClose,
+	MetricSplitFactor,
+	MetricDividendCash,
+}
+
+// Manager data manager type. Symbols in Proxies have their history
+// extended with the history of another symbol from before they were
+// listed, e.g. VOO with VFINX; see SpliceHistory.
 type Manager struct {
 	Begin           time.Time
 	End             time.Time
 	Frequency       string
 	Metric          string
+	Intraday        bool
+	Proxies         map[string]string
 	credentials     map[string]string
 	providers       map[string]Provider
 	dateProvider    DateProvider
+	quoteProvider   QuoteProvider
+	cache           *PriceCache
+	diskCache       *DiskCache
+	snapshots       *snapshotLog
 	lastRiskFreeIdx int
 }
 
@@ -67,7 +102,7 @@ func InitializeDataManager() {
 		time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC), time.Now())
 	if err != nil {
 		log.WithFields(log.Fields{
-			"Error": err,
+			logging.FieldError: err,
 		}).Fatal("Cannot load risk free rate")
 	}
 
@@ -87,6 +122,8 @@ func NewManager(credentials map[string]string) Manager {
 		credentials: credentials,
 		providers:   map[string]Provider{},
 		Metric:      MetricAdjustedClose,
+		diskCache:   diskCacheFromEnv(),
+		snapshots:   newSnapshotLog(),
 	}
 
 	// Create Tiingo API
@@ -94,6 +131,8 @@ func NewManager(credentials map[string]string) Manager {
 		tiingo := NewTiingo(val)
 		m.RegisterDataProvider(tiingo)
 		m.dateProvider = tiingo
+		m.quoteProvider = tiingo
+		m.RegisterDataProvider(NewTiingoCrypto(val))
 	} else {
 		log.Warn("No tiingo API key provided")
 	}
@@ -102,6 +141,9 @@ func NewManager(credentials map[string]string) Manager {
 	fred := NewFred()
 	m.RegisterDataProvider(fred)
 
+	// Fama-French factors
+	m.RegisterDataProvider(NewFamaFrench())
+
 	return m
 }
 
@@ -121,7 +163,7 @@ func (m *Manager) RiskFreeRate(t time.Time) float64 {
 	}
 
 	var ret float64
-	iterator := riskFreeRate.ValuesIterator(dataframe.ValuesOptions{start, 1, true})
+	iterator := riskFreeRate.ValuesIterator(dataframe.ValuesOptions{InitialRow: start, Step: 1, DontReadLock: true})
 	for {
 		row, vals, _ := iterator(dataframe.SeriesName)
 		if row == nil {
@@ -158,22 +200,196 @@ func (m *Manager) LastTradingDayOfYear(t time.Time) (time.Time, error) {
 	return m.dateProvider.LastTradingDayOfYear(t)
 }
 
-// GetData get a dataframe for the requested symbol
+// GetQuotes get the latest intraday quote of each symbol; symbols without a
+// quote are omitted
+func (m *Manager) GetQuotes(symbols ...string) (map[string]Quote, error) {
+	if m.quoteProvider == nil {
+		return nil, ErrNoQuoteProvider
+	}
+	if len(symbols) == 0 {
+		return map[string]Quote{}, nil
+	}
+
+	upper := make([]string, len(symbols))
+	for ii, symbol := range symbols {
+		upper[ii] = strings.ToUpper(symbol)
+	}
+	return m.quoteProvider.GetQuotes(upper)
+}
+
+// GetData get a dataframe for the requested symbol. When Intraday is set
+// the current quote of a security is used as its price for today, previewing
+// results as if the market closed now.
 func (m *Manager) GetData(symbol string) (*dataframe.DataFrame, error) {
-	kind := "security"
+	resolved, err := ResolveSymbol(symbol)
+	if err != nil {
+		return nil, err
+	}
+	if resolved.Kind == "" {
+		return nil, fmt.Errorf("%s has a constant price of 1 and no data", resolved.Symbol)
+	}
+
+	provider, ok := m.providers[resolved.Kind]
+	if !ok {
+		return nil, fmt.Errorf("no data provider is registered for %s data such as %s", resolved.Kind, resolved.Symbol)
+	}
+
+	load := func(begin time.Time, end time.Time) (*dataframe.DataFrame, time.Time, error) {
+		return m.download(resolved, m.Metric, begin, end, func() (*dataframe.DataFrame, error) {
+			if resolved.Kind == "crypto" {
+				return m.getCryptoData(provider, resolved.Name, begin, end)
+			}
+			return provider.GetDataForPeriod(resolved.Name, m.Metric, m.Frequency, begin, end)
+		})
+	}
+
+	var df *dataframe.DataFrame
+	var retrieved time.Time
+	if m.cache != nil && m.cache.covers(m.Begin, m.End, m.Frequency) {
+		df, retrieved, err = m.cache.get(resolved.Symbol, m.Metric, m.Frequency, m.Begin, m.End, func() (*dataframe.DataFrame, time.Time, error) {
+			return load(m.cache.Begin, m.cache.End)
+		})
+	} else {
+		df, retrieved, err = load(m.Begin, m.End)
+	}
+	if err == nil {
+		m.snapshots.record(resolved.Symbol, retrieved)
+		df, err = m.withProxyHistory(df, resolved.Symbol)
+	}
+
+	if err != nil || !m.Intraday || resolved.Kind != "security" {
+		return df, err
+	}
+	return m.withIntradayQuote(df, resolved.Name)
+}
+
+// GetOHLCV get a dataframe with every metric of symbol, one column per
+// metric named as in OHLCVMetrics, from a single download. Intraday quotes
+// are not applied.
+func (m *Manager) GetOHLCV(symbol string) (*dataframe.DataFrame, error) {
+	resolved, err := ResolveSymbol(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, ok := m.providers[resolved.Kind].(MultiMetricProvider)
+	if !ok {
+		return nil, fmt.Errorf("%s data such as %s is not available with multiple metrics", resolved.Kind, resolved.Symbol)
+	}
+
+	load := func(begin time.Time, end time.Time) (*dataframe.DataFrame, time.Time, error) {
+		return m.download(resolved, metricOHLCV, begin, end, func() (*dataframe.DataFrame, error) {
+			return provider.GetOHLCVForPeriod(resolved.Name, m.Frequency, begin, end)
+		})
+	}
+
+	var df *dataframe.DataFrame
+	var retrieved time.Time
+	if m.cache != nil && m.cache.covers(m.Begin, m.End, m.Frequency) {
+		df, retrieved, err = m.cache.get(resolved.Symbol, metricOHLCV, m.Frequency, m.Begin, m.End, func() (*dataframe.DataFrame, time.Time, error) {
+			return load(m.cache.Begin, m.cache.End)
+		})
+	} else {
+		df, retrieved, err = load(m.Begin, m.End)
+	}
+	if err != nil {
+		return nil, err
+	}
+	m.snapshots.record(resolved.Symbol, retrieved)
+	return df, nil
+}
 
-	symbol = strings.ToUpper(symbol)
+// GetMetrics get a dataframe with the requested metrics of symbol, one
+// column per metric named by the metric, downloading the data once
+func (m *Manager) GetMetrics(symbol string, metrics ...string) (*dataframe.DataFrame, error) {
+	ohlcv, err := m.GetOHLCV(symbol)
+	if err != nil {
+		return nil, err
+	}
 
-	if strings.HasPrefix(symbol, "$RATE.") {
-		kind = "rate"
-		symbol = strings.TrimPrefix(symbol, "$RATE.")
+	dateIdx, err := ohlcv.NameToColumn(DateIdx)
+	if err != nil {
+		return nil, err
 	}
+	series := []dataframe.Series{ohlcv.Series[dateIdx]}
+	seen := map[string]bool{}
+	for _, metric := range metrics {
+		if seen[metric] {
+			continue
+		}
+		seen[metric] = true
 
-	if provider, ok := m.providers[kind]; ok {
-		return provider.GetDataForPeriod(symbol, m.Metric, m.Frequency, m.Begin, m.End)
+		idx, err := ohlcv.NameToColumn(metric)
+		if err != nil {
+			return nil, fmt.Errorf("unknown metric '%s'", metric)
+		}
+		series = append(series, ohlcv.Series[idx])
+	}
+	return dataframe.NewDataFrame(series...), nil
+}
+
+// download metric of resolved between begin and end at the manager's
+// frequency with fetch, sharing the download with identical concurrent
+// requests and serving it from the disk cache when one is used. Returns the
+// data and when it was retrieved from its provider.
+func (m *Manager) download(resolved Symbol, metric string, begin time.Time, end time.Time, fetch func() (*dataframe.DataFrame, error)) (*dataframe.DataFrame, time.Time, error) {
+	key := flightKey{kind: resolved.Kind, symbol: resolved.Name, metric: metric, frequency: m.Frequency, begin: begin.Unix(), end: end.Unix()}
+	return downloads.do(key, func() (*dataframe.DataFrame, time.Time, error) {
+		if m.diskCache != nil {
+			return m.diskCache.get(resolved.Kind, resolved.Name, metric, m.Frequency, begin, end, fetch)
+		}
+		df, err := fetch()
+		return df, time.Now(), err
+	})
+}
+
+// getCryptoData crypto currencies trade seven days a week; when a calendar
+// is available their prices are sampled on the trading days of the stock
+// market so they line up with securities in the same strategy
+func (m *Manager) getCryptoData(provider Provider, symbol string, begin time.Time, end time.Time) (*dataframe.DataFrame, error) {
+	calendarProvider, ok := m.providers["security"]
+	if !ok {
+		return provider.GetDataForPeriod(symbol, m.Metric, m.Frequency, begin, end)
 	}
 
-	return nil, errors.New("Specified kind '" + kind + "' is not supported")
+	daily, err := provider.GetDataForPeriod(symbol, m.Metric, FrequencyDaily, begin, end)
+	if err != nil {
+		return nil, err
+	}
+
+	calendar, err := calendarProvider.GetDataForPeriod(calendarSymbol, MetricClose, m.Frequency, begin, end)
+	if err != nil {
+		return nil, err
+	}
+
+	return alignToCalendar(daily, symbol, calendar)
+}
+
+// withIntradayQuote replace the price of symbol for today with its intraday
+// quote. Metrics other than prices, and securities without a quote, are
+// returned unchanged.
+func (m *Manager) withIntradayQuote(df *dataframe.DataFrame, symbol string) (*dataframe.DataFrame, error) {
+	if m.Metric != MetricClose && m.Metric != MetricAdjustedClose {
+		return df, nil
+	}
+
+	quotes, err := m.GetQuotes(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	q, ok := quotes[symbol]
+	if !ok {
+		return df, nil
+	}
+	if !m.End.IsZero() && quoteDate(q).After(m.End) {
+		return df, nil
+	}
+
+	if err := applyQuote(df, symbol, q, m.Frequency); err != nil {
+		return nil, err
+	}
+	return df, nil
 }
 
 // GetMultipleData get multiple quotes simultaneously
@@ -191,8 +407,8 @@ func (m *Manager) GetMultipleData(symbols ...string) (map[string]*dataframe.Data
 			res[v.Ticker] = v.Data
 		} else {
 			log.WithFields(log.Fields{
-				"Ticker": v.Ticker,
-				"Error":  v.Err,
+				"Ticker":           v.Ticker,
+				logging.FieldError: v.Err,
 			}).Warn("Cannot download ticker data")
 			errs = append(errs, v.Err)
 		}
@@ -1,8 +1,6 @@
 package data_test
 
 import (
-	"fmt"
-	"io/ioutil"
 	"time"
 
 	"github.com/jarcoal/httpmock"
@@ -18,22 +16,9 @@ var _ = Describe("Provider", func() {
 	)
 
 	BeforeEach(func() {
-		content, err := ioutil.ReadFile("testdata/VFINX.csv")
-		if err != nil {
-			panic(err)
-		}
-		httpmock.RegisterResponder("GET", "https://api.tiingo.com/tiingo/daily/VFINX/prices?startDate=1980-01-01&endDate=2021-01-01&format=csv&resampleFreq=Monthly&token=TEST",
-			httpmock.NewBytesResponder(200, content))
-
-		content, err = ioutil.ReadFile("testdata/riskfree.csv")
-		if err != nil {
-			panic(err)
-		}
-
-		today := time.Now()
-		url := fmt.Sprintf("https://fred.stlouisfed.org/graph/fredgraph.csv?mode=fred&id=DTB3&cosd=1970-01-01&coed=%d-%02d-%02d&fq=Daily&fam=avg", today.Year(), today.Month(), today.Day())
-		httpmock.RegisterResponder("GET", url,
-			httpmock.NewBytesResponder(200, content))
+		// the risk free rate is requested through today
+		fixtures := data.NewFixtureTransport("testdata/fixtures", "coed")
+		httpmock.RegisterNoResponder(fixtures.RoundTrip)
 
 		data.InitializeDataManager()
 
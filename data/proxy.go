@@ -0,0 +1,125 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	dataframe "github.com/rocketlaunchr/dataframe-go"
+)
+
+// valueColumn index of the column of a single symbol frame that is not the
+// date
+func valueColumn(df *dataframe.DataFrame) (int, int, error) {
+	dateIdx, err := df.NameToColumn(DateIdx)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(df.Series) != 2 {
+		return 0, 0, errors.New("expected a date and a single value column")
+	}
+	return dateIdx, 1 - dateIdx, nil
+}
+
+// SpliceHistory extend the history of df with the history of proxy, e.g.
+// an ETF with the mutual fund tracking the same index before the ETF was
+// listed. The proxy's values before the first value of df are scaled so
+// they join df on the last proxy date at or before it; df is unchanged from
+// there on. Both frames hold a date and a single value column; the result
+// keeps df's column name. df is returned as is when the proxy does not
+// overlap it.
+func SpliceHistory(df *dataframe.DataFrame, proxy *dataframe.DataFrame) (*dataframe.DataFrame, error) {
+	dateIdx, valIdx, err := valueColumn(df)
+	if err != nil {
+		return nil, err
+	}
+	proxyDateIdx, proxyValIdx, err := valueColumn(proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	// first value of df
+	first := -1
+	for row := 0; row < df.NRows(); row++ {
+		if val, ok := df.Series[valIdx].Value(row).(float64); ok && !math.IsNaN(val) {
+			first = row
+			break
+		}
+	}
+	if first == -1 {
+		return df, nil
+	}
+	firstDate, ok := df.Series[dateIdx].Value(first).(time.Time)
+	if !ok {
+		return nil, fmt.Errorf("row %d has no date", first)
+	}
+	firstVal := df.Series[valIdx].Value(first).(float64)
+
+	dates := []interface{}{}
+	vals := []float64{}
+	anchor := math.NaN()
+	for row := 0; row < proxy.NRows(); row++ {
+		date, ok := proxy.Series[proxyDateIdx].Value(row).(time.Time)
+		if !ok || date.After(firstDate) {
+			break
+		}
+		val, ok := proxy.Series[proxyValIdx].Value(row).(float64)
+		if !ok || math.IsNaN(val) {
+			continue
+		}
+		anchor = val
+		if date.Before(firstDate) {
+			dates = append(dates, date)
+			vals = append(vals, val)
+		}
+	}
+	if math.IsNaN(anchor) || anchor == 0 {
+		return df, nil
+	}
+
+	scale := firstVal / anchor
+	spliced := make([]interface{}, len(vals))
+	for ii, val := range vals {
+		spliced[ii] = val * scale
+	}
+	for row := first; row < df.NRows(); row++ {
+		dates = append(dates, df.Series[dateIdx].Value(row))
+		spliced = append(spliced, df.Series[valIdx].Value(row))
+	}
+
+	return dataframe.NewDataFrame(
+		dataframe.NewSeriesTime(DateIdx, &dataframe.SeriesInit{Capacity: len(dates)}, dates...),
+		dataframe.NewSeriesFloat64(df.Series[valIdx].Name(), &dataframe.SeriesInit{Capacity: len(spliced)}, spliced...),
+	), nil
+}
+
+// withProxyHistory splice the history of the proxy of symbol, if it has
+// one in Proxies, onto df when df starts after the manager's begin date.
+// Volumes and split factors are not spliced.
+func (m *Manager) withProxyHistory(df *dataframe.DataFrame, symbol string) (*dataframe.DataFrame, error) {
+	proxy, ok := m.Proxies[symbol]
+	if !ok || m.Metric == MetricVolume || m.Metric == MetricSplitFactor {
+		return df, nil
+	}
+
+	dateIdx, err := df.NameToColumn(DateIdx)
+	if err != nil {
+		return nil, err
+	}
+	if df.NRows() > 0 {
+		if first, ok := df.Series[dateIdx].Value(0).(time.Time); ok && !first.After(m.Begin) {
+			return df, nil
+		}
+	}
+
+	// proxies are not chained
+	proxyManager := *m
+	proxyManager.Proxies = nil
+	proxyManager.Intraday = false
+	proxyDf, err := proxyManager.GetData(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("cannot extend the history of %s with %s: %w", symbol, proxy, err)
+	}
+	return SpliceHistory(df, proxyDf)
+}
@@ -0,0 +1,93 @@
+package data_test
+
+import (
+	"math"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	dataframe "github.com/rocketlaunchr/dataframe-go"
+
+	"main/data"
+)
+
+var _ = Describe("Proxy", func() {
+	var (
+		d1 time.Time
+		d2 time.Time
+		d3 time.Time
+		d4 time.Time
+	)
+
+	frame := func(name string, dates []interface{}, vals ...interface{}) *dataframe.DataFrame {
+		return dataframe.NewDataFrame(
+			dataframe.NewSeriesTime(data.DateIdx, &dataframe.SeriesInit{}, dates...),
+			dataframe.NewSeriesFloat64(name, &dataframe.SeriesInit{}, vals...),
+		)
+	}
+
+	BeforeEach(func() {
+		d1 = time.Date(2010, time.June, 30, 0, 0, 0, 0, time.UTC)
+		d2 = time.Date(2010, time.July, 30, 0, 0, 0, 0, time.UTC)
+		d3 = time.Date(2010, time.August, 31, 0, 0, 0, 0, time.UTC)
+		d4 = time.Date(2010, time.September, 30, 0, 0, 0, 0, time.UTC)
+	})
+
+	Describe("When splicing history", func() {
+		It("should scale the proxy to join the first value", func() {
+			df := frame("VOO", []interface{}{d2, d3, d4}, math.NaN(), 100.0, 110.0)
+			proxy := frame("VFINX", []interface{}{d1, d2, d3, d4}, 40.0, 45.0, 50.0, 55.0)
+
+			spliced, err := data.SpliceHistory(df, proxy)
+			Expect(err).To(BeNil())
+			Expect(spliced.NRows()).To(Equal(4))
+			Expect(spliced.Series[1].Name()).To(Equal("VOO"))
+
+			Expect(spliced.Series[0].Value(0)).To(Equal(d1))
+			Expect(spliced.Series[1].Value(0).(float64)).Should(BeNumerically("~", 80.0, 1e-9))
+			Expect(spliced.Series[1].Value(1).(float64)).Should(BeNumerically("~", 90.0, 1e-9))
+			Expect(spliced.Series[1].Value(2).(float64)).Should(BeNumerically("~", 100.0, 1e-9))
+			Expect(spliced.Series[1].Value(3).(float64)).Should(BeNumerically("~", 110.0, 1e-9))
+		})
+
+		It("should leave history the proxy does not overlap unchanged", func() {
+			df := frame("VOO", []interface{}{d1, d2}, 100.0, 110.0)
+			proxy := frame("VFINX", []interface{}{d3, d4}, 50.0, 55.0)
+
+			spliced, err := data.SpliceHistory(df, proxy)
+			Expect(err).To(BeNil())
+			Expect(spliced).To(BeIdenticalTo(df))
+		})
+	})
+
+	Describe("When a manager has proxies", func() {
+		It("should extend the history of the symbol", func() {
+			httpmock.RegisterResponder("GET", "https://api.tiingo.com/tiingo/daily/VOO/prices?startDate=2010-06-01&endDate=2010-09-30&format=csv&resampleFreq=Monthly&token=TEST",
+				httpmock.NewStringResponder(200, "date,close,high,low,open,volume,adjClose,adjHigh,adjLow,adjOpen,adjVolume,divCash,splitFactor\n"+
+					"2010-08-31,100,100,100,100,0,100,100,100,100,0,0.0,1.0\n"+
+					"2010-09-30,110,110,110,110,0,110,110,110,110,0,0.0,1.0\n"))
+			httpmock.RegisterResponder("GET", "https://api.tiingo.com/tiingo/daily/VFINX/prices?startDate=2010-06-01&endDate=2010-09-30&format=csv&resampleFreq=Monthly&token=TEST",
+				httpmock.NewStringResponder(200, "date,close,high,low,open,volume,adjClose,adjHigh,adjLow,adjOpen,adjVolume,divCash,splitFactor\n"+
+					"2010-06-30,40,40,40,40,0,40,40,40,40,0,0.0,1.0\n"+
+					"2010-07-30,45,45,45,45,0,45,45,45,45,0,0.0,1.0\n"+
+					"2010-08-31,50,50,50,50,0,50,50,50,50,0,0.0,1.0\n"+
+					"2010-09-30,55,55,55,55,0,55,55,55,55,0,0.0,1.0\n"))
+
+			manager := data.NewManager(map[string]string{
+				"tiingo": "TEST",
+			})
+			manager.Begin = time.Date(2010, time.June, 1, 0, 0, 0, 0, time.UTC)
+			manager.End = d4
+			manager.Frequency = data.FrequencyMonthly
+			manager.Proxies = map[string]string{"VOO": "VFINX"}
+
+			df, err := manager.GetData("VOO")
+			Expect(err).To(BeNil())
+			Expect(df.NRows()).To(Equal(4))
+			Expect(df.Series[0].Value(0)).To(Equal(d1))
+			Expect(df.Series[1].Value(0).(float64)).Should(BeNumerically("~", 80.0, 1e-9))
+			Expect(df.Series[1].Value(3).(float64)).Should(BeNumerically("~", 110.0, 1e-9))
+		})
+	})
+})
@@ -0,0 +1,175 @@
+package data
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"main/logging"
+	"strings"
+	"time"
+
+	dataframe "github.com/rocketlaunchr/dataframe-go"
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrNoQuoteProvider returned when intraday quotes are requested from a
+// manager without a quote provider
+var ErrNoQuoteProvider = errors.New("no quote provider configured")
+
+// Quote last traded price of a security during the trading day. Quotes are
+// preliminary; they are superseded by the end of day price once the market
+// closes.
+type Quote struct {
+	Ticker    string    `json:"ticker"`
+	Price     float64   `json:"price"`
+	PrevClose float64   `json:"prevClose"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// QuoteProvider interface for retrieving intraday quotes
+type QuoteProvider interface {
+	GetQuotes(symbols []string) (map[string]Quote, error)
+}
+
+type tiingoIEXResponse struct {
+	Ticker    string    `json:"ticker"`
+	Timestamp time.Time `json:"timestamp"`
+	Last      float64   `json:"last"`
+	TngoLast  float64   `json:"tngoLast"`
+	PrevClose float64   `json:"prevClose"`
+}
+
+// GetQuotes get the latest IEX quote of each symbol. Securities that do not
+// trade on an exchange, such as mutual funds, have no quote and are omitted
+// from the result.
+func (t tiingo) GetQuotes(symbols []string) (map[string]Quote, error) {
+	url := fmt.Sprintf("%s/iex/?tickers=%s&token=%s", tiingoAPI, strings.Join(symbols, ","), t.apikey)
+
+	resp, err := providerGet("tiingo", t.apikey, url)
+	if err != nil {
+		log.WithFields(log.Fields{
+			logging.FieldFunction: "data/quote.go:GetQuotes",
+			"Symbols":             symbols,
+			logging.FieldError:    err,
+		}).Error("HTTP error response")
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		log.WithFields(log.Fields{
+			logging.FieldFunction: "data/quote.go:GetQuotes",
+			"Symbols":             symbols,
+			logging.FieldError:    err,
+		}).Error("Failed to read HTTP body")
+		return nil, err
+	}
+
+	if resp.StatusCode >= 400 {
+		log.WithFields(log.Fields{
+			logging.FieldFunction: "data/quote.go:GetQuotes",
+			"Symbols":             symbols,
+			"Body":                string(body),
+			"StatusCode":          resp.StatusCode,
+		}).Error("HTTP error response")
+		return nil, fmt.Errorf("HTTP request returned invalid status code: %d", resp.StatusCode)
+	}
+
+	jsonResp := []tiingoIEXResponse{}
+	if err := json.Unmarshal(body, &jsonResp); err != nil {
+		log.WithFields(log.Fields{
+			logging.FieldFunction: "data/quote.go:GetQuotes",
+			"Symbols":             symbols,
+			"Body":                string(body),
+			logging.FieldError:    err,
+		}).Error("Failed to parse JSON")
+		return nil, err
+	}
+
+	quotes := make(map[string]Quote, len(jsonResp))
+	for _, q := range jsonResp {
+		// tngoLast falls back to the mid price when there hasn't been a
+		// trade on IEX yet
+		price := q.TngoLast
+		if price == 0 {
+			price = q.Last
+		}
+		if price == 0 {
+			continue
+		}
+
+		ticker := strings.ToUpper(q.Ticker)
+		quotes[ticker] = Quote{
+			Ticker:    ticker,
+			Price:     price,
+			PrevClose: q.PrevClose,
+			Timestamp: q.Timestamp,
+		}
+	}
+
+	return quotes, nil
+}
+
+// quoteDate trading day of a quote, at midnight UTC like the dates of end of
+// day prices
+func quoteDate(q Quote) time.Time {
+	year, month, day := q.Timestamp.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// samePeriod true if a and b fall in the same period at frequency
+func samePeriod(a time.Time, b time.Time, frequency string) bool {
+	switch frequency {
+	case FrequencyWeekly:
+		ay, aw := a.ISOWeek()
+		by, bw := b.ISOWeek()
+		return ay == by && aw == bw
+	case FrequencyMonthly:
+		return a.Year() == b.Year() && a.Month() == b.Month()
+	case FrequencyAnnualy:
+		return a.Year() == b.Year()
+	default:
+		return a.Equal(b)
+	}
+}
+
+// applyQuote use the price of q as the value of symbol for the trading day
+// of the quote, as if the market closed now. If the last row of df is in the
+// same period as the quote it is replaced, otherwise a row is appended.
+// Quotes older than the last row of df are ignored.
+func applyQuote(df *dataframe.DataFrame, symbol string, q Quote, frequency string) error {
+	dateIdx, err := df.NameToColumn(DateIdx)
+	if err != nil {
+		return err
+	}
+	if _, err := df.NameToColumn(symbol); err != nil {
+		return err
+	}
+	if len(df.Series) != 2 {
+		return errors.New("quotes can only be applied to a date and a price series")
+	}
+
+	date := quoteDate(q)
+	nrows := df.NRows()
+	if nrows > 0 {
+		last := df.Series[dateIdx].Value(nrows - 1).(time.Time)
+		if date.Before(last) {
+			return nil
+		}
+		if samePeriod(last, date, frequency) {
+			df.UpdateRow(nrows-1, nil, map[string]interface{}{
+				DateIdx: date,
+				symbol:  q.Price,
+			})
+			return nil
+		}
+	}
+
+	df.Append(nil, map[string]interface{}{
+		DateIdx: date,
+		symbol:  q.Price,
+	})
+	return nil
+}
@@ -0,0 +1,97 @@
+package data_test
+
+import (
+	"io/ioutil"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	dataframe "github.com/rocketlaunchr/dataframe-go"
+
+	"main/data"
+)
+
+var _ = Describe("Quote", func() {
+	var (
+		dataProxy data.Manager
+		content   []byte
+	)
+
+	BeforeEach(func() {
+		var err error
+		content, err = ioutil.ReadFile("testdata/VFINX.csv")
+		if err != nil {
+			panic(err)
+		}
+
+		dataProxy = data.NewManager(map[string]string{
+			"tiingo": "TEST",
+		})
+		dataProxy.Begin = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+		dataProxy.Frequency = data.FrequencyMonthly
+	})
+
+	Describe("When requesting intraday quotes", func() {
+		It("should omit securities without a quote", func() {
+			httpmock.RegisterResponder("GET", "https://api.tiingo.com/iex/?tickers=SPY,VFINX&token=TEST",
+				httpmock.NewStringResponder(200, `[{"ticker":"SPY","timestamp":"2021-01-29T11:30:00-05:00","last":370.5,"tngoLast":370.45,"prevClose":377.63}]`))
+
+			quotes, err := dataProxy.GetQuotes("spy", "vfinx")
+			Expect(err).To(BeNil())
+			Expect(quotes).To(HaveLen(1))
+			Expect(quotes).To(HaveKey("SPY"))
+			Expect(quotes["SPY"].Price).Should(BeNumerically("~", 370.45, 1e-9))
+			Expect(quotes["SPY"].PrevClose).Should(BeNumerically("~", 377.63, 1e-9))
+		})
+
+		It("should not request quotes for an empty list", func() {
+			quotes, err := dataProxy.GetQuotes()
+			Expect(err).To(BeNil())
+			Expect(quotes).To(BeEmpty())
+			Expect(httpmock.GetTotalCallCount()).To(Equal(0))
+		})
+	})
+
+	Describe("When using intraday prices", func() {
+		BeforeEach(func() {
+			dataProxy.Intraday = true
+		})
+
+		It("should replace the price of the current period", func() {
+			httpmock.RegisterResponder("GET", "https://api.tiingo.com/tiingo/daily/VFINX/prices?startDate=1980-01-01&endDate=2021-01-29&format=csv&resampleFreq=Monthly&token=TEST",
+				httpmock.NewBytesResponder(200, content))
+			httpmock.RegisterResponder("GET", "https://api.tiingo.com/iex/?tickers=VFINX&token=TEST",
+				httpmock.NewStringResponder(200, `[{"ticker":"VFINX","timestamp":"2021-01-29T11:30:00-05:00","last":350.25,"tngoLast":350.25,"prevClose":345.10}]`))
+
+			dataProxy.End = time.Date(2021, time.January, 29, 0, 0, 0, 0, time.UTC)
+			df, err := dataProxy.GetData("VFINX")
+			Expect(err).To(BeNil())
+
+			last := df.Row(df.NRows()-1, false, dataframe.SeriesName)
+			Expect(last[data.DateIdx]).To(Equal(time.Date(2021, time.January, 29, 0, 0, 0, 0, time.UTC)))
+			Expect(last["VFINX"].(float64)).Should(BeNumerically("~", 350.25, 1e-9))
+
+			prev := df.Row(df.NRows()-2, false, dataframe.SeriesName)
+			Expect(prev[data.DateIdx]).To(Equal(time.Date(2020, time.December, 31, 0, 0, 0, 0, time.UTC)))
+		})
+
+		It("should append a row for a new period", func() {
+			httpmock.RegisterResponder("GET", "https://api.tiingo.com/tiingo/daily/VFINX/prices?startDate=1980-01-01&endDate=2021-02-01&format=csv&resampleFreq=Monthly&token=TEST",
+				httpmock.NewBytesResponder(200, content))
+			httpmock.RegisterResponder("GET", "https://api.tiingo.com/iex/?tickers=VFINX&token=TEST",
+				httpmock.NewStringResponder(200, `[{"ticker":"VFINX","timestamp":"2021-02-01T11:30:00-05:00","last":352.00,"tngoLast":352.00,"prevClose":341.5}]`))
+
+			dataProxy.End = time.Date(2021, time.February, 1, 0, 0, 0, 0, time.UTC)
+			df, err := dataProxy.GetData("VFINX")
+			Expect(err).To(BeNil())
+
+			last := df.Row(df.NRows()-1, false, dataframe.SeriesName)
+			Expect(last[data.DateIdx]).To(Equal(time.Date(2021, time.February, 1, 0, 0, 0, 0, time.UTC)))
+			Expect(last["VFINX"].(float64)).Should(BeNumerically("~", 352.00, 1e-9))
+
+			prev := df.Row(df.NRows()-2, false, dataframe.SeriesName)
+			Expect(prev[data.DateIdx]).To(Equal(time.Date(2021, time.January, 29, 0, 0, 0, 0, time.UTC)))
+		})
+	})
+})
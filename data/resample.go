@@ -0,0 +1,75 @@
+package data
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+// resamplePeriod identify the period of frequency that t falls in
+func resamplePeriod(t time.Time, frequency string) (int, error) {
+	switch frequency {
+	case FrequencyWeekly:
+		year, week := t.ISOWeek()
+		return year*100 + week, nil
+	case FrequencyMonthly:
+		return t.Year()*100 + int(t.Month()), nil
+	case FrequencyAnnualy:
+		return t.Year(), nil
+	}
+	return 0, fmt.Errorf("cannot resample to frequency '%s'", frequency)
+}
+
+// Resample keep the last row of df in each week, month, or year so
+// indicators measured in months can be computed from daily prices, e.g. a
+// strategy that trades on a daily signal but ranks assets by 6 month
+// momentum. Rows are expected in date order; the last row is kept even if
+// its period has not ended. Resampling to FrequencyDaily returns a copy.
+func Resample(df *dataframe.DataFrame, frequency string) (*dataframe.DataFrame, error) {
+	if frequency == FrequencyDaily {
+		return df.Copy(), nil
+	}
+
+	dateIdx, err := df.NameToColumn(DateIdx)
+	if err != nil {
+		return nil, err
+	}
+
+	dates := df.Series[dateIdx]
+	nrows := dates.NRows()
+	keep := make([]int, 0, nrows)
+	for row := 0; row < nrows; row++ {
+		date, ok := dates.Value(row).(time.Time)
+		if !ok {
+			return nil, fmt.Errorf("row %d has no date", row)
+		}
+		period, err := resamplePeriod(date, frequency)
+		if err != nil {
+			return nil, err
+		}
+		if row+1 < nrows {
+			next, ok := dates.Value(row + 1).(time.Time)
+			if !ok {
+				return nil, fmt.Errorf("row %d has no date", row+1)
+			}
+			nextPeriod, _ := resamplePeriod(next, frequency)
+			if nextPeriod == period {
+				continue
+			}
+		}
+		keep = append(keep, row)
+	}
+
+	series := make([]dataframe.Series, len(df.Series))
+	for ii, s := range df.Series {
+		resampled := s.Copy()
+		resampled.Reset()
+		for _, row := range keep {
+			resampled.Append(s.Value(row))
+		}
+		series[ii] = resampled
+	}
+
+	return dataframe.NewDataFrame(series...), nil
+}
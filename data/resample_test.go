@@ -0,0 +1,67 @@
+package data_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/rocketlaunchr/dataframe-go"
+
+	"main/data"
+)
+
+var _ = Describe("Resample", func() {
+	var df *dataframe.DataFrame
+
+	BeforeEach(func() {
+		dates := []time.Time{}
+		closes := []float64{}
+		day := time.Date(2021, time.January, 4, 0, 0, 0, 0, time.UTC)
+		for ii := 0; ii < 45; ii++ {
+			if day.Weekday() != time.Saturday && day.Weekday() != time.Sunday {
+				dates = append(dates, day)
+				closes = append(closes, float64(ii))
+			}
+			day = day.AddDate(0, 0, 1)
+		}
+
+		df = dataframe.NewDataFrame(
+			dataframe.NewSeriesTime(data.DateIdx, nil, dates),
+			dataframe.NewSeriesFloat64("VFINX", nil, closes),
+		)
+	})
+
+	Describe("When resampling daily prices", func() {
+		It("should keep the last trading day of each month", func() {
+			monthly, err := data.Resample(df, data.FrequencyMonthly)
+			Expect(err).To(BeNil())
+			Expect(monthly.NRows()).To(Equal(2))
+			Expect(monthly.Series[0].Value(0)).To(Equal(time.Date(2021, time.January, 29, 0, 0, 0, 0, time.UTC)))
+			Expect(monthly.Series[1].Value(0)).To(Equal(25.0))
+
+			// the month in progress ends on the last row
+			Expect(monthly.Series[0].Value(1)).To(Equal(time.Date(2021, time.February, 17, 0, 0, 0, 0, time.UTC)))
+			Expect(monthly.Series[1].Value(1)).To(Equal(44.0))
+		})
+
+		It("should keep the last trading day of each week", func() {
+			weekly, err := data.Resample(df, data.FrequencyWeekly)
+			Expect(err).To(BeNil())
+			Expect(weekly.NRows()).To(Equal(7))
+			for row := 0; row < 6; row++ {
+				Expect(weekly.Series[0].Value(row).(time.Time).Weekday()).To(Equal(time.Friday))
+			}
+		})
+
+		It("should not change the original", func() {
+			_, err := data.Resample(df, data.FrequencyAnnualy)
+			Expect(err).To(BeNil())
+			Expect(df.NRows()).To(Equal(33))
+		})
+
+		It("should reject unknown frequencies", func() {
+			_, err := data.Resample(df, "Hourly")
+			Expect(err).NotTo(BeNil())
+		})
+	})
+})
@@ -0,0 +1,49 @@
+package data
+
+import (
+	"sync"
+	"time"
+)
+
+// snapshotLog when the data served for each symbol was retrieved from its
+// provider
+type snapshotLog struct {
+	mu        sync.Mutex
+	retrieved map[string]time.Time
+}
+
+func newSnapshotLog() *snapshotLog {
+	return &snapshotLog{retrieved: make(map[string]time.Time)}
+}
+
+// record that data of symbol retrieved at t was served. The earliest time is
+// kept since results are only as current as the oldest data they used.
+func (l *snapshotLog) record(symbol string, t time.Time) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if prev, ok := l.retrieved[symbol]; !ok || t.Before(prev) {
+		l.retrieved[symbol] = t
+	}
+}
+
+// Snapshots when the data of each symbol requested from the manager was
+// retrieved from its provider; a symbol requested more than once reports
+// its oldest data. Cached data reports when it was downloaded, not when it
+// was read from the cache.
+func (m *Manager) Snapshots() map[string]time.Time {
+	snapshots := map[string]time.Time{}
+	if m.snapshots == nil {
+		return snapshots
+	}
+
+	m.snapshots.mu.Lock()
+	defer m.snapshots.mu.Unlock()
+	for symbol, t := range m.snapshots.retrieved {
+		snapshots[symbol] = t
+	}
+	return snapshots
+}
@@ -0,0 +1,124 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CashSymbol symbol of cash, which has a constant price of 1
+const CashSymbol = "$CASH"
+
+// ErrInvalidSymbol returned when a symbol cannot be resolved to a provider
+var ErrInvalidSymbol = errors.New("invalid symbol")
+
+// Namespace symbols named Prefix, a period, and a name, e.g. $RATE.TB3MS, are
+// served by the provider whose DataType is Kind
+type Namespace struct {
+	Prefix    string
+	Kind      string
+	AssetType string
+	Example   string
+}
+
+// Namespaces namespaces keyed by prefix; symbols without a namespace are
+// securities
+var Namespaces = map[string]Namespace{
+	"$RATE":   {Prefix: "$RATE", Kind: "rate", AssetType: AssetTypeRate, Example: "$RATE.TB3MS"},
+	"$FRED":   {Prefix: "$FRED", Kind: "rate", AssetType: AssetTypeRate, Example: "$FRED.DGS10"},
+	"$CRYPTO": {Prefix: "$CRYPTO", Kind: "crypto", AssetType: AssetTypeCrypto, Example: "$CRYPTO.BTCUSD"},
+	"$FUT":    {Prefix: "$FUT", Kind: "futures", AssetType: AssetTypeFutures, Example: "$FUT.ES"},
+	"$SYN":    {Prefix: "$SYN", Kind: "synthetic", AssetType: AssetTypeSynthetic, Example: "$SYN.SPY"},
+	"$FF":     {Prefix: "$FF", Kind: "factor", AssetType: AssetTypeFactor, Example: "$FF.SMB"},
+}
+
+// Symbol a symbol resolved to the kind of data it refers to
+type Symbol struct {
+	// Symbol upper case symbol including its namespace, e.g. $RATE.TB3MS
+	Symbol string
+
+	// Name symbol within its namespace that providers are queried with,
+	// e.g. TB3MS
+	Name string
+
+	// Namespace prefix of the symbol; empty for securities
+	Namespace string
+
+	// Kind DataType of the provider that serves the symbol; empty for cash,
+	// which has no data
+	Kind      string
+	AssetType string
+}
+
+// namespacePrefixes sorted list of the namespace prefixes, for errors
+func namespacePrefixes() string {
+	prefixes := []string{CashSymbol}
+	for prefix := range Namespaces {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	return strings.Join(prefixes, ", ")
+}
+
+// ResolveSymbol determine which provider serves symbol. Symbols starting
+// with $ must be $CASH or belong to one of the Namespaces; all others are
+// securities. Mutual fund tickers are identified by the 5 letter, X suffixed
+// convention of the NASDAQ Mutual Fund Quotation Service.
+func ResolveSymbol(symbol string) (Symbol, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if symbol == "" {
+		return Symbol{}, fmt.Errorf("%w: symbol is empty", ErrInvalidSymbol)
+	}
+
+	if symbol == CashSymbol {
+		return Symbol{
+			Symbol:    symbol,
+			Name:      symbol,
+			Namespace: CashSymbol,
+			AssetType: AssetTypeCash,
+		}, nil
+	}
+
+	if strings.HasPrefix(symbol, "$") {
+		parts := strings.SplitN(symbol, ".", 2)
+		prefix := parts[0]
+		if prefix == CashSymbol {
+			return Symbol{}, fmt.Errorf("%w: %s does not take a name; use %s", ErrInvalidSymbol, symbol, CashSymbol)
+		}
+
+		ns, ok := Namespaces[prefix]
+		if !ok {
+			return Symbol{}, fmt.Errorf("%w: unknown namespace %s in %s; expected one of %s", ErrInvalidSymbol, prefix, symbol, namespacePrefixes())
+		}
+		if len(parts) < 2 || parts[1] == "" {
+			return Symbol{}, fmt.Errorf("%w: %s is missing a name, e.g. %s", ErrInvalidSymbol, symbol, ns.Example)
+		}
+
+		return Symbol{
+			Symbol:    symbol,
+			Name:      parts[1],
+			Namespace: prefix,
+			Kind:      ns.Kind,
+			AssetType: ns.AssetType,
+		}, nil
+	}
+
+	for _, c := range symbol {
+		if !((c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '.' || c == '-') {
+			return Symbol{}, fmt.Errorf("%w: %s contains invalid character %q", ErrInvalidSymbol, symbol, c)
+		}
+	}
+
+	assetType := AssetTypeStock
+	if len(symbol) == 5 && strings.HasSuffix(symbol, "X") {
+		assetType = AssetTypeMutualFund
+	}
+
+	return Symbol{
+		Symbol:    symbol,
+		Name:      symbol,
+		Kind:      "security",
+		AssetType: assetType,
+	}, nil
+}
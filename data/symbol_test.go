@@ -0,0 +1,86 @@
+package data_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"main/data"
+)
+
+var _ = Describe("Symbol", func() {
+	Describe("When resolving symbols", func() {
+		It("should resolve securities", func() {
+			symbol, err := data.ResolveSymbol(" vfinx ")
+			Expect(err).To(BeNil())
+			Expect(symbol).To(Equal(data.Symbol{
+				Symbol:    "VFINX",
+				Name:      "VFINX",
+				Kind:      "security",
+				AssetType: data.AssetTypeMutualFund,
+			}))
+
+			symbol, err = data.ResolveSymbol("BRK.B")
+			Expect(err).To(BeNil())
+			Expect(symbol.AssetType).To(Equal(data.AssetTypeStock))
+		})
+
+		It("should resolve namespaced symbols", func() {
+			symbol, err := data.ResolveSymbol("$rate.tb3ms")
+			Expect(err).To(BeNil())
+			Expect(symbol).To(Equal(data.Symbol{
+				Symbol:    "$RATE.TB3MS",
+				Name:      "TB3MS",
+				Namespace: "$RATE",
+				Kind:      "rate",
+				AssetType: data.AssetTypeRate,
+			}))
+
+			symbol, err = data.ResolveSymbol("$FRED.DGS10")
+			Expect(err).To(BeNil())
+			Expect(symbol.Kind).To(Equal("rate"))
+
+			symbol, err = data.ResolveSymbol("$CRYPTO.BTCUSD")
+			Expect(err).To(BeNil())
+			Expect(symbol.Kind).To(Equal("crypto"))
+			Expect(symbol.Name).To(Equal("BTCUSD"))
+		})
+
+		It("should resolve cash without a provider", func() {
+			symbol, err := data.ResolveSymbol("$CASH")
+			Expect(err).To(BeNil())
+			Expect(symbol.Kind).To(BeEmpty())
+			Expect(symbol.AssetType).To(Equal(data.AssetTypeCash))
+		})
+
+		It("should explain invalid symbols", func() {
+			_, err := data.ResolveSymbol("$RATE")
+			Expect(err).To(MatchError("invalid symbol: $RATE is missing a name, e.g. $RATE.TB3MS"))
+			Expect(errors.Is(err, data.ErrInvalidSymbol)).To(BeTrue())
+
+			_, err = data.ResolveSymbol("$CASH.USD")
+			Expect(err).To(MatchError("invalid symbol: $CASH.USD does not take a name; use $CASH"))
+
+			_, err = data.ResolveSymbol("$BOND.AGG")
+			Expect(err).To(MatchError("invalid symbol: unknown namespace $BOND in $BOND.AGG; expected one of $CASH, $CRYPTO, $FF, $FRED, $FUT, $RATE, $SYN"))
+
+			_, err = data.ResolveSymbol("SP Y")
+			Expect(err).To(MatchError(`invalid symbol: SP Y contains invalid character ' '`))
+
+			_, err = data.ResolveSymbol("")
+			Expect(err).To(MatchError("invalid symbol: symbol is empty"))
+		})
+	})
+
+	Describe("When requesting data", func() {
+		It("should explain symbols without a provider", func() {
+			manager := data.NewManager(map[string]string{})
+			_, err := manager.GetData("$SYN.SPY")
+			Expect(err).To(MatchError("no data provider is registered for synthetic data such as $SYN.SPY"))
+
+			_, err = manager.GetData("$CASH")
+			Expect(err).To(MatchError("$CASH has a constant price of 1 and no data"))
+		})
+	})
+})
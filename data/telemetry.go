@@ -0,0 +1,113 @@
+package data
+
+import (
+	"io"
+	"main/telemetry"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+var (
+	providerDuration = telemetry.NewHistogram("pvapi.data.provider.duration", "ms",
+		"Time to download a response from a data provider, including reading its body", telemetry.DurationBuckets)
+	providerBytes = telemetry.NewHistogram("pvapi.data.provider.response_size", "By",
+		"Size of the response bodies of data providers", telemetry.SizeBuckets)
+	providerRequests = telemetry.NewCounter("pvapi.data.provider.requests", "{request}",
+		"Requests made to data providers by provider and HTTP status code")
+	cacheRequests = telemetry.NewCounter("pvapi.data.cache.requests", "{request}",
+		"Requests for data served from or missed by the price and disk caches")
+)
+
+// Attribute values of the telemetry recorded by the data layer
+const (
+	cacheMemory = "memory"
+	cacheDisk   = "disk"
+	cacheHit    = "hit"
+	cacheMiss   = "miss"
+)
+
+// recordCache count a request to cache that hit or missed
+func recordCache(cache string, hit bool) {
+	result := cacheMiss
+	if hit {
+		result = cacheHit
+	}
+	cacheRequests.Add(1, telemetry.Attributes{"cache": cache, "result": result})
+}
+
+// providerGet download u from provider with the shared client, recording the
+// call against token in Usage. The latency, size, and status code of the
+// download are recorded when the response body is closed.
+func providerGet(provider string, token string, u string) (*http.Response, error) {
+	Usage.Record(provider, token)
+
+	attrs := telemetry.Attributes{"provider": provider}
+	if parsed, err := url.Parse(u); err == nil {
+		attrs["http.host"] = parsed.Host
+	}
+	span := telemetry.StartSpan("data.provider.get", attrs)
+
+	resp, err := httpClient.Get(u)
+	if err != nil {
+		providerRequests.Add(1, telemetry.Attributes{"provider": provider, "http.status_code": "error"})
+		providerDuration.Record(telemetry.Milliseconds(time.Since(span.Start)), telemetry.Attributes{"provider": provider})
+		span.Finish(err)
+		return nil, err
+	}
+
+	resp.Body = &measuredBody{
+		ReadCloser: resp.Body,
+		provider:   provider,
+		status:     resp.StatusCode,
+		span:       span,
+	}
+	return resp, nil
+}
+
+// measuredBody a response body that records the telemetry of its download
+// when closed
+type measuredBody struct {
+	io.ReadCloser
+	provider string
+	status   int
+	span     *telemetry.Span
+	n        int64
+	closed   bool
+}
+
+func (b *measuredBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+func (b *measuredBody) Close() error {
+	err := b.ReadCloser.Close()
+	if b.closed {
+		return err
+	}
+	b.closed = true
+
+	provider := telemetry.Attributes{"provider": b.provider}
+	providerDuration.Record(telemetry.Milliseconds(time.Since(b.span.Start)), provider)
+	providerBytes.Record(float64(b.n), provider)
+	providerRequests.Add(1, telemetry.Attributes{"provider": b.provider, "http.status_code": strconv.Itoa(b.status)})
+
+	b.span.SetAttribute("http.status_code", strconv.Itoa(b.status))
+	b.span.SetAttribute("http.response_content_length", strconv.FormatInt(b.n, 10))
+	var spanErr error
+	if b.status >= 400 {
+		spanErr = httpStatusError(b.status)
+	}
+	b.span.Finish(spanErr)
+	return err
+}
+
+// httpStatusError the error of a response with an unsuccessful status code
+type httpStatusError int
+
+func (e httpStatusError) Error() string {
+	return "HTTP status " + strconv.Itoa(int(e))
+}
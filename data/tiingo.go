@@ -1,20 +1,19 @@
 package data
 
 import (
-	"bytes"
-	"context"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"main/logging"
 	"math"
-	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	dataframe "github.com/rocketlaunchr/dataframe-go"
-	imports "github.com/rocketlaunchr/dataframe-go/imports"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -55,37 +54,37 @@ func (t tiingo) LastTradingDay(forDate time.Time, frequency string) (time.Time,
 	symbol := "SPY"
 	url := fmt.Sprintf("%s/tiingo/daily/%s/prices?startDate=%s&endDate=%s&resampleFreq=%s&token=%s", tiingoAPI, symbol, forDate.Format("2006-01-02"), forDate.Format("2006-01-02"), frequency, t.apikey)
 
-	resp, err := http.Get(url)
+	resp, err := providerGet("tiingo", t.apikey, url)
 	if err != nil {
 		log.WithFields(log.Fields{
-			"Function":  "data/tiingo.go:LastTradingDay",
-			"ForDate":   forDate,
-			"Frequency": frequency,
-			"Error":     err,
+			logging.FieldFunction: "data/tiingo.go:LastTradingDay",
+			"ForDate":             forDate,
+			"Frequency":           frequency,
+			logging.FieldError:    err,
 		}).Error("HTTP error response")
 		return time.Time{}, err
 	}
 
+	defer resp.Body.Close()
 	if resp.StatusCode >= 400 {
 		log.WithFields(log.Fields{
-			"Function":   "data/tiingo.go:LastTradingDay",
-			"ForDate":    forDate,
-			"Frequency":  frequency,
-			"StatusCode": resp.StatusCode,
-			"Error":      err,
+			logging.FieldFunction: "data/tiingo.go:LastTradingDay",
+			"ForDate":             forDate,
+			"Frequency":           frequency,
+			"StatusCode":          resp.StatusCode,
+			logging.FieldError:    err,
 		}).Error("HTTP error response")
 		return time.Time{}, fmt.Errorf("HTTP request returned invalid status code: %d", resp.StatusCode)
 	}
 
-	defer resp.Body.Close()
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		log.WithFields(log.Fields{
-			"Function":  "data/tiingo.go:LastTradingDay",
-			"ForDate":   forDate,
-			"Frequency": frequency,
-			"Body":      string(body),
-			"Error":     err,
+			logging.FieldFunction: "data/tiingo.go:LastTradingDay",
+			"ForDate":             forDate,
+			"Frequency":           frequency,
+			"Body":                string(body),
+			logging.FieldError:    err,
 		}).Error("Failed to read HTTP body")
 		return time.Time{}, err
 	}
@@ -94,11 +93,11 @@ func (t tiingo) LastTradingDay(forDate time.Time, frequency string) (time.Time,
 	err = json.Unmarshal(body, &jsonResp)
 	if err != nil {
 		log.WithFields(log.Fields{
-			"Function":  "data/tiingo.go:LastTradingDay",
-			"ForDate":   forDate,
-			"Frequency": frequency,
-			"Body":      string(body),
-			"Error":     err,
+			logging.FieldFunction: "data/tiingo.go:LastTradingDay",
+			"ForDate":             forDate,
+			"Frequency":           frequency,
+			"Body":                string(body),
+			logging.FieldError:    err,
 		}).Error("Failed to parse JSON")
 		return time.Time{}, err
 	}
@@ -107,22 +106,22 @@ func (t tiingo) LastTradingDay(forDate time.Time, frequency string) (time.Time,
 		dtParts := strings.Split(jsonResp[0].Date, "T")
 		if len(dtParts) == 0 {
 			log.WithFields(log.Fields{
-				"Function":  "data/tiingo.go:LastTradingDay",
-				"ForDate":   forDate,
-				"Frequency": frequency,
-				"DateStr":   jsonResp[0].Date,
-				"Error":     err,
+				logging.FieldFunction: "data/tiingo.go:LastTradingDay",
+				"ForDate":             forDate,
+				"Frequency":           frequency,
+				"DateStr":             jsonResp[0].Date,
+				logging.FieldError:    err,
 			}).Error("Invalid date format")
 			return time.Time{}, errors.New("Invalid date format")
 		}
 		lastDay, err := time.Parse("2006-01-02", dtParts[0])
 		if err != nil {
 			log.WithFields(log.Fields{
-				"Function":   "data/tiingo.go:LastTradingDay",
-				"ForDate":    forDate,
-				"Frequency":  frequency,
-				"StatusCode": resp.StatusCode,
-				"Error":      err,
+				logging.FieldFunction: "data/tiingo.go:LastTradingDay",
+				"ForDate":             forDate,
+				"Frequency":           frequency,
+				"StatusCode":          resp.StatusCode,
+				logging.FieldError:    err,
 			}).Error("Cannot parse date")
 			return time.Time{}, err
 		}
@@ -154,7 +153,67 @@ func (t tiingo) DataType() string {
 	return "security"
 }
 
-func (t tiingo) GetDataForPeriod(symbol string, metric string, frequency string, begin time.Time, end time.Time) (data *dataframe.DataFrame, err error) {
+// tiingoColumns column of the tiingo price CSV holding each metric
+var tiingoColumns = map[string]string{
+	MetricOpen:          "open",
+	MetricHigh:          "high",
+	MetricLow:           "low",
+	MetricClose:         "close",
+	MetricVolume:        "volume",
+	MetricAdjustedOpen:  "adjOpen",
+	MetricAdjustedHigh:  "adjHigh",
+	MetricAdjustedLow:   "adjLow",
+	MetricAdjustedClose: "adjClose",
+	MetricSplitFactor:   "splitFactor",
+	MetricDividendCash:  "divCash",
+}
+
+func (t tiingo) GetDataForPeriod(symbol string, metric string, frequency string, begin time.Time, end time.Time) (*dataframe.DataFrame, error) {
+	column, ok := tiingoColumns[metric]
+	if !ok {
+		return nil, errors.New("Un-supported metric")
+	}
+
+	res, err := t.fetchPrices(symbol, metric, frequency, begin, end)
+	if err != nil {
+		return nil, err
+	}
+
+	valueSeriesIdx, err := res.NameToColumn(column)
+	if err != nil {
+		return nil, fmt.Errorf("%s metric not found", metric)
+	}
+	valueSeries := res.Series[valueSeriesIdx]
+	valueSeries.Rename(symbol)
+
+	timeSeriesIdx, _ := res.NameToColumn(DateIdx)
+	return dataframe.NewDataFrame(res.Series[timeSeriesIdx], valueSeries), nil
+}
+
+// GetOHLCVForPeriod every metric of symbol from a single download; columns
+// are named by metric in the order of OHLCVMetrics
+func (t tiingo) GetOHLCVForPeriod(symbol string, frequency string, begin time.Time, end time.Time) (*dataframe.DataFrame, error) {
+	res, err := t.fetchPrices(symbol, "OHLCV", frequency, begin, end)
+	if err != nil {
+		return nil, err
+	}
+
+	timeSeriesIdx, _ := res.NameToColumn(DateIdx)
+	series := []dataframe.Series{res.Series[timeSeriesIdx]}
+	for _, metric := range OHLCVMetrics {
+		idx, err := res.NameToColumn(tiingoColumns[metric])
+		if err != nil {
+			return nil, fmt.Errorf("%s metric not found", metric)
+		}
+		res.Series[idx].Rename(metric)
+		series = append(series, res.Series[idx])
+	}
+	return dataframe.NewDataFrame(series...), nil
+}
+
+// fetchPrices download the price CSV of symbol with every metric; metric is
+// only used for logging. The date column is renamed to DateIdx.
+func (t tiingo) fetchPrices(symbol string, metric string, frequency string, begin time.Time, end time.Time) (*dataframe.DataFrame, error) {
 	validFrequencies := map[string]bool{
 		FrequencyDaily:   true,
 		FrequencyWeekly:  true,
@@ -182,38 +241,24 @@ func (t tiingo) GetDataForPeriod(symbol string, metric string, frequency string,
 		url = fmt.Sprintf("%s/tiingo/daily/%s/prices?startDate=%s&endDate=%s&format=csv&resampleFreq=%s&token=%s", tiingoAPI, symbol, begin.Format("2006-01-02"), end.Format("2006-01-02"), frequency, t.apikey)
 	}
 
-	resp, err := http.Get(url)
+	resp, err := providerGet("tiingo", t.apikey, url)
 
 	if err != nil {
 		log.WithFields(log.Fields{
-			"Url":       url,
-			"Symbol":    symbol,
-			"Metric":    metric,
-			"Frequency": frequency,
-			"StartTime": begin.String(),
-			"EndTime":   end.String(),
-			"Error":     err,
+			"Url":              url,
+			"Symbol":           symbol,
+			"Metric":           metric,
+			"Frequency":        frequency,
+			"StartTime":        begin.String(),
+			"EndTime":          end.String(),
+			logging.FieldError: err,
 		}).Debug("Failed to load eod prices")
 		return nil, err
 	}
 
 	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		log.WithFields(log.Fields{
-			"Url":        url,
-			"Symbol":     symbol,
-			"Metric":     metric,
-			"Frequency":  frequency,
-			"StartTime":  begin.String(),
-			"EndTime":    end.String(),
-			"Error":      err,
-			"StatusCode": resp.StatusCode,
-		}).Debug("Failed to load eod prices -- reading body failed")
-		return nil, err
-	}
-
 	if resp.StatusCode >= 400 {
+		body, _ := ioutil.ReadAll(resp.Body)
 		log.WithFields(log.Fields{
 			"Url":        url,
 			"Symbol":     symbol,
@@ -227,115 +272,111 @@ func (t tiingo) GetDataForPeriod(symbol string, metric string, frequency string,
 		return nil, fmt.Errorf("HTTP request returned invalid status code: %d", resp.StatusCode)
 	}
 
-	floatConverter := imports.Converter{
-		ConcreteType: float64(0),
-		ConverterFunc: func(in interface{}) (interface{}, error) {
-			v, err := strconv.ParseFloat(in.(string), 64)
-			if err != nil {
-				return math.NaN(), nil
-			}
-			return v, nil
-		},
+	res, err := parseTiingoCSV(resp.Body, resp.ContentLength)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"Url":              url,
+			"Symbol":           symbol,
+			"Metric":           metric,
+			"Frequency":        frequency,
+			"StartTime":        begin.String(),
+			"EndTime":          end.String(),
+			logging.FieldError: err,
+			"StatusCode":       resp.StatusCode,
+		}).Debug("Failed to load eod prices -- parsing body failed")
+		return nil, err
 	}
 
-	res, err := imports.LoadFromCSV(context.TODO(), bytes.NewReader(body), imports.CSVLoadOptions{
-		DictateDataType: map[string]interface{}{
-			"date": imports.Converter{
-				ConcreteType: time.Time{},
-				ConverterFunc: func(in interface{}) (interface{}, error) {
-					return time.Parse("2006-01-02", in.(string))
-				},
-			},
-			"open":      floatConverter,
-			"high":      floatConverter,
-			"low":       floatConverter,
-			"close":     floatConverter,
-			"volume":    floatConverter,
-			"adjOpen":   floatConverter,
-			"adjHigh":   floatConverter,
-			"adjLow":    floatConverter,
-			"adjClose":  floatConverter,
-			"adjVolume": floatConverter,
-		},
-	})
-
-	err = nil
-	var timeSeries dataframe.Series
-	var valueSeries dataframe.Series
-
-	timeSeriesIdx, err := res.NameToColumn("date")
+	return res, nil
+}
+
+// tiingoCSVRowBytes approximate length of a row of the tiingo price CSV,
+// used to size series from the Content-Length of the response
+const tiingoCSVRowBytes = 120
+
+// parseTiingoCSV decode a tiingo price CSV as it is read from r, building
+// each series in place rather than buffering the body. contentLength is the
+// size of the body in bytes, or -1 if unknown, and only sizes the series.
+// The date column is named DateIdx; all other columns are float64 with
+// unparsable values as NaN.
+func parseTiingoCSV(r io.Reader, contentLength int64) (*dataframe.DataFrame, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
 	if err != nil {
-		return nil, errors.New("Cannot find time series")
+		if err == io.EOF {
+			return nil, dataframe.ErrNoRows
+		}
+		return nil, err
 	}
 
-	timeSeries = res.Series[timeSeriesIdx]
-	timeSeries.Rename(DateIdx)
+	rows := 0
+	if contentLength > 0 {
+		rows = int(contentLength/tiingoCSVRowBytes) + 1
+	}
 
-	switch metric {
-	case MetricOpen:
-		valueSeriesIdx, err := res.NameToColumn("open")
-		if err != nil {
-			return nil, errors.New("open metric not found")
-		}
-		valueSeries = res.Series[valueSeriesIdx]
-	case MetricHigh:
-		valueSeriesIdx, err := res.NameToColumn("high")
-		if err != nil {
-			return nil, errors.New("high metric not found")
-		}
-		valueSeries = res.Series[valueSeriesIdx]
-	case MetricLow:
-		valueSeriesIdx, err := res.NameToColumn("low")
-		if err != nil {
-			return nil, errors.New("low metric not found")
+	dateCol := -1
+	names := make([]string, len(header))
+	values := make([][]float64, len(header))
+	for ii, name := range header {
+		names[ii] = name
+		if name == "date" {
+			dateCol = ii
+			continue
 		}
-		valueSeries = res.Series[valueSeriesIdx]
-	case MetricClose:
-		valueSeriesIdx, err := res.NameToColumn("close")
-		if err != nil {
-			return nil, errors.New("close metric not found")
-		}
-		valueSeries = res.Series[valueSeriesIdx]
-	case MetricVolume:
-		valueSeriesIdx, err := res.NameToColumn("volume")
-		if err != nil {
-			return nil, errors.New("volume metric not found")
-		}
-		valueSeries = res.Series[valueSeriesIdx]
-	case MetricAdjustedOpen:
-		valueSeriesIdx, err := res.NameToColumn("adjOpen")
-		if err != nil {
-			return nil, errors.New("Adjusted open metric not found")
+		values[ii] = make([]float64, 0, rows)
+	}
+	if dateCol < 0 {
+		return nil, errors.New("Cannot find time series")
+	}
+	dates := make([]time.Time, 0, rows)
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
 		}
-		valueSeries = res.Series[valueSeriesIdx]
-	case MetricAdjustedHigh:
-		valueSeriesIdx, err := res.NameToColumn("adjHigh")
 		if err != nil {
-			return nil, errors.New("Adjusted high metric not found")
+			return nil, err
 		}
-		valueSeries = res.Series[valueSeriesIdx]
-	case MetricAdjustedLow:
-		valueSeriesIdx, err := res.NameToColumn("adjLow")
+
+		date, err := time.Parse("2006-01-02", record[dateCol])
 		if err != nil {
-			return nil, errors.New("Adjusted low metric not found")
+			return nil, err
 		}
-		valueSeries = res.Series[valueSeriesIdx]
-	case MetricAdjustedClose:
-		valueSeriesIdx, err := res.NameToColumn("adjClose")
-		if err != nil {
-			return nil, errors.New("Adjsuted close metric not found")
+		dates = append(dates, date)
+
+		for ii, field := range record {
+			if ii == dateCol {
+				continue
+			}
+			v, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				v = math.NaN()
+			}
+			values[ii] = append(values[ii], v)
 		}
-		valueSeries = res.Series[valueSeriesIdx]
-	default:
-		return nil, errors.New("Un-supported metric")
 	}
 
-	if err != nil {
-		return nil, err
+	if len(dates) == 0 {
+		return nil, dataframe.ErrNoRows
 	}
 
-	valueSeries.Rename(symbol)
-	df := dataframe.NewDataFrame(timeSeries, valueSeries)
+	series := make([]dataframe.Series, len(header))
+	for ii, name := range names {
+		if ii == dateCol {
+			// point into dates rather than allocating each time separately
+			timeSeries := dataframe.NewSeriesTime(DateIdx, nil)
+			timeSeries.Values = make([]*time.Time, len(dates))
+			for jj := range dates {
+				timeSeries.Values[jj] = &dates[jj]
+			}
+			series[ii] = timeSeries
+			continue
+		}
+		series[ii] = dataframe.NewSeriesFloat64(name, &dataframe.SeriesInit{Capacity: len(values[ii])}, values[ii])
+	}
 
-	return df, err
+	return dataframe.NewDataFrame(series...), nil
 }
@@ -0,0 +1,72 @@
+package data_test
+
+import (
+	"math"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	dataframe "github.com/rocketlaunchr/dataframe-go"
+
+	"main/data"
+)
+
+var _ = Describe("Tiingo", func() {
+	var (
+		dataProxy data.Manager
+	)
+
+	const url = "https://api.tiingo.com/tiingo/daily/XYZ/prices?startDate=2021-01-01&endDate=2021-02-01&format=csv&resampleFreq=Daily&token=TEST"
+	const header = "date,close,high,low,open,volume,adjClose,adjHigh,adjLow,adjOpen,adjVolume,divCash,splitFactor\n"
+
+	BeforeEach(func() {
+		dataProxy = data.NewManager(map[string]string{
+			"tiingo": "TEST",
+		})
+		dataProxy.Begin = time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+		dataProxy.End = time.Date(2021, time.February, 1, 0, 0, 0, 0, time.UTC)
+		dataProxy.Frequency = data.FrequencyDaily
+	})
+
+	Describe("When parsing prices", func() {
+		It("should read each row and treat missing values as NaN", func() {
+			httpmock.RegisterResponder("GET", url, httpmock.NewStringResponder(200, header+
+				"2021-01-04,10.0,10.5,9.5,9.8,1000,9.0,9.45,8.55,8.82,1000,0.0,1.0\n"+
+				"2021-01-05,,10.5,9.5,9.8,1000,,9.45,8.55,8.82,1000,0.0,1.0\n"))
+
+			df, err := dataProxy.GetData("XYZ")
+			Expect(err).To(BeNil())
+			Expect(df.NRows()).To(Equal(2))
+			Expect(df.Names()).To(Equal([]string{data.DateIdx, "XYZ"}))
+
+			row := df.Row(0, false, dataframe.SeriesName)
+			Expect(row[data.DateIdx]).To(Equal(time.Date(2021, time.January, 4, 0, 0, 0, 0, time.UTC)))
+			Expect(row["XYZ"]).To(Equal(9.0))
+
+			row = df.Row(1, false, dataframe.SeriesName)
+			Expect(row["XYZ"]).To(BeNil())
+			Expect(math.IsNaN(df.Series[1].(*dataframe.SeriesFloat64).Values[1])).To(BeTrue())
+			Expect(df.Series[1].NilCount()).To(Equal(1))
+		})
+
+		It("should fail when there are no prices", func() {
+			httpmock.RegisterResponder("GET", url, httpmock.NewStringResponder(200, header))
+			_, err := dataProxy.GetData("XYZ")
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("should fail on malformed dates", func() {
+			httpmock.RegisterResponder("GET", url, httpmock.NewStringResponder(200, header+
+				"01/04/2021,10.0,10.5,9.5,9.8,1000,9.0,9.45,8.55,8.82,1000,0.0,1.0\n"))
+			_, err := dataProxy.GetData("XYZ")
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("should fail on error responses", func() {
+			httpmock.RegisterResponder("GET", url, httpmock.NewStringResponder(404, "not found"))
+			_, err := dataProxy.GetData("XYZ")
+			Expect(err).NotTo(BeNil())
+		})
+	})
+})
@@ -0,0 +1,318 @@
+package data
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"main/logging"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Quota number of API calls a provider allows per token each hour and day
+type Quota struct {
+	Hourly int
+	Daily  int
+}
+
+// Quotas limits of the providers that enforce them, keyed by provider. Tiingo
+// limits are those of its power plan.
+var Quotas = map[string]Quota{
+	"tiingo": {Hourly: 10000, Daily: 100000},
+}
+
+// QuotaReserve fraction of each quota reserved for interactive requests; low
+// priority work is deferred once the rest is used
+const QuotaReserve = 0.1
+
+// UsageRecord API calls made with a token to a provider during the hour
+// starting at Hour. Tokens are identified by their TokenHash.
+type UsageRecord struct {
+	TokenHash string
+	Provider  string
+	Hour      time.Time
+	Calls     int
+}
+
+// UsageStore persists usage so it is shared by every process making calls
+type UsageStore interface {
+	// AddUsage add the calls of each record to the stored totals
+	AddUsage(ctx context.Context, records []UsageRecord) error
+
+	// UsageSince stored usage of tokenHash in the hours starting at or after
+	// since
+	UsageSince(ctx context.Context, tokenHash string, since time.Time) ([]UsageRecord, error)
+}
+
+// ProviderUsage calls made with a token to a provider in the current hour
+// and day and the provider's limits; limits are 0 if the provider has none
+type ProviderUsage struct {
+	Provider    string `json:"provider"`
+	HourCalls   int    `json:"hourCalls"`
+	DayCalls    int    `json:"dayCalls"`
+	HourlyLimit int    `json:"hourlyLimit"`
+	DailyLimit  int    `json:"dailyLimit"`
+	NearQuota   bool   `json:"nearQuota"`
+}
+
+// Usage API calls made by the providers of this process
+var Usage = NewUsageTracker()
+
+// TokenHash identifier of an API token that is stored in place of the token
+func TokenHash(token string) string {
+	if token == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// UsageDay start of the UTC day containing now; daily quotas reset at the
+// start of each day
+func UsageDay(now time.Time) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// usagePeriods start of the hour and UTC day containing now
+func usagePeriods(now time.Time) (time.Time, time.Time) {
+	return now.UTC().Truncate(time.Hour), UsageDay(now)
+}
+
+// SummarizeUsage usage of each provider in the current hour and day of now,
+// including providers with a quota that have not been called
+func SummarizeUsage(records []UsageRecord, now time.Time) []ProviderUsage {
+	hour, day := usagePeriods(now)
+
+	byProvider := map[string]*ProviderUsage{}
+	summary := func(provider string) *ProviderUsage {
+		u, ok := byProvider[provider]
+		if !ok {
+			quota := Quotas[provider]
+			u = &ProviderUsage{
+				Provider:    provider,
+				HourlyLimit: quota.Hourly,
+				DailyLimit:  quota.Daily,
+			}
+			byProvider[provider] = u
+		}
+		return u
+	}
+
+	for provider := range Quotas {
+		summary(provider)
+	}
+	for _, r := range records {
+		if r.Hour.Before(day) {
+			continue
+		}
+		u := summary(r.Provider)
+		u.DayCalls += r.Calls
+		if r.Hour.Equal(hour) {
+			u.HourCalls += r.Calls
+		}
+	}
+
+	res := make([]ProviderUsage, 0, len(byProvider))
+	for _, u := range byProvider {
+		u.NearQuota = nearQuota(Quotas[u.Provider], u.HourCalls, u.DayCalls)
+		res = append(res, *u)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Provider < res[j].Provider
+	})
+	return res
+}
+
+// nearQuota true if either limit of quota is within its reserve
+func nearQuota(quota Quota, hourCalls int, dayCalls int) bool {
+	if quota.Hourly > 0 && float64(hourCalls) >= float64(quota.Hourly)*(1-QuotaReserve) {
+		return true
+	}
+	return quota.Daily > 0 && float64(dayCalls) >= float64(quota.Daily)*(1-QuotaReserve)
+}
+
+type usageKey struct {
+	tokenHash string
+	provider  string
+	hour      time.Time
+}
+
+// UsageTracker counts the API calls made by a process. Counts include the
+// totals of other processes as of the last Sync.
+type UsageTracker struct {
+	mu      sync.Mutex
+	counts  map[usageKey]int
+	pending map[usageKey]int
+
+	// made calls this process made to each provider since it started
+	made map[string]int
+}
+
+// NewUsageTracker create a tracker that has not counted any calls
+func NewUsageTracker() *UsageTracker {
+	return &UsageTracker{
+		counts:  make(map[usageKey]int),
+		pending: make(map[usageKey]int),
+		made:    make(map[string]int),
+	}
+}
+
+// Record count a call made to provider with token
+func (u *UsageTracker) Record(provider string, token string) {
+	hour, _ := usagePeriods(time.Now())
+	key := usageKey{tokenHash: TokenHash(token), provider: provider, hour: hour}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.counts[key]++
+	u.pending[key]++
+	u.made[provider]++
+}
+
+// Made number of calls this process made to each provider since it started,
+// not including calls of other processes
+func (u *UsageTracker) Made() map[string]int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	made := make(map[string]int, len(u.made))
+	for provider, calls := range u.made {
+		made[provider] = calls
+	}
+	return made
+}
+
+// Calls number of calls made to provider with token in the current hour and
+// UTC day
+func (u *UsageTracker) Calls(provider string, token string) (int, int) {
+	hour, day := usagePeriods(time.Now())
+	tokenHash := TokenHash(token)
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	hourCalls, dayCalls := 0, 0
+	for key, calls := range u.counts {
+		if key.tokenHash != tokenHash || key.provider != provider || key.hour.Before(day) {
+			continue
+		}
+		dayCalls += calls
+		if key.hour.Equal(hour) {
+			hourCalls += calls
+		}
+	}
+	return hourCalls, dayCalls
+}
+
+// NearQuota true if the calls made to provider with token have used all but
+// the QuotaReserve of its hourly or daily limit. Low priority work, such as
+// the nightly update of saved portfolios, should be deferred until the
+// quota resets.
+func (u *UsageTracker) NearQuota(provider string, token string) bool {
+	quota, ok := Quotas[provider]
+	if !ok {
+		return false
+	}
+	hourCalls, dayCalls := u.Calls(provider, token)
+	return nearQuota(quota, hourCalls, dayCalls)
+}
+
+// Sync write the calls counted since the last sync to store and refresh the
+// counts of every token used by this process with the stored totals
+func (u *UsageTracker) Sync(ctx context.Context, store UsageStore) error {
+	_, day := usagePeriods(time.Now())
+
+	u.mu.Lock()
+	records := make([]UsageRecord, 0, len(u.pending))
+	for key, calls := range u.pending {
+		records = append(records, UsageRecord{TokenHash: key.tokenHash, Provider: key.provider, Hour: key.hour, Calls: calls})
+	}
+	u.pending = make(map[usageKey]int)
+
+	tokens := map[string]bool{}
+	for key := range u.counts {
+		if key.hour.Before(day) {
+			delete(u.counts, key)
+			continue
+		}
+		tokens[key.tokenHash] = true
+	}
+	u.mu.Unlock()
+
+	if len(records) > 0 {
+		if err := store.AddUsage(ctx, records); err != nil {
+			u.mu.Lock()
+			for _, r := range records {
+				u.pending[usageKey{tokenHash: r.TokenHash, provider: r.Provider, hour: r.Hour}] += r.Calls
+			}
+			u.mu.Unlock()
+			return err
+		}
+	}
+
+	for tokenHash := range tokens {
+		if err := u.refresh(ctx, store, tokenHash, day); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Refresh replace the counts of token with the totals in store, e.g. to
+// include calls other processes made before this one used the token
+func (u *UsageTracker) Refresh(ctx context.Context, store UsageStore, token string) error {
+	_, day := usagePeriods(time.Now())
+	return u.refresh(ctx, store, TokenHash(token), day)
+}
+
+func (u *UsageTracker) refresh(ctx context.Context, store UsageStore, tokenHash string, day time.Time) error {
+	stored, err := store.UsageSince(ctx, tokenHash, day)
+	if err != nil {
+		return err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	for key := range u.counts {
+		if key.tokenHash == tokenHash {
+			delete(u.counts, key)
+		}
+	}
+	for _, r := range stored {
+		u.counts[usageKey{tokenHash: r.TokenHash, provider: r.Provider, hour: r.Hour.UTC()}] += r.Calls
+	}
+	// calls that are not stored yet
+	for key, calls := range u.pending {
+		if key.tokenHash == tokenHash {
+			u.counts[key] += calls
+		}
+	}
+	return nil
+}
+
+// SyncEvery sync with store at interval until ctx is done
+func (u *UsageTracker) SyncEvery(ctx context.Context, store UsageStore, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := u.Sync(ctx, store); err != nil {
+			log.WithFields(log.Fields{
+				logging.FieldFunction: "data/usage.go:SyncEvery",
+				logging.FieldError:    err,
+			}).Warn("Could not sync provider usage")
+		}
+	}
+}
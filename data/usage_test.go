@@ -0,0 +1,183 @@
+package data_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"main/data"
+)
+
+// memoryUsageStore a usage store shared by trackers in place of the database
+type memoryUsageStore struct {
+	records []data.UsageRecord
+	err     error
+}
+
+func (s *memoryUsageStore) AddUsage(ctx context.Context, records []data.UsageRecord) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.records = append(s.records, records...)
+	return nil
+}
+
+func (s *memoryUsageStore) UsageSince(ctx context.Context, tokenHash string, since time.Time) ([]data.UsageRecord, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	res := []data.UsageRecord{}
+	for _, r := range s.records {
+		if r.TokenHash == tokenHash && !r.Hour.Before(since) {
+			res = append(res, r)
+		}
+	}
+	return res, nil
+}
+
+var _ = Describe("Usage", func() {
+	var (
+		tracker *data.UsageTracker
+		store   *memoryUsageStore
+	)
+
+	BeforeEach(func() {
+		data.Quotas["test"] = data.Quota{Hourly: 10, Daily: 100}
+		tracker = data.NewUsageTracker()
+		store = &memoryUsageStore{}
+	})
+
+	AfterEach(func() {
+		delete(data.Quotas, "test")
+	})
+
+	record := func(t *data.UsageTracker, token string, n int) {
+		for ii := 0; ii < n; ii++ {
+			t.Record("test", token)
+		}
+	}
+
+	Describe("When calls are recorded", func() {
+		It("should count them per token", func() {
+			record(tracker, "A", 3)
+			record(tracker, "B", 1)
+
+			hourCalls, dayCalls := tracker.Calls("test", "A")
+			Expect(hourCalls).To(Equal(3))
+			Expect(dayCalls).To(Equal(3))
+		})
+
+		It("should be near quota once the reserve is reached", func() {
+			record(tracker, "A", 8)
+			Expect(tracker.NearQuota("test", "A")).To(BeFalse())
+			record(tracker, "A", 1)
+			Expect(tracker.NearQuota("test", "A")).To(BeTrue())
+			Expect(tracker.NearQuota("test", "B")).To(BeFalse())
+		})
+
+		It("should never be near quota for providers without one", func() {
+			for ii := 0; ii < 1000; ii++ {
+				tracker.Record("other", "A")
+			}
+			Expect(tracker.NearQuota("other", "A")).To(BeFalse())
+		})
+
+		It("should count the calls made by the process per provider", func() {
+			other := data.NewUsageTracker()
+			record(other, "A", 8)
+			Expect(other.Sync(context.Background(), store)).To(Succeed())
+
+			record(tracker, "A", 2)
+			record(tracker, "B", 1)
+			Expect(tracker.Sync(context.Background(), store)).To(Succeed())
+			Expect(tracker.Made()).To(Equal(map[string]int{"test": 3}))
+		})
+	})
+
+	Describe("When syncing", func() {
+		It("should store each call once", func() {
+			record(tracker, "A", 3)
+			Expect(tracker.Sync(context.Background(), store)).To(Succeed())
+			Expect(tracker.Sync(context.Background(), store)).To(Succeed())
+
+			total := 0
+			for _, r := range store.records {
+				Expect(r.TokenHash).To(Equal(data.TokenHash("A")))
+				total += r.Calls
+			}
+			Expect(total).To(Equal(3))
+		})
+
+		It("should include calls made by other processes", func() {
+			other := data.NewUsageTracker()
+			record(other, "A", 8)
+			Expect(other.Sync(context.Background(), store)).To(Succeed())
+
+			record(tracker, "A", 1)
+			Expect(tracker.NearQuota("test", "A")).To(BeFalse())
+			Expect(tracker.Sync(context.Background(), store)).To(Succeed())
+
+			hourCalls, _ := tracker.Calls("test", "A")
+			Expect(hourCalls).To(Equal(9))
+			Expect(tracker.NearQuota("test", "A")).To(BeTrue())
+		})
+
+		It("should read the usage of a token it has not used on refresh", func() {
+			other := data.NewUsageTracker()
+			record(other, "A", 9)
+			Expect(other.Sync(context.Background(), store)).To(Succeed())
+
+			Expect(tracker.Refresh(context.Background(), store, "A")).To(Succeed())
+			Expect(tracker.NearQuota("test", "A")).To(BeTrue())
+		})
+
+		It("should keep calls that could not be stored", func() {
+			record(tracker, "A", 2)
+			store.err = errors.New("database unavailable")
+			Expect(tracker.Sync(context.Background(), store)).NotTo(Succeed())
+
+			store.err = nil
+			Expect(tracker.Sync(context.Background(), store)).To(Succeed())
+			Expect(store.records).To(HaveLen(1))
+			Expect(store.records[0].Calls).To(Equal(2))
+		})
+	})
+
+	Describe("When summarizing usage", func() {
+		It("should report the current hour and day with the limits", func() {
+			now := time.Date(2021, time.March, 10, 15, 30, 0, 0, time.UTC)
+			records := []data.UsageRecord{
+				{Provider: "test", Hour: time.Date(2021, time.March, 9, 23, 0, 0, 0, time.UTC), Calls: 50},
+				{Provider: "test", Hour: time.Date(2021, time.March, 10, 9, 0, 0, 0, time.UTC), Calls: 4},
+				{Provider: "test", Hour: time.Date(2021, time.March, 10, 15, 0, 0, 0, time.UTC), Calls: 2},
+				{Provider: "fred", Hour: time.Date(2021, time.March, 10, 15, 0, 0, 0, time.UTC), Calls: 1},
+			}
+
+			usage := data.SummarizeUsage(records, now)
+			byProvider := map[string]data.ProviderUsage{}
+			for _, u := range usage {
+				byProvider[u.Provider] = u
+			}
+
+			Expect(byProvider).To(HaveKey("tiingo"))
+			Expect(byProvider["tiingo"].DayCalls).To(Equal(0))
+			Expect(byProvider["fred"].HourCalls).To(Equal(1))
+			Expect(byProvider["fred"].HourlyLimit).To(Equal(0))
+			Expect(byProvider["test"]).To(Equal(data.ProviderUsage{
+				Provider:    "test",
+				HourCalls:   2,
+				DayCalls:    6,
+				HourlyLimit: 10,
+				DailyLimit:  100,
+			}))
+		})
+	})
+
+	It("should not store tokens", func() {
+		Expect(data.TokenHash("secret")).NotTo(ContainSubstring("secret"))
+		Expect(data.TokenHash("")).To(BeEmpty())
+	})
+})
@@ -1,7 +1,9 @@
 package database
 
 import (
+	"errors"
 	"os"
+	"strings"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/jmoiron/sqlx"
@@ -10,22 +12,73 @@ import (
 
 var Conn *sqlx.DB
 
+// ErrSQLiteUnsupported returned when DATABASE_URL names a SQLite database
+// and the binary was built without the sqlite build tag
+var ErrSQLiteUnsupported = errors.New("SQLite support requires building with -tags sqlite")
+
+// sqliteSupported set by sqlite.go when built with the sqlite build tag
+var sqliteSupported = false
+
+// migrationsURL source of the migrations for the configured database.
+// SQLite migrations are read from the local checkout unless MIGRATIONS_URL
+// is set.
+func migrationsURL() string {
+	if url := os.Getenv("MIGRATIONS_URL"); url != "" {
+		return url
+	}
+	if Current == SQLite {
+		return "file://database/migrations/sqlite"
+	}
+	return "github://jdfergason/pv-api/database/migrations"
+}
+
+// configure select the dialect from the scheme of DATABASE_URL. SQLite
+// databases are given as sqlite3://path/to/file.db
+func configure() (string, error) {
+	url := os.Getenv("DATABASE_URL")
+	if strings.HasPrefix(url, "sqlite3://") {
+		if !sqliteSupported {
+			return "", ErrSQLiteUnsupported
+		}
+		Current = SQLite
+		return url, nil
+	}
+
+	Current = Postgres
+	return url, nil
+}
+
 func SetupDatabaseMigrations() error {
-	m, err := migrate.New(
-		"github://jdfergason/pv-api/database/migrations",
-		os.Getenv("DATABASE_URL"))
+	url, err := configure()
 	if err != nil {
 		log.Fatal(err)
 		return err
 	}
-	m.Steps(2)
+
+	m, err := migrate.New(migrationsURL(), url)
+	if err != nil {
+		log.Fatal(err)
+		return err
+	}
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		log.Error(err)
+		return err
+	}
 	log.Info("Database migrated")
 	return nil
 }
 
 func Connect() error {
-	var err error
-	Conn, err = sqlx.Open("postgres", os.Getenv("DATABASE_URL"))
+	url, err := configure()
+	if err != nil {
+		return err
+	}
+
+	if Current == SQLite {
+		url = strings.TrimPrefix(url, "sqlite3://")
+	}
+
+	Conn, err = sqlx.Open(Current.Driver, url)
 	if err != nil {
 		return err
 	}
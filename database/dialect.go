@@ -0,0 +1,47 @@
+package database
+
+import "fmt"
+
+// Dialect the SQL that differs between the databases pv-api can run against
+type Dialect struct {
+	// Driver name of the database/sql driver
+	Driver string
+
+	// ForUpdate clause that locks the selected rows until the transaction
+	// ends. SQLite locks the database for the duration of a write
+	// transaction so no clause is needed.
+	ForUpdate string
+
+	// SkipLocked clause that locks the selected rows and skips rows locked
+	// by other transactions, so concurrent workers claim different rows
+	SkipLocked string
+
+	epoch string
+}
+
+var (
+	// Postgres the production database
+	Postgres = &Dialect{
+		Driver:     "postgres",
+		ForUpdate:  " FOR UPDATE",
+		SkipLocked: " FOR UPDATE SKIP LOCKED",
+		epoch:      "extract(epoch from %[1]s)::int",
+	}
+
+	// SQLite used for local development and the CLI tools
+	SQLite = &Dialect{
+		Driver:     "sqlite3",
+		ForUpdate:  "",
+		SkipLocked: "",
+		epoch:      "CAST(strftime('%%s', %[1]s) AS INTEGER)",
+	}
+)
+
+// Current the dialect of the database in DATABASE_URL; set by Connect
+var Current = Postgres
+
+// Epoch expression converting the timestamp column to seconds since the Unix
+// epoch, named after the column
+func (d *Dialect) Epoch(column string) string {
+	return fmt.Sprintf(d.epoch+" as %[1]s", column)
+}
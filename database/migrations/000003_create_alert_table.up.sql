@@ -0,0 +1,26 @@
+-- Create alert table that stores user-defined alert rules evaluated by the notifier
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS alert (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    userid VARCHAR(32) NOT NULL,
+    portfolio_id UUID REFERENCES portfolio(id) ON DELETE CASCADE,
+    kind VARCHAR(32) NOT NULL,
+    ticker VARCHAR(32),
+    threshold FLOAT NOT NULL,
+    lookback_days INT NOT NULL DEFAULT 7,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    triggered BOOLEAN NOT NULL DEFAULT FALSE,
+    last_triggered TIMESTAMP,
+    created TIMESTAMP NOT NULL DEFAULT now(),
+    lastchanged TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE INDEX alert_userid_idx ON alert(userid);
+CREATE INDEX alert_portfolio_id_idx ON alert(portfolio_id);
+
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON alert
+FOR EACH ROW
+EXECUTE FUNCTION trigger_set_timestamp();
+
+COMMIT;
\ No newline at end of file
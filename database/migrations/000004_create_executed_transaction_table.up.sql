@@ -0,0 +1,20 @@
+-- Create executed_transaction table that stores trades actually executed by the
+-- user in their brokerage account against a saved portfolio
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS executed_transaction (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    portfolio_id UUID NOT NULL REFERENCES portfolio(id) ON DELETE CASCADE,
+    userid VARCHAR(32) NOT NULL,
+    trade_date TIMESTAMP NOT NULL,
+    ticker VARCHAR(32) NOT NULL,
+    kind VARCHAR(16) NOT NULL,
+    shares FLOAT NOT NULL DEFAULT 0,
+    price_per_share FLOAT NOT NULL DEFAULT 0,
+    fees FLOAT NOT NULL DEFAULT 0,
+    total_value FLOAT NOT NULL,
+    created TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE INDEX executed_transaction_portfolio_id_idx ON executed_transaction(portfolio_id, trade_date);
+
+COMMIT;
\ No newline at end of file
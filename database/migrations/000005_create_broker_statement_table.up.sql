@@ -0,0 +1,15 @@
+-- Create broker_statement table that stores positions imported from a
+-- brokerage statement for reconciliation against the executed transactions
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS broker_statement (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    portfolio_id UUID NOT NULL REFERENCES portfolio(id) ON DELETE CASCADE,
+    userid VARCHAR(32) NOT NULL,
+    as_of TIMESTAMP NOT NULL,
+    positions JSONB NOT NULL,
+    created TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE INDEX broker_statement_portfolio_id_idx ON broker_statement(portfolio_id, as_of);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS portfolio_transaction;
+DROP TABLE IF EXISTS portfolio_update;
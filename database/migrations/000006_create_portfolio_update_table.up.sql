@@ -0,0 +1,32 @@
+-- Create portfolio_update ledger that records each nightly update of a
+-- portfolio so re-running an update is idempotent, and portfolio_transaction
+-- that stores the transactions computed for a portfolio
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS portfolio_update (
+    portfolio_id UUID NOT NULL REFERENCES portfolio(id) ON DELETE CASCADE,
+    through_date DATE NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'running',
+    ytd_return FLOAT,
+    cagr_since_inception FLOAT,
+    num_transactions INT NOT NULL DEFAULT 0,
+    started TIMESTAMP NOT NULL DEFAULT now(),
+    completed TIMESTAMP,
+    PRIMARY KEY (portfolio_id, through_date)
+);
+
+CREATE TABLE IF NOT EXISTS portfolio_transaction (
+    portfolio_id UUID NOT NULL REFERENCES portfolio(id) ON DELETE CASCADE,
+    trade_date TIMESTAMP NOT NULL,
+    ticker VARCHAR(32) NOT NULL,
+    kind VARCHAR(16) NOT NULL,
+    shares FLOAT NOT NULL,
+    price_per_share FLOAT NOT NULL,
+    fees FLOAT NOT NULL DEFAULT 0,
+    total_value FLOAT NOT NULL,
+    justification JSONB,
+    created TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (portfolio_id, trade_date, ticker, kind)
+);
+
+COMMIT;
\ No newline at end of file
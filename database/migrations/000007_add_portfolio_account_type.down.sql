@@ -0,0 +1,4 @@
+ALTER TABLE portfolio DROP COLUMN IF EXISTS account_type;
+ALTER TABLE portfolio DROP COLUMN IF EXISTS short_term_tax_rate;
+ALTER TABLE portfolio DROP COLUMN IF EXISTS long_term_tax_rate;
+ALTER TABLE portfolio DROP COLUMN IF EXISTS dividend_tax_rate;
@@ -0,0 +1,10 @@
+-- Add the account type of a portfolio and the tax rates used to calculate
+-- after-tax performance of taxable accounts
+BEGIN;
+
+ALTER TABLE portfolio ADD COLUMN IF NOT EXISTS account_type VARCHAR(16) NOT NULL DEFAULT 'taxable';
+ALTER TABLE portfolio ADD COLUMN IF NOT EXISTS short_term_tax_rate FLOAT NOT NULL DEFAULT 24.0;
+ALTER TABLE portfolio ADD COLUMN IF NOT EXISTS long_term_tax_rate FLOAT NOT NULL DEFAULT 15.0;
+ALTER TABLE portfolio ADD COLUMN IF NOT EXISTS dividend_tax_rate FLOAT NOT NULL DEFAULT 15.0;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS portfolio_benchmark;
+DROP TABLE IF EXISTS benchmark;
@@ -0,0 +1,31 @@
+-- Create benchmark table that stores user-defined benchmarks and
+-- portfolio_benchmark that attaches benchmarks to portfolios. Built-in
+-- benchmarks are not stored in the database and are referenced by their id
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS benchmark (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    userid VARCHAR(32) NOT NULL,
+    name VARCHAR(128) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    kind VARCHAR(16) NOT NULL,
+    allocation JSONB,
+    strategy_shortcode VARCHAR(8),
+    arguments JSONB,
+    created TIMESTAMP NOT NULL DEFAULT now(),
+    lastchanged TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE INDEX benchmark_userid_idx ON benchmark(userid);
+
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON benchmark
+FOR EACH ROW
+EXECUTE FUNCTION trigger_set_timestamp();
+
+CREATE TABLE IF NOT EXISTS portfolio_benchmark (
+    portfolio_id UUID NOT NULL REFERENCES portfolio(id) ON DELETE CASCADE,
+    benchmark_id VARCHAR(36) NOT NULL,
+    PRIMARY KEY (portfolio_id, benchmark_id)
+);
+
+COMMIT;
\ No newline at end of file
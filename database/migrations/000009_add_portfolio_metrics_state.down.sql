@@ -0,0 +1,5 @@
+ALTER TABLE portfolio DROP COLUMN IF EXISTS metrics_state;
+ALTER TABLE portfolio DROP COLUMN IF EXISTS std_dev;
+ALTER TABLE portfolio DROP COLUMN IF EXISTS sharpe_ratio;
+ALTER TABLE portfolio DROP COLUMN IF EXISTS sortino_ratio;
+ALTER TABLE portfolio DROP COLUMN IF EXISTS max_draw_down;
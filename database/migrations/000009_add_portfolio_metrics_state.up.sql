@@ -0,0 +1,11 @@
+-- Persist running metric statistics of a portfolio so the nightly update
+-- only processes new measurements, along with the metrics derived from them
+BEGIN;
+
+ALTER TABLE portfolio ADD COLUMN IF NOT EXISTS metrics_state JSONB;
+ALTER TABLE portfolio ADD COLUMN IF NOT EXISTS std_dev FLOAT;
+ALTER TABLE portfolio ADD COLUMN IF NOT EXISTS sharpe_ratio FLOAT;
+ALTER TABLE portfolio ADD COLUMN IF NOT EXISTS sortino_ratio FLOAT;
+ALTER TABLE portfolio ADD COLUMN IF NOT EXISTS max_draw_down FLOAT;
+
+COMMIT;
\ No newline at end of file
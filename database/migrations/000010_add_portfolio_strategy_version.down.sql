@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS strategy_migration;
+ALTER TABLE portfolio DROP COLUMN IF EXISTS strategy_version;
@@ -0,0 +1,20 @@
+-- Record the version of the strategy each portfolio's arguments were saved
+-- for, and an audit of the migrations applied to saved arguments when a
+-- strategy's argument schema changes
+BEGIN;
+
+ALTER TABLE portfolio ADD COLUMN IF NOT EXISTS strategy_version VARCHAR(16) NOT NULL DEFAULT '1.0.0';
+
+CREATE TABLE IF NOT EXISTS strategy_migration (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    portfolio_id UUID NOT NULL REFERENCES portfolio(id) ON DELETE CASCADE,
+    strategy_shortcode VARCHAR(8) NOT NULL,
+    from_version VARCHAR(16) NOT NULL,
+    to_version VARCHAR(16) NOT NULL,
+    old_arguments JSONB NOT NULL,
+    new_arguments JSONB NOT NULL,
+    migrated TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE INDEX strategy_migration_portfolio_id_idx ON strategy_migration(portfolio_id, migrated);
+
+COMMIT;
\ No newline at end of file
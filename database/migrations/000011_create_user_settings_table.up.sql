@@ -0,0 +1,22 @@
+-- Create user_settings table that stores each user's display and notification
+-- preferences. Users without a row use the default preferences
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS user_settings (
+    userid VARCHAR(32) PRIMARY KEY,
+    base_currency VARCHAR(3) NOT NULL DEFAULT 'USD',
+    digest VARCHAR(16) NOT NULL DEFAULT 'portfolio',
+    notification_channels JSONB NOT NULL DEFAULT '["email"]'::jsonb,
+    default_benchmark VARCHAR(36) NOT NULL DEFAULT 'sp500',
+    timezone VARCHAR(64) NOT NULL DEFAULT 'America/New_York',
+    number_format VARCHAR(16) NOT NULL DEFAULT '1,234.56',
+    created TIMESTAMP NOT NULL DEFAULT now(),
+    lastchanged TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON user_settings
+FOR EACH ROW
+EXECUTE FUNCTION trigger_set_timestamp();
+
+COMMIT;
\ No newline at end of file
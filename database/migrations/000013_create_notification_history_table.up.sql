@@ -0,0 +1,34 @@
+-- Create notification_history table recording each email sent by the
+-- notifier and notification_event recording the SendGrid delivery events
+-- received for those emails
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS notification_history (
+    message_id VARCHAR(128) PRIMARY KEY,
+    userid VARCHAR(32) NOT NULL,
+    portfolio_id UUID REFERENCES portfolio(id) ON DELETE SET NULL,
+    kind VARCHAR(16) NOT NULL,
+    frequency VARCHAR(16) NOT NULL DEFAULT '',
+    email VARCHAR(320) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'sent',
+    sent TIMESTAMP NOT NULL DEFAULT now(),
+    lastchanged TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE INDEX notification_history_userid_idx ON notification_history(userid, sent);
+
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON notification_history
+FOR EACH ROW
+EXECUTE FUNCTION trigger_set_timestamp();
+
+CREATE TABLE IF NOT EXISTS notification_event (
+    event_id VARCHAR(128) PRIMARY KEY,
+    message_id VARCHAR(128) NOT NULL,
+    email VARCHAR(320) NOT NULL,
+    event VARCHAR(16) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    occurred TIMESTAMP NOT NULL
+);
+CREATE INDEX notification_event_message_id_idx ON notification_event(message_id);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,32 @@
+-- Create notification_templates table mapping each kind of notification,
+-- channel, frequency, and locale to a SendGrid dynamic template or an inline
+-- Go template rendered by the notifier. An empty frequency applies to every
+-- frequency. Seeded with the SendGrid templates previously hardcoded in the
+-- notifier
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS notification_templates (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    kind VARCHAR(16) NOT NULL,
+    channel VARCHAR(16) NOT NULL,
+    frequency VARCHAR(16) NOT NULL DEFAULT '',
+    locale VARCHAR(16) NOT NULL,
+    sendgrid_template_id VARCHAR(64) NOT NULL DEFAULT '',
+    subject TEXT NOT NULL DEFAULT '',
+    body TEXT NOT NULL DEFAULT '',
+    created TIMESTAMP NOT NULL DEFAULT now(),
+    lastchanged TIMESTAMP NOT NULL DEFAULT now(),
+    UNIQUE (kind, channel, frequency, locale)
+);
+
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON notification_templates
+FOR EACH ROW
+EXECUTE FUNCTION trigger_set_timestamp();
+
+INSERT INTO notification_templates (kind, channel, locale, sendgrid_template_id) VALUES
+    ('portfolio', 'email', 'en-US', 'd-69e0989795c24f348959cf399024bd54'),
+    ('alert', 'email', 'en-US', 'd-a2c2b3a1f5e44f4e9b8f0d2c6e1b7a90'),
+    ('household', 'email', 'en-US', 'd-5b7e4f2c9a1d4e6b8c3f0a9d2e1b4c7f');
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE user_settings ALTER COLUMN number_format SET DEFAULT '1,234.56';
+ALTER TABLE user_settings DROP COLUMN IF EXISTS locale;
@@ -0,0 +1,8 @@
+-- Add the user's locale, used to translate notifications and format dates
+-- and numbers. An empty number format uses the locale's format
+BEGIN;
+
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS locale VARCHAR(16) NOT NULL DEFAULT 'en-US';
+ALTER TABLE user_settings ALTER COLUMN number_format SET DEFAULT '';
+
+COMMIT;
\ No newline at end of file
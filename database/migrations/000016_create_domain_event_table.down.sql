@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS event_consumer;
+DROP TABLE IF EXISTS domain_event;
@@ -0,0 +1,24 @@
+-- Create domain_event table storing events published to the event bus and
+-- event_consumer recording the last event each consumer has received
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS domain_event (
+    id BIGSERIAL PRIMARY KEY,
+    topic VARCHAR(64) NOT NULL,
+    data JSONB NOT NULL,
+    created TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE INDEX domain_event_topic_idx ON domain_event(topic, id);
+
+CREATE TABLE IF NOT EXISTS event_consumer (
+    consumer VARCHAR(64) PRIMARY KEY,
+    last_event_id BIGINT NOT NULL DEFAULT 0,
+    lastchanged TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON event_consumer
+FOR EACH ROW
+EXECUTE FUNCTION trigger_set_timestamp();
+
+COMMIT;
\ No newline at end of file
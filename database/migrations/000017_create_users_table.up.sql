@@ -0,0 +1,22 @@
+-- Create users table storing the Auth0 accounts of users so the notifier
+-- does not request each user from Auth0. Tiingo tokens are encrypted with
+-- USER_TOKEN_KEY
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS users (
+    userid VARCHAR(64) PRIMARY KEY,
+    name TEXT NOT NULL DEFAULT '',
+    email VARCHAR(320) NOT NULL DEFAULT '',
+    email_verified BOOLEAN NOT NULL DEFAULT FALSE,
+    tiingo_token TEXT NOT NULL DEFAULT '',
+    synced TIMESTAMP NOT NULL DEFAULT now(),
+    created TIMESTAMP NOT NULL DEFAULT now(),
+    lastchanged TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON users
+FOR EACH ROW
+EXECUTE FUNCTION trigger_set_timestamp();
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,14 @@
+-- Create user_audit table recording changes made to users and their
+-- portfolios when accounts are synchronized from Auth0
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS user_audit (
+    id BIGSERIAL PRIMARY KEY,
+    userid VARCHAR(64) NOT NULL,
+    action VARCHAR(32) NOT NULL,
+    detail JSONB NOT NULL,
+    created TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE INDEX user_audit_userid_idx ON user_audit(userid, created);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,8 @@
+-- Soft delete portfolios: deleted portfolios are kept in the trash, where they
+-- can be restored, until the notifier purges them after 30 days
+BEGIN;
+
+ALTER TABLE portfolio ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+CREATE INDEX IF NOT EXISTS portfolio_deleted_at_idx ON portfolio(deleted_at) WHERE deleted_at IS NOT NULL;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,22 @@
+-- Create job table: a work queue of strategy computations populated by the API
+-- and the notifier and processed by cmd/worker
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS job (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    kind VARCHAR(64) NOT NULL,
+    userid VARCHAR(63) NOT NULL DEFAULT '',
+    payload JSONB NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'queued',
+    result JSONB,
+    error TEXT,
+    attempts INT NOT NULL DEFAULT 0,
+    worker VARCHAR(128),
+    created TIMESTAMP NOT NULL DEFAULT now(),
+    started TIMESTAMP,
+    finished TIMESTAMP
+);
+CREATE INDEX job_queued_idx ON job(created) WHERE status = 'queued';
+CREATE INDEX job_running_idx ON job(started) WHERE status = 'running';
+
+COMMIT;
\ No newline at end of file
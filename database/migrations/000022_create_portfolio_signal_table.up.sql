@@ -0,0 +1,14 @@
+-- Create portfolio_signal table archiving the allocation the strategy of a
+-- portfolio selected on each date and the scores that justified it
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS portfolio_signal (
+    portfolio_id UUID NOT NULL REFERENCES portfolio(id) ON DELETE CASCADE,
+    signal_date DATE NOT NULL,
+    target JSONB NOT NULL,
+    justification JSONB,
+    created TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (portfolio_id, signal_date)
+);
+
+COMMIT;
\ No newline at end of file
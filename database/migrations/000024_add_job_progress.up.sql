@@ -0,0 +1,8 @@
+-- Add the progress of running jobs: percent complete and the date the
+-- simulation has reached
+BEGIN;
+
+ALTER TABLE job ADD COLUMN IF NOT EXISTS progress REAL NOT NULL DEFAULT 0;
+ALTER TABLE job ADD COLUMN IF NOT EXISTS progress_date TIMESTAMP;
+
+COMMIT;
\ No newline at end of file
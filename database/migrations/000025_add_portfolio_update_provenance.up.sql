@@ -0,0 +1,7 @@
+-- Add the provenance of each portfolio update: the code version, strategy
+-- version, arguments, and data snapshots the update was computed from
+BEGIN;
+
+ALTER TABLE portfolio_update ADD COLUMN IF NOT EXISTS provenance JSONB;
+
+COMMIT;
\ No newline at end of file
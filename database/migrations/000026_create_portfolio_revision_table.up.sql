@@ -0,0 +1,15 @@
+-- Create portfolio_revision table storing the changes to the history of a
+-- portfolio found when the notifier recomputes it, e.g. after dividends or
+-- splits were restated
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS portfolio_revision (
+    portfolio_id UUID NOT NULL REFERENCES portfolio(id) ON DELETE CASCADE,
+    through_date DATE NOT NULL,
+    num_changes INT NOT NULL,
+    report JSONB NOT NULL,
+    created TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (portfolio_id, through_date)
+);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS portfolio_org_id_idx;
+ALTER TABLE portfolio DROP COLUMN IF EXISTS org_id;
+DROP TABLE IF EXISTS organization_member;
+DROP TABLE IF EXISTS organization;
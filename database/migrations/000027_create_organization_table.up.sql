@@ -0,0 +1,26 @@
+-- Create organization and organization_member tables so groups of users,
+-- such as an advisor and their clients or an investment club, can share
+-- portfolios. Members are viewers, editors, or admins; portfolios owned by an
+-- organization keep the user that created them in userid
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS organization (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    created TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS organization_member (
+    org_id UUID NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    user_id VARCHAR(64) NOT NULL,
+    role VARCHAR(16) NOT NULL DEFAULT 'viewer',
+    notify BOOLEAN NOT NULL DEFAULT TRUE,
+    created TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (org_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS organization_member_user_idx ON organization_member(user_id);
+
+ALTER TABLE portfolio ADD COLUMN IF NOT EXISTS org_id UUID REFERENCES organization(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS portfolio_org_id_idx ON portfolio(org_id) WHERE org_id IS NOT NULL;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,24 @@
+-- Create portfolio_note table storing the decision journal of a portfolio:
+-- dated notes its users write, e.g. why a trade was skipped, optionally shown
+-- on the performance chart
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS portfolio_note (
+    id UUID PRIMARY KEY,
+    portfolio_id UUID NOT NULL REFERENCES portfolio(id) ON DELETE CASCADE,
+    userid VARCHAR(64) NOT NULL,
+    note_date TIMESTAMP NOT NULL,
+    text TEXT NOT NULL,
+    ticker VARCHAR(32) NOT NULL DEFAULT '',
+    show_on_chart BOOLEAN NOT NULL DEFAULT TRUE,
+    created TIMESTAMP NOT NULL DEFAULT now(),
+    lastchanged TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS portfolio_note_portfolio_idx ON portfolio_note(portfolio_id, note_date);
+
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON portfolio_note
+FOR EACH ROW
+EXECUTE FUNCTION trigger_set_timestamp();
+
+COMMIT;
\ No newline at end of file
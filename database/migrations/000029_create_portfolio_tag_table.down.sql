@@ -0,0 +1,3 @@
+ALTER TABLE portfolio DROP COLUMN IF EXISTS last_signal_change;
+ALTER TABLE portfolio DROP COLUMN IF EXISTS folder;
+DROP TABLE IF EXISTS portfolio_tag;
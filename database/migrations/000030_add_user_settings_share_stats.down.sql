@@ -0,0 +1 @@
+ALTER TABLE user_settings DROP COLUMN IF EXISTS share_stats;
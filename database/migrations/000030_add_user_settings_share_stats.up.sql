@@ -0,0 +1,7 @@
+-- Let users opt in to contributing the metrics of their portfolios to the
+-- anonymized community statistics of each strategy
+BEGIN;
+
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS share_stats BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMIT;
\ No newline at end of file
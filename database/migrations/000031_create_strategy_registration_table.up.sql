@@ -0,0 +1,22 @@
+-- Create strategy_registration table storing the marketplace status of
+-- strategies so they can be soft launched to a share of users, deprecated,
+-- or disabled without a redeploy. Strategies without a row are enabled for
+-- everyone
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS strategy_registration (
+    shortcode VARCHAR(8) PRIMARY KEY,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    deprecated BOOLEAN NOT NULL DEFAULT FALSE,
+    deprecation_note TEXT NOT NULL DEFAULT '',
+    replacement VARCHAR(8) NOT NULL DEFAULT '',
+    rollout_percent INTEGER NOT NULL DEFAULT 100 CHECK (rollout_percent BETWEEN 0 AND 100),
+    lastchanged TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON strategy_registration
+FOR EACH ROW
+EXECUTE FUNCTION trigger_set_timestamp();
+
+COMMIT;
\ No newline at end of file
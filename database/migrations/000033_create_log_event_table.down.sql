@@ -0,0 +1 @@
+DROP TABLE IF EXISTS log_event;
\ No newline at end of file
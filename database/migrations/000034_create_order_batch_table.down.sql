@@ -0,0 +1 @@
+DROP TABLE IF EXISTS order_batch;
\ No newline at end of file
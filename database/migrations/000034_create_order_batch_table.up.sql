@@ -0,0 +1,19 @@
+-- Create order_batch table storing the orders suggested for a portfolio
+-- while they await confirmation. Confirmed orders are recorded in
+-- executed_transaction; pending orders expire at expires
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS order_batch (
+    id UUID PRIMARY KEY,
+    portfolio_id UUID NOT NULL REFERENCES portfolio(id) ON DELETE CASCADE,
+    userid VARCHAR(64) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    signal_date TIMESTAMP NOT NULL,
+    orders JSONB NOT NULL,
+    expires TIMESTAMP NOT NULL,
+    resolved TIMESTAMP,
+    created TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS order_batch_portfolio_idx ON order_batch(portfolio_id, created);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE users DROP COLUMN IF EXISTS verification_sent;
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
+ALTER TABLE users DROP COLUMN IF EXISTS verified_email;
\ No newline at end of file
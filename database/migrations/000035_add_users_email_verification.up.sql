@@ -0,0 +1,9 @@
+-- Record email addresses verified by the service rather than Auth0 and when
+-- the last verification email was sent so resends can be rate limited
+BEGIN;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS verified_email VARCHAR(320) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS verification_sent TIMESTAMP;
+
+COMMIT;
\ No newline at end of file
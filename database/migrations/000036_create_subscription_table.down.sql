@@ -0,0 +1 @@
+DROP TABLE IF EXISTS subscription;
\ No newline at end of file
@@ -0,0 +1,25 @@
+-- Create portfolio tables that stores saved portfolios. SQLite has no uuid
+-- type; ids are stored as text generated in the uuid v4 format, and the
+-- lastchanged column is maintained by a trigger on each table
+
+CREATE TABLE IF NOT EXISTS portfolio (
+    id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6)))),
+    userid VARCHAR(32) NOT NULL,
+    name VARCHAR(32) NOT NULL,
+    strategy_shortcode VARCHAR(8) NOT NULL,
+    arguments TEXT NOT NULL,
+    start_date TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ytd_return FLOAT,
+    cagr_since_inception FLOAT,
+    notifications INT NOT NULL DEFAULT 1,
+    created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    lastchanged TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX portfolio_userid_idx ON portfolio(userid);
+
+CREATE TRIGGER portfolio_set_timestamp
+AFTER UPDATE ON portfolio
+FOR EACH ROW WHEN NEW.lastchanged = OLD.lastchanged
+BEGIN
+  UPDATE portfolio SET lastchanged = CURRENT_TIMESTAMP WHERE rowid = NEW.rowid;
+END;
\ No newline at end of file
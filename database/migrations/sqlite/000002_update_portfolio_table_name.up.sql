@@ -0,0 +1,4 @@
+-- Change the type of the name column on the portfolio to text so the size
+-- is unlimited. SQLite does not enforce the length of VARCHAR columns so
+-- there is nothing to change
+SELECT 1;
\ No newline at end of file
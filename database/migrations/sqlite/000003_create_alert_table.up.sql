@@ -0,0 +1,25 @@
+-- Create alert table that stores user-defined alert rules evaluated by the notifier
+
+CREATE TABLE IF NOT EXISTS alert (
+    id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6)))),
+    userid VARCHAR(32) NOT NULL,
+    portfolio_id TEXT REFERENCES portfolio(id) ON DELETE CASCADE,
+    kind VARCHAR(32) NOT NULL,
+    ticker VARCHAR(32),
+    threshold FLOAT NOT NULL,
+    lookback_days INT NOT NULL DEFAULT 7,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    triggered BOOLEAN NOT NULL DEFAULT FALSE,
+    last_triggered TIMESTAMP,
+    created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    lastchanged TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX alert_userid_idx ON alert(userid);
+CREATE INDEX alert_portfolio_id_idx ON alert(portfolio_id);
+
+CREATE TRIGGER alert_set_timestamp
+AFTER UPDATE ON alert
+FOR EACH ROW WHEN NEW.lastchanged = OLD.lastchanged
+BEGIN
+  UPDATE alert SET lastchanged = CURRENT_TIMESTAMP WHERE rowid = NEW.rowid;
+END;
\ No newline at end of file
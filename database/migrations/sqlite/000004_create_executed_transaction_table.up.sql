@@ -0,0 +1,17 @@
+-- Create executed_transaction table that stores trades actually executed by the
+-- user in their brokerage account against a saved portfolio
+
+CREATE TABLE IF NOT EXISTS executed_transaction (
+    id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6)))),
+    portfolio_id TEXT NOT NULL REFERENCES portfolio(id) ON DELETE CASCADE,
+    userid VARCHAR(32) NOT NULL,
+    trade_date TIMESTAMP NOT NULL,
+    ticker VARCHAR(32) NOT NULL,
+    kind VARCHAR(16) NOT NULL,
+    shares FLOAT NOT NULL DEFAULT 0,
+    price_per_share FLOAT NOT NULL DEFAULT 0,
+    fees FLOAT NOT NULL DEFAULT 0,
+    total_value FLOAT NOT NULL,
+    created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX executed_transaction_portfolio_id_idx ON executed_transaction(portfolio_id, trade_date);
\ No newline at end of file
@@ -0,0 +1,12 @@
+-- Create broker_statement table that stores positions imported from a
+-- brokerage statement for reconciliation against the executed transactions
+
+CREATE TABLE IF NOT EXISTS broker_statement (
+    id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6)))),
+    portfolio_id TEXT NOT NULL REFERENCES portfolio(id) ON DELETE CASCADE,
+    userid VARCHAR(32) NOT NULL,
+    as_of TIMESTAMP NOT NULL,
+    positions TEXT NOT NULL,
+    created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX broker_statement_portfolio_id_idx ON broker_statement(portfolio_id, as_of);
\ No newline at end of file
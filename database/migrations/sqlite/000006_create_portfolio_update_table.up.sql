@@ -0,0 +1,29 @@
+-- Create portfolio_update ledger that records each nightly update of a
+-- portfolio so re-running an update is idempotent, and portfolio_transaction
+-- that stores the transactions computed for a portfolio
+
+CREATE TABLE IF NOT EXISTS portfolio_update (
+    portfolio_id TEXT NOT NULL REFERENCES portfolio(id) ON DELETE CASCADE,
+    through_date DATE NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'running',
+    ytd_return FLOAT,
+    cagr_since_inception FLOAT,
+    num_transactions INT NOT NULL DEFAULT 0,
+    started TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed TIMESTAMP,
+    PRIMARY KEY (portfolio_id, through_date)
+);
+
+CREATE TABLE IF NOT EXISTS portfolio_transaction (
+    portfolio_id TEXT NOT NULL REFERENCES portfolio(id) ON DELETE CASCADE,
+    trade_date TIMESTAMP NOT NULL,
+    ticker VARCHAR(32) NOT NULL,
+    kind VARCHAR(16) NOT NULL,
+    shares FLOAT NOT NULL,
+    price_per_share FLOAT NOT NULL,
+    fees FLOAT NOT NULL DEFAULT 0,
+    total_value FLOAT NOT NULL,
+    justification TEXT,
+    created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (portfolio_id, trade_date, ticker, kind)
+);
\ No newline at end of file
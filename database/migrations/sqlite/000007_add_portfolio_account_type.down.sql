@@ -0,0 +1,4 @@
+ALTER TABLE portfolio DROP COLUMN account_type;
+ALTER TABLE portfolio DROP COLUMN short_term_tax_rate;
+ALTER TABLE portfolio DROP COLUMN long_term_tax_rate;
+ALTER TABLE portfolio DROP COLUMN dividend_tax_rate;
@@ -0,0 +1,7 @@
+-- Add the account type of a portfolio and the tax rates used to calculate
+-- after-tax performance of taxable accounts
+
+ALTER TABLE portfolio ADD COLUMN account_type VARCHAR(16) NOT NULL DEFAULT 'taxable';
+ALTER TABLE portfolio ADD COLUMN short_term_tax_rate FLOAT NOT NULL DEFAULT 24.0;
+ALTER TABLE portfolio ADD COLUMN long_term_tax_rate FLOAT NOT NULL DEFAULT 15.0;
+ALTER TABLE portfolio ADD COLUMN dividend_tax_rate FLOAT NOT NULL DEFAULT 15.0;
\ No newline at end of file
@@ -0,0 +1,30 @@
+-- Create benchmark table that stores user-defined benchmarks and
+-- portfolio_benchmark that attaches benchmarks to portfolios. Built-in
+-- benchmarks are not stored in the database and are referenced by their id
+
+CREATE TABLE IF NOT EXISTS benchmark (
+    id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6)))),
+    userid VARCHAR(32) NOT NULL,
+    name VARCHAR(128) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    kind VARCHAR(16) NOT NULL,
+    allocation TEXT,
+    strategy_shortcode VARCHAR(8),
+    arguments TEXT,
+    created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    lastchanged TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX benchmark_userid_idx ON benchmark(userid);
+
+CREATE TRIGGER benchmark_set_timestamp
+AFTER UPDATE ON benchmark
+FOR EACH ROW WHEN NEW.lastchanged = OLD.lastchanged
+BEGIN
+  UPDATE benchmark SET lastchanged = CURRENT_TIMESTAMP WHERE rowid = NEW.rowid;
+END;
+
+CREATE TABLE IF NOT EXISTS portfolio_benchmark (
+    portfolio_id TEXT NOT NULL REFERENCES portfolio(id) ON DELETE CASCADE,
+    benchmark_id VARCHAR(36) NOT NULL,
+    PRIMARY KEY (portfolio_id, benchmark_id)
+);
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE portfolio DROP COLUMN metrics_state;
+ALTER TABLE portfolio DROP COLUMN std_dev;
+ALTER TABLE portfolio DROP COLUMN sharpe_ratio;
+ALTER TABLE portfolio DROP COLUMN sortino_ratio;
+ALTER TABLE portfolio DROP COLUMN max_draw_down;
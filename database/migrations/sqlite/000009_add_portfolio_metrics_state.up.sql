@@ -0,0 +1,8 @@
+-- Persist running metric statistics of a portfolio so the nightly update
+-- only processes new measurements, along with the metrics derived from them
+
+ALTER TABLE portfolio ADD COLUMN metrics_state TEXT;
+ALTER TABLE portfolio ADD COLUMN std_dev FLOAT;
+ALTER TABLE portfolio ADD COLUMN sharpe_ratio FLOAT;
+ALTER TABLE portfolio ADD COLUMN sortino_ratio FLOAT;
+ALTER TABLE portfolio ADD COLUMN max_draw_down FLOAT;
\ No newline at end of file
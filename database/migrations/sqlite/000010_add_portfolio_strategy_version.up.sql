@@ -0,0 +1,17 @@
+-- Record the version of the strategy each portfolio's arguments were saved
+-- for, and an audit of the migrations applied to saved arguments when a
+-- strategy's argument schema changes
+
+ALTER TABLE portfolio ADD COLUMN strategy_version VARCHAR(16) NOT NULL DEFAULT '1.0.0';
+
+CREATE TABLE IF NOT EXISTS strategy_migration (
+    id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6)))),
+    portfolio_id TEXT NOT NULL REFERENCES portfolio(id) ON DELETE CASCADE,
+    strategy_shortcode VARCHAR(8) NOT NULL,
+    from_version VARCHAR(16) NOT NULL,
+    to_version VARCHAR(16) NOT NULL,
+    old_arguments TEXT NOT NULL,
+    new_arguments TEXT NOT NULL,
+    migrated TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX strategy_migration_portfolio_id_idx ON strategy_migration(portfolio_id, migrated);
\ No newline at end of file
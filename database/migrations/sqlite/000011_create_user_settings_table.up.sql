@@ -0,0 +1,21 @@
+-- Create user_settings table that stores each user's display and notification
+-- preferences. Users without a row use the default preferences
+
+CREATE TABLE IF NOT EXISTS user_settings (
+    userid VARCHAR(32) PRIMARY KEY,
+    base_currency VARCHAR(3) NOT NULL DEFAULT 'USD',
+    digest VARCHAR(16) NOT NULL DEFAULT 'portfolio',
+    notification_channels TEXT NOT NULL DEFAULT '["email"]',
+    default_benchmark VARCHAR(36) NOT NULL DEFAULT 'sp500',
+    timezone VARCHAR(64) NOT NULL DEFAULT 'America/New_York',
+    number_format VARCHAR(16) NOT NULL DEFAULT '1,234.56',
+    created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    lastchanged TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER user_settings_set_timestamp
+AFTER UPDATE ON user_settings
+FOR EACH ROW WHEN NEW.lastchanged = OLD.lastchanged
+BEGIN
+  UPDATE user_settings SET lastchanged = CURRENT_TIMESTAMP WHERE rowid = NEW.rowid;
+END;
\ No newline at end of file
@@ -0,0 +1,9 @@
+-- Create email_suppression table listing addresses that must not receive
+-- email because they bounced or reported messages as spam
+
+CREATE TABLE IF NOT EXISTS email_suppression (
+    email VARCHAR(320) PRIMARY KEY,
+    reason VARCHAR(32) NOT NULL,
+    detail TEXT NOT NULL DEFAULT '',
+    created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
\ No newline at end of file
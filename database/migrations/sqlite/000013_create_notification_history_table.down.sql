@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS notification_event;
+DROP TABLE IF EXISTS notification_history;
@@ -0,0 +1,33 @@
+-- Create notification_history table recording each email sent by the
+-- notifier and notification_event recording the SendGrid delivery events
+-- received for those emails
+
+CREATE TABLE IF NOT EXISTS notification_history (
+    message_id VARCHAR(128) PRIMARY KEY,
+    userid VARCHAR(32) NOT NULL,
+    portfolio_id TEXT REFERENCES portfolio(id) ON DELETE SET NULL,
+    kind VARCHAR(16) NOT NULL,
+    frequency VARCHAR(16) NOT NULL DEFAULT '',
+    email VARCHAR(320) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'sent',
+    sent TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    lastchanged TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX notification_history_userid_idx ON notification_history(userid, sent);
+
+CREATE TRIGGER notification_history_set_timestamp
+AFTER UPDATE ON notification_history
+FOR EACH ROW WHEN NEW.lastchanged = OLD.lastchanged
+BEGIN
+  UPDATE notification_history SET lastchanged = CURRENT_TIMESTAMP WHERE rowid = NEW.rowid;
+END;
+
+CREATE TABLE IF NOT EXISTS notification_event (
+    event_id VARCHAR(128) PRIMARY KEY,
+    message_id VARCHAR(128) NOT NULL,
+    email VARCHAR(320) NOT NULL,
+    event VARCHAR(16) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    occurred TIMESTAMP NOT NULL
+);
+CREATE INDEX notification_event_message_id_idx ON notification_event(message_id);
\ No newline at end of file
@@ -0,0 +1,6 @@
+-- Add the user's locale, used to translate notifications and format dates
+-- and numbers. An empty number format uses the locale's format. SQLite
+-- cannot change the default of an existing column; preferences are always
+-- saved with an explicit number format
+
+ALTER TABLE user_settings ADD COLUMN locale VARCHAR(16) NOT NULL DEFAULT 'en-US';
\ No newline at end of file
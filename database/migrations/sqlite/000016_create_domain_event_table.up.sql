@@ -0,0 +1,23 @@
+-- Create domain_event table storing events published to the event bus and
+-- event_consumer recording the last event each consumer has received
+
+CREATE TABLE IF NOT EXISTS domain_event (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    topic VARCHAR(64) NOT NULL,
+    data TEXT NOT NULL,
+    created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX domain_event_topic_idx ON domain_event(topic, id);
+
+CREATE TABLE IF NOT EXISTS event_consumer (
+    consumer VARCHAR(64) PRIMARY KEY,
+    last_event_id BIGINT NOT NULL DEFAULT 0,
+    lastchanged TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER event_consumer_set_timestamp
+AFTER UPDATE ON event_consumer
+FOR EACH ROW WHEN NEW.lastchanged = OLD.lastchanged
+BEGIN
+  UPDATE event_consumer SET lastchanged = CURRENT_TIMESTAMP WHERE rowid = NEW.rowid;
+END;
\ No newline at end of file
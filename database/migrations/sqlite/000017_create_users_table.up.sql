@@ -0,0 +1,21 @@
+-- Create users table storing the Auth0 accounts of users so the notifier
+-- does not request each user from Auth0. Tiingo tokens are encrypted with
+-- USER_TOKEN_KEY
+
+CREATE TABLE IF NOT EXISTS users (
+    userid VARCHAR(64) PRIMARY KEY,
+    name TEXT NOT NULL DEFAULT '',
+    email VARCHAR(320) NOT NULL DEFAULT '',
+    email_verified BOOLEAN NOT NULL DEFAULT FALSE,
+    tiingo_token TEXT NOT NULL DEFAULT '',
+    synced TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    lastchanged TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER users_set_timestamp
+AFTER UPDATE ON users
+FOR EACH ROW WHEN NEW.lastchanged = OLD.lastchanged
+BEGIN
+  UPDATE users SET lastchanged = CURRENT_TIMESTAMP WHERE rowid = NEW.rowid;
+END;
\ No newline at end of file
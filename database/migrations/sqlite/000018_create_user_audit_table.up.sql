@@ -0,0 +1,11 @@
+-- Create user_audit table recording changes made to users and their
+-- portfolios when accounts are synchronized from Auth0
+
+CREATE TABLE IF NOT EXISTS user_audit (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    userid VARCHAR(64) NOT NULL,
+    action VARCHAR(32) NOT NULL,
+    detail TEXT NOT NULL,
+    created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX user_audit_userid_idx ON user_audit(userid, created);
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS portfolio_deleted_at_idx;
+ALTER TABLE portfolio DROP COLUMN deleted_at;
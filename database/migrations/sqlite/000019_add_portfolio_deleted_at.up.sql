@@ -0,0 +1,5 @@
+-- Soft delete portfolios: deleted portfolios are kept in the trash, where they
+-- can be restored, until the notifier purges them after 30 days
+
+ALTER TABLE portfolio ADD COLUMN deleted_at TIMESTAMP;
+CREATE INDEX IF NOT EXISTS portfolio_deleted_at_idx ON portfolio(deleted_at) WHERE deleted_at IS NOT NULL;
\ No newline at end of file
@@ -0,0 +1,19 @@
+-- Create job table: a work queue of strategy computations populated by the API
+-- and the notifier and processed by cmd/worker
+
+CREATE TABLE IF NOT EXISTS job (
+    id TEXT PRIMARY KEY,
+    kind VARCHAR(64) NOT NULL,
+    userid VARCHAR(63) NOT NULL DEFAULT '',
+    payload TEXT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'queued',
+    result TEXT,
+    error TEXT,
+    attempts INT NOT NULL DEFAULT 0,
+    worker VARCHAR(128),
+    created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started TIMESTAMP,
+    finished TIMESTAMP
+);
+CREATE INDEX job_queued_idx ON job(created) WHERE status = 'queued';
+CREATE INDEX job_running_idx ON job(started) WHERE status = 'running';
\ No newline at end of file
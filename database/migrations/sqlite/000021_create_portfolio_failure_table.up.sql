@@ -0,0 +1,13 @@
+-- Create portfolio_failure table recording each portfolio the notifier could
+-- not update, with the stack trace of computations that panicked
+
+CREATE TABLE IF NOT EXISTS portfolio_failure (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    portfolio_id TEXT NOT NULL REFERENCES portfolio(id) ON DELETE CASCADE,
+    through_date DATE NOT NULL,
+    stage VARCHAR(32) NOT NULL,
+    error TEXT NOT NULL,
+    stack TEXT,
+    created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX portfolio_failure_portfolio_idx ON portfolio_failure(portfolio_id, through_date);
\ No newline at end of file
@@ -0,0 +1,11 @@
+-- Create portfolio_signal table archiving the allocation the strategy of a
+-- portfolio selected on each date and the scores that justified it
+
+CREATE TABLE IF NOT EXISTS portfolio_signal (
+    portfolio_id TEXT NOT NULL REFERENCES portfolio(id) ON DELETE CASCADE,
+    signal_date DATE NOT NULL,
+    target TEXT NOT NULL,
+    justification TEXT,
+    created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (portfolio_id, signal_date)
+);
\ No newline at end of file
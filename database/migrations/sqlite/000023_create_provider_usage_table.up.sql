@@ -0,0 +1,10 @@
+-- Create provider_usage table counting the API calls made to each data
+-- provider with a token per hour; tokens are stored as sha256 hashes
+
+CREATE TABLE IF NOT EXISTS provider_usage (
+    token_hash TEXT NOT NULL,
+    provider TEXT NOT NULL,
+    hour TIMESTAMP NOT NULL,
+    calls INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (token_hash, provider, hour)
+);
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE job DROP COLUMN progress_date;
+ALTER TABLE job DROP COLUMN progress;
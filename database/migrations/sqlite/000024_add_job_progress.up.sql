@@ -0,0 +1,5 @@
+-- Add the progress of running jobs: percent complete and the date the
+-- simulation has reached
+
+ALTER TABLE job ADD COLUMN progress REAL NOT NULL DEFAULT 0;
+ALTER TABLE job ADD COLUMN progress_date TIMESTAMP;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE portfolio_update DROP COLUMN provenance;
@@ -0,0 +1,4 @@
+-- Add the provenance of each portfolio update: the code version, strategy
+-- version, arguments, and data snapshots the update was computed from
+
+ALTER TABLE portfolio_update ADD COLUMN provenance TEXT;
\ No newline at end of file
@@ -0,0 +1,12 @@
+-- Create portfolio_revision table storing the changes to the history of a
+-- portfolio found when the notifier recomputes it, e.g. after dividends or
+-- splits were restated
+
+CREATE TABLE IF NOT EXISTS portfolio_revision (
+    portfolio_id TEXT NOT NULL REFERENCES portfolio(id) ON DELETE CASCADE,
+    through_date DATE NOT NULL,
+    num_changes INT NOT NULL,
+    report TEXT NOT NULL,
+    created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (portfolio_id, through_date)
+);
\ No newline at end of file
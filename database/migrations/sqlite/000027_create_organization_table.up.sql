@@ -0,0 +1,23 @@
+-- Create organization and organization_member tables so groups of users,
+-- such as an advisor and their clients or an investment club, can share
+-- portfolios. Members are viewers, editors, or admins; portfolios owned by an
+-- organization keep the user that created them in userid
+
+CREATE TABLE IF NOT EXISTS organization (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS organization_member (
+    org_id TEXT NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    user_id VARCHAR(64) NOT NULL,
+    role VARCHAR(16) NOT NULL DEFAULT 'viewer',
+    notify BOOLEAN NOT NULL DEFAULT TRUE,
+    created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (org_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS organization_member_user_idx ON organization_member(user_id);
+
+ALTER TABLE portfolio ADD COLUMN org_id TEXT REFERENCES organization(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS portfolio_org_id_idx ON portfolio(org_id) WHERE org_id IS NOT NULL;
\ No newline at end of file
@@ -0,0 +1,23 @@
+-- Create portfolio_note table storing the decision journal of a portfolio:
+-- dated notes its users write, e.g. why a trade was skipped, optionally shown
+-- on the performance chart
+
+CREATE TABLE IF NOT EXISTS portfolio_note (
+    id TEXT PRIMARY KEY,
+    portfolio_id TEXT NOT NULL REFERENCES portfolio(id) ON DELETE CASCADE,
+    userid VARCHAR(64) NOT NULL,
+    note_date TIMESTAMP NOT NULL,
+    text TEXT NOT NULL,
+    ticker VARCHAR(32) NOT NULL DEFAULT '',
+    show_on_chart BOOLEAN NOT NULL DEFAULT TRUE,
+    created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    lastchanged TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS portfolio_note_portfolio_idx ON portfolio_note(portfolio_id, note_date);
+
+CREATE TRIGGER portfolio_note_set_timestamp
+AFTER UPDATE ON portfolio_note
+FOR EACH ROW WHEN NEW.lastchanged = OLD.lastchanged
+BEGIN
+  UPDATE portfolio_note SET lastchanged = CURRENT_TIMESTAMP WHERE rowid = NEW.rowid;
+END;
\ No newline at end of file
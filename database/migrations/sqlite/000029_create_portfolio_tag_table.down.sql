@@ -0,0 +1,3 @@
+ALTER TABLE portfolio DROP COLUMN last_signal_change;
+ALTER TABLE portfolio DROP COLUMN folder;
+DROP TABLE IF EXISTS portfolio_tag;
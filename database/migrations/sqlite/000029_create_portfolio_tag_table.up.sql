@@ -0,0 +1,17 @@
+-- Organize saved portfolios with tags, e.g. retirement or experimental, and a
+-- folder path. last_signal_change records the last date the strategy's signal
+-- changed so portfolios can be sorted by it without reading their signals
+
+CREATE TABLE IF NOT EXISTS portfolio_tag (
+    portfolio_id TEXT NOT NULL REFERENCES portfolio(id) ON DELETE CASCADE,
+    tag VARCHAR(32) NOT NULL,
+    PRIMARY KEY (portfolio_id, tag)
+);
+
+ALTER TABLE portfolio ADD COLUMN folder VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE portfolio ADD COLUMN last_signal_change TIMESTAMP;
+
+UPDATE portfolio SET last_signal_change=(
+    SELECT MAX(s.signal_date) FROM portfolio_signal s WHERE s.portfolio_id=portfolio.id AND NOT EXISTS (
+        SELECT 1 FROM portfolio_signal p WHERE p.portfolio_id=s.portfolio_id AND p.target=s.target AND p.signal_date=(
+            SELECT MAX(signal_date) FROM portfolio_signal WHERE portfolio_id=s.portfolio_id AND signal_date < s.signal_date)));
\ No newline at end of file
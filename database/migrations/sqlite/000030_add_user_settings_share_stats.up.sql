@@ -0,0 +1,4 @@
+-- Let users opt in to contributing the metrics of their portfolios to the
+-- anonymized community statistics of each strategy
+
+ALTER TABLE user_settings ADD COLUMN share_stats BOOLEAN NOT NULL DEFAULT FALSE;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS strategy_registration;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS nightly_run;
\ No newline at end of file
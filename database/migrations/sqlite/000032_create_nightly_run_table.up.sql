@@ -0,0 +1,21 @@
+-- Create nightly_run table storing a report of each run of the notifier:
+-- portfolios processed, failures and their reasons, emails sent, duration,
+-- and data provider calls. Runs that crash stay running
+
+CREATE TABLE IF NOT EXISTS nightly_run (
+    id TEXT PRIMARY KEY,
+    job VARCHAR(32) NOT NULL,
+    for_date DATE NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'running',
+    started TIMESTAMP NOT NULL,
+    completed TIMESTAMP,
+    portfolios INTEGER NOT NULL DEFAULT 0,
+    succeeded INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    deferred INTEGER NOT NULL DEFAULT 0,
+    emails_sent INTEGER NOT NULL DEFAULT 0,
+    provider_calls TEXT NOT NULL DEFAULT '{}',
+    failures TEXT NOT NULL DEFAULT '[]',
+    reasons TEXT NOT NULL DEFAULT '[]'
+);
+CREATE INDEX IF NOT EXISTS nightly_run_started_idx ON nightly_run(started);
\ No newline at end of file
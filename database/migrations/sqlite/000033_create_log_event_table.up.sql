@@ -0,0 +1,20 @@
+-- Create log_event table storing warning and error log events when
+-- LOG_DATABASE_LEVEL is set, so support can look up the errors a user or
+-- portfolio ran into. severity is the logrus level; lower is more severe
+
+CREATE TABLE IF NOT EXISTS log_event (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    logged TIMESTAMP NOT NULL,
+    level VARCHAR(16) NOT NULL,
+    severity SMALLINT NOT NULL,
+    message TEXT NOT NULL,
+    function TEXT NOT NULL DEFAULT '',
+    userid VARCHAR(64) NOT NULL DEFAULT '',
+    portfolio_id VARCHAR(36) NOT NULL DEFAULT '',
+    strategy VARCHAR(16) NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    fields TEXT NOT NULL DEFAULT '{}'
+);
+CREATE INDEX IF NOT EXISTS log_event_userid_idx ON log_event(userid, logged);
+CREATE INDEX IF NOT EXISTS log_event_portfolio_idx ON log_event(portfolio_id, logged);
+CREATE INDEX IF NOT EXISTS log_event_logged_idx ON log_event(logged);
\ No newline at end of file
@@ -0,0 +1,16 @@
+-- Create order_batch table storing the orders suggested for a portfolio
+-- while they await confirmation. Confirmed orders are recorded in
+-- executed_transaction; pending orders expire at expires
+
+CREATE TABLE IF NOT EXISTS order_batch (
+    id TEXT PRIMARY KEY,
+    portfolio_id TEXT NOT NULL REFERENCES portfolio(id) ON DELETE CASCADE,
+    userid VARCHAR(64) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    signal_date TIMESTAMP NOT NULL,
+    orders TEXT NOT NULL,
+    expires TIMESTAMP NOT NULL,
+    resolved TIMESTAMP,
+    created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS order_batch_portfolio_idx ON order_batch(portfolio_id, created);
\ No newline at end of file
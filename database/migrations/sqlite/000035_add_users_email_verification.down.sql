@@ -0,0 +1,3 @@
+ALTER TABLE users DROP COLUMN verification_sent;
+ALTER TABLE users DROP COLUMN email_verified_at;
+ALTER TABLE users DROP COLUMN verified_email;
\ No newline at end of file
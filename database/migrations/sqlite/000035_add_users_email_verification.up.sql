@@ -0,0 +1,6 @@
+-- Record email addresses verified by the service rather than Auth0 and when
+-- the last verification email was sent so resends can be rate limited
+
+ALTER TABLE users ADD COLUMN verified_email VARCHAR(320) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN verification_sent TIMESTAMP;
\ No newline at end of file
@@ -0,0 +1,15 @@
+-- Create subscription table storing the Stripe subscription of each user.
+-- Users without a subscription are on the free plan; event_created orders
+-- the Stripe events the subscription is updated from
+
+CREATE TABLE IF NOT EXISTS subscription (
+    userid VARCHAR(64) PRIMARY KEY,
+    plan VARCHAR(32) NOT NULL,
+    status VARCHAR(32) NOT NULL,
+    trial_ends TIMESTAMP,
+    current_period_end TIMESTAMP,
+    stripe_customer_id VARCHAR(255) NOT NULL DEFAULT '',
+    stripe_subscription_id VARCHAR(255) NOT NULL DEFAULT '',
+    event_created TIMESTAMP NOT NULL,
+    created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
\ No newline at end of file
@@ -0,0 +1,14 @@
+//go:build sqlite
+// +build sqlite
+
+package database
+
+import (
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func init() {
+	sqliteSupported = true
+}
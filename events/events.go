@@ -0,0 +1,119 @@
+package events
+
+import (
+	"encoding/json"
+	"main/portfolio"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Topics of domain events
+const (
+	// TopicPortfolioUpdated a saved portfolio was recomputed and its
+	// performance persisted
+	TopicPortfolioUpdated = "portfolio.updated"
+
+	// TopicSignalChanged the holdings a strategy signals for a saved
+	// portfolio changed
+	TopicSignalChanged = "signal.changed"
+
+	// TopicJobCompleted a batch job such as the nightly notifier run finished
+	TopicJobCompleted = "job.completed"
+
+	// TopicNotificationSent a notification was delivered to the user's
+	// email provider
+	TopicNotificationSent = "notification.sent"
+
+	// TopicHistoryRevised recomputing a saved portfolio changed its stored
+	// history, e.g. because dividends or splits were restated
+	TopicHistoryRevised = "portfolio.history_revised"
+)
+
+// Event a domain event. Data is the JSON encoded payload; Value is the
+// payload as published and is only set for subscribers in the publishing
+// process. Subscribers receiving events from another process decode Data.
+type Event struct {
+	ID      int64           `json:"id"`
+	Topic   string          `json:"topic"`
+	Data    json.RawMessage `json:"data"`
+	Created time.Time       `json:"created"`
+	Value   interface{}     `json:"-"`
+}
+
+// Decode unmarshal the event's payload into v
+func (e *Event) Decode(v interface{}) error {
+	return json.Unmarshal(e.Data, v)
+}
+
+// Handler a subscriber to events of a topic
+type Handler func(e *Event) error
+
+// Bus delivers published events to the handlers subscribed to their topic
+type Bus interface {
+	Publish(topic string, payload interface{}) error
+	Subscribe(topic string, handler Handler)
+}
+
+// PortfolioUpdated payload of TopicPortfolioUpdated. The computed portfolio
+// and performance are only available to in-process subscribers.
+type PortfolioUpdated struct {
+	PortfolioID        uuid.UUID              `json:"portfolioId"`
+	UserID             string                 `json:"userId"`
+	Through            time.Time              `json:"through"`
+	YTDReturn          float64                `json:"ytdReturn"`
+	CagrSinceInception float64                `json:"cagrSinceInception"`
+	CurrentAsset       string                 `json:"currentAsset"`
+	Portfolio          *portfolio.Portfolio   `json:"-"`
+	Performance        *portfolio.Performance `json:"-"`
+}
+
+// SignalChanged payload of TopicSignalChanged
+type SignalChanged struct {
+	PortfolioID uuid.UUID `json:"portfolioId"`
+	UserID      string    `json:"userId"`
+	Date        time.Time `json:"date"`
+	Previous    string    `json:"previous"`
+	Current     string    `json:"current"`
+}
+
+// JobCompleted payload of TopicJobCompleted
+type JobCompleted struct {
+	Job       string    `json:"job"`
+	ForDate   time.Time `json:"forDate"`
+	Started   time.Time `json:"started"`
+	Completed time.Time `json:"completed"`
+	Succeeded int       `json:"succeeded"`
+	Failed    int       `json:"failed"`
+}
+
+// NotificationSent payload of TopicNotificationSent. PortfolioID is nil for
+// notifications that are not about a single portfolio.
+type NotificationSent struct {
+	MessageIDs  []string   `json:"messageIds"`
+	UserID      string     `json:"userId"`
+	Email       string     `json:"email"`
+	PortfolioID *uuid.UUID `json:"portfolioId"`
+	Kind        string     `json:"kind"`
+	Frequency   string     `json:"frequency"`
+}
+
+// HistoryRevised payload of TopicHistoryRevised
+type HistoryRevised struct {
+	PortfolioID uuid.UUID                 `json:"portfolioId"`
+	UserID      string                    `json:"userId"`
+	Through     time.Time                 `json:"through"`
+	Revision    portfolio.HistoryRevision `json:"revision"`
+}
+
+// SignalChange the previous and current holdings if the holdings in the
+// last two measurements of perf differ
+func SignalChange(perf *portfolio.Performance) (string, string, bool) {
+	n := len(perf.Measurements)
+	if n < 2 {
+		return "", "", false
+	}
+	previous := perf.Measurements[n-2].Holdings
+	current := perf.Measurements[n-1].Holdings
+	return previous, current, previous != current
+}
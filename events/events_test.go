@@ -0,0 +1,96 @@
+package events_test
+
+import (
+	"errors"
+	"main/events"
+	"main/portfolio"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Events", func() {
+	var (
+		bus *events.MemoryBus
+	)
+
+	BeforeEach(func() {
+		bus = events.NewMemoryBus()
+	})
+
+	Describe("When publishing to the memory bus", func() {
+		It("should deliver events only to subscribers of the topic", func() {
+			received := []*events.Event{}
+			bus.Subscribe(events.TopicSignalChanged, func(e *events.Event) error {
+				received = append(received, e)
+				return nil
+			})
+			bus.Subscribe(events.TopicJobCompleted, func(e *events.Event) error {
+				Fail("delivered to the wrong topic")
+				return nil
+			})
+
+			id := uuid.New()
+			payload := &events.SignalChanged{PortfolioID: id, UserID: "auth0|1", Previous: "VFINX", Current: "VUSTX"}
+			Expect(bus.Publish(events.TopicSignalChanged, payload)).To(Succeed())
+
+			Expect(received).To(HaveLen(1))
+			Expect(received[0].Topic).To(Equal(events.TopicSignalChanged))
+			Expect(received[0].Value).To(BeIdenticalTo(payload))
+
+			decoded := events.SignalChanged{}
+			Expect(received[0].Decode(&decoded)).To(Succeed())
+			Expect(decoded.PortfolioID).To(Equal(id))
+			Expect(decoded.Current).To(Equal("VUSTX"))
+		})
+
+		It("should deliver to every subscriber when one fails", func() {
+			calls := 0
+			bus.Subscribe(events.TopicJobCompleted, func(e *events.Event) error {
+				calls++
+				return errors.New("subscriber failed")
+			})
+			bus.Subscribe(events.TopicJobCompleted, func(e *events.Event) error {
+				calls++
+				panic("subscriber panicked")
+			})
+			bus.Subscribe(events.TopicJobCompleted, func(e *events.Event) error {
+				calls++
+				return nil
+			})
+
+			err := bus.Publish(events.TopicJobCompleted, &events.JobCompleted{Job: "notifier"})
+			Expect(err).To(MatchError("subscriber failed"))
+			Expect(calls).To(Equal(3))
+		})
+
+		It("should publish without subscribers", func() {
+			Expect(bus.Publish(events.TopicNotificationSent, &events.NotificationSent{})).To(Succeed())
+		})
+	})
+
+	Describe("When detecting signal changes", func() {
+		It("should compare the last two measurements", func() {
+			perf := &portfolio.Performance{
+				Measurements: []portfolio.PerformanceMeasurement{
+					{Holdings: "VFINX"},
+					{Holdings: "VFINX"},
+					{Holdings: "VUSTX"},
+				},
+			}
+			previous, current, changed := events.SignalChange(perf)
+			Expect(changed).To(BeTrue())
+			Expect(previous).To(Equal("VFINX"))
+			Expect(current).To(Equal("VUSTX"))
+
+			perf.Measurements = perf.Measurements[:2]
+			_, _, changed = events.SignalChange(perf)
+			Expect(changed).To(BeFalse())
+
+			perf.Measurements = perf.Measurements[:1]
+			_, _, changed = events.SignalChange(perf)
+			Expect(changed).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,89 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"main/logging"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// MemoryBus delivers events synchronously to subscribers in the publishing
+// process
+type MemoryBus struct {
+	mu          sync.RWMutex
+	subscribers map[string][]Handler
+}
+
+// NewMemoryBus create an in-process event bus
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{
+		subscribers: make(map[string][]Handler),
+	}
+}
+
+// Subscribe call handler for each event published to topic
+func (bus *MemoryBus) Subscribe(topic string, handler Handler) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	bus.subscribers[topic] = append(bus.subscribers[topic], handler)
+}
+
+// Publish deliver payload to the subscribers of topic
+func (bus *MemoryBus) Publish(topic string, payload interface{}) error {
+	e, err := newEvent(topic, payload)
+	if err != nil {
+		return err
+	}
+	return bus.Dispatch(e)
+}
+
+// Dispatch deliver an event to each subscriber of its topic. Every
+// subscriber receives the event even if an earlier one fails or panics; the
+// first error is returned.
+func (bus *MemoryBus) Dispatch(e *Event) error {
+	bus.mu.RLock()
+	handlers := bus.subscribers[e.Topic]
+	bus.mu.RUnlock()
+
+	var first error
+	for _, handler := range handlers {
+		if err := deliver(handler, e); err != nil {
+			log.WithFields(log.Fields{
+				logging.FieldFunction: "events/memory.go:Dispatch",
+				"Topic":               e.Topic,
+				"EventID":             e.ID,
+				logging.FieldError:    err,
+			}).Error("Event subscriber failed")
+			if first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
+
+// deliver call handler, converting a panic into an error
+func deliver(handler Handler, e *Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("subscriber panic: %v", r)
+		}
+	}()
+	return handler(e)
+}
+
+func newEvent(topic string, payload interface{}) (*Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &Event{
+		Topic:   topic,
+		Data:    data,
+		Created: time.Now(),
+		Value:   payload,
+	}, nil
+}
@@ -0,0 +1,97 @@
+package events
+
+import (
+	"database/sql"
+	"main/database"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresBus delivers events to subscribers in the publishing process and
+// stores them in the domain_event table so other processes can consume them
+// with Consume
+type PostgresBus struct {
+	*MemoryBus
+	db *sqlx.DB
+}
+
+// NewPostgresBus create an event bus that persists events to db
+func NewPostgresBus(db *sqlx.DB) *PostgresBus {
+	return &PostgresBus{
+		MemoryBus: NewMemoryBus(),
+		db:        db,
+	}
+}
+
+// Publish store the event and deliver it to in-process subscribers. An
+// error storing the event is returned after the event is delivered.
+func (bus *PostgresBus) Publish(topic string, payload interface{}) error {
+	e, err := newEvent(topic, payload)
+	if err != nil {
+		return err
+	}
+
+	// in-process subscribers receive the event even if it could not be stored
+	storeErr := bus.db.QueryRow(`INSERT INTO domain_event ("topic", "data", "created") VALUES ($1, $2, $3) RETURNING id`,
+		e.Topic, string(e.Data), e.Created).Scan(&e.ID)
+
+	if err := bus.Dispatch(e); err != nil {
+		return err
+	}
+	return storeErr
+}
+
+// Consume deliver up to limit stored events that consumer has not yet
+// received to the in-process subscribers, in the order they were published.
+// The consumer's position is saved after each event so a failing subscriber
+// does not block later events. Returns the number of events delivered.
+// Consumers should be processes other than the publisher, whose subscribers
+// already received the events when they were published.
+func (bus *PostgresBus) Consume(consumer string, limit int) (int, error) {
+	tx, err := bus.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	// lock the consumer's position so concurrent consumers with the same
+	// name do not deliver events twice
+	var last int64
+	err = tx.QueryRow(`SELECT last_event_id FROM event_consumer WHERE consumer=$1`+database.Current.ForUpdate, consumer).Scan(&last)
+	if err == sql.ErrNoRows {
+		_, err = tx.Exec(`INSERT INTO event_consumer ("consumer", "last_event_id") VALUES ($1, 0)`, consumer)
+	}
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	rows, err := tx.Query(`SELECT id, topic, data, created FROM domain_event WHERE id > $1 ORDER BY id LIMIT $2`, last, limit)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	pending := []*Event{}
+	for rows.Next() {
+		e := &Event{}
+		var data []byte
+		if err := rows.Scan(&e.ID, &e.Topic, &data, &e.Created); err != nil {
+			rows.Close()
+			tx.Rollback()
+			return 0, err
+		}
+		e.Data = data
+		pending = append(pending, e)
+	}
+	rows.Close()
+
+	for _, e := range pending {
+		bus.Dispatch(e)
+		if _, err := tx.Exec(`UPDATE event_consumer SET last_event_id=$1 WHERE consumer=$2`, e.ID, consumer); err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+
+	return len(pending), tx.Commit()
+}
@@ -0,0 +1,205 @@
+// Package export writes price frames and portfolio measurements as Parquet
+// or Arrow IPC streams so they can be loaded directly into pandas, polars, or
+// R. Data is converted to Arrow record batches of BatchSize rows and written
+// as each batch is built, so only one batch is held in Arrow memory at a time.
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"main/data"
+	"main/portfolio"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow/go/v7/arrow"
+	"github.com/apache/arrow/go/v7/arrow/array"
+	"github.com/apache/arrow/go/v7/arrow/ipc"
+	"github.com/apache/arrow/go/v7/arrow/memory"
+	"github.com/apache/arrow/go/v7/parquet"
+	"github.com/apache/arrow/go/v7/parquet/compress"
+	"github.com/apache/arrow/go/v7/parquet/pqarrow"
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+// Supported export formats
+const (
+	FormatParquet = "parquet"
+	FormatArrow   = "arrow"
+)
+
+// BatchSize number of rows in each record batch; each batch is a Parquet row
+// group
+const BatchSize = 16384
+
+// ErrUnknownFormat the requested format is not parquet or arrow
+var ErrUnknownFormat = errors.New("format must be parquet or arrow")
+
+// ContentType media type of a file in format
+func ContentType(format string) string {
+	if format == FormatArrow {
+		return "application/vnd.apache.arrow.stream"
+	}
+	return "application/vnd.apache.parquet"
+}
+
+// Extension file extension of a file in format
+func Extension(format string) string {
+	if format == FormatArrow {
+		return ".arrows"
+	}
+	return ".parquet"
+}
+
+// ParseFormat validate a format name; case insensitive
+func ParseFormat(format string) (string, error) {
+	switch format = strings.ToLower(format); format {
+	case FormatParquet, FormatArrow:
+		return format, nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// recordWriter writes record batches in an export format
+type recordWriter interface {
+	Write(rec arrow.Record) error
+	Close() error
+}
+
+func newRecordWriter(w io.Writer, format string, schema *arrow.Schema, mem memory.Allocator) (recordWriter, error) {
+	switch format {
+	case FormatParquet:
+		props := parquet.NewWriterProperties(
+			parquet.WithCompression(compress.Codecs.Snappy),
+			parquet.WithAllocator(mem),
+		)
+		// the parquet writer closes its sink if it can; w belongs to the caller
+		sink := struct{ io.Writer }{w}
+		return pqarrow.NewFileWriter(schema, sink, props, pqarrow.NewArrowWriterProperties(pqarrow.WithAllocator(mem)))
+	case FormatArrow:
+		return ipc.NewWriter(w, ipc.WithSchema(schema), ipc.WithAllocator(mem)), nil
+	}
+	return nil, ErrUnknownFormat
+}
+
+// writeBatches write n rows to w, calling appendRow to add row i to the
+// builder and flushing a record batch every BatchSize rows
+func writeBatches(w io.Writer, format string, schema *arrow.Schema, n int, appendRow func(b *array.RecordBuilder, i int) error) error {
+	mem := memory.NewGoAllocator()
+	rw, err := newRecordWriter(w, format, schema, mem)
+	if err != nil {
+		return err
+	}
+
+	b := array.NewRecordBuilder(mem, schema)
+	defer b.Release()
+
+	flush := func() error {
+		rec := b.NewRecord()
+		defer rec.Release()
+		if rec.NumRows() == 0 {
+			return nil
+		}
+		return rw.Write(rec)
+	}
+
+	for ii := 0; ii < n; ii++ {
+		if err := appendRow(b, ii); err != nil {
+			rw.Close()
+			return err
+		}
+		if (ii+1)%BatchSize == 0 {
+			if err := flush(); err != nil {
+				rw.Close()
+				return err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		rw.Close()
+		return err
+	}
+	return rw.Close()
+}
+
+// MeasurementSchema schema of exported measurements. Justification is a JSON
+// object since its keys differ between strategies.
+var MeasurementSchema = arrow.NewSchema([]arrow.Field{
+	{Name: "time", Type: arrow.FixedWidthTypes.Timestamp_s},
+	{Name: "value", Type: arrow.PrimitiveTypes.Float64},
+	{Name: "riskFreeValue", Type: arrow.PrimitiveTypes.Float64},
+	{Name: "holdings", Type: arrow.BinaryTypes.String},
+	{Name: "percentReturn", Type: arrow.PrimitiveTypes.Float64},
+	{Name: "leverage", Type: arrow.PrimitiveTypes.Float64},
+	{Name: "afterTaxValue", Type: arrow.PrimitiveTypes.Float64},
+	{Name: "justification", Type: arrow.BinaryTypes.String},
+}, nil)
+
+// WriteMeasurements write portfolio measurements to w in format
+func WriteMeasurements(w io.Writer, format string, measurements []portfolio.PerformanceMeasurement) error {
+	return writeBatches(w, format, MeasurementSchema, len(measurements), func(b *array.RecordBuilder, i int) error {
+		m := measurements[i]
+		justification, err := json.Marshal(m.Justification)
+		if err != nil {
+			return err
+		}
+
+		b.Field(0).(*array.TimestampBuilder).Append(arrow.Timestamp(m.Time))
+		b.Field(1).(*array.Float64Builder).Append(m.Value)
+		b.Field(2).(*array.Float64Builder).Append(m.RiskFreeValue)
+		b.Field(3).(*array.StringBuilder).Append(m.Holdings)
+		b.Field(4).(*array.Float64Builder).Append(m.PercentReturn)
+		b.Field(5).(*array.Float64Builder).Append(m.Leverage)
+		b.Field(6).(*array.Float64Builder).Append(m.AfterTaxValue)
+		b.Field(7).(*array.StringBuilder).Append(string(justification))
+		return nil
+	})
+}
+
+// WritePrices write a price frame with a data.DateIdx column and a float
+// column per ticker to w in format. Dates are written as a date column and
+// missing prices as nulls.
+func WritePrices(w io.Writer, format string, df *dataframe.DataFrame) error {
+	dateIdx, err := df.NameToColumn(data.DateIdx)
+	if err != nil {
+		return err
+	}
+
+	fields := []arrow.Field{{Name: "date", Type: arrow.FixedWidthTypes.Date32}}
+	columns := []int{}
+	for ii, series := range df.Series {
+		if ii == dateIdx {
+			continue
+		}
+		if _, ok := series.(*dataframe.SeriesFloat64); !ok {
+			return fmt.Errorf("column %s is not a float column", series.Name())
+		}
+		fields = append(fields, arrow.Field{Name: series.Name(), Type: arrow.PrimitiveTypes.Float64, Nullable: true})
+		columns = append(columns, ii)
+	}
+	schema := arrow.NewSchema(fields, nil)
+
+	dates := df.Series[dateIdx]
+	return writeBatches(w, format, schema, df.NRows(), func(b *array.RecordBuilder, i int) error {
+		date, ok := dates.Value(i).(time.Time)
+		if !ok {
+			return fmt.Errorf("row %d has no date", i)
+		}
+		b.Field(0).(*array.Date32Builder).Append(arrow.Date32FromTime(date))
+
+		for jj, col := range columns {
+			fb := b.Field(jj + 1).(*array.Float64Builder)
+			val, ok := df.Series[col].Value(i).(float64)
+			if !ok || math.IsNaN(val) {
+				fb.AppendNull()
+				continue
+			}
+			fb.Append(val)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,133 @@
+package export_test
+
+import (
+	"bytes"
+	"context"
+	"main/data"
+	"main/export"
+	"main/portfolio"
+	"math"
+	"time"
+
+	"github.com/apache/arrow/go/v7/arrow"
+	"github.com/apache/arrow/go/v7/arrow/array"
+	"github.com/apache/arrow/go/v7/arrow/ipc"
+	"github.com/apache/arrow/go/v7/arrow/memory"
+	"github.com/apache/arrow/go/v7/parquet/file"
+	"github.com/apache/arrow/go/v7/parquet/pqarrow"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+// readArrow the records of an Arrow IPC stream
+func readArrow(buf []byte) []arrow.Record {
+	r, err := ipc.NewReader(bytes.NewReader(buf))
+	Expect(err).To(BeNil())
+	defer r.Release()
+
+	records := []arrow.Record{}
+	for r.Next() {
+		rec := r.Record()
+		rec.Retain()
+		records = append(records, rec)
+	}
+	return records
+}
+
+// readParquet the table of a parquet file
+func readParquet(buf []byte) arrow.Table {
+	pf, err := file.NewParquetReader(bytes.NewReader(buf))
+	Expect(err).To(BeNil())
+	fr, err := pqarrow.NewFileReader(pf, pqarrow.ArrowReadProperties{}, memory.NewGoAllocator())
+	Expect(err).To(BeNil())
+	tbl, err := fr.ReadTable(context.Background())
+	Expect(err).To(BeNil())
+	return tbl
+}
+
+var _ = Describe("Export", func() {
+	var measurements []portfolio.PerformanceMeasurement
+
+	BeforeEach(func() {
+		measurements = make([]portfolio.PerformanceMeasurement, export.BatchSize+10)
+		for ii := range measurements {
+			measurements[ii] = portfolio.PerformanceMeasurement{
+				Time:          int64(86400 * ii),
+				Value:         10000 + float64(ii),
+				Holdings:      "VFINX",
+				Justification: map[string]interface{}{"score": float64(ii)},
+			}
+		}
+	})
+
+	Describe("When parsing formats", func() {
+		It("should accept parquet and arrow", func() {
+			Expect(export.ParseFormat("Parquet")).To(Equal(export.FormatParquet))
+			Expect(export.ParseFormat("arrow")).To(Equal(export.FormatArrow))
+			_, err := export.ParseFormat("csv")
+			Expect(err).To(Equal(export.ErrUnknownFormat))
+		})
+	})
+
+	Describe("When exporting measurements", func() {
+		It("should write an Arrow stream in batches", func() {
+			buf := bytes.Buffer{}
+			Expect(export.WriteMeasurements(&buf, export.FormatArrow, measurements)).To(Succeed())
+
+			records := readArrow(buf.Bytes())
+			Expect(records).To(HaveLen(2))
+			Expect(records[0].NumRows()).To(BeNumerically("==", export.BatchSize))
+			Expect(records[1].NumRows()).To(BeNumerically("==", 10))
+			Expect(records[0].Schema().Equal(export.MeasurementSchema)).To(BeTrue())
+
+			last := records[1]
+			Expect(last.Column(0).(*array.Timestamp).Value(9)).To(Equal(arrow.Timestamp(86400 * (export.BatchSize + 9))))
+			Expect(last.Column(1).(*array.Float64).Value(9)).To(Equal(10000.0 + export.BatchSize + 9))
+			Expect(last.Column(7).(*array.String).Value(0)).To(MatchJSON(`{"score": 16384}`))
+		})
+
+		It("should write a parquet file", func() {
+			buf := bytes.Buffer{}
+			Expect(export.WriteMeasurements(&buf, export.FormatParquet, measurements)).To(Succeed())
+
+			tbl := readParquet(buf.Bytes())
+			defer tbl.Release()
+			Expect(tbl.NumRows()).To(BeNumerically("==", len(measurements)))
+			Expect(tbl.Schema().Field(3).Name).To(Equal("holdings"))
+		})
+
+		It("should write the schema when there are no measurements", func() {
+			buf := bytes.Buffer{}
+			Expect(export.WriteMeasurements(&buf, export.FormatArrow, nil)).To(Succeed())
+			Expect(readArrow(buf.Bytes())).To(BeEmpty())
+		})
+	})
+
+	Describe("When exporting prices", func() {
+		It("should write a column per ticker with nulls for missing prices", func() {
+			df := dataframe.NewDataFrame(
+				dataframe.NewSeriesTime(data.DateIdx, nil,
+					time.Date(2021, time.January, 4, 0, 0, 0, 0, time.UTC),
+					time.Date(2021, time.January, 5, 0, 0, 0, 0, time.UTC)),
+				dataframe.NewSeriesFloat64("VFINX", nil, 340.5, 342.1),
+				dataframe.NewSeriesFloat64("PRIDX", nil, math.NaN(), 81.2),
+			)
+
+			buf := bytes.Buffer{}
+			Expect(export.WritePrices(&buf, export.FormatParquet, df)).To(Succeed())
+
+			tbl := readParquet(buf.Bytes())
+			defer tbl.Release()
+			Expect(tbl.NumCols()).To(BeNumerically("==", 3))
+			Expect(tbl.Schema().Field(2).Name).To(Equal("PRIDX"))
+
+			dates := tbl.Column(0).Data().Chunk(0).(*array.Date32)
+			Expect(dates.Value(1).ToTime()).To(Equal(time.Date(2021, time.January, 5, 0, 0, 0, 0, time.UTC)))
+
+			pridx := tbl.Column(2).Data().Chunk(0).(*array.Float64)
+			Expect(pridx.IsNull(0)).To(BeTrue())
+			Expect(pridx.Value(1)).To(Equal(81.2))
+		})
+	})
+})
@@ -5,18 +5,19 @@ module main
 go 1.15
 
 require (
+	github.com/apache/arrow/go/v7 v7.0.0
 	github.com/dgrijalva/jwt-go v3.2.0+incompatible
 	github.com/gofiber/fiber/v2 v2.4.1
 	github.com/gofiber/jwt/v2 v2.0.0
 	github.com/golang-migrate/migrate/v4 v4.14.2-0.20210203071828-a185b9e78f19
-	github.com/golang/protobuf v1.4.3
-	github.com/golang/snappy v0.0.1
+	github.com/golang/protobuf v1.5.2
+	github.com/golang/snappy v0.0.4
 	github.com/google/uuid v1.2.0
 	github.com/jarcoal/httpmock v1.0.8
 	github.com/jmoiron/sqlx v1.3.1
-	github.com/klauspost/compress v1.11.7 // indirect
 	github.com/lestrrat-go/jwx v1.0.8
 	github.com/mattn/go-runewidth v0.0.10 // indirect
+	github.com/mattn/go-sqlite3 v1.14.7
 	github.com/onsi/ginkgo v1.14.2
 	github.com/onsi/gomega v1.10.4
 	github.com/prometheus/common v0.15.0
@@ -24,10 +25,11 @@ require (
 	github.com/rocketlaunchr/dataframe-go v0.0.0-20201007021539-67b046771f0b
 	github.com/sendgrid/rest v2.6.2+incompatible // indirect
 	github.com/sendgrid/sendgrid-go v3.7.2+incompatible
-	github.com/sirupsen/logrus v1.7.0
+	github.com/sirupsen/logrus v1.8.1
 	github.com/valyala/fasthttp v1.19.0 // indirect
-	golang.org/x/sync v0.0.0-20201020160332-67f06af15bc9 // indirect
-	golang.org/x/sys v0.0.0-20210124154548-22da62e12c0c // indirect
-	gonum.org/v1/gonum v0.8.2
+	go.starlark.net v0.0.0-20210223155950-e043a3d3c984
+	gonum.org/v1/gonum v0.9.3
+	google.golang.org/grpc v1.41.0
+	google.golang.org/protobuf v1.27.1
 	gopkg.in/yaml.v2 v2.4.0 // indirect
 )
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"encoding/json"
+	"main/alert"
+	"main/repository"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// ListAlerts list all alerts for logged in user
+func ListAlerts(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	alerts, err := repository.Alerts.List(c.Context(), userID)
+	if err != nil {
+		log.Warnf("ListAlerts failed: %s", err)
+		return fiber.ErrNotFound
+	}
+
+	return c.JSON(alerts)
+}
+
+// CreateAlert create a new alert rule
+func CreateAlert(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	params := alert.Rule{
+		Active:       true,
+		LookbackDays: 7,
+	}
+	if err := json.Unmarshal(c.Body(), &params); err != nil {
+		log.Warnf("CreateAlert bad request: %s", err)
+		return fiber.ErrBadRequest
+	}
+	params.Ticker = strings.ToUpper(params.Ticker)
+
+	if err := params.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	// make sure the portfolio belongs to the user
+	if params.PortfolioID != nil {
+		p, err := repository.Portfolios.Get(c.Context(), params.PortfolioID.String(), userID)
+		if err != nil || p.UserID != userID {
+			log.Warnf("CreateAlert portfolio %s not found for user %s", params.PortfolioID, userID)
+			return fiber.ErrNotFound
+		}
+	}
+
+	if err := repository.Alerts.Create(c.Context(), &params, userID); err != nil {
+		log.Warnf("Failed to create alert for user %s: %s", userID, err)
+		return fiber.ErrBadRequest
+	}
+
+	return c.JSON(params)
+}
+
+// UpdateAlert update threshold, lookback, or active status of an alert
+func UpdateAlert(c *fiber.Ctx) error {
+	alertID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	a, err := repository.Alerts.Get(c.Context(), alertID, userID)
+	if err != nil {
+		log.Warnf("UpdateAlert %s failed: %s", alertID, err)
+		return fiber.ErrNotFound
+	}
+
+	// unmarshal on top of the existing alert so unspecified fields are kept;
+	// the kind and portfolio of an alert cannot be changed
+	id := a.ID
+	kind := a.Kind
+	portfolioID := a.PortfolioID
+	if err := json.Unmarshal(c.Body(), a); err != nil {
+		log.Warnf("UpdateAlert bad request: %s, for alert: %s", err, alertID)
+		return fiber.ErrBadRequest
+	}
+	a.ID = id
+	a.Kind = kind
+	a.PortfolioID = portfolioID
+	a.Ticker = strings.ToUpper(a.Ticker)
+
+	if err := a.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	if err := repository.Alerts.Update(c.Context(), a, userID); err != nil {
+		log.Warnf("UpdateAlert SQL update failed: %s for alert: %s", err, alertID)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(a)
+}
+
+// DeleteAlert delete alert
+func DeleteAlert(c *fiber.Ctx) error {
+	alertID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	if err := repository.Alerts.Delete(c.Context(), alertID, userID); err != nil {
+		log.Warnf("DeleteAlert delete failed: %s, for alert: %s", err, alertID)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(fiber.Map{"status": "success"})
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"encoding/json"
 	"main/data"
+	"main/logging"
 	"main/portfolio"
 	"runtime"
 	"strings"
@@ -29,9 +30,9 @@ func Benchmark(c *fiber.Ctx) (resp error) {
 	startDate, err := time.Parse("2006-01-02", startDateStr)
 	if err != nil {
 		log.WithFields(log.Fields{
-			"StartDateStr": startDateStr,
-			"EndDateStr":   endDateStr,
-			"Error":        err,
+			"StartDateStr":     startDateStr,
+			"EndDateStr":       endDateStr,
+			logging.FieldError: err,
 		}).Error("Cannoy parse start date query parameter")
 		return fiber.ErrNotAcceptable
 	}
@@ -45,14 +46,19 @@ func Benchmark(c *fiber.Ctx) (resp error) {
 		endDate, err = time.Parse("2006-01-02", endDateStr)
 		if err != nil {
 			log.WithFields(log.Fields{
-				"StartDateStr": startDateStr,
-				"EndDateStr":   endDateStr,
-				"Error":        err,
+				"StartDateStr":     startDateStr,
+				"EndDateStr":       endDateStr,
+				logging.FieldError: err,
 			}).Error("Cannoy parse end date query parameter")
 			return fiber.ErrNotAcceptable
 		}
 	}
 
+	encoding, err := parseEncoding(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
 	defer func() {
 		if err := recover(); err != nil {
 			stackSlice := make([]byte, 1024)
@@ -86,10 +92,10 @@ func Benchmark(c *fiber.Ctx) (resp error) {
 	if err := json.Unmarshal(c.Body(), &args); err != nil {
 		log.WithFields(
 			log.Fields{
-				"Error":      err,
-				"StatusCode": fiber.ErrBadRequest,
-				"Body":       c.Body(),
-				"Uri":        "/v1/benchmark",
+				logging.FieldError: err,
+				"StatusCode":       fiber.ErrBadRequest,
+				"Body":             c.Body(),
+				"Uri":              "/v1/benchmark",
 			}).Warn("/v1/benchmark called with invalid args")
 		return fiber.ErrBadRequest
 	}
@@ -98,8 +104,8 @@ func Benchmark(c *fiber.Ctx) (resp error) {
 		securityStart, err := manager.GetData(args.Ticker)
 		if err != nil {
 			log.WithFields(log.Fields{
-				"Symbol": args.Ticker,
-				"Error":  err,
+				"Symbol":           args.Ticker,
+				logging.FieldError: err,
 			}).Warn("Could not load symbol data")
 			return fiber.ErrBadRequest
 		}
@@ -114,11 +120,11 @@ func Benchmark(c *fiber.Ctx) (resp error) {
 	targetPortfolio := dataframe.NewDataFrame(dates, tickers)
 
 	p := portfolio.NewPortfolio(args.Ticker, &manager)
-	err = p.TargetPortfolio(10000, targetPortfolio)
+	err = p.TargetPortfolio(portfolio.DefaultInitialCapital, targetPortfolio)
 	if err != nil {
 		log.WithFields(log.Fields{
-			"Error":      err,
-			"StatusCode": fiber.ErrBadRequest,
+			logging.FieldError: err,
+			"StatusCode":       fiber.ErrBadRequest,
 		}).Warn("Error creating target portfolio")
 		return fiber.ErrBadRequest
 	}
@@ -131,5 +137,5 @@ func Benchmark(c *fiber.Ctx) (resp error) {
 	}
 	performance.BuildMetricsBundle()
 
-	return c.JSON(performance)
+	return sendPerformance(c, &performance, encoding)
 }
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"main/account"
+	"main/auth0"
+	"os"
+
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// auth0Hook request sent by the Auth0 post-registration and post-login
+// actions
+type auth0Hook struct {
+	Event string     `json:"event"`
+	User  auth0.User `json:"user"`
+}
+
+// Auth0Hook store the account of a user that registered or logged in. Users
+// that register with an address Auth0 has not verified are sent a
+// verification email. The request must carry the shared secret in
+// AUTH0_HOOK_SECRET as a bearer token.
+func Auth0Hook(c *fiber.Ctx) error {
+	secret := os.Getenv("AUTH0_HOOK_SECRET")
+	if secret == "" || subtle.ConstantTimeCompare([]byte(c.Get("Authorization")), []byte("Bearer "+secret)) != 1 {
+		log.Warn("Auth0Hook rejected request")
+		return fiber.ErrUnauthorized
+	}
+
+	hook := auth0Hook{}
+	if err := json.Unmarshal(c.Body(), &hook); err != nil {
+		log.Warnf("Auth0Hook bad request: %s", err)
+		return fiber.ErrBadRequest
+	}
+
+	if hook.Event != "post-registration" && hook.Event != "post-login" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "event must be post-registration or post-login"})
+	}
+
+	if _, err := account.Save(c.Context(), &hook.User, hook.Event); err != nil {
+		log.Warnf("Auth0Hook failed for user %s: %s", hook.User.UserID, err)
+		return fiber.ErrInternalServerError
+	}
+
+	if hook.Event == "post-registration" && !hook.User.EmailVerified {
+		sendVerification(c, hook.User.UserID)
+	}
+
+	return c.JSON(fiber.Map{"status": "success"})
+}
+
+// sendVerification email a newly registered user a link that verifies their
+// email address. Failures are logged; the user can request another link.
+func sendVerification(c *fiber.Ctx, userID string) {
+	verifier, err := account.NewVerifier()
+	if err != nil {
+		log.Infof("Not sending verification email to user %s: %s", userID, err)
+		return
+	}
+	if err := verifier.Send(c.Context(), userID); err != nil {
+		log.Warnf("Could not send verification email to user %s: %s", userID, err)
+	}
+}
+
+// SyncUsers synchronize the local user store with Auth0, removing users
+// deleted from Auth0 and their portfolios
+func SyncUsers(c *fiber.Ctx) error {
+	result, err := account.SyncAuth0(c.Context())
+	if err != nil {
+		log.Warnf("SyncUsers failed: %s", err)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(result)
+}
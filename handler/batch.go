@@ -0,0 +1,285 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"main/billing"
+	"main/data"
+	"main/middleware"
+	"main/portfolio"
+	"main/reporting"
+	"main/strategies"
+	"main/util"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// maxBatchRuns most strategy runs in a batch
+	maxBatchRuns = 10
+
+	// maxBatchConcurrency most runs of a batch computed at once
+	maxBatchConcurrency = 4
+
+	// batchLookbackYears history shared by the runs of a batch before the
+	// earliest start date; covers the lookback periods of the strategies.
+	// Runs that look back further download their data themselves.
+	batchLookbackYears = 2
+)
+
+// batchRun a strategy run in a batch. Dates are formatted 2006-01-02; the
+// start date defaults to the earliest the user's plan allows and the end
+// date to today.
+type batchRun struct {
+	Strategy  string                     `json:"strategy"`
+	Arguments map[string]json.RawMessage `json:"arguments"`
+	StartDate string                     `json:"startDate"`
+	EndDate   string                     `json:"endDate"`
+
+	strat     strategies.StrategyInfo
+	startDate time.Time
+	endDate   time.Time
+}
+
+// batchResult the outcome of a run; runs that fail do not fail the batch
+type batchResult struct {
+	Status      string      `json:"status"`
+	Message     string      `json:"message,omitempty"`
+	Performance interface{} `json:"performance,omitempty"`
+}
+
+// prepare validate the run and resolve its dates within limits
+func (r *batchRun) prepare(c *fiber.Ctx, limits *billing.Limits, today time.Time) error {
+	strat, ok := availableStrategy(c, r.Strategy)
+	if !ok {
+		return fmt.Errorf("strategy '%s' not found", r.Strategy)
+	}
+	r.strat = strat
+	if r.Arguments == nil {
+		r.Arguments = map[string]json.RawMessage{}
+	}
+
+	var err error
+	r.endDate = today
+	if r.EndDate != "" {
+		if r.endDate, err = time.Parse("2006-01-02", r.EndDate); err != nil {
+			return fmt.Errorf("invalid endDate '%s'", r.EndDate)
+		}
+	}
+	r.startDate = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+	if r.StartDate != "" {
+		if r.startDate, err = time.Parse("2006-01-02", r.StartDate); err != nil {
+			return fmt.Errorf("invalid startDate '%s'", r.StartDate)
+		}
+	}
+	if !r.endDate.After(r.startDate) {
+		return fmt.Errorf("endDate must be after startDate")
+	}
+
+	// the free plan limits how far back strategies are tested
+	earliest := limits.EarliestStart(r.endDate)
+	if r.StartDate == "" && r.startDate.Before(earliest) {
+		r.startDate = earliest
+	}
+	return limits.CheckBacktest(r.startDate, r.endDate)
+}
+
+// compute run the strategy and calculate its performance with data from
+// cache
+func (r *batchRun) compute(credentials map[string]string, cache *data.PriceCache, resolution string) (*portfolio.Performance, error) {
+	manager := data.NewManager(credentials)
+	manager.Begin = r.startDate
+	manager.End = r.endDate
+	manager.UseCache(cache)
+
+	stratObject, err := r.strat.Factory(r.Arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	// history is only required when an explicit start date is given
+	historyFrom := time.Time{}
+	if r.StartDate != "" {
+		historyFrom = r.startDate
+	}
+	if err := r.strat.CheckConstraints(r.Arguments, historyFrom, &manager); err != nil {
+		return nil, err
+	}
+
+	p, err := stratObject.Compute(&manager)
+	if err != nil {
+		return nil, err
+	}
+
+	p.Resolution = resolution
+	performance, err := p.CalculatePerformance(manager.End)
+	if err != nil {
+		return nil, err
+	}
+	if r.StartDate != "" {
+		performance.RequestedStart = r.startDate.Unix()
+	}
+	performance.BuildMetricsBundle()
+	return &performance, nil
+}
+
+// batchOutcome the performance of a run or why it failed
+type batchOutcome struct {
+	performance *portfolio.Performance
+	err         error
+}
+
+// batchConcurrency reserve the user's compute slots for the runs of a batch,
+// at most maxBatchConcurrency; the request's own slot computes one run
+func batchConcurrency(c *fiber.Ctx, runs int) int {
+	if runs > maxBatchConcurrency {
+		runs = maxBatchConcurrency
+	}
+	return 1 + middleware.ReserveConcurrent(c, runs-1)
+}
+
+// computeBatch compute runs concurrently, at most concurrency at a time, with
+// data shared through cache. Outcomes are in the order of runs.
+func computeBatch(runs []*batchRun, concurrency int, credentials map[string]string, cache *data.PriceCache, resolution string, userID string) []batchOutcome {
+	outcomes := make([]batchOutcome, len(runs))
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, concurrency)
+
+	for ii, run := range runs {
+		wg.Add(1)
+		go func(ii int, run *batchRun) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			defer func() {
+				if err := recover(); err != nil {
+					log.Error(err)
+					stack := debug.Stack()
+					reporting.Report(&util.PanicError{Stage: "compute", Value: err, Stack: string(stack)}, reporting.Context{
+						UserID:   userID,
+						Strategy: run.Strategy,
+					})
+					outcomes[ii] = batchOutcome{err: errors.New("strategy failed")}
+				}
+			}()
+
+			performance, err := run.compute(credentials, cache, resolution)
+			if err != nil {
+				log.Warnf("Cannot compute strategy %s in batch: %s", run.Strategy, err)
+			}
+			outcomes[ii] = batchOutcome{performance: performance, err: err}
+		}(ii, run)
+	}
+	wg.Wait()
+	return outcomes
+}
+
+// RunStrategyBatch run several strategies concurrently, e.g. to compare them,
+// sharing the data they download. The body is an array of runs; results are
+// keyed by the index of the run in the request. Each run counts as a request
+// against the rate limit, and runs compute in parallel only as far as the
+// user's compute budget has slots free.
+// @Description Run up to 10 strategies at once
+// @Id RunStrategyBatch
+// @Produce json
+// @Param resolution query string false "daily, weekly, or monthly (default)"
+// @Param encoding query string false "rows (default) or columnar"
+func RunStrategyBatch(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+	credentials := map[string]string{
+		"tiingo": claims["https://pennyvault.com/tiingo_token"].(string),
+	}
+
+	runs := []*batchRun{}
+	if err := json.Unmarshal(c.Body(), &runs); err != nil {
+		log.Warnf("RunStrategyBatch bad request: %s", err)
+		return fiber.ErrBadRequest
+	}
+	if len(runs) == 0 || len(runs) > maxBatchRuns {
+		msg := fmt.Sprintf("between 1 and %d strategies may be run at once", maxBatchRuns)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": msg})
+	}
+
+	resolution, err := parseResolution(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+	encoding, err := parseEncoding(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	limits, _, err := userLimits(c.Context(), userID)
+	if err != nil {
+		return planError(c, err)
+	}
+
+	year, month, day := time.Now().Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	// reject the batch if any run is invalid before computing any of them
+	var begin, end time.Time
+	for ii, run := range runs {
+		if run == nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": fmt.Sprintf("run %d is empty", ii)})
+		}
+		if err := run.prepare(c, &limits, today); err != nil {
+			var limitErr *billing.LimitError
+			if errors.As(err, &limitErr) {
+				return planError(c, err)
+			}
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": fmt.Sprintf("run %d: %s", ii, err)})
+		}
+		if begin.IsZero() || run.startDate.Before(begin) {
+			begin = run.startDate
+		}
+		if run.endDate.After(end) {
+			end = run.endDate
+		}
+	}
+
+	// the request was counted as the first run
+	if ok, err := middleware.ChargeRequests(c, len(runs)-1); !ok {
+		return err
+	}
+	concurrency := batchConcurrency(c, len(runs))
+
+	// runs share the data they download; each symbol is downloaded once for
+	// the period covering every run and the lookback before it
+	cache := data.NewPriceCache(begin.AddDate(-batchLookbackYears, 0, 0), end)
+
+	start := time.Now()
+	outcomes := computeBatch(runs, concurrency, credentials, cache, resolution, userID)
+	results := make(map[string]batchResult, len(runs))
+	for ii, outcome := range outcomes {
+		result := batchResult{Status: "success"}
+		switch {
+		case outcome.err != nil:
+			result = batchResult{Status: "error", Message: outcome.err.Error()}
+		case encoding == encodingColumnar:
+			result.Performance = outcome.performance.Columnar()
+		default:
+			result.Performance = outcome.performance
+		}
+		results[strconv.Itoa(ii)] = result
+	}
+
+	log.WithFields(log.Fields{
+		"Runs":        len(runs),
+		"Concurrency": concurrency,
+		"Duration":    time.Since(start).Round(time.Millisecond),
+		"Cached":      cache.Len(),
+	}).Info("Strategy batch calculated")
+
+	return c.JSON(fiber.Map{"results": results})
+}
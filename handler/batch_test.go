@@ -0,0 +1,213 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"main/billing"
+	"main/data"
+	"main/handler"
+	"main/middleware"
+	"main/portfolio"
+	"main/repository"
+	"main/strategies"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// noSubscriptions users without a subscription, who are on the free plan
+type noSubscriptions struct{}
+
+func (noSubscriptions) Get(ctx context.Context, userID string) (*billing.Subscription, error) {
+	return nil, sql.ErrNoRows
+}
+
+func (noSubscriptions) Save(ctx context.Context, sub *billing.Subscription) error {
+	return nil
+}
+
+// batchStrategy buys VFINX with the initial deposit after looking at its
+// prices over the prior 6 months, or fails if its fail argument is set
+type batchStrategy struct {
+	fail bool
+}
+
+// batchComputed number of times batchStrategy was computed
+var batchComputed int32
+
+func (s *batchStrategy) GetInfo() strategies.StrategyInfo {
+	return strategies.StrategyMap["batchtest"]
+}
+
+func (s *batchStrategy) Compute(manager *data.Manager) (*portfolio.Portfolio, error) {
+	atomic.AddInt32(&batchComputed, 1)
+	if s.fail {
+		return nil, errors.New("strategy failed")
+	}
+
+	manager.Begin = manager.Begin.AddDate(0, -6, 0)
+	if _, err := manager.GetData("VFINX"); err != nil {
+		return nil, err
+	}
+
+	date := time.Date(2020, time.January, 31, 0, 0, 0, 0, time.UTC)
+	p, err := portfolio.NewPortfolioFromTransactions("Batch", manager, []portfolio.Transaction{
+		{Date: date, Ticker: "$CASH", Kind: portfolio.DepositTransaction, TotalValue: 1000},
+		{Date: date, Ticker: "VFINX", Kind: portfolio.BuyTransaction, Shares: 10, PricePerShare: 100, TotalValue: 1000},
+	})
+	return &p, err
+}
+
+var _ = Describe("RunStrategyBatch", func() {
+	var app *fiber.App
+
+	BeforeEach(func() {
+		prices := `date,close,high,low,open,volume,adjClose,adjHigh,adjLow,adjOpen,adjVolume,divCash,splitFactor
+2020-01-31,100.0,100.0,100.0,100.0,0,100.0,100.0,100.0,100.0,0,0.0,1.0
+2020-02-28,90.0,90.0,90.0,90.0,0,90.0,90.0,90.0,90.0,0,0.0,1.0
+2020-03-31,80.0,80.0,80.0,80.0,0,80.0,80.0,80.0,80.0,0,0.0,1.0
+2020-04-30,95.0,95.0,95.0,95.0,0,95.0,95.0,95.0,95.0,0,0.0,1.0
+2020-05-29,100.0,100.0,100.0,100.0,0,100.0,100.0,100.0,100.0,0,0.0,1.0
+2020-06-30,105.0,105.0,105.0,105.0,0,105.0,105.0,105.0,105.0,0,0.0,1.0
+`
+		httpmock.RegisterResponder("GET", `=~^https://api\.tiingo\.com/tiingo/daily/VFINX/prices`,
+			httpmock.NewStringResponder(200, prices))
+
+		today := time.Now()
+		url := fmt.Sprintf("https://fred.stlouisfed.org/graph/fredgraph.csv?mode=fred&id=DTB3&cosd=1970-01-01&coed=%d-%02d-%02d&fq=Daily&fam=avg", today.Year(), today.Month(), today.Day())
+		httpmock.RegisterResponder("GET", url,
+			httpmock.NewStringResponder(200, "DATE,DTB3\n2020-01-02,1.5\n2020-04-01,0.1\n2020-07-01,0.1\n"))
+		data.InitializeDataManager()
+
+		repository.Subscriptions = noSubscriptions{}
+		if _, ok := strategies.StrategyMap["batchtest"]; !ok {
+			Expect(strategies.Register(strategies.StrategyInfo{
+				Name:      "Batch Test",
+				Shortcode: "batchtest",
+				Factory: func(args map[string]json.RawMessage) (strategies.Strategy, error) {
+					s := &batchStrategy{}
+					if raw, ok := args["fail"]; ok {
+						if err := json.Unmarshal(raw, &s.fail); err != nil {
+							return nil, err
+						}
+					}
+					return s, nil
+				},
+			})).To(Succeed())
+		}
+		atomic.StoreInt32(&batchComputed, 0)
+
+		app = fiber.New()
+		app.Post("/batch", func(c *fiber.Ctx) error {
+			c.Locals("user", &jwt.Token{Claims: jwt.MapClaims{
+				"sub":                                 "alice",
+				"https://pennyvault.com/tiingo_token": "TEST",
+			}})
+			return c.Next()
+		}, handler.RunStrategyBatch)
+	})
+
+	run := func(fail bool, startDate string) map[string]interface{} {
+		return map[string]interface{}{
+			"strategy":  "batchtest",
+			"arguments": map[string]bool{"fail": fail},
+			"startDate": startDate,
+			"endDate":   "2020-06-30",
+		}
+	}
+
+	post := func(runs ...map[string]interface{}) (int, map[string]interface{}) {
+		body, err := json.Marshal(runs)
+		Expect(err).To(BeNil())
+		req := httptest.NewRequest("POST", "/batch", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req, -1)
+		Expect(err).To(BeNil())
+
+		result := map[string]interface{}{}
+		if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+			Expect(json.NewDecoder(resp.Body).Decode(&result)).To(Succeed())
+		}
+		return resp.StatusCode, result
+	}
+
+	It("should key results by the index of the run", func() {
+		status, body := post(run(false, "2020-01-31"), run(false, "2020-02-28"))
+		Expect(status).To(Equal(fiber.StatusOK))
+		results := body["results"].(map[string]interface{})
+		Expect(results).To(HaveLen(2))
+		for _, key := range []string{"0", "1"} {
+			result := results[key].(map[string]interface{})
+			Expect(result["status"]).To(Equal("success"))
+			Expect(result["performance"]).NotTo(BeNil())
+		}
+
+		// the performance of each run starts at its own start date
+		first := results["0"].(map[string]interface{})["performance"].(map[string]interface{})
+		second := results["1"].(map[string]interface{})["performance"].(map[string]interface{})
+		Expect(first["requestedStart"]).To(BeNumerically("<", second["requestedStart"]))
+	})
+
+	It("should download the data of the runs and their lookback once", func() {
+		httpmock.ZeroCallCounters()
+		status, _ := post(run(false, "2020-01-31"), run(false, "2020-02-28"))
+		Expect(status).To(Equal(fiber.StatusOK))
+		Expect(httpmock.GetTotalCallCount()).To(Equal(1))
+	})
+
+	It("should not fail the batch when a run fails", func() {
+		status, body := post(run(false, "2020-01-31"), run(true, "2020-01-31"))
+		Expect(status).To(Equal(fiber.StatusOK))
+		results := body["results"].(map[string]interface{})
+		Expect(results["0"].(map[string]interface{})["status"]).To(Equal("success"))
+		failed := results["1"].(map[string]interface{})
+		Expect(failed["status"]).To(Equal("error"))
+		Expect(failed["message"]).To(Equal("strategy failed"))
+	})
+
+	It("should run at most 10 strategies", func() {
+		runs := make([]map[string]interface{}, 11)
+		for ii := range runs {
+			runs[ii] = run(false, "2020-01-31")
+		}
+		status, _ := post(runs...)
+		Expect(status).To(Equal(fiber.StatusBadRequest))
+		Expect(atomic.LoadInt32(&batchComputed)).To(BeEquivalentTo(0))
+	})
+
+	It("should count each run against the rate limit", func() {
+		app = fiber.New()
+		app.Post("/batch", func(c *fiber.Ctx) error {
+			c.Locals("user", &jwt.Token{Claims: jwt.MapClaims{
+				"sub":                                 "alice",
+				"https://pennyvault.com/tiingo_token": "TEST",
+			}})
+			return c.Next()
+		}, middleware.RateLimit(middleware.RateLimitConfig{PerMinute: 3}), handler.RunStrategyBatch)
+
+		status, _ := post(run(false, "2020-01-31"), run(false, "2020-02-28"))
+		Expect(status).To(Equal(fiber.StatusOK))
+
+		status, _ = post(run(false, "2020-01-31"), run(false, "2020-02-28"))
+		Expect(status).To(Equal(fiber.StatusTooManyRequests))
+		Expect(atomic.LoadInt32(&batchComputed)).To(BeEquivalentTo(2))
+	})
+
+	It("should reject the batch when a run is beyond the plan", func() {
+		status, body := post(run(false, "2020-01-31"), run(false, "1990-01-31"))
+		Expect(status).To(Equal(fiber.StatusPaymentRequired))
+		Expect(body["plan"]).To(Equal(billing.PlanFree))
+		Expect(atomic.LoadInt32(&batchComputed)).To(BeEquivalentTo(0))
+	})
+})
@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"main/benchmark"
+	"main/portfolio"
+	"main/repository"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// loadBenchmarks resolve benchmark ids to either a built-in benchmark or a
+// custom benchmark owned by userID
+func loadBenchmarks(ctx context.Context, ids []string, userID string) ([]benchmark.Benchmark, error) {
+	benchmarks := make([]benchmark.Benchmark, 0, len(ids))
+	for _, id := range ids {
+		if b, ok := benchmark.Preset(id); ok {
+			benchmarks = append(benchmarks, b)
+			continue
+		}
+
+		if _, err := uuid.Parse(id); err != nil {
+			return nil, errors.New("unknown benchmark " + id)
+		}
+
+		b, err := repository.Benchmarks.Get(ctx, id, userID)
+		if err != nil {
+			return nil, errors.New("unknown benchmark " + id)
+		}
+		benchmarks = append(benchmarks, b)
+	}
+	return benchmarks, nil
+}
+
+// benchmarkIDs benchmarks requested with the benchmarks query parameter, or
+// attached if none were requested. If neither are available the user's
+// default benchmark is used.
+func benchmarkIDs(c *fiber.Ctx, attached []string) []string {
+	if requested := c.Query("benchmarks"); requested != "" {
+		ids := []string{}
+		for _, id := range strings.Split(requested, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				ids = append(ids, id)
+			}
+		}
+		return ids
+	}
+
+	if len(attached) > 0 {
+		return attached
+	}
+
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	prefs, err := loadPreferences(c.Context(), claims["sub"].(string))
+	if err != nil {
+		log.Warnf("Cannot load preferences, using default benchmark: %s", err)
+		return []string{benchmark.DefaultID}
+	}
+	return []string{prefs.DefaultBenchmark}
+}
+
+// attachedBenchmarkIDs ids of the benchmarks attached to a portfolio
+func attachedBenchmarkIDs(ctx context.Context, portfolioID string) []string {
+	ids, err := repository.Benchmarks.Attached(ctx, portfolioID)
+	if err != nil {
+		log.Warnf("Cannot load benchmarks for portfolio %s: %s", portfolioID, err)
+		return []string{}
+	}
+	return ids
+}
+
+// compareToBenchmarks calculate the performance of perf relative to each of
+// the benchmarks. Benchmarks that cannot be computed are skipped.
+func compareToBenchmarks(c *fiber.Ctx, perf *portfolio.Performance, ids []string) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	benchmarks, err := loadBenchmarks(c.Context(), ids, userID)
+	if err != nil {
+		return err
+	}
+
+	perf.Benchmarks = make([]portfolio.RelativeMetrics, 0, len(benchmarks))
+	for ii := range benchmarks {
+		manager := newDataManager(c)
+		metrics, err := benchmarks[ii].Compare(perf, &manager)
+		if err != nil {
+			log.Warnf("Cannot compare to benchmark %s: %s", benchmarks[ii].ID, err)
+			continue
+		}
+		perf.Benchmarks = append(perf.Benchmarks, metrics)
+	}
+
+	if len(perf.Benchmarks) > 0 {
+		perf.MetricsBundle.TrackingError = perf.Benchmarks[0].TrackingError
+		perf.MetricsBundle.InformationRatio = perf.Benchmarks[0].InformationRatio
+	}
+
+	return nil
+}
+
+// ListBenchmarks list built-in benchmarks and the custom benchmarks of the
+// logged in user
+func ListBenchmarks(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	benchmarks := make([]benchmark.Benchmark, 0, len(benchmark.Presets))
+	benchmarks = append(benchmarks, benchmark.Presets...)
+
+	custom, err := repository.Benchmarks.List(c.Context(), userID)
+	if err != nil {
+		log.Warnf("ListBenchmarks failed: %s", err)
+		return fiber.ErrNotFound
+	}
+	benchmarks = append(benchmarks, custom...)
+
+	return c.JSON(benchmarks)
+}
+
+// CreateBenchmark create a custom benchmark from a static mix of securities
+// or a strategy
+func CreateBenchmark(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	params := benchmark.Benchmark{}
+	if err := json.Unmarshal(c.Body(), &params); err != nil {
+		log.Warnf("CreateBenchmark bad request: %s", err)
+		return fiber.ErrBadRequest
+	}
+
+	if err := params.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	if err := repository.Benchmarks.Create(c.Context(), &params, userID); err != nil {
+		log.Warnf("Failed to create benchmark for user %s: %s", userID, err)
+		return fiber.ErrBadRequest
+	}
+
+	return c.JSON(params)
+}
+
+// UpdateBenchmark update a custom benchmark
+func UpdateBenchmark(c *fiber.Ctx) error {
+	benchmarkID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	b, err := repository.Benchmarks.Get(c.Context(), benchmarkID, userID)
+	if err != nil {
+		log.Warnf("UpdateBenchmark %s failed: %s", benchmarkID, err)
+		return fiber.ErrNotFound
+	}
+
+	// unmarshal on top of the existing benchmark so unspecified fields are kept
+	id := b.ID
+	if err := json.Unmarshal(c.Body(), &b); err != nil {
+		log.Warnf("UpdateBenchmark bad request: %s, for benchmark: %s", err, benchmarkID)
+		return fiber.ErrBadRequest
+	}
+	b.ID = id
+
+	if err := b.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	if err := repository.Benchmarks.Update(c.Context(), &b, userID); err != nil {
+		log.Warnf("UpdateBenchmark SQL update failed: %s for benchmark: %s", err, benchmarkID)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(b)
+}
+
+// DeleteBenchmark delete a custom benchmark and detach it from all portfolios
+func DeleteBenchmark(c *fiber.Ctx) error {
+	benchmarkID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	err := repository.Transaction(c.Context(), func(r *repository.Repositories) error {
+		return r.Benchmarks.Delete(c.Context(), benchmarkID, userID)
+	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Warnf("DeleteBenchmark delete failed: %s, for benchmark: %s", err, benchmarkID)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(fiber.Map{"status": "success"})
+}
+
+// SetPortfolioBenchmarks replace the benchmarks attached to a portfolio with
+// the list of benchmark ids in the request body
+func SetPortfolioBenchmarks(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	p, err := loadPortfolio(c.Context(), portfolioID, userID)
+	if err != nil {
+		log.Warnf("SetPortfolioBenchmarks %s failed: %s", portfolioID, err)
+		return fiber.ErrNotFound
+	}
+	if !p.canEdit() {
+		return fiber.ErrForbidden
+	}
+
+	ids := []string{}
+	if err := json.Unmarshal(c.Body(), &ids); err != nil {
+		log.Warnf("SetPortfolioBenchmarks bad request: %s", err)
+		return fiber.ErrBadRequest
+	}
+
+	benchmarks, err := loadBenchmarks(c.Context(), ids, userID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	attached := make([]string, len(benchmarks))
+	for ii, b := range benchmarks {
+		attached[ii] = b.ID
+	}
+	err = repository.Transaction(c.Context(), func(r *repository.Repositories) error {
+		return r.Benchmarks.SetAttached(c.Context(), portfolioID, attached)
+	})
+	if err != nil {
+		log.Warnf("SetPortfolioBenchmarks failed: %s, for portfolio: %s", err, portfolioID)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(benchmarks)
+}
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"main/billing"
+	"main/repository"
+	"os"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// userLimits the limits of the plan userID is currently entitled to
+func userLimits(ctx context.Context, userID string) (billing.Limits, *billing.Subscription, error) {
+	sub, err := repository.Subscriptions.Get(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return billing.Entitlements(nil, time.Now()), nil, nil
+	}
+	if err != nil {
+		return billing.Limits{}, nil, err
+	}
+	return billing.Entitlements(sub, time.Now()), sub, nil
+}
+
+// checkPortfolioPlan verify that the user's plan allows a portfolio starting
+// at startDate with notifications; newPortfolio counts the portfolio against
+// the plan's limit. Returns a *billing.LimitError if the plan does not.
+func checkPortfolioPlan(ctx context.Context, userID string, newPortfolio bool, startDate int64, notifications int) error {
+	limits, _, err := userLimits(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if newPortfolio {
+		owned, err := repository.Portfolios.ListByUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if err := limits.CheckPortfolios(len(owned)); err != nil {
+			return err
+		}
+	}
+	if startDate != 0 {
+		if err := limits.CheckBacktest(time.Unix(startDate, 0), time.Now()); err != nil {
+			return err
+		}
+	}
+	return limits.CheckNotifications(notifications)
+}
+
+// backtestStart the start of a backtest to endDate within the user's plan.
+// Without an explicit start date the backtest starts as early as the plan
+// allows; explicit start dates before that are rejected with a
+// *billing.LimitError.
+func backtestStart(c *fiber.Ctx, userID string, startDate time.Time, endDate time.Time) (time.Time, error) {
+	limits, _, err := userLimits(c.Context(), userID)
+	if err != nil {
+		return startDate, err
+	}
+	earliest := limits.EarliestStart(endDate)
+	if c.Query("startDate") == "" && startDate.Before(earliest) {
+		return earliest, nil
+	}
+	return startDate, limits.CheckBacktest(startDate, endDate)
+}
+
+// planError the response to a request that failed a plan check: 402 if the
+// request exceeds the user's plan, 500 if the plan could not be loaded
+func planError(c *fiber.Ctx, err error) error {
+	var limitErr *billing.LimitError
+	if errors.As(err, &limitErr) {
+		return c.Status(fiber.StatusPaymentRequired).JSON(fiber.Map{"status": "error", "message": err.Error(), "plan": limitErr.Plan})
+	}
+	log.Warnf("Cannot check plan limits: %s", err)
+	return fiber.ErrInternalServerError
+}
+
+// GetEntitlements the user's subscription, the limits of the plan it entitles
+// them to, and how many portfolios they own
+// @Description Plan, limits, and usage of the user's subscription
+// @Id GetEntitlements
+// @Produce json
+func GetEntitlements(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	limits, sub, err := userLimits(c.Context(), userID)
+	if err != nil {
+		log.Warnf("GetEntitlements failed: %s, for user: %s", err, userID)
+		return fiber.ErrInternalServerError
+	}
+	owned, err := repository.Portfolios.ListByUser(c.Context(), userID)
+	if err != nil {
+		log.Warnf("GetEntitlements failed: %s, for user: %s", err, userID)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(fiber.Map{
+		"subscription": sub,
+		"limits":       limits,
+		"portfolios":   len(owned),
+	})
+}
+
+// StripeEvents update the subscriptions of users from the events Stripe posts
+// to its webhook. Subscriptions name the user in their user_id metadata.
+// Requests must be signed with the endpoint secret in STRIPE_WEBHOOK_SECRET;
+// events other than subscription changes are acknowledged and ignored.
+func StripeEvents(c *fiber.Ctx) error {
+	err := billing.VerifyStripeWebhook(os.Getenv("STRIPE_WEBHOOK_SECRET"), c.Get("Stripe-Signature"), c.Body(), time.Now())
+	if err != nil {
+		log.Warnf("StripeEvents rejected request: %s", err)
+		return fiber.ErrUnauthorized
+	}
+
+	event, err := billing.ParseStripeEvent(c.Body())
+	if err != nil {
+		log.Warnf("StripeEvents bad request: %s", err)
+		return fiber.ErrBadRequest
+	}
+	if !event.IsSubscription() {
+		return c.JSON(fiber.Map{"status": "ignored"})
+	}
+
+	sub, err := event.Subscription()
+	if err != nil {
+		// retrying will not fix a malformed subscription
+		log.Warnf("StripeEvents cannot read subscription of event %s: %s", event.ID, err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	// Stripe retries deliveries and does not order them, so older events
+	// do not overwrite newer ones
+	if err := repository.Subscriptions.Save(c.Context(), sub); err != nil {
+		log.Warnf("StripeEvents failed: %s, for event: %s", err, event.ID)
+		return fiber.ErrInternalServerError
+	}
+	log.Infof("Subscription of user %s is %s %s", sub.UserID, sub.Plan, sub.Status)
+
+	return c.JSON(fiber.Map{"status": "success"})
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"main/community"
+	"main/repository"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// ListCommunityStats list the anonymized statistics of each strategy across
+// the portfolios of users that opted in to sharing them
+// @Param sort query string false "popularity (default), ytd, or cagr"
+func ListCommunityStats(c *fiber.Ctx) error {
+	samples, err := repository.Portfolios.CommunitySamples(c.Context())
+	if err != nil {
+		log.Warnf("ListCommunityStats failed: %s", err)
+		return fiber.ErrInternalServerError
+	}
+
+	stats := community.Summarize(samples)
+	if err := community.Leaderboard(stats, c.Query("sort")); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	return c.JSON(stats)
+}
+
+// GetPortfolioCommunity compare the metrics of a portfolio with the community
+// statistics of its strategy
+func GetPortfolioCommunity(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	p, err := loadPortfolio(c.Context(), portfolioID, userID)
+	if err != nil {
+		log.Warnf("GetPortfolioCommunity %s failed: %s", portfolioID, err)
+		return fiber.ErrNotFound
+	}
+
+	samples, err := repository.Portfolios.CommunitySamples(c.Context())
+	if err != nil {
+		log.Warnf("GetPortfolioCommunity failed: %s, for portfolio: %s", err, portfolioID)
+		return fiber.ErrInternalServerError
+	}
+
+	stats := &community.Stats{Strategy: p.Strategy}
+	for _, s := range community.Summarize(samples) {
+		if s.Strategy == p.Strategy {
+			stats = s
+			break
+		}
+	}
+
+	return c.JSON(stats.Compare(p.YTDReturn, p.CAGRSinceInception))
+}
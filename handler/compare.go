@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"main/benchmark"
+	"main/portfolio"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	minComparePortfolios = 2
+	maxComparePortfolios = 5
+)
+
+// compareRequest portfolios to compare; ad-hoc strategies use the same
+// definition as custom benchmarks
+type compareRequest struct {
+	Portfolios []string              `json:"portfolios"`
+	Strategies []benchmark.Benchmark `json:"strategies"`
+}
+
+// ComparePortfolios compare 2-5 saved portfolios or ad-hoc strategies over
+// the period they have in common. Ad-hoc strategies are computed between the
+// startDate and endDate query parameters.
+func ComparePortfolios(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	params := compareRequest{}
+	if err := json.Unmarshal(c.Body(), &params); err != nil {
+		log.Warnf("ComparePortfolios bad request: %s", err)
+		return fiber.ErrBadRequest
+	}
+
+	cnt := len(params.Portfolios) + len(params.Strategies)
+	if cnt < minComparePortfolios || cnt > maxComparePortfolios {
+		msg := fmt.Sprintf("between %d and %d portfolios may be compared", minComparePortfolios, maxComparePortfolios)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": msg})
+	}
+
+	startDate, err := time.Parse("2006-01-02", c.Query("startDate", "1980-01-01"))
+	if err != nil {
+		return fiber.ErrBadRequest
+	}
+	endDate := time.Now()
+	year, month, day := endDate.Date()
+	endDate = time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	if endDateStr := c.Query("endDate", "now"); endDateStr != "now" {
+		endDate, err = time.Parse("2006-01-02", endDateStr)
+		if err != nil {
+			return fiber.ErrBadRequest
+		}
+	}
+
+	resolution, err := parseResolution(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	members := make([]portfolio.HouseholdMember, 0, cnt)
+	for _, portfolioID := range params.Portfolios {
+		p, err := loadPortfolio(c.Context(), portfolioID, userID)
+		if err != nil {
+			log.Warnf("ComparePortfolios portfolio %s not found: %s", portfolioID, err)
+			return fiber.ErrNotFound
+		}
+
+		manager := newDataManager(c)
+		perf, err := computeSavedPerformance(&p, &manager, resolution)
+		if err != nil {
+			log.Warnf("ComparePortfolios cannot calculate performance for portfolio %s: %s", portfolioID, err)
+			return fiber.ErrBadRequest
+		}
+		members = append(members, portfolio.HouseholdMember{
+			ID:          p.ID.String(),
+			Name:        p.Name,
+			Performance: perf,
+		})
+	}
+
+	for ii := range params.Strategies {
+		strat := &params.Strategies[ii]
+		if err := strat.Validate(); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		}
+
+		manager := newDataManager(c)
+		manager.Begin = startDate
+		manager.End = endDate
+		p, err := strat.Compute(&manager)
+		if err != nil {
+			log.Warnf("ComparePortfolios cannot compute strategy %s: %s", strat.Name, err)
+			return fiber.ErrBadRequest
+		}
+
+		p.Resolution = resolution
+		perf, err := p.CalculatePerformance(endDate)
+		if err != nil {
+			log.Warnf("ComparePortfolios cannot calculate performance for strategy %s: %s", strat.Name, err)
+			return fiber.ErrBadRequest
+		}
+		members = append(members, portfolio.HouseholdMember{
+			ID:          fmt.Sprintf("strategy-%d", ii),
+			Name:        strat.Name,
+			Performance: &perf,
+		})
+	}
+
+	return c.JSON(portfolio.Compare(members))
+}
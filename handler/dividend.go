@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// GetDividendCalendar historical and upcoming distributions of the
+// securities held by a saved portfolio
+// @Description Distributions received by a portfolio and the income projected over the next 12 months from trailing distributions
+// @Id GetDividendCalendar
+// @Produce json
+// @Param id path string true "id of portfolio"
+// @Param date query string false "date of calendar, defaults to today"
+func GetDividendCalendar(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	date := time.Now()
+	year, month, day := date.Date()
+	date = time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	if dateStr := c.Query("date"); dateStr != "" {
+		var err error
+		if date, err = time.Parse("2006-01-02", dateStr); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "date must be formatted YYYY-MM-DD"})
+		}
+	}
+
+	p, err := loadPortfolio(c.Context(), portfolioID, userID)
+	if err != nil {
+		log.Warnf("GetDividendCalendar %s failed: %s", portfolioID, err)
+		return fiber.ErrNotFound
+	}
+
+	if date.Before(time.Unix(p.StartDate, 0)) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "date must be on or after the portfolio start date"})
+	}
+
+	manager := newDataManager(c)
+	manager.Begin = time.Unix(p.StartDate, 0)
+	manager.End = date
+	computed, err := computeSavedPortfolio(&p, &manager)
+	if err != nil {
+		log.Warnf("GetDividendCalendar cannot compute portfolio %s: %s", portfolioID, err)
+		return fiber.ErrBadRequest
+	}
+
+	calendar, err := computed.DividendCalendar(date)
+	if err != nil {
+		log.Warnf("GetDividendCalendar cannot load distributions of portfolio %s: %s", portfolioID, err)
+		return fiber.ErrBadRequest
+	}
+
+	return c.JSON(calendar)
+}
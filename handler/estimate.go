@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"main/notification"
+	"main/portfolio"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// EstimatePortfolio preliminary value of a saved portfolio at the latest
+// intraday quotes. On rebalance days the allocation the strategy would
+// select if the market closed now is included.
+// @Description Preliminary intraday value of a portfolio and, on rebalance days, a preview of its next signal
+// @Id EstimatePortfolio
+// @Produce json
+// @Param id path string true "id of portfolio"
+func EstimatePortfolio(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	p, err := loadPortfolio(c.Context(), portfolioID, userID)
+	if err != nil {
+		log.Warnf("EstimatePortfolio %s failed: %s", portfolioID, err)
+		return fiber.ErrNotFound
+	}
+
+	tz, err := time.LoadLocation(notification.MarketTimezone)
+	if err != nil {
+		log.Warnf("EstimatePortfolio cannot load market timezone: %s", err)
+		return fiber.ErrInternalServerError
+	}
+	now := time.Now().In(tz)
+	year, month, day := now.Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	manager := newDataManager(c)
+	manager.Begin = time.Unix(p.StartDate, 0)
+	manager.End = today
+	computed, err := computeSavedPortfolio(&p, &manager)
+	if err != nil {
+		log.Warnf("EstimatePortfolio cannot compute portfolio %s: %s", portfolioID, err)
+		return fiber.ErrBadRequest
+	}
+
+	lastClose, err := computed.HoldingsAsOf(today.AddDate(0, 0, -1))
+	if err != nil {
+		log.Warnf("EstimatePortfolio cannot value holdings of portfolio %s: %s", portfolioID, err)
+		return fiber.ErrBadRequest
+	}
+
+	tickers := make([]string, 0, len(lastClose.Positions))
+	for _, pos := range lastClose.Positions {
+		if pos.Ticker != "$CASH" {
+			tickers = append(tickers, pos.Ticker)
+		}
+	}
+	quotes, err := manager.GetQuotes(tickers...)
+	if err != nil {
+		log.Warnf("EstimatePortfolio cannot get quotes for portfolio %s: %s", portfolioID, err)
+		return fiber.ErrBadGateway
+	}
+
+	estimate := portfolio.NewEstimate(lastClose, quotes, now)
+
+	if notification.IsLastTradingDayOfMonth(today) {
+		// the preview is best effort; the estimate is still useful without it
+		preview := newDataManager(c)
+		preview.Begin = time.Unix(p.StartDate, 0)
+		preview.End = today
+		preview.Intraday = true
+		if previewed, err := computeSavedPortfolio(&p, &preview); err != nil {
+			log.Warnf("EstimatePortfolio cannot preview signal of portfolio %s: %s", portfolioID, err)
+		} else if n := len(previewed.Signals); n > 0 && previewed.Signals[n-1].Date.Equal(today) {
+			estimate.Signal = &previewed.Signals[n-1]
+		}
+	}
+
+	return c.JSON(estimate)
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"main/strategies"
+	"main/util"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// ExplainStrategy intermediate values a strategy computed to select its
+// assets on a date
+// @Description Momentum scores, lags, and risk-free adjustment behind the asset a strategy selected
+// @Id ExplainStrategy
+// @Produce json
+// @Param id path string true "shortcode of strategy"
+// @Param date query string false "date to explain, defaults to today"
+// @Param arguments query string false "JSON value of each strategy argument, named after the argument; defaults to the argument's default"
+func ExplainStrategy(c *fiber.Ctx) error {
+	shortcode := c.Params("id")
+	strat, ok := availableStrategy(c, shortcode)
+	if !ok {
+		return fiber.ErrNotFound
+	}
+
+	date := time.Now()
+	year, month, day := date.Date()
+	date = time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	if dateStr := c.Query("date"); dateStr != "" {
+		var err error
+		if date, err = time.Parse("2006-01-02", dateStr); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "date must be formatted YYYY-MM-DD"})
+		}
+	}
+
+	// arguments are passed as query parameters named after the argument
+	params := make(map[string]json.RawMessage, len(strat.Arguments))
+	for name, arg := range strat.Arguments {
+		params[name] = json.RawMessage(c.Query(name, arg.DefaultVal))
+	}
+
+	stratObject, err := strat.Factory(params)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	explainer, ok := stratObject.(strategies.Explainer)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "strategy '" + shortcode + "' cannot explain its computation"})
+	}
+
+	// a year of history covers the longest lookback period
+	manager := newDataManager(c)
+	manager.Begin = date.AddDate(-1, 0, 0)
+	manager.End = date
+
+	var explanation *strategies.Explanation
+	err = util.Recover("explain", func() error {
+		if _, err := stratObject.Compute(&manager); err != nil {
+			return err
+		}
+		var err error
+		explanation, err = explainer.Explain(date)
+		return err
+	})
+	if errors.Is(err, strategies.ErrNoExplanation) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+	if err != nil {
+		log.Warnf("ExplainStrategy %s failed: %s", shortcode, err)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(explanation)
+}
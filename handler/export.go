@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"main/data"
+	"main/dfextras"
+	"main/export"
+	"main/logging"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rocketlaunchr/dataframe-go"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxExportTickers number of tickers that can be exported in one request
+const maxExportTickers = 50
+
+// streamExport respond with a file in format, written by write as the
+// response is sent so the file is never held in memory
+func streamExport(c *fiber.Ctx, format string, name string, write func(w *bufio.Writer) error) error {
+	c.Set(fiber.HeaderContentType, export.ContentType(format))
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s%s"`, name, export.Extension(format)))
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := write(w); err != nil {
+			log.WithFields(log.Fields{
+				"Name":             name,
+				"Format":           format,
+				logging.FieldError: err,
+			}).Warn("Export failed after the response started")
+		}
+	})
+	return nil
+}
+
+// ExportPortfolio download the measurements of a saved portfolio as a Parquet
+// file or Arrow IPC stream
+// @Description Measurements of a saved portfolio in a binary columnar format
+// @Id ExportPortfolio
+// @Produce application/vnd.apache.parquet
+// @Param id path string true "id of portfolio to export"
+// @Param format query string false "parquet (default) or arrow"
+// @Param resolution query string false "daily, weekly, or monthly (default)"
+func ExportPortfolio(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	format, err := export.ParseFormat(c.Query("format", export.FormatParquet))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	resolution, err := parseResolution(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	p, err := loadPortfolio(c.Context(), portfolioID, userID)
+	if err != nil {
+		log.Warnf("ExportPortfolio %s failed: %s", portfolioID, err)
+		return fiber.ErrNotFound
+	}
+
+	manager := newDataManager(c)
+	perf, err := computeSavedPerformance(&p, &manager, resolution)
+	if err != nil {
+		log.Warnf("ExportPortfolio cannot calculate performance for portfolio %s: %s", portfolioID, err)
+		return fiber.ErrBadRequest
+	}
+
+	return streamExport(c, format, portfolioID, func(w *bufio.Writer) error {
+		if err := export.WriteMeasurements(w, format, perf.Measurements); err != nil {
+			return err
+		}
+		return w.Flush()
+	})
+}
+
+// ExportPrices download the prices of a comma separated list of tickers as a
+// Parquet file or Arrow IPC stream with a date column and a column per ticker
+// @Description Prices of tickers in a binary columnar format
+// @Id ExportPrices
+// @Produce application/vnd.apache.parquet
+// @Param tickers query string true "comma separated list of tickers"
+// @Param startDate query string false "first date, defaults to 1990-01-01"
+// @Param endDate query string false "last date, defaults to today"
+// @Param format query string false "parquet (default) or arrow"
+// @Param resolution query string false "daily, weekly, or monthly (default)"
+func ExportPrices(c *fiber.Ctx) error {
+	format, err := export.ParseFormat(c.Query("format", export.FormatParquet))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	resolution, err := parseResolution(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	tickers := []string{}
+	seen := map[string]bool{}
+	for _, ticker := range strings.Split(c.Query("tickers"), ",") {
+		if ticker = strings.ToUpper(strings.TrimSpace(ticker)); ticker != "" && !seen[ticker] {
+			tickers = append(tickers, ticker)
+			seen[ticker] = true
+		}
+	}
+	if len(tickers) == 0 || len(tickers) > maxExportTickers {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": fmt.Sprintf("tickers must list between 1 and %d tickers", maxExportTickers)})
+	}
+
+	begin, err := time.Parse("2006-01-02", c.Query("startDate", "1990-01-01"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "startDate must be formatted YYYY-MM-DD"})
+	}
+	end := time.Now()
+	if endDate := c.Query("endDate"); endDate != "" {
+		if end, err = time.Parse("2006-01-02", endDate); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "endDate must be formatted YYYY-MM-DD"})
+		}
+	}
+
+	manager := newDataManager(c)
+	manager.Begin = begin
+	manager.End = end
+	manager.Frequency = resolution
+
+	prices, errs := manager.GetMultipleData(tickers...)
+	if len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": fmt.Sprintf("could not load prices: %s", errs[0])})
+	}
+
+	frames := make([]*dataframe.DataFrame, 0, len(tickers))
+	for _, ticker := range tickers {
+		frames = append(frames, prices[ticker])
+	}
+	merged, err := dfextras.MergeAndTimeAlign(context.TODO(), data.DateIdx, frames...)
+	if err != nil {
+		log.Warnf("ExportPrices cannot merge prices: %s", err)
+		return fiber.ErrInternalServerError
+	}
+
+	return streamExport(c, format, "prices", func(w *bufio.Writer) error {
+		if err := export.WritePrices(w, format, merged); err != nil {
+			return err
+		}
+		return w.Flush()
+	})
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"main/data"
+	"main/portfolio"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rocketlaunchr/dataframe-go"
+	log "github.com/sirupsen/logrus"
+)
+
+// FactorRegression regress the excess returns of a saved portfolio on the
+// Fama-French factors. Like the stress test, the strategy is computed over
+// all available history.
+// @Description Factor loadings, alpha, t-statistics, and R² of a portfolio regressed on a Fama-French factor model
+// @Id FactorRegression
+// @Produce json
+// @Param id path string true "id of portfolio"
+// @Param model query string false "ff3 (default), ff3mom, ff5, or ff5mom"
+func FactorRegression(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	model := strings.ToLower(c.Query("model", portfolio.FactorModelFF3))
+	names, ok := portfolio.FactorModels[model]
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "model must be one of ff3, ff3mom, ff5, or ff5mom"})
+	}
+
+	p, err := loadPortfolio(c.Context(), portfolioID, userID)
+	if err != nil {
+		log.Warnf("FactorRegression %s failed: %s", portfolioID, err)
+		return fiber.ErrNotFound
+	}
+
+	endDate := time.Now()
+	year, month, day := endDate.Date()
+	endDate = time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	manager := newDataManager(c)
+	manager.Begin = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+	manager.End = endDate
+	computed, err := computeSavedPortfolio(&p, &manager)
+	if err != nil {
+		log.Warnf("FactorRegression cannot compute portfolio %s: %s", portfolioID, err)
+		return fiber.ErrBadRequest
+	}
+
+	computed.Resolution = data.FrequencyMonthly
+	perf, err := computed.CalculatePerformance(endDate)
+	if err != nil {
+		log.Warnf("FactorRegression cannot calculate performance for portfolio %s: %s", portfolioID, err)
+		return fiber.ErrBadRequest
+	}
+
+	factorManager := newDataManager(c)
+	factorManager.Begin = time.Unix(perf.PeriodStart, 0)
+	factorManager.End = endDate
+	factorManager.Frequency = data.FrequencyMonthly
+	factors := make(map[string]*dataframe.DataFrame, len(names)+1)
+	for _, name := range append([]string{data.FactorRiskFree}, names...) {
+		df, err := factorManager.GetData("$FF." + name)
+		if err != nil {
+			log.Warnf("FactorRegression cannot load factor %s: %s", name, err)
+			return fiber.ErrInternalServerError
+		}
+		factors[name] = df
+	}
+
+	regression, err := perf.FactorRegression(model, factors)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"portfolio":  p.ID,
+		"regression": regression,
+	})
+}
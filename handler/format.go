@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"database/sql"
+	"main/locale"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// requestLocale locale used to format values for the user when the request
+// asks for formatted values with formatted=true; nil otherwise
+func requestLocale(c *fiber.Ctx, userID string) *locale.Locale {
+	if c.Query("formatted") != "true" {
+		return nil
+	}
+
+	prefs, err := loadPreferences(c.Context(), userID)
+	if err != nil {
+		log.Warnf("Cannot load preferences, formatting with default locale: %s", err)
+	}
+	return prefs.Localization()
+}
+
+// formatPortfolio add the portfolio's dates and metrics formatted in the
+// user's locale to the response
+func formatPortfolio(p *PortfolioResponse, loc *locale.Locale) {
+	if loc == nil {
+		return
+	}
+
+	p.Formatted = map[string]string{
+		"start_date":  loc.FormatDate(time.Unix(p.StartDate, 0).UTC()),
+		"created":     loc.FormatDate(time.Unix(p.Created, 0).UTC()),
+		"lastchanged": loc.FormatDate(time.Unix(p.LastChanged, 0).UTC()),
+	}
+	if p.LastSignalChange != nil {
+		p.Formatted["last_signal_change"] = loc.FormatDate(time.Unix(*p.LastSignalChange, 0).UTC())
+	}
+
+	percents := map[string]sql.NullFloat64{
+		"ytd_return":           p.YTDReturn,
+		"cagr_since_inception": p.CAGRSinceInception,
+		"max_draw_down":        p.MaxDrawDown,
+	}
+	for name, val := range percents {
+		if val.Valid {
+			p.Formatted[name] = loc.FormatPercent(val.Float64)
+		}
+	}
+
+	if p.StdDev.Valid {
+		p.Formatted["std_dev"] = loc.FormatShare(p.StdDev.Float64)
+	}
+
+	ratios := map[string]sql.NullFloat64{
+		"sharpe_ratio":  p.SharpeRatio,
+		"sortino_ratio": p.SortinoRatio,
+	}
+	for name, val := range ratios {
+		if val.Valid {
+			p.Formatted[name] = loc.FormatNumber(val.Float64, 2)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"encoding/json"
+	"main/portfolio"
+	"main/repository"
+	"main/strategies"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// ListGallery list the curated strategy configurations users can create a
+// portfolio from
+func ListGallery(c *fiber.Ctx) error {
+	return c.JSON(strategies.Gallery())
+}
+
+// CreateFromTemplate create a portfolio from a template of the gallery. The
+// body may set the name, start_date, and account_type of the portfolio; by
+// default it is a taxable portfolio named after the template that starts
+// today.
+func CreateFromTemplate(c *fiber.Ctx) error {
+	templateID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	template, ok := strategies.FindTemplate(templateID)
+	if !ok {
+		return fiber.ErrNotFound
+	}
+
+	params := struct {
+		Name        string `json:"name"`
+		StartDate   int64  `json:"start_date"`
+		AccountType string `json:"account_type"`
+	}{}
+	if len(c.Body()) > 0 {
+		if err := json.Unmarshal(c.Body(), &params); err != nil {
+			log.Warnf("CreateFromTemplate bad request: %s, for template: %s", err, templateID)
+			return fiber.ErrBadRequest
+		}
+	}
+
+	if params.Name == "" {
+		params.Name = template.StrategyName + ": " + template.Name
+	}
+	if params.StartDate == 0 {
+		now := time.Now().UTC()
+		params.StartDate = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).Unix()
+	}
+	if params.AccountType == "" {
+		params.AccountType = portfolio.AccountTaxable
+	}
+	if !portfolio.ValidAccountType(params.AccountType) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "account_type must be one of taxable, ira, or 401k"})
+	}
+
+	if err := checkStrategyConstraints(c, template.Strategy, template.Arguments, time.Unix(params.StartDate, 0)); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+	if err := checkPortfolioPlan(c.Context(), userID, true, params.StartDate, 0); err != nil {
+		return planError(c, err)
+	}
+
+	args, err := json.Marshal(template.Arguments)
+	if err != nil {
+		log.Warnf("CreateFromTemplate failed: %s, for template: %s", err, templateID)
+		return fiber.ErrInternalServerError
+	}
+
+	p := repository.Portfolio{
+		UserID:           userID,
+		Name:             params.Name,
+		Strategy:         template.Strategy,
+		Arguments:        args,
+		StrategyVersion:  strategies.StrategyMap[template.Strategy].Version,
+		StartDate:        params.StartDate,
+		AccountType:      params.AccountType,
+		ShortTermTaxRate: portfolio.DefaultTaxRates.ShortTermCapitalGains,
+		LongTermTaxRate:  portfolio.DefaultTaxRates.LongTermCapitalGains,
+		DividendTaxRate:  portfolio.DefaultTaxRates.Dividends,
+	}
+	if err := repository.Portfolios.Create(c.Context(), &p); err != nil {
+		log.Warnf("CreateFromTemplate failed to create portfolio: %s, for template: %s", err, templateID)
+		return fiber.ErrInternalServerError
+	}
+
+	created, err := loadPortfolio(c.Context(), p.ID.String(), userID)
+	if err != nil {
+		log.Warnf("CreateFromTemplate %s failed: %s", templateID, err)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(created)
+}
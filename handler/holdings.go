@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// GetHoldings positions of a saved portfolio on a date, derived from the
+// transactions of its strategy
+// @Description Shares, price, value, and weight of each position of a portfolio on a date
+// @Id GetHoldings
+// @Produce json
+// @Param id path string true "id of portfolio"
+// @Param date query string false "date of holdings, defaults to today"
+func GetHoldings(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	date := time.Now()
+	year, month, day := date.Date()
+	date = time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	if dateStr := c.Query("date"); dateStr != "" {
+		var err error
+		if date, err = time.Parse("2006-01-02", dateStr); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "date must be formatted YYYY-MM-DD"})
+		}
+	}
+
+	p, err := loadPortfolio(c.Context(), portfolioID, userID)
+	if err != nil {
+		log.Warnf("GetHoldings %s failed: %s", portfolioID, err)
+		return fiber.ErrNotFound
+	}
+
+	if date.Before(time.Unix(p.StartDate, 0)) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "date must be on or after the portfolio start date"})
+	}
+
+	manager := newDataManager(c)
+	manager.Begin = time.Unix(p.StartDate, 0)
+	manager.End = date
+	computed, err := computeSavedPortfolio(&p, &manager)
+	if err != nil {
+		log.Warnf("GetHoldings cannot compute portfolio %s: %s", portfolioID, err)
+		return fiber.ErrBadRequest
+	}
+
+	holdings, err := computed.HoldingsAsOf(date)
+	if err != nil {
+		log.Warnf("GetHoldings cannot value holdings of portfolio %s: %s", portfolioID, err)
+		return fiber.ErrBadRequest
+	}
+
+	return c.JSON(holdings)
+}
+
+// GetAllocationHistory weight of each position of a saved portfolio over
+// time, for stacked area charts
+// @Description Weight of each position of a portfolio on every date at the requested resolution
+// @Id GetAllocationHistory
+// @Produce json
+// @Param id path string true "id of portfolio"
+// @Param startDate query string false "first date, defaults to the portfolio start date"
+// @Param endDate query string false "last date, defaults to today"
+// @Param resolution query string false "daily, weekly, or monthly (default)"
+func GetAllocationHistory(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	p, err := loadPortfolio(c.Context(), portfolioID, userID)
+	if err != nil {
+		log.Warnf("GetAllocationHistory %s failed: %s", portfolioID, err)
+		return fiber.ErrNotFound
+	}
+
+	begin := time.Unix(p.StartDate, 0).UTC()
+	if startDate := c.Query("startDate"); startDate != "" {
+		if begin, err = time.Parse("2006-01-02", startDate); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "startDate must be formatted YYYY-MM-DD"})
+		}
+	}
+	end := time.Now()
+	year, month, day := end.Date()
+	end = time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	if endDate := c.Query("endDate"); endDate != "" {
+		if end, err = time.Parse("2006-01-02", endDate); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "endDate must be formatted YYYY-MM-DD"})
+		}
+	}
+	if end.Before(begin) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "endDate must be on or after startDate"})
+	}
+
+	resolution, err := parseResolution(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	manager := newDataManager(c)
+	manager.Begin = time.Unix(p.StartDate, 0)
+	manager.End = end
+	computed, err := computeSavedPortfolio(&p, &manager)
+	if err != nil {
+		log.Warnf("GetAllocationHistory cannot compute portfolio %s: %s", portfolioID, err)
+		return fiber.ErrBadRequest
+	}
+
+	allocations, err := computed.AllocationHistory(begin, end, resolution)
+	if err != nil {
+		log.Warnf("GetAllocationHistory cannot value holdings of portfolio %s: %s", portfolioID, err)
+		return fiber.ErrBadRequest
+	}
+
+	return c.JSON(allocations)
+}
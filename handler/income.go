@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"main/portfolio"
+	"main/repository"
+	"strconv"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// incomeCSVHeader columns of the CSV income report
+var incomeCSVHeader = []string{"year", "dividends", "dividendGrowth", "dividendsPaid", "interest", "tradingFees", "marginInterest", "borrowFees", "advisoryFees", "totalFees", "deposits", "withdrawals"}
+
+// writeIncomeCSV write an income statement as CSV with a row per year
+func writeIncomeCSV(w io.Writer, statement []portfolio.IncomeYear) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(incomeCSVHeader); err != nil {
+		return err
+	}
+
+	amount := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	}
+	for ii := range statement {
+		y := &statement[ii]
+		row := []string{
+			strconv.Itoa(y.Year),
+			amount(y.Dividends),
+			strconv.FormatFloat(y.DividendGrowth, 'f', 4, 64),
+			amount(y.DividendsPaid),
+			amount(y.Interest),
+			amount(y.TradingFees),
+			amount(y.MarginInterest),
+			amount(y.BorrowFees),
+			amount(y.AdvisoryFees),
+			amount(y.Fees()),
+			amount(y.Deposits),
+			amount(y.Withdrawals),
+		}
+		if err := out.Write(row); err != nil {
+			return err
+		}
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+// GetIncomeReport dividends, interest, fees, deposits, and withdrawals of a
+// portfolio per year, summarized from its executed transactions
+// @Description Cash-flow statement of a portfolio per calendar year for reconciling against broker tax forms
+// @Id GetIncomeReport
+// @Produce json
+// @Produce text/csv
+// @Param id path string true "id of portfolio"
+// @Param format query string false "json (default) or csv"
+func GetIncomeReport(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	format := strings.ToLower(c.Query("format", "json"))
+	if format != "json" && format != "csv" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "format must be json or csv"})
+	}
+
+	if _, err := loadPortfolio(c.Context(), portfolioID, userID); err != nil {
+		log.Warnf("GetIncomeReport %s failed: %s", portfolioID, err)
+		return fiber.ErrNotFound
+	}
+
+	executed, err := repository.Transactions.List(c.Context(), portfolioID, userID)
+	if err != nil {
+		log.Warnf("GetIncomeReport cannot load transactions for portfolio %s: %s", portfolioID, err)
+		return fiber.ErrInternalServerError
+	}
+
+	trxs := make([]portfolio.Transaction, 0, len(executed))
+	for ii := range executed {
+		trxs = append(trxs, executed[ii].Transaction())
+	}
+	statement := portfolio.IncomeStatement(trxs)
+
+	if format == "json" {
+		return c.JSON(statement)
+	}
+
+	var buf bytes.Buffer
+	if err := writeIncomeCSV(&buf, statement); err != nil {
+		log.Warnf("GetIncomeReport %s failed: %s", portfolioID, err)
+		return fiber.ErrInternalServerError
+	}
+
+	c.Set(fiber.HeaderContentType, "text/csv")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s-income.csv"`, portfolioID))
+	return c.Send(buf.Bytes())
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"encoding/json"
+	"main/queue"
+	"main/repository"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// JobUsage the number of userID's queued and running jobs and the time spent
+// running the jobs that finished since since; counted by the compute budget
+func JobUsage(userID string, since time.Time) (int, time.Duration, error) {
+	usage, err := repository.Jobs.Usage(userID, since)
+	return usage.Active, usage.Elapsed, err
+}
+
+// EnqueueStrategy run a strategy in the background on a worker
+// @Description Queue a strategy backtest; poll the returned job for the result
+// @Id EnqueueStrategy
+// @Produce json
+// @Param id path string true "shortcode of the strategy to run"
+// @Param startDate query string false "first date, defaults to 1980-01-01"
+// @Param endDate query string false "last date, defaults to today"
+// @Param resolution query string false "daily, weekly, or monthly (default)"
+// @Param accountType query string false "calculate after-tax values for the account type"
+func EnqueueStrategy(c *fiber.Ctx) error {
+	shortcode := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	strat, ok := availableStrategy(c, shortcode)
+	if !ok {
+		return fiber.ErrNotFound
+	}
+
+	startDate, err := time.Parse("2006-01-02", c.Query("startDate", "1980-01-01"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "startDate must be formatted YYYY-MM-DD"})
+	}
+
+	year, month, day := time.Now().Date()
+	endDate := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	if endDateStr := c.Query("endDate"); endDateStr != "" {
+		if endDate, err = time.Parse("2006-01-02", endDateStr); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "endDate must be formatted YYYY-MM-DD"})
+		}
+	}
+
+	if startDate, err = backtestStart(c, userID, startDate, endDate); err != nil {
+		return planError(c, err)
+	}
+
+	resolution, err := parseResolution(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	params := map[string]json.RawMessage{}
+	if err := json.Unmarshal(c.Body(), &params); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "arguments must be a JSON object"})
+	}
+	if _, err := strat.Factory(params); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	job, err := repository.Jobs.Enqueue(queue.KindRunStrategy, userID, &queue.RunStrategy{
+		Shortcode:    shortcode,
+		Arguments:    json.RawMessage(c.Body()),
+		StartDate:    startDate,
+		EndDate:      endDate,
+		Resolution:   resolution,
+		AccountType:  c.Query("accountType"),
+		CheckHistory: c.Query("startDate") != "",
+	})
+	if err != nil {
+		log.Warnf("EnqueueStrategy %s failed: %s", shortcode, err)
+		return fiber.ErrInternalServerError
+	}
+
+	c.Set(fiber.HeaderLocation, "/v1/jobs/"+job.ID.String())
+	return c.Status(fiber.StatusAccepted).JSON(job)
+}
+
+// GetJob get the status of a queued job, its progress while it runs, and,
+// once it succeeds, its result
+// @Description Status, progress, and result of a job queued by the user
+// @Id GetJob
+// @Produce json
+// @Param id path string true "id of the job"
+func GetJob(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fiber.ErrNotFound
+	}
+
+	job, err := repository.Jobs.Get(id, userID)
+	if err == queue.ErrNotFound {
+		return fiber.ErrNotFound
+	}
+	if err != nil {
+		log.Warnf("GetJob %s failed: %s", id, err)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(job)
+}
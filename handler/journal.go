@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"main/journal"
+	"main/portfolio"
+	"main/repository"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// journalEnd last date notes are listed through when no end date is given
+var journalEnd = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+// ListNotes list the decision journal of a portfolio, optionally limited to
+// notes between startDate and endDate
+// @Param startDate query string false "first date of notes to list, YYYY-MM-DD"
+// @Param endDate query string false "last date of notes to list, YYYY-MM-DD"
+func ListNotes(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	p, err := loadPortfolio(c.Context(), portfolioID, userID)
+	if err != nil {
+		log.Warnf("ListNotes %s failed: %s", portfolioID, err)
+		return fiber.ErrNotFound
+	}
+
+	begin := time.Unix(0, 0).UTC()
+	if startDate := c.Query("startDate"); startDate != "" {
+		if begin, err = time.Parse("2006-01-02", startDate); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "startDate must be formatted YYYY-MM-DD"})
+		}
+	}
+	end := journalEnd
+	if endDate := c.Query("endDate"); endDate != "" {
+		if end, err = time.Parse("2006-01-02", endDate); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "endDate must be formatted YYYY-MM-DD"})
+		}
+		end = end.Add(24*time.Hour - time.Second)
+	}
+
+	notes, err := repository.Journals.List(c.Context(), p.ID, begin, end)
+	if err != nil {
+		log.Warnf("ListNotes failed: %s, for portfolio: %s", err, portfolioID)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(notes)
+}
+
+// CreateNote add a note to the decision journal of a portfolio. Notes are
+// shown on the performance chart unless showOnChart is false.
+func CreateNote(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	p, err := loadPortfolio(c.Context(), portfolioID, userID)
+	if err != nil {
+		log.Warnf("CreateNote %s failed: %s", portfolioID, err)
+		return fiber.ErrNotFound
+	}
+
+	n := journal.Note{ShowOnChart: true}
+	if err := json.Unmarshal(c.Body(), &n); err != nil {
+		log.Warnf("CreateNote bad request: %s, for portfolio: %s", err, portfolioID)
+		return fiber.ErrBadRequest
+	}
+	if err := n.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	n.PortfolioID = p.ID
+	n.UserID = userID
+	if err := repository.Journals.Create(c.Context(), &n); err != nil {
+		log.Warnf("CreateNote failed: %s, for portfolio: %s", err, portfolioID)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(n)
+}
+
+// UpdateNote change a note the logged in user wrote
+func UpdateNote(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	noteID := c.Params("noteId")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	p, err := loadPortfolio(c.Context(), portfolioID, userID)
+	if err != nil {
+		log.Warnf("UpdateNote %s failed: %s", portfolioID, err)
+		return fiber.ErrNotFound
+	}
+
+	id, err := uuid.Parse(noteID)
+	if err != nil {
+		return fiber.ErrNotFound
+	}
+
+	n := journal.Note{ShowOnChart: true}
+	if err := json.Unmarshal(c.Body(), &n); err != nil {
+		log.Warnf("UpdateNote bad request: %s, for note: %s", err, noteID)
+		return fiber.ErrBadRequest
+	}
+	if err := n.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	n.ID = id
+	n.PortfolioID = p.ID
+	n.UserID = userID
+	err = repository.Journals.Update(c.Context(), &n)
+	if err == sql.ErrNoRows {
+		return fiber.ErrNotFound
+	}
+	if err != nil {
+		log.Warnf("UpdateNote failed: %s, for note: %s", err, noteID)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(n)
+}
+
+// DeleteNote remove a note the logged in user wrote
+func DeleteNote(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	noteID := c.Params("noteId")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	p, err := loadPortfolio(c.Context(), portfolioID, userID)
+	if err != nil {
+		log.Warnf("DeleteNote %s failed: %s", portfolioID, err)
+		return fiber.ErrNotFound
+	}
+
+	if _, err := uuid.Parse(noteID); err != nil {
+		return fiber.ErrNotFound
+	}
+
+	err = repository.Journals.Delete(c.Context(), noteID, p.ID, userID)
+	if err == sql.ErrNoRows {
+		return fiber.ErrNotFound
+	}
+	if err != nil {
+		log.Warnf("DeleteNote failed: %s, for note: %s", err, noteID)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(fiber.Map{"status": "success"})
+}
+
+// annotateWithNotes show the journal notes of a portfolio on the timeline of
+// its performance. Notes that cannot be read are logged and left off.
+func annotateWithNotes(c *fiber.Ctx, portfolioID uuid.UUID, perf *portfolio.Performance) {
+	end := journalEnd
+	if perf.PeriodEnd != 0 {
+		end = time.Unix(perf.PeriodEnd, 0)
+	}
+	notes, err := repository.Journals.List(c.Context(), portfolioID, time.Unix(perf.PeriodStart, 0), end)
+	if err != nil {
+		log.Warnf("Cannot read journal of portfolio %s: %s", portfolioID, err)
+		return
+	}
+	perf.Annotate(journal.Annotations(notes))
+}
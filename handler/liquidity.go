@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"fmt"
+	"main/data"
+	"main/portfolio"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// parseMaxVolumeFraction read the largest fraction of daily dollar volume a
+// trade may be from the maxVolumeFraction query parameter
+func parseMaxVolumeFraction(c *fiber.Ctx) (float64, error) {
+	fractionStr := c.Query("maxVolumeFraction")
+	if fractionStr == "" {
+		return portfolio.DefaultMaxVolumeFraction, nil
+	}
+
+	fraction, err := strconv.ParseFloat(fractionStr, 64)
+	if err != nil || fraction <= 0 {
+		return 0, fmt.Errorf("invalid maxVolumeFraction '%s'", fractionStr)
+	}
+	return fraction, nil
+}
+
+// checkLiquidity warn about trades of perf larger than maxFraction of the
+// daily dollar volume of the security. Securities whose volume cannot be
+// loaded are not checked.
+func checkLiquidity(c *fiber.Ctx, perf *portfolio.Performance, maxFraction float64) {
+	tickers := perf.TradedTickers()
+	if len(tickers) == 0 {
+		return
+	}
+
+	manager := newDataManager(c)
+	manager.Begin = time.Unix(perf.PeriodStart, 0)
+	manager.End = time.Unix(perf.PeriodEnd, 0)
+	manager.Frequency = data.FrequencyDaily
+
+	manager.Metric = data.MetricClose
+	closes, _ := manager.GetMultipleData(tickers...)
+	manager.Metric = data.MetricVolume
+	volumes, _ := manager.GetMultipleData(tickers...)
+
+	dollarVolume := make(map[string]map[string]float64, len(tickers))
+	for _, ticker := range tickers {
+		if closes[ticker] == nil || volumes[ticker] == nil {
+			log.Warnf("Cannot check liquidity of %s: volume is not available", ticker)
+			continue
+		}
+		dollarVolume[ticker] = portfolio.DollarVolume(closes[ticker], volumes[ticker], ticker)
+	}
+
+	perf.LiquidityWarnings = perf.CheckLiquidity(dollarVolume, maxFraction)
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"main/logging"
+	"main/repository"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultLogLimit number of log events listed when limit is not given
+const defaultLogLimit = 100
+
+// ListLogEvents list the persisted log events of a user or portfolio, newest
+// first, for support requests
+// @Param userId query string false "Auth0 id of the user"
+// @Param portfolioId query string false "id of the portfolio"
+// @Param level query string false "least severe level, defaults to warn"
+// @Param since query string false "first date, YYYY-MM-DD; defaults to 7 days ago"
+// @Param limit query int false "number of events, defaults to 100"
+func ListLogEvents(c *fiber.Ctx) error {
+	q := &logging.Query{
+		UserID:      c.Query("userId"),
+		PortfolioID: c.Query("portfolioId"),
+		Since:       time.Now().UTC().AddDate(0, 0, -7),
+		Limit:       defaultLogLimit,
+	}
+
+	var err error
+	if q.Level, err = logging.ParseLevel(c.Query("level", "warn")); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+	if q.Level > log.WarnLevel {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "only warnings and more severe events are stored"})
+	}
+	if sinceStr := c.Query("since"); sinceStr != "" {
+		if q.Since, err = time.Parse("2006-01-02", sinceStr); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "since must be formatted YYYY-MM-DD"})
+		}
+	}
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if q.Limit, err = strconv.Atoi(limitStr); err != nil || q.Limit < 1 || q.Limit > 1000 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "limit must be between 1 and 1000"})
+		}
+	}
+
+	events, err := repository.Logs.ListEvents(c.Context(), q)
+	if err != nil {
+		log.Warnf("ListLogEvents failed: %s", err)
+		return fiber.ErrInternalServerError
+	}
+	return c.JSON(events)
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"main/repository"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// ListStrategyMigrations list the versions of its strategy a saved portfolio
+// has run and the argument migrations applied between them
+func ListStrategyMigrations(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	p, err := loadPortfolio(c.Context(), portfolioID, userID)
+	if err != nil {
+		log.Warnf("ListStrategyMigrations %s failed: %s", portfolioID, err)
+		return fiber.ErrNotFound
+	}
+
+	migrations, err := repository.Portfolios.Migrations(c.Context(), p.ID)
+	if err != nil {
+		log.Warnf("ListStrategyMigrations %s query failed: %s", portfolioID, err)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(fiber.Map{
+		"portfolio":        portfolioID,
+		"strategy":         p.Strategy,
+		"strategy_version": p.StrategyVersion,
+		"migrations":       migrations,
+	})
+}
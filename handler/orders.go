@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"main/data"
+	"main/notification"
+	"main/portfolio"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// ordersCSVHeader columns of the CSV order list
+var ordersCSVHeader = []string{"ticker", "side", "shares", "estimatedPrice", "estimatedValue"}
+
+// writeOrdersCSV write orders as CSV with a row per order
+func writeOrdersCSV(w io.Writer, orders []portfolio.Order) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(ordersCSVHeader); err != nil {
+		return err
+	}
+
+	for _, order := range orders {
+		row := []string{
+			order.Ticker,
+			order.Side,
+			strconv.FormatFloat(order.Shares, 'f', -1, 64),
+			strconv.FormatFloat(order.EstimatedPrice, 'f', 2, 64),
+			strconv.FormatFloat(order.EstimatedValue, 'f', 2, 64),
+		}
+		if err := out.Write(row); err != nil {
+			return err
+		}
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+// latestPrices price of each ticker from its intraday quote, or its last
+// close on or before today for tickers without a quote, e.g. mutual funds
+func latestPrices(c *fiber.Ctx, tickers []string, today time.Time) (map[string]float64, error) {
+	prices := make(map[string]float64, len(tickers))
+	if len(tickers) == 0 {
+		return prices, nil
+	}
+
+	manager := newDataManager(c)
+	quotes, err := manager.GetQuotes(tickers...)
+	if err != nil {
+		// quotes are best effort; the last close is used instead
+		log.Warnf("Cannot get quotes, using the last close: %s", err)
+	}
+	for ticker, quote := range quotes {
+		prices[ticker] = quote.Price
+	}
+
+	manager.Begin = today.AddDate(0, 0, -7)
+	manager.End = today
+	manager.Frequency = data.FrequencyDaily
+	manager.Metric = data.MetricClose
+	for _, ticker := range tickers {
+		if _, ok := prices[ticker]; ok {
+			continue
+		}
+
+		df, err := manager.GetData(ticker)
+		if err != nil {
+			return nil, err
+		}
+		idx, err := df.NameToColumn(strings.ToUpper(ticker))
+		if err != nil {
+			return nil, err
+		}
+		for row := df.NRows() - 1; row >= 0; row-- {
+			if price, ok := df.Series[idx].Value(row).(float64); ok && !math.IsNaN(price) {
+				prices[ticker] = price
+				break
+			}
+		}
+	}
+
+	return prices, nil
+}
+
+// suggestOrders orders that move the holdings of a portfolio's executed
+// transactions to the latest signal of its strategy on today, configured
+// by the cash and wholeShares query parameters. If ok is false the error
+// response has been sent and err should be returned by the handler.
+func suggestOrders(c *fiber.Ctx, p *PortfolioResponse, userID string, today time.Time) (orders portfolio.OrderList, ok bool, err error) {
+	portfolioID := p.ID.String()
+
+	manager := newDataManager(c)
+	manager.Begin = time.Unix(p.StartDate, 0)
+	manager.End = today
+	computed, err := computeSavedPortfolio(p, &manager)
+	if err != nil {
+		log.Warnf("suggestOrders cannot compute portfolio %s: %s", portfolioID, err)
+		return orders, false, fiber.ErrBadRequest
+	}
+	if len(computed.Signals) == 0 {
+		return orders, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "the strategy has not produced a signal"})
+	}
+	signal := computed.Signals[len(computed.Signals)-1]
+
+	trxs, err := loadSplitAdjustedTransactions(c.Context(), portfolioID, userID, &manager)
+	if err != nil {
+		log.Warnf("suggestOrders cannot load transactions for portfolio %s: %s", portfolioID, err)
+		return orders, false, fiber.ErrInternalServerError
+	}
+	holdings := portfolio.LedgerHoldings(trxs, today)
+	if cashStr := c.Query("cash"); cashStr != "" {
+		cash, err := strconv.ParseFloat(cashStr, 64)
+		if err != nil || cash < 0 {
+			return orders, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "cash must be a positive number"})
+		}
+		holdings["$CASH"] = cash
+	}
+
+	constraints := computed.Execution
+	constraints.WholeShares = c.Query("wholeShares", "true") != "false"
+
+	tickers := []string{}
+	for ticker := range holdings {
+		if ticker != "$CASH" {
+			tickers = append(tickers, ticker)
+		}
+	}
+	for ticker := range signal.Target {
+		if _, ok := holdings[ticker]; !ok && ticker != "$CASH" {
+			tickers = append(tickers, ticker)
+		}
+	}
+	prices, err := latestPrices(c, tickers, today)
+	if err != nil {
+		log.Warnf("suggestOrders cannot get prices for portfolio %s: %s", portfolioID, err)
+		return orders, false, fiber.ErrBadGateway
+	}
+
+	orders, err = portfolio.BuildOrders(holdings, signal, prices, constraints)
+	if err != nil {
+		return orders, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+	return orders, true, nil
+}
+
+// marketToday the current date in the market's timezone at midnight UTC
+func marketToday() (time.Time, *time.Location, error) {
+	tz, err := time.LoadLocation(notification.MarketTimezone)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	year, month, day := time.Now().In(tz).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC), tz, nil
+}
+
+// GenerateOrders orders that move the holdings of a portfolio's executed
+// transactions to the latest signal of its strategy. Orders are in whole
+// shares unless wholeShares=false and respect the execution constraints of
+// the strategy. cash overrides the cash balance of the executed
+// transactions, e.g. when deposits are not recorded.
+// @Description Buy and sell orders that rebalance the executed holdings of a portfolio to its latest signal
+// @Id GenerateOrders
+// @Produce json
+// @Produce text/csv
+// @Param id path string true "id of portfolio"
+// @Param cash query number false "cash available in the brokerage account"
+// @Param wholeShares query bool false "only order whole shares; defaults to true"
+// @Param format query string false "json (default) or csv"
+func GenerateOrders(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	format := strings.ToLower(c.Query("format", "json"))
+	if format != "json" && format != "csv" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "format must be json or csv"})
+	}
+
+	p, err := loadPortfolio(c.Context(), portfolioID, userID)
+	if err != nil {
+		log.Warnf("GenerateOrders %s failed: %s", portfolioID, err)
+		return fiber.ErrNotFound
+	}
+
+	today, _, err := marketToday()
+	if err != nil {
+		log.Warnf("GenerateOrders cannot load market timezone: %s", err)
+		return fiber.ErrInternalServerError
+	}
+
+	orders, ok, err := suggestOrders(c, &p, userID, today)
+	if !ok {
+		return err
+	}
+
+	if format == "json" {
+		return c.JSON(orders)
+	}
+
+	var buf bytes.Buffer
+	if err := writeOrdersCSV(&buf, orders.Orders); err != nil {
+		log.Warnf("GenerateOrders %s failed: %s", portfolioID, err)
+		return fiber.ErrInternalServerError
+	}
+
+	c.Set(fiber.HeaderContentType, "text/csv")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s-orders.csv"`, portfolioID))
+	return c.Send(buf.Bytes())
+}
@@ -0,0 +1,400 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"main/organization"
+	"main/repository"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// OrganizationResponse an organization and its members
+type OrganizationResponse struct {
+	organization.Organization
+	Members []*organization.Member `json:"members"`
+}
+
+// loadOrganization retrieve an organization the logged in user belongs to;
+// returns fiber.ErrNotFound if they are not a member and
+// fiber.ErrForbidden if manage is set and they are not an admin
+func loadOrganization(c *fiber.Ctx, userID string, manage bool) (*organization.Organization, error) {
+	orgID := c.Params("id")
+	org, err := repository.Organizations.Get(c.Context(), orgID, userID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Warnf("Failed to load organization %s: %s", orgID, err)
+		}
+		return nil, fiber.ErrNotFound
+	}
+	if manage && !organization.CanManage(org.Role) {
+		return nil, fiber.ErrForbidden
+	}
+	return org, nil
+}
+
+// OrganizationAudience the members of the organization in the url, whose
+// cached responses are invalidated when it changes
+func OrganizationAudience(c *fiber.Ctx) []string {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	org, err := repository.Organizations.Get(c.Context(), c.Params("id"), userID)
+	if err != nil {
+		return nil
+	}
+	return memberIDs(c.Context(), org.ID)
+}
+
+// memberIDs the user ids of the members of an organization
+func memberIDs(ctx context.Context, orgID uuid.UUID) []string {
+	members, err := repository.Organizations.Members(ctx, orgID)
+	if err != nil {
+		log.Warnf("Failed to load members of organization %s: %s", orgID, err)
+		return nil
+	}
+	ids := make([]string, 0, len(members))
+	for _, m := range members {
+		ids = append(ids, m.UserID)
+	}
+	return ids
+}
+
+// ListOrganizations list the organizations of the logged in user
+func ListOrganizations(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	orgs, err := repository.Organizations.ListByUser(c.Context(), userID)
+	if err != nil {
+		log.Warnf("ListOrganizations failed: %s", err)
+		return fiber.ErrInternalServerError
+	}
+	return c.JSON(orgs)
+}
+
+// CreateOrganization create an organization with the logged in user as its
+// admin
+func CreateOrganization(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	org := organization.Organization{}
+	if err := json.Unmarshal(c.Body(), &org); err != nil {
+		log.Warnf("CreateOrganization bad request: %s", err)
+		return fiber.ErrBadRequest
+	}
+	if err := org.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	org.ID = uuid.Nil
+	err := repository.Transaction(c.Context(), func(r *repository.Repositories) error {
+		return r.Organizations.Create(c.Context(), &org, userID)
+	})
+	if err != nil {
+		log.Warnf("CreateOrganization failed: %s", err)
+		return fiber.ErrInternalServerError
+	}
+
+	created, err := repository.Organizations.Get(c.Context(), org.ID.String(), userID)
+	if err != nil {
+		log.Warnf("CreateOrganization %s failed: %s", org.ID, err)
+		return fiber.ErrInternalServerError
+	}
+	return c.JSON(created)
+}
+
+// GetOrganization get an organization of the logged in user and its members
+func GetOrganization(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	org, err := loadOrganization(c, userID, false)
+	if err != nil {
+		return err
+	}
+
+	members, err := repository.Organizations.Members(c.Context(), org.ID)
+	if err != nil {
+		log.Warnf("GetOrganization failed to list members: %s, for organization: %s", err, org.ID)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(OrganizationResponse{
+		Organization: *org,
+		Members:      members,
+	})
+}
+
+// UpdateOrganization rename an organization; admins only
+func UpdateOrganization(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	org, err := loadOrganization(c, userID, true)
+	if err != nil {
+		return err
+	}
+
+	params := organization.Organization{}
+	if err := json.Unmarshal(c.Body(), &params); err != nil {
+		log.Warnf("UpdateOrganization bad request: %s, for organization: %s", err, org.ID)
+		return fiber.ErrBadRequest
+	}
+	if err := params.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	if err := repository.Organizations.Rename(c.Context(), org.ID, params.Name); err != nil {
+		log.Warnf("UpdateOrganization failed: %s, for organization: %s", err, org.ID)
+		return fiber.ErrInternalServerError
+	}
+
+	org.Name = params.Name
+	return c.JSON(org)
+}
+
+// DeleteOrganization delete an organization; admins only. Its portfolios
+// return to the members that created them.
+func DeleteOrganization(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	org, err := loadOrganization(c, userID, true)
+	if err != nil {
+		return err
+	}
+
+	err = repository.Transaction(c.Context(), func(r *repository.Repositories) error {
+		return r.Organizations.Delete(c.Context(), org.ID)
+	})
+	if err != nil {
+		log.Warnf("DeleteOrganization failed: %s, for organization: %s", err, org.ID)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(fiber.Map{"status": "success"})
+}
+
+// memberParams the role and notifications of a member in a request body
+type memberParams struct {
+	Email  string `json:"email"`
+	Role   string `json:"role"`
+	Notify *bool  `json:"notify"`
+}
+
+// AddMember add the user with the email in the body to an organization as a
+// viewer, or with the role in the body; admins only. The user must have
+// signed in to the service before.
+func AddMember(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	org, err := loadOrganization(c, userID, true)
+	if err != nil {
+		return err
+	}
+
+	params := memberParams{}
+	if err := json.Unmarshal(c.Body(), &params); err != nil {
+		log.Warnf("AddMember bad request: %s, for organization: %s", err, org.ID)
+		return fiber.ErrBadRequest
+	}
+	if params.Role == "" {
+		params.Role = organization.RoleViewer
+	}
+	if !organization.ValidRole(params.Role) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "role must be one of viewer, editor, or admin"})
+	}
+
+	memberID, err := repository.Users.IDByEmail(c.Context(), strings.TrimSpace(params.Email))
+	if err == sql.ErrNoRows {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "no user has that email"})
+	}
+	if err != nil {
+		log.Warnf("AddMember failed to find user: %s, for organization: %s", err, org.ID)
+		return fiber.ErrInternalServerError
+	}
+
+	members, err := repository.Organizations.Members(c.Context(), org.ID)
+	if err != nil {
+		log.Warnf("AddMember failed to list members: %s, for organization: %s", err, org.ID)
+		return fiber.ErrInternalServerError
+	}
+	for _, m := range members {
+		if m.UserID == memberID {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "user is already a member"})
+		}
+	}
+
+	m := organization.Member{
+		OrgID:  org.ID,
+		UserID: memberID,
+		Role:   params.Role,
+		Notify: params.Notify == nil || *params.Notify,
+	}
+	if err := repository.Organizations.SaveMember(c.Context(), &m); err != nil {
+		log.Warnf("AddMember failed: %s, for organization: %s", err, org.ID)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(m)
+}
+
+// findMember the member of members with userID, or nil
+func findMember(members []*organization.Member, userID string) *organization.Member {
+	for _, m := range members {
+		if m.UserID == userID {
+			return m
+		}
+	}
+	return nil
+}
+
+// UpdateMember change the role or notifications of a member. Admins may
+// change any member; other members may only change whether they are
+// notified. The last admin cannot give up their role.
+func UpdateMember(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+	memberID := c.Params("userId")
+
+	org, err := loadOrganization(c, userID, false)
+	if err != nil {
+		return err
+	}
+	if memberID != userID && !organization.CanManage(org.Role) {
+		return fiber.ErrForbidden
+	}
+
+	params := memberParams{}
+	if err := json.Unmarshal(c.Body(), &params); err != nil {
+		log.Warnf("UpdateMember bad request: %s, for organization: %s", err, org.ID)
+		return fiber.ErrBadRequest
+	}
+
+	members, err := repository.Organizations.Members(c.Context(), org.ID)
+	if err != nil {
+		log.Warnf("UpdateMember failed to list members: %s, for organization: %s", err, org.ID)
+		return fiber.ErrInternalServerError
+	}
+	m := findMember(members, memberID)
+	if m == nil {
+		return fiber.ErrNotFound
+	}
+
+	if params.Role != "" && params.Role != m.Role {
+		if !organization.CanManage(org.Role) {
+			return fiber.ErrForbidden
+		}
+		if !organization.ValidRole(params.Role) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "role must be one of viewer, editor, or admin"})
+		}
+		if organization.LastAdmin(members, memberID) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "an organization must have an admin"})
+		}
+		m.Role = params.Role
+	}
+	if params.Notify != nil {
+		m.Notify = *params.Notify
+	}
+
+	if err := repository.Organizations.SaveMember(c.Context(), m); err != nil {
+		log.Warnf("UpdateMember failed: %s, for organization: %s", err, org.ID)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(m)
+}
+
+// RemoveMember remove a member from an organization. Admins may remove any
+// member and members may leave; the last admin cannot leave.
+func RemoveMember(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+	memberID := c.Params("userId")
+
+	org, err := loadOrganization(c, userID, false)
+	if err != nil {
+		return err
+	}
+	if memberID != userID && !organization.CanManage(org.Role) {
+		return fiber.ErrForbidden
+	}
+
+	members, err := repository.Organizations.Members(c.Context(), org.ID)
+	if err != nil {
+		log.Warnf("RemoveMember failed to list members: %s, for organization: %s", err, org.ID)
+		return fiber.ErrInternalServerError
+	}
+	if organization.LastAdmin(members, memberID) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "an organization must have an admin"})
+	}
+
+	err = repository.Organizations.RemoveMember(c.Context(), org.ID, memberID)
+	if err == sql.ErrNoRows {
+		return fiber.ErrNotFound
+	}
+	if err != nil {
+		log.Warnf("RemoveMember failed: %s, for organization: %s", err, org.ID)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(fiber.Map{"status": "success"})
+}
+
+// SetPortfolioOrganization transfer a portfolio the logged in user created to
+// the organization in the body's org_id, which they must be an editor of, or
+// back to them if org_id is null
+func SetPortfolioOrganization(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	params := struct {
+		OrgID *uuid.UUID `json:"org_id"`
+	}{}
+	if err := json.Unmarshal(c.Body(), &params); err != nil {
+		log.Warnf("SetPortfolioOrganization bad request: %s, for portfolio: %s", err, portfolioID)
+		return fiber.ErrBadRequest
+	}
+
+	if params.OrgID != nil {
+		org, err := repository.Organizations.Get(c.Context(), params.OrgID.String(), userID)
+		if err != nil || !organization.CanEdit(org.Role) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "org_id must be an organization you are an editor of"})
+		}
+	}
+
+	err := repository.Portfolios.SetOrganization(c.Context(), portfolioID, userID, params.OrgID)
+	if err == sql.ErrNoRows {
+		return fiber.ErrNotFound
+	}
+	if err != nil {
+		log.Warnf("SetPortfolioOrganization failed: %s, for portfolio: %s", err, portfolioID)
+		return fiber.ErrInternalServerError
+	}
+
+	p, err := loadPortfolio(c.Context(), portfolioID, userID)
+	if err != nil {
+		log.Warnf("SetPortfolioOrganization %s failed: %s", portfolioID, err)
+		return fiber.ErrInternalServerError
+	}
+	return c.JSON(p)
+}
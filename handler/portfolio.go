@@ -1,29 +1,104 @@
 package handler
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
-	"main/database"
+	"main/data"
+	"main/notification"
+	"main/organization"
+	"main/portfolio"
+	"main/repository"
+	"main/strategies"
+	"main/tag"
+	"strings"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
-	"github.com/jmoiron/sqlx/types"
 	log "github.com/sirupsen/logrus"
 )
 
+// PortfolioResponse a saved portfolio as returned by the API
 type PortfolioResponse struct {
-	ID                 uuid.UUID       `json:"id"`
-	Name               string          `json:"name"`
-	Strategy           string          `json:"strategy"`
-	Arguments          types.JSONText  `json:"arguments"`
-	StartDate          int64           `json:"start_date"`
-	YTDReturn          sql.NullFloat64 `json:"ytd_return"`
-	CAGRSinceInception sql.NullFloat64 `json:"cagr_since_inception"`
-	Notifications      int             `json:"notifications"`
-	Created            int64           `json:"created"`
-	LastChanged        int64           `json:"lastchanged"`
+	repository.Portfolio
+
+	// Formatted dates and metrics formatted in the user's locale, included
+	// when requested with formatted=true
+	Formatted map[string]string `json:"formatted,omitempty"`
+
+	// Role of the logged in user: owner for portfolios they created,
+	// otherwise their role in the organization that owns the portfolio
+	Role string `json:"role,omitempty"`
+}
+
+// roleOwner role of the user that created a portfolio
+const roleOwner = "owner"
+
+// canEdit true if the logged in user may change the portfolio
+func (p *PortfolioResponse) canEdit() bool {
+	return p.Role == roleOwner || organization.CanEdit(p.Role)
+}
+
+// loadPortfolio retrieve a saved portfolio owned by userID or shared with
+// them by an organization
+func loadPortfolio(ctx context.Context, portfolioID string, userID string) (PortfolioResponse, error) {
+	p, err := repository.Portfolios.Get(ctx, portfolioID, userID)
+	if err != nil {
+		return PortfolioResponse{}, err
+	}
+
+	resp := PortfolioResponse{Portfolio: *p, Role: roleOwner}
+	if p.UserID != userID && p.OrgID != nil {
+		org, err := repository.Organizations.Get(ctx, p.OrgID.String(), userID)
+		if err != nil {
+			return PortfolioResponse{}, err
+		}
+		resp.Role = org.Role
+	}
+	return resp, nil
+}
+
+// loadPortfolios retrieve the saved portfolios owned by userID
+func loadPortfolios(ctx context.Context, userID string) ([]PortfolioResponse, error) {
+	saved, err := repository.Portfolios.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	portfolios := make([]PortfolioResponse, 0, len(saved))
+	for _, p := range saved {
+		portfolios = append(portfolios, PortfolioResponse{Portfolio: *p, Role: roleOwner})
+	}
+	return portfolios, nil
+}
+
+// loadSharedPortfolios retrieve the saved portfolios other members of
+// userID's organizations created
+func loadSharedPortfolios(ctx context.Context, userID string) ([]PortfolioResponse, error) {
+	orgs, err := repository.Organizations.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(orgs) == 0 {
+		return []PortfolioResponse{}, nil
+	}
+	roles := make(map[uuid.UUID]string, len(orgs))
+	for _, org := range orgs {
+		roles[org.ID] = org.Role
+	}
+
+	saved, err := repository.Portfolios.ListShared(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	portfolios := make([]PortfolioResponse, 0, len(saved))
+	for _, p := range saved {
+		portfolios = append(portfolios, PortfolioResponse{Portfolio: *p, Role: roles[*p.OrgID]})
+	}
+	return portfolios, nil
 }
 
 // GetPortfolio get a portfolio
@@ -37,47 +112,218 @@ func GetPortfolio(c *fiber.Ctx) error {
 	claims := user.Claims.(jwt.MapClaims)
 	userID := claims["sub"].(string)
 
-	portfolioSQL := `SELECT id, name, strategy_shortcode, arguments, extract(epoch from start_date)::int as start_date, ytd_return, cagr_since_inception, notifications, extract(epoch from created)::int as created, extract(epoch from lastchanged)::int as lastchanged FROM portfolio WHERE id=$1 AND userid=$2`
-	row := database.Conn.QueryRow(portfolioSQL, portfolioID, userID)
-	p := PortfolioResponse{}
-	err := row.Scan(&p.ID, &p.Name, &p.Strategy, &p.Arguments, &p.StartDate, &p.YTDReturn, &p.CAGRSinceInception, &p.Notifications, &p.Created, &p.LastChanged)
+	p, err := loadPortfolio(c.Context(), portfolioID, userID)
 	if err != nil {
 		log.Warnf("GetPortfolio %s failed: %s", portfolioID, err)
 		return fiber.ErrNotFound
 	}
 
+	portfolios := []PortfolioResponse{p}
+	if err := attachTags(c.Context(), userID, portfolios); err != nil {
+		log.Warnf("GetPortfolio %s failed to list tags: %s", portfolioID, err)
+		return fiber.ErrInternalServerError
+	}
+	p = portfolios[0]
+
+	formatPortfolio(&p, requestLocale(c, userID))
 	return c.JSON(p)
 }
 
-// ListPortfolios list all portfolios for logged in user
-func ListPortfolios(c *fiber.Ctx) error {
+// GetPortfolioPerformance calculate the performance of a saved portfolio
+// from its start date through today. After-tax values are calculated based
+// on the account type of the portfolio; journal notes shown on the chart are
+// included as annotations
+func GetPortfolioPerformance(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
 	user := c.Locals("user").(*jwt.Token)
 	claims := user.Claims.(jwt.MapClaims)
 	userID := claims["sub"].(string)
 
-	portfolioSQL := `SELECT id, name, strategy_shortcode, arguments, extract(epoch from start_date)::int as start_date, ytd_return, cagr_since_inception, notifications, extract(epoch from created)::int as created, extract(epoch from lastchanged)::int as lastchanged FROM portfolio WHERE userid=$1 ORDER BY name, created`
-	rows, err := database.Conn.Query(portfolioSQL, userID)
+	p, err := loadPortfolio(c.Context(), portfolioID, userID)
 	if err != nil {
-		log.Warnf("ListPortfolio failed: %s", err)
+		log.Warnf("GetPortfolioPerformance %s failed: %s", portfolioID, err)
 		return fiber.ErrNotFound
 	}
 
-	portfolios := []PortfolioResponse{}
-	for rows.Next() {
-		p := PortfolioResponse{}
-		err := rows.Scan(&p.ID, &p.Name, &p.Strategy, &p.Arguments, &p.StartDate, &p.YTDReturn, &p.CAGRSinceInception, &p.Notifications, &p.Created, &p.LastChanged)
+	resolution, err := parseResolution(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	encoding, err := parseEncoding(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	manager := newDataManager(c)
+	perf, err := computeSavedPerformance(&p, &manager, resolution)
+	if err != nil {
+		log.Warnf("GetPortfolioPerformance cannot calculate performance for portfolio %s: %s", portfolioID, err)
+		return fiber.ErrBadRequest
+	}
+
+	perf.BuildMetricsBundle()
+	if err := compareToBenchmarks(c, perf, benchmarkIDs(c, attachedBenchmarkIDs(c.Context(), portfolioID))); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+	annotateWithNotes(c, p.ID, perf)
+
+	return sendPerformance(c, perf, encoding)
+}
+
+// PerformanceExpiration cache saved portfolio performance until prices for
+// the next trading day are available
+func PerformanceExpiration(now time.Time) time.Time {
+	tz, err := time.LoadLocation(notification.MarketTimezone)
+	if err != nil {
+		tz = time.UTC
+	}
+	return notification.NextPricesAvailable(now, tz)
+}
+
+// PortfolioAudience the other users that see the portfolio in the url: the
+// members of the organization that owns it. Their cached responses are
+// invalidated when the portfolio changes.
+func PortfolioAudience(c *fiber.Ctx) []string {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	p, err := repository.Portfolios.Get(c.Context(), c.Params("id"), userID)
+	if err != nil || p.OrgID == nil {
+		return nil
+	}
+	return memberIDs(c.Context(), *p.OrgID)
+}
+
+// computeSavedPerformance calculate the performance of a saved portfolio
+// from its start date through today at the given resolution, including
+// after-tax values
+func computeSavedPerformance(p *PortfolioResponse, manager *data.Manager, resolution string) (*portfolio.Performance, error) {
+	endDate := time.Now()
+	year, month, day := endDate.Date()
+	endDate = time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	manager.Begin = time.Unix(p.StartDate, 0)
+	manager.End = endDate
+	computed, err := computeSavedPortfolio(p, manager)
+	if err != nil {
+		return nil, err
+	}
+
+	computed.Resolution = resolution
+	perf, err := computed.CalculatePerformance(endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := perf.CalculateAfterTax(p.AccountType, p.TaxRates()); err != nil {
+		return nil, err
+	}
+
+	return &perf, nil
+}
+
+// AggregatePortfolios combine the user's portfolios into a single household
+// view with a combined equity curve, holdings, and metrics. The portfolios
+// to include may be limited with a comma separated list of ids.
+func AggregatePortfolios(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	portfolios, err := loadPortfolios(c.Context(), userID)
+	if err != nil {
+		log.Warnf("AggregatePortfolios failed: %s", err)
+		return fiber.ErrNotFound
+	}
+
+	include := make(map[string]bool)
+	if ids := c.Query("ids"); ids != "" {
+		for _, id := range strings.Split(ids, ",") {
+			include[strings.TrimSpace(id)] = true
+		}
+	}
+
+	saved := []PortfolioResponse{}
+	for _, p := range portfolios {
+		if len(include) == 0 || include[p.ID.String()] {
+			saved = append(saved, p)
+		}
+	}
+
+	if len(saved) == 0 {
+		return fiber.ErrNotFound
+	}
+
+	resolution, err := parseResolution(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	members := make([]portfolio.HouseholdMember, 0, len(saved))
+	for ii := range saved {
+		manager := newDataManager(c)
+		perf, err := computeSavedPerformance(&saved[ii], &manager, resolution)
 		if err != nil {
-			log.Warnf("ListPortfolio failed %s", err)
+			log.Warnf("AggregatePortfolios cannot calculate performance for portfolio %s: %s", saved[ii].ID, err)
+			return fiber.ErrBadRequest
 		}
-		portfolios = append(portfolios, p)
+		members = append(members, portfolio.HouseholdMember{
+			ID:          saved[ii].ID.String(),
+			Name:        saved[ii].Name,
+			Performance: perf,
+		})
+	}
+
+	report := portfolio.Household(members)
+	if err := compareToBenchmarks(c, &report.Performance, benchmarkIDs(c, nil)); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
 	}
 
-	err = rows.Err()
+	return c.JSON(report)
+}
+
+// ListPortfolios list all portfolios for logged in user, followed by the
+// portfolios shared with them by their organizations
+// @Param tag query string false "comma separated tags portfolios must have"
+// @Param folder query string false "folder portfolios are filed in, including subfolders"
+// @Param sort query string false "sort by name, cagr, ytd, or signal (last signal change)"
+// @Param order query string false "asc or desc; names sort ascending and the others descending by default"
+func ListPortfolios(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	portfolios, err := loadPortfolios(c.Context(), userID)
 	if err != nil {
 		log.Warnf("ListPortfolio failed: %s", err)
 		return fiber.ErrNotFound
 	}
 
+	shared, err := loadSharedPortfolios(c.Context(), userID)
+	if err != nil {
+		log.Warnf("ListPortfolio failed to list shared portfolios: %s", err)
+		return fiber.ErrNotFound
+	}
+	portfolios = append(portfolios, shared...)
+
+	if err := attachTags(c.Context(), userID, portfolios); err != nil {
+		log.Warnf("ListPortfolio failed to list tags: %s", err)
+		return fiber.ErrInternalServerError
+	}
+	portfolios = filterPortfolios(c, portfolios)
+	if sortBy := c.Query("sort"); sortBy != "" {
+		if err := sortPortfolios(portfolios, sortBy, c.Query("order")); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		}
+	}
+
+	loc := requestLocale(c, userID)
+	for ii := range portfolios {
+		formatPortfolio(&portfolios[ii], loc)
+	}
+
 	return c.JSON(portfolios)
 }
 
@@ -94,19 +340,80 @@ func CreatePortfolio(c *fiber.Ctx) error {
 		return fiber.ErrBadRequest
 	}
 
+	if params.AccountType == "" {
+		params.AccountType = portfolio.AccountTaxable
+	}
+	if !portfolio.ValidAccountType(params.AccountType) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "account_type must be one of taxable, ira, or 401k"})
+	}
+	if params.ShortTermTaxRate == 0 && params.LongTermTaxRate == 0 && params.DividendTaxRate == 0 {
+		params.ShortTermTaxRate = portfolio.DefaultTaxRates.ShortTermCapitalGains
+		params.LongTermTaxRate = portfolio.DefaultTaxRates.LongTermCapitalGains
+		params.DividendTaxRate = portfolio.DefaultTaxRates.Dividends
+	}
+
+	args := map[string]json.RawMessage{}
+	if err := json.Unmarshal(params.Arguments, &args); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "arguments must be a JSON object"})
+	}
+	if err := checkStrategyConstraints(c, params.Strategy, args, time.Unix(params.StartDate, 0)); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+	if err := checkPortfolioPlan(c.Context(), userID, true, params.StartDate, 0); err != nil {
+		return planError(c, err)
+	}
+
+	// portfolios are created for an organization by its editors
+	if params.OrgID != nil {
+		org, err := repository.Organizations.Get(c.Context(), params.OrgID.String(), userID)
+		if err != nil || !organization.CanEdit(org.Role) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "org_id must be an organization you are an editor of"})
+		}
+	}
+
+	var err error
+	if params.Folder, err = tag.NormalizeFolder(params.Folder); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+	if params.Tags, err = tag.Normalize(params.Tags); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	// arguments are saved for the current version of the strategy
+	params.StrategyVersion = strategies.StrategyMap[params.Strategy].Version
+
 	// Save to database
-	portfolioID := uuid.New()
-	portfolioSQL := `INSERT INTO Portfolio ("id", "userid", "name", "strategy_shortcode", "arguments", "start_date") VALUES ($1, $2, $3, $4, $5, $6)`
-	_, err := database.Conn.Exec(portfolioSQL, portfolioID, userID, params.Name, params.Strategy, params.Arguments, time.Unix(params.StartDate, 0))
+	params.ID = uuid.Nil
+	params.UserID = userID
+	err = repository.Transaction(c.Context(), func(r *repository.Repositories) error {
+		if err := r.Portfolios.Create(c.Context(), &params.Portfolio); err != nil {
+			return err
+		}
+		if len(params.Tags) > 0 {
+			return r.Portfolios.SetTags(c.Context(), params.ID, params.Folder, params.Tags)
+		}
+		return nil
+	})
 	if err != nil {
 		log.Warnf("Failed to create portfolio for %s: %s", params.Strategy, err)
 		return fiber.ErrBadRequest
 	}
 
 	return c.JSON(PortfolioResponse{
-		ID:       portfolioID,
-		Name:     params.Name,
-		Strategy: params.Strategy,
+		Portfolio: repository.Portfolio{
+			ID:               params.ID,
+			Name:             params.Name,
+			Strategy:         params.Strategy,
+			StrategyVersion:  params.StrategyVersion,
+			AccountType:      params.AccountType,
+			ShortTermTaxRate: params.ShortTermTaxRate,
+			LongTermTaxRate:  params.LongTermTaxRate,
+			DividendTaxRate:  params.DividendTaxRate,
+			OrgID:            params.OrgID,
+			Folder:           params.Folder,
+			Tags:             params.Tags,
+		},
+		Role: roleOwner,
 	})
 }
 
@@ -123,14 +430,14 @@ func UpdatePortfolio(c *fiber.Ctx) error {
 		return fiber.ErrBadRequest
 	}
 
-	portfolioSQL := `SELECT id, name, strategy_shortcode, arguments, extract(epoch from start_date)::int as start_date, ytd_return, cagr_since_inception, notifications, extract(epoch from created)::int as created, extract(epoch from lastchanged)::int as lastchanged FROM portfolio WHERE id=$1 AND userid=$2`
-	row := database.Conn.QueryRow(portfolioSQL, portfolioID, userID)
-	p := PortfolioResponse{}
-	err := row.Scan(&p.ID, &p.Name, &p.Strategy, &p.Arguments, &p.StartDate, &p.YTDReturn, &p.CAGRSinceInception, &p.Notifications, &p.Created, &p.LastChanged)
+	p, err := loadPortfolio(c.Context(), portfolioID, userID)
 	if err != nil {
 		log.Warnf("UpdatePortfolio %s failed: %s", portfolioID, err)
 		return fiber.ErrNotFound
 	}
+	if !p.canEdit() {
+		return fiber.ErrForbidden
+	}
 
 	if params.Name == "" {
 		params.Name = p.Name
@@ -139,17 +446,39 @@ func UpdatePortfolio(c *fiber.Ctx) error {
 	if params.Notifications == 0 {
 		params.Notifications = p.Notifications
 	}
+	if params.Notifications != p.Notifications {
+		if err := checkPortfolioPlan(c.Context(), userID, false, 0, params.Notifications); err != nil {
+			return planError(c, err)
+		}
+	}
 
-	updateSQL := `UPDATE Portfolio SET name=$1, notifications=$2 WHERE id=$3 AND userid=$4`
-	_, err = database.Conn.Exec(updateSQL, params.Name, params.Notifications, portfolioID, userID)
-	if err != nil {
+	if params.AccountType == "" {
+		params.AccountType = p.AccountType
+	}
+	if !portfolio.ValidAccountType(params.AccountType) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "account_type must be one of taxable, ira, or 401k"})
+	}
+
+	if params.ShortTermTaxRate == 0 {
+		params.ShortTermTaxRate = p.ShortTermTaxRate
+	}
+
+	if params.LongTermTaxRate == 0 {
+		params.LongTermTaxRate = p.LongTermTaxRate
+	}
+
+	if params.DividendTaxRate == 0 {
+		params.DividendTaxRate = p.DividendTaxRate
+	}
+
+	params.ID = p.ID
+	params.UserID = userID
+	if err := repository.Portfolios.Update(c.Context(), &params.Portfolio); err != nil {
 		log.Warnf("UpdatePortfolio SQL update failed: %s for portfolio: %s", err, portfolioID)
 		return fiber.ErrInternalServerError
 	}
 
-	row = database.Conn.QueryRow(portfolioSQL, portfolioID, userID)
-	p = PortfolioResponse{}
-	err = row.Scan(&p.ID, &p.Name, &p.Strategy, &p.Arguments, &p.StartDate, &p.YTDReturn, &p.CAGRSinceInception, &p.Notifications, &p.Created, &p.LastChanged)
+	p, err = loadPortfolio(c.Context(), portfolioID, userID)
 	if err != nil {
 		log.Warnf("UpdatePortfolio %s failed: %s", portfolioID, err)
 		return fiber.ErrInternalServerError
@@ -165,8 +494,10 @@ func DeletePortfolio(c *fiber.Ctx) error {
 	claims := user.Claims.(jwt.MapClaims)
 	userID := claims["sub"].(string)
 
-	deleteSQL := "DELETE FROM Portfolio WHERE id=$1 AND userid=$2"
-	_, err := database.Conn.Exec(deleteSQL, portfolioID, userID)
+	err := repository.Portfolios.Delete(c.Context(), portfolioID, userID)
+	if err == sql.ErrNoRows {
+		return fiber.ErrNotFound
+	}
 	if err != nil {
 		log.Warnf("DeletePortfolio delete failed: %s, for portfolio: %s", err, portfolioID)
 		return fiber.ErrInternalServerError
@@ -174,3 +505,140 @@ func DeletePortfolio(c *fiber.Ctx) error {
 
 	return c.JSON(fiber.Map{"status": "success"})
 }
+
+// TrashedPortfolio a deleted portfolio and when it will be permanently removed
+type TrashedPortfolio struct {
+	PortfolioResponse
+	PurgeAt int64 `json:"purge_at"`
+}
+
+// ListTrashedPortfolios list the deleted portfolios of the logged in user that
+// can still be restored
+func ListTrashedPortfolios(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	trashed, err := repository.Portfolios.ListTrash(c.Context(), userID)
+	if err != nil {
+		log.Warnf("ListTrashedPortfolios failed: %s", err)
+		return fiber.ErrInternalServerError
+	}
+
+	loc := requestLocale(c, userID)
+	portfolios := make([]TrashedPortfolio, 0, len(trashed))
+	for _, p := range trashed {
+		t := TrashedPortfolio{
+			PortfolioResponse: PortfolioResponse{Portfolio: *p},
+			PurgeAt:           *p.DeletedAt + int64(repository.TrashRetention/time.Second),
+		}
+		formatPortfolio(&t.PortfolioResponse, loc)
+		portfolios = append(portfolios, t)
+	}
+
+	return c.JSON(portfolios)
+}
+
+// RestorePortfolio move a deleted portfolio out of the trash
+func RestorePortfolio(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	if err := checkPortfolioPlan(c.Context(), userID, true, 0, 0); err != nil {
+		return planError(c, err)
+	}
+
+	err := repository.Portfolios.Restore(c.Context(), portfolioID, userID)
+	if err == sql.ErrNoRows {
+		return fiber.ErrNotFound
+	}
+	if err != nil {
+		log.Warnf("RestorePortfolio failed: %s, for portfolio: %s", err, portfolioID)
+		return fiber.ErrInternalServerError
+	}
+
+	p, err := loadPortfolio(c.Context(), portfolioID, userID)
+	if err != nil {
+		log.Warnf("RestorePortfolio %s failed: %s", portfolioID, err)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(p)
+}
+
+// ClonePortfolio copy a portfolio's strategy, arguments, settings, and tags to a
+// new portfolio named by the optional name in the body, or "<name> (copy)".
+// With history=true its metrics, transactions, and signals are copied as
+// well; otherwise the notifier computes them from the start date.
+func ClonePortfolio(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	params := struct {
+		Name string `json:"name"`
+	}{}
+	if len(c.Body()) > 0 {
+		if err := json.Unmarshal(c.Body(), &params); err != nil {
+			log.Warnf("ClonePortfolio bad request: %s, for portfolio: %s", err, portfolioID)
+			return fiber.ErrBadRequest
+		}
+	}
+
+	p, err := loadPortfolio(c.Context(), portfolioID, userID)
+	if err != nil {
+		log.Warnf("ClonePortfolio %s failed: %s", portfolioID, err)
+		return fiber.ErrNotFound
+	}
+	history := c.Query("history") == "true"
+
+	tags, err := repository.Portfolios.Tags(c.Context(), userID)
+	if err != nil {
+		log.Warnf("ClonePortfolio failed to list tags: %s, for portfolio: %s", err, portfolioID)
+		return fiber.ErrInternalServerError
+	}
+
+	// the clone belongs to the user cloning it, even if the portfolio is
+	// shared with them by an organization
+	clone := p.Portfolio
+	clone.ID = uuid.Nil
+	clone.UserID = userID
+	clone.OrgID = nil
+	clone.Name = params.Name
+	if clone.Name == "" {
+		clone.Name = p.Name + " (copy)"
+	}
+	if err := checkPortfolioPlan(c.Context(), userID, true, clone.StartDate, 0); err != nil {
+		return planError(c, err)
+	}
+
+	err = repository.Transaction(c.Context(), func(r *repository.Repositories) error {
+		if err := r.Portfolios.Create(c.Context(), &clone); err != nil {
+			return err
+		}
+		if len(tags[p.ID]) > 0 {
+			if err := r.Portfolios.SetTags(c.Context(), clone.ID, clone.Folder, tags[p.ID]); err != nil {
+				return err
+			}
+		}
+		if history {
+			return r.Measurements.CopyHistory(c.Context(), p.ID, clone.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Warnf("ClonePortfolio failed: %s, for portfolio: %s", err, portfolioID)
+		return fiber.ErrInternalServerError
+	}
+
+	cloned, err := loadPortfolio(c.Context(), clone.ID.String(), userID)
+	if err != nil {
+		log.Warnf("ClonePortfolio %s failed: %s", portfolioID, err)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(cloned)
+}
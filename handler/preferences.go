@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"main/preferences"
+	"main/repository"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// loadPreferences retrieve the preferences of userID; users that have not
+// saved any preferences receive the defaults
+func loadPreferences(ctx context.Context, userID string) (preferences.Preferences, error) {
+	prefs, err := repository.Users.Preferences(ctx, userID)
+	if err != nil {
+		return preferences.Preferences{}, err
+	}
+	return *prefs, nil
+}
+
+// GetPreferences get the preferences of the logged in user
+func GetPreferences(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	prefs, err := loadPreferences(c.Context(), userID)
+	if err != nil {
+		log.Warnf("GetPreferences failed for user %s: %s", userID, err)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(prefs)
+}
+
+// UpdatePreferences update the preferences of the logged in user; fields not
+// present in the request body are unchanged
+func UpdatePreferences(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	prefs, err := loadPreferences(c.Context(), userID)
+	if err != nil {
+		log.Warnf("UpdatePreferences failed for user %s: %s", userID, err)
+		return fiber.ErrInternalServerError
+	}
+
+	// unmarshal on top of the existing preferences so unspecified fields are kept
+	if err := json.Unmarshal(c.Body(), &prefs); err != nil {
+		log.Warnf("UpdatePreferences bad request: %s", err)
+		return fiber.ErrBadRequest
+	}
+
+	if err := prefs.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	if _, err := loadBenchmarks(c.Context(), []string{prefs.DefaultBenchmark}, userID); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	prefs.UserID = userID
+	if err := repository.Users.SavePreferences(c.Context(), &prefs); err != nil {
+		log.Warnf("UpdatePreferences SQL upsert failed: %s for user: %s", err, userID)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(prefs)
+}
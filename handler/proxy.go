@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"encoding/json"
+	"main/data"
+	"main/portfolio"
+	"main/strategies"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// etfProxyArguments substitute the ETF equivalents of the mutual funds in
+// the arguments of strat. Returns the substituted arguments and the
+// substitutions that were made.
+func etfProxyArguments(strat *strategies.StrategyInfo, params map[string]json.RawMessage) (map[string]json.RawMessage, []portfolio.Substitution, error) {
+	tickers, err := strat.Tickers(params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	proxies := portfolio.ETFProxies(tickers)
+	replacements := make(map[string]string, len(proxies))
+	for _, s := range proxies {
+		replacements[s.From.Ticker] = s.To.Ticker
+	}
+
+	substituted, _, err := strat.SubstituteTickers(params, replacements)
+	if err != nil {
+		return nil, nil, err
+	}
+	return substituted, proxies, nil
+}
+
+// useETFProxies substitute the ETF equivalents of the mutual funds in the
+// arguments of strat and extend the history of each ETF on manager with the
+// fund it replaces
+func useETFProxies(strat *strategies.StrategyInfo, params map[string]json.RawMessage, manager *data.Manager) (map[string]json.RawMessage, error) {
+	substituted, proxies, err := etfProxyArguments(strat, params)
+	if err != nil {
+		return nil, err
+	}
+	if len(proxies) > 0 {
+		manager.Proxies = portfolio.ProxyHistory(proxies)
+	}
+	return substituted, nil
+}
+
+// SuggestETFProxies suggest ETF equivalents of the mutual funds in the
+// arguments of a strategy for users whose broker does not offer the funds
+// @Description ETF equivalents of the mutual funds in strategy arguments and the arguments with them substituted
+// @Id SuggestETFProxies
+// @Accept json
+// @Produce json
+// @Param id path string true "strategy shortcode"
+func SuggestETFProxies(c *fiber.Ctx) error {
+	shortcode := c.Params("id")
+	strat, ok := availableStrategy(c, shortcode)
+	if !ok {
+		return fiber.ErrNotFound
+	}
+
+	params := map[string]json.RawMessage{}
+	if err := json.Unmarshal(c.Body(), &params); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "arguments must be a JSON object"})
+	}
+
+	substituted, proxies, err := etfProxyArguments(&strat, params)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"substitutions": proxies,
+		"arguments":     substituted,
+	})
+}
@@ -0,0 +1,262 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"main/rebalance"
+	"main/repository"
+	"os"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// pendingOrders a batch of orders and the link that confirms them without
+// logging in
+type pendingOrders struct {
+	*rebalance.Batch
+	ConfirmURL string `json:"confirmUrl,omitempty"`
+}
+
+// loadBatch load a batch of orders, marking it expired if it has passed
+// its expiry while pending
+func loadBatch(ctx context.Context, id uuid.UUID, now time.Time) (*rebalance.Batch, error) {
+	b, err := repository.Rebalances.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if b.Expire(now) {
+		if err := repository.Rebalances.UpdateStatus(ctx, b); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// userBatch the batch of orders in the batchId parameter if it belongs to
+// the portfolio in the id parameter and was created by userID
+func userBatch(c *fiber.Ctx, userID string) (*rebalance.Batch, error) {
+	id, err := uuid.Parse(c.Params("batchId"))
+	if err != nil {
+		return nil, sql.ErrNoRows
+	}
+	b, err := loadBatch(c.Context(), id, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if b.UserID != userID || b.PortfolioID.String() != c.Params("id") {
+		return nil, sql.ErrNoRows
+	}
+	return b, nil
+}
+
+// confirmOrders confirm a batch of orders with the fills in the request body,
+// if any, and record them as executed transactions traded today
+func confirmOrders(c *fiber.Ctx, b *rebalance.Batch) error {
+	fills := []rebalance.Fill{}
+	if len(c.Body()) > 0 {
+		if err := json.Unmarshal(c.Body(), &fills); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "fills must be a list of ticker, shares, price, and fees"})
+		}
+	}
+
+	today, _, err := marketToday()
+	if err != nil {
+		log.Warnf("confirmOrders cannot load market timezone: %s", err)
+		return fiber.ErrInternalServerError
+	}
+
+	trxs, err := b.Confirm(time.Now(), today, fills)
+	if errors.Is(err, rebalance.ErrNotPending) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"status": "error", "message": err.Error(), "orderStatus": b.Status})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	err = repository.Transaction(c.Context(), func(r *repository.Repositories) error {
+		if err := r.Rebalances.UpdateStatus(c.Context(), b); err != nil {
+			return err
+		}
+		return r.Rebalances.RecordTransactions(c.Context(), b.PortfolioID, b.UserID, trxs)
+	})
+	if err != nil {
+		log.Warnf("confirmOrders failed: %s, for orders: %s", err, b.ID)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(fiber.Map{
+		"orders":       b,
+		"transactions": trxs,
+	})
+}
+
+// cancelOrders cancel a pending batch of orders
+func cancelOrders(c *fiber.Ctx, b *rebalance.Batch) error {
+	if err := b.Cancel(time.Now()); err != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"status": "error", "message": err.Error(), "orderStatus": b.Status})
+	}
+	if err := repository.Rebalances.UpdateStatus(c.Context(), b); err != nil {
+		log.Warnf("cancelOrders failed: %s, for orders: %s", err, b.ID)
+		return fiber.ErrInternalServerError
+	}
+	return c.JSON(b)
+}
+
+// CreatePendingOrders suggest orders for a portfolio, as GenerateOrders does,
+// and save them pending confirmation. The response includes a signed link
+// that confirms them when ORDER_CONFIRMATION_URL and
+// ORDER_CONFIRMATION_SECRET are set. Unconfirmed orders expire once prices
+// for the next trading day are available.
+// @Description Save the suggested orders of a portfolio pending confirmation
+// @Id CreatePendingOrders
+// @Produce json
+// @Param id path string true "id of portfolio"
+// @Param cash query number false "cash available in the brokerage account"
+// @Param wholeShares query bool false "only order whole shares; defaults to true"
+func CreatePendingOrders(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	p, err := loadPortfolio(c.Context(), portfolioID, userID)
+	if err != nil {
+		log.Warnf("CreatePendingOrders %s failed: %s", portfolioID, err)
+		return fiber.ErrNotFound
+	}
+
+	today, tz, err := marketToday()
+	if err != nil {
+		log.Warnf("CreatePendingOrders cannot load market timezone: %s", err)
+		return fiber.ErrInternalServerError
+	}
+
+	orders, ok, err := suggestOrders(c, &p, userID, today)
+	if !ok {
+		return err
+	}
+	if len(orders.Orders) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "the portfolio is already at its target allocation"})
+	}
+
+	b := rebalance.NewBatch(p.ID, userID, &orders, time.Now(), tz)
+	if err := repository.Rebalances.Create(c.Context(), b); err != nil {
+		log.Warnf("CreatePendingOrders failed: %s, for portfolio: %s", err, portfolioID)
+		return fiber.ErrInternalServerError
+	}
+
+	resp := pendingOrders{Batch: b}
+	if base := os.Getenv("ORDER_CONFIRMATION_URL"); base != "" {
+		if resp.ConfirmURL, err = b.URL(base, os.Getenv("ORDER_CONFIRMATION_SECRET")); err != nil {
+			log.Warnf("CreatePendingOrders cannot sign confirmation link: %s", err)
+		}
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(resp)
+}
+
+// ListPendingOrders list the batches of orders created for a portfolio,
+// newest first
+func ListPendingOrders(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	p, err := loadPortfolio(c.Context(), portfolioID, userID)
+	if err != nil {
+		log.Warnf("ListPendingOrders %s failed: %s", portfolioID, err)
+		return fiber.ErrNotFound
+	}
+
+	batches, err := repository.Rebalances.List(c.Context(), p.ID, userID)
+	if err != nil {
+		log.Warnf("ListPendingOrders failed: %s, for portfolio: %s", err, portfolioID)
+		return fiber.ErrInternalServerError
+	}
+
+	// expiry is reported but not saved until the batch is loaded on its own
+	now := time.Now()
+	for _, b := range batches {
+		b.Expire(now)
+	}
+
+	return c.JSON(batches)
+}
+
+// ConfirmPendingOrders confirm a batch of orders and record them as executed
+// transactions. The body optionally lists the actual fills of the orders.
+func ConfirmPendingOrders(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	b, err := userBatch(c, userID)
+	if err != nil {
+		log.Warnf("ConfirmPendingOrders %s failed: %s", c.Params("batchId"), err)
+		return fiber.ErrNotFound
+	}
+	return confirmOrders(c, b)
+}
+
+// CancelPendingOrders cancel a batch of orders
+func CancelPendingOrders(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	b, err := userBatch(c, userID)
+	if err != nil {
+		log.Warnf("CancelPendingOrders %s failed: %s", c.Params("batchId"), err)
+		return fiber.ErrNotFound
+	}
+	return cancelOrders(c, b)
+}
+
+// tokenBatch the batch of orders authorized by the signed token in the
+// token query parameter
+func tokenBatch(c *fiber.Ctx) (*rebalance.Batch, error) {
+	id, err := rebalance.ParseToken(c.Query("token"), os.Getenv("ORDER_CONFIRMATION_SECRET"))
+	if err != nil {
+		return nil, err
+	}
+	return loadBatch(c.Context(), id, time.Now())
+}
+
+// GetLinkedOrders view the batch of orders of a signed confirmation link. No
+// login is required; the link's signature authorizes the request.
+func GetLinkedOrders(c *fiber.Ctx) error {
+	b, err := tokenBatch(c)
+	if err != nil {
+		log.Warnf("GetLinkedOrders failed: %s", err)
+		return fiber.ErrNotFound
+	}
+	return c.JSON(b)
+}
+
+// ConfirmLinkedOrders confirm the batch of orders of a signed confirmation
+// link
+func ConfirmLinkedOrders(c *fiber.Ctx) error {
+	b, err := tokenBatch(c)
+	if err != nil {
+		log.Warnf("ConfirmLinkedOrders failed: %s", err)
+		return fiber.ErrNotFound
+	}
+	return confirmOrders(c, b)
+}
+
+// CancelLinkedOrders cancel the batch of orders of a signed confirmation link
+func CancelLinkedOrders(c *fiber.Ctx) error {
+	b, err := tokenBatch(c)
+	if err != nil {
+		log.Warnf("CancelLinkedOrders failed: %s", err)
+		return fiber.ErrNotFound
+	}
+	return cancelOrders(c, b)
+}
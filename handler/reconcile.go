@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"main/data"
+	"main/portfolio"
+	"main/repository"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// BrokerStatement positions held in a brokerage account on a given date
+type BrokerStatement struct {
+	AsOf      int64                `json:"asOf"`
+	Positions []portfolio.Position `json:"positions"`
+	Cash      float64              `json:"cash"`
+}
+
+// parseBrokerStatementCSV parse a CSV broker statement with the columns
+// ticker,shares. Cash is reported with the ticker CASH or $CASH.
+func parseBrokerStatementCSV(body []byte) ([]portfolio.Position, error) {
+	r := csv.NewReader(bytes.NewReader(body))
+	r.TrimLeadingSpace = true
+
+	positions := []portfolio.Position{}
+	first := true
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if len(record) < 2 {
+			return nil, errors.New("broker statement rows must have a ticker and shares column")
+		}
+
+		shares, err := strconv.ParseFloat(strings.ReplaceAll(record[1], ",", ""), 64)
+		if err != nil {
+			// skip the header row
+			if first {
+				first = false
+				continue
+			}
+			return nil, err
+		}
+		first = false
+
+		ticker := strings.ToUpper(strings.TrimSpace(record[0]))
+		if ticker == "CASH" {
+			ticker = "$CASH"
+		}
+
+		positions = append(positions, portfolio.Position{
+			Ticker: ticker,
+			Shares: shares,
+		})
+	}
+
+	return positions, nil
+}
+
+// loadSplitAdjustedTransactions the executed transactions of a portfolio
+// with the splits since each trade applied
+func loadSplitAdjustedTransactions(ctx context.Context, portfolioID string, userID string, manager *data.Manager) ([]portfolio.Transaction, error) {
+	executedTrxs, err := repository.Transactions.List(ctx, portfolioID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	trxs := make([]portfolio.Transaction, len(executedTrxs))
+	for ii := range executedTrxs {
+		trxs[ii] = executedTrxs[ii].Transaction()
+	}
+
+	// executed transactions are recorded in actual shares so splits must be
+	// applied before comparing to the broker
+	if len(trxs) > 0 {
+		executed, err := portfolio.NewPortfolioFromTransactions(portfolioID, manager, trxs)
+		if err != nil {
+			return nil, err
+		}
+		if err := executed.ApplySplits(); err != nil {
+			return nil, err
+		}
+		trxs = executed.Transactions
+	}
+
+	return trxs, nil
+}
+
+func reconcilePortfolio(ctx context.Context, portfolioID string, userID string, statement *BrokerStatement, manager *data.Manager) (portfolio.ReconciliationReport, error) {
+	trxs, err := loadSplitAdjustedTransactions(ctx, portfolioID, userID, manager)
+	if err != nil {
+		return portfolio.ReconciliationReport{}, err
+	}
+
+	return portfolio.Reconcile(trxs, statement.Positions, time.Unix(statement.AsOf, 0).UTC()), nil
+}
+
+// ImportBrokerStatement import positions from a broker statement and
+// reconcile them against the executed transactions of the portfolio.
+// Accepts either JSON or CSV (Content-Type: text/csv) bodies
+func ImportBrokerStatement(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	if _, err := loadPortfolio(c.Context(), portfolioID, userID); err != nil {
+		log.Warnf("ImportBrokerStatement portfolio %s not found: %s", portfolioID, err)
+		return fiber.ErrNotFound
+	}
+
+	statement := BrokerStatement{}
+	if strings.HasPrefix(c.Get(fiber.HeaderContentType), "text/csv") {
+		positions, err := parseBrokerStatementCSV(c.Body())
+		if err != nil {
+			log.Warnf("ImportBrokerStatement bad request: %s", err)
+			return fiber.ErrBadRequest
+		}
+		statement.Positions = positions
+
+		asOf, err := time.Parse("2006-01-02", c.Query("asOf", time.Now().Format("2006-01-02")))
+		if err != nil {
+			return fiber.ErrBadRequest
+		}
+		statement.AsOf = asOf.Unix()
+	} else {
+		if err := json.Unmarshal(c.Body(), &statement); err != nil {
+			log.Warnf("ImportBrokerStatement bad request: %s", err)
+			return fiber.ErrBadRequest
+		}
+		if statement.Cash != 0 {
+			statement.Positions = append(statement.Positions, portfolio.Position{
+				Ticker: "$CASH",
+				Shares: statement.Cash,
+			})
+		}
+	}
+
+	for ii := range statement.Positions {
+		statement.Positions[ii].Ticker = strings.ToUpper(statement.Positions[ii].Ticker)
+	}
+
+	err := repository.Portfolios.SaveStatement(c.Context(), portfolioID, userID, time.Unix(statement.AsOf, 0), statement.Positions)
+	if err != nil {
+		log.Warnf("ImportBrokerStatement failed to save statement for portfolio %s: %s", portfolioID, err)
+		return fiber.ErrInternalServerError
+	}
+
+	manager := newDataManager(c)
+	report, err := reconcilePortfolio(c.Context(), portfolioID, userID, &statement, &manager)
+	if err != nil {
+		log.Warnf("ImportBrokerStatement reconciliation failed for portfolio %s: %s", portfolioID, err)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(report)
+}
+
+// ReconcilePortfolio reconcile the executed transactions against the most
+// recently imported broker statement
+func ReconcilePortfolio(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	statement := BrokerStatement{}
+	var err error
+	statement.AsOf, statement.Positions, err = repository.Portfolios.LatestStatement(c.Context(), portfolioID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		log.Warnf("ReconcilePortfolio no broker statement for portfolio %s", portfolioID)
+		return fiber.ErrNotFound
+	}
+	if err != nil {
+		log.Warnf("ReconcilePortfolio invalid broker statement for portfolio %s: %s", portfolioID, err)
+		return fiber.ErrInternalServerError
+	}
+
+	manager := newDataManager(c)
+	report, err := reconcilePortfolio(c.Context(), portfolioID, userID, &statement, &manager)
+	if err != nil {
+		log.Warnf("ReconcilePortfolio reconciliation failed for portfolio %s: %s", portfolioID, err)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(report)
+}
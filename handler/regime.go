@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"main/data"
+	"main/portfolio"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	regimeMarketProxy     = "SPY"
+	regimeVolatilityProxy = "VIXCLS"
+	regimeRateProxy       = "DGS10"
+)
+
+// AnalyzeRegimes split the returns of a saved portfolio by market regime:
+// bull or bear market (SPY above or below its 200-day moving average), high
+// or low volatility (VIX), and rising or falling 10-year treasury yields.
+// Regimes whose data cannot be loaded are omitted.
+func AnalyzeRegimes(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	resolution, err := parseResolution(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	p, err := loadPortfolio(c.Context(), portfolioID, userID)
+	if err != nil {
+		log.Warnf("AnalyzeRegimes %s failed: %s", portfolioID, err)
+		return fiber.ErrNotFound
+	}
+
+	manager := newDataManager(c)
+	perf, err := computeSavedPerformance(&p, &manager, resolution)
+	if err != nil {
+		log.Warnf("AnalyzeRegimes cannot calculate performance for portfolio %s: %s", portfolioID, err)
+		return fiber.ErrBadRequest
+	}
+
+	// daily indicators starting early enough to classify the first period
+	indicatorManager := newDataManager(c)
+	indicatorManager.Begin = time.Unix(perf.PeriodStart, 0).AddDate(-1, 0, 0)
+	indicatorManager.End = time.Unix(perf.PeriodEnd, 0)
+	indicatorManager.Frequency = data.FrequencyDaily
+	market, err := indicatorManager.GetData(regimeMarketProxy)
+	if err != nil {
+		log.Warnf("AnalyzeRegimes cannot load %s: %s", regimeMarketProxy, err)
+	}
+	vix, err := indicatorManager.GetData("$RATE." + regimeVolatilityProxy)
+	if err != nil {
+		log.Warnf("AnalyzeRegimes cannot load %s: %s", regimeVolatilityProxy, err)
+	}
+	rates, err := indicatorManager.GetData("$RATE." + regimeRateProxy)
+	if err != nil {
+		log.Warnf("AnalyzeRegimes cannot load %s: %s", regimeRateProxy, err)
+	}
+	indicators := portfolio.NewRegimeIndicators(perf, market, regimeMarketProxy, vix, regimeVolatilityProxy, rates, regimeRateProxy)
+
+	return c.JSON(fiber.Map{
+		"portfolio": p.ID,
+		"regimes":   perf.RegimeAnalysis(indicators),
+	})
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"main/repository"
+	"main/strategies"
+
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// ListRegistrations list the marketplace registration of every strategy,
+// including the defaults of strategies without a stored registration
+func ListRegistrations(c *fiber.Ctx) error {
+	regs := make([]strategies.Registration, 0, len(strategies.StrategyList))
+	for _, info := range strategies.StrategyList {
+		regs = append(regs, strategies.Registered(info.Shortcode))
+	}
+	return c.JSON(regs)
+}
+
+// UpdateRegistration set the marketplace registration of a strategy. Fields
+// left out of the body keep their current value. Other processes pick up the
+// change within strategies.RegistrationInterval.
+func UpdateRegistration(c *fiber.Ctx) error {
+	shortcode := c.Params("id")
+	if _, ok := strategies.StrategyMap[shortcode]; !ok {
+		return fiber.ErrNotFound
+	}
+
+	// unmarshal on top of the current registration so unspecified fields are kept
+	reg := strategies.Registered(shortcode)
+	if err := json.Unmarshal(c.Body(), &reg); err != nil {
+		log.Warnf("UpdateRegistration bad request: %s, for strategy: %s", err, shortcode)
+		return fiber.ErrBadRequest
+	}
+	reg.Shortcode = shortcode
+
+	if err := reg.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	if err := repository.Strategies.SaveRegistration(c.Context(), &reg); err != nil {
+		log.Warnf("UpdateRegistration failed: %s, for strategy: %s", err, shortcode)
+		return fiber.ErrInternalServerError
+	}
+	reloadRegistrations(c)
+
+	return c.JSON(strategies.Registered(shortcode))
+}
+
+// DeleteRegistration remove the stored registration of a strategy so it is
+// enabled for everyone
+func DeleteRegistration(c *fiber.Ctx) error {
+	shortcode := c.Params("id")
+
+	err := repository.Strategies.DeleteRegistration(c.Context(), shortcode)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fiber.ErrNotFound
+	}
+	if err != nil {
+		log.Warnf("DeleteRegistration failed: %s, for strategy: %s", err, shortcode)
+		return fiber.ErrInternalServerError
+	}
+	reloadRegistrations(c)
+
+	return c.JSON(fiber.Map{"status": "success"})
+}
+
+// reloadRegistrations apply a change to the registrations in this process
+// immediately rather than at the next periodic reload
+func reloadRegistrations(c *fiber.Ctx) {
+	if err := strategies.LoadRegistrations(c.Context(), repository.Strategies); err != nil {
+		log.Warnf("Could not reload strategy registrations: %s", err)
+	}
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"main/repository"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// ListRevisions changes to the stored history of a saved portfolio found
+// when it was recomputed, e.g. after dividends or splits were restated
+// @Description Revisions of a portfolio's history with the old and new values of each changed transaction, newest first
+// @Id ListRevisions
+// @Produce json
+// @Param id path string true "id of portfolio"
+func ListRevisions(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	p, err := loadPortfolio(c.Context(), portfolioID, userID)
+	if err != nil {
+		log.Warnf("ListRevisions %s failed: %s", portfolioID, err)
+		return fiber.ErrNotFound
+	}
+
+	revisions, err := repository.Measurements.ListRevisions(c.Context(), p.ID)
+	if err != nil {
+		log.Warnf("ListRevisions %s failed: %s", portfolioID, err)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(revisions)
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"main/portfolio"
+	"strconv"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultRollingMonths trailing window of the rolling alpha and beta
+const defaultRollingMonths = 36
+
+// rollingAlphaBeta rolling market sensitivity of a portfolio for charting
+type rollingAlphaBeta struct {
+	BenchmarkID   string                   `json:"benchmarkId"`
+	BenchmarkName string                   `json:"benchmarkName"`
+	WindowMonths  int                      `json:"windowMonths"`
+	Series        []portfolio.RollingPoint `json:"series"`
+}
+
+// GetRollingAlphaBeta alpha and beta of a saved portfolio against its
+// benchmark over a trailing window of months (36 unless the window query
+// parameter is given) at each measurement. The benchmark is the first one
+// requested with the benchmarks query parameter or attached to the
+// portfolio, otherwise the user's default benchmark.
+func GetRollingAlphaBeta(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	months := defaultRollingMonths
+	if window := c.Query("window"); window != "" {
+		var err error
+		months, err = strconv.Atoi(window)
+		if err != nil || months < 2 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "window must be a number of months of at least 2"})
+		}
+	}
+
+	resolution, err := parseResolution(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	p, err := loadPortfolio(c.Context(), portfolioID, userID)
+	if err != nil {
+		log.Warnf("GetRollingAlphaBeta %s failed: %s", portfolioID, err)
+		return fiber.ErrNotFound
+	}
+
+	ids := benchmarkIDs(c, attachedBenchmarkIDs(c.Context(), portfolioID))
+	if len(ids) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "no benchmark requested"})
+	}
+	benchmarks, err := loadBenchmarks(c.Context(), ids[:1], userID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+	bench := benchmarks[0]
+
+	manager := newDataManager(c)
+	perf, err := computeSavedPerformance(&p, &manager, resolution)
+	if err != nil {
+		log.Warnf("GetRollingAlphaBeta cannot calculate performance for portfolio %s: %s", portfolioID, err)
+		return fiber.ErrBadRequest
+	}
+
+	benchManager := newDataManager(c)
+	benchPerf, err := bench.PerformanceFor(perf, &benchManager)
+	if err != nil {
+		log.Warnf("GetRollingAlphaBeta cannot compute benchmark %s: %s", bench.ID, err)
+		return fiber.ErrBadRequest
+	}
+
+	return c.JSON(rollingAlphaBeta{
+		BenchmarkID:   bench.ID,
+		BenchmarkName: bench.Name,
+		WindowMonths:  months,
+		Series:        perf.RollingAlphaBeta(benchPerf, months),
+	})
+}
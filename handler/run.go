@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"main/repository"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultRunLimit number of run reports listed when limit is not given
+const defaultRunLimit = 30
+
+// GetSystemStats counts of users, portfolios, and jobs with the report of the
+// last nightly run
+func GetSystemStats(c *fiber.Ctx) error {
+	stats, err := repository.Runs.Stats(c.Context())
+	if err != nil {
+		log.Warnf("GetSystemStats failed: %s", err)
+		return fiber.ErrInternalServerError
+	}
+	return c.JSON(stats)
+}
+
+// ListRuns list the reports of the most recent nightly runs, newest first
+// @Param limit query int false "number of runs, defaults to 30"
+func ListRuns(c *fiber.Ctx) error {
+	limit := defaultRunLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > 365 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "limit must be between 1 and 365"})
+		}
+	}
+
+	runs, err := repository.Runs.ListRuns(c.Context(), limit)
+	if err != nil {
+		log.Warnf("ListRuns failed: %s", err)
+		return fiber.ErrInternalServerError
+	}
+	return c.JSON(runs)
+}
+
+// GetRun get the report of a nightly run, including why portfolios failed
+func GetRun(c *fiber.Ctx) error {
+	runID := c.Params("id")
+	if _, err := uuid.Parse(runID); err != nil {
+		return fiber.ErrNotFound
+	}
+
+	report, err := repository.Runs.GetRun(c.Context(), runID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fiber.ErrNotFound
+	}
+	if err != nil {
+		log.Warnf("GetRun %s failed: %s", runID, err)
+		return fiber.ErrInternalServerError
+	}
+	return c.JSON(report)
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"main/billing"
+	"main/data"
+	"main/portfolio"
+	"main/strategies"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxScreenTickers most tickers in a screened universe
+const maxScreenTickers = 50
+
+// screenRequest the universe and period to screen strategies over. Without
+// a universe every strategy runs with its default tickers.
+type screenRequest struct {
+	Universe  []string `json:"universe"`
+	StartDate string   `json:"startDate"`
+	EndDate   string   `json:"endDate"`
+	RankBy    string   `json:"rankBy"`
+}
+
+// screenSkip a strategy left out of the screening and why
+type screenSkip struct {
+	Strategy string `json:"strategy"`
+	Name     string `json:"name"`
+	Reason   string `json:"reason"`
+}
+
+// ScreenStrategies run every strategy offered to the user with its default
+// arguments over the same universe and period and rank them, answering
+// which strategy would have done best for the universe. Strategies that
+// cannot use the universe or fail to run are listed with the reason.
+// @Description Rank all strategies over a universe of tickers
+// @Id ScreenStrategies
+// @Produce json
+// @Param resolution query string false "daily, weekly, or monthly (default)"
+func ScreenStrategies(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+	credentials := map[string]string{
+		"tiingo": claims["https://pennyvault.com/tiingo_token"].(string),
+	}
+
+	params := screenRequest{}
+	if err := json.Unmarshal(c.Body(), &params); err != nil {
+		log.Warnf("ScreenStrategies bad request: %s", err)
+		return fiber.ErrBadRequest
+	}
+	if len(params.Universe) > maxScreenTickers {
+		msg := fmt.Sprintf("at most %d tickers may be screened", maxScreenTickers)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": msg})
+	}
+	for ii, ticker := range params.Universe {
+		params.Universe[ii] = strings.ToUpper(strings.TrimSpace(ticker))
+	}
+	if params.RankBy == "" {
+		params.RankBy = portfolio.RankCagr
+	}
+	// reject unknown metrics before computing anything
+	if _, err := (&portfolio.Comparison{}).Rank(params.RankBy); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	resolution, err := parseResolution(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	limits, _, err := userLimits(c.Context(), userID)
+	if err != nil {
+		return planError(c, err)
+	}
+
+	year, month, day := time.Now().Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	runs := []*batchRun{}
+	skipped := []screenSkip{}
+	for ii := range strategies.StrategyList {
+		info := &strategies.StrategyList[ii]
+		reg := strategies.Registered(info.Shortcode)
+		if !reg.Available(userID) || reg.Deprecated {
+			continue
+		}
+
+		args, err := info.ScreeningArguments(params.Universe)
+		if err != nil {
+			skipped = append(skipped, screenSkip{Strategy: info.Shortcode, Name: info.Name, Reason: err.Error()})
+			continue
+		}
+		run := &batchRun{
+			Strategy:  info.Shortcode,
+			Arguments: args,
+			StartDate: params.StartDate,
+			EndDate:   params.EndDate,
+		}
+		// every run has the same period, so an invalid period or one beyond
+		// the plan fails the screening
+		if err := run.prepare(c, &limits, today); err != nil {
+			var limitErr *billing.LimitError
+			if errors.As(err, &limitErr) {
+				return planError(c, err)
+			}
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		}
+		runs = append(runs, run)
+	}
+	if len(runs) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "no strategy can screen the universe", "skipped": skipped})
+	}
+
+	cache := data.NewPriceCache(runs[0].startDate.AddDate(-batchLookbackYears, 0, 0), runs[0].endDate)
+	start := time.Now()
+	outcomes := computeBatch(runs, batchConcurrency(c, len(runs)), credentials, cache, resolution, userID)
+
+	members := make([]portfolio.HouseholdMember, 0, len(runs))
+	for ii, outcome := range outcomes {
+		info := runs[ii].strat
+		if outcome.err != nil {
+			skipped = append(skipped, screenSkip{Strategy: info.Shortcode, Name: info.Name, Reason: outcome.err.Error()})
+			continue
+		}
+		members = append(members, portfolio.HouseholdMember{
+			ID:          info.Shortcode,
+			Name:        info.Name,
+			Performance: outcome.performance,
+		})
+	}
+
+	// strategies are compared over the period all of them were invested
+	comparison := portfolio.Compare(members)
+	ranked, _ := comparison.Rank(params.RankBy)
+
+	log.WithFields(log.Fields{
+		"Strategies": len(runs),
+		"Skipped":    len(skipped),
+		"Duration":   time.Since(start).Round(time.Millisecond),
+		"Cached":     cache.Len(),
+	}).Info("Strategies screened")
+
+	periodStart, periodEnd := int64(0), int64(0)
+	if len(comparison.Times) > 0 {
+		periodStart = comparison.Times[0]
+		periodEnd = comparison.Times[len(comparison.Times)-1]
+	}
+	return c.JSON(fiber.Map{
+		"universe":    params.Universe,
+		"rankBy":      params.RankBy,
+		"periodStart": periodStart,
+		"periodEnd":   periodEnd,
+		"strategies":  ranked,
+		"skipped":     skipped,
+	})
+}
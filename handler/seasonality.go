@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// GetSeasonality average return and hit rate of a saved portfolio by
+// calendar month and, when the resolution query parameter is daily, by day
+// of the week
+func GetSeasonality(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	resolution, err := parseResolution(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	p, err := loadPortfolio(c.Context(), portfolioID, userID)
+	if err != nil {
+		log.Warnf("GetSeasonality %s failed: %s", portfolioID, err)
+		return fiber.ErrNotFound
+	}
+
+	manager := newDataManager(c)
+	perf, err := computeSavedPerformance(&p, &manager, resolution)
+	if err != nil {
+		log.Warnf("GetSeasonality cannot calculate performance for portfolio %s: %s", portfolioID, err)
+		return fiber.ErrBadRequest
+	}
+
+	return c.JSON(perf.Seasonality())
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"main/notification"
+	"main/repository"
+	"os"
+
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// SendGridEvents record the delivery events SendGrid posts to its event
+// webhook against the notification history. Addresses that hard bounce or
+// report a message as spam are added to the email suppression list. Requests
+// must be signed with the key in SENDGRID_WEBHOOK_PUBLIC_KEY.
+func SendGridEvents(c *fiber.Ctx) error {
+	err := notification.VerifyWebhook(os.Getenv("SENDGRID_WEBHOOK_PUBLIC_KEY"),
+		c.Get("X-Twilio-Email-Event-Webhook-Signature"), c.Get("X-Twilio-Email-Event-Webhook-Timestamp"), c.Body())
+	if err != nil {
+		log.Warnf("SendGridEvents rejected request: %s", err)
+		return fiber.ErrUnauthorized
+	}
+
+	events, err := notification.ParseEvents(c.Body())
+	if err != nil {
+		log.Warnf("SendGridEvents bad request: %s", err)
+		return fiber.ErrBadRequest
+	}
+
+	for ii := range events {
+		event := &events[ii]
+		messageID := event.SendMessageID()
+
+		// SendGrid retries deliveries so events may be received more than once
+		if err := repository.Notifications.RecordEvent(c.Context(), event); err != nil {
+			log.Warnf("SendGridEvents insert failed: %s for message: %s", err, messageID)
+			return fiber.ErrInternalServerError
+		}
+
+		if event.Suppress() {
+			err := repository.Users.SuppressEmail(c.Context(), event.Email, event.Event, event.Reason, event.Time())
+			if err != nil {
+				log.Warnf("SendGridEvents suppression failed: %s for message: %s", err, messageID)
+				return fiber.ErrInternalServerError
+			}
+			log.Infof("Suppressed email to %s after %s", event.Email, event.Event)
+		}
+	}
+
+	return c.JSON(fiber.Map{"status": "success"})
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"errors"
+	"main/notification"
+	"main/portfolio"
+	"main/repository"
+	"main/strategies"
+	"main/util"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// ListSignals history of the allocations the strategy of a saved portfolio
+// selected and the scores that justified them
+// @Description Archived strategy signals of a portfolio, oldest first
+// @Id ListSignals
+// @Produce json
+// @Param id path string true "id of portfolio"
+// @Param startDate query string false "first date, defaults to the start of the archive"
+// @Param endDate query string false "last date, defaults to today"
+func ListSignals(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	begin := time.Time{}
+	if startDate := c.Query("startDate"); startDate != "" {
+		var err error
+		if begin, err = time.Parse("2006-01-02", startDate); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "startDate must be formatted YYYY-MM-DD"})
+		}
+	}
+	end := time.Now()
+	if endDate := c.Query("endDate"); endDate != "" {
+		var err error
+		if end, err = time.Parse("2006-01-02", endDate); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "endDate must be formatted YYYY-MM-DD"})
+		}
+	}
+
+	p, err := loadPortfolio(c.Context(), portfolioID, userID)
+	if err != nil {
+		log.Warnf("ListSignals %s failed: %s", portfolioID, err)
+		return fiber.ErrNotFound
+	}
+
+	signals, err := repository.Measurements.ListSignals(c.Context(), p.ID, begin, end)
+	if err != nil {
+		log.Warnf("ListSignals %s failed: %s", portfolioID, err)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(signals)
+}
+
+// signalPreview allocation a strategy would select if the current period
+// ended now
+type signalPreview struct {
+	Provisional bool             `json:"provisional"`
+	AsOf        time.Time        `json:"asOf"`
+	Signal      portfolio.Signal `json:"signal"`
+
+	// FlipDistances score distance from the chosen asset to each alternative;
+	// only reported by strategies that can explain their selection
+	FlipDistances map[string]float64 `json:"flipDistances,omitempty"`
+}
+
+// NextSignal preview of the allocation the strategy of a saved portfolio
+// would select if the current month ended now, computed with a provisional
+// bar for the current month that uses intraday quotes where available
+// @Description Provisional signal for the upcoming rebalance and the score distance to each alternative
+// @Id NextSignal
+// @Produce json
+// @Param id path string true "id of portfolio"
+func NextSignal(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	p, err := loadPortfolio(c.Context(), portfolioID, userID)
+	if err != nil {
+		log.Warnf("NextSignal %s failed: %s", portfolioID, err)
+		return fiber.ErrNotFound
+	}
+
+	strat, params, err := savedStrategyArguments(&p)
+	if err != nil {
+		log.Warnf("NextSignal cannot load arguments of portfolio %s: %s", portfolioID, err)
+		return fiber.ErrBadRequest
+	}
+
+	stratObject, err := strat.Factory(params)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	tz, err := time.LoadLocation(notification.MarketTimezone)
+	if err != nil {
+		log.Warnf("NextSignal cannot load market timezone: %s", err)
+		return fiber.ErrInternalServerError
+	}
+	now := time.Now().In(tz)
+	year, month, day := now.Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	// a year of history covers the longest lookback period
+	manager := newDataManager(c)
+	manager.Begin = today.AddDate(-1, 0, 0)
+	manager.End = today
+	manager.Intraday = true
+
+	preview := signalPreview{
+		Provisional: true,
+		AsOf:        now,
+	}
+	err = util.Recover("next-signal", func() error {
+		computed, err := stratObject.Compute(&manager)
+		if err != nil {
+			return err
+		}
+		if len(computed.Signals) == 0 {
+			return strategies.ErrNoExplanation
+		}
+		preview.Signal = computed.Signals[len(computed.Signals)-1]
+
+		if explainer, ok := stratObject.(strategies.Explainer); ok {
+			explanation, err := explainer.Explain(preview.Signal.Date)
+			if err != nil {
+				return err
+			}
+			preview.FlipDistances = explanation.FlipDistances()
+		}
+		return nil
+	})
+	if errors.Is(err, strategies.ErrNoExplanation) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+	if err != nil {
+		log.Warnf("NextSignal %s failed: %s", portfolioID, err)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(preview)
+}
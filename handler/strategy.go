@@ -2,9 +2,17 @@ package handler
 
 import (
 	"encoding/json"
+	"fmt"
 	"main/data"
+	"main/logging"
+	"main/portfolio"
+	"main/reporting"
 	"main/strategies"
+	"main/util"
+	"os"
 	"runtime/debug"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
@@ -12,20 +20,183 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-// ListStrategies get a list of all strategies
+// strategyListing a strategy and its marketplace status
+type strategyListing struct {
+	strategies.StrategyInfo
+	Deprecated      bool   `json:"deprecated"`
+	DeprecationNote string `json:"deprecationNote,omitempty"`
+	Replacement     string `json:"replacement,omitempty"`
+}
+
+func newStrategyListing(info strategies.StrategyInfo, reg strategies.Registration) strategyListing {
+	return strategyListing{
+		StrategyInfo:    info,
+		Deprecated:      reg.Deprecated,
+		DeprecationNote: reg.DeprecationNote,
+		Replacement:     reg.Replacement,
+	}
+}
+
+// StrategyListExpiration cache the strategy list until registrations changed
+// by administrators are reloaded
+func StrategyListExpiration(now time.Time) time.Time {
+	return now.Add(strategies.RegistrationInterval)
+}
+
+// ListStrategies get a list of the strategies available to the user;
+// deprecated strategies are listed so portfolios using them can be displayed
 func ListStrategies(c *fiber.Ctx) error {
-	return c.JSON(strategies.StrategyList)
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	listing := make([]strategyListing, 0, len(strategies.StrategyList))
+	for _, info := range strategies.StrategyList {
+		if reg := strategies.Registered(info.Shortcode); reg.Available(userID) {
+			listing = append(listing, newStrategyListing(info, reg))
+		}
+	}
+	return c.JSON(listing)
 }
 
 // GetStrategy get configuration for a specific strategy
 func GetStrategy(c *fiber.Ctx) error {
 	shortcode := c.Params("id")
-	if strategy, ok := strategies.StrategyMap[shortcode]; ok {
-		return c.JSON(strategy)
+	if strategy, ok := availableStrategy(c, shortcode); ok {
+		return c.JSON(newStrategyListing(strategy, strategies.Registered(shortcode)))
 	}
 	return fiber.ErrNotFound
 }
 
+// availableStrategy the strategy with shortcode if it is enabled and the
+// logged in user is in its rollout
+func availableStrategy(c *fiber.Ctx, shortcode string) (strategies.StrategyInfo, bool) {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	strat, ok := strategies.StrategyMap[shortcode]
+	if !ok {
+		return strat, false
+	}
+	reg := strategies.Registered(shortcode)
+	return strat, reg.Available(userID)
+}
+
+// savedStrategyArguments the strategy of a saved portfolio and its arguments
+// migrated to the current version of the strategy
+func savedStrategyArguments(p *PortfolioResponse) (*strategies.StrategyInfo, map[string]json.RawMessage, error) {
+	strat, ok := strategies.StrategyMap[p.Strategy]
+	if !ok {
+		return nil, nil, fmt.Errorf("strategy '%s' not found", p.Strategy)
+	}
+
+	params := map[string]json.RawMessage{}
+	if err := json.Unmarshal(p.Arguments, &params); err != nil {
+		return nil, nil, err
+	}
+
+	// arguments saved for an earlier version of the strategy are migrated in
+	// memory; the notifier persists the migration
+	params, _, err := strat.MigrateArguments(p.StrategyVersion, params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &strat, params, nil
+}
+
+// computeSavedPortfolio run the strategy of a saved portfolio using the date
+// range configured on manager
+func computeSavedPortfolio(p *PortfolioResponse, manager *data.Manager) (*portfolio.Portfolio, error) {
+	strat, params, err := savedStrategyArguments(p)
+	if err != nil {
+		return nil, err
+	}
+
+	stratObject, err := strat.Factory(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return stratObject.Compute(manager)
+}
+
+// newDataManager create a data manager using the tiingo token of the
+// logged in user
+func newDataManager(c *fiber.Ctx) data.Manager {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	tiingoToken := claims["https://pennyvault.com/tiingo_token"].(string)
+
+	return data.NewManager(map[string]string{
+		"tiingo": tiingoToken,
+	})
+}
+
+// parseResolution read the measurement resolution from the resolution query
+// parameter; daily, weekly, or monthly (the default)
+func parseResolution(c *fiber.Ctx) (string, error) {
+	switch strings.ToLower(c.Query("resolution", "monthly")) {
+	case "daily":
+		return data.FrequencyDaily, nil
+	case "weekly":
+		return data.FrequencyWeekly, nil
+	case "monthly":
+		return data.FrequencyMonthly, nil
+	}
+	return "", fmt.Errorf("invalid resolution '%s'", c.Query("resolution"))
+}
+
+const (
+	// encodingRows measurements encoded as an object per measurement
+	encodingRows = "rows"
+
+	// encodingColumnar measurements encoded as an array per field
+	encodingColumnar = "columnar"
+)
+
+// parseEncoding read how measurements are encoded from the encoding query
+// parameter; rows (the default) or columnar
+func parseEncoding(c *fiber.Ctx) (string, error) {
+	switch encoding := strings.ToLower(c.Query("encoding", encodingRows)); encoding {
+	case encodingRows, encodingColumnar:
+		return encoding, nil
+	}
+	return "", fmt.Errorf("invalid encoding '%s'", c.Query("encoding"))
+}
+
+// sendPerformance respond with perf, encoding its measurements as requested
+func sendPerformance(c *fiber.Ctx, perf *portfolio.Performance, encoding string) error {
+	if encoding == encodingColumnar {
+		return c.JSON(perf.Columnar())
+	}
+	return c.JSON(perf)
+}
+
+// checkStrategyConstraints verify the strategy is offered to the user, the
+// arguments are valid for it, and they satisfy its constraints for a run
+// starting at begin; begin may be zero to skip the history check
+func checkStrategyConstraints(c *fiber.Ctx, shortcode string, args map[string]json.RawMessage, begin time.Time) error {
+	strat, ok := availableStrategy(c, shortcode)
+	if !ok {
+		return fmt.Errorf("strategy '%s' not found", shortcode)
+	}
+	if reg := strategies.Registered(shortcode); reg.Deprecated {
+		if reg.Replacement != "" {
+			return fmt.Errorf("strategy '%s' is deprecated, use '%s' instead", shortcode, reg.Replacement)
+		}
+		return fmt.Errorf("strategy '%s' is deprecated", shortcode)
+	}
+
+	if _, err := strat.Factory(args); err != nil {
+		return err
+	}
+
+	manager := newDataManager(c)
+	return strat.CheckConstraints(args, begin, &manager)
+}
+
 // RunStrategy execute strategy
 func RunStrategy(c *fiber.Ctx) (resp error) {
 	shortcode := c.Params("id")
@@ -38,11 +209,11 @@ func RunStrategy(c *fiber.Ctx) (resp error) {
 	startDate, err := time.Parse("2006-01-02", startDateStr)
 	if err != nil {
 		log.WithFields(log.Fields{
-			"Function":     "handler/strategy.go:RunStrategy",
-			"Strategy":     shortcode,
-			"StartDateStr": startDateStr,
-			"EndDateStr":   endDateStr,
-			"Error":        err,
+			logging.FieldFunction: "handler/strategy.go:RunStrategy",
+			logging.FieldStrategy: shortcode,
+			"StartDateStr":        startDateStr,
+			"EndDateStr":          endDateStr,
+			logging.FieldError:    err,
 		}).Error("Cannoy parse start date query parameter")
 		return fiber.ErrNotAcceptable
 	}
@@ -56,25 +227,42 @@ func RunStrategy(c *fiber.Ctx) (resp error) {
 		endDate, err = time.Parse("2006-01-02", endDateStr)
 		if err != nil {
 			log.WithFields(log.Fields{
-				"Function":     "handler/strategy.go:RunStrategy",
-				"Strategy":     shortcode,
-				"StartDateStr": startDateStr,
-				"EndDateStr":   endDateStr,
-				"Error":        err,
+				logging.FieldFunction: "handler/strategy.go:RunStrategy",
+				logging.FieldStrategy: shortcode,
+				"StartDateStr":        startDateStr,
+				"EndDateStr":          endDateStr,
+				logging.FieldError:    err,
 			}).Error("Cannoy parse end date query parameter")
 			return fiber.ErrNotAcceptable
 		}
 	}
 
+	if !endDate.After(startDate) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "endDate must be after startDate"})
+	}
+
+	// the free plan limits how far back strategies are tested
+	userID := c.Locals("user").(*jwt.Token).Claims.(jwt.MapClaims)["sub"].(string)
+	if startDate, err = backtestStart(c, userID, startDate, endDate); err != nil {
+		return planError(c, err)
+	}
+
 	defer func() {
 		if err := recover(); err != nil {
 			log.Error(err)
-			debug.PrintStack()
+			stack := debug.Stack()
+			os.Stderr.Write(stack)
+			user := c.Locals("user").(*jwt.Token)
+			claims := user.Claims.(jwt.MapClaims)
+			reporting.Report(&util.PanicError{Stage: "compute", Value: err, Stack: string(stack)}, reporting.Context{
+				UserID:   claims["sub"].(string),
+				Strategy: shortcode,
+			})
 			resp = fiber.ErrInternalServerError
 		}
 	}()
 
-	if strat, ok := strategies.StrategyMap[shortcode]; ok {
+	if strat, ok := availableStrategy(c, shortcode); ok {
 		credentials := make(map[string]string)
 
 		// get tiingo token from jwt claims
@@ -93,12 +281,45 @@ func RunStrategy(c *fiber.Ctx) (resp error) {
 			return fiber.ErrBadRequest
 		}
 
+		// optionally hold ETF equivalents of mutual funds, extending their
+		// history with that of the funds
+		if c.Query("etfProxies") == "true" {
+			if params, err = useETFProxies(&strat, params, &manager); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+			}
+		}
+
 		stratObject, err := strat.Factory(params)
 		if err != nil {
 			log.Println(err)
 			return fiber.ErrBadRequest
 		}
 
+		// history is only required when an explicit start date is given;
+		// by default the strategy runs from the earliest date data allows
+		historyFrom := time.Time{}
+		if c.Query("startDate") != "" {
+			historyFrom = startDate
+		}
+		if err := strat.CheckConstraints(params, historyFrom, &manager); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		}
+
+		resolution, err := parseResolution(c)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		}
+
+		encoding, err := parseEncoding(c)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		}
+
+		maxVolumeFraction, err := parseMaxVolumeFraction(c)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		}
+
 		start := time.Now()
 		p, err := stratObject.Compute(&manager)
 		if err != nil {
@@ -108,7 +329,20 @@ func RunStrategy(c *fiber.Ctx) (resp error) {
 		stop := time.Now()
 		stratComputeDur := stop.Sub(start).Round(time.Millisecond)
 
+		// optionally accrue interest on idle cash
+		if c.Query("cashInterest") == "true" {
+			p.CashInterest = true
+			if rateStr := c.Query("cashInterestRate"); rateStr != "" {
+				rate, err := strconv.ParseFloat(rateStr, 64)
+				if err != nil || rate < 0 {
+					return fiber.ErrBadRequest
+				}
+				p.CashInterestRate = rate
+			}
+		}
+
 		// calculate the portfolio's performance
+		p.Resolution = resolution
 		start = time.Now()
 		performance, err := p.CalculatePerformance(manager.End)
 		if err != nil {
@@ -118,18 +352,41 @@ func RunStrategy(c *fiber.Ctx) (resp error) {
 		stop = time.Now()
 		calcPerfDur := stop.Sub(start).Round(time.Millisecond)
 
+		// the strategy may start later than requested while it warms up
+		if c.Query("startDate") != "" {
+			performance.RequestedStart = startDate.Unix()
+		}
+
+		// optionally calculate after-tax performance for the given account type
+		if accountType := c.Query("accountType"); accountType != "" {
+			if err := performance.CalculateAfterTax(accountType, portfolio.DefaultTaxRates); err != nil {
+				log.Println(err)
+				return fiber.ErrBadRequest
+			}
+		}
+
 		start = time.Now()
 		performance.BuildMetricsBundle()
 		stop = time.Now()
 		metricCalcDur := stop.Sub(start).Round(time.Millisecond)
 
+		// compare to the requested benchmarks
+		if err := compareToBenchmarks(c, &performance, benchmarkIDs(c, nil)); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		}
+
+		// optionally warn about trades the market could not absorb
+		if c.Query("liquidityCheck") == "true" {
+			checkLiquidity(c, &performance, maxVolumeFraction)
+		}
+
 		log.WithFields(log.Fields{
 			"StratCalcDur":  stratComputeDur,
 			"PerfCalcDur":   calcPerfDur,
 			"MetricCalcDur": metricCalcDur,
 		}).Info("Strategy calculated")
 
-		return c.JSON(performance)
+		return sendPerformance(c, &performance, encoding)
 	}
 
 	return fiber.ErrNotFound
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"main/portfolio"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	stressEquityProxy = "VFINX"
+	stressRateProxy   = "DGS10"
+)
+
+// StressTestPortfolio replay the allocation rules of a saved portfolio
+// through historical crises and estimate its loss from hypothetical shocks.
+// The portfolio's strategy is computed over all available history, not just
+// since the portfolio was created.
+func StressTestPortfolio(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	p, err := loadPortfolio(c.Context(), portfolioID, userID)
+	if err != nil {
+		log.Warnf("StressTestPortfolio %s failed: %s", portfolioID, err)
+		return fiber.ErrNotFound
+	}
+
+	endDate := time.Now()
+	year, month, day := endDate.Date()
+	endDate = time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	manager := newDataManager(c)
+	manager.Begin = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+	manager.End = endDate
+	computed, err := computeSavedPortfolio(&p, &manager)
+	if err != nil {
+		log.Warnf("StressTestPortfolio cannot compute portfolio %s: %s", portfolioID, err)
+		return fiber.ErrBadRequest
+	}
+
+	perf, err := computed.CalculatePerformance(endDate)
+	if err != nil {
+		log.Warnf("StressTestPortfolio cannot calculate performance for portfolio %s: %s", portfolioID, err)
+		return fiber.ErrBadRequest
+	}
+
+	// market factors used to estimate sensitivity to hypothetical shocks
+	factorManager := newDataManager(c)
+	factorManager.Begin = time.Unix(perf.PeriodStart, 0)
+	factorManager.End = endDate
+	equity, err := factorManager.GetData(stressEquityProxy)
+	if err != nil {
+		log.Warnf("StressTestPortfolio cannot load %s: %s", stressEquityProxy, err)
+	}
+	rates, err := factorManager.GetData("$RATE." + stressRateProxy)
+	if err != nil {
+		log.Warnf("StressTestPortfolio cannot load %s: %s", stressRateProxy, err)
+	}
+	factors := portfolio.NewStressFactors(&perf, equity, stressEquityProxy, rates, stressRateProxy)
+
+	return c.JSON(fiber.Map{
+		"portfolio": p.ID,
+		"scenarios": perf.StressTest(factors),
+	})
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"main/portfolio"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// SubstitutionAnalysis re-run a saved portfolio substituting cheaper share
+// classes or ETF equivalents for its funds and report the difference in fee
+// drag over the backtest period
+// @Description Fee drag of a portfolio compared to the same portfolio holding cheaper equivalents of its funds
+// @Id SubstitutionAnalysis
+// @Produce json
+// @Param id path string true "id of portfolio"
+func SubstitutionAnalysis(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	p, err := loadPortfolio(c.Context(), portfolioID, userID)
+	if err != nil {
+		log.Warnf("SubstitutionAnalysis %s failed: %s", portfolioID, err)
+		return fiber.ErrNotFound
+	}
+
+	strat, params, err := savedStrategyArguments(&p)
+	if err != nil {
+		log.Warnf("SubstitutionAnalysis cannot load arguments of portfolio %s: %s", portfolioID, err)
+		return fiber.ErrBadRequest
+	}
+
+	substitutedParams, replaced, err := strat.SubstituteTickers(params, portfolio.ReplacementTickers())
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+	if len(replaced) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "no cheaper equivalent is known for the funds of the portfolio"})
+	}
+	substitutions := make([]portfolio.Substitution, 0, len(replaced))
+	for _, ticker := range replaced {
+		substitutions = append(substitutions, portfolio.Substitutions[ticker])
+	}
+
+	original, err := strat.Factory(params)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+	substitute, err := strat.Factory(substitutedParams)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	endDate := time.Now()
+	year, month, day := endDate.Date()
+	endDate = time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	// equivalents are often younger than the funds they replace; both
+	// portfolios are compared from the date the substituted portfolio starts
+	manager := newDataManager(c)
+	manager.Begin = time.Unix(p.StartDate, 0)
+	manager.End = endDate
+	substituted, err := substitute.Compute(&manager)
+	if err != nil {
+		log.Warnf("SubstitutionAnalysis cannot compute substituted portfolio %s: %s", portfolioID, err)
+		return fiber.ErrBadRequest
+	}
+
+	begin := time.Unix(p.StartDate, 0)
+	if substituted.StartTime.After(begin) {
+		begin = substituted.StartTime
+	}
+	manager.Begin = begin
+	manager.End = endDate
+	computed, err := original.Compute(&manager)
+	if err != nil {
+		log.Warnf("SubstitutionAnalysis cannot compute portfolio %s: %s", portfolioID, err)
+		return fiber.ErrBadRequest
+	}
+
+	ratios := portfolio.ExpenseRatios()
+	drags := make([]portfolio.ExpenseDrag, 0, 2)
+	for _, result := range []*portfolio.Portfolio{computed, substituted} {
+		perf, err := result.CalculatePerformance(endDate)
+		if err != nil {
+			log.Warnf("SubstitutionAnalysis cannot calculate performance for portfolio %s: %s", portfolioID, err)
+			return fiber.ErrBadRequest
+		}
+
+		allocations, err := result.AllocationHistory(result.StartTime, endDate, perf.Resolution)
+		if err != nil {
+			log.Warnf("SubstitutionAnalysis cannot calculate allocations for portfolio %s: %s", portfolioID, err)
+			return fiber.ErrBadRequest
+		}
+
+		drags = append(drags, portfolio.NewExpenseDrag(&perf, allocations, ratios))
+	}
+
+	return c.JSON(portfolio.NewSubstitutionReport(substitutions, drags[0], drags[1], begin, endDate))
+}
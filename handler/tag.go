@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"main/repository"
+	"main/tag"
+	"sort"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// Keys ListPortfolios sorts by
+const (
+	sortByName   = "name"
+	sortByCAGR   = "cagr"
+	sortByYTD    = "ytd"
+	sortBySignal = "signal"
+)
+
+// attachTags add the tags of each portfolio userID can view to portfolios
+func attachTags(ctx context.Context, userID string, portfolios []PortfolioResponse) error {
+	tags, err := repository.Portfolios.Tags(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for ii := range portfolios {
+		portfolios[ii].Tags = tags[portfolios[ii].ID]
+	}
+	return nil
+}
+
+// filterPortfolios the portfolios with every tag in the comma separated tag
+// query parameter that are filed in the folder query parameter or one of its
+// subfolders
+func filterPortfolios(c *fiber.Ctx, portfolios []PortfolioResponse) []PortfolioResponse {
+	var tags []string
+	if t := c.Query("tag"); t != "" {
+		tags = strings.Split(t, ",")
+	}
+	folder, _ := tag.NormalizeFolder(c.Query("folder"))
+	if len(tags) == 0 && folder == "" {
+		return portfolios
+	}
+
+	filtered := make([]PortfolioResponse, 0, len(portfolios))
+	for _, p := range portfolios {
+		if tag.Has(p.Tags, tags) && tag.InFolder(p.Folder, folder) {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered
+}
+
+// sortPortfolios order portfolios by key, one of name, cagr, ytd, or signal.
+// Names sort ascending and the others descending unless order is asc or desc.
+// Portfolios without a value, e.g. before they are first computed, are last.
+func sortPortfolios(portfolios []PortfolioResponse, key string, order string) error {
+	var value func(p *PortfolioResponse) sql.NullFloat64
+	switch key {
+	case "", sortByName:
+		desc := order == "desc"
+		sort.SliceStable(portfolios, func(i, j int) bool {
+			a, b := strings.ToLower(portfolios[i].Name), strings.ToLower(portfolios[j].Name)
+			if desc {
+				return a > b
+			}
+			return a < b
+		})
+		return nil
+	case sortByCAGR:
+		value = func(p *PortfolioResponse) sql.NullFloat64 { return p.CAGRSinceInception }
+	case sortByYTD:
+		value = func(p *PortfolioResponse) sql.NullFloat64 { return p.YTDReturn }
+	case sortBySignal:
+		value = func(p *PortfolioResponse) sql.NullFloat64 {
+			if p.LastSignalChange == nil {
+				return sql.NullFloat64{}
+			}
+			return sql.NullFloat64{Float64: float64(*p.LastSignalChange), Valid: true}
+		}
+	default:
+		return fmt.Errorf("sort must be one of %s, %s, %s, or %s", sortByName, sortByCAGR, sortByYTD, sortBySignal)
+	}
+
+	asc := order == "asc"
+	sort.SliceStable(portfolios, func(i, j int) bool {
+		a, b := value(&portfolios[i]), value(&portfolios[j])
+		if !a.Valid || !b.Valid {
+			return a.Valid
+		}
+		if asc {
+			return a.Float64 < b.Float64
+		}
+		return a.Float64 > b.Float64
+	})
+	return nil
+}
+
+// ListPortfolioTags list the tags and folders used by the portfolios of the
+// logged in user and those shared with them
+func ListPortfolioTags(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	portfolios, err := loadPortfolios(c.Context(), userID)
+	if err != nil {
+		log.Warnf("ListPortfolioTags failed: %s", err)
+		return fiber.ErrInternalServerError
+	}
+	shared, err := loadSharedPortfolios(c.Context(), userID)
+	if err != nil {
+		log.Warnf("ListPortfolioTags failed to list shared portfolios: %s", err)
+		return fiber.ErrInternalServerError
+	}
+	portfolios = append(portfolios, shared...)
+	if err := attachTags(c.Context(), userID, portfolios); err != nil {
+		log.Warnf("ListPortfolioTags failed to list tags: %s", err)
+		return fiber.ErrInternalServerError
+	}
+
+	tags := []string{}
+	folders := []string{}
+	seenTags := make(map[string]bool)
+	seenFolders := make(map[string]bool)
+	for _, p := range portfolios {
+		for _, t := range p.Tags {
+			if !seenTags[t] {
+				seenTags[t] = true
+				tags = append(tags, t)
+			}
+		}
+		if p.Folder != "" && !seenFolders[p.Folder] {
+			seenFolders[p.Folder] = true
+			folders = append(folders, p.Folder)
+		}
+	}
+	sort.Strings(tags)
+	sort.Strings(folders)
+
+	return c.JSON(fiber.Map{"tags": tags, "folders": folders})
+}
+
+// SetPortfolioTags file a portfolio in the folder in the body and replace its
+// tags with the body's tags
+func SetPortfolioTags(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	params := struct {
+		Folder string   `json:"folder"`
+		Tags   []string `json:"tags"`
+	}{}
+	if err := json.Unmarshal(c.Body(), &params); err != nil {
+		log.Warnf("SetPortfolioTags bad request: %s, for portfolio: %s", err, portfolioID)
+		return fiber.ErrBadRequest
+	}
+
+	p, err := loadPortfolio(c.Context(), portfolioID, userID)
+	if err != nil {
+		log.Warnf("SetPortfolioTags %s failed: %s", portfolioID, err)
+		return fiber.ErrNotFound
+	}
+	if !p.canEdit() {
+		return fiber.ErrForbidden
+	}
+
+	folder, err := tag.NormalizeFolder(params.Folder)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+	tags, err := tag.Normalize(params.Tags)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	err = repository.Transaction(c.Context(), func(r *repository.Repositories) error {
+		return r.Portfolios.SetTags(c.Context(), p.ID, folder, tags)
+	})
+	if err != nil {
+		log.Warnf("SetPortfolioTags failed: %s, for portfolio: %s", err, portfolioID)
+		return fiber.ErrInternalServerError
+	}
+
+	p.Folder = folder
+	p.Tags = tags
+	return c.JSON(p)
+}
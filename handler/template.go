@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"encoding/json"
+	"main/locale"
+	"main/notification"
+	"main/repository"
+
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// ListTemplates list the notification templates
+func ListTemplates(c *fiber.Ctx) error {
+	templates, err := repository.Notifications.Templates(c.Context())
+	if err != nil {
+		log.Warnf("ListTemplates failed: %s", err)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(templates)
+}
+
+// CreateTemplate create a notification template
+func CreateTemplate(c *fiber.Ctx) error {
+	t := notification.Template{}
+	if err := json.Unmarshal(c.Body(), &t); err != nil {
+		log.Warnf("CreateTemplate bad request: %s", err)
+		return fiber.ErrBadRequest
+	}
+
+	if err := t.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	if err := repository.Notifications.CreateTemplate(c.Context(), &t); err != nil {
+		log.Warnf("Failed to create template: %s", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "a template already exists for this kind, channel, frequency, and locale"})
+	}
+
+	return c.JSON(t)
+}
+
+// UpdateTemplate update a notification template
+func UpdateTemplate(c *fiber.Ctx) error {
+	templateID := c.Params("id")
+
+	t, err := repository.Notifications.Template(c.Context(), templateID)
+	if err != nil {
+		log.Warnf("UpdateTemplate %s failed: %s", templateID, err)
+		return fiber.ErrNotFound
+	}
+
+	// unmarshal on top of the existing template so unspecified fields are kept
+	id := t.ID
+	if err := json.Unmarshal(c.Body(), &t); err != nil {
+		log.Warnf("UpdateTemplate bad request: %s, for template: %s", err, templateID)
+		return fiber.ErrBadRequest
+	}
+	t.ID = id
+
+	if err := t.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	if err := repository.Notifications.UpdateTemplate(c.Context(), &t); err != nil {
+		log.Warnf("UpdateTemplate SQL update failed: %s for template: %s", err, templateID)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(t)
+}
+
+// DeleteTemplate delete a notification template; notifications it rendered
+// fall back to less specific or built-in templates
+func DeleteTemplate(c *fiber.Ctx) error {
+	templateID := c.Params("id")
+
+	if err := repository.Notifications.DeleteTemplate(c.Context(), templateID); err != nil {
+		log.Warnf("DeleteTemplate delete failed: %s, for template: %s", err, templateID)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(fiber.Map{"status": "success"})
+}
+
+// PreviewTemplate render a notification template with the data in the
+// request body. SendGrid templates are rendered by SendGrid and cannot be
+// previewed.
+func PreviewTemplate(c *fiber.Ctx) error {
+	templateID := c.Params("id")
+
+	t, err := repository.Notifications.Template(c.Context(), templateID)
+	if err != nil {
+		log.Warnf("PreviewTemplate %s failed: %s", templateID, err)
+		return fiber.ErrNotFound
+	}
+
+	if t.SendGridTemplateID != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "SendGrid templates cannot be previewed"})
+	}
+
+	data := map[string]interface{}{}
+	if err := json.Unmarshal(c.Body(), &data); err != nil {
+		log.Warnf("PreviewTemplate bad request: %s", err)
+		return fiber.ErrBadRequest
+	}
+
+	subject, body, err := t.Render(data, locale.Get(t.Locale))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"subject": subject, "body": body})
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"main/portfolio"
+	"main/repository"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// ListExecutedTransactions list all executed transactions for a portfolio
+func ListExecutedTransactions(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	trxs, err := repository.Transactions.List(c.Context(), portfolioID, userID)
+	if err != nil {
+		log.Warnf("ListExecutedTransactions failed: %s, for portfolio: %s", err, portfolioID)
+		return fiber.ErrNotFound
+	}
+
+	return c.JSON(trxs)
+}
+
+// CreateExecutedTransaction record a trade executed against a portfolio
+func CreateExecutedTransaction(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	if _, err := loadPortfolio(c.Context(), portfolioID, userID); err != nil {
+		log.Warnf("CreateExecutedTransaction portfolio %s not found: %s", portfolioID, err)
+		return fiber.ErrNotFound
+	}
+
+	t := repository.ExecutedTransaction{}
+	if err := json.Unmarshal(c.Body(), &t); err != nil {
+		log.Warnf("CreateExecutedTransaction bad request: %s", err)
+		return fiber.ErrBadRequest
+	}
+
+	t.Kind = strings.ToUpper(t.Kind)
+	t.Ticker = strings.ToUpper(t.Ticker)
+	switch t.Kind {
+	case portfolio.BuyTransaction, portfolio.SellTransaction:
+		if t.Ticker == "" || t.Shares <= 0 || t.PricePerShare <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "trades require a ticker, shares, and price per share"})
+		}
+		if t.TotalValue == 0 {
+			t.TotalValue = t.Shares * t.PricePerShare
+		}
+	case portfolio.DividendTransaction, portfolio.BorrowFeeTransaction:
+		// dividends paid by short positions are recorded as negative values
+		if t.Ticker == "" || t.TotalValue == 0 || (t.Kind == portfolio.BorrowFeeTransaction && t.TotalValue < 0) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "dividends and borrow fees require a ticker and total value"})
+		}
+	case portfolio.DepositTransaction, portfolio.WithdrawTransaction, portfolio.InterestTransaction, portfolio.MarginInterestTransaction, portfolio.AdvisoryFeeTransaction:
+		if t.TotalValue <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "deposits, withdrawals, interest, and advisory fees require a positive total value"})
+		}
+		t.Ticker = "$CASH"
+		t.Shares = t.TotalValue
+		t.PricePerShare = 1.0
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "kind must be one of BUY, SELL, DEPOSIT, WITHDRAW, INTEREST, MARGIN_INTEREST, BORROW_FEE, ADVISORY_FEE, or DIVIDEND"})
+	}
+
+	t.PortfolioID = uuid.MustParse(portfolioID)
+	if err := repository.Transactions.Create(c.Context(), &t, userID); err != nil {
+		log.Warnf("Failed to create executed transaction for portfolio %s: %s", portfolioID, err)
+		return fiber.ErrBadRequest
+	}
+
+	return c.JSON(t)
+}
+
+// DeleteExecutedTransaction delete an executed transaction
+func DeleteExecutedTransaction(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	trxID := c.Params("trxId")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	if _, err := uuid.Parse(trxID); err != nil {
+		return fiber.ErrNotFound
+	}
+
+	err := repository.Transactions.Delete(c.Context(), trxID, portfolioID, userID)
+	if err == sql.ErrNoRows {
+		return fiber.ErrNotFound
+	}
+	if err != nil {
+		log.Warnf("DeleteExecutedTransaction delete failed: %s, for transaction: %s", err, trxID)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(fiber.Map{"status": "success"})
+}
+
+// SlippageReport compare as-executed performance to the ideal strategy
+// performance over the same period. Executed transactions are in actual
+// shares so they are valued at the unadjusted close with the splits and
+// dividends since each trade added to the ledger.
+func SlippageReport(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	p, err := loadPortfolio(c.Context(), portfolioID, userID)
+	if err != nil {
+		log.Warnf("SlippageReport %s failed: %s", portfolioID, err)
+		return fiber.ErrNotFound
+	}
+
+	executedTrxs, err := repository.Transactions.List(c.Context(), portfolioID, userID)
+	if err != nil || len(executedTrxs) == 0 {
+		log.Warnf("SlippageReport no executed transactions for portfolio %s: %v", portfolioID, err)
+		return fiber.ErrNotFound
+	}
+
+	trxs := make([]portfolio.Transaction, len(executedTrxs))
+	for ii := range executedTrxs {
+		trxs[ii] = executedTrxs[ii].Transaction()
+	}
+
+	endDate := time.Now()
+	year, month, day := endDate.Date()
+	endDate = time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	manager := newDataManager(c)
+	executed, err := portfolio.NewPortfolioFromTransactions(p.Name, &manager, trxs)
+	if err != nil {
+		log.Warnf("SlippageReport cannot build executed portfolio %s: %s", portfolioID, err)
+		return fiber.ErrBadRequest
+	}
+
+	if err := executed.ApplySplits(); err != nil {
+		log.Warnf("SlippageReport cannot apply splits to portfolio %s: %s", portfolioID, err)
+		return fiber.ErrBadRequest
+	}
+	if err := executed.ApplyDividends(); err != nil {
+		log.Warnf("SlippageReport cannot apply dividends to portfolio %s: %s", portfolioID, err)
+		return fiber.ErrBadRequest
+	}
+
+	manager.Begin = executed.StartTime
+	manager.End = endDate
+	ideal, err := computeSavedPortfolio(&p, &manager)
+	if err != nil {
+		log.Warnf("SlippageReport cannot compute strategy for portfolio %s: %s", portfolioID, err)
+		return fiber.ErrBadRequest
+	}
+
+	idealPerf, err := ideal.CalculatePerformance(endDate)
+	if err != nil {
+		log.Warnf("SlippageReport cannot calculate ideal performance for portfolio %s: %s", portfolioID, err)
+		return fiber.ErrBadRequest
+	}
+
+	executedPerf, err := executed.CalculatePerformance(endDate)
+	if err != nil {
+		log.Warnf("SlippageReport cannot calculate executed performance for portfolio %s: %s", portfolioID, err)
+		return fiber.ErrBadRequest
+	}
+
+	return c.JSON(portfolio.Slippage(&idealPerf, &executedPerf))
+}
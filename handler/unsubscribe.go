@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"encoding/json"
+	"main/notification"
+	"main/repository"
+	"os"
+
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// unsubscribeToken verify the signed token in the token query parameter and
+// load the notifications bitmask of its portfolio
+func unsubscribeToken(c *fiber.Ctx) (notification.Unsubscribe, string, int, error) {
+	u, err := notification.ParseUnsubscribe(c.Query("token"), os.Getenv("UNSUBSCRIBE_SECRET"))
+	if err != nil {
+		return u, "", 0, err
+	}
+
+	name, notifications, err := repository.Portfolios.Notifications(c.Context(), u.PortfolioID)
+	return u, name, notifications, err
+}
+
+// Unsubscribe stop the notifications named by a signed unsubscribe link. No
+// login is required; the link's signature authorizes the change.
+func Unsubscribe(c *fiber.Ctx) error {
+	u, name, notifications, err := unsubscribeToken(c)
+	if err != nil {
+		log.Warnf("Unsubscribe failed: %s", err)
+		return fiber.ErrNotFound
+	}
+
+	notifications = u.Apply(notifications)
+	err = repository.Portfolios.SetNotifications(c.Context(), u.PortfolioID, notifications)
+	if err != nil {
+		log.Warnf("Unsubscribe SQL update failed: %s for portfolio: %s", err, u.PortfolioID)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(fiber.Map{
+		"status":        "success",
+		"portfolio":     name,
+		"unsubscribed":  u.Frequency,
+		"notifications": notification.FrequencyFlags(notifications),
+	})
+}
+
+// GetNotificationPreferences list the notification frequencies enabled for
+// the portfolio of a signed unsubscribe link
+func GetNotificationPreferences(c *fiber.Ctx) error {
+	_, name, notifications, err := unsubscribeToken(c)
+	if err != nil {
+		log.Warnf("GetNotificationPreferences failed: %s", err)
+		return fiber.ErrNotFound
+	}
+
+	return c.JSON(fiber.Map{
+		"portfolio":     name,
+		"notifications": notification.FrequencyFlags(notifications),
+	})
+}
+
+// UpdateNotificationPreferences enable or disable notification frequencies
+// for the portfolio of a signed unsubscribe link. The request body maps
+// frequency names to whether they are enabled, e.g. {"weekly": false}.
+func UpdateNotificationPreferences(c *fiber.Ctx) error {
+	u, name, notifications, err := unsubscribeToken(c)
+	if err != nil {
+		log.Warnf("UpdateNotificationPreferences failed: %s", err)
+		return fiber.ErrNotFound
+	}
+
+	flags := map[string]bool{}
+	if err := json.Unmarshal(c.Body(), &flags); err != nil {
+		log.Warnf("UpdateNotificationPreferences bad request: %s", err)
+		return fiber.ErrBadRequest
+	}
+
+	notifications, err = notification.SetFrequencyFlags(notifications, flags)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	err = repository.Portfolios.SetNotifications(c.Context(), u.PortfolioID, notifications)
+	if err != nil {
+		log.Warnf("UpdateNotificationPreferences SQL update failed: %s for portfolio: %s", err, u.PortfolioID)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(fiber.Map{
+		"portfolio":     name,
+		"notifications": notification.FrequencyFlags(notifications),
+	})
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"main/data"
+	"main/repository"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// GetUsage get the number of API calls made to each data provider with the
+// logged in user's token in the current hour and UTC day, and the limits
+// of the providers
+func GetUsage(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+	tiingoToken, _ := claims["https://pennyvault.com/tiingo_token"].(string)
+
+	// calls made by this process are included
+	if err := data.Usage.Sync(c.Context(), repository.Usage); err != nil {
+		log.Warnf("GetUsage sync failed for user %s: %s", userID, err)
+	}
+
+	now := time.Now()
+	records, err := repository.Usage.UsageSince(c.Context(), data.TokenHash(tiingoToken), data.UsageDay(now))
+	if err != nil {
+		log.Warnf("GetUsage failed for user %s: %s", userID, err)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(data.SummarizeUsage(records, now))
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"main/account"
+	"main/repository"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// verificationStatus whether a user's email address may be sent
+// notifications and who verified it
+type verificationStatus struct {
+	Email            string `json:"email"`
+	Verified         bool   `json:"verified"`
+	VerifiedBy       string `json:"verifiedBy,omitempty"`
+	VerifiedAt       int64  `json:"verifiedAt,omitempty"`
+	VerificationSent int64  `json:"verificationSent,omitempty"`
+}
+
+func newVerificationStatus(u *repository.User) verificationStatus {
+	status := verificationStatus{
+		Email:            u.Email,
+		Verified:         u.EmailVerified(),
+		VerificationSent: u.VerificationSent,
+	}
+	switch {
+	case u.Verified:
+		status.VerifiedBy = "auth0"
+	case status.Verified:
+		status.VerifiedBy = "pvapi"
+		status.VerifiedAt = u.VerifiedAt
+	}
+	return status
+}
+
+// GetEmailVerification report whether the user's email address is verified;
+// notifications are only sent to verified addresses
+// @Description Verification status of the user's email address
+// @Id GetEmailVerification
+// @Produce json
+func GetEmailVerification(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	u, err := repository.Users.Get(c.Context(), userID)
+	if err != nil {
+		log.Warnf("GetEmailVerification failed: %s, for user: %s", err, userID)
+		return fiber.ErrNotFound
+	}
+
+	return c.JSON(newVerificationStatus(u))
+}
+
+// ResendVerification email the user a link that verifies their current email
+// address
+// @Description Send a verification email to the user's email address
+// @Id ResendVerification
+// @Produce json
+func ResendVerification(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	verifier, err := account.NewVerifier()
+	if err != nil {
+		log.Warnf("ResendVerification failed: %s", err)
+		return fiber.ErrServiceUnavailable
+	}
+
+	err = verifier.Send(c.Context(), userID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return fiber.ErrNotFound
+	case errors.Is(err, account.ErrAlreadyVerified):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	case errors.Is(err, account.ErrNoEmail):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	case errors.Is(err, account.ErrResendTooSoon):
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	case err != nil:
+		log.Warnf("ResendVerification failed: %s, for user: %s", err, userID)
+		return fiber.ErrBadGateway
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"status": "success"})
+}
+
+// VerifyEmail record that the user of a signed verification link owns the
+// address it was sent to. No login is required; the link's signature
+// authorizes the change.
+func VerifyEmail(c *fiber.Ctx) error {
+	verifier, err := account.NewVerifier()
+	if err != nil {
+		log.Warnf("VerifyEmail failed: %s", err)
+		return fiber.ErrServiceUnavailable
+	}
+
+	u, err := verifier.Verify(c.Context(), c.Query("token"))
+	switch {
+	case errors.Is(err, account.ErrVerificationExpired), errors.Is(err, account.ErrEmailChanged):
+		return c.Status(fiber.StatusGone).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	case err != nil:
+		log.Warnf("VerifyEmail failed: %s", err)
+		return fiber.ErrNotFound
+	}
+
+	return c.JSON(newVerificationStatus(u))
+}
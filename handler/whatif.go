@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"encoding/json"
+	"main/portfolio"
+	"main/repository"
+	"main/strategies"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// whatIfRequest alternative strategy the portfolio switches to on the pivot
+// date; the strategy defaults to the portfolio's own strategy
+type whatIfRequest struct {
+	PivotDate string                     `json:"pivotDate"`
+	Strategy  string                     `json:"strategy"`
+	Arguments map[string]json.RawMessage `json:"arguments"`
+}
+
+// WhatIfPortfolio calculate the counterfactual performance of a saved
+// portfolio had it switched to a different strategy or arguments on the pivot
+// date. History before the pivot is read from the transactions persisted by
+// the notifier; if none are stored it is recomputed.
+func WhatIfPortfolio(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	p, err := loadPortfolio(c.Context(), portfolioID, userID)
+	if err != nil {
+		log.Warnf("WhatIfPortfolio %s failed: %s", portfolioID, err)
+		return fiber.ErrNotFound
+	}
+
+	params := whatIfRequest{}
+	if err := json.Unmarshal(c.Body(), &params); err != nil {
+		log.Warnf("WhatIfPortfolio bad request: %s", err)
+		return fiber.ErrBadRequest
+	}
+
+	pivot, err := time.Parse("2006-01-02", params.PivotDate)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "pivotDate must be formatted as YYYY-MM-DD"})
+	}
+
+	endDate := time.Now()
+	year, month, day := endDate.Date()
+	endDate = time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	if !pivot.After(time.Unix(p.StartDate, 0)) || !pivot.Before(endDate) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "pivotDate must be between the portfolio start date and today"})
+	}
+
+	resolution, err := parseResolution(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	encoding, err := parseEncoding(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	if params.Strategy == "" {
+		params.Strategy = p.Strategy
+	}
+	strat, ok := strategies.StrategyMap[params.Strategy]
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "unknown strategy " + params.Strategy})
+	}
+	if params.Arguments == nil {
+		params.Arguments = map[string]json.RawMessage{}
+	}
+	stratObject, err := strat.Factory(params.Arguments)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	manager := newDataManager(c)
+
+	history, err := repository.Measurements.TransactionsBefore(c.Context(), p.ID, pivot)
+	if err != nil {
+		log.Warnf("WhatIfPortfolio cannot load transactions for portfolio %s: %s", portfolioID, err)
+		return fiber.ErrInternalServerError
+	}
+	if len(history) == 0 {
+		manager.Begin = time.Unix(p.StartDate, 0)
+		manager.End = pivot
+		computed, err := computeSavedPortfolio(&p, &manager)
+		if err != nil {
+			log.Warnf("WhatIfPortfolio cannot compute portfolio %s: %s", portfolioID, err)
+			return fiber.ErrBadRequest
+		}
+		history = computed.Transactions
+	}
+
+	manager.Begin = pivot
+	manager.End = endDate
+	alternative, err := stratObject.Compute(&manager)
+	if err != nil {
+		log.Warnf("WhatIfPortfolio cannot compute alternative strategy %s: %s", params.Strategy, err)
+		return fiber.ErrBadRequest
+	}
+	alternative.Resolution = resolution
+
+	counterfactual, err := portfolio.WhatIf(p.Name, &manager, history, alternative, pivot)
+	if err != nil {
+		log.Warnf("WhatIfPortfolio cannot splice portfolio %s: %s", portfolioID, err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	perf, err := counterfactual.CalculatePerformance(endDate)
+	if err != nil {
+		log.Warnf("WhatIfPortfolio cannot calculate performance for portfolio %s: %s", portfolioID, err)
+		return fiber.ErrBadRequest
+	}
+
+	if err := perf.CalculateAfterTax(p.AccountType, p.TaxRates()); err != nil {
+		log.Warnf("WhatIfPortfolio cannot calculate after-tax performance for portfolio %s: %s", portfolioID, err)
+		return fiber.ErrBadRequest
+	}
+
+	perf.BuildMetricsBundle()
+	return sendPerformance(c, &perf, encoding)
+}
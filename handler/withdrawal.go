@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"encoding/json"
+	"main/data"
+	"main/portfolio"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// SimulateWithdrawals run a retirement withdrawal plan over the historical
+// returns of a saved portfolio's strategy and Monte Carlo paths resampled
+// from them. Like the stress test, the strategy is computed over all
+// available history.
+// @Description Success rate and terminal values of a withdrawal plan drawn from a portfolio
+// @Id SimulateWithdrawals
+// @Accept json
+// @Produce json
+// @Param id path string true "id of portfolio"
+func SimulateWithdrawals(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID := claims["sub"].(string)
+
+	plan := portfolio.WithdrawalPlan{}
+	if len(c.Body()) > 0 {
+		if err := json.Unmarshal(c.Body(), &plan); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "body must be a JSON withdrawal plan"})
+		}
+	}
+	if err := plan.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	p, err := loadPortfolio(c.Context(), portfolioID, userID)
+	if err != nil {
+		log.Warnf("SimulateWithdrawals %s failed: %s", portfolioID, err)
+		return fiber.ErrNotFound
+	}
+
+	endDate := time.Now()
+	year, month, day := endDate.Date()
+	endDate = time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	manager := newDataManager(c)
+	manager.Begin = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+	manager.End = endDate
+	computed, err := computeSavedPortfolio(&p, &manager)
+	if err != nil {
+		log.Warnf("SimulateWithdrawals cannot compute portfolio %s: %s", portfolioID, err)
+		return fiber.ErrBadRequest
+	}
+
+	computed.Resolution = data.FrequencyMonthly
+	perf, err := computed.CalculatePerformance(endDate)
+	if err != nil {
+		log.Warnf("SimulateWithdrawals cannot calculate performance for portfolio %s: %s", portfolioID, err)
+		return fiber.ErrBadRequest
+	}
+
+	result, err := perf.SimulateWithdrawals(plan)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	return c.JSON(result)
+}
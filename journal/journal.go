@@ -0,0 +1,65 @@
+package journal
+
+import (
+	"errors"
+	"main/portfolio"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// maxTextLength longest note accepted, in characters
+const maxTextLength = 10000
+
+// Note a dated entry in the decision journal of a portfolio, e.g. "skipped
+// this month's trade because the fund was closed". Notes are visible to
+// everyone that can view the portfolio and only changed by their author.
+type Note struct {
+	ID          uuid.UUID `json:"id"`
+	PortfolioID uuid.UUID `json:"portfolioId"`
+	UserID      string    `json:"-"`
+	Date        int64     `json:"date"`
+	Text        string    `json:"text"`
+
+	// Ticker the holding the note is about, if any
+	Ticker string `json:"ticker,omitempty"`
+
+	// ShowOnChart show the note on the timeline of the portfolio's
+	// performance chart
+	ShowOnChart bool `json:"showOnChart"`
+
+	Created     int64 `json:"created"`
+	LastChanged int64 `json:"lastChanged"`
+}
+
+// Validate check that the note is well formed
+func (n *Note) Validate() error {
+	n.Text = strings.TrimSpace(n.Text)
+	n.Ticker = strings.ToUpper(strings.TrimSpace(n.Ticker))
+	if n.Date == 0 {
+		return errors.New("date is required")
+	}
+	if n.Text == "" {
+		return errors.New("text is required")
+	}
+	if utf8.RuneCountInString(n.Text) > maxTextLength {
+		return errors.New("text must be at most 10000 characters")
+	}
+	return nil
+}
+
+// Annotations the notes to show on a performance chart
+func Annotations(notes []*Note) []portfolio.Annotation {
+	annotations := []portfolio.Annotation{}
+	for _, n := range notes {
+		if n.ShowOnChart {
+			annotations = append(annotations, portfolio.Annotation{
+				Time:   n.Date,
+				Text:   n.Text,
+				Ticker: n.Ticker,
+			})
+		}
+	}
+	return annotations
+}
@@ -0,0 +1,45 @@
+package journal_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"main/journal"
+	"main/portfolio"
+)
+
+var _ = Describe("Journal", func() {
+	Describe("When validating a note", func() {
+		It("should normalize the text and ticker", func() {
+			n := journal.Note{Date: 100, Text: " skipped the trade\n", Ticker: " vfinx"}
+			Expect(n.Validate()).To(Succeed())
+			Expect(n.Text).To(Equal("skipped the trade"))
+			Expect(n.Ticker).To(Equal("VFINX"))
+		})
+
+		It("should require a date and text", func() {
+			n := journal.Note{Text: "no date"}
+			Expect(n.Validate()).NotTo(Succeed())
+
+			n = journal.Note{Date: 100, Text: "  "}
+			Expect(n.Validate()).NotTo(Succeed())
+		})
+
+		It("should reject long notes", func() {
+			n := journal.Note{Date: 100, Text: strings.Repeat("a", 10001)}
+			Expect(n.Validate()).NotTo(Succeed())
+		})
+	})
+
+	It("should only annotate charts with notes shown on them", func() {
+		notes := []*journal.Note{
+			{Date: 100, Text: "skipped the trade", Ticker: "VFINX", ShowOnChart: true},
+			{Date: 200, Text: "private reminder"},
+		}
+		Expect(journal.Annotations(notes)).To(Equal([]portfolio.Annotation{
+			{Time: 100, Text: "skipped the trade", Ticker: "VFINX"},
+		}))
+	})
+})
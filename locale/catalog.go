@@ -0,0 +1,96 @@
+package locale
+
+// locales supported locales; the first is the default
+var locales = []Locale{
+	{
+		Tag:                "en-US",
+		DateFormat:         "02 Jan 2006",
+		UpperCaseDates:     true,
+		NumberFormat:       "1,234.56",
+		PercentFormat:      "%s%%",
+		CurrencyFormat:     "%[2]s%[1]s",
+		CurrencyCodeFormat: "%[2]s %[1]s",
+		messages: map[string]string{
+			"Daily":              "Daily",
+			"Weekly":             "Weekly",
+			"Monthly":            "Monthly",
+			"Annually":           "Annually",
+			"label.currentAsset": "Current asset",
+			"label.periodReturn": "Period return",
+			"label.ytdReturn":    "YTD return",
+			"label.totalValue":   "Total value",
+			"label.overlap":      "Overlap",
+			"label.portfolio":    "Portfolio",
+			"label.portfolios":   "Portfolios",
+			"label.holdings":     "Holdings",
+			"label.value":        "Value",
+			"label.date":         "Date",
+			"label.ticker":       "Ticker",
+			"label.transaction":  "Transaction",
+			"label.oldValue":     "Stored value",
+			"label.newValue":     "Recomputed value",
+			"label.moreChanges":  "and {{.moreChanges}} more changes",
+			"link.preferences":   "Manage notifications",
+			"link.unsubscribe":   "Unsubscribe",
+			"heading.portfolio":  "{{.frequency}} update for {{.forDate}}",
+			"heading.alert":      "Alert triggered on {{.forDate}}",
+			"heading.household":  "{{.frequency}} household digest for {{.forDate}}",
+			"subject.portfolio":  "{{.frequency}} update for {{.portfolioName}}",
+			"subject.alert":      "Alert{{if .portfolioName}} for {{.portfolioName}}{{end}}: {{.message}}",
+			"subject.household":  "{{.frequency}} household digest",
+			"subject.revision":   "History of {{.portfolioName}} revised",
+			"heading.revision":   "Restated market data changed {{.numChanges}} transactions on {{.numDates}} dates through {{.forDate}}",
+			"alert.drawdown":     "Draw down",
+			"alert.price_change": "Price change",
+
+			"subject.verification": "Verify your email address",
+			"heading.verification": "Confirm that {{.email}} is your email address to receive notifications from Penny Vault.",
+			"link.verify":          "Verify email address",
+		},
+	},
+	{
+		Tag:                "de-DE",
+		DateFormat:         "02.01.2006",
+		NumberFormat:       "1.234,56",
+		PercentFormat:      "%s %%",
+		CurrencyFormat:     "%[1]s %[2]s",
+		CurrencyCodeFormat: "%[1]s %[2]s",
+		messages: map[string]string{
+			"Daily":              "Tägliche",
+			"Weekly":             "Wöchentliche",
+			"Monthly":            "Monatliche",
+			"Annually":           "Jährliche",
+			"label.currentAsset": "Aktuelle Anlage",
+			"label.periodReturn": "Rendite im Zeitraum",
+			"label.ytdReturn":    "Rendite seit Jahresbeginn",
+			"label.totalValue":   "Gesamtwert",
+			"label.overlap":      "Überschneidung",
+			"label.portfolio":    "Portfolio",
+			"label.portfolios":   "Portfolios",
+			"label.holdings":     "Positionen",
+			"label.value":        "Wert",
+			"label.date":         "Datum",
+			"label.ticker":       "Symbol",
+			"label.transaction":  "Transaktion",
+			"label.oldValue":     "Gespeicherter Wert",
+			"label.newValue":     "Neu berechneter Wert",
+			"label.moreChanges":  "und {{.moreChanges}} weitere Änderungen",
+			"link.preferences":   "Benachrichtigungen verwalten",
+			"link.unsubscribe":   "Abmelden",
+			"heading.portfolio":  "{{.frequency}} Übersicht vom {{.forDate}}",
+			"heading.alert":      "Alarm ausgelöst am {{.forDate}}",
+			"heading.household":  "{{.frequency}} Haushaltsübersicht vom {{.forDate}}",
+			"subject.portfolio":  "{{.frequency}} Übersicht für {{.portfolioName}}",
+			"subject.alert":      "Alarm{{if .portfolioName}} für {{.portfolioName}}{{end}}: {{.message}}",
+			"subject.household":  "{{.frequency}} Haushaltsübersicht",
+			"subject.revision":   "Verlauf von {{.portfolioName}} geändert",
+			"heading.revision":   "Korrigierte Marktdaten haben {{.numChanges}} Transaktionen an {{.numDates}} Tagen bis zum {{.forDate}} geändert",
+			"alert.drawdown":     "Rückgang",
+			"alert.price_change": "Kursänderung",
+
+			"subject.verification": "Bestätigen Sie Ihre E-Mail-Adresse",
+			"heading.verification": "Bestätigen Sie, dass {{.email}} Ihre E-Mail-Adresse ist, um Benachrichtigungen von Penny Vault zu erhalten.",
+			"link.verify":          "E-Mail-Adresse bestätigen",
+		},
+	},
+}
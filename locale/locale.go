@@ -0,0 +1,200 @@
+package locale
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultTag locale used when the requested locale is not supported
+const DefaultTag = "en-US"
+
+// Locale formatting conventions and translated strings for a language and
+// region
+type Locale struct {
+	Tag string
+
+	// DateFormat Go layout used to format dates
+	DateFormat string
+
+	// UpperCaseDates format dates in upper case, e.g. 02 JAN 2006
+	UpperCaseDates bool
+
+	// NumberFormat the number 1234.56 written with the locale's separators
+	NumberFormat string
+
+	// PercentFormat fmt format of a signed percent, e.g. "%s%%"
+	PercentFormat string
+
+	// CurrencyFormat fmt format of an amount and currency symbol
+	CurrencyFormat string
+
+	// CurrencyCodeFormat fmt format of an amount and ISO 4217 currency code
+	// for currencies without a symbol
+	CurrencyCodeFormat string
+
+	messages map[string]string
+}
+
+// Supported tags of the supported locales
+func Supported() []string {
+	tags := make([]string, 0, len(locales))
+	for _, loc := range locales {
+		tags = append(tags, loc.Tag)
+	}
+	return tags
+}
+
+// IsSupported true if tag is a supported locale
+func IsSupported(tag string) bool {
+	for _, loc := range locales {
+		if loc.Tag == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Get the locale for tag, or the default locale if tag is not supported
+func Get(tag string) *Locale {
+	for ii := range locales {
+		if locales[ii].Tag == tag {
+			loc := locales[ii]
+			return &loc
+		}
+	}
+	loc := locales[0]
+	return &loc
+}
+
+// WithNumberFormat copy of the locale that writes numbers with the
+// separators of format, e.g. "1.234,56". An empty format keeps the locale's.
+func (l *Locale) WithNumberFormat(format string) *Locale {
+	loc := *l
+	if format != "" {
+		loc.NumberFormat = format
+	}
+	return &loc
+}
+
+// separators thousands and decimal separators of the number format
+func (l *Locale) separators() (string, string) {
+	runes := []rune(l.NumberFormat)
+	if len(runes) != len("1,234.56") {
+		return ",", "."
+	}
+	return string(runes[1]), string(runes[5])
+}
+
+// FormatDate format a date with the locale's date format
+func (l *Locale) FormatDate(date time.Time) string {
+	formatted := date.Format(l.DateFormat)
+	if l.UpperCaseDates {
+		formatted = strings.ToUpper(formatted)
+	}
+	return formatted
+}
+
+// FormatNumber format a number with decimals digits after the decimal
+// separator and thousands grouped
+func (l *Locale) FormatNumber(value float64, decimals int) string {
+	thousands, decimal := l.separators()
+
+	sign := ""
+	if value < 0 {
+		sign = "-"
+		value = math.Abs(value)
+	}
+
+	digits := fmt.Sprintf("%.*f", decimals, value)
+	whole, fraction := digits, ""
+	if idx := strings.Index(digits, "."); idx >= 0 {
+		whole, fraction = digits[:idx], digits[idx+1:]
+	}
+
+	var grouped strings.Builder
+	for ii, digit := range whole {
+		if ii > 0 && (len(whole)-ii)%3 == 0 {
+			grouped.WriteString(thousands)
+		}
+		grouped.WriteRune(digit)
+	}
+
+	if fraction != "" {
+		return sign + grouped.String() + decimal + fraction
+	}
+	return sign + grouped.String()
+}
+
+// FormatPercent format a fraction as a signed percent with 2 decimals, e.g.
+// 0.05 is +5.00%
+func (l *Locale) FormatPercent(value float64) string {
+	sign := "+"
+	if value < 0 {
+		sign = ""
+	}
+	return fmt.Sprintf(l.PercentFormat, sign+l.FormatNumber(value*100, 2))
+}
+
+// FormatShare format a fraction as an unsigned percent with 2 decimals, e.g.
+// 0.6 is 60.00%
+func (l *Locale) FormatShare(value float64) string {
+	return fmt.Sprintf(l.PercentFormat, l.FormatNumber(value*100, 2))
+}
+
+// FormatCurrency format an amount in currency, an ISO 4217 code, with 2
+// decimals
+func (l *Locale) FormatCurrency(value float64, currency string) string {
+	if symbol, ok := currencySymbols[currency]; ok {
+		return fmt.Sprintf(l.CurrencyFormat, l.FormatNumber(value, 2), symbol)
+	}
+	return fmt.Sprintf(l.CurrencyCodeFormat, l.FormatNumber(value, 2), currency)
+}
+
+// T translate the message with key. Messages are Go templates executed with
+// data, e.g. T("subject.portfolio", map[string]interface{}{...}). Keys
+// missing from the locale's catalog are looked up in the default locale;
+// unknown keys are returned unchanged.
+func (l *Locale) T(key string, data ...interface{}) string {
+	msg, ok := l.messages[key]
+	if !ok {
+		msg, ok = locales[0].messages[key]
+	}
+	if !ok {
+		return key
+	}
+
+	tmpl, err := template.New(key).Parse(msg)
+	if err != nil {
+		return key
+	}
+
+	var arg interface{}
+	if len(data) > 0 {
+		arg = data[0]
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, arg); err != nil {
+		return key
+	}
+	return buf.String()
+}
+
+// Funcs template functions that translate with the locale, for use in html
+// and text templates
+func (l *Locale) Funcs() map[string]interface{} {
+	return map[string]interface{}{
+		"T": l.T,
+	}
+}
+
+var currencySymbols = map[string]string{
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"JPY": "¥",
+}
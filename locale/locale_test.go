@@ -0,0 +1,82 @@
+package locale_test
+
+import (
+	"main/locale"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Locale", func() {
+	var (
+		enUS *locale.Locale
+		deDE *locale.Locale
+		date time.Time
+	)
+
+	BeforeEach(func() {
+		enUS = locale.Get("en-US")
+		deDE = locale.Get("de-DE")
+		date = time.Date(2021, time.March, 5, 0, 0, 0, 0, time.UTC)
+	})
+
+	Describe("When looking up locales", func() {
+		It("should fall back to the default locale", func() {
+			Expect(locale.Get("fr-FR").Tag).To(Equal(locale.DefaultTag))
+			Expect(locale.IsSupported("de-DE")).To(BeTrue())
+			Expect(locale.IsSupported("fr-FR")).To(BeFalse())
+			Expect(locale.Supported()).To(Equal([]string{"en-US", "de-DE"}))
+		})
+	})
+
+	Describe("When formatting values", func() {
+		It("should format dates", func() {
+			Expect(enUS.FormatDate(date)).To(Equal("05 MAR 2021"))
+			Expect(deDE.FormatDate(date)).To(Equal("05.03.2021"))
+		})
+
+		It("should format numbers", func() {
+			Expect(enUS.FormatNumber(1234567.891, 2)).To(Equal("1,234,567.89"))
+			Expect(deDE.FormatNumber(1234567.891, 2)).To(Equal("1.234.567,89"))
+			Expect(enUS.FormatNumber(-999.5, 0)).To(Equal("-1,000"))
+			Expect(enUS.FormatNumber(12.5, 1)).To(Equal("12.5"))
+		})
+
+		It("should override the number format", func() {
+			Expect(enUS.WithNumberFormat("1 234,56").FormatNumber(1234.5, 2)).To(Equal("1 234,50"))
+			Expect(enUS.WithNumberFormat("").FormatNumber(1234.5, 2)).To(Equal("1,234.50"))
+			Expect(enUS.NumberFormat).To(Equal("1,234.56"))
+		})
+
+		It("should format percents", func() {
+			Expect(enUS.FormatPercent(0.0523)).To(Equal("+5.23%"))
+			Expect(enUS.FormatPercent(-0.1)).To(Equal("-10.00%"))
+			Expect(deDE.FormatPercent(0.0523)).To(Equal("+5,23 %"))
+			Expect(deDE.FormatShare(0.6)).To(Equal("60,00 %"))
+		})
+
+		It("should format currency", func() {
+			Expect(enUS.FormatCurrency(10234.5, "USD")).To(Equal("$10,234.50"))
+			Expect(deDE.FormatCurrency(10234.5, "EUR")).To(Equal("10.234,50 €"))
+			Expect(deDE.FormatCurrency(10, "CHF")).To(Equal("10,00 CHF"))
+			Expect(enUS.FormatCurrency(10, "CHF")).To(Equal("CHF 10.00"))
+		})
+	})
+
+	Describe("When translating messages", func() {
+		It("should translate with the message catalog", func() {
+			Expect(enUS.T("Monthly")).To(Equal("Monthly"))
+			Expect(deDE.T("Monthly")).To(Equal("Monatliche"))
+		})
+
+		It("should execute message templates", func() {
+			data := map[string]interface{}{"frequency": "Monatliche", "portfolioName": "Rente"}
+			Expect(deDE.T("subject.portfolio", data)).To(Equal("Monatliche Übersicht für Rente"))
+		})
+
+		It("should return unknown keys unchanged", func() {
+			Expect(deDE.T("no.such.key")).To(Equal("no.such.key"))
+		})
+	})
+})
@@ -0,0 +1,241 @@
+// Package logging defines the schema of log events: the logrus fields every
+// package uses to say which function logged an event and which user,
+// portfolio, and strategy it is about. Sink persists warning and error events
+// so support can look up the errors a user or portfolio ran into.
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Fields of log events. Events about a user, portfolio, or strategy must use
+// these names so they can be found by the sink's queries.
+const (
+	// FieldFunction file and function that logged the event, e.g.
+	// cmd/notifier/main.go:notifyRecipient
+	FieldFunction = "Function"
+
+	// FieldError the error being reported
+	FieldError = "Error"
+
+	// FieldUserID the Auth0 id of the user the event is about
+	FieldUserID = "UserId"
+
+	// FieldPortfolio the id of the portfolio the event is about
+	FieldPortfolio = "Portfolio"
+
+	// FieldStrategy the shortcode of the strategy the event is about
+	FieldStrategy = "Strategy"
+)
+
+// Event a log event in the schema stored by Sink. Fields holds the fields
+// that are not part of the schema.
+type Event struct {
+	ID          int64                  `json:"id"`
+	Time        int64                  `json:"time"`
+	Level       string                 `json:"level"`
+	Message     string                 `json:"message"`
+	Function    string                 `json:"function,omitempty"`
+	UserID      string                 `json:"userId,omitempty"`
+	PortfolioID string                 `json:"portfolioId,omitempty"`
+	Strategy    string                 `json:"strategy,omitempty"`
+	Error       string                 `json:"error,omitempty"`
+	Fields      map[string]interface{} `json:"fields,omitempty"`
+}
+
+// Query selects stored events; empty fields match every event
+type Query struct {
+	UserID      string
+	PortfolioID string
+
+	// Level least severe level returned
+	Level logrus.Level
+
+	Since time.Time
+	Limit int
+}
+
+// Store persists events
+type Store interface {
+	// SaveEvents store events
+	SaveEvents(ctx context.Context, events []*Event) error
+}
+
+// NewEvent convert a logrus entry into an event
+func NewEvent(entry *logrus.Entry) *Event {
+	e := &Event{
+		Time:    entry.Time.Unix(),
+		Level:   entry.Level.String(),
+		Message: entry.Message,
+		Fields:  map[string]interface{}{},
+	}
+
+	for key, val := range entry.Data {
+		str := fieldString(val)
+		switch key {
+		case FieldFunction:
+			e.Function = str
+		case FieldError:
+			e.Error = str
+		case FieldUserID:
+			e.UserID = str
+		case FieldPortfolio:
+			e.PortfolioID = str
+		case FieldStrategy:
+			e.Strategy = str
+		default:
+			if _, err := json.Marshal(val); err != nil {
+				val = str
+			}
+			e.Fields[key] = val
+		}
+	}
+
+	if e.Function == "" && entry.HasCaller() {
+		e.Function = entry.Caller.Function
+	}
+	return e
+}
+
+// fieldString the text of a field value
+func fieldString(val interface{}) string {
+	if err, ok := val.(error); ok {
+		return err.Error()
+	}
+	return fmt.Sprint(val)
+}
+
+// ParseLevel the level named by s; "warn", "error", etc.
+func ParseLevel(s string) (logrus.Level, error) {
+	return logrus.ParseLevel(strings.TrimSpace(s))
+}
+
+// sink configuration
+const (
+	sinkBuffer    = 1024
+	sinkBatchSize = 100
+	sinkInterval  = time.Second
+)
+
+// Sink a logrus hook that persists events at or above a level to a store.
+// Events are saved in batches in the background; events logged while the
+// buffer is full are dropped rather than slowing down the caller.
+type Sink struct {
+	store  Store
+	level  logrus.Level
+	events chan *Event
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	dropped int
+}
+
+// NewSink persist events at level or more severe to store
+func NewSink(store Store, level logrus.Level) *Sink {
+	s := &Sink{
+		store:  store,
+		level:  level,
+		events: make(chan *Event, sinkBuffer),
+	}
+	s.wg.Add(1)
+	go s.run()
+	return s
+}
+
+// PersistFromEnv add a sink saving events to store to the standard logger if
+// LOG_DATABASE_LEVEL is set, e.g. to warn. Returns nil if it is not set.
+func PersistFromEnv(store Store) (*Sink, error) {
+	levelStr := os.Getenv("LOG_DATABASE_LEVEL")
+	if levelStr == "" {
+		return nil, nil
+	}
+	level, err := ParseLevel(levelStr)
+	if err != nil {
+		return nil, err
+	}
+	sink := NewSink(store, level)
+	logrus.AddHook(sink)
+	return sink, nil
+}
+
+// Levels the levels persisted by the sink
+func (s *Sink) Levels() []logrus.Level {
+	levels := []logrus.Level{}
+	for _, level := range logrus.AllLevels {
+		if level <= s.level {
+			levels = append(levels, level)
+		}
+	}
+	return levels
+}
+
+// Fire queue the entry to be saved
+func (s *Sink) Fire(entry *logrus.Entry) error {
+	select {
+	case s.events <- NewEvent(entry):
+	default:
+		s.mu.Lock()
+		s.dropped++
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// Dropped number of events dropped because the buffer was full
+func (s *Sink) Dropped() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+// Close save the queued events and stop the sink. Events must not be logged
+// to the sink after it is closed.
+func (s *Sink) Close() {
+	close(s.events)
+	s.wg.Wait()
+}
+
+func (s *Sink) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(sinkInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Event, 0, sinkBatchSize)
+	for {
+		select {
+		case e, ok := <-s.events:
+			if !ok {
+				s.save(batch)
+				return
+			}
+			batch = append(batch, e)
+			if len(batch) < sinkBatchSize {
+				continue
+			}
+		case <-ticker.C:
+		}
+
+		s.save(batch)
+		batch = make([]*Event, 0, sinkBatchSize)
+	}
+}
+
+// save store a batch of events. Errors are written to stderr rather than
+// logged, which would feed them back into the sink.
+func (s *Sink) save(batch []*Event) {
+	if len(batch) == 0 {
+		return
+	}
+	if err := s.store.SaveEvents(context.Background(), batch); err != nil {
+		fmt.Fprintf(os.Stderr, "%v ERROR: could not save %d log events: %v\n", time.Now(), len(batch), err)
+	}
+}
@@ -0,0 +1,77 @@
+package logging_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"main/logging"
+	"sync"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+)
+
+type memoryStore struct {
+	mu     sync.Mutex
+	events []*logging.Event
+}
+
+func (s *memoryStore) SaveEvents(ctx context.Context, events []*logging.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, events...)
+	return nil
+}
+
+var _ = Describe("Logging", func() {
+	var (
+		logger *logrus.Logger
+		store  *memoryStore
+		sink   *logging.Sink
+	)
+
+	BeforeEach(func() {
+		logger = logrus.New()
+		logger.SetOutput(ioutil.Discard)
+		store = &memoryStore{}
+		sink = logging.NewSink(store, logrus.WarnLevel)
+		logger.AddHook(sink)
+	})
+
+	Describe("When events are logged", func() {
+		It("should persist warnings and errors in the event schema", func() {
+			portfolioID := uuid.New()
+			logger.WithFields(logrus.Fields{
+				logging.FieldFunction:  "cmd/notifier/main.go:notifyRecipient",
+				logging.FieldUserID:    "auth0|123",
+				logging.FieldPortfolio: portfolioID,
+				logging.FieldStrategy:  "adm",
+				logging.FieldError:     errors.New("no cash"),
+				"Frequency":            "Daily",
+			}).Error("Could not send email")
+			logger.Warn("Slow request")
+			logger.Info("Not persisted")
+			sink.Close()
+
+			Expect(store.events).To(HaveLen(2))
+			e := store.events[0]
+			Expect(e.Level).To(Equal("error"))
+			Expect(e.Message).To(Equal("Could not send email"))
+			Expect(e.Function).To(Equal("cmd/notifier/main.go:notifyRecipient"))
+			Expect(e.UserID).To(Equal("auth0|123"))
+			Expect(e.PortfolioID).To(Equal(portfolioID.String()))
+			Expect(e.Strategy).To(Equal("adm"))
+			Expect(e.Error).To(Equal("no cash"))
+			Expect(e.Fields).To(Equal(map[string]interface{}{"Frequency": "Daily"}))
+
+			Expect(store.events[1].Level).To(Equal("warning"))
+		})
+
+		It("should only persist events at or above its level", func() {
+			Expect(sink.Levels()).To(ConsistOf(logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel, logrus.WarnLevel))
+			sink.Close()
+		})
+	})
+})
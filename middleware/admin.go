@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"os"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Admin restrict a route to the users listed in ADMIN_USERS, a comma
+// separated list of user ids. Must be used after JWTAuth.
+func Admin() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user := c.Locals("user").(*jwt.Token)
+		claims := user.Claims.(jwt.MapClaims)
+		userID, _ := claims["sub"].(string)
+
+		for _, admin := range strings.Split(os.Getenv("ADMIN_USERS"), ",") {
+			if admin = strings.TrimSpace(admin); admin != "" && admin == userID {
+				return c.Next()
+			}
+		}
+
+		return c.Status(fiber.StatusForbidden).
+			JSON(fiber.Map{"status": "error", "message": "Administrator access required", "data": nil})
+	}
+}
@@ -0,0 +1,249 @@
+package middleware
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// BackgroundUsage the number of a user's queued and running background jobs
+// and the time spent running the jobs that finished since since
+type BackgroundUsage func(user string, since time.Time) (active int, elapsed time.Duration, err error)
+
+// ComputeBudgetConfig the compute each user may consume. A limit of 0
+// disables the check.
+type ComputeBudgetConfig struct {
+	// MaxConcurrent compute requests and background jobs a user may have
+	// running at once
+	MaxConcurrent int
+
+	// SecondsPerDay seconds of compute a user may consume each day (UTC)
+	SecondsPerDay int
+
+	// Background reports the compute of the user's background jobs, which
+	// counts against the same limits; optional
+	Background BackgroundUsage
+
+	// Now returns the current time; defaults to time.Now
+	Now func() time.Time
+}
+
+// ComputeBudgetLimits the compute budget of compute-heavy routes. Defaults to
+// 2 concurrent requests or jobs and 30 minutes of compute per day, which can
+// be changed with COMPUTE_MAX_CONCURRENT and COMPUTE_SECONDS_PER_DAY.
+func ComputeBudgetLimits() ComputeBudgetConfig {
+	return ComputeBudgetConfig{
+		MaxConcurrent: envInt("COMPUTE_MAX_CONCURRENT", 2),
+		SecondsPerDay: envInt("COMPUTE_SECONDS_PER_DAY", 1800),
+	}
+}
+
+// computeUsage compute requests of a user running and the time spent on
+// requests that finished today
+type computeUsage struct {
+	running int
+	spent   time.Duration
+}
+
+// ComputeBudget tracks the compute each user consumes in this process.
+// Compute is measured as the time requests spend in their handlers times the
+// concurrency slots they hold, which is dominated by CPU for the routes it
+// guards.
+type ComputeBudget struct {
+	config ComputeBudgetConfig
+
+	mu    sync.Mutex
+	users map[string]*computeUsage
+	day   time.Time
+}
+
+// NewComputeBudget create a budget with no compute consumed
+func NewComputeBudget(config ComputeBudgetConfig) *ComputeBudget {
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+	return &ComputeBudget{
+		config: config,
+		users:  make(map[string]*computeUsage),
+	}
+}
+
+// usage the usage of key today; must be called with mu held
+func (b *ComputeBudget) usage(key string, now time.Time) *computeUsage {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if !day.Equal(b.day) {
+		// requests running over midnight still finish
+		users := make(map[string]*computeUsage, len(b.users))
+		for k, u := range b.users {
+			if u.running > 0 {
+				users[k] = &computeUsage{running: u.running}
+			}
+		}
+		b.users = users
+		b.day = day
+	}
+
+	u, ok := b.users[key]
+	if !ok {
+		u = &computeUsage{}
+		b.users[key] = u
+	}
+	return u
+}
+
+// begin start a request by key unless the user is at their concurrency
+// limit or has spent their daily budget. Returns the seconds of the budget
+// remaining before the request, and if the request is rejected, why and
+// how long until it may be retried.
+func (b *ComputeBudget) begin(key string, background int, backgroundSpent time.Duration) (remaining int, reason string, retry time.Duration) {
+	now := b.config.Now().UTC()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	u := b.usage(key, now)
+	spent := u.spent + backgroundSpent
+	remaining = b.config.SecondsPerDay - int(spent/time.Second)
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	switch {
+	case b.config.SecondsPerDay > 0 && remaining == 0:
+		reason = fmt.Sprintf("Daily compute budget of %d seconds exhausted", b.config.SecondsPerDay)
+		retry = b.day.AddDate(0, 0, 1).Sub(now)
+	case b.config.MaxConcurrent > 0 && u.running+background >= b.config.MaxConcurrent:
+		reason = fmt.Sprintf("%d compute requests or jobs are already running; wait for one to finish", u.running+background)
+	default:
+		u.running++
+	}
+	return remaining, reason, retry
+}
+
+// reserve take up to n more concurrency slots for a running request by key,
+// as many as are free; returns the slots taken
+func (b *ComputeBudget) reserve(key string, background int, n int) int {
+	now := b.config.Now().UTC()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	u := b.usage(key, now)
+	if b.config.MaxConcurrent > 0 {
+		free := b.config.MaxConcurrent - u.running - background
+		if free < 0 {
+			free = 0
+		}
+		if n > free {
+			n = free
+		}
+	}
+	u.running += n
+	return n
+}
+
+// end finish a request by key that held slots for elapsed; its compute is
+// charged for each slot
+func (b *ComputeBudget) end(key string, elapsed time.Duration, slots int) {
+	now := b.config.Now().UTC()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	u := b.usage(key, now)
+	u.running -= slots
+	if u.running < 0 {
+		u.running = 0
+	}
+	u.spent += elapsed * time.Duration(slots)
+}
+
+// computeBudgetLocal the Locals key of the slots a request holds, read by
+// ReserveConcurrent
+const computeBudgetLocal = "computeBudget"
+
+// computeSlots the concurrency slots held by a request
+type computeSlots struct {
+	budget     *ComputeBudget
+	key        string
+	background int
+	held       int
+}
+
+// ReserveConcurrent reserve up to n concurrency slots for the request's user
+// in addition to the one the request holds, for handlers that compute in
+// parallel such as strategy batches. Returns the slots reserved, which may be
+// fewer than n if the user has other requests or jobs running; they are
+// released when the request finishes, and the request's compute time is
+// charged once for every slot it held. Reserves all n on routes without a
+// ComputeBudget.
+func ReserveConcurrent(c *fiber.Ctx, n int) int {
+	slots, ok := c.Locals(computeBudgetLocal).(*computeSlots)
+	if !ok || n <= 0 {
+		return n
+	}
+	n = slots.budget.reserve(slots.key, slots.background, n)
+	slots.held += n
+	return n
+}
+
+// Limit reject requests of users that have MaxConcurrent requests or jobs
+// running, or have consumed SecondsPerDay of compute today, with 429. Users
+// are identified as by RateLimit, so it must be used after JWTAuth. Responses
+// include the X-Compute-* budget headers.
+func (b *ComputeBudget) Limit() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := "ip:" + c.IP()
+		sub := requestUser(c)
+		if sub != "" {
+			key = "user:" + sub
+		}
+
+		var active int
+		var elapsed time.Duration
+		if b.config.Background != nil && sub != "" {
+			now := b.config.Now().UTC()
+			var err error
+			active, elapsed, err = b.config.Background(sub, time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC))
+			if err != nil {
+				// background jobs are not counted rather than failing the request
+				log.Warnf("Cannot read background compute of %s: %s", key, err)
+			}
+		}
+
+		remaining, reason, retry := b.begin(key, active, elapsed)
+		if b.config.SecondsPerDay > 0 {
+			c.Set("X-Compute-Limit-Day", strconv.Itoa(b.config.SecondsPerDay))
+			c.Set("X-Compute-Remaining-Day", strconv.Itoa(remaining))
+		}
+		if b.config.MaxConcurrent > 0 {
+			c.Set("X-Compute-Limit-Concurrent", strconv.Itoa(b.config.MaxConcurrent))
+		}
+
+		if reason != "" {
+			if retry > 0 {
+				c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int((retry+time.Second-1)/time.Second)))
+			}
+			log.WithFields(log.Fields{
+				"Key":    key,
+				"Path":   c.Path(),
+				"Reason": reason,
+			}).Warn("Compute budget exceeded")
+			return c.Status(fiber.StatusTooManyRequests).
+				JSON(fiber.Map{"status": "error", "message": reason, "data": nil})
+		}
+
+		slots := &computeSlots{budget: b, key: key, background: active, held: 1}
+		c.Locals(computeBudgetLocal, slots)
+
+		start := b.config.Now()
+		defer func() {
+			b.end(key, b.config.Now().Sub(start), slots.held)
+		}()
+		return c.Next()
+	}
+}
@@ -0,0 +1,165 @@
+package middleware_test
+
+import (
+	"io/ioutil"
+	"main/middleware"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ComputeBudget", func() {
+	var (
+		app        *fiber.App
+		now        time.Time
+		active     int
+		background time.Duration
+		started    chan bool
+		release    chan bool
+	)
+
+	setup := func(maxConcurrent, secondsPerDay int) {
+		app = fiber.New()
+		budget := middleware.NewComputeBudget(middleware.ComputeBudgetConfig{
+			MaxConcurrent: maxConcurrent,
+			SecondsPerDay: secondsPerDay,
+			Background: func(user string, since time.Time) (int, time.Duration, error) {
+				return active, background, nil
+			},
+			Now: func() time.Time { return now },
+		})
+		authenticate := func(c *fiber.Ctx) error {
+			c.Locals("user", &jwt.Token{Claims: jwt.MapClaims{"sub": c.Get("X-User")}})
+			return c.Next()
+		}
+		// each request computes for the seconds in X-Seconds, or until
+		// released if X-Block is set
+		app.Post("/run", authenticate, budget.Limit(), func(c *fiber.Ctx) error {
+			if c.Get("X-Block") != "" {
+				started <- true
+				<-release
+			}
+			seconds, _ := strconv.Atoi(c.Get("X-Seconds"))
+			now = now.Add(time.Duration(seconds) * time.Second)
+			return c.SendString("ok")
+		})
+		// computes in parallel for X-Seconds with as many as X-Slots slots
+		// in addition to its own; responds with the slots reserved
+		app.Post("/parallel", authenticate, budget.Limit(), func(c *fiber.Ctx) error {
+			slots, _ := strconv.Atoi(c.Get("X-Slots"))
+			reserved := middleware.ReserveConcurrent(c, slots)
+			seconds, _ := strconv.Atoi(c.Get("X-Seconds"))
+			now = now.Add(time.Duration(seconds) * time.Second)
+			return c.SendString(strconv.Itoa(reserved))
+		})
+	}
+
+	request := func(user string, seconds int, block bool) (int, string, string) {
+		req := httptest.NewRequest("POST", "/run", nil)
+		req.Header.Set("X-User", user)
+		req.Header.Set("X-Seconds", strconv.Itoa(seconds))
+		if block {
+			req.Header.Set("X-Block", "true")
+		}
+		resp, err := app.Test(req, -1)
+		Expect(err).To(BeNil())
+		return resp.StatusCode, resp.Header.Get("Retry-After"), resp.Header.Get("X-Compute-Remaining-Day")
+	}
+
+	BeforeEach(func() {
+		now = time.Date(2021, time.March, 1, 23, 0, 0, 0, time.UTC)
+		active = 0
+		background = 0
+		started = make(chan bool)
+		release = make(chan bool)
+	})
+
+	It("should reject requests once the daily budget is spent", func() {
+		setup(0, 100)
+
+		code, _, remaining := request("alice", 60, false)
+		Expect(code).To(Equal(fiber.StatusOK))
+		Expect(remaining).To(Equal("100"))
+
+		code, _, remaining = request("alice", 60, false)
+		Expect(code).To(Equal(fiber.StatusOK))
+		Expect(remaining).To(Equal("40"))
+
+		code, retry, remaining := request("alice", 1, false)
+		Expect(code).To(Equal(fiber.StatusTooManyRequests))
+		Expect(remaining).To(Equal("0"))
+		Expect(retry).To(Equal("3480"))
+
+		code, _, _ = request("bob", 1, false)
+		Expect(code).To(Equal(fiber.StatusOK))
+
+		// the budget is renewed each day
+		now = now.Add(time.Hour)
+		code, _, _ = request("alice", 1, false)
+		Expect(code).To(Equal(fiber.StatusOK))
+	})
+
+	It("should count the compute of background jobs", func() {
+		setup(0, 100)
+		background = 100 * time.Second
+
+		code, _, _ := request("alice", 1, false)
+		Expect(code).To(Equal(fiber.StatusTooManyRequests))
+	})
+
+	It("should limit concurrent requests and jobs", func() {
+		setup(2, 0)
+
+		done := make(chan int)
+		go func() {
+			code, _, _ := request("alice", 1, true)
+			done <- code
+		}()
+		<-started
+
+		active = 1
+		code, retry, _ := request("alice", 1, false)
+		Expect(code).To(Equal(fiber.StatusTooManyRequests))
+		Expect(retry).To(Equal(""))
+
+		active = 0
+		code, _, _ = request("alice", 1, false)
+		Expect(code).To(Equal(fiber.StatusOK))
+
+		release <- true
+		Expect(<-done).To(Equal(fiber.StatusOK))
+	})
+
+	It("should charge parallel compute for each slot it holds", func() {
+		setup(3, 100)
+		active = 1
+
+		parallel := func() (string, string) {
+			req := httptest.NewRequest("POST", "/parallel", nil)
+			req.Header.Set("X-User", "alice")
+			req.Header.Set("X-Slots", "4")
+			req.Header.Set("X-Seconds", "10")
+			resp, err := app.Test(req, -1)
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(fiber.StatusOK))
+			body, err := ioutil.ReadAll(resp.Body)
+			Expect(err).To(BeNil())
+			return string(body), resp.Header.Get("X-Compute-Remaining-Day")
+		}
+
+		reserved, remaining := parallel()
+		Expect(reserved).To(Equal("1"))
+		Expect(remaining).To(Equal("100"))
+
+		// the request and its reserved slot computed for 10 seconds each,
+		// and both slots were released
+		reserved, remaining = parallel()
+		Expect(reserved).To(Equal("1"))
+		Expect(remaining).To(Equal("80"))
+	})
+})
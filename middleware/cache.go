@@ -0,0 +1,256 @@
+package middleware
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ResponseCacheConfig configuration of a ResponseCache
+type ResponseCacheConfig struct {
+	// MaxEntries number of responses kept; the oldest response is evicted
+	// when the cache is full. Defaults to 256.
+	MaxEntries int
+
+	// Now returns the current time; defaults to time.Now
+	Now func() time.Time
+}
+
+// Expiration when a response cached at now must be computed again. A zero
+// time caches the response until it is invalidated.
+type Expiration func(now time.Time) time.Time
+
+// UntilInvalidated cache responses until the cache is invalidated, e.g. for
+// data that only changes when the server is deployed
+func UntilInvalidated(now time.Time) time.Time {
+	return time.Time{}
+}
+
+// cachedResponse a successful response to a GET request
+type cachedResponse struct {
+	body         []byte
+	contentType  string
+	etag         string
+	lastModified time.Time
+	expires      time.Time
+}
+
+// ResponseCache in-memory cache of GET responses keyed by user and URL.
+// Cached responses carry ETag and Last-Modified headers so clients can make
+// conditional requests, which are answered with 304 Not Modified.
+type ResponseCache struct {
+	config ResponseCacheConfig
+
+	mu      sync.Mutex
+	entries map[string]*cachedResponse
+	order   []string
+}
+
+// NewResponseCache create an empty response cache
+func NewResponseCache(config ResponseCacheConfig) *ResponseCache {
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = 256
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+	return &ResponseCache{
+		config:  config,
+		entries: make(map[string]*cachedResponse),
+	}
+}
+
+// requestUser the JWT subject of the request, or an empty string for
+// unauthenticated requests
+func requestUser(c *fiber.Ctx) string {
+	if user, ok := c.Locals("user").(*jwt.Token); ok {
+		if claims, ok := user.Claims.(jwt.MapClaims); ok {
+			sub, _ := claims["sub"].(string)
+			return sub
+		}
+	}
+	return ""
+}
+
+// userPrefix prefix of the cache keys of a user's responses
+func userPrefix(user string) string {
+	return user + "\x00"
+}
+
+// etag strong entity tag of body
+func etag(body []byte) string {
+	h := fnv.New64a()
+	h.Write(body)
+	return fmt.Sprintf(`"%x-%x"`, len(body), h.Sum64())
+}
+
+// Cache serve GET requests from the cache until the response expires. Must be
+// used after JWTAuth on routes whose responses depend on the user.
+func (rc *ResponseCache) Cache(expires Expiration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+			return c.Next()
+		}
+
+		now := rc.config.Now()
+		key := userPrefix(requestUser(c)) + c.OriginalURL()
+
+		if entry := rc.get(key, now); entry != nil {
+			return entry.send(c, now)
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+		if c.Response().StatusCode() != fiber.StatusOK {
+			return nil
+		}
+
+		body := c.Response().Body()
+		entry := &cachedResponse{
+			body:         append([]byte(nil), body...),
+			contentType:  string(c.Response().Header.ContentType()),
+			etag:         etag(body),
+			lastModified: now.UTC().Truncate(time.Second),
+			expires:      expires(now),
+		}
+		rc.put(key, entry, now)
+		return entry.send(c, now)
+	}
+}
+
+// Invalidate remove the user's cached responses once a request that changes
+// their data succeeds. Must be used after JWTAuth.
+func (rc *ResponseCache) Invalidate() fiber.Handler {
+	return rc.InvalidateFor(nil)
+}
+
+// InvalidateFor like Invalidate but also remove the cached responses of the
+// users returned by audience, e.g. the members of the organization a changed
+// portfolio is shared with. audience is called before the request is handled
+// so it sees the data as it was before the change.
+func (rc *ResponseCache) InvalidateFor(audience func(c *fiber.Ctx) []string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		users := []string{requestUser(c)}
+		if audience != nil {
+			users = append(users, audience(c)...)
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+		if c.Response().StatusCode() < fiber.StatusBadRequest {
+			rc.removeUsers(users)
+		}
+		return nil
+	}
+}
+
+func (rc *ResponseCache) get(key string, now time.Time) *cachedResponse {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	entry, ok := rc.entries[key]
+	if !ok {
+		return nil
+	}
+	if !entry.expires.IsZero() && !now.Before(entry.expires) {
+		delete(rc.entries, key)
+		return nil
+	}
+	return entry
+}
+
+func (rc *ResponseCache) put(key string, entry *cachedResponse, now time.Time) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if _, ok := rc.entries[key]; !ok {
+		rc.order = append(rc.order, key)
+	}
+	rc.entries[key] = entry
+
+	// evict the oldest responses, skipping keys that were already removed
+	for len(rc.entries) > rc.config.MaxEntries && len(rc.order) > 0 {
+		delete(rc.entries, rc.order[0])
+		rc.order = rc.order[1:]
+	}
+	if len(rc.order) > 2*rc.config.MaxEntries {
+		rc.compact()
+	}
+}
+
+func (rc *ResponseCache) removeUsers(users []string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	for _, user := range users {
+		prefix := userPrefix(user)
+		for key := range rc.entries {
+			if strings.HasPrefix(key, prefix) {
+				delete(rc.entries, key)
+			}
+		}
+	}
+	rc.compact()
+}
+
+// compact drop keys of removed entries from the eviction order
+func (rc *ResponseCache) compact() {
+	order := make([]string, 0, len(rc.entries))
+	for _, key := range rc.order {
+		if _, ok := rc.entries[key]; ok {
+			order = append(order, key)
+		}
+	}
+	rc.order = order
+}
+
+// fresh whether the client's copy of the response is current
+func (entry *cachedResponse) fresh(c *fiber.Ctx) bool {
+	if noneMatch := c.Get(fiber.HeaderIfNoneMatch); noneMatch != "" {
+		for _, tag := range strings.Split(noneMatch, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == entry.etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if modifiedSince := c.Get(fiber.HeaderIfModifiedSince); modifiedSince != "" {
+		since, err := http.ParseTime(modifiedSince)
+		return err == nil && !entry.lastModified.After(since)
+	}
+
+	return false
+}
+
+// send write the cached response, or 304 Not Modified if the client's copy is
+// current
+func (entry *cachedResponse) send(c *fiber.Ctx, now time.Time) error {
+	c.Set(fiber.HeaderETag, entry.etag)
+	c.Set(fiber.HeaderLastModified, entry.lastModified.Format(http.TimeFormat))
+	if entry.expires.IsZero() {
+		c.Set(fiber.HeaderCacheControl, "private, no-cache")
+	} else {
+		maxAge := int(entry.expires.Sub(now) / time.Second)
+		c.Set(fiber.HeaderCacheControl, "private, max-age="+strconv.Itoa(maxAge))
+	}
+
+	if entry.fresh(c) {
+		c.Response().ResetBody()
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	c.Set(fiber.HeaderContentType, entry.contentType)
+	c.Status(fiber.StatusOK)
+	return c.Send(entry.body)
+}
@@ -0,0 +1,143 @@
+package middleware_test
+
+import (
+	"main/middleware"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ResponseCache", func() {
+	var (
+		app   *fiber.App
+		now   time.Time
+		calls int
+	)
+
+	BeforeEach(func() {
+		now = time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+		calls = 0
+
+		responses := middleware.NewResponseCache(middleware.ResponseCacheConfig{
+			MaxEntries: 2,
+			Now:        func() time.Time { return now },
+		})
+		authenticate := func(c *fiber.Ctx) error {
+			c.Locals("user", &jwt.Token{Claims: jwt.MapClaims{"sub": c.Get("X-User")}})
+			return c.Next()
+		}
+		hour := func(now time.Time) time.Time { return now.Add(time.Hour) }
+
+		app = fiber.New()
+		app.Get("/measurements/:id", authenticate, responses.Cache(hour), func(c *fiber.Ctx) error {
+			calls++
+			return c.JSON(fiber.Map{"id": c.Params("id"), "calls": calls})
+		})
+		app.Get("/missing", authenticate, responses.Cache(hour), func(c *fiber.Ctx) error {
+			calls++
+			return fiber.ErrNotFound
+		})
+		app.Patch("/measurements/:id", authenticate, responses.Invalidate(), func(c *fiber.Ctx) error {
+			return c.SendStatus(fiber.StatusNoContent)
+		})
+		// measurements of a shared portfolio are seen by every member
+		members := func(c *fiber.Ctx) []string { return []string{"alice", "bob"} }
+		app.Put("/measurements/:id", authenticate, responses.InvalidateFor(members), func(c *fiber.Ctx) error {
+			return c.SendStatus(fiber.StatusNoContent)
+		})
+	})
+
+	request := func(method, path, user string, header http.Header) *http.Response {
+		req := httptest.NewRequest(method, path, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		req.Header.Set("X-User", user)
+		resp, err := app.Test(req)
+		Expect(err).To(BeNil())
+		return resp
+	}
+
+	It("should serve repeated requests from the cache", func() {
+		first := request("GET", "/measurements/1", "alice", nil)
+		Expect(first.StatusCode).To(Equal(fiber.StatusOK))
+		Expect(first.Header.Get("ETag")).NotTo(BeEmpty())
+		Expect(first.Header.Get("Cache-Control")).To(Equal("private, max-age=3600"))
+
+		now = now.Add(30 * time.Minute)
+		second := request("GET", "/measurements/1", "alice", nil)
+		Expect(second.Header.Get("ETag")).To(Equal(first.Header.Get("ETag")))
+		Expect(second.Header.Get("Cache-Control")).To(Equal("private, max-age=1800"))
+		Expect(calls).To(Equal(1))
+	})
+
+	It("should answer conditional requests with 304", func() {
+		first := request("GET", "/measurements/1", "alice", nil)
+
+		resp := request("GET", "/measurements/1", "alice", http.Header{"If-None-Match": {first.Header.Get("ETag")}})
+		Expect(resp.StatusCode).To(Equal(fiber.StatusNotModified))
+
+		resp = request("GET", "/measurements/1", "alice", http.Header{"If-None-Match": {`"stale"`}})
+		Expect(resp.StatusCode).To(Equal(fiber.StatusOK))
+
+		resp = request("GET", "/measurements/1", "alice", http.Header{"If-Modified-Since": {first.Header.Get("Last-Modified")}})
+		Expect(resp.StatusCode).To(Equal(fiber.StatusNotModified))
+		Expect(calls).To(Equal(1))
+	})
+
+	It("should compute the response again once it expires", func() {
+		request("GET", "/measurements/1", "alice", nil)
+		now = now.Add(time.Hour)
+		request("GET", "/measurements/1", "alice", nil)
+		Expect(calls).To(Equal(2))
+	})
+
+	It("should cache responses for each user", func() {
+		request("GET", "/measurements/1", "alice", nil)
+		request("GET", "/measurements/1", "bob", nil)
+		Expect(calls).To(Equal(2))
+	})
+
+	It("should not cache errors", func() {
+		request("GET", "/missing", "alice", nil)
+		resp := request("GET", "/missing", "alice", nil)
+		Expect(resp.StatusCode).To(Equal(fiber.StatusNotFound))
+		Expect(calls).To(Equal(2))
+	})
+
+	It("should invalidate a user's responses when they change data", func() {
+		request("GET", "/measurements/1", "alice", nil)
+		request("GET", "/measurements/1", "bob", nil)
+		Expect(request("PATCH", "/measurements/1", "alice", nil).StatusCode).To(Equal(fiber.StatusNoContent))
+
+		request("GET", "/measurements/1", "alice", nil)
+		request("GET", "/measurements/1", "bob", nil)
+		Expect(calls).To(Equal(3))
+	})
+
+	It("should invalidate the responses of every member a change is shared with", func() {
+		request("GET", "/measurements/1", "alice", nil)
+		request("GET", "/measurements/1", "bob", nil)
+		Expect(request("PUT", "/measurements/1", "bob", nil).StatusCode).To(Equal(fiber.StatusNoContent))
+
+		resp := request("GET", "/measurements/1", "alice", nil)
+		Expect(resp.StatusCode).To(Equal(fiber.StatusOK))
+		request("GET", "/measurements/1", "bob", nil)
+		Expect(calls).To(Equal(4))
+	})
+
+	It("should evict the oldest response when full", func() {
+		for ii := 1; ii <= 3; ii++ {
+			request("GET", "/measurements/"+strconv.Itoa(ii), "alice", nil)
+		}
+		request("GET", "/measurements/3", "alice", nil)
+		request("GET", "/measurements/1", "alice", nil)
+		Expect(calls).To(Equal(4))
+	})
+})
@@ -41,6 +41,12 @@ func NewLogger() fiber.Handler {
 		// Set latency stop time
 		stop = time.Now()
 
+		// reading a streamed body would buffer the whole response
+		numBytesSent := -1
+		if !c.Response().IsBodyStream() {
+			numBytesSent = len(c.Response().Body())
+		}
+
 		entry := log.WithFields(log.Fields{
 			"StatusCode":        c.Response().StatusCode(),
 			"Latency":           stop.Sub(start).Round(time.Millisecond),
@@ -54,7 +60,7 @@ func NewLogger() fiber.Handler {
 			"URL":               c.OriginalURL(),
 			"UserAgent":         c.Get(fiber.HeaderUserAgent),
 			"NumBytesReceived":  len(c.Request().Body()),
-			"NumBytesSent":      len(c.Response().Body()),
+			"NumBytesSent":      numBytesSent,
 			"Route":             c.Route().Path,
 			"RequestBody":       string(c.Body()),
 			"QueryStringParams": c.Request().URI().QueryArgs().String(),
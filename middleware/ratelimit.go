@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// RateLimitConfig the number of requests a user may make in each window. A
+// limit of 0 disables the window.
+type RateLimitConfig struct {
+	PerMinute int
+	PerDay    int
+
+	// Now returns the current time; defaults to time.Now
+	Now func() time.Time
+}
+
+// ComputeRateLimit the quota for compute-heavy routes such as running a
+// strategy. Defaults to 10 requests per minute and 500 per day, which can be
+// changed with RATE_LIMIT_PER_MINUTE and RATE_LIMIT_PER_DAY.
+func ComputeRateLimit() RateLimitConfig {
+	return RateLimitConfig{
+		PerMinute: envInt("RATE_LIMIT_PER_MINUTE", 10),
+		PerDay:    envInt("RATE_LIMIT_PER_DAY", 500),
+	}
+}
+
+func envInt(name string, def int) int {
+	val := os.Getenv(name)
+	if val == "" {
+		return def
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil || n < 0 {
+		log.Warnf("%s must be a non-negative integer, using %d", name, def)
+		return def
+	}
+	return n
+}
+
+// usage requests made by a user in the current windows
+type usage struct {
+	minute      time.Time
+	minuteCount int
+	day         time.Time
+	dayCount    int
+}
+
+// limiter counts requests in fixed one minute and one day (UTC) windows
+type limiter struct {
+	config RateLimitConfig
+
+	mu    sync.Mutex
+	users map[string]*usage
+	day   time.Time
+}
+
+// rateLimitLocal the Locals key of the limiter and key of a request, read by
+// ChargeRequests
+const rateLimitLocal = "rateLimit"
+
+// rateLimitCharge the limiter of a request and the key of its user
+type rateLimitCharge struct {
+	limiter *limiter
+	key     string
+}
+
+// allow count n requests by key; returns the requests remaining in each
+// window and, if the requests are over quota, how long until they may be
+// retried, in which case none are counted
+func (l *limiter) allow(key string, n int) (minuteLeft int, dayLeft int, retry time.Duration) {
+	now := l.config.Now().UTC()
+	minute := now.Truncate(time.Minute)
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// usage from previous days is no longer needed
+	if !day.Equal(l.day) {
+		l.users = make(map[string]*usage)
+		l.day = day
+	}
+
+	u, ok := l.users[key]
+	if !ok {
+		u = &usage{}
+		l.users[key] = u
+	}
+	if !u.minute.Equal(minute) {
+		u.minute, u.minuteCount = minute, 0
+	}
+	if !u.day.Equal(day) {
+		u.day, u.dayCount = day, 0
+	}
+
+	switch {
+	case l.config.PerDay > 0 && u.dayCount+n > l.config.PerDay:
+		retry = day.AddDate(0, 0, 1).Sub(now)
+	case l.config.PerMinute > 0 && u.minuteCount+n > l.config.PerMinute:
+		retry = minute.Add(time.Minute).Sub(now)
+	default:
+		u.minuteCount += n
+		u.dayCount += n
+	}
+
+	return l.config.PerMinute - u.minuteCount, l.config.PerDay - u.dayCount, retry
+}
+
+// RateLimit limit the requests each user makes to the routes sharing the
+// returned handler. Users are identified by the JWT subject, or the client IP
+// for unauthenticated requests, so it must be used after JWTAuth. Responses
+// include the X-RateLimit-* quota headers; requests over quota are rejected
+// with 429 and a Retry-After header.
+func RateLimit(config RateLimitConfig) fiber.Handler {
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+	l := &limiter{
+		config: config,
+		users:  make(map[string]*usage),
+	}
+
+	return func(c *fiber.Ctx) error {
+		key := "ip:" + c.IP()
+		if sub := requestUser(c); sub != "" {
+			key = "user:" + sub
+		}
+
+		charge := &rateLimitCharge{limiter: l, key: key}
+		if ok, err := charge.apply(c, 1); !ok {
+			return err
+		}
+
+		c.Locals(rateLimitLocal, charge)
+		return c.Next()
+	}
+}
+
+// apply count n requests and set the quota headers; returns false after
+// writing a 429 response if they are over quota
+func (r *rateLimitCharge) apply(c *fiber.Ctx, n int) (bool, error) {
+	config := r.limiter.config
+	minuteLeft, dayLeft, retry := r.limiter.allow(r.key, n)
+	if config.PerMinute > 0 {
+		c.Set("X-RateLimit-Limit-Minute", strconv.Itoa(config.PerMinute))
+		c.Set("X-RateLimit-Remaining-Minute", strconv.Itoa(minuteLeft))
+	}
+	if config.PerDay > 0 {
+		c.Set("X-RateLimit-Limit-Day", strconv.Itoa(config.PerDay))
+		c.Set("X-RateLimit-Remaining-Day", strconv.Itoa(dayLeft))
+	}
+
+	if retry > 0 {
+		seconds := int((retry + time.Second - 1) / time.Second)
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+		log.WithFields(log.Fields{
+			"Key":        r.key,
+			"Path":       c.Path(),
+			"Requests":   n,
+			"RetryAfter": seconds,
+		}).Warn("Rate limit exceeded")
+		return false, c.Status(fiber.StatusTooManyRequests).
+			JSON(fiber.Map{"status": "error", "message": "Rate limit exceeded, retry in " + strconv.Itoa(seconds) + " seconds", "data": nil})
+	}
+	return true, nil
+}
+
+// ChargeRequests count n more requests against the rate limit of the
+// request's user, for handlers that do the work of several requests such as
+// strategy batches. If they are over quota none are counted and it returns
+// false after writing the 429 response, which the handler should return.
+// Always succeeds on routes without RateLimit.
+func ChargeRequests(c *fiber.Ctx, n int) (bool, error) {
+	charge, ok := c.Locals(rateLimitLocal).(*rateLimitCharge)
+	if !ok || n <= 0 {
+		return true, nil
+	}
+	return charge.apply(c, n)
+}
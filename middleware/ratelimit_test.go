@@ -0,0 +1,144 @@
+package middleware_test
+
+import (
+	"main/middleware"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RateLimit", func() {
+	var (
+		app *fiber.App
+		now time.Time
+	)
+
+	setup := func(perMinute, perDay int) {
+		app = fiber.New()
+		limit := middleware.RateLimit(middleware.RateLimitConfig{
+			PerMinute: perMinute,
+			PerDay:    perDay,
+			Now:       func() time.Time { return now },
+		})
+		authenticate := func(c *fiber.Ctx) error {
+			if sub := c.Get("X-User"); sub != "" {
+				c.Locals("user", &jwt.Token{Claims: jwt.MapClaims{"sub": sub}})
+			}
+			return c.Next()
+		}
+		app.Post("/run", authenticate, limit, func(c *fiber.Ctx) error {
+			return c.SendString("ok")
+		})
+		// a batch of X-Runs requests
+		app.Post("/batch", authenticate, limit, func(c *fiber.Ctx) error {
+			runs, _ := strconv.Atoi(c.Get("X-Runs"))
+			if ok, err := middleware.ChargeRequests(c, runs-1); !ok {
+				return err
+			}
+			return c.SendString("ok")
+		})
+	}
+
+	batch := func(user string, runs int) (int, string) {
+		req := httptest.NewRequest("POST", "/batch", nil)
+		req.Header.Set("X-User", user)
+		req.Header.Set("X-Runs", strconv.Itoa(runs))
+		resp, err := app.Test(req)
+		Expect(err).To(BeNil())
+		return resp.StatusCode, resp.Header.Get("X-RateLimit-Remaining-Minute")
+	}
+
+	request := func(user string) (int, string, string) {
+		req := httptest.NewRequest("POST", "/run", nil)
+		if user != "" {
+			req.Header.Set("X-User", user)
+		}
+		resp, err := app.Test(req)
+		Expect(err).To(BeNil())
+		return resp.StatusCode, resp.Header.Get("Retry-After"), resp.Header.Get("X-RateLimit-Remaining-Minute")
+	}
+
+	BeforeEach(func() {
+		now = time.Date(2021, time.March, 1, 12, 0, 15, 0, time.UTC)
+	})
+
+	Context("with a per-minute quota", func() {
+		BeforeEach(func() {
+			setup(2, 0)
+		})
+
+		It("should reject requests over quota until the next minute", func() {
+			code, _, remaining := request("alice")
+			Expect(code).To(Equal(fiber.StatusOK))
+			Expect(remaining).To(Equal("1"))
+
+			code, _, remaining = request("alice")
+			Expect(code).To(Equal(fiber.StatusOK))
+			Expect(remaining).To(Equal("0"))
+
+			code, retry, _ := request("alice")
+			Expect(code).To(Equal(fiber.StatusTooManyRequests))
+			Expect(retry).To(Equal("45"))
+
+			now = now.Add(45 * time.Second)
+			code, _, _ = request("alice")
+			Expect(code).To(Equal(fiber.StatusOK))
+		})
+
+		It("should count each request of a batch", func() {
+			code, remaining := batch("alice", 2)
+			Expect(code).To(Equal(fiber.StatusOK))
+			Expect(remaining).To(Equal("0"))
+
+			now = now.Add(time.Minute)
+			code, _ = batch("alice", 3)
+			Expect(code).To(Equal(fiber.StatusTooManyRequests))
+
+			// none of the rejected batch's requests are counted
+			code, _, remaining = request("alice")
+			Expect(code).To(Equal(fiber.StatusOK))
+			Expect(remaining).To(Equal("0"))
+		})
+
+		It("should count each user separately", func() {
+			request("alice")
+			request("alice")
+			code, _, _ := request("bob")
+			Expect(code).To(Equal(fiber.StatusOK))
+		})
+
+		It("should limit unauthenticated requests by IP", func() {
+			request("")
+			request("")
+			code, _, _ := request("")
+			Expect(code).To(Equal(fiber.StatusTooManyRequests))
+		})
+	})
+
+	Context("with a per-day quota", func() {
+		BeforeEach(func() {
+			setup(10, 3)
+		})
+
+		It("should reject requests until the next UTC day", func() {
+			for ii := 0; ii < 3; ii++ {
+				now = now.Add(time.Minute)
+				code, _, _ := request("alice")
+				Expect(code).To(Equal(fiber.StatusOK))
+			}
+
+			code, retry, _ := request("alice")
+			Expect(code).To(Equal(fiber.StatusTooManyRequests))
+			Expect(retry).To(Equal("43005"))
+
+			now = time.Date(2021, time.March, 2, 0, 0, 0, 0, time.UTC)
+			code, _, _ = request("alice")
+			Expect(code).To(Equal(fiber.StatusOK))
+		})
+	})
+})
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"errors"
+	"main/reporting"
+	"main/util"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Recover convert a panic in a later handler into a util.PanicError so it is
+// reported by ErrorHandler instead of crashing the server
+func Recover() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return util.Recover("http", c.Next)
+	}
+}
+
+// ErrorHandler report server errors and panics with the user and route of
+// the request, then respond as fiber.DefaultErrorHandler does. Panics are
+// answered with a generic internal server error.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	code := fiber.StatusInternalServerError
+	var fe *fiber.Error
+	if errors.As(err, &fe) {
+		code = fe.Code
+	}
+
+	if code >= fiber.StatusInternalServerError {
+		reporting.Report(err, requestContext(c))
+	}
+
+	var pe *util.PanicError
+	if errors.As(err, &pe) {
+		err = fiber.ErrInternalServerError
+	}
+	return fiber.DefaultErrorHandler(c, err)
+}
+
+// requestContext the user, portfolio, and strategy a request is for
+func requestContext(c *fiber.Ctx) reporting.Context {
+	ctx := reporting.Context{
+		Stage: "http",
+		Tags: map[string]string{
+			"method": c.Method(),
+			"route":  c.Route().Path,
+		},
+	}
+
+	if user, ok := c.Locals("user").(*jwt.Token); ok {
+		if claims, ok := user.Claims.(jwt.MapClaims); ok {
+			ctx.UserID, _ = claims["sub"].(string)
+		}
+	}
+
+	route := c.Route().Path
+	switch {
+	case strings.Contains(route, "/portfolio/:id"):
+		ctx.PortfolioID = c.Params("id")
+	case strings.Contains(route, "/strategy/:id"):
+		ctx.Strategy = c.Params("id")
+	}
+	return ctx
+}
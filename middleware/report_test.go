@@ -0,0 +1,87 @@
+package middleware_test
+
+import (
+	"errors"
+	"main/middleware"
+	"main/reporting"
+	"main/util"
+	"net/http/httptest"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type reports struct {
+	errs []error
+	ctxs []reporting.Context
+}
+
+func (r *reports) Report(err error, ctx reporting.Context, stack []reporting.Frame) {
+	r.errs = append(r.errs, err)
+	r.ctxs = append(r.ctxs, ctx)
+}
+
+func (r *reports) Flush(timeout time.Duration) bool { return true }
+
+var _ = Describe("ErrorHandler", func() {
+	var (
+		app      *fiber.App
+		reported *reports
+	)
+
+	BeforeEach(func() {
+		reported = &reports{}
+		reporting.SetReporter(reported)
+
+		app = fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler})
+		app.Use(middleware.Recover())
+		authenticate := func(c *fiber.Ctx) error {
+			c.Locals("user", &jwt.Token{Claims: jwt.MapClaims{"sub": "alice"}})
+			return c.Next()
+		}
+		app.Get("/portfolio/:id", authenticate, func(c *fiber.Ctx) error {
+			var values []float64
+			return c.JSON(values[2])
+		})
+		app.Get("/strategy/:id", func(c *fiber.Ctx) error {
+			return fiber.ErrNotFound
+		})
+		app.Get("/fail", func(c *fiber.Ctx) error {
+			return errors.New("database unavailable")
+		})
+	})
+
+	AfterEach(func() {
+		reporting.SetReporter(nil)
+	})
+
+	request := func(path string) int {
+		resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+		Expect(err).To(BeNil())
+		return resp.StatusCode
+	}
+
+	It("should report panics with the user and portfolio", func() {
+		Expect(request("/portfolio/abc")).To(Equal(fiber.StatusInternalServerError))
+		Expect(reported.errs).To(HaveLen(1))
+		var pe *util.PanicError
+		Expect(errors.As(reported.errs[0], &pe)).To(BeTrue())
+		Expect(reported.ctxs[0].UserID).To(Equal("alice"))
+		Expect(reported.ctxs[0].PortfolioID).To(Equal("abc"))
+		Expect(reported.ctxs[0].Tags).To(HaveKeyWithValue("route", "/portfolio/:id"))
+	})
+
+	It("should report server errors", func() {
+		Expect(request("/fail")).To(Equal(fiber.StatusInternalServerError))
+		Expect(reported.errs).To(HaveLen(1))
+		Expect(reported.errs[0]).To(MatchError("database unavailable"))
+	})
+
+	It("should not report client errors", func() {
+		Expect(request("/strategy/adm")).To(Equal(fiber.StatusNotFound))
+		Expect(reported.errs).To(BeEmpty())
+	})
+})
@@ -0,0 +1,110 @@
+package notification
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// SendGrid event types recorded against the notification history
+const (
+	EventDelivered  = "delivered"
+	EventBounce     = "bounce"
+	EventOpen       = "open"
+	EventSpamReport = "spamreport"
+	EventDropped    = "dropped"
+)
+
+// Event a SendGrid event webhook notification
+type Event struct {
+	Email     string `json:"email"`
+	Timestamp int64  `json:"timestamp"`
+	Event     string `json:"event"`
+	EventID   string `json:"sg_event_id"`
+	MessageID string `json:"sg_message_id"`
+	Reason    string `json:"reason"`
+	Type      string `json:"type"`
+}
+
+// ParseEvents parse the body of a SendGrid event webhook request
+func ParseEvents(body []byte) ([]Event, error) {
+	events := []Event{}
+	if err := json.Unmarshal(body, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Time when the event occurred
+func (e *Event) Time() time.Time {
+	return time.Unix(e.Timestamp, 0)
+}
+
+// SendMessageID id returned by the SendGrid mail send API for the message
+// the event refers to. SendGrid appends a filter suffix to the message id
+// in events, e.g. "14c5d75ce93.dfd.64b469.filter0001.16648.5515E0B88.0"
+func (e *Event) SendMessageID() string {
+	if idx := strings.Index(e.MessageID, "."); idx >= 0 {
+		return e.MessageID[:idx]
+	}
+	return e.MessageID
+}
+
+// HardBounce true if the address permanently rejected the message. SendGrid
+// reports temporary rejections as bounces with a type of "blocked".
+func (e *Event) HardBounce() bool {
+	return e.Event == EventBounce && e.Type != "blocked"
+}
+
+// Suppress true if the address should not receive further email
+func (e *Event) Suppress() bool {
+	return e.HardBounce() || e.Event == EventSpamReport
+}
+
+// VerifyWebhook check the signature SendGrid attached to an event webhook
+// request. publicKey is the base64 encoded verification key from the SendGrid
+// mail settings; signature and timestamp are the values of the
+// X-Twilio-Email-Event-Webhook-Signature and -Timestamp headers.
+func VerifyWebhook(publicKey string, signature string, timestamp string, body []byte) error {
+	if publicKey == "" {
+		return errors.New("webhook verification key is not configured")
+	}
+
+	der, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil {
+		return errors.New("malformed webhook verification key")
+	}
+	parsed, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return errors.New("malformed webhook verification key")
+	}
+	key, ok := parsed.(*ecdsa.PublicKey)
+	if !ok {
+		return errors.New("webhook verification key is not an ECDSA key")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return errors.New("malformed webhook signature")
+	}
+	var rs struct {
+		R, S *big.Int
+	}
+	if _, err := asn1.Unmarshal(sig, &rs); err != nil {
+		return errors.New("malformed webhook signature")
+	}
+
+	digest := sha256.Sum256(append([]byte(timestamp), body...))
+	if !ecdsa.Verify(key, digest[:], rs.R, rs.S) {
+		return errors.New("invalid webhook signature")
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package notification_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"main/notification"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Event", func() {
+	var (
+		body      []byte
+		publicKey string
+		signature string
+		timestamp string
+	)
+
+	BeforeEach(func() {
+		body = []byte(`[
+			{"email": "a@example.com", "timestamp": 1600000000, "event": "delivered", "sg_event_id": "e1", "sg_message_id": "14c5d75ce93.filter0001.16648.5515E0B88.0"},
+			{"email": "b@example.com", "timestamp": 1600000001, "event": "bounce", "type": "bounce", "reason": "550 5.1.1 no such user", "sg_event_id": "e2", "sg_message_id": "24c5d75ce93.filter0001"},
+			{"email": "c@example.com", "timestamp": 1600000002, "event": "bounce", "type": "blocked", "sg_event_id": "e3", "sg_message_id": "34c5d75ce93"},
+			{"email": "d@example.com", "timestamp": 1600000003, "event": "spamreport", "sg_event_id": "e4", "sg_message_id": "44c5d75ce93.filter0001"}
+		]`)
+
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).To(BeNil())
+		der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		Expect(err).To(BeNil())
+		publicKey = base64.StdEncoding.EncodeToString(der)
+
+		timestamp = "1600000010"
+		digest := sha256.Sum256(append([]byte(timestamp), body...))
+		sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+		Expect(err).To(BeNil())
+		signature = base64.StdEncoding.EncodeToString(sig)
+	})
+
+	Describe("When parsing webhook events", func() {
+		It("should classify events", func() {
+			events, err := notification.ParseEvents(body)
+			Expect(err).To(BeNil())
+			Expect(events).To(HaveLen(4))
+
+			Expect(events[0].SendMessageID()).To(Equal("14c5d75ce93"))
+			Expect(events[0].Suppress()).To(BeFalse())
+			Expect(events[1].HardBounce()).To(BeTrue())
+			Expect(events[1].Suppress()).To(BeTrue())
+			Expect(events[2].HardBounce()).To(BeFalse())
+			Expect(events[2].SendMessageID()).To(Equal("34c5d75ce93"))
+			Expect(events[3].Suppress()).To(BeTrue())
+			Expect(events[3].Time().Unix()).To(Equal(int64(1600000003)))
+		})
+	})
+
+	Describe("When verifying webhook signatures", func() {
+		It("should accept a valid signature", func() {
+			Expect(notification.VerifyWebhook(publicKey, signature, timestamp, body)).To(Succeed())
+		})
+
+		It("should reject a modified body", func() {
+			err := notification.VerifyWebhook(publicKey, signature, timestamp, append(body, ' '))
+			Expect(err).To(MatchError("invalid webhook signature"))
+		})
+
+		It("should reject a modified timestamp", func() {
+			err := notification.VerifyWebhook(publicKey, signature, "1600000011", body)
+			Expect(err).To(MatchError("invalid webhook signature"))
+		})
+
+		It("should require a verification key", func() {
+			err := notification.VerifyWebhook("", signature, timestamp, body)
+			Expect(err).To(MatchError("webhook verification key is not configured"))
+		})
+	})
+})
@@ -0,0 +1,145 @@
+package notification
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Notification frequencies stored in a portfolio's notifications bitmask
+const (
+	Daily    = 0x00000010
+	Weekly   = 0x00000100
+	Monthly  = 0x00001000
+	Annually = 0x00010000
+)
+
+// FrequencyAll unsubscribe from every notification frequency
+const FrequencyAll = "all"
+
+// Frequencies bitmask flag of each notification frequency by name
+var Frequencies = map[string]int{
+	"daily":    Daily,
+	"weekly":   Weekly,
+	"monthly":  Monthly,
+	"annually": Annually,
+}
+
+// Unsubscribe a request to stop notifications of a given frequency for a
+// portfolio, carried in a signed link
+type Unsubscribe struct {
+	PortfolioID uuid.UUID
+	Frequency   string
+}
+
+// Mask bitmask flags cleared by the unsubscribe request
+func (u *Unsubscribe) Mask() int {
+	if u.Frequency == FrequencyAll {
+		return Daily | Weekly | Monthly | Annually
+	}
+	return Frequencies[u.Frequency]
+}
+
+// Apply clear the flags of the unsubscribed frequency from notifications
+func (u *Unsubscribe) Apply(notifications int) int {
+	return notifications &^ u.Mask()
+}
+
+// Token sign the unsubscribe request with secret so it can be embedded in a
+// link and verified without the user logging in
+func (u *Unsubscribe) Token(secret string) (string, error) {
+	if secret == "" {
+		return "", errors.New("unsubscribe secret is not configured")
+	}
+	frequency := strings.ToLower(u.Frequency)
+	if _, ok := Frequencies[frequency]; !ok && frequency != FrequencyAll {
+		return "", fmt.Errorf("unknown notification frequency '%s'", u.Frequency)
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString([]byte(u.PortfolioID.String() + ":" + frequency))
+	return payload + "." + sign(payload, secret), nil
+}
+
+// URL link to base that unsubscribes when followed
+func (u *Unsubscribe) URL(base string, secret string) (string, error) {
+	token, err := u.Token(secret)
+	if err != nil {
+		return "", err
+	}
+	return base + "?token=" + url.QueryEscape(token), nil
+}
+
+// ParseUnsubscribe verify the signature of an unsubscribe token and return
+// the request it carries
+func ParseUnsubscribe(token string, secret string) (Unsubscribe, error) {
+	u := Unsubscribe{}
+	if secret == "" {
+		return u, errors.New("unsubscribe secret is not configured")
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return u, errors.New("malformed unsubscribe token")
+	}
+	if !hmac.Equal([]byte(parts[1]), []byte(sign(parts[0], secret))) {
+		return u, errors.New("invalid unsubscribe token signature")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return u, errors.New("malformed unsubscribe token")
+	}
+	fields := strings.Split(string(payload), ":")
+	if len(fields) != 2 {
+		return u, errors.New("malformed unsubscribe token")
+	}
+
+	u.PortfolioID, err = uuid.Parse(fields[0])
+	if err != nil {
+		return u, errors.New("malformed unsubscribe token")
+	}
+	u.Frequency = fields[1]
+	if _, ok := Frequencies[u.Frequency]; !ok && u.Frequency != FrequencyAll {
+		return u, fmt.Errorf("unknown notification frequency '%s'", u.Frequency)
+	}
+
+	return u, nil
+}
+
+// FrequencyFlags the frequencies enabled in a notifications bitmask by name
+func FrequencyFlags(notifications int) map[string]bool {
+	flags := make(map[string]bool, len(Frequencies))
+	for name, mask := range Frequencies {
+		flags[name] = notifications&mask == mask
+	}
+	return flags
+}
+
+// SetFrequencyFlags update notifications with the frequencies in flags;
+// frequencies not in flags are unchanged
+func SetFrequencyFlags(notifications int, flags map[string]bool) (int, error) {
+	for name, enabled := range flags {
+		mask, ok := Frequencies[name]
+		if !ok {
+			return notifications, fmt.Errorf("unknown notification frequency '%s'", name)
+		}
+		if enabled {
+			notifications |= mask
+		} else {
+			notifications &^= mask
+		}
+	}
+	return notifications, nil
+}
+
+func sign(payload string, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
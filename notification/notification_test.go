@@ -0,0 +1,99 @@
+package notification_test
+
+import (
+	"main/notification"
+	"strings"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Notification", func() {
+	var (
+		portfolioID uuid.UUID
+		unsubscribe notification.Unsubscribe
+	)
+
+	BeforeEach(func() {
+		portfolioID = uuid.MustParse("6f0c1b8e-6c0a-4c8e-9e35-0a1b2c3d4e5f")
+		unsubscribe = notification.Unsubscribe{
+			PortfolioID: portfolioID,
+			Frequency:   "weekly",
+		}
+	})
+
+	Describe("When signing unsubscribe links", func() {
+		It("should round trip a signed token", func() {
+			token, err := unsubscribe.Token("secret")
+			Expect(err).To(BeNil())
+
+			parsed, err := notification.ParseUnsubscribe(token, "secret")
+			Expect(err).To(BeNil())
+			Expect(parsed).To(Equal(unsubscribe))
+		})
+
+		It("should reject tokens signed with another secret", func() {
+			token, err := unsubscribe.Token("secret")
+			Expect(err).To(BeNil())
+
+			_, err = notification.ParseUnsubscribe(token, "other")
+			Expect(err).To(MatchError("invalid unsubscribe token signature"))
+		})
+
+		It("should reject tampered tokens", func() {
+			token, err := unsubscribe.Token("secret")
+			Expect(err).To(BeNil())
+
+			other := notification.Unsubscribe{PortfolioID: portfolioID, Frequency: notification.FrequencyAll}
+			otherToken, err := other.Token("secret")
+			Expect(err).To(BeNil())
+
+			forged := strings.Split(otherToken, ".")[0] + "." + strings.Split(token, ".")[1]
+			_, err = notification.ParseUnsubscribe(forged, "secret")
+			Expect(err).To(MatchError("invalid unsubscribe token signature"))
+		})
+
+		It("should reject unknown frequencies", func() {
+			unsubscribe.Frequency = "hourly"
+			_, err := unsubscribe.Token("secret")
+			Expect(err).To(MatchError("unknown notification frequency 'hourly'"))
+		})
+
+		It("should require a secret", func() {
+			_, err := unsubscribe.Token("")
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("should build an unsubscribe url", func() {
+			link, err := unsubscribe.URL("https://example.com/v1/unsubscribe", "secret")
+			Expect(err).To(BeNil())
+			Expect(link).To(HavePrefix("https://example.com/v1/unsubscribe?token="))
+		})
+	})
+
+	Describe("When updating the notifications bitmask", func() {
+		It("should clear a single frequency", func() {
+			notifications := notification.Daily | notification.Weekly | notification.Monthly
+			Expect(unsubscribe.Apply(notifications)).To(Equal(notification.Daily | notification.Monthly))
+		})
+
+		It("should clear all frequencies", func() {
+			unsubscribe.Frequency = notification.FrequencyAll
+			notifications := notification.Daily | notification.Weekly | notification.Monthly | notification.Annually
+			Expect(unsubscribe.Apply(notifications)).To(Equal(0))
+		})
+
+		It("should report and set frequency flags", func() {
+			flags := notification.FrequencyFlags(notification.Monthly)
+			Expect(flags).To(Equal(map[string]bool{"daily": false, "weekly": false, "monthly": true, "annually": false}))
+
+			notifications, err := notification.SetFrequencyFlags(notification.Monthly, map[string]bool{"daily": true, "monthly": false})
+			Expect(err).To(BeNil())
+			Expect(notifications).To(Equal(notification.Daily))
+
+			_, err = notification.SetFrequencyFlags(0, map[string]bool{"hourly": true})
+			Expect(err).To(MatchError("unknown notification frequency 'hourly'"))
+		})
+	})
+})
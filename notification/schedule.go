@@ -0,0 +1,59 @@
+package notification
+
+import "time"
+
+// MarketTimezone timezone of the exchanges that portfolios are priced on;
+// a trading day is complete once it is over in this timezone
+const MarketTimezone = "America/New_York"
+
+// DeliveryHour local hour that periodic notifications are delivered at
+const DeliveryHour = 7
+
+// PricesAvailableHour hour in MarketTimezone by which end of day prices for
+// the trading day are available
+const PricesAvailableHour = 18
+
+// Yesterday the calendar date before now in tz, at midnight UTC
+func Yesterday(now time.Time, tz *time.Location) time.Time {
+	year, month, day := now.In(tz).AddDate(0, 0, -1).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// DeliveryTime when a notification about the trading day forDate should be
+// delivered to a user in tz. Notifications are delivered at DeliveryHour on
+// the user's first local day after forDate, i.e. once forDate is yesterday
+// for the user, or immediately if that time has already passed.
+func DeliveryTime(forDate time.Time, now time.Time, tz *time.Location) time.Time {
+	year, month, day := forDate.Date()
+	deliver := time.Date(year, month, day+1, DeliveryHour, 0, 0, 0, tz)
+	if deliver.Before(now) {
+		return now
+	}
+	return deliver
+}
+
+// NextPricesAvailable when end of day prices for the next trading day after
+// now become available; PricesAvailableHour on the next weekday in tz.
+// Holidays are not skipped.
+func NextPricesAvailable(now time.Time, tz *time.Location) time.Time {
+	year, month, day := now.In(tz).Date()
+	next := time.Date(year, month, day, PricesAvailableHour, 0, 0, 0, tz)
+	for !next.After(now) || next.Weekday() == time.Saturday || next.Weekday() == time.Sunday {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// IsLastTradingDayOfMonth true if date is the last weekday of its month, the
+// day monthly strategies rebalance on. Holidays are not skipped.
+func IsLastTradingDayOfMonth(date time.Time) bool {
+	if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+		return false
+	}
+	for next := date.AddDate(0, 0, 1); next.Month() == date.Month(); next = next.AddDate(0, 0, 1) {
+		if next.Weekday() != time.Saturday && next.Weekday() != time.Sunday {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+package notification_test
+
+import (
+	"main/notification"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Schedule", func() {
+	var (
+		newYork  *time.Location
+		tokyo    *time.Location
+		honolulu *time.Location
+		forDate  time.Time
+	)
+
+	BeforeEach(func() {
+		var err error
+		newYork, err = time.LoadLocation("America/New_York")
+		Expect(err).To(BeNil())
+		tokyo, err = time.LoadLocation("Asia/Tokyo")
+		Expect(err).To(BeNil())
+		honolulu, err = time.LoadLocation("Pacific/Honolulu")
+		Expect(err).To(BeNil())
+
+		forDate = time.Date(2021, time.March, 15, 0, 0, 0, 0, time.UTC)
+	})
+
+	Describe("When computing yesterday", func() {
+		It("should use the calendar date in the timezone", func() {
+			// 01:00 UTC on the 16th is still the 15th in New York
+			now := time.Date(2021, time.March, 16, 1, 0, 0, 0, time.UTC)
+			Expect(notification.Yesterday(now, newYork)).To(Equal(time.Date(2021, time.March, 14, 0, 0, 0, 0, time.UTC)))
+			Expect(notification.Yesterday(now, tokyo)).To(Equal(time.Date(2021, time.March, 15, 0, 0, 0, 0, time.UTC)))
+		})
+	})
+
+	Describe("When scheduling delivery", func() {
+		It("should deliver immediately once the window has opened", func() {
+			// 06:00 in New York on the 16th is 19:00 in Tokyo
+			now := time.Date(2021, time.March, 16, 6, 0, 0, 0, newYork)
+			Expect(notification.DeliveryTime(forDate, now, tokyo)).To(Equal(now))
+		})
+
+		It("should wait for the local delivery hour", func() {
+			// 06:00 in New York on the 16th is 00:00 in Honolulu
+			now := time.Date(2021, time.March, 16, 6, 0, 0, 0, newYork)
+			deliver := notification.DeliveryTime(forDate, now, honolulu)
+			Expect(deliver.In(honolulu)).To(Equal(time.Date(2021, time.March, 16, notification.DeliveryHour, 0, 0, 0, honolulu)))
+		})
+
+		It("should deliver old notifications immediately", func() {
+			now := time.Date(2021, time.April, 1, 12, 0, 0, 0, time.UTC)
+			Expect(notification.DeliveryTime(forDate, now, newYork)).To(Equal(now))
+		})
+	})
+
+	Describe("When computing when prices are available", func() {
+		It("should wait for the hour on the same trading day", func() {
+			now := time.Date(2021, time.March, 16, 12, 0, 0, 0, newYork)
+			Expect(notification.NextPricesAvailable(now, newYork)).To(Equal(time.Date(2021, time.March, 16, notification.PricesAvailableHour, 0, 0, 0, newYork)))
+		})
+
+		It("should move to the next trading day after the hour", func() {
+			now := time.Date(2021, time.March, 16, notification.PricesAvailableHour, 0, 0, 0, newYork)
+			Expect(notification.NextPricesAvailable(now, newYork)).To(Equal(time.Date(2021, time.March, 17, notification.PricesAvailableHour, 0, 0, 0, newYork)))
+		})
+
+		It("should skip weekends", func() {
+			// 21:00 Friday in New York
+			now := time.Date(2021, time.March, 20, 1, 0, 0, 0, time.UTC)
+			Expect(notification.NextPricesAvailable(now, newYork)).To(Equal(time.Date(2021, time.March, 22, notification.PricesAvailableHour, 0, 0, 0, newYork)))
+		})
+	})
+	Describe("When checking for the last trading day of the month", func() {
+		It("should be the last weekday of the month", func() {
+			Expect(notification.IsLastTradingDayOfMonth(time.Date(2021, time.March, 31, 0, 0, 0, 0, time.UTC))).To(BeTrue())
+			Expect(notification.IsLastTradingDayOfMonth(time.Date(2021, time.March, 30, 0, 0, 0, 0, time.UTC))).To(BeFalse())
+		})
+
+		It("should skip weekends at the end of the month", func() {
+			// July 31, 2021 is a Saturday
+			Expect(notification.IsLastTradingDayOfMonth(time.Date(2021, time.July, 30, 0, 0, 0, 0, time.UTC))).To(BeTrue())
+			Expect(notification.IsLastTradingDayOfMonth(time.Date(2021, time.July, 31, 0, 0, 0, 0, time.UTC))).To(BeFalse())
+		})
+	})
+})
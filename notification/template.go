@@ -0,0 +1,218 @@
+package notification
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"main/locale"
+	"main/preferences"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/google/uuid"
+)
+
+// Kinds of notification email
+const (
+	KindPortfolio = "portfolio"
+	KindAlert     = "alert"
+	KindHousehold = "household"
+	KindRevision  = "revision"
+
+	// KindVerification asks the user to verify their email address
+	KindVerification = "verification"
+)
+
+// DefaultLocale locale used when no template matches the user's locale
+const DefaultLocale = locale.DefaultTag
+
+// Template how a notification is rendered: either a SendGrid dynamic
+// template or an inline Go template rendered locally. Frequency is empty for
+// templates that apply to every frequency.
+type Template struct {
+	ID                 uuid.UUID `json:"id"`
+	Kind               string    `json:"kind"`
+	Channel            string    `json:"channel"`
+	Frequency          string    `json:"frequency"`
+	Locale             string    `json:"locale"`
+	SendGridTemplateID string    `json:"sendgridTemplateId"`
+	Subject            string    `json:"subject"`
+	Body               string    `json:"body"`
+}
+
+// Validate check that the template is well formed and its inline templates
+// parse. Frequencies are normalized to lower case.
+func (t *Template) Validate() error {
+	switch t.Kind {
+	case KindPortfolio, KindAlert, KindHousehold, KindRevision, KindVerification:
+	default:
+		return fmt.Errorf("unknown notification kind '%s'", t.Kind)
+	}
+
+	known := false
+	for _, channel := range preferences.Channels {
+		known = known || channel == t.Channel
+	}
+	if !known {
+		return fmt.Errorf("unknown notification channel '%s'", t.Channel)
+	}
+
+	t.Frequency = strings.ToLower(t.Frequency)
+	if _, ok := Frequencies[t.Frequency]; !ok && t.Frequency != "" {
+		return fmt.Errorf("unknown notification frequency '%s'", t.Frequency)
+	}
+
+	if t.Locale == "" {
+		return errors.New("templates require a locale")
+	}
+
+	if t.SendGridTemplateID == "" {
+		if t.Subject == "" || t.Body == "" {
+			return errors.New("templates require a SendGrid template id or an inline subject and body")
+		}
+		funcs := locale.Get(t.Locale).Funcs()
+		if _, err := texttemplate.New("subject").Funcs(funcs).Parse(t.Subject); err != nil {
+			return fmt.Errorf("invalid subject template: %s", err)
+		}
+		if _, err := htmltemplate.New("body").Funcs(funcs).Parse(t.Body); err != nil {
+			return fmt.Errorf("invalid body template: %s", err)
+		}
+	}
+
+	return nil
+}
+
+// Render execute the inline subject and HTML body templates with data.
+// Templates translate messages from the locale's catalog with the T
+// function, e.g. {{T "label.ytdReturn"}}
+func (t *Template) Render(data map[string]interface{}, loc *locale.Locale) (string, string, error) {
+	funcs := loc.Funcs()
+	subjectTmpl, err := texttemplate.New("subject").Funcs(funcs).Parse(t.Subject)
+	if err != nil {
+		return "", "", err
+	}
+	bodyTmpl, err := htmltemplate.New("body").Funcs(funcs).Parse(t.Body)
+	if err != nil {
+		return "", "", err
+	}
+
+	var subject bytes.Buffer
+	if err := subjectTmpl.Execute(&subject, data); err != nil {
+		return "", "", err
+	}
+	var body bytes.Buffer
+	if err := bodyTmpl.Execute(&body, data); err != nil {
+		return "", "", err
+	}
+
+	return strings.TrimSpace(subject.String()), body.String(), nil
+}
+
+// SelectTemplate choose the template for a notification. Templates for the
+// exact frequency are preferred to those for every frequency, and templates
+// for the user's locale to those for the default locale. The built-in
+// templates are translated into every supported locale, so they are preferred
+// to default locale templates for users of another supported locale.
+func SelectTemplate(templates []Template, kind string, channel string, frequency string, tag string) Template {
+	frequency = strings.ToLower(frequency)
+	tags := []string{tag}
+	if tag != DefaultLocale && !locale.IsSupported(tag) {
+		tags = append(tags, DefaultLocale)
+	}
+	for _, loc := range tags {
+		for _, freq := range []string{frequency, ""} {
+			for _, t := range templates {
+				if t.Kind == kind && t.Channel == channel && t.Frequency == freq && t.Locale == loc {
+					return t
+				}
+			}
+		}
+	}
+
+	for _, t := range DefaultTemplates {
+		if t.Kind == kind {
+			return t
+		}
+	}
+	return Template{Kind: kind, Channel: channel, Locale: DefaultLocale}
+}
+
+// DefaultTemplates built-in templates rendered locally when no template is
+// configured in the database. Labels are translated with the locale's
+// message catalog.
+var DefaultTemplates = []Template{
+	{
+		Kind:    KindPortfolio,
+		Channel: preferences.ChannelEmail,
+		Locale:  DefaultLocale,
+		Subject: `{{T "subject.portfolio" .}}`,
+		Body: `<html><body>
+<h2>{{.portfolioName}}</h2>
+<p>{{T "heading.portfolio" .}}{{if .strategy}} ({{.strategy}}){{end}}</p>
+<table>
+<tr><td>{{T "label.currentAsset"}}</td><td>{{.currentAsset}}</td></tr>
+<tr><td>{{T "label.periodReturn"}}</td><td>{{.periodReturn}}</td></tr>
+<tr><td>{{T "label.ytdReturn"}}</td><td>{{.ytdReturn}}</td></tr>
+</table>
+{{if .unsubscribeUrl}}<p><a href="{{.preferencesUrl}}">{{T "link.preferences"}}</a> | <a href="{{.unsubscribeUrl}}">{{T "link.unsubscribe"}}</a></p>{{end}}
+</body></html>`,
+	},
+	{
+		Kind:    KindAlert,
+		Channel: preferences.ChannelEmail,
+		Locale:  DefaultLocale,
+		Subject: `{{T "subject.alert" .}}`,
+		Body: `<html><body>
+<h2>{{T "heading.alert" .}}</h2>
+{{if .portfolioName}}<p>{{T "label.portfolio"}}: {{.portfolioName}}</p>{{end}}
+<p>{{.message}}</p>
+<p>{{T "label.value"}}: {{.value}}</p>
+</body></html>`,
+	},
+	{
+		Kind:    KindHousehold,
+		Channel: preferences.ChannelEmail,
+		Locale:  DefaultLocale,
+		Subject: `{{T "subject.household" .}}`,
+		Body: `<html><body>
+<h2>{{T "heading.household" .}}</h2>
+<table>
+<tr><td>{{T "label.totalValue"}}</td><td>{{.totalValue}}</td></tr>
+<tr><td>{{T "label.periodReturn"}}</td><td>{{.periodReturn}}</td></tr>
+<tr><td>{{T "label.ytdReturn"}}</td><td>{{.ytdReturn}}</td></tr>
+<tr><td>{{T "label.overlap"}}</td><td>{{.overlap}}</td></tr>
+</table>
+<h3>{{T "label.portfolios"}}</h3>
+<ul>{{range .portfolios}}<li>{{.}}</li>{{end}}</ul>
+<h3>{{T "label.holdings"}}</h3>
+<table>{{range .holdings}}<tr><td>{{.ticker}}</td><td>{{.percent}}</td></tr>{{end}}</table>
+</body></html>`,
+	},
+	{
+		Kind:    KindRevision,
+		Channel: preferences.ChannelEmail,
+		Locale:  DefaultLocale,
+		Subject: `{{T "subject.revision" .}}`,
+		Body: `<html><body>
+<h2>{{.portfolioName}}</h2>
+<p>{{T "heading.revision" .}}</p>
+<table>
+<tr><th>{{T "label.date"}}</th><th>{{T "label.ticker"}}</th><th>{{T "label.transaction"}}</th><th>{{T "label.oldValue"}}</th><th>{{T "label.newValue"}}</th></tr>
+{{range .changes}}<tr><td>{{.date}}</td><td>{{.ticker}}</td><td>{{.kind}}</td><td>{{.oldValue}}</td><td>{{.newValue}}</td></tr>
+{{end}}</table>
+{{if .moreChanges}}<p>{{T "label.moreChanges" .}}</p>{{end}}
+</body></html>`,
+	},
+	{
+		Kind:    KindVerification,
+		Channel: preferences.ChannelEmail,
+		Locale:  DefaultLocale,
+		Subject: `{{T "subject.verification"}}`,
+		Body: `<html><body>
+<h2>{{T "subject.verification"}}</h2>
+<p>{{T "heading.verification" .}}</p>
+<p><a href="{{.verifyUrl}}">{{T "link.verify"}}</a></p>
+</body></html>`,
+	},
+}
@@ -0,0 +1,120 @@
+package notification_test
+
+import (
+	"main/locale"
+	"main/notification"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Template", func() {
+	var templates []notification.Template
+
+	BeforeEach(func() {
+		templates = []notification.Template{
+			{Kind: notification.KindPortfolio, Channel: "email", Locale: "en-US", SendGridTemplateID: "d-default"},
+			{Kind: notification.KindPortfolio, Channel: "email", Frequency: "weekly", Locale: "en-US", SendGridTemplateID: "d-weekly"},
+			{Kind: notification.KindPortfolio, Channel: "email", Locale: "de-DE", Subject: "{{.portfolioName}}", Body: "<p>{{.ytdReturn}}</p>"},
+		}
+	})
+
+	Describe("When selecting a template", func() {
+		It("should prefer the exact frequency", func() {
+			t := notification.SelectTemplate(templates, notification.KindPortfolio, "email", "Weekly", "en-US")
+			Expect(t.SendGridTemplateID).To(Equal("d-weekly"))
+		})
+
+		It("should fall back to the template for every frequency", func() {
+			t := notification.SelectTemplate(templates, notification.KindPortfolio, "email", "Monthly", "en-US")
+			Expect(t.SendGridTemplateID).To(Equal("d-default"))
+		})
+
+		It("should prefer the user's locale", func() {
+			t := notification.SelectTemplate(templates, notification.KindPortfolio, "email", "Weekly", "de-DE")
+			Expect(t.Locale).To(Equal("de-DE"))
+		})
+
+		It("should fall back to the default locale", func() {
+			t := notification.SelectTemplate(templates, notification.KindPortfolio, "email", "Monthly", "fr-FR")
+			Expect(t.SendGridTemplateID).To(Equal("d-default"))
+		})
+
+		It("should prefer the translated built-in template to another locale", func() {
+			t := notification.SelectTemplate(templates, notification.KindPortfolio, "email", "Monthly", "de-DE")
+			Expect(t.Locale).To(Equal("de-DE"))
+
+			t = notification.SelectTemplate(templates[:2], notification.KindPortfolio, "email", "Monthly", "de-DE")
+			Expect(t.SendGridTemplateID).To(BeEmpty())
+			Expect(t.Body).NotTo(BeEmpty())
+		})
+
+		It("should fall back to the built-in template", func() {
+			t := notification.SelectTemplate(templates, notification.KindAlert, "email", "", "en-US")
+			Expect(t.SendGridTemplateID).To(BeEmpty())
+			Expect(t.Body).NotTo(BeEmpty())
+		})
+	})
+
+	Describe("When rendering a template", func() {
+		It("should render the subject and escape the body", func() {
+			subject, body, err := templates[2].Render(map[string]interface{}{
+				"portfolioName": "Retirement",
+				"ytdReturn":     "<b>+5.00%</b>",
+			}, locale.Get("de-DE"))
+			Expect(err).To(BeNil())
+			Expect(subject).To(Equal("Retirement"))
+			Expect(body).To(Equal("<p>&lt;b&gt;&#43;5.00%&lt;/b&gt;</p>"))
+		})
+
+		It("should render every built-in template", func() {
+			data := map[string]interface{}{
+				"portfolioName": "Retirement",
+				"frequency":     "Monthly",
+				"message":       "VFINX fell 5%",
+				"portfolios":    []string{"Retirement", "College"},
+				"holdings":      []map[string]string{{"ticker": "VFINX", "percent": "60.00%"}},
+			}
+			for _, t := range notification.DefaultTemplates {
+				Expect(t.Validate()).To(Succeed(), t.Kind)
+				subject, _, err := t.Render(data, locale.Get("en-US"))
+				Expect(err).To(BeNil(), t.Kind)
+				Expect(subject).NotTo(BeEmpty(), t.Kind)
+			}
+		})
+
+		It("should translate built-in templates", func() {
+			data := map[string]interface{}{
+				"portfolioName": "Rente",
+				"frequency":     "Monatliche",
+				"forDate":       "31.03.2021",
+			}
+			t := notification.SelectTemplate(nil, notification.KindPortfolio, "email", "monthly", "de-DE")
+			subject, body, err := t.Render(data, locale.Get("de-DE"))
+			Expect(err).To(BeNil())
+			Expect(subject).To(Equal("Monatliche Übersicht für Rente"))
+			Expect(body).To(ContainSubstring("Rendite seit Jahresbeginn"))
+			Expect(body).To(ContainSubstring("Monatliche Übersicht vom 31.03.2021"))
+		})
+	})
+
+	Describe("When validating a template", func() {
+		It("should require a SendGrid id or inline templates", func() {
+			t := notification.Template{Kind: notification.KindAlert, Channel: "email", Locale: "en-US"}
+			Expect(t.Validate()).To(MatchError("templates require a SendGrid template id or an inline subject and body"))
+		})
+
+		It("should reject templates that do not parse", func() {
+			t := notification.Template{Kind: notification.KindAlert, Channel: "email", Locale: "en-US", Subject: "Alert", Body: "{{.message"}
+			Expect(t.Validate()).NotTo(Succeed())
+		})
+
+		It("should reject unknown kinds and frequencies", func() {
+			t := notification.Template{Kind: "sms", Channel: "email", Locale: "en-US", SendGridTemplateID: "d-1"}
+			Expect(t.Validate()).To(MatchError("unknown notification kind 'sms'"))
+
+			t = notification.Template{Kind: notification.KindAlert, Channel: "email", Frequency: "Hourly", Locale: "en-US", SendGridTemplateID: "d-1"}
+			Expect(t.Validate()).To(MatchError("unknown notification frequency 'hourly'"))
+		})
+	})
+})
@@ -0,0 +1,105 @@
+package organization
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Roles of the members of an organization
+const (
+	// RoleViewer may view the portfolios of the organization
+	RoleViewer = "viewer"
+
+	// RoleEditor may also create, change, and delete portfolios of the
+	// organization
+	RoleEditor = "editor"
+
+	// RoleAdmin may also manage the members of the organization and delete it
+	RoleAdmin = "admin"
+)
+
+// maxNameLength longest organization name accepted
+const maxNameLength = 100
+
+// Organization a group of users, such as an advisor and their clients or an
+// investment club, that share portfolios
+type Organization struct {
+	ID      uuid.UUID `json:"id"`
+	Name    string    `json:"name"`
+	Created int64     `json:"created"`
+
+	// Role of the user the organization was retrieved for
+	Role string `json:"role,omitempty"`
+}
+
+// Member a user that belongs to an organization. Members with Notify set
+// receive the notifications of the organization's portfolios.
+type Member struct {
+	OrgID   uuid.UUID `json:"-"`
+	UserID  string    `json:"userId"`
+	Name    string    `json:"name"`
+	Email   string    `json:"email"`
+	Role    string    `json:"role"`
+	Notify  bool      `json:"notify"`
+	Created int64     `json:"created"`
+}
+
+// Validate check that the organization is well formed
+func (o *Organization) Validate() error {
+	o.Name = strings.TrimSpace(o.Name)
+	if o.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(o.Name) > maxNameLength {
+		return errors.New("name must be at most 100 characters")
+	}
+	return nil
+}
+
+// ValidRole true if role is one of the member roles
+func ValidRole(role string) bool {
+	switch role {
+	case RoleViewer, RoleEditor, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+// CanEdit true if members with role may change the organization's portfolios
+func CanEdit(role string) bool {
+	return role == RoleEditor || role == RoleAdmin
+}
+
+// CanManage true if members with role may manage the organization's members
+func CanManage(role string) bool {
+	return role == RoleAdmin
+}
+
+// LastAdmin true if userID is the only admin among members, so removing them
+// or changing their role would leave the organization without an admin
+func LastAdmin(members []*Member, userID string) bool {
+	found := false
+	for _, m := range members {
+		if m.Role != RoleAdmin {
+			continue
+		}
+		if m.UserID != userID {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+// Recipients the ids of members that receive notifications
+func Recipients(members []*Member) []string {
+	ids := []string{}
+	for _, m := range members {
+		if m.Notify {
+			ids = append(ids, m.UserID)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,73 @@
+package organization_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"main/organization"
+)
+
+var _ = Describe("Organization", func() {
+	Describe("When validating an organization", func() {
+		It("should trim the name", func() {
+			o := organization.Organization{Name: "  Investment Club "}
+			Expect(o.Validate()).To(Succeed())
+			Expect(o.Name).To(Equal("Investment Club"))
+		})
+
+		It("should require a name", func() {
+			o := organization.Organization{Name: " "}
+			Expect(o.Validate()).NotTo(Succeed())
+		})
+
+		It("should reject long names", func() {
+			o := organization.Organization{Name: strings.Repeat("a", 101)}
+			Expect(o.Validate()).NotTo(Succeed())
+		})
+	})
+
+	Describe("When checking roles", func() {
+		It("should only allow editors and admins to edit", func() {
+			Expect(organization.CanEdit(organization.RoleViewer)).To(BeFalse())
+			Expect(organization.CanEdit(organization.RoleEditor)).To(BeTrue())
+			Expect(organization.CanEdit(organization.RoleAdmin)).To(BeTrue())
+			Expect(organization.CanEdit("")).To(BeFalse())
+		})
+
+		It("should only allow admins to manage members", func() {
+			Expect(organization.CanManage(organization.RoleEditor)).To(BeFalse())
+			Expect(organization.CanManage(organization.RoleAdmin)).To(BeTrue())
+		})
+
+		It("should accept only known roles", func() {
+			Expect(organization.ValidRole(organization.RoleViewer)).To(BeTrue())
+			Expect(organization.ValidRole("owner")).To(BeFalse())
+		})
+	})
+
+	Describe("When listing members", func() {
+		var members []*organization.Member
+
+		BeforeEach(func() {
+			members = []*organization.Member{
+				{UserID: "advisor", Role: organization.RoleAdmin, Notify: true},
+				{UserID: "client1", Role: organization.RoleViewer, Notify: true},
+				{UserID: "client2", Role: organization.RoleViewer, Notify: false},
+			}
+		})
+
+		It("should find the last admin", func() {
+			Expect(organization.LastAdmin(members, "advisor")).To(BeTrue())
+			Expect(organization.LastAdmin(members, "client1")).To(BeFalse())
+
+			members[1].Role = organization.RoleAdmin
+			Expect(organization.LastAdmin(members, "advisor")).To(BeFalse())
+		})
+
+		It("should notify members that opted in", func() {
+			Expect(organization.Recipients(members)).To(Equal([]string{"advisor", "client1"}))
+		})
+	})
+})
@@ -0,0 +1,26 @@
+package portfolio
+
+import "sort"
+
+// Annotation a dated label shown on the timeline of a performance chart,
+// e.g. a note from the portfolio's decision journal
+type Annotation struct {
+	Time   int64  `json:"time"`
+	Text   string `json:"text"`
+	Ticker string `json:"ticker,omitempty"`
+}
+
+// Annotate attach the annotations that fall within the period of the
+// performance, ordered by time
+func (perf *Performance) Annotate(annotations []Annotation) {
+	perf.Annotations = make([]Annotation, 0, len(annotations))
+	for _, a := range annotations {
+		if a.Time < perf.PeriodStart || (perf.PeriodEnd != 0 && a.Time > perf.PeriodEnd) {
+			continue
+		}
+		perf.Annotations = append(perf.Annotations, a)
+	}
+	sort.SliceStable(perf.Annotations, func(i, j int) bool {
+		return perf.Annotations[i].Time < perf.Annotations[j].Time
+	})
+}
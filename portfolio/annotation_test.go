@@ -0,0 +1,28 @@
+package portfolio_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"main/portfolio"
+)
+
+var _ = Describe("Annotation", func() {
+	It("should attach annotations within the period in time order", func() {
+		perf := portfolio.Performance{
+			PeriodStart: 100,
+			PeriodEnd:   300,
+		}
+		perf.Annotate([]portfolio.Annotation{
+			{Time: 250, Text: "rebalanced late"},
+			{Time: 50, Text: "before the portfolio started"},
+			{Time: 100, Text: "skipped the trade", Ticker: "VFINX"},
+			{Time: 400, Text: "after the period"},
+		})
+
+		Expect(perf.Annotations).To(Equal([]portfolio.Annotation{
+			{Time: 100, Text: "skipped the trade", Ticker: "VFINX"},
+			{Time: 250, Text: "rebalanced late"},
+		}))
+	})
+})
@@ -0,0 +1,73 @@
+package portfolio
+
+import (
+	"math"
+	"time"
+)
+
+// RebalanceBands tolerance around the target weights within which the
+// portfolio is left to drift. A date of the target portfolio is only traded
+// when a holding's weight differs from its target by more than Absolute
+// percentage points or Relative percent of the target weight, e.g. the
+// common 5/25 rule. A band of 0 is not checked.
+type RebalanceBands struct {
+	Absolute float64 `json:"absolute,omitempty"`
+	Relative float64 `json:"relative,omitempty"`
+}
+
+// active check if either band is set
+func (b RebalanceBands) active() bool {
+	return b.Absolute > 0 || b.Relative > 0
+}
+
+// exceeded check if current drifted outside the bands around target
+func (b RebalanceBands) exceeded(current, target float64) bool {
+	drift := math.Abs(current - target)
+	if b.Absolute > 0 && drift > b.Absolute/100.0 {
+		return true
+	}
+	return b.Relative > 0 && target != 0 && drift/math.Abs(target) > b.Relative/100.0
+}
+
+// withinBands check if the holdings of the portfolio valued on date are
+// within the rebalance bands of target. Cash is the remainder of the other
+// weights so it is not checked separately.
+func (p *Portfolio) withinBands(date time.Time, target map[string]float64) bool {
+	if len(p.Holdings) == 0 {
+		return false
+	}
+
+	values := make(map[string]float64, len(p.Holdings))
+	total := 0.0
+	for k, v := range p.Holdings {
+		if k == "$CASH" {
+			total += v
+			continue
+		}
+		price, ok := p.priceOn(k, date)
+		if !ok {
+			return false
+		}
+		values[k] = v * price
+		total += v * price
+	}
+	if total <= 0 {
+		return false
+	}
+
+	for k, v := range values {
+		// positions that are no longer targeted are always sold
+		weight, ok := target[k]
+		if (!ok && math.Abs(p.Holdings[k]) > 1.0e-5) || p.RebalanceBands.exceeded(v/total, weight) {
+			return false
+		}
+	}
+	// and new positions are always bought
+	for k, v := range target {
+		if _, ok := values[k]; !ok && k != "$CASH" && v != 0 {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,65 @@
+package portfolio
+
+// MeasurementColumns measurements encoded as one array per field rather than
+// an object per measurement, which avoids repeating the field names in every
+// row of long daily histories. Leverage and AfterTaxValue are omitted when no
+// measurement sets them.
+type MeasurementColumns struct {
+	Time          []int64                  `json:"time"`
+	Value         []float64                `json:"value"`
+	RiskFreeValue []float64                `json:"riskFreeValue"`
+	Holdings      []string                 `json:"holdings"`
+	PercentReturn []float64                `json:"percentReturn"`
+	Leverage      []float64                `json:"leverage,omitempty"`
+	AfterTaxValue []float64                `json:"afterTaxValue,omitempty"`
+	Justification []map[string]interface{} `json:"justification"`
+}
+
+// ColumnarPerformance performance with its measurements encoded as columns
+type ColumnarPerformance struct {
+	Performance
+	Measurements MeasurementColumns `json:"measurements"`
+}
+
+// Columns encode measurements as columns
+func Columns(measurements []PerformanceMeasurement) MeasurementColumns {
+	n := len(measurements)
+	cols := MeasurementColumns{
+		Time:          make([]int64, n),
+		Value:         make([]float64, n),
+		RiskFreeValue: make([]float64, n),
+		Holdings:      make([]string, n),
+		PercentReturn: make([]float64, n),
+		Justification: make([]map[string]interface{}, n),
+	}
+
+	leverage := make([]float64, n)
+	afterTax := make([]float64, n)
+	for ii, m := range measurements {
+		cols.Time[ii] = m.Time
+		cols.Value[ii] = m.Value
+		cols.RiskFreeValue[ii] = m.RiskFreeValue
+		cols.Holdings[ii] = m.Holdings
+		cols.PercentReturn[ii] = m.PercentReturn
+		cols.Justification[ii] = m.Justification
+
+		leverage[ii] = m.Leverage
+		if m.Leverage != 0 {
+			cols.Leverage = leverage
+		}
+		afterTax[ii] = m.AfterTaxValue
+		if m.AfterTaxValue != 0 {
+			cols.AfterTaxValue = afterTax
+		}
+	}
+
+	return cols
+}
+
+// Columnar performance with its measurements encoded as columns
+func (perf *Performance) Columnar() ColumnarPerformance {
+	return ColumnarPerformance{
+		Performance:  *perf,
+		Measurements: Columns(perf.Measurements),
+	}
+}
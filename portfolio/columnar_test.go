@@ -0,0 +1,52 @@
+package portfolio_test
+
+import (
+	"encoding/json"
+	"main/portfolio"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Columnar", func() {
+	var perf portfolio.Performance
+
+	BeforeEach(func() {
+		perf = portfolio.Performance{
+			PeriodStart: 100,
+			PeriodEnd:   200,
+			Measurements: []portfolio.PerformanceMeasurement{
+				{Time: 100, Value: 10000, RiskFreeValue: 10000, Holdings: "VFINX", Justification: map[string]interface{}{"score": 1.0}},
+				{Time: 200, Value: 10500, RiskFreeValue: 10010, Holdings: "VUSTX", PercentReturn: 0.05, AfterTaxValue: 10400},
+			},
+		}
+	})
+
+	Describe("When encoding measurements as columns", func() {
+		It("should have one array per field", func() {
+			cols := portfolio.Columns(perf.Measurements)
+			Expect(cols.Time).To(Equal([]int64{100, 200}))
+			Expect(cols.Value).To(Equal([]float64{10000, 10500}))
+			Expect(cols.Holdings).To(Equal([]string{"VFINX", "VUSTX"}))
+			Expect(cols.PercentReturn).To(Equal([]float64{0, 0.05}))
+			Expect(cols.AfterTaxValue).To(Equal([]float64{0, 10400}))
+			Expect(cols.Justification[0]).To(HaveKeyWithValue("score", 1.0))
+		})
+
+		It("should omit fields that no measurement sets", func() {
+			cols := portfolio.Columns(perf.Measurements)
+			Expect(cols.Leverage).To(BeNil())
+		})
+
+		It("should replace the measurements of the performance", func() {
+			buf, err := json.Marshal(perf.Columnar())
+			Expect(err).To(BeNil())
+
+			decoded := map[string]interface{}{}
+			Expect(json.Unmarshal(buf, &decoded)).To(Succeed())
+			Expect(decoded).To(HaveKeyWithValue("periodStart", 100.0))
+			Expect(decoded["measurements"]).To(HaveKeyWithValue("time", []interface{}{100.0, 200.0}))
+			Expect(decoded["measurements"]).NotTo(HaveKey("leverage"))
+		})
+	})
+})
@@ -0,0 +1,192 @@
+package portfolio
+
+import (
+	"math"
+	"time"
+
+	"gonum.org/v1/gonum/stat"
+)
+
+// ComparisonMember statistics of one portfolio over the aligned period
+type ComparisonMember struct {
+	ID          string        `json:"id"`
+	Name        string        `json:"name"`
+	TotalReturn float64       `json:"totalReturn"`
+	Cagr        float64       `json:"cagr"`
+	MaxDrawDown float64       `json:"maxDrawDown"`
+	Metrics     MetricsBundle `json:"metrics"`
+}
+
+// Comparison side-by-side view of several portfolios over the period that
+// all of them were measured. Series are in the same order as Portfolios.
+type Comparison struct {
+	Portfolios []ComparisonMember `json:"portfolios"`
+	Times      []int64            `json:"times"`
+
+	// EquityCurves growth of 1.0 invested at the start of the aligned period
+	EquityCurves [][]float64 `json:"equityCurves"`
+
+	// DrawDowns decline of each equity curve from its prior peak
+	DrawDowns [][]float64 `json:"drawDowns"`
+
+	// RelativeDrawDowns decline of each equity curve relative to the first
+	// portfolio from the prior peak of their ratio; the first is all zeros
+	RelativeDrawDowns [][]float64 `json:"relativeDrawDowns"`
+
+	// Correlation matrix of monthly returns
+	Correlation [][]float64 `json:"correlation"`
+}
+
+// growthIndex cumulative growth of the portfolio keyed by measurement time;
+// deposits and withdrawals do not affect the index
+func growthIndex(perf *Performance) map[int64]float64 {
+	index := make(map[int64]float64, len(perf.Measurements))
+	growth := 1.0
+	for ii, m := range perf.Measurements {
+		if ii > 0 {
+			growth *= 1.0 + m.PercentReturn
+		}
+		index[m.Time] = growth
+	}
+	return index
+}
+
+// drawDownSeries decline of each value from the highest preceding value
+func drawDownSeries(values []float64) []float64 {
+	dd := make([]float64, len(values))
+	peak := math.Inf(-1)
+	for ii, v := range values {
+		peak = math.Max(peak, v)
+		if peak > 0 {
+			dd[ii] = v/peak - 1.0
+		}
+	}
+	return dd
+}
+
+// monthlyReturns return between the last value of each calendar month
+func monthlyReturns(times []int64, values []float64) []float64 {
+	rets := []float64{}
+	var last float64
+	var lastMonth time.Month
+	var lastYear int
+	for ii, t := range times {
+		dt := time.Unix(t, 0).UTC()
+		endOfMonth := ii == len(times)-1
+		if !endOfMonth {
+			next := time.Unix(times[ii+1], 0).UTC()
+			endOfMonth = next.Month() != dt.Month() || next.Year() != dt.Year()
+		}
+		if !endOfMonth {
+			continue
+		}
+
+		if last != 0 && (dt.Month() != lastMonth || dt.Year() != lastYear) {
+			rets = append(rets, values[ii]/last-1.0)
+		}
+		last = values[ii]
+		lastMonth = dt.Month()
+		lastYear = dt.Year()
+	}
+	return rets
+}
+
+// Compare align the performance of several portfolios on the measurement
+// times they have in common and compare them side-by-side
+func Compare(members []HouseholdMember) Comparison {
+	comparison := Comparison{
+		Portfolios:        make([]ComparisonMember, len(members)),
+		Times:             []int64{},
+		EquityCurves:      make([][]float64, len(members)),
+		DrawDowns:         make([][]float64, len(members)),
+		RelativeDrawDowns: make([][]float64, len(members)),
+		Correlation:       make([][]float64, len(members)),
+	}
+
+	indexes := make([]map[int64]float64, len(members))
+	for ii, member := range members {
+		indexes[ii] = growthIndex(member.Performance)
+	}
+
+	// times measured by every portfolio
+	if len(members) > 0 {
+		for _, m := range members[0].Performance.Measurements {
+			common := true
+			for _, index := range indexes[1:] {
+				if _, ok := index[m.Time]; !ok {
+					common = false
+					break
+				}
+			}
+			if common {
+				comparison.Times = append(comparison.Times, m.Time)
+			}
+		}
+	}
+
+	monthly := make([][]float64, len(members))
+	for ii, member := range members {
+		curve := make([]float64, len(comparison.Times))
+		for jj, t := range comparison.Times {
+			curve[jj] = indexes[ii][t] / indexes[ii][comparison.Times[0]]
+		}
+		comparison.EquityCurves[ii] = curve
+		comparison.DrawDowns[ii] = drawDownSeries(curve)
+		monthly[ii] = monthlyReturns(comparison.Times, curve)
+
+		stats := ComparisonMember{
+			ID:   member.ID,
+			Name: member.Name,
+		}
+		if len(curve) > 0 {
+			stats.TotalReturn = curve[len(curve)-1] - 1.0
+			years := float64(comparison.Times[len(curve)-1]-comparison.Times[0]) / (365.25 * 86400.0)
+			if years > 0 {
+				stats.Cagr = math.Pow(curve[len(curve)-1], 1.0/years) - 1.0
+			}
+			for _, dd := range comparison.DrawDowns[ii] {
+				stats.MaxDrawDown = math.Min(stats.MaxDrawDown, dd)
+			}
+		}
+
+		// metrics are calculated over the aligned period only
+		if len(comparison.Times) > 1 {
+			trimmed := Performance{
+				PeriodStart: comparison.Times[0],
+				PeriodEnd:   comparison.Times[len(comparison.Times)-1],
+			}
+			for _, m := range member.Performance.Measurements {
+				if m.Time >= trimmed.PeriodStart && m.Time <= trimmed.PeriodEnd {
+					trimmed.Measurements = append(trimmed.Measurements, m)
+				}
+			}
+			trimmed.Measurements[0].PercentReturn = 0
+			trimmed.BuildMetricsBundle()
+			stats.Metrics = trimmed.MetricsBundle
+		}
+
+		comparison.Portfolios[ii] = stats
+	}
+
+	for ii := range members {
+		relative := make([]float64, len(comparison.Times))
+		for jj := range comparison.Times {
+			relative[jj] = comparison.EquityCurves[ii][jj] / comparison.EquityCurves[0][jj]
+		}
+		comparison.RelativeDrawDowns[ii] = drawDownSeries(relative)
+
+		comparison.Correlation[ii] = make([]float64, len(members))
+		for jj := range members {
+			corr := 1.0
+			if ii != jj {
+				corr = stat.Correlation(monthly[ii], monthly[jj], nil)
+			}
+			if len(monthly[ii]) < 2 || math.IsNaN(corr) {
+				corr = 0
+			}
+			comparison.Correlation[ii][jj] = corr
+		}
+	}
+
+	return comparison
+}
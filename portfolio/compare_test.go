@@ -0,0 +1,115 @@
+package portfolio_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"main/portfolio"
+)
+
+var _ = Describe("Compare", func() {
+	var (
+		perf1 portfolio.Performance
+		perf2 portfolio.Performance
+	)
+
+	monthEnd := func(month time.Month) int64 {
+		return time.Date(2020, month+1, 0, 0, 0, 0, 0, time.UTC).Unix()
+	}
+
+	BeforeEach(func() {
+		perf1 = portfolio.Performance{
+			Measurements: []portfolio.PerformanceMeasurement{
+				{Time: monthEnd(time.January), Value: 1000, RiskFreeValue: 1000},
+				{Time: monthEnd(time.February), Value: 1100, RiskFreeValue: 1000, PercentReturn: 0.10},
+				{Time: monthEnd(time.March), Value: 990, RiskFreeValue: 1000, PercentReturn: -0.10},
+				{Time: monthEnd(time.April), Value: 1089, RiskFreeValue: 1000, PercentReturn: 0.10},
+			},
+		}
+
+		// starts a month later and has deposits that do not affect returns
+		perf2 = portfolio.Performance{
+			Measurements: []portfolio.PerformanceMeasurement{
+				{Time: monthEnd(time.February), Value: 5000, RiskFreeValue: 5000},
+				{Time: monthEnd(time.March), Value: 10000, RiskFreeValue: 5000, PercentReturn: -0.05},
+				{Time: monthEnd(time.April), Value: 10500, RiskFreeValue: 5000, PercentReturn: 0.05},
+			},
+		}
+	})
+
+	Describe("When comparing portfolios", func() {
+		It("should align equity curves", func() {
+			comparison := portfolio.Compare([]portfolio.HouseholdMember{
+				{ID: "a", Name: "Portfolio A", Performance: &perf1},
+				{ID: "b", Name: "Portfolio B", Performance: &perf2},
+			})
+
+			Expect(comparison.Times).To(HaveLen(3))
+			Expect(comparison.EquityCurves[0][0]).Should(BeNumerically("~", 1.0, 1e-9))
+			Expect(comparison.EquityCurves[0][2]).Should(BeNumerically("~", 0.99, 1e-9))
+			Expect(comparison.EquityCurves[1][0]).Should(BeNumerically("~", 1.0, 1e-9))
+			Expect(comparison.EquityCurves[1][2]).Should(BeNumerically("~", 0.9975, 1e-9))
+
+			Expect(comparison.Portfolios[0].MaxDrawDown).Should(BeNumerically("~", -0.10, 1e-9))
+			Expect(comparison.Portfolios[1].TotalReturn).Should(BeNumerically("~", -0.0025, 1e-9))
+		})
+
+		It("should calculate relative draw downs", func() {
+			comparison := portfolio.Compare([]portfolio.HouseholdMember{
+				{ID: "a", Name: "Portfolio A", Performance: &perf1},
+				{ID: "b", Name: "Portfolio B", Performance: &perf2},
+			})
+
+			Expect(comparison.RelativeDrawDowns[0]).To(Equal([]float64{0, 0, 0}))
+			Expect(comparison.RelativeDrawDowns[1][1]).Should(BeNumerically("~", 0, 1e-9))
+			Expect(comparison.RelativeDrawDowns[1][2]).Should(BeNumerically("~", 0.9975/0.99*0.9/0.95-1.0, 1e-9))
+		})
+
+		It("should correlate monthly returns", func() {
+			comparison := portfolio.Compare([]portfolio.HouseholdMember{
+				{ID: "a", Name: "Portfolio A", Performance: &perf1},
+				{ID: "b", Name: "Portfolio B", Performance: &perf2},
+			})
+
+			Expect(comparison.Correlation[0][0]).Should(BeNumerically("~", 1.0, 1e-9))
+			Expect(comparison.Correlation[0][1]).Should(BeNumerically("~", 1.0, 1e-9))
+			Expect(comparison.Correlation[1][0]).Should(BeNumerically("~", 1.0, 1e-9))
+		})
+	})
+
+	Describe("When ranking a comparison", func() {
+		It("should order portfolios best first", func() {
+			comparison := portfolio.Compare([]portfolio.HouseholdMember{
+				{ID: "a", Name: "Portfolio A", Performance: &perf1},
+				{ID: "b", Name: "Portfolio B", Performance: &perf2},
+			})
+
+			ranked, err := comparison.Rank(portfolio.RankCagr)
+			Expect(err).To(BeNil())
+			Expect(ranked).To(HaveLen(2))
+			Expect(ranked[0].ID).To(Equal("b"))
+			Expect(ranked[0].Rank).To(Equal(1))
+			Expect(ranked[1].ID).To(Equal("a"))
+			Expect(ranked[1].Rank).To(Equal(2))
+
+			// the smaller draw down ranks first
+			ranked, err = comparison.Rank(portfolio.RankMaxDrawDown)
+			Expect(err).To(BeNil())
+			Expect(ranked[0].ID).To(Equal("b"))
+
+			ranked, err = comparison.Rank(portfolio.RankPainRatio)
+			Expect(err).To(BeNil())
+			Expect(ranked).To(HaveLen(2))
+		})
+
+		It("should reject unknown metrics", func() {
+			comparison := portfolio.Compare([]portfolio.HouseholdMember{
+				{ID: "a", Name: "Portfolio A", Performance: &perf1},
+			})
+			_, err := comparison.Rank("alpha")
+			Expect(err).NotTo(BeNil())
+		})
+	})
+})
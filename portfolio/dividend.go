@@ -0,0 +1,322 @@
+package portfolio
+
+import (
+	"errors"
+	"fmt"
+	"main/data"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+// Distribution a dividend or other cash distribution of a security. Amount
+// is per share; Shares and TotalValue are those of the portfolio, which are
+// zero for distributions of securities that were not held.
+type Distribution struct {
+	Ticker     string    `json:"ticker"`
+	ExDate     time.Time `json:"exDate"`
+	Amount     float64   `json:"amount"`
+	Shares     float64   `json:"shares"`
+	TotalValue float64   `json:"totalValue"`
+
+	// Estimated an upcoming distribution projected from the same
+	// distribution a year earlier
+	Estimated bool `json:"estimated,omitempty"`
+}
+
+// MonthlyIncome projected distributions paid in a calendar month
+type MonthlyIncome struct {
+	Month  string  `json:"month"`
+	Income float64 `json:"income"`
+}
+
+// DividendCalendar distributions of the securities held by a portfolio
+type DividendCalendar struct {
+	AsOf time.Time `json:"asOf"`
+
+	// Historical distributions received while each security was held
+	Historical []Distribution `json:"historical"`
+
+	// Upcoming distributions over the next 12 months estimated from the
+	// trailing 12 months of distributions of the current holdings; the
+	// price data has no declared future distributions
+	Upcoming        []Distribution  `json:"upcoming"`
+	ProjectedIncome float64         `json:"projectedIncome"`
+	ProjectedMonths []MonthlyIncome `json:"projectedMonths"`
+}
+
+// DistributionsFromDataFrame extract the distributions from a dataframe
+// containing the dividend cash of symbol for each trading day
+func DistributionsFromDataFrame(df *dataframe.DataFrame, symbol string) ([]Distribution, error) {
+	distributions := []Distribution{}
+	iterator := df.ValuesIterator(dataframe.ValuesOptions{InitialRow: 0, Step: 1, DontReadLock: false})
+	for {
+		row, vals, _ := iterator(dataframe.SeriesName)
+		if row == nil {
+			break
+		}
+
+		date, ok := vals[data.DateIdx].(time.Time)
+		if !ok {
+			return nil, errors.New("dividend dataframe is missing the date column")
+		}
+
+		amount, ok := vals[symbol].(float64)
+		if !ok {
+			return nil, fmt.Errorf("dividend dataframe is missing the %s column", symbol)
+		}
+
+		if math.IsNaN(amount) || amount <= 0 {
+			continue
+		}
+
+		distributions = append(distributions, Distribution{
+			Ticker: symbol,
+			ExDate: date,
+			Amount: amount,
+		})
+	}
+
+	return distributions, nil
+}
+
+// BuildDividendCalendar the distributions of each security received by a
+// ledger of (sorted) transactions through asOf, and those projected for the
+// 12 months after asOf from the holdings on asOf. A distribution is
+// received for the shares held at the close before its ex-date.
+func BuildDividendCalendar(trxs []Transaction, distributions map[string][]Distribution, asOf time.Time) DividendCalendar {
+	calendar := DividendCalendar{
+		AsOf:            asOf,
+		Historical:      []Distribution{},
+		Upcoming:        []Distribution{},
+		ProjectedMonths: []MonthlyIncome{},
+	}
+
+	start := asOf
+	if len(trxs) > 0 {
+		start = trxs[0].Date
+	}
+
+	current := LedgerHoldings(trxs, asOf)
+	trailing := asOf.AddDate(-1, 0, 0)
+	months := make(map[string]float64)
+
+	for ticker, tickerDistributions := range distributions {
+		for _, dist := range tickerDistributions {
+			if dist.ExDate.After(asOf) {
+				continue
+			}
+
+			if dist.ExDate.After(start) {
+				shares := LedgerHoldings(trxs, dist.ExDate.AddDate(0, 0, -1))[ticker]
+				if shares > 1.0e-5 {
+					received := dist
+					received.Ticker = ticker
+					received.Shares = shares
+					received.TotalValue = shares * dist.Amount
+					calendar.Historical = append(calendar.Historical, received)
+				}
+			}
+
+			// each distribution of the trailing year is expected to recur
+			shares := current[ticker]
+			if !dist.ExDate.After(trailing) || shares <= 1.0e-5 {
+				continue
+			}
+			upcoming := Distribution{
+				Ticker:     ticker,
+				ExDate:     dist.ExDate.AddDate(1, 0, 0),
+				Amount:     dist.Amount,
+				Shares:     shares,
+				TotalValue: shares * dist.Amount,
+				Estimated:  true,
+			}
+			calendar.Upcoming = append(calendar.Upcoming, upcoming)
+			calendar.ProjectedIncome += upcoming.TotalValue
+			months[upcoming.ExDate.Format("2006-01")] += upcoming.TotalValue
+		}
+	}
+
+	byDate := func(dists []Distribution) {
+		sort.Slice(dists, func(i, j int) bool {
+			if dists[i].ExDate.Equal(dists[j].ExDate) {
+				return dists[i].Ticker < dists[j].Ticker
+			}
+			return dists[i].ExDate.Before(dists[j].ExDate)
+		})
+	}
+	byDate(calendar.Historical)
+	byDate(calendar.Upcoming)
+
+	for month, income := range months {
+		calendar.ProjectedMonths = append(calendar.ProjectedMonths, MonthlyIncome{
+			Month:  month,
+			Income: income,
+		})
+	}
+	sort.Slice(calendar.ProjectedMonths, func(i, j int) bool {
+		return calendar.ProjectedMonths[i].Month < calendar.ProjectedMonths[j].Month
+	})
+
+	return calendar
+}
+
+// DividendCalendar download the dividend history of every security held by
+// the portfolio and build its dividend calendar as of asOf
+func (p *Portfolio) DividendCalendar(asOf time.Time) (DividendCalendar, error) {
+	tickerSet := make(map[string]bool)
+	for _, trx := range p.Transactions {
+		if trx.Kind == BuyTransaction && !trx.Date.After(asOf) {
+			tickerSet[trx.Ticker] = true
+		}
+	}
+
+	if len(tickerSet) == 0 {
+		return BuildDividendCalendar(p.Transactions, nil, asOf), nil
+	}
+
+	symbols := make([]string, 0, len(tickerSet))
+	for k := range tickerSet {
+		symbols = append(symbols, k)
+	}
+
+	// the trailing year is needed for the projection even if the portfolio
+	// is younger
+	begin := p.StartTime
+	if trailing := asOf.AddDate(-1, 0, 0); trailing.Before(begin) {
+		begin = trailing
+	}
+
+	distributions, err := p.distributions(symbols, begin, asOf)
+	if err != nil {
+		return DividendCalendar{}, err
+	}
+
+	return BuildDividendCalendar(p.Transactions, distributions, asOf), nil
+}
+
+// InsertDividendTransactions replay the (sorted) transactions and insert a
+// dividend transaction for each distribution of a security that is held at
+// the close before its ex-date. Short positions pay the distribution, which
+// is recorded as a negative value. Existing dividend transactions are
+// replaced.
+func InsertDividendTransactions(trxs []Transaction, distributions map[string][]Distribution) []Transaction {
+	pending := []Distribution{}
+	for ticker, tickerDistributions := range distributions {
+		for _, dist := range tickerDistributions {
+			dist.Ticker = ticker
+			pending = append(pending, dist)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		if pending[i].ExDate.Equal(pending[j].ExDate) {
+			return pending[i].Ticker < pending[j].Ticker
+		}
+		return pending[i].ExDate.Before(pending[j].ExDate)
+	})
+
+	final := make([]Transaction, 0, len(trxs)+len(pending))
+	holdings := make(map[string]float64)
+	distIdx := 0
+	emitDividends := func(through time.Time) {
+		for ; distIdx < len(pending); distIdx++ {
+			dist := pending[distIdx]
+			if dist.ExDate.After(through) {
+				return
+			}
+
+			held := holdings[dist.Ticker]
+			if math.Abs(held) <= 1.0e-5 {
+				continue
+			}
+
+			trx := Transaction{
+				Date:          dist.ExDate,
+				Ticker:        dist.Ticker,
+				Kind:          DividendTransaction,
+				Shares:        held,
+				PricePerShare: dist.Amount,
+				TotalValue:    held * dist.Amount,
+			}
+			applyTransaction(holdings, &trx)
+			final = append(final, trx)
+		}
+	}
+
+	for _, trx := range trxs {
+		if trx.Kind == DividendTransaction {
+			continue
+		}
+		// trades on the ex-date are made without the distribution
+		emitDividends(trx.Date)
+		applyTransaction(holdings, &trx)
+		final = append(final, trx)
+	}
+	emitDividends(time.Now())
+
+	return final
+}
+
+// ApplyDividends download the dividend history of every security held by
+// the portfolio and add dividend transactions crediting the cash paid. This
+// is needed for ledgers valued at the unadjusted close, such as executed
+// transactions, whose prices do not include distributions. Splits must be
+// applied first so distributions are paid on the current share count.
+func (p *Portfolio) ApplyDividends() error {
+	tickerSet := make(map[string]bool)
+	for _, trx := range p.Transactions {
+		if trx.Kind == BuyTransaction || trx.Kind == SellTransaction {
+			tickerSet[trx.Ticker] = true
+		}
+	}
+
+	if len(tickerSet) == 0 {
+		return nil
+	}
+
+	symbols := make([]string, 0, len(tickerSet))
+	for k := range tickerSet {
+		symbols = append(symbols, k)
+	}
+
+	distributions, err := p.distributions(symbols, p.StartTime, time.Now())
+	if err != nil {
+		return err
+	}
+
+	p.Transactions = InsertDividendTransactions(p.Transactions, distributions)
+	p.Holdings = LedgerHoldings(p.Transactions, time.Now())
+	return nil
+}
+
+// distributions download the distributions of symbols between begin and end
+func (p *Portfolio) distributions(symbols []string, begin time.Time, end time.Time) (map[string][]Distribution, error) {
+	origBegin, origEnd := p.dataProxy.Begin, p.dataProxy.End
+	origFrequency, origMetric := p.dataProxy.Frequency, p.dataProxy.Metric
+	defer func() {
+		p.dataProxy.Begin, p.dataProxy.End = origBegin, origEnd
+		p.dataProxy.Frequency, p.dataProxy.Metric = origFrequency, origMetric
+	}()
+
+	p.dataProxy.Begin = begin
+	p.dataProxy.End = end
+	p.dataProxy.Frequency = data.FrequencyDaily
+	p.dataProxy.Metric = data.MetricDividendCash
+
+	cash, errs := p.dataProxy.GetMultipleData(symbols...)
+	if len(errs) > 0 {
+		return nil, errors.New("Failed to download dividend data for tickers")
+	}
+
+	distributions := make(map[string][]Distribution)
+	for symbol, df := range cash {
+		tickerDistributions, err := DistributionsFromDataFrame(df, symbol)
+		if err != nil {
+			return nil, err
+		}
+		distributions[symbol] = tickerDistributions
+	}
+	return distributions, nil
+}
@@ -0,0 +1,130 @@
+package portfolio_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/rocketlaunchr/dataframe-go"
+
+	"main/data"
+	"main/portfolio"
+)
+
+var _ = Describe("Dividend", func() {
+	var (
+		trxs []portfolio.Transaction
+		d1   time.Time
+		d2   time.Time
+		asOf time.Time
+	)
+
+	BeforeEach(func() {
+		d1 = time.Date(2020, time.January, 2, 0, 0, 0, 0, time.UTC)
+		d2 = time.Date(2020, time.July, 1, 0, 0, 0, 0, time.UTC)
+		asOf = time.Date(2020, time.December, 31, 0, 0, 0, 0, time.UTC)
+		trxs = []portfolio.Transaction{
+			{Date: d1, Ticker: "$CASH", Kind: portfolio.DepositTransaction, TotalValue: 10000},
+			{Date: d1, Ticker: "VFINX", Kind: portfolio.BuyTransaction, Shares: 20, PricePerShare: 300, TotalValue: 6000},
+			{Date: d2, Ticker: "VFINX", Kind: portfolio.BuyTransaction, Shares: 10, PricePerShare: 300, TotalValue: 3000},
+		}
+	})
+
+	Describe("When given dividend cash", func() {
+		It("should extract distributions from a dataframe", func() {
+			dates := dataframe.NewSeriesTime(data.DateIdx, &dataframe.SeriesInit{Size: 3}, []time.Time{
+				time.Date(2020, time.March, 25, 0, 0, 0, 0, time.UTC),
+				time.Date(2020, time.March, 26, 0, 0, 0, 0, time.UTC),
+				time.Date(2020, time.March, 27, 0, 0, 0, 0, time.UTC),
+			})
+			cash := dataframe.NewSeriesFloat64("VFINX", &dataframe.SeriesInit{Size: 3}, []float64{0.0, 1.25, 0.0})
+			dists, err := portfolio.DistributionsFromDataFrame(dataframe.NewDataFrame(dates, cash), "VFINX")
+			Expect(err).To(BeNil())
+			Expect(dists).To(HaveLen(1))
+			Expect(dists[0].ExDate).To(Equal(time.Date(2020, time.March, 26, 0, 0, 0, 0, time.UTC)))
+			Expect(dists[0].Amount).To(Equal(1.25))
+		})
+	})
+
+	Describe("When building a dividend calendar", func() {
+		It("should report the distributions received while held", func() {
+			calendar := portfolio.BuildDividendCalendar(trxs, map[string][]portfolio.Distribution{
+				"VFINX": {
+					{Ticker: "VFINX", ExDate: time.Date(2019, time.December, 20, 0, 0, 0, 0, time.UTC), Amount: 1.5},
+					{Ticker: "VFINX", ExDate: time.Date(2020, time.March, 26, 0, 0, 0, 0, time.UTC), Amount: 1.25},
+					{Ticker: "VFINX", ExDate: time.Date(2020, time.September, 24, 0, 0, 0, 0, time.UTC), Amount: 1.0},
+				},
+			}, asOf)
+
+			Expect(calendar.Historical).To(HaveLen(2))
+			Expect(calendar.Historical[0].Shares).To(Equal(20.0))
+			Expect(calendar.Historical[0].TotalValue).To(Equal(25.0))
+			Expect(calendar.Historical[1].Shares).To(Equal(30.0))
+			Expect(calendar.Historical[1].TotalValue).To(Equal(30.0))
+		})
+
+		It("should project the next 12 months from trailing distributions", func() {
+			calendar := portfolio.BuildDividendCalendar(trxs, map[string][]portfolio.Distribution{
+				"VFINX": {
+					{Ticker: "VFINX", ExDate: time.Date(2019, time.December, 20, 0, 0, 0, 0, time.UTC), Amount: 1.5},
+					{Ticker: "VFINX", ExDate: time.Date(2020, time.March, 26, 0, 0, 0, 0, time.UTC), Amount: 1.25},
+					{Ticker: "VFINX", ExDate: time.Date(2020, time.September, 24, 0, 0, 0, 0, time.UTC), Amount: 1.0},
+				},
+			}, asOf)
+
+			Expect(calendar.Upcoming).To(HaveLen(2))
+			Expect(calendar.Upcoming[0].ExDate).To(Equal(time.Date(2021, time.March, 26, 0, 0, 0, 0, time.UTC)))
+			Expect(calendar.Upcoming[0].Estimated).To(BeTrue())
+			Expect(calendar.Upcoming[0].TotalValue).To(Equal(37.5))
+			Expect(calendar.ProjectedIncome).To(Equal(67.5))
+			Expect(calendar.ProjectedMonths).To(Equal([]portfolio.MonthlyIncome{
+				{Month: "2021-03", Income: 37.5},
+				{Month: "2021-09", Income: 30.0},
+			}))
+		})
+
+		It("should not project distributions of securities that were sold", func() {
+			trxs = append(trxs, portfolio.Transaction{Date: time.Date(2020, time.October, 1, 0, 0, 0, 0, time.UTC), Ticker: "VFINX", Kind: portfolio.SellTransaction, Shares: 30, PricePerShare: 320, TotalValue: 9600})
+			calendar := portfolio.BuildDividendCalendar(trxs, map[string][]portfolio.Distribution{
+				"VFINX": {
+					{Ticker: "VFINX", ExDate: time.Date(2020, time.September, 24, 0, 0, 0, 0, time.UTC), Amount: 1.0},
+				},
+			}, asOf)
+
+			Expect(calendar.Historical).To(HaveLen(1))
+			Expect(calendar.Upcoming).To(BeEmpty())
+			Expect(calendar.ProjectedIncome).To(Equal(0.0))
+		})
+	})
+
+	Describe("When inserting dividend transactions", func() {
+		It("should pay distributions on the shares held before the ex-date", func() {
+			res := portfolio.InsertDividendTransactions(trxs, map[string][]portfolio.Distribution{
+				"VFINX": {
+					{ExDate: time.Date(2020, time.March, 26, 0, 0, 0, 0, time.UTC), Amount: 1.25},
+					{ExDate: d2, Amount: 1.0},
+				},
+			})
+			Expect(res).To(HaveLen(5))
+			Expect(res[2].Kind).To(Equal(portfolio.DividendTransaction))
+			Expect(res[2].TotalValue).Should(BeNumerically("~", 25, 1e-9))
+
+			// the buy on the ex-date is made without the distribution
+			Expect(res[3].Kind).To(Equal(portfolio.DividendTransaction))
+			Expect(res[3].Shares).Should(BeNumerically("~", 20, 1e-9))
+			Expect(res[4].Kind).To(Equal(portfolio.BuyTransaction))
+
+			holdings := portfolio.LedgerHoldings(res, asOf)
+			Expect(holdings["$CASH"]).Should(BeNumerically("~", 1045, 1e-9))
+		})
+
+		It("should not duplicate existing dividend transactions", func() {
+			distributions := map[string][]portfolio.Distribution{
+				"VFINX": {{ExDate: time.Date(2020, time.March, 26, 0, 0, 0, 0, time.UTC), Amount: 1.25}},
+			}
+			res := portfolio.InsertDividendTransactions(trxs, distributions)
+			res = portfolio.InsertDividendTransactions(res, distributions)
+			Expect(res).To(HaveLen(4))
+		})
+	})
+})
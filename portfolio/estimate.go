@@ -0,0 +1,76 @@
+package portfolio
+
+import (
+	"main/data"
+	"sort"
+	"time"
+)
+
+// Estimate value of a portfolio during the trading day at the latest
+// intraday quotes. Estimates are always preliminary; the value is final once
+// end of day prices are available.
+type Estimate struct {
+	Preliminary bool      `json:"preliminary"`
+	AsOf        time.Time `json:"asOf"`
+	Value       float64   `json:"value"`
+
+	// PreviousClose value of the portfolio at the close of the last trading
+	// day
+	PreviousClose float64         `json:"previousClose"`
+	PercentChange float64         `json:"percentChange"`
+	Positions     []PositionValue `json:"positions"`
+
+	// Stale securities without an intraday quote, such as mutual funds,
+	// which are valued at their last close
+	Stale []string `json:"stale"`
+
+	// Signal allocation the strategy would select if the market closed now;
+	// only previewed on rebalance days
+	Signal *Signal `json:"signal,omitempty"`
+}
+
+// NewEstimate value the positions of a holdings snapshot taken at the last
+// close at intraday quotes
+func NewEstimate(lastClose *HoldingsSnapshot, quotes map[string]data.Quote, asOf time.Time) *Estimate {
+	estimate := &Estimate{
+		Preliminary:   true,
+		AsOf:          asOf,
+		PreviousClose: lastClose.Value,
+		Positions:     make([]PositionValue, 0, len(lastClose.Positions)),
+		Stale:         []string{},
+	}
+
+	for _, pos := range lastClose.Positions {
+		if pos.Ticker != "$CASH" {
+			if q, ok := quotes[pos.Ticker]; ok {
+				pos.Price = q.Price
+			} else {
+				estimate.Stale = append(estimate.Stale, pos.Ticker)
+			}
+		}
+		pos.Value = pos.Shares * pos.Price
+		estimate.Value += pos.Value
+		estimate.Positions = append(estimate.Positions, pos)
+	}
+
+	for ii := range estimate.Positions {
+		estimate.Positions[ii].Weight = 0
+		if estimate.Value != 0 {
+			estimate.Positions[ii].Weight = estimate.Positions[ii].Value / estimate.Value
+		}
+	}
+
+	sort.Slice(estimate.Positions, func(i, j int) bool {
+		if estimate.Positions[i].Value != estimate.Positions[j].Value {
+			return estimate.Positions[i].Value > estimate.Positions[j].Value
+		}
+		return estimate.Positions[i].Ticker < estimate.Positions[j].Ticker
+	})
+	sort.Strings(estimate.Stale)
+
+	if estimate.PreviousClose != 0 {
+		estimate.PercentChange = estimate.Value/estimate.PreviousClose - 1.0
+	}
+
+	return estimate
+}
@@ -0,0 +1,62 @@
+package portfolio_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"main/data"
+	"main/portfolio"
+)
+
+var _ = Describe("Estimate", func() {
+	var (
+		lastClose *portfolio.HoldingsSnapshot
+		asOf      time.Time
+	)
+
+	BeforeEach(func() {
+		asOf = time.Date(2021, time.January, 29, 11, 30, 0, 0, time.UTC)
+		lastClose = &portfolio.HoldingsSnapshot{
+			Date:  time.Date(2021, time.January, 28, 0, 0, 0, 0, time.UTC),
+			Value: 10000,
+			Positions: []portfolio.PositionValue{
+				{Ticker: "VOO", Shares: 10, Price: 350, Value: 3500, Weight: 0.35},
+				{Ticker: "VFINX", Shares: 10, Price: 400, Value: 4000, Weight: 0.40},
+				{Ticker: "$CASH", Shares: 2500, Price: 1, Value: 2500, Weight: 0.25},
+			},
+		}
+	})
+
+	Describe("When valuing holdings at intraday quotes", func() {
+		It("should use quotes and fall back to the last close", func() {
+			quotes := map[string]data.Quote{
+				"VOO": {Ticker: "VOO", Price: 360, PrevClose: 350},
+			}
+
+			estimate := portfolio.NewEstimate(lastClose, quotes, asOf)
+			Expect(estimate.Preliminary).To(BeTrue())
+			Expect(estimate.AsOf).To(Equal(asOf))
+			Expect(estimate.PreviousClose).Should(BeNumerically("~", 10000, 1e-9))
+			Expect(estimate.Value).Should(BeNumerically("~", 10100, 1e-9))
+			Expect(estimate.PercentChange).Should(BeNumerically("~", 0.01, 1e-9))
+			Expect(estimate.Stale).To(Equal([]string{"VFINX"}))
+			Expect(estimate.Signal).To(BeNil())
+
+			Expect(estimate.Positions).To(HaveLen(3))
+			Expect(estimate.Positions[0].Ticker).To(Equal("VFINX"))
+			Expect(estimate.Positions[1].Ticker).To(Equal("VOO"))
+			Expect(estimate.Positions[1].Price).Should(BeNumerically("~", 360, 1e-9))
+			Expect(estimate.Positions[1].Value).Should(BeNumerically("~", 3600, 1e-9))
+			Expect(estimate.Positions[1].Weight).Should(BeNumerically("~", 3600.0/10100.0, 1e-9))
+		})
+
+		It("should not modify the snapshot", func() {
+			portfolio.NewEstimate(lastClose, map[string]data.Quote{
+				"VOO": {Ticker: "VOO", Price: 360},
+			}, asOf)
+			Expect(lastClose.Positions[0].Price).Should(BeNumerically("~", 350, 1e-9))
+		})
+	})
+})
@@ -0,0 +1,158 @@
+package portfolio
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// ExecutionConstraints limits on the trades of a rebalance so backtests
+// resemble portfolios executed in a real account. The zero value places no
+// constraints on trades.
+type ExecutionConstraints struct {
+	// WholeShares only trade whole shares; positions are rounded down so
+	// the leftover is held as cash
+	WholeShares bool `json:"wholeShares,omitempty"`
+
+	// MinTradeValue and MinTradePercent skip trades worth less than a
+	// dollar amount or a percent of the portfolio's value. Positions that
+	// are no longer in the target are always sold.
+	MinTradeValue   float64 `json:"minTradeValue,omitempty"`
+	MinTradePercent float64 `json:"minTradePercent,omitempty"`
+
+	// CashBuffer percent of the portfolio's value held in cash; the long
+	// weights of the target are reduced proportionally when they would
+	// leave less cash. It is not applied to leveraged portfolios.
+	CashBuffer float64 `json:"cashBuffer,omitempty"`
+}
+
+// active check if any constraint is set
+func (c ExecutionConstraints) active() bool {
+	return c.WholeShares || c.MinTradeValue > 0 || c.MinTradePercent > 0 || c.CashBuffer > 0
+}
+
+// constrainedRebalance compute the trades and resulting holdings that move
+// the portfolio toward target within the execution constraints. Sells are
+// made first so that buys never spend more cash than is available, unless
+// the portfolio is leveraged.
+func (p *Portfolio) constrainedRebalance(date time.Time, target map[string]float64, prices map[string]float64, investable float64, justification map[string]interface{}) (map[string]float64, []Transaction, []Transaction) {
+	leveraged := p.Leverage > 1.0
+
+	weights := make(map[string]float64, len(target))
+	long := 0.0
+	for k, v := range target {
+		if k == "$CASH" {
+			continue
+		}
+		weights[k] = v
+		if v > 0 {
+			long += v
+		}
+	}
+
+	if p.Execution.CashBuffer > 0 && !leveraged {
+		maxInvested := 1.0 - p.Execution.CashBuffer/100.0
+		if long > maxInvested {
+			scale := maxInvested / long
+			for k, v := range weights {
+				if v > 0 {
+					weights[k] = v * scale
+				}
+			}
+		}
+	}
+
+	minTrade := math.Max(p.Execution.MinTradeValue, investable*p.Execution.MinTradePercent/100.0)
+
+	tickers := make([]string, 0, len(weights)+len(p.Holdings))
+	for k := range weights {
+		tickers = append(tickers, k)
+	}
+	for k := range p.Holdings {
+		if _, ok := weights[k]; !ok && k != "$CASH" {
+			tickers = append(tickers, k)
+		}
+	}
+	sort.Strings(tickers)
+
+	// change in shares of each security
+	deltas := make(map[string]float64, len(tickers))
+	for _, k := range tickers {
+		current := p.Holdings[k]
+		desired := investable * weights[k] / prices[k]
+		if weights[k] == 0 {
+			deltas[k] = -current
+			continue
+		}
+
+		delta := desired - current
+		if p.Execution.WholeShares {
+			delta = math.Trunc(delta)
+		}
+		if math.Abs(delta*prices[k]) < minTrade {
+			delta = 0
+		}
+		deltas[k] = delta
+	}
+
+	cash := p.Holdings["$CASH"]
+	sells := []Transaction{}
+	buys := []Transaction{}
+	newHoldings := make(map[string]float64, len(tickers)+1)
+
+	for _, k := range tickers {
+		if deltas[k] >= 0 {
+			continue
+		}
+		shares := -deltas[k]
+		cash += shares * prices[k]
+		sells = append(sells, Transaction{
+			Date:          date,
+			Ticker:        k,
+			Kind:          SellTransaction,
+			PricePerShare: prices[k],
+			Shares:        shares,
+			TotalValue:    shares * prices[k],
+			Justification: justification,
+		})
+	}
+
+	for _, k := range tickers {
+		shares := deltas[k]
+		if shares <= 0 {
+			continue
+		}
+		if !leveraged && shares*prices[k] > cash {
+			// skipped sells may leave too little cash for the full buy
+			shares = math.Max(cash, 0) / prices[k]
+			if p.Execution.WholeShares {
+				shares = math.Floor(shares)
+			}
+			if shares <= 0 || shares*prices[k] < minTrade {
+				deltas[k] = 0
+				continue
+			}
+			deltas[k] = shares
+		}
+		cash -= shares * prices[k]
+		buys = append(buys, Transaction{
+			Date:          date,
+			Ticker:        k,
+			Kind:          BuyTransaction,
+			PricePerShare: prices[k],
+			Shares:        shares,
+			TotalValue:    shares * prices[k],
+			Justification: justification,
+		})
+	}
+
+	for _, k := range tickers {
+		held := p.Holdings[k] + deltas[k]
+		if math.Abs(held) > 1.0e-5 {
+			newHoldings[k] = held
+		}
+	}
+	newHoldings["$CASH"] = cash
+
+	return newHoldings, sells, buys
+}
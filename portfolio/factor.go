@@ -0,0 +1,211 @@
+package portfolio
+
+import (
+	"errors"
+	"fmt"
+	"main/data"
+	"math"
+	"time"
+
+	"github.com/rocketlaunchr/dataframe-go"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Factor models the excess returns of a portfolio can be regressed on
+const (
+	FactorModelFF3    = "ff3"
+	FactorModelFF3Mom = "ff3mom"
+	FactorModelFF5    = "ff5"
+	FactorModelFF5Mom = "ff5mom"
+)
+
+// FactorModels factors of each model, in the order they are reported
+var FactorModels = map[string][]string{
+	FactorModelFF3:    {data.FactorMarket, data.FactorSize, data.FactorValue},
+	FactorModelFF3Mom: {data.FactorMarket, data.FactorSize, data.FactorValue, data.FactorMomentum},
+	FactorModelFF5:    {data.FactorMarket, data.FactorSize, data.FactorValue, data.FactorProfitMkt, data.FactorInvestment},
+	FactorModelFF5Mom: {data.FactorMarket, data.FactorSize, data.FactorValue, data.FactorProfitMkt, data.FactorInvestment, data.FactorMomentum},
+}
+
+// minFactorObservations months of returns required for a regression
+const minFactorObservations = 12
+
+// FactorLoading exposure of the portfolio to a factor
+type FactorLoading struct {
+	Factor  string  `json:"factor"`
+	Loading float64 `json:"loading"`
+	StdErr  float64 `json:"stdErr"`
+	TStat   float64 `json:"tStat"`
+}
+
+// FactorRegression ordinary least squares regression of the monthly excess
+// returns of a portfolio on the returns of a factor model
+type FactorRegression struct {
+	Model        string    `json:"model"`
+	Observations int       `json:"observations"`
+	Start        time.Time `json:"start"`
+	End          time.Time `json:"end"`
+
+	// Alpha monthly return not explained by the factors
+	Alpha           float64 `json:"alpha"`
+	AnnualizedAlpha float64 `json:"annualizedAlpha"`
+	AlphaStdErr     float64 `json:"alphaStdErr"`
+	AlphaTStat      float64 `json:"alphaTStat"`
+
+	Loadings         []FactorLoading `json:"loadings"`
+	RSquared         float64         `json:"rSquared"`
+	AdjustedRSquared float64         `json:"adjustedRSquared"`
+}
+
+// factorReturns monthly returns of a factor keyed by year and month
+func factorReturns(df *dataframe.DataFrame, factor string) (map[int]float64, error) {
+	dateIdx, err := df.NameToColumn(data.DateIdx)
+	if err != nil {
+		return nil, err
+	}
+	valueIdx, err := df.NameToColumn(factor)
+	if err != nil {
+		return nil, fmt.Errorf("%s factor returns are missing", factor)
+	}
+
+	returns := make(map[int]float64, df.NRows())
+	for ii := 0; ii < df.NRows(); ii++ {
+		date, ok := df.Series[dateIdx].Value(ii).(time.Time)
+		ret, ok2 := df.Series[valueIdx].Value(ii).(float64)
+		if !ok || !ok2 || math.IsNaN(ret) {
+			continue
+		}
+		returns[date.Year()*100+int(date.Month())] = ret
+	}
+	return returns, nil
+}
+
+// FactorRegression regress the monthly excess returns of the portfolio on
+// the factors of model. factors holds the monthly returns of each factor of
+// the model and of the risk free rate, data.FactorRiskFree, keyed by factor
+// name as returned by the $FF data provider. Only months with returns for
+// the portfolio and every factor are used. The performance must be
+// measured monthly.
+func (perf *Performance) FactorRegression(model string, factors map[string]*dataframe.DataFrame) (FactorRegression, error) {
+	names, ok := FactorModels[model]
+	if !ok {
+		return FactorRegression{}, fmt.Errorf("unknown factor model '%s'", model)
+	}
+	if perf.Resolution != "" && perf.Resolution != data.FrequencyMonthly {
+		return FactorRegression{}, errors.New("factor regressions require monthly performance")
+	}
+
+	series := make([]map[int]float64, 0, len(names)+1)
+	for _, name := range append([]string{data.FactorRiskFree}, names...) {
+		df, ok := factors[name]
+		if !ok {
+			return FactorRegression{}, fmt.Errorf("%s factor returns are missing", name)
+		}
+		returns, err := factorReturns(df, name)
+		if err != nil {
+			return FactorRegression{}, err
+		}
+		series = append(series, returns)
+	}
+
+	returns, dates := perf.monthlyReturns()
+	k := len(names) + 1
+	ys := []float64{}
+	xs := []float64{}
+	used := []time.Time{}
+	for ii, ret := range returns {
+		month := dates[ii].Year()*100 + int(dates[ii].Month())
+		row := make([]float64, k)
+		row[0] = 1
+		complete := true
+		for jj, s := range series {
+			val, ok := s[month]
+			if !ok {
+				complete = false
+				break
+			}
+			if jj == 0 {
+				ret -= val
+			} else {
+				row[jj] = val
+			}
+		}
+		if !complete {
+			continue
+		}
+		ys = append(ys, ret)
+		xs = append(xs, row...)
+		used = append(used, dates[ii])
+	}
+
+	n := len(ys)
+	if n < minFactorObservations || n <= k {
+		return FactorRegression{}, fmt.Errorf("factor regression requires at least %d months of returns, found %d", minFactorObservations, n)
+	}
+
+	x := mat.NewDense(n, k, xs)
+	y := mat.NewVecDense(n, ys)
+
+	var xtx mat.Dense
+	xtx.Mul(x.T(), x)
+	var inv mat.Dense
+	if err := inv.Inverse(&xtx); err != nil {
+		return FactorRegression{}, errors.New("factor returns are collinear")
+	}
+
+	var xty mat.VecDense
+	xty.MulVec(x.T(), y)
+	var beta mat.VecDense
+	beta.MulVec(&inv, &xty)
+
+	var fitted mat.VecDense
+	fitted.MulVec(x, &beta)
+	mean := 0.0
+	for _, v := range ys {
+		mean += v
+	}
+	mean /= float64(n)
+	var ssr, sst float64
+	for ii, v := range ys {
+		ssr += math.Pow(v-fitted.AtVec(ii), 2)
+		sst += math.Pow(v-mean, 2)
+	}
+
+	dof := float64(n - k)
+	variance := ssr / dof
+	stdErr := func(ii int) float64 {
+		return math.Sqrt(variance * inv.At(ii, ii))
+	}
+	tStat := func(ii int) float64 {
+		if se := stdErr(ii); se > 0 {
+			return beta.AtVec(ii) / se
+		}
+		return 0
+	}
+
+	result := FactorRegression{
+		Model:           model,
+		Observations:    n,
+		Start:           used[0],
+		End:             used[n-1],
+		Alpha:           beta.AtVec(0),
+		AnnualizedAlpha: math.Pow(1+beta.AtVec(0), 12) - 1,
+		AlphaStdErr:     stdErr(0),
+		AlphaTStat:      tStat(0),
+		Loadings:        make([]FactorLoading, 0, len(names)),
+	}
+	for ii, name := range names {
+		result.Loadings = append(result.Loadings, FactorLoading{
+			Factor:  name,
+			Loading: beta.AtVec(ii + 1),
+			StdErr:  stdErr(ii + 1),
+			TStat:   tStat(ii + 1),
+		})
+	}
+	if sst > 0 {
+		result.RSquared = 1 - ssr/sst
+		result.AdjustedRSquared = 1 - (1-result.RSquared)*float64(n-1)/dof
+	}
+
+	return result, nil
+}
@@ -0,0 +1,95 @@
+package portfolio_test
+
+import (
+	"math"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	dataframe "github.com/rocketlaunchr/dataframe-go"
+
+	"main/data"
+	"main/portfolio"
+)
+
+var _ = Describe("Factor", func() {
+	var (
+		perf    portfolio.Performance
+		factors map[string]*dataframe.DataFrame
+	)
+
+	// monthly returns of the portfolio and factors where the portfolio's
+	// excess return is 0.001 + 1.2 MKT + 0.5 SMB - 0.3 HML
+	build := func(months int) (portfolio.Performance, map[string]*dataframe.DataFrame) {
+		perf := portfolio.Performance{Resolution: data.FrequencyMonthly}
+		start := time.Date(2000, time.January, 31, 0, 0, 0, 0, time.UTC)
+		series := map[string][]interface{}{}
+		dates := []interface{}{}
+		for ii := 0; ii <= months; ii++ {
+			date := time.Date(2000, time.February+time.Month(ii), 0, 0, 0, 0, 0, time.UTC)
+			meas := portfolio.PerformanceMeasurement{Time: date.Unix()}
+			if ii == 0 {
+				meas.Time = start.Unix()
+			} else {
+				x := float64(ii)
+				rf := 0.002
+				mkt := 0.03 * math.Sin(x)
+				smb := 0.02 * math.Cos(1.7*x)
+				hml := 0.01 * math.Sin(0.5*x+1)
+				meas.PercentReturn = rf + 0.001 + 1.2*mkt + 0.5*smb - 0.3*hml
+				dates = append(dates, date)
+				series[data.FactorRiskFree] = append(series[data.FactorRiskFree], rf)
+				series[data.FactorMarket] = append(series[data.FactorMarket], mkt)
+				series[data.FactorSize] = append(series[data.FactorSize], smb)
+				series[data.FactorValue] = append(series[data.FactorValue], hml)
+			}
+			perf.Measurements = append(perf.Measurements, meas)
+		}
+
+		factors := make(map[string]*dataframe.DataFrame)
+		for name, vals := range series {
+			factors[name] = dataframe.NewDataFrame(
+				dataframe.NewSeriesTime(data.DateIdx, &dataframe.SeriesInit{}, dates...),
+				dataframe.NewSeriesFloat64(name, &dataframe.SeriesInit{}, vals...),
+			)
+		}
+		return perf, factors
+	}
+
+	BeforeEach(func() {
+		perf, factors = build(60)
+	})
+
+	Describe("When regressing on the 3 factor model", func() {
+		It("should recover the loadings and alpha", func() {
+			regression, err := perf.FactorRegression(portfolio.FactorModelFF3, factors)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(regression.Observations).To(Equal(60))
+			Expect(regression.Alpha).To(BeNumerically("~", 0.001, 1e-9))
+			Expect(regression.Loadings).To(HaveLen(3))
+			Expect(regression.Loadings[0].Factor).To(Equal(data.FactorMarket))
+			Expect(regression.Loadings[0].Loading).To(BeNumerically("~", 1.2, 1e-9))
+			Expect(regression.Loadings[1].Loading).To(BeNumerically("~", 0.5, 1e-9))
+			Expect(regression.Loadings[2].Loading).To(BeNumerically("~", -0.3, 1e-9))
+			Expect(regression.RSquared).To(BeNumerically("~", 1, 1e-9))
+		})
+
+		It("should report missing factors", func() {
+			_, err := perf.FactorRegression(portfolio.FactorModelFF5, factors)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("When given invalid input", func() {
+		It("should reject unknown models", func() {
+			_, err := perf.FactorRegression("capm", factors)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should require enough observations", func() {
+			perf, factors = build(6)
+			_, err := perf.FactorRegression(portfolio.FactorModelFF3, factors)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,220 @@
+package portfolio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"main/data"
+	"main/dfextras"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+// PositionValue shares of a security held on a date and their market value.
+// Cash is reported under the ticker $CASH with a price of 1.
+type PositionValue struct {
+	Ticker string  `json:"ticker"`
+	Shares float64 `json:"shares"`
+	Price  float64 `json:"price"`
+	Value  float64 `json:"value"`
+
+	// Weight fraction of the portfolio's value held in the position
+	Weight float64 `json:"weight"`
+}
+
+// HoldingsSnapshot positions of a portfolio on a date, largest first
+type HoldingsSnapshot struct {
+	Date      time.Time       `json:"date"`
+	Value     float64         `json:"value"`
+	Positions []PositionValue `json:"positions"`
+}
+
+// Allocation weight of each position of a portfolio on a date
+type Allocation struct {
+	Date    time.Time          `json:"date"`
+	Weights map[string]float64 `json:"weights"`
+}
+
+// ValueHoldings replay the transactions up to and including asOf and value
+// the resulting positions at prices. Transactions must be sorted by date.
+func ValueHoldings(trxs []Transaction, asOf time.Time, prices map[string]float64) (*HoldingsSnapshot, error) {
+	return valueHoldings(LedgerHoldings(trxs, asOf), asOf, prices)
+}
+
+// valueHoldings value share counts at prices; positions too small to matter
+// are omitted
+func valueHoldings(holdings map[string]float64, date time.Time, prices map[string]float64) (*HoldingsSnapshot, error) {
+	snapshot := &HoldingsSnapshot{
+		Date:      date,
+		Positions: make([]PositionValue, 0, len(holdings)),
+	}
+
+	for ticker, shares := range holdings {
+		if math.Abs(shares) <= 1.0e-5 {
+			continue
+		}
+
+		price := 1.0
+		if ticker != "$CASH" {
+			var ok bool
+			if price, ok = prices[ticker]; !ok {
+				return nil, fmt.Errorf("no quote for symbol: %s", ticker)
+			}
+		}
+
+		pos := PositionValue{
+			Ticker: ticker,
+			Shares: shares,
+			Price:  price,
+			Value:  shares * price,
+		}
+		snapshot.Value += pos.Value
+		snapshot.Positions = append(snapshot.Positions, pos)
+	}
+
+	if snapshot.Value != 0 {
+		for ii := range snapshot.Positions {
+			snapshot.Positions[ii].Weight = snapshot.Positions[ii].Value / snapshot.Value
+		}
+	}
+
+	sort.Slice(snapshot.Positions, func(i, j int) bool {
+		if snapshot.Positions[i].Value != snapshot.Positions[j].Value {
+			return snapshot.Positions[i].Value > snapshot.Positions[j].Value
+		}
+		return snapshot.Positions[i].Ticker < snapshot.Positions[j].Ticker
+	})
+
+	return snapshot, nil
+}
+
+// HoldingsAsOf positions of the portfolio at the close of the last trading
+// day on or before date
+func (p *Portfolio) HoldingsAsOf(date time.Time) (*HoldingsSnapshot, error) {
+	// Get last 7 days of prices, in case 'date' isn't a market day
+	snapshots, err := p.valueLedger(date.AddDate(0, 0, -7), date, data.FrequencyDaily)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 0 {
+		return nil, errors.New("Failed to compute holdings for date")
+	}
+	return snapshots[len(snapshots)-1], nil
+}
+
+// AllocationHistory weight of each position on every date between begin and
+// end prices are available at the given frequency
+func (p *Portfolio) AllocationHistory(begin time.Time, end time.Time, frequency string) ([]Allocation, error) {
+	snapshots, err := p.valueLedger(begin, end, frequency)
+	if err != nil {
+		return nil, err
+	}
+
+	allocations := make([]Allocation, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		weights := make(map[string]float64, len(snapshot.Positions))
+		for _, pos := range snapshot.Positions {
+			weights[pos.Ticker] = pos.Weight
+		}
+		allocations = append(allocations, Allocation{
+			Date:    snapshot.Date,
+			Weights: weights,
+		})
+	}
+
+	return allocations, nil
+}
+
+// valueLedger value the positions implied by the portfolio's transactions
+// on each date between begin and end prices are available at frequency
+func (p *Portfolio) valueLedger(begin time.Time, end time.Time, frequency string) ([]*HoldingsSnapshot, error) {
+	if len(p.Transactions) == 0 {
+		return nil, errors.New("Cannot calculate holdings for portfolio with no transactions")
+	}
+
+	// securities held at any time during the period
+	tickerSet := map[string]bool{}
+	for ticker := range LedgerHoldings(p.Transactions, begin) {
+		tickerSet[ticker] = true
+	}
+	for _, trx := range p.Transactions {
+		if trx.Date.After(begin) && !trx.Date.After(end) {
+			switch trx.Kind {
+			case BuyTransaction, SellTransaction, SplitTransaction:
+				tickerSet[trx.Ticker] = true
+			}
+		}
+	}
+	delete(tickerSet, "$CASH")
+
+	if len(tickerSet) == 0 {
+		snapshot, err := ValueHoldings(p.Transactions, end, map[string]float64{})
+		if err != nil {
+			return nil, err
+		}
+		return []*HoldingsSnapshot{snapshot}, nil
+	}
+
+	symbols := make([]string, 0, len(tickerSet))
+	for ticker := range tickerSet {
+		symbols = append(symbols, ticker)
+	}
+
+	p.dataProxy.Begin = begin
+	p.dataProxy.End = end
+	p.dataProxy.Frequency = frequency
+
+	quotes, errs := p.dataProxy.GetMultipleData(symbols...)
+	if len(errs) > 0 {
+		return nil, errors.New("Failed to download data for tickers")
+	}
+
+	var eod = []*dataframe.DataFrame{}
+	for _, val := range quotes {
+		eod = append(eod, val)
+	}
+
+	eodQuotes, err := dfextras.Merge(context.TODO(), data.DateIdx, eod...)
+	if err != nil {
+		return nil, err
+	}
+
+	// replay the ledger alongside the quotes, carrying prices forward over
+	// days a security did not trade
+	snapshots := []*HoldingsSnapshot{}
+	holdings := make(map[string]float64)
+	prices := make(map[string]float64)
+	next := 0
+	iterator := eodQuotes.ValuesIterator(dataframe.ValuesOptions{InitialRow: 0, Step: 1, DontReadLock: false})
+	for {
+		row, vals, _ := iterator(dataframe.SeriesName)
+		if row == nil {
+			break
+		}
+		date := vals[data.DateIdx].(time.Time)
+		year, month, day := date.Date()
+		date = time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+		for _, ticker := range symbols {
+			if price, ok := vals[ticker].(float64); ok {
+				prices[ticker] = price
+			}
+		}
+
+		for next < len(p.Transactions) && !p.Transactions[next].Date.After(date) {
+			applyTransaction(holdings, &p.Transactions[next])
+			next++
+		}
+
+		snapshot, err := valueHoldings(holdings, date, prices)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return snapshots, nil
+}
@@ -0,0 +1,58 @@
+package portfolio_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"main/portfolio"
+)
+
+var _ = Describe("Holdings", func() {
+	var (
+		trxs []portfolio.Transaction
+		d1   time.Time
+		d2   time.Time
+	)
+
+	BeforeEach(func() {
+		d1 = time.Date(2019, time.January, 31, 0, 0, 0, 0, time.UTC)
+		d2 = time.Date(2019, time.June, 28, 0, 0, 0, 0, time.UTC)
+
+		trxs = []portfolio.Transaction{
+			{Date: d1, Ticker: "$CASH", Kind: portfolio.DepositTransaction, TotalValue: 10000},
+			{Date: d1, Ticker: "VFINX", Kind: portfolio.BuyTransaction, Shares: 60, PricePerShare: 100, TotalValue: 6000},
+			{Date: d1, Ticker: "VUSTX", Kind: portfolio.BuyTransaction, Shares: 200, PricePerShare: 10, TotalValue: 2000},
+			{Date: d2, Ticker: "VUSTX", Kind: portfolio.SellTransaction, Shares: 200, PricePerShare: 11, TotalValue: 2200},
+		}
+	})
+
+	Describe("When valuing the holdings of a ledger", func() {
+		It("should value each position on the date", func() {
+			snapshot, err := portfolio.ValueHoldings(trxs, d1, map[string]float64{"VFINX": 110, "VUSTX": 11})
+			Expect(err).To(BeNil())
+			Expect(snapshot.Date).To(Equal(d1))
+			Expect(snapshot.Value).Should(BeNumerically("~", 10800, 1e-9))
+			Expect(snapshot.Positions).To(Equal([]portfolio.PositionValue{
+				{Ticker: "VFINX", Shares: 60, Price: 110, Value: 6600, Weight: 6600.0 / 10800.0},
+				{Ticker: "VUSTX", Shares: 200, Price: 11, Value: 2200, Weight: 2200.0 / 10800.0},
+				{Ticker: "$CASH", Shares: 2000, Price: 1, Value: 2000, Weight: 2000.0 / 10800.0},
+			}))
+		})
+
+		It("should omit positions that were sold", func() {
+			snapshot, err := portfolio.ValueHoldings(trxs, d2, map[string]float64{"VFINX": 120})
+			Expect(err).To(BeNil())
+			Expect(snapshot.Positions).To(HaveLen(2))
+			Expect(snapshot.Positions[0].Ticker).To(Equal("VFINX"))
+			Expect(snapshot.Positions[1].Ticker).To(Equal("$CASH"))
+			Expect(snapshot.Positions[1].Shares).Should(BeNumerically("~", 4200, 1e-9))
+		})
+
+		It("should fail without a price for a held security", func() {
+			_, err := portfolio.ValueHoldings(trxs, d1, map[string]float64{"VFINX": 110})
+			Expect(err).ToNot(BeNil())
+		})
+	})
+})
@@ -0,0 +1,178 @@
+package portfolio
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// HouseholdMember a portfolio included in a household roll-up
+type HouseholdMember struct {
+	ID          string
+	Name        string
+	Performance *Performance
+}
+
+// HouseholdHolding combined position in a security across portfolios
+type HouseholdHolding struct {
+	Ticker     string   `json:"ticker"`
+	Value      float64  `json:"value"`
+	Percent    float64  `json:"percent"`
+	Portfolios []string `json:"portfolios"`
+}
+
+// HouseholdReport aggregate of several portfolios owned by the same user
+type HouseholdReport struct {
+	Portfolios  []string           `json:"portfolios"`
+	Performance Performance        `json:"performance"`
+	Holdings    []HouseholdHolding `json:"holdings"`
+
+	// OverlapPercent fraction of the combined value invested in securities
+	// that are held by more than one portfolio
+	OverlapPercent float64 `json:"overlapPercent"`
+}
+
+// Household combine the performance of multiple portfolios into a single
+// equity curve. Portfolios that start later are added to the combined value
+// without affecting returns; the return of each period is the value-weighted
+// return of the portfolios that existed at the start of the period. The
+// risk-free value is an index grown by the same weighting.
+func Household(members []HouseholdMember) HouseholdReport {
+	report := HouseholdReport{
+		Portfolios: []string{},
+		Holdings:   []HouseholdHolding{},
+	}
+
+	// collect the union of all measurement times
+	timeSet := make(map[int64]bool)
+	for _, member := range members {
+		report.Portfolios = append(report.Portfolios, member.ID)
+		for _, m := range member.Performance.Measurements {
+			timeSet[m.Time] = true
+		}
+	}
+
+	times := make([]int64, 0, len(timeSet))
+	for t := range timeSet {
+		times = append(times, t)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+
+	// walk the measurements of every member in time order
+	idx := make([]int, len(members))
+	current := make([]*PerformanceMeasurement, len(members))
+	measurements := make([]PerformanceMeasurement, 0, len(times))
+	var riskFreeValue float64
+	for _, t := range times {
+		var total, weightedReturn, weightedRiskFree, prevBase float64
+		for ii, member := range members {
+			meas := member.Performance.Measurements
+			prev := current[ii]
+			if idx[ii] < len(meas) && meas[idx[ii]].Time == t {
+				current[ii] = &meas[idx[ii]]
+				idx[ii]++
+
+				// only portfolios that existed last period contribute returns
+				if prev != nil {
+					weightedReturn += prev.Value * current[ii].PercentReturn
+					if prev.RiskFreeValue != 0 {
+						weightedRiskFree += prev.Value * (current[ii].RiskFreeValue/prev.RiskFreeValue - 1.0)
+					}
+				}
+			}
+
+			if prev != nil {
+				prevBase += prev.Value
+			}
+			if current[ii] != nil {
+				total += current[ii].Value
+			}
+		}
+
+		var ret float64
+		if prevBase > 0 {
+			ret = weightedReturn / prevBase
+			riskFreeValue *= 1.0 + weightedRiskFree/prevBase
+		}
+
+		if riskFreeValue == 0 {
+			riskFreeValue = total
+		}
+
+		measurements = append(measurements, PerformanceMeasurement{
+			Time:          t,
+			Value:         total,
+			RiskFreeValue: riskFreeValue,
+			PercentReturn: ret,
+		})
+	}
+
+	perf := Performance{
+		Measurements:    measurements,
+		CurrentHoldings: make(map[string]float64),
+	}
+	for _, member := range members {
+		perf.TotalDeposited += member.Performance.TotalDeposited
+		perf.TotalWithdrawn += member.Performance.TotalWithdrawn
+	}
+
+	if len(measurements) > 0 {
+		perf.PeriodStart = measurements[0].Time
+		perf.PeriodEnd = measurements[len(measurements)-1].Time
+		perf.ComputedOn = time.Now().Unix()
+
+		// YTD return compounds the returns of the current year
+		lastYear := time.Unix(perf.PeriodEnd, 0).UTC().Year()
+		ytd := 1.0
+		for _, m := range measurements[1:] {
+			if time.Unix(m.Time, 0).UTC().Year() == lastYear {
+				ytd *= 1.0 + m.PercentReturn
+			}
+		}
+		perf.YTDReturn = ytd - 1.0
+	}
+
+	// combine current holdings
+	holdings := make(map[string]*HouseholdHolding)
+	var totalValue float64
+	for _, member := range members {
+		for ticker, value := range member.Performance.CurrentHoldings {
+			h, ok := holdings[ticker]
+			if !ok {
+				h = &HouseholdHolding{
+					Ticker:     ticker,
+					Portfolios: []string{},
+				}
+				holdings[ticker] = h
+			}
+			h.Value += value
+			h.Portfolios = append(h.Portfolios, member.ID)
+			totalValue += value
+			perf.CurrentHoldings[ticker] += value
+		}
+	}
+
+	var overlap float64
+	for _, h := range holdings {
+		if totalValue != 0 {
+			h.Percent = h.Value / totalValue
+		}
+		if h.Ticker != "$CASH" && len(h.Portfolios) > 1 {
+			overlap += math.Abs(h.Value)
+		}
+		report.Holdings = append(report.Holdings, *h)
+	}
+	sort.Slice(report.Holdings, func(i, j int) bool {
+		return report.Holdings[i].Value > report.Holdings[j].Value
+	})
+	if totalValue > 0 {
+		report.OverlapPercent = overlap / totalValue
+	}
+
+	if len(measurements) > 1 {
+		perf.BuildMetricsBundle()
+	}
+	report.Performance = perf
+
+	return report
+}
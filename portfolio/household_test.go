@@ -0,0 +1,73 @@
+package portfolio_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"main/portfolio"
+)
+
+var _ = Describe("Household", func() {
+	var (
+		perf1 portfolio.Performance
+		perf2 portfolio.Performance
+	)
+
+	BeforeEach(func() {
+		perf1 = portfolio.Performance{
+			Measurements: []portfolio.PerformanceMeasurement{
+				{Time: 100, Value: 1000, RiskFreeValue: 1000},
+				{Time: 200, Value: 1100, RiskFreeValue: 1010, PercentReturn: 0.10},
+				{Time: 300, Value: 1210, RiskFreeValue: 1020.1, PercentReturn: 0.10},
+			},
+			CurrentHoldings: map[string]float64{
+				"VFINX": 1210,
+			},
+		}
+
+		perf2 = portfolio.Performance{
+			Measurements: []portfolio.PerformanceMeasurement{
+				{Time: 200, Value: 3000, RiskFreeValue: 3000},
+				{Time: 300, Value: 2700, RiskFreeValue: 3030, PercentReturn: -0.10},
+			},
+			CurrentHoldings: map[string]float64{
+				"VFINX": 1350,
+				"VUSTX": 1350,
+			},
+		}
+	})
+
+	Describe("When aggregating portfolios", func() {
+		It("should combine equity curves", func() {
+			report := portfolio.Household([]portfolio.HouseholdMember{
+				{ID: "a", Name: "Portfolio A", Performance: &perf1},
+				{ID: "b", Name: "Portfolio B", Performance: &perf2},
+			})
+
+			m := report.Performance.Measurements
+			Expect(m).To(HaveLen(3))
+			Expect(m[0].Value).Should(BeNumerically("~", 1000, 1e-9))
+			Expect(m[1].Value).Should(BeNumerically("~", 4100, 1e-9))
+			Expect(m[2].Value).Should(BeNumerically("~", 3910, 1e-9))
+
+			// the portfolio added in the second period does not affect returns
+			Expect(m[1].PercentReturn).Should(BeNumerically("~", 0.10, 1e-9))
+			Expect(m[2].PercentReturn).Should(BeNumerically("~", (110.0-300.0)/4100.0, 1e-9))
+			Expect(m[1].RiskFreeValue).Should(BeNumerically("~", 1010, 1e-9))
+		})
+
+		It("should combine holdings and measure overlap", func() {
+			report := portfolio.Household([]portfolio.HouseholdMember{
+				{ID: "a", Name: "Portfolio A", Performance: &perf1},
+				{ID: "b", Name: "Portfolio B", Performance: &perf2},
+			})
+
+			Expect(report.Portfolios).To(Equal([]string{"a", "b"}))
+			Expect(report.Holdings).To(HaveLen(2))
+			Expect(report.Holdings[0].Ticker).To(Equal("VFINX"))
+			Expect(report.Holdings[0].Value).Should(BeNumerically("~", 2560, 1e-9))
+			Expect(report.Holdings[0].Portfolios).To(Equal([]string{"a", "b"}))
+			Expect(report.OverlapPercent).Should(BeNumerically("~", 2560.0/3910.0, 1e-9))
+		})
+	})
+})
@@ -0,0 +1,176 @@
+package portfolio
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// IncomeYear cash flows of a portfolio in a calendar year, for reconciling
+// against the tax forms issued by a broker. All amounts are positive.
+type IncomeYear struct {
+	Year int `json:"year"`
+
+	// Dividends dividends received on long positions
+	Dividends float64 `json:"dividends"`
+
+	// DividendGrowth change in Dividends from the prior year; 0 when the
+	// prior year had no dividends
+	DividendGrowth float64 `json:"dividendGrowth"`
+
+	// DividendsPaid dividends paid on short positions
+	DividendsPaid float64 `json:"dividendsPaid"`
+
+	// Interest interest earned on cash
+	Interest float64 `json:"interest"`
+
+	// TradingFees commissions and fees paid on buys and sells
+	TradingFees    float64 `json:"tradingFees"`
+	MarginInterest float64 `json:"marginInterest"`
+	BorrowFees     float64 `json:"borrowFees"`
+	AdvisoryFees   float64 `json:"advisoryFees"`
+
+	Deposits    float64 `json:"deposits"`
+	Withdrawals float64 `json:"withdrawals"`
+}
+
+// Fees total of trading fees, margin interest, borrow fees, and advisory fees
+func (y *IncomeYear) Fees() float64 {
+	return y.TradingFees + y.MarginInterest + y.BorrowFees + y.AdvisoryFees
+}
+
+// IncomeStatement summarize the dividends, interest, fees, deposits, and
+// withdrawals in trxs per calendar year, oldest first. Years without any of
+// these cash flows are omitted.
+func IncomeStatement(trxs []Transaction) []IncomeYear {
+	years := make(map[int]*IncomeYear)
+	year := func(trx *Transaction) *IncomeYear {
+		y := trx.Date.Year()
+		if _, ok := years[y]; !ok {
+			years[y] = &IncomeYear{Year: y}
+		}
+		return years[y]
+	}
+
+	for ii := range trxs {
+		trx := &trxs[ii]
+		switch trx.Kind {
+		case DividendTransaction:
+			// short positions pay the dividend, which is recorded as a negative value
+			if trx.TotalValue >= 0 {
+				year(trx).Dividends += trx.TotalValue
+			} else {
+				year(trx).DividendsPaid -= trx.TotalValue
+			}
+		case InterestTransaction:
+			year(trx).Interest += trx.TotalValue
+		case MarginInterestTransaction:
+			year(trx).MarginInterest += trx.TotalValue
+		case BorrowFeeTransaction:
+			year(trx).BorrowFees += trx.TotalValue
+		case AdvisoryFeeTransaction:
+			year(trx).AdvisoryFees += trx.TotalValue
+		case DepositTransaction:
+			year(trx).Deposits += trx.TotalValue
+		case WithdrawTransaction:
+			year(trx).Withdrawals += trx.TotalValue
+		case BuyTransaction, SellTransaction:
+			if trx.Fees != 0 {
+				year(trx).TradingFees += trx.Fees
+			}
+		}
+	}
+
+	statement := make([]IncomeYear, 0, len(years))
+	for _, y := range years {
+		statement = append(statement, *y)
+	}
+	sort.Slice(statement, func(i, j int) bool {
+		return statement[i].Year < statement[j].Year
+	})
+
+	for ii := 1; ii < len(statement); ii++ {
+		prior := &statement[ii-1]
+		if prior.Year == statement[ii].Year-1 && prior.Dividends > 0 {
+			statement[ii].DividendGrowth = statement[ii].Dividends/prior.Dividends - 1
+		}
+	}
+
+	return statement
+}
+
+// IncomeStats dividend income of a portfolio, for investors that live off
+// of its distributions. Dividends paid on short positions are deducted.
+type IncomeStats struct {
+	// TrailingIncome dividends over the 12 months before the last measurement
+	TrailingIncome float64 `json:"trailingIncome"`
+
+	// TrailingYield TrailingIncome as a fraction of the last measured value
+	TrailingYield float64 `json:"trailingYield"`
+
+	// IncomePerPeriod average dividends per measurement period
+	IncomePerPeriod float64 `json:"incomePerPeriod"`
+
+	// IncomeGrowthRate compound annual growth of dividends between the
+	// first and last complete calendar years that paid dividends
+	IncomeGrowthRate float64 `json:"incomeGrowthRate"`
+}
+
+// IncomeStats summarize the dividends of the portfolio; returns nil if it
+// did not receive any dividends
+func (perf *Performance) IncomeStats() *IncomeStats {
+	measurements := make([]PerformanceMeasurement, 0, len(perf.Measurements))
+	for _, meas := range perf.Measurements {
+		if !meas.WarmUp {
+			measurements = append(measurements, meas)
+		}
+	}
+	if len(measurements) == 0 {
+		return nil
+	}
+
+	first := time.Unix(measurements[0].Time, 0).UTC()
+	last := time.Unix(measurements[len(measurements)-1].Time, 0).UTC()
+	trailing := last.AddDate(-1, 0, 0)
+
+	var stats IncomeStats
+	var total float64
+	received := false
+	years := make(map[int]float64)
+	for _, trx := range perf.Transactions {
+		if trx.Kind != DividendTransaction || trx.Date.After(last) {
+			continue
+		}
+		received = true
+		total += trx.TotalValue
+		years[trx.Date.Year()] += trx.TotalValue
+		if trx.Date.After(trailing) {
+			stats.TrailingIncome += trx.TotalValue
+		}
+	}
+	if !received {
+		return nil
+	}
+
+	if value := measurements[len(measurements)-1].Value; value > 0 {
+		stats.TrailingYield = stats.TrailingIncome / value
+	}
+	stats.IncomePerPeriod = total / float64(len(measurements))
+
+	// the first and last years are only partially measured
+	firstYear, lastYear := 0, 0
+	for year := first.Year() + 1; year < last.Year(); year++ {
+		if years[year] <= 0 {
+			continue
+		}
+		if firstYear == 0 {
+			firstYear = year
+		}
+		lastYear = year
+	}
+	if lastYear > firstYear {
+		stats.IncomeGrowthRate = math.Pow(years[lastYear]/years[firstYear], 1.0/float64(lastYear-firstYear)) - 1
+	}
+
+	return &stats
+}
@@ -0,0 +1,116 @@
+package portfolio_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"main/portfolio"
+)
+
+var _ = Describe("Income", func() {
+	var (
+		trxs []portfolio.Transaction
+	)
+
+	BeforeEach(func() {
+		d1 := time.Date(2019, time.January, 31, 0, 0, 0, 0, time.UTC)
+		d2 := time.Date(2019, time.June, 28, 0, 0, 0, 0, time.UTC)
+		d3 := time.Date(2021, time.March, 31, 0, 0, 0, 0, time.UTC)
+
+		trxs = []portfolio.Transaction{
+			{Date: d1, Ticker: "$CASH", Kind: portfolio.DepositTransaction, TotalValue: 10000},
+			{Date: d1, Ticker: "VFINX", Kind: portfolio.BuyTransaction, Shares: 50, PricePerShare: 100, TotalValue: 5000, Fees: 4.95},
+			{Date: d1, Ticker: "SPY", Kind: portfolio.SellTransaction, Shares: 10, PricePerShare: 250, TotalValue: 2500, Fees: 4.95},
+			{Date: d2, Ticker: "VFINX", Kind: portfolio.DividendTransaction, TotalValue: 42.10},
+			{Date: d2, Ticker: "SPY", Kind: portfolio.DividendTransaction, TotalValue: -13.50},
+			{Date: d2, Ticker: "$CASH", Kind: portfolio.InterestTransaction, TotalValue: 12.25},
+			{Date: d2, Ticker: "SPY", Kind: portfolio.BorrowFeeTransaction, TotalValue: 3.10},
+			{Date: d2, Ticker: "$CASH", Kind: portfolio.MarginInterestTransaction, TotalValue: 7.80},
+			{Date: d3, Ticker: "$CASH", Kind: portfolio.WithdrawTransaction, TotalValue: 1000},
+		}
+	})
+
+	Describe("When summarizing cash flows", func() {
+		It("should total each kind of cash flow per year", func() {
+			statement := portfolio.IncomeStatement(trxs)
+			Expect(statement).To(HaveLen(2))
+
+			Expect(statement[0].Year).To(Equal(2019))
+			Expect(statement[0].Deposits).Should(BeNumerically("~", 10000, 1e-9))
+			Expect(statement[0].Dividends).Should(BeNumerically("~", 42.10, 1e-9))
+			Expect(statement[0].DividendsPaid).Should(BeNumerically("~", 13.50, 1e-9))
+			Expect(statement[0].Interest).Should(BeNumerically("~", 12.25, 1e-9))
+			Expect(statement[0].TradingFees).Should(BeNumerically("~", 9.90, 1e-9))
+			Expect(statement[0].BorrowFees).Should(BeNumerically("~", 3.10, 1e-9))
+			Expect(statement[0].MarginInterest).Should(BeNumerically("~", 7.80, 1e-9))
+			Expect(statement[0].Fees()).Should(BeNumerically("~", 20.80, 1e-9))
+			Expect(statement[0].Withdrawals).To(BeZero())
+		})
+
+		It("should omit years without cash flows", func() {
+			statement := portfolio.IncomeStatement(trxs)
+			Expect(statement[1]).To(Equal(portfolio.IncomeYear{
+				Year:        2021,
+				Withdrawals: 1000,
+			}))
+		})
+
+		It("should report the growth of dividends from the prior year", func() {
+			trxs = append(trxs, portfolio.Transaction{Date: time.Date(2020, time.June, 30, 0, 0, 0, 0, time.UTC), Ticker: "VFINX", Kind: portfolio.DividendTransaction, TotalValue: 50.52})
+			statement := portfolio.IncomeStatement(trxs)
+			Expect(statement).To(HaveLen(3))
+			Expect(statement[0].DividendGrowth).To(BeZero())
+			Expect(statement[1].DividendGrowth).Should(BeNumerically("~", 0.2, 1e-9))
+			Expect(statement[2].DividendGrowth).Should(BeNumerically("~", -1, 1e-9))
+		})
+	})
+
+	Describe("When summarizing dividend income", func() {
+		var (
+			perf portfolio.Performance
+		)
+
+		BeforeEach(func() {
+			perf = portfolio.Performance{}
+			for year := 2017; year <= 2020; year++ {
+				for month := time.January; month <= time.December; month++ {
+					perf.Measurements = append(perf.Measurements, portfolio.PerformanceMeasurement{
+						Time:  time.Date(year, month, 28, 0, 0, 0, 0, time.UTC).Unix(),
+						Value: 20000,
+					})
+				}
+				for _, month := range []time.Month{time.March, time.September} {
+					perf.Transactions = append(perf.Transactions, portfolio.Transaction{
+						Date:       time.Date(year, month, 20, 0, 0, 0, 0, time.UTC),
+						Ticker:     "VFINX",
+						Kind:       portfolio.DividendTransaction,
+						TotalValue: 100 * float64(year-2016),
+					})
+				}
+			}
+		})
+
+		It("should compute the trailing yield and income growth", func() {
+			stats := perf.IncomeStats()
+			Expect(stats).NotTo(BeNil())
+			Expect(stats.TrailingIncome).Should(BeNumerically("~", 800, 1e-9))
+			Expect(stats.TrailingYield).Should(BeNumerically("~", 0.04, 1e-9))
+			Expect(stats.IncomePerPeriod).Should(BeNumerically("~", 2000.0/48.0, 1e-9))
+
+			// 2018 and 2019 are the only complete years
+			Expect(stats.IncomeGrowthRate).Should(BeNumerically("~", 0.5, 1e-9))
+		})
+
+		It("should be included in the metrics bundle", func() {
+			perf.BuildMetricsBundle()
+			Expect(perf.MetricsBundle.Income).NotTo(BeNil())
+		})
+
+		It("should be omitted without dividends", func() {
+			perf.Transactions = nil
+			Expect(perf.IncomeStats()).To(BeNil())
+		})
+	})
+})
@@ -0,0 +1,94 @@
+package portfolio
+
+import (
+	"sort"
+	"time"
+
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+// DefaultMaxVolumeFraction fraction of a security's daily dollar volume a
+// single trade may be before the strategy's capacity is questionable
+const DefaultMaxVolumeFraction = 0.01
+
+// LiquidityWarning a trade of a backtest that is a larger fraction of the
+// security's dollar volume that day than the market could likely absorb
+// without moving the price
+type LiquidityWarning struct {
+	Date         time.Time `json:"date"`
+	Ticker       string    `json:"ticker"`
+	Kind         string    `json:"kind"`
+	TradeValue   float64   `json:"tradeValue"`
+	DollarVolume float64   `json:"dollarVolume"`
+	Fraction     float64   `json:"fraction"`
+}
+
+// DollarVolume daily dollar volume of a security: the close price (the
+// column of close) times the number of shares traded (the column of
+// volume), keyed by date. Both must be unadjusted so splits cancel out.
+func DollarVolume(close *dataframe.DataFrame, volume *dataframe.DataFrame, column string) map[string]float64 {
+	closeDates, prices := columnObservations(close, column)
+	priceOn := make(map[string]float64, len(prices))
+	for ii, date := range closeDates {
+		priceOn[date.Format("2006-01-02")] = prices[ii]
+	}
+
+	volumeDates, shares := columnObservations(volume, column)
+	res := make(map[string]float64, len(shares))
+	for ii, date := range volumeDates {
+		day := date.Format("2006-01-02")
+		if price, ok := priceOn[day]; ok {
+			res[day] = price * shares[ii]
+		}
+	}
+	return res
+}
+
+// CheckLiquidity compare each buy and sell of the performance to the dollar
+// volume of the security on the day of the trade (as returned by
+// DollarVolume, keyed by ticker) and warn about trades larger than
+// maxFraction of it. Trades on days without volume data are not checked.
+func (perf *Performance) CheckLiquidity(dollarVolume map[string]map[string]float64, maxFraction float64) []LiquidityWarning {
+	warnings := []LiquidityWarning{}
+	for _, trx := range perf.Transactions {
+		if trx.Ticker == "$CASH" || (trx.Kind != BuyTransaction && trx.Kind != SellTransaction) {
+			continue
+		}
+
+		volume, ok := dollarVolume[trx.Ticker][trx.Date.Format("2006-01-02")]
+		if !ok || volume <= 0 {
+			continue
+		}
+
+		fraction := trx.TotalValue / volume
+		if fraction > maxFraction {
+			warnings = append(warnings, LiquidityWarning{
+				Date:         trx.Date,
+				Ticker:       trx.Ticker,
+				Kind:         trx.Kind,
+				TradeValue:   trx.TotalValue,
+				DollarVolume: volume,
+				Fraction:     fraction,
+			})
+		}
+	}
+	return warnings
+}
+
+// TradedTickers securities bought or sold by the performance, whose volume
+// is needed to check its liquidity
+func (perf *Performance) TradedTickers() []string {
+	seen := map[string]bool{}
+	tickers := []string{}
+	for _, trx := range perf.Transactions {
+		if trx.Ticker == "$CASH" || (trx.Kind != BuyTransaction && trx.Kind != SellTransaction) {
+			continue
+		}
+		if !seen[trx.Ticker] {
+			seen[trx.Ticker] = true
+			tickers = append(tickers, trx.Ticker)
+		}
+	}
+	sort.Strings(tickers)
+	return tickers
+}
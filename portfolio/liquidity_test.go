@@ -0,0 +1,72 @@
+package portfolio_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/rocketlaunchr/dataframe-go"
+
+	"main/data"
+	"main/portfolio"
+)
+
+var _ = Describe("Liquidity", func() {
+	var (
+		perf         portfolio.Performance
+		dollarVolume map[string]map[string]float64
+		d1, d2, d3   time.Time
+	)
+
+	BeforeEach(func() {
+		d1 = time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)
+		d2 = time.Date(2021, time.March, 2, 0, 0, 0, 0, time.UTC)
+		d3 = time.Date(2021, time.March, 3, 0, 0, 0, 0, time.UTC)
+
+		// $10 a share with 10,000 shares traded on d1 and 1,000 on d2; d3 is
+		// missing
+		dates := []time.Time{d1, d2}
+		closes := dataframe.NewDataFrame(
+			dataframe.NewSeriesTime(data.DateIdx, &dataframe.SeriesInit{Size: len(dates)}, dates),
+			dataframe.NewSeriesFloat64("XYZ", &dataframe.SeriesInit{Size: 2}, 10.0, 10.0),
+		)
+		volumes := dataframe.NewDataFrame(
+			dataframe.NewSeriesTime(data.DateIdx, &dataframe.SeriesInit{Size: len(dates)}, dates),
+			dataframe.NewSeriesFloat64("XYZ", &dataframe.SeriesInit{Size: 2}, 10000.0, 1000.0),
+		)
+		dollarVolume = map[string]map[string]float64{
+			"XYZ": portfolio.DollarVolume(closes, volumes, "XYZ"),
+		}
+
+		perf = portfolio.Performance{
+			Transactions: []portfolio.Transaction{
+				{Date: d1, Ticker: "$CASH", Kind: portfolio.DepositTransaction, TotalValue: 10000},
+				{Date: d1, Ticker: "XYZ", Kind: portfolio.BuyTransaction, Shares: 500, TotalValue: 5000},
+				{Date: d2, Ticker: "XYZ", Kind: portfolio.SellTransaction, Shares: 500, TotalValue: 5000},
+				{Date: d3, Ticker: "XYZ", Kind: portfolio.BuyTransaction, Shares: 500, TotalValue: 5000},
+			},
+		}
+	})
+
+	It("should compute the dollar volume of each day", func() {
+		Expect(dollarVolume["XYZ"]).To(Equal(map[string]float64{
+			"2021-03-01": 100000,
+			"2021-03-02": 10000,
+		}))
+	})
+
+	It("should warn about trades larger than the fraction of volume", func() {
+		warnings := perf.CheckLiquidity(dollarVolume, 0.1)
+		Expect(warnings).To(Equal([]portfolio.LiquidityWarning{
+			{Date: d2, Ticker: "XYZ", Kind: portfolio.SellTransaction, TradeValue: 5000, DollarVolume: 10000, Fraction: 0.5},
+		}))
+	})
+
+	It("should warn about every trade with a small enough fraction", func() {
+		Expect(perf.CheckLiquidity(dollarVolume, 0.01)).To(HaveLen(2))
+	})
+
+	It("should list the traded securities", func() {
+		Expect(perf.TradedTickers()).To(Equal([]string{"XYZ"}))
+	})
+})
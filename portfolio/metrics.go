@@ -1,6 +1,8 @@
 package portfolio
 
 import (
+	"main/data"
+	"main/logging"
 	"math"
 	"sort"
 	"time"
@@ -24,6 +26,14 @@ type CAGR struct {
 	TenYear   float64 `json:"10-yr"`
 }
 
+// LeverageStats statistics describing the use of margin by a portfolio
+type LeverageStats struct {
+	Average        float64 `json:"average"`
+	Max            float64 `json:"max"`
+	PercentTime    float64 `json:"percentTime"`
+	MarginInterest float64 `json:"marginInterest"`
+}
+
 // MetricsBundle collection of statistics for a portfolio
 type MetricsBundle struct {
 	CAGRS         CAGR        `json:"cagrs"`
@@ -32,6 +42,40 @@ type MetricsBundle struct {
 	SortinoRatio  float64     `json:"sortinoRatio"`
 	StdDev        float64     `json:"stdDev"`
 	UlcerIndexAvg float64     `json:"ulcerIndexAvg"`
+
+	// UlcerIndex and PainIndex are in percent over the full period
+	UlcerIndex  float64 `json:"ulcerIndex"`
+	MartinRatio float64 `json:"martinRatio"`
+	PainIndex   float64 `json:"painIndex"`
+	PainRatio   float64 `json:"painRatio"`
+
+	Leverage *LeverageStats `json:"leverage,omitempty"`
+
+	// AdvisoryFees total advisory fees deducted from the portfolio
+	AdvisoryFees float64 `json:"advisoryFees,omitempty"`
+
+	// Income yield and growth of the dividends received by the portfolio
+	Income *IncomeStats `json:"income,omitempty"`
+
+	CalmarRatio      float64 `json:"calmarRatio"`
+	KRatio           float64 `json:"kRatio"`
+	Skewness         float64 `json:"skewness"`
+	ExcessKurtosis   float64 `json:"excessKurtosis"`
+	GainLossRatio    float64 `json:"gainLossRatio"`
+	NPositivePeriods int     `json:"nPositivePeriods"`
+
+	// Trades statistics of the round trips in each security
+	Trades TradeStats `json:"trades"`
+
+	// TaxCostRatio estimated fraction of the annualized return lost to taxes
+	// in a taxable account at the rates the after-tax values were
+	// calculated with, or DefaultTaxRates
+	TaxCostRatio float64 `json:"taxCostRatio"`
+
+	// TrackingError and InformationRatio are relative to the first benchmark
+	// the portfolio is compared against
+	TrackingError    float64 `json:"trackingError,omitempty"`
+	InformationRatio float64 `json:"informationRatio,omitempty"`
 }
 
 func min(x, y int) int {
@@ -57,11 +101,78 @@ func (perf *Performance) BuildMetricsBundle() {
 		SortinoRatio:  perf.SortinoRatio(),
 		StdDev:        perf.StdDev(),
 		UlcerIndexAvg: perf.AvgUlcerIndex(14),
+		Leverage:      perf.LeverageStats(),
+		AdvisoryFees:  perf.AdvisoryFees(),
+		Income:        perf.IncomeStats(),
+
+		CalmarRatio:      perf.CalmarRatio(),
+		KRatio:           perf.KRatio(),
+		Skewness:         perf.Skewness(),
+		ExcessKurtosis:   perf.ExcessKurtosis(),
+		GainLossRatio:    perf.GainLossRatio(),
+		NPositivePeriods: perf.NPositivePeriods(),
+		Trades:           perf.TradeStats(),
+
+		UlcerIndex:  perf.PeriodUlcerIndex(),
+		MartinRatio: perf.MartinRatio(),
+		PainIndex:   perf.PainIndex(),
+		PainRatio:   perf.PainRatio(),
 	}
 
+	rates := DefaultTaxRates
+	if perf.taxRates != nil {
+		rates = *perf.taxRates
+	}
+	bundle.TaxCostRatio = perf.TaxCostRatio(rates)
+
 	perf.MetricsBundle = bundle
 }
 
+// LeverageStats summarize the leverage of the portfolio over time; returns
+// nil if the portfolio was not computed with leverage
+func (perf *Performance) LeverageStats() *LeverageStats {
+	var stats LeverageStats
+	var total float64
+	var n int
+	var leveraged int
+	for _, m := range perf.Measurements {
+		if m.Leverage == 0 {
+			continue
+		}
+		total += m.Leverage
+		n++
+		stats.Max = math.Max(stats.Max, m.Leverage)
+		if m.Leverage > 1.0+1.0e-5 {
+			leveraged++
+		}
+	}
+
+	if n == 0 {
+		return nil
+	}
+
+	stats.Average = total / float64(n)
+	stats.PercentTime = float64(leveraged) / float64(n)
+	for _, trx := range perf.Transactions {
+		if trx.Kind == MarginInterestTransaction {
+			stats.MarginInterest += trx.TotalValue
+		}
+	}
+
+	return &stats
+}
+
+// AdvisoryFees total of the advisory fees deducted from the portfolio
+func (perf *Performance) AdvisoryFees() float64 {
+	var total float64
+	for _, trx := range perf.Transactions {
+		if trx.Kind == AdvisoryFeeTransaction {
+			total += trx.TotalValue
+		}
+	}
+	return total
+}
+
 // DrawDowns compute top 10 draw downs
 func (perf *Performance) DrawDowns() []*DrawDown {
 	if len(perf.Measurements) <= 0 {
@@ -103,7 +214,32 @@ func (perf *Performance) DrawDowns() []*DrawDown {
 	return allDrawDowns[0:min(10, len(allDrawDowns))]
 }
 
-// OneDayReturn compute the return over the last day
+// annualPeriods number of measurements per year at the performance's
+// resolution
+func (perf *Performance) annualPeriods() float64 {
+	switch perf.Resolution {
+	case data.FrequencyDaily:
+		return 252
+	case data.FrequencyWeekly:
+		return 52
+	default:
+		return 12
+	}
+}
+
+// measuredValueAsOf value of the last measurement on or before forDate
+func (perf *Performance) measuredValueAsOf(forDate time.Time) (float64, bool) {
+	for ii := len(perf.Measurements) - 1; ii >= 0; ii-- {
+		if !time.Unix(perf.Measurements[ii].Time, 0).After(forDate) {
+			return perf.Measurements[ii].Value, true
+		}
+	}
+	return 0, false
+}
+
+// OneDayReturn compute the return over the last day. Daily measurements are
+// used if available, otherwise the value of the portfolio one day ago is
+// computed from its holdings
 func (perf *Performance) OneDayReturn(forDate time.Time, p *Portfolio) float64 {
 	// Compute 1-day return
 	value := perf.Measurements
@@ -113,12 +249,18 @@ func (perf *Performance) OneDayReturn(forDate time.Time, p *Portfolio) float64 {
 		todaysValue = value[sz-1].Value
 	}
 
+	if perf.Resolution == data.FrequencyDaily {
+		if yesterdayValue, ok := perf.measuredValueAsOf(forDate.AddDate(0, 0, -1)); ok && yesterdayValue > 0 {
+			return todaysValue/yesterdayValue - 1.0
+		}
+	}
+
 	yesterdayValue, err := p.ValueAsOf(forDate.AddDate(0, 0, -1))
 	if err != nil {
 		log.WithFields(log.Fields{
-			"TargetDate": forDate.AddDate(0, 0, -1),
-			"Function":   "cmd/notifier/main.go:oneDayReturn",
-			"Error":      err,
+			"TargetDate":          forDate.AddDate(0, 0, -1),
+			logging.FieldFunction: "cmd/notifier/main.go:oneDayReturn",
+			logging.FieldError:    err,
 		}).Error("Cannot get value of portfolio for date")
 	}
 
@@ -129,7 +271,8 @@ func (perf *Performance) OneDayReturn(forDate time.Time, p *Portfolio) float64 {
 	return 0
 }
 
-// OneWeekReturn compute the return over one week
+// OneWeekReturn compute the return over one week. Daily or weekly
+// measurements are used if available
 func (perf *Performance) OneWeekReturn(forDate time.Time, p *Portfolio) float64 {
 	// Compute 1-day return
 	value := perf.Measurements
@@ -139,12 +282,18 @@ func (perf *Performance) OneWeekReturn(forDate time.Time, p *Portfolio) float64
 		todaysValue = value[sz-1].Value
 	}
 
+	if perf.Resolution == data.FrequencyDaily || perf.Resolution == data.FrequencyWeekly {
+		if lastWeekValue, ok := perf.measuredValueAsOf(forDate.AddDate(0, 0, -7)); ok && lastWeekValue > 0 {
+			return todaysValue/lastWeekValue - 1.0
+		}
+	}
+
 	lastWeekValue, err := p.ValueAsOf(forDate.AddDate(0, 0, -7))
 	if err != nil {
 		log.WithFields(log.Fields{
-			"TargetDate": forDate.AddDate(0, 0, -7),
-			"Function":   "cmd/notifier/main.go:oneDayReturn",
-			"Error":      err,
+			"TargetDate":          forDate.AddDate(0, 0, -7),
+			logging.FieldFunction: "cmd/notifier/main.go:oneDayReturn",
+			logging.FieldError:    err,
 		}).Error("Cannot get value of portfolio for date")
 	}
 
@@ -169,7 +318,7 @@ func (perf *Performance) OneMonthReturn(forDate time.Time) float64 {
 	}
 
 	log.WithFields(log.Fields{
-		"Function": "cmd/notifier/main.go:oneMonthReturn",
+		logging.FieldFunction: "cmd/notifier/main.go:oneMonthReturn",
 	}).Error("Could not find one-month return for requested date")
 	return 0
 }
@@ -222,7 +371,7 @@ func (perf *Performance) StdDev() float64 {
 		stderr += math.Pow(xx.PercentReturn-m, 2)
 	}
 
-	return math.Sqrt(stderr/float64(N)) * math.Sqrt(12)
+	return math.Sqrt(stderr/float64(N)) * math.Sqrt(perf.annualPeriods())
 }
 
 // UlcerIndex The Ulcer Index (UI) is a technical indicator that measures downside
@@ -284,6 +433,102 @@ func (perf *Performance) AvgUlcerIndex(period int) float64 {
 	return stat.Mean(u, nil)
 }
 
+// funded the measurements after the portfolio was funded; warm-up
+// measurements have no value
+func (perf *Performance) funded() []PerformanceMeasurement {
+	measurements := make([]PerformanceMeasurement, 0, len(perf.Measurements))
+	for _, xx := range perf.Measurements {
+		if !xx.WarmUp {
+			measurements = append(measurements, xx)
+		}
+	}
+	return measurements
+}
+
+// percentDrawDowns decline of each funded measurement from the highest
+// preceding value in percent, as measured by UlcerIndex
+func (perf *Performance) percentDrawDowns() []float64 {
+	measurements := perf.funded()
+	dd := make([]float64, len(measurements))
+	var peak float64
+	for ii, xx := range measurements {
+		peak = math.Max(peak, xx.Value)
+		if peak > 0 {
+			dd[ii] = ((xx.Value - peak) / peak) * 100
+		}
+	}
+	return dd
+}
+
+// annualizedReturn compound annual growth of the portfolio's returns over
+// the funded period; deposits and withdrawals do not affect it
+func (perf *Performance) annualizedReturn() float64 {
+	measurements := perf.funded()
+	N := len(measurements)
+	if N < 2 {
+		return 0
+	}
+
+	growth := 1.0
+	for _, xx := range measurements[1:] {
+		growth *= 1.0 + xx.PercentReturn
+	}
+	years := float64(measurements[N-1].Time-measurements[0].Time) / (365.25 * 86400.0)
+	if years <= 0 || growth <= 0 {
+		return 0
+	}
+	return math.Pow(growth, 1.0/years) - 1.0
+}
+
+// PeriodUlcerIndex the Ulcer Index over the full measured period: the root
+// mean square of the percent draw down of each measurement from its prior
+// peak, rather than a rolling window as in UlcerIndex
+func (perf *Performance) PeriodUlcerIndex() float64 {
+	dd := perf.percentDrawDowns()
+	if len(dd) == 0 {
+		return 0
+	}
+
+	var sqSum float64
+	for _, xx := range dd {
+		sqSum += xx * xx
+	}
+	return math.Sqrt(sqSum / float64(len(dd)))
+}
+
+// PainIndex the average percent draw down of the measurements from their
+// prior peak. Unlike the Ulcer Index, deep draw downs are not penalized more
+// than their depth.
+func (perf *Performance) PainIndex() float64 {
+	dd := perf.percentDrawDowns()
+	if len(dd) == 0 {
+		return 0
+	}
+	return -stat.Mean(dd, nil)
+}
+
+// MartinRatio The Ulcer Performance Index; the annualized return earned per
+// unit of the Ulcer Index over the measured period
+// Martin = CAGR / UI
+func (perf *Performance) MartinRatio() float64 {
+	ui := perf.PeriodUlcerIndex()
+	if ui == 0 {
+		return 0
+	}
+	return perf.annualizedReturn() * 100 / ui
+}
+
+// PainRatio the annualized return earned per unit of the Pain Index over
+// the measured period
+// Pain Ratio = CAGR / Pain Index
+func (perf *Performance) PainRatio() float64 {
+	pain := perf.PainIndex()
+	if pain == 0 {
+		return 0
+	}
+	return perf.annualizedReturn() * 100 / pain
+}
+
 // ExcessReturn compute the rate of return that is in excess of the risk free rate
 func (perf *Performance) ExcessReturn() []float64 {
 	rets := make([]float64, len(perf.Measurements))
@@ -303,7 +548,7 @@ func (perf *Performance) ExcessReturn() []float64 {
 func (perf *Performance) SharpeRatio() float64 {
 	excessReturn := perf.ExcessReturn()
 	sharpe := stat.Mean(excessReturn, nil) / stat.StdDev(excessReturn, nil)
-	return sharpe * math.Sqrt(12) // annualize rate
+	return sharpe * math.Sqrt(perf.annualPeriods()) // annualize rate
 }
 
 // SortinoRatio a variation of the Sharpe ratio that differentiates harmful
@@ -329,11 +574,42 @@ func (perf *Performance) SortinoRatio() float64 {
 		return 0
 	}
 	sortino := stat.Mean(excessReturn, nil) / math.Sqrt(downside)
-	return sortino * math.Sqrt(12) // annualize rate by adjusting by month
+	return sortino * math.Sqrt(perf.annualPeriods()) // annualize rate by adjusting by measurement period
 }
 
 // KRatio The K-ratio is a valuation metric that examines the consistency of an equity's return over time.
 // k-ratio = (Slope logVAMI regression line) / n(Standard Error of the Slope)
+func (perf *Performance) KRatio() float64 {
+	n := len(perf.Measurements)
+	if n < 3 {
+		return 0
+	}
+
+	// log of the value added monthly index
+	xs := make([]float64, n)
+	logVAMI := make([]float64, n)
+	vami := 1.0
+	for ii, xx := range perf.Measurements {
+		vami *= 1.0 + xx.PercentReturn
+		xs[ii] = float64(ii)
+		logVAMI[ii] = math.Log(vami)
+	}
+
+	alpha, slope := stat.LinearRegression(xs, logVAMI, nil, false)
+
+	var sse float64
+	for ii := range xs {
+		resid := logVAMI[ii] - (alpha + slope*xs[ii])
+		sse += resid * resid
+	}
+	xVar := stat.Variance(xs, nil) * float64(n-1)
+	stderr := math.Sqrt(sse/float64(n-2)) / math.Sqrt(xVar)
+	if stderr == 0 {
+		return 0
+	}
+
+	return slope / (float64(n) * stderr)
+}
 
 // VolatilityMonthly
 
@@ -350,11 +626,12 @@ func (perf *Performance) SortinoRatio() float64 {
 // risky securities and for generating estimates of the expected returns of assets,
 // considering both the risk of those assets and the cost of capital.
 func (perf *Performance) Beta(benchmark *Performance) float64 {
-	retA := perf.Return()
-	retB := benchmark.Return()
-
-	covar := stat.Covariance(retA, retB, nil)
-	return covar / stat.Variance(retB, nil)
+	rp, rb, rf, times := alignedReturns(perf, benchmark)
+	if len(rp) < 2 {
+		return 0
+	}
+	_, beta := alphaBeta(rp, rb, rf, periodsPerYear(times))
+	return beta
 }
 
 // Alpha
@@ -364,30 +641,128 @@ func (perf *Performance) Beta(benchmark *Performance) float64 {
 // TreynorRatio also known as the reward-to-volatility ratio, is a performance
 // metric for determining how much excess return was generated for each unit of risk
 // taken on by a portfolio.
-// treynor = Excess Return / Beta
+// treynor = Annualized Excess Return / Beta
 func (perf *Performance) TreynorRatio(benchmark *Performance) float64 {
-	excessReturn := perf.ExcessReturn()
-	return stat.Mean(excessReturn, nil) / perf.Beta(benchmark)
+	rp, rb, rf, times := alignedReturns(perf, benchmark)
+	if len(rp) < 2 {
+		return 0
+	}
+	_, beta := alphaBeta(rp, rb, rf, periodsPerYear(times))
+	if beta == 0 {
+		return 0
+	}
+
+	excess := make([]float64, len(rp))
+	for ii := range rp {
+		excess[ii] = rp[ii] - rf[ii]
+	}
+	return stat.Mean(excess, nil) * periodsPerYear(times) / beta
 }
 
-// CalmarRatio
+// CalmarRatio compound annual return over the last 36 months divided by the
+// magnitude of the maximum draw down during the same period
+func (perf *Performance) CalmarRatio() float64 {
+	if len(perf.Measurements) == 0 {
+		return 0
+	}
+
+	finalDate := time.Unix(perf.Measurements[len(perf.Measurements)-1].Time, 0)
+	initialDate := finalDate.AddDate(-3, 0, 0)
 
-// Return
+	var peak float64
+	var maxDrawDown float64
+	for _, xx := range perf.Measurements {
+		if time.Unix(xx.Time, 0).Before(initialDate) {
+			continue
+		}
+		peak = math.Max(peak, xx.Value)
+		maxDrawDown = math.Min(maxDrawDown, xx.Value/peak-1.0)
+	}
+
+	if maxDrawDown == 0 {
+		return 0
+	}
+	return perf.PeriodCagr(3) / math.Abs(maxDrawDown)
+}
+
+// Return the return of each period after the portfolio was funded; warm-up
+// measurements and the first funded measurement have no return
 func (perf *Performance) Return() []float64 {
-	rets := make([]float64, len(perf.Measurements))
+	rets := []float64{}
 	for ii, xx := range perf.Measurements {
-		rets[ii] = xx.Value
+		if ii == 0 || xx.WarmUp || perf.Measurements[ii-1].WarmUp {
+			continue
+		}
+		rets = append(rets, xx.PercentReturn)
 	}
 	return rets
 }
 
-// TrackingError
+// TrackingError standard deviation of the difference between the portfolio's
+// and benchmark's returns, annualized
+func (perf *Performance) TrackingError(benchmark *Performance) float64 {
+	rp, rb, _, times := alignedReturns(perf, benchmark)
+	if len(rp) < 2 {
+		return 0
+	}
+
+	active := make([]float64, len(rp))
+	for ii := range rp {
+		active[ii] = rp[ii] - rb[ii]
+	}
+	return stat.StdDev(active, nil) * math.Sqrt(periodsPerYear(times))
+}
 
-// InformationRatio
+// InformationRatio annualized return in excess of the benchmark divided by
+// the tracking error
+func (perf *Performance) InformationRatio(benchmark *Performance) float64 {
+	trackingError := perf.TrackingError(benchmark)
+	if trackingError == 0 {
+		return 0
+	}
+
+	rp, rb, _, times := alignedReturns(perf, benchmark)
+	active := make([]float64, len(rp))
+	for ii := range rp {
+		active[ii] = rp[ii] - rb[ii]
+	}
+	return stat.Mean(active, nil) * periodsPerYear(times) / trackingError
+}
 
-// Skewness
+// periodReturns percent return of each measurement
+func (perf *Performance) periodReturns() []float64 {
+	rets := make([]float64, len(perf.Measurements))
+	for ii, xx := range perf.Measurements {
+		rets[ii] = xx.PercentReturn
+	}
+	return rets
+}
+
+// Skewness measure of the asymmetry of the distribution of returns; negative
+// values indicate large losses are more likely than large gains
+func (perf *Performance) Skewness() float64 {
+	if len(perf.Measurements) < 3 {
+		return 0
+	}
+	skew := stat.Skew(perf.periodReturns(), nil)
+	if math.IsNaN(skew) {
+		return 0
+	}
+	return skew
+}
 
-// ExcessKurtosis
+// ExcessKurtosis measure of the tails of the distribution of returns relative
+// to a normal distribution; positive values indicate fat tails
+func (perf *Performance) ExcessKurtosis() float64 {
+	if len(perf.Measurements) < 4 {
+		return 0
+	}
+	kurtosis := stat.ExKurtosis(perf.periodReturns(), nil)
+	if math.IsNaN(kurtosis) {
+		return 0
+	}
+	return kurtosis
+}
 
 // ValueAtRisk
 
@@ -399,6 +774,34 @@ func (perf *Performance) Return() []float64 {
 
 // PerpetualWithdrawalRate
 
-// NPositivePeriods
+// NPositivePeriods number of periods with a positive return
+func (perf *Performance) NPositivePeriods() int {
+	var cnt int
+	for _, xx := range perf.Measurements {
+		if xx.PercentReturn > 0 {
+			cnt++
+		}
+	}
+	return cnt
+}
 
-// GainLossRatio
+// GainLossRatio average return of periods with a gain divided by the magnitude
+// of the average return of periods with a loss
+func (perf *Performance) GainLossRatio() float64 {
+	var gain, loss float64
+	var nGain, nLoss int
+	for _, xx := range perf.Measurements {
+		if xx.PercentReturn > 0 {
+			gain += xx.PercentReturn
+			nGain++
+		} else if xx.PercentReturn < 0 {
+			loss += xx.PercentReturn
+			nLoss++
+		}
+	}
+
+	if nGain == 0 || nLoss == 0 {
+		return 0
+	}
+	return (gain / float64(nGain)) / math.Abs(loss/float64(nLoss))
+}
@@ -3,10 +3,13 @@ package portfolio_test
 import (
 	"encoding/json"
 	"io/ioutil"
+	"math"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	"main/data"
 	"main/portfolio"
 )
 
@@ -140,6 +143,107 @@ var _ = Describe("Metrics", func() {
 			It("should have a sortino ratio", func() {
 				Expect(perf2.SortinoRatio()).Should(BeNumerically("~", 2.066, 1e-3))
 			})
+
+			It("should have a calmar ratio", func() {
+				Expect(perf.CalmarRatio()).Should(BeNumerically("~", 0.4308, 1e-3))
+			})
+
+			It("should have a k-ratio", func() {
+				Expect(perf.KRatio()).Should(BeNumerically("~", 0.2453, 1e-3))
+			})
+
+			It("should have a skewness", func() {
+				Expect(perf.Skewness()).Should(BeNumerically("~", 0.2490, 1e-3))
+			})
+
+			It("should have an excess kurtosis", func() {
+				Expect(perf.ExcessKurtosis()).Should(BeNumerically("~", 4.8366, 1e-3))
+			})
+
+			It("should have a gain/loss ratio", func() {
+				Expect(perf.GainLossRatio()).Should(BeNumerically("~", 0.8885, 1e-3))
+			})
+
+			It("should count positive periods", func() {
+				Expect(perf.NPositivePeriods()).To(Equal(259))
+			})
+
+			It("should annualize by the measurement resolution", func() {
+				monthly := perf.StdDev()
+				perf.Resolution = data.FrequencyDaily
+				Expect(perf.StdDev()).Should(BeNumerically("~", monthly*math.Sqrt(252.0/12.0), 1e-9))
+			})
+
+			It("should use daily measurements for short-horizon returns", func() {
+				perf.Resolution = data.FrequencyDaily
+				last := perf.Measurements[len(perf.Measurements)-1]
+				prev := perf.Measurements[len(perf.Measurements)-2]
+				forDate := time.Unix(last.Time, 0)
+				Expect(perf.OneDayReturn(forDate, nil)).Should(BeNumerically("~", last.Value/prev.Value-1.0, 1e-9))
+			})
+
+			It("should have pain-based metrics", func() {
+				yearStart := func(year int) int64 {
+					return time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+				}
+				pain := portfolio.Performance{
+					Measurements: []portfolio.PerformanceMeasurement{
+						{Time: yearStart(2018), Value: 100},
+						{Time: yearStart(2019), Value: 110, PercentReturn: 0.10},
+						{Time: yearStart(2020), Value: 99, PercentReturn: -0.10},
+						{Time: yearStart(2021), Value: 133.1, PercentReturn: 133.1/99 - 1},
+					},
+				}
+
+				// the only draw down is 10% at the third measurement
+				Expect(pain.PeriodUlcerIndex()).Should(BeNumerically("~", 5.0, 1e-9))
+				Expect(pain.PainIndex()).Should(BeNumerically("~", 2.5, 1e-9))
+
+				// a CAGR of 10% over 3 years
+				Expect(pain.MartinRatio()).Should(BeNumerically("~", 2.0, 1e-3))
+				Expect(pain.PainRatio()).Should(BeNumerically("~", 4.0, 1e-3))
+			})
+
+			It("should skip warm-up measurements in pain-based metrics", func() {
+				yearStart := func(year int) int64 {
+					return time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+				}
+				pain := portfolio.Performance{
+					Measurements: []portfolio.PerformanceMeasurement{
+						{Time: yearStart(2016), WarmUp: true},
+						{Time: yearStart(2017), WarmUp: true},
+						{Time: yearStart(2018), Value: 100},
+						{Time: yearStart(2019), Value: 110, PercentReturn: 0.10},
+						{Time: yearStart(2020), Value: 99, PercentReturn: -0.10},
+						{Time: yearStart(2021), Value: 133.1, PercentReturn: 133.1/99 - 1},
+					},
+					WarmUpPeriods: 2,
+				}
+
+				Expect(pain.PeriodUlcerIndex()).Should(BeNumerically("~", 5.0, 1e-9))
+				Expect(pain.PainIndex()).Should(BeNumerically("~", 2.5, 1e-9))
+				Expect(pain.MartinRatio()).Should(BeNumerically("~", 2.0, 1e-3))
+				Expect(pain.PainRatio()).Should(BeNumerically("~", 4.0, 1e-3))
+			})
+
+			It("should have no pain-based ratios without draw downs", func() {
+				perf2.Measurements = perf2.Measurements[:1]
+				Expect(perf2.PainIndex()).Should(BeNumerically("==", 0))
+				Expect(perf2.MartinRatio()).Should(BeNumerically("==", 0))
+				Expect(perf2.PainRatio()).Should(BeNumerically("==", 0))
+			})
+
+			It("should include the metrics in the bundle", func() {
+				perf2.BuildMetricsBundle()
+				Expect(perf2.MetricsBundle.Skewness).Should(BeNumerically("~", 0.3509, 1e-3))
+				Expect(perf2.MetricsBundle.ExcessKurtosis).Should(BeNumerically("~", 3.6569, 1e-3))
+				Expect(perf2.MetricsBundle.NPositivePeriods).To(Equal(263))
+				Expect(perf2.MetricsBundle.GainLossRatio).Should(BeNumerically("~", 1.2231, 1e-3))
+				Expect(perf2.MetricsBundle.KRatio).Should(BeNumerically("~", 0.3694, 1e-3))
+				Expect(perf2.MetricsBundle.UlcerIndex).Should(BeNumerically("~", perf2.PeriodUlcerIndex(), 1e-9))
+				Expect(perf2.MetricsBundle.MartinRatio).Should(BeNumerically(">", 0))
+				Expect(perf2.MetricsBundle.PainRatio).Should(BeNumerically(">", perf2.MetricsBundle.MartinRatio))
+			})
 		})
 	})
 
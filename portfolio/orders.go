@@ -0,0 +1,108 @@
+package portfolio
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Sides of an order
+const (
+	OrderBuy  = "buy"
+	OrderSell = "sell"
+)
+
+// Order a trade to place with a broker. Price is an estimate from the
+// latest quote or close; the broker fills at the market price.
+type Order struct {
+	Ticker         string  `json:"ticker"`
+	Side           string  `json:"side"`
+	Shares         float64 `json:"shares"`
+	EstimatedPrice float64 `json:"estimatedPrice"`
+	EstimatedValue float64 `json:"estimatedValue"`
+}
+
+// OrderList orders that move the holdings of a brokerage account to the
+// latest signal of a strategy. Sells are listed first so they fund the
+// buys.
+type OrderList struct {
+	SignalDate   time.Time          `json:"signalDate"`
+	Target       map[string]float64 `json:"target"`
+	AccountValue float64            `json:"accountValue"`
+	Orders       []Order            `json:"orders"`
+
+	// Cash before the orders are placed and the estimated cash left after
+	CashBefore float64 `json:"cashBefore"`
+	CashAfter  float64 `json:"cashAfter"`
+
+	// Holdings estimated shares of each security after the orders fill
+	Holdings map[string]float64 `json:"holdings"`
+}
+
+// BuildOrders the orders that rebalance holdings, shares of each security
+// and cash under $CASH, to the target of signal at prices within the
+// execution constraints, e.g. whole shares. Every security held or in the
+// target must have a price.
+func BuildOrders(holdings map[string]float64, signal Signal, prices map[string]float64, constraints ExecutionConstraints) (OrderList, error) {
+	tickers := make([]string, 0, len(holdings)+len(signal.Target))
+	for ticker := range holdings {
+		tickers = append(tickers, ticker)
+	}
+	for ticker := range signal.Target {
+		tickers = append(tickers, ticker)
+	}
+	sort.Strings(tickers)
+	for _, ticker := range tickers {
+		if ticker == "$CASH" {
+			continue
+		}
+		if price, ok := prices[ticker]; !ok || price <= 0 {
+			return OrderList{}, fmt.Errorf("no price is available for %s", ticker)
+		}
+	}
+
+	account := Portfolio{
+		Holdings:  make(map[string]float64, len(holdings)),
+		Execution: constraints,
+	}
+	value := 0.0
+	for ticker, shares := range holdings {
+		account.Holdings[ticker] = shares
+		if ticker == "$CASH" {
+			value += shares
+		} else {
+			value += shares * prices[ticker]
+		}
+	}
+	if value <= 0 {
+		return OrderList{}, errors.New("account has no value to allocate")
+	}
+
+	after, sells, buys := account.constrainedRebalance(signal.Date, signal.Target, prices, value, nil)
+
+	orders := OrderList{
+		SignalDate:   signal.Date,
+		Target:       signal.Target,
+		AccountValue: value,
+		Orders:       make([]Order, 0, len(sells)+len(buys)),
+		CashBefore:   holdings["$CASH"],
+		CashAfter:    after["$CASH"],
+		Holdings:     after,
+	}
+	for _, trx := range append(sells, buys...) {
+		side := OrderBuy
+		if trx.Kind == SellTransaction {
+			side = OrderSell
+		}
+		orders.Orders = append(orders.Orders, Order{
+			Ticker:         trx.Ticker,
+			Side:           side,
+			Shares:         trx.Shares,
+			EstimatedPrice: trx.PricePerShare,
+			EstimatedValue: trx.TotalValue,
+		})
+	}
+
+	return orders, nil
+}
@@ -0,0 +1,78 @@
+package portfolio_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"main/portfolio"
+)
+
+var _ = Describe("Orders", func() {
+	var (
+		signal portfolio.Signal
+		prices map[string]float64
+	)
+
+	BeforeEach(func() {
+		signal = portfolio.Signal{
+			Date:   time.Date(2021, time.January, 29, 0, 0, 0, 0, time.UTC),
+			Target: map[string]float64{"VOO": 0.6, "VGLT": 0.4},
+		}
+		prices = map[string]float64{
+			"VOO":  300.0,
+			"VGLT": 90.0,
+			"VWO":  50.0,
+		}
+	})
+
+	Describe("When building orders", func() {
+		It("should sell positions no longer in the target before buying", func() {
+			holdings := map[string]float64{"VWO": 100.0, "$CASH": 5000.0}
+			orders, err := portfolio.BuildOrders(holdings, signal, prices, portfolio.ExecutionConstraints{WholeShares: true})
+			Expect(err).To(BeNil())
+			Expect(orders.AccountValue).Should(BeNumerically("~", 10000.0, 1e-9))
+			Expect(orders.Orders).To(HaveLen(3))
+
+			Expect(orders.Orders[0]).To(Equal(portfolio.Order{
+				Ticker:         "VWO",
+				Side:           portfolio.OrderSell,
+				Shares:         100,
+				EstimatedPrice: 50,
+				EstimatedValue: 5000,
+			}))
+
+			// whole shares of the 6000 and 4000 targets
+			Expect(orders.Orders[1].Ticker).To(Equal("VGLT"))
+			Expect(orders.Orders[1].Side).To(Equal(portfolio.OrderBuy))
+			Expect(orders.Orders[1].Shares).To(Equal(44.0))
+			Expect(orders.Orders[2].Ticker).To(Equal("VOO"))
+			Expect(orders.Orders[2].Shares).To(Equal(20.0))
+
+			Expect(orders.CashBefore).Should(BeNumerically("~", 5000.0, 1e-9))
+			Expect(orders.CashAfter).Should(BeNumerically("~", 40.0, 1e-9))
+			Expect(orders.Holdings).NotTo(HaveKey("VWO"))
+		})
+
+		It("should only trade the difference from the current holdings", func() {
+			holdings := map[string]float64{"VOO": 20.0, "VGLT": 40.0, "$CASH": 400.0}
+			orders, err := portfolio.BuildOrders(holdings, signal, prices, portfolio.ExecutionConstraints{WholeShares: true})
+			Expect(err).To(BeNil())
+			Expect(orders.Orders).To(HaveLen(1))
+			Expect(orders.Orders[0].Ticker).To(Equal("VGLT"))
+			Expect(orders.Orders[0].Shares).To(Equal(4.0))
+		})
+
+		It("should require a price for every security", func() {
+			delete(prices, "VGLT")
+			_, err := portfolio.BuildOrders(map[string]float64{"$CASH": 1000.0}, signal, prices, portfolio.ExecutionConstraints{})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should require an account with value", func() {
+			_, err := portfolio.BuildOrders(map[string]float64{}, signal, prices, portfolio.ExecutionConstraints{})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,88 @@
+package portfolio
+
+import (
+	"main/data"
+	"time"
+)
+
+// periodBounds the calendar period of resolution that date falls in. start
+// is the last day of the prior period so a full period spans end.Sub(start)
+func periodBounds(date time.Time, resolution string) (time.Time, time.Time) {
+	year, month, day := date.Date()
+	date = time.Date(year, month, day, 0, 0, 0, 0, date.Location())
+
+	switch resolution {
+	case data.FrequencyWeekly:
+		// weeks run Monday through Sunday
+		offset := (int(date.Weekday()) + 6) % 7
+		start := date.AddDate(0, 0, -offset-1)
+		return start, start.AddDate(0, 0, 7)
+	case data.FrequencyMonthly:
+		start := time.Date(year, month, 1, 0, 0, 0, 0, date.Location()).AddDate(0, 0, -1)
+		return start, time.Date(year, month+1, 1, 0, 0, 0, 0, date.Location()).AddDate(0, 0, -1)
+	}
+	return date.AddDate(0, 0, -1), date
+}
+
+// periodFraction the fraction of the period ending at date that falls
+// between begin and through
+func periodFraction(date, begin, through time.Time, resolution string) float64 {
+	periodStart, periodEnd := periodBounds(date, resolution)
+	start, end := periodStart, periodEnd
+	if begin.After(start) {
+		start = begin
+	}
+	if through.Before(end) {
+		end = through
+	}
+
+	if !end.After(start) {
+		return 0
+	}
+	return float64(end.Sub(start)) / float64(periodEnd.Sub(periodStart))
+}
+
+// markPartialPeriods flag the first and last measurements after the
+// warm-up period when the backtest begins or ends part way through a
+// period, e.g. a monthly backtest started on the 15th. Their returns cover
+// only the fraction of the period the portfolio was measured.
+func (perf *Performance) markPartialPeriods(begin, through time.Time) {
+	if perf.Resolution == data.FrequencyDaily {
+		return
+	}
+
+	first := -1
+	last := -1
+	for ii, meas := range perf.Measurements {
+		if meas.WarmUp {
+			continue
+		}
+		if first == -1 {
+			first = ii
+		}
+		last = ii
+	}
+	if first == -1 {
+		return
+	}
+
+	for _, ii := range []int{first, last} {
+		meas := &perf.Measurements[ii]
+		date := time.Unix(meas.Time, 0).UTC()
+		if date.After(through) {
+			// the quotes ran past the end of the backtest
+			continue
+		}
+		fraction := periodFraction(date, begin, through, perf.Resolution)
+		if fraction <= 0 || fraction >= 1 {
+			continue
+		}
+		meas.PeriodFraction = fraction
+		if ii == first {
+			perf.PartialFirstPeriod = true
+		}
+		if ii == last {
+			perf.PartialLastPeriod = true
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"main/data"
 	"main/dfextras"
+	"main/logging"
 	"math"
 	"sort"
 	"strings"
@@ -19,12 +20,29 @@ const (
 	TickerName = "TICKER"
 )
 
+// DefaultInitialCapital amount deposited when a portfolio is created if the
+// user doesn't choose one
+const DefaultInitialCapital = 10000.0
+
 const (
 	SellTransaction     = "SELL"
 	BuyTransaction      = "BUY"
 	DepositTransaction  = "DEPOSIT"
 	WithdrawTransaction = "WITHDRAW"
 	MarkerTransaction   = "MARKER"
+	SplitTransaction    = "SPLIT"
+	InterestTransaction = "INTEREST"
+
+	MarginInterestTransaction = "MARGIN_INTEREST"
+	BorrowFeeTransaction      = "BORROW_FEE"
+	DividendTransaction       = "DIVIDEND"
+	AdvisoryFeeTransaction    = "ADVISORY_FEE"
+)
+
+// Frequencies advisory fees are deducted at
+const (
+	FeeMonthly   = "monthly"
+	FeeQuarterly = "quarterly"
 )
 
 type Transaction struct {
@@ -34,6 +52,7 @@ type Transaction struct {
 	PricePerShare float64                `json:"pricePerShare"`
 	Shares        float64                `json:"shares"`
 	TotalValue    float64                `json:"totalValue"`
+	Fees          float64                `json:"fees"`
 	Justification map[string]interface{} `json:"justification"`
 }
 
@@ -50,9 +69,82 @@ type Portfolio struct {
 	EndTime      time.Time
 	Transactions []Transaction
 	Holdings     map[string]float64
-	dataProxy    *data.Manager
-	securities   map[string]bool
-	priceData    map[string]*dataframe.DataFrame
+
+	// Signals the allocation the strategy selected on each date of the
+	// target portfolio and the scores that justified it; set by
+	// TargetPortfolio
+	Signals []Signal
+
+	// CashInterest accrue interest on idle cash balances when calculating
+	// performance. Interest is paid at CashInterestRate (annual percent) or
+	// at the risk-free rate if CashInterestRate is 0
+	CashInterest     bool
+	CashInterestRate float64
+
+	// Leverage ratio of gross exposure to equity. When greater than 1 the
+	// risky weights of target portfolios are scaled by Leverage and the
+	// borrowed cash is held as a negative $CASH balance. Interest is charged
+	// on borrowed cash at MarginRate (annual percent) or, if MarginRate is 0,
+	// at the federal funds rate plus MarginSpread
+	Leverage     float64
+	MarginRate   float64
+	MarginSpread float64
+	marginRates  *dataframe.DataFrame
+
+	// BorrowRate annual fee (percent) charged on the market value of short
+	// positions. Short positions are valued with dividend adjusted prices
+	// so dividends owed by the short are reflected in their value
+	BorrowRate float64
+
+	// AdvisoryFee annual advisory or platform fee in basis points of the
+	// portfolio's value, deducted from cash as ADVISORY_FEE transactions
+	// each AdvisoryFeeFrequency period (FeeMonthly, the default, or
+	// FeeQuarterly) to model the drag of paid services or fund wrappers
+	AdvisoryFee          float64
+	AdvisoryFeeFrequency string
+
+	// Resolution frequency of performance measurements: data.FrequencyDaily,
+	// data.FrequencyWeekly, or data.FrequencyMonthly. Defaults to monthly
+	Resolution string
+
+	// Provenance the strategy and arguments the portfolio was computed
+	// with; CalculatePerformance adds the code version and data snapshots
+	Provenance Provenance
+
+	// Progress notified as TargetPortfolio works through the dates of the
+	// target portfolio; may be nil
+	Progress ProgressReporter
+
+	// RebalanceBands only rebalance when a holding drifts outside a band
+	// around its target weight; checked on every date of the target
+	// portfolio, which for static allocations is every measurement date
+	RebalanceBands RebalanceBands
+
+	// Execution limits on the trades made when rebalancing, e.g. whole
+	// shares only
+	Execution ExecutionConstraints
+
+	// Normalize report performance as the growth of 1.0 rather than in
+	// dollars. The portfolio is still simulated with its initial deposit so
+	// share counts are not lost to rounding
+	Normalize bool
+
+	// RebalanceOnChange only trade when the target allocation differs from
+	// the previous date's. Strategies that evaluate their signal every
+	// trading day set it so the portfolio is not rebalanced back to its
+	// target weights each day; signals are still recorded for every date
+	RebalanceOnChange bool
+
+	// ActualShares the ledger is recorded in actual shares, e.g. executed
+	// transactions, with SPLIT transactions adjusting the share counts. Its
+	// securities are valued at the unadjusted close since split-adjusted
+	// prices would count each split twice. Set by ApplySplits
+	ActualShares bool
+
+	dataProxy  *data.Manager
+	securities map[string]bool
+	priceData  map[string]*dataframe.DataFrame
+	priceIndex map[string]map[int64]float64
 }
 
 type PerformanceMeasurement struct {
@@ -61,22 +153,51 @@ type PerformanceMeasurement struct {
 	RiskFreeValue float64                `json:"riskFreeValue"`
 	Holdings      string                 `json:"holdings"`
 	PercentReturn float64                `json:"percentReturn"`
+	Leverage      float64                `json:"leverage,omitempty"`
+	AfterTaxValue float64                `json:"afterTaxValue,omitempty"`
 	Justification map[string]interface{} `json:"justification"`
+
+	// WarmUp the measurement is before the portfolio was funded, e.g.
+	// during a strategy's lookback, and is not part of its return
+	WarmUp bool `json:"warmUp,omitempty"`
+
+	// PeriodFraction fraction of the period covered by the first or last
+	// measurement when the backtest starts or ends mid-period
+	PeriodFraction float64 `json:"periodFraction,omitempty"`
 }
 
 // Performance of portfolio
 type Performance struct {
 	PeriodStart        int64                    `json:"periodStart"`
 	PeriodEnd          int64                    `json:"periodEnd"`
+	RequestedStart     int64                    `json:"requestedStart,omitempty"`
+	EffectiveStart     int64                    `json:"effectiveStart"`
+	WarmUpPeriods      int                      `json:"warmUpPeriods,omitempty"`
+	PartialFirstPeriod bool                     `json:"partialFirstPeriod,omitempty"`
+	PartialLastPeriod  bool                     `json:"partialLastPeriod,omitempty"`
 	ComputedOn         int64                    `json:"computedOn"`
 	Measurements       []PerformanceMeasurement `json:"measurements"`
 	Transactions       []Transaction            `json:"transactions"`
 	CagrSinceInception float64                  `json:"cagrSinceInception"`
 	YTDReturn          float64                  `json:"ytdReturn"`
 	CurrentAsset       string                   `json:"currentAsset"`
+	InitialCapital     float64                  `json:"initialCapital"`
+	Normalized         bool                     `json:"normalized,omitempty"`
 	TotalDeposited     float64                  `json:"totalDeposited"`
 	TotalWithdrawn     float64                  `json:"totalWithdrawn"`
+	AccountType        string                   `json:"accountType,omitempty"`
+	TaxesPaid          float64                  `json:"taxesPaid,omitempty"`
+	CurrentHoldings    map[string]float64       `json:"currentHoldings,omitempty"`
+	Benchmarks         []RelativeMetrics        `json:"benchmarks,omitempty"`
+	LiquidityWarnings  []LiquidityWarning       `json:"liquidityWarnings,omitempty"`
+	Resolution         string                   `json:"resolution,omitempty"`
 	MetricsBundle      MetricsBundle            `json:"metrics"`
+	Provenance         Provenance               `json:"provenance"`
+	Annotations        []Annotation             `json:"annotations,omitempty"`
+
+	// taxRates rates of the last CalculateAfterTax; used to estimate the tax
+	// cost ratio
+	taxRates *TaxRates
 }
 
 // NewPortfolio create a portfolio
@@ -87,6 +208,101 @@ func NewPortfolio(name string, manager *data.Manager) Portfolio {
 	}
 }
 
+// NewPortfolioFromTransactions create a portfolio from an existing ledger
+// of transactions, e.g. trades that were actually executed in a brokerage
+// account
+func NewPortfolioFromTransactions(name string, manager *data.Manager, trxs []Transaction) (Portfolio, error) {
+	p := NewPortfolio(name, manager)
+	if len(trxs) == 0 {
+		return p, errors.New("Cannot create portfolio with no transactions")
+	}
+
+	p.Transactions = make([]Transaction, len(trxs))
+	copy(p.Transactions, trxs)
+	SortTransactions(p.Transactions)
+
+	p.StartTime = p.Transactions[0].Date
+	p.EndTime = p.Transactions[len(p.Transactions)-1].Date
+	p.securities = make(map[string]bool)
+	for _, trx := range p.Transactions {
+		if trx.Kind == BuyTransaction || trx.Kind == SellTransaction {
+			p.securities[trx.Ticker] = true
+		}
+	}
+	p.Holdings = LedgerHoldings(p.Transactions, p.EndTime)
+
+	return p, nil
+}
+
+// LedgerHoldings replay the transactions up to and including asOf and
+// return the resulting share count of each security and the cash balance
+// (stored under $CASH). Transactions must be sorted by date.
+func LedgerHoldings(trxs []Transaction, asOf time.Time) map[string]float64 {
+	holdings := make(map[string]float64)
+	for _, trx := range trxs {
+		if trx.Date.After(asOf) {
+			break
+		}
+		applyTransaction(holdings, &trx)
+	}
+	return holdings
+}
+
+// applyTransaction update holdings with the effect of trx on share counts
+// and the cash balance
+func applyTransaction(holdings map[string]float64, trx *Transaction) {
+	switch trx.Kind {
+	case DepositTransaction:
+		holdings["$CASH"] += trx.TotalValue
+	case WithdrawTransaction:
+		holdings["$CASH"] -= trx.TotalValue
+	case BuyTransaction:
+		holdings[trx.Ticker] += trx.Shares
+		holdings["$CASH"] -= trx.TotalValue + trx.Fees
+	case SellTransaction:
+		holdings[trx.Ticker] -= trx.Shares
+		holdings["$CASH"] += trx.TotalValue - trx.Fees
+		if math.Abs(holdings[trx.Ticker]) <= 1.0e-5 {
+			delete(holdings, trx.Ticker)
+		}
+	case SplitTransaction:
+		holdings[trx.Ticker] += trx.Shares
+	case InterestTransaction:
+		holdings["$CASH"] += trx.TotalValue
+	case MarginInterestTransaction, BorrowFeeTransaction, AdvisoryFeeTransaction:
+		holdings["$CASH"] -= trx.TotalValue
+	case DividendTransaction:
+		// short positions pay the dividend, which is recorded as a negative value
+		holdings["$CASH"] += trx.TotalValue
+	}
+}
+
+// SortTransactions order transactions by date. Transactions on the same day
+// are ordered deposits, sells, buys, and then withdrawals so that cash is
+// available when it is needed. Interest and fees accrued since the prior
+// period are settled before any trades.
+func SortTransactions(trxs []Transaction) {
+	priority := map[string]int{
+		MarkerTransaction:         0,
+		SplitTransaction:          1,
+		InterestTransaction:       2,
+		MarginInterestTransaction: 2,
+		BorrowFeeTransaction:      2,
+		AdvisoryFeeTransaction:    2,
+		DividendTransaction:       2,
+		DepositTransaction:        3,
+		SellTransaction:           4,
+		BuyTransaction:            5,
+		WithdrawTransaction:       6,
+	}
+	sort.SliceStable(trxs, func(i, j int) bool {
+		if !trxs[i].Date.Equal(trxs[j].Date) {
+			return trxs[i].Date.Before(trxs[j].Date)
+		}
+		return priority[trxs[i].Kind] < priority[trxs[j].Kind]
+	})
+}
+
 // ValueAsOf return the value of the portfolio for the given date
 func (p *Portfolio) ValueAsOf(d time.Time) (float64, error) {
 	// Get last 7 days of values, in case 'd' isn't a market day
@@ -129,7 +345,7 @@ func (p *Portfolio) valueOverPeriod(s time.Time, e time.Time) ([]*PerformanceMea
 	}
 
 	// get quote data
-	quotes, errs := p.dataProxy.GetMultipleData(symbols...)
+	quotes, errs := p.quotes(symbols...)
 	if len(errs) > 0 {
 		return nil, errors.New("Failed to download data for tickers")
 	}
@@ -187,7 +403,9 @@ func (p *Portfolio) holdingsOverPeriod(s time.Time, e time.Time) (map[time.Time]
 	periodHoldings := map[time.Time][]Holding{}
 
 	for _, t := range p.Transactions {
-		if t.Kind == DepositTransaction || t.Kind == WithdrawTransaction || t.Kind == MarkerTransaction {
+		switch t.Kind {
+		case DepositTransaction, WithdrawTransaction, MarkerTransaction, InterestTransaction,
+			MarginInterestTransaction, BorrowFeeTransaction, DividendTransaction, AdvisoryFeeTransaction:
 			continue
 		}
 
@@ -206,24 +424,30 @@ func (p *Portfolio) holdingsOverPeriod(s time.Time, e time.Time) (map[time.Time]
 
 		if h, ok := currHoldings[t.Ticker]; ok {
 			switch t.Kind {
-			case BuyTransaction:
+			case BuyTransaction, SplitTransaction:
 				h.Shares += t.Shares
 			case SellTransaction:
 				h.Shares -= t.Shares
 			}
-			if h.Shares <= 1e-5 {
+			if math.Abs(h.Shares) <= 1e-5 {
 				delete(currHoldings, h.Ticker)
 			} else {
 				currHoldings[t.Ticker] = h
 			}
 		} else {
-			if t.Kind != BuyTransaction {
+			// a sell of a security that is not held opens a short position
+			shares := t.Shares
+			switch t.Kind {
+			case BuyTransaction:
+			case SellTransaction:
+				shares = -t.Shares
+			default:
 				log.Error("Transactions are out of order")
 				return nil, errors.New("Transactions are out of order")
 			}
 			currHoldings[t.Ticker] = Holding{
 				Ticker: t.Ticker,
-				Shares: t.Shares,
+				Shares: shares,
 			}
 		}
 
@@ -245,17 +469,56 @@ func (p *Portfolio) holdingsOverPeriod(s time.Time, e time.Time) (map[time.Time]
 	return periodHoldings, nil
 }
 
+// ValidResolution check if resolution is a supported measurement frequency
+func ValidResolution(resolution string) bool {
+	switch resolution {
+	case data.FrequencyDaily, data.FrequencyWeekly, data.FrequencyMonthly:
+		return true
+	}
+	return false
+}
+
 // CalculatePerformance calculate performance of portfolio
 func (p *Portfolio) CalculatePerformance(through time.Time) (Performance, error) {
 	if len(p.Transactions) == 0 {
 		return Performance{}, errors.New("Cannot calculate performance for portfolio with no transactions")
 	}
 
+	// accrued interest and fees are recalculated each time performance is
+	// computed
+	leveraged := p.Leverage > 1.0
+	borrowFees := p.BorrowRate > 0
+	advisoryFees := p.AdvisoryFee > 0
+	if p.CashInterest || leveraged || borrowFees || advisoryFees {
+		trxs := make([]Transaction, 0, len(p.Transactions))
+		for _, trx := range p.Transactions {
+			if (p.CashInterest && trx.Kind == InterestTransaction) ||
+				(leveraged && trx.Kind == MarginInterestTransaction) ||
+				(borrowFees && trx.Kind == BorrowFeeTransaction) ||
+				(advisoryFees && trx.Kind == AdvisoryFeeTransaction) {
+				continue
+			}
+			trxs = append(trxs, trx)
+		}
+		p.Transactions = trxs
+	}
+	accruals := []Transaction{}
+
+	resolution := p.Resolution
+	if resolution == "" {
+		resolution = data.FrequencyMonthly
+	}
+	if !ValidResolution(resolution) {
+		return Performance{}, fmt.Errorf("invalid resolution '%s'", resolution)
+	}
+
 	perf := Performance{
-		PeriodStart:  p.StartTime.Unix(),
-		PeriodEnd:    through.Unix(),
-		ComputedOn:   time.Now().Unix(),
-		Transactions: p.Transactions,
+		PeriodStart:    p.StartTime.Unix(),
+		PeriodEnd:      through.Unix(),
+		ComputedOn:     time.Now().Unix(),
+		Transactions:   p.Transactions,
+		Resolution:     resolution,
+		InitialCapital: p.initialCapital(),
 	}
 
 	// Calculate performance
@@ -266,9 +529,9 @@ func (p *Portfolio) CalculatePerformance(through time.Time) (Performance, error)
 
 	p.dataProxy.Begin = p.StartTime
 	p.dataProxy.End = through
-	p.dataProxy.Frequency = data.FrequencyMonthly
+	p.dataProxy.Frequency = resolution
 
-	quotes, errs := p.dataProxy.GetMultipleData(symbols...)
+	quotes, errs := p.quotes(symbols...)
 	if len(errs) > 0 {
 		return Performance{}, errors.New("Failed to download data for tickers")
 	}
@@ -300,21 +563,97 @@ func (p *Portfolio) CalculatePerformance(through time.Time) (Performance, error)
 	var totalVal float64
 	var currYearStartValue float64 = -1.0
 	var riskFreeValue float64 = 0
+	var prevDate time.Time
 
 	var lastJustification map[string]interface{}
+	var lastQuotes map[interface{}]interface{}
+	var lastFeeDate time.Time
 
 	for {
 		row, quotes, _ := iterator(dataframe.SeriesName)
 		if row == nil {
 			break
 		}
+		lastQuotes = quotes
 		date := quotes[data.DateIdx].(time.Time)
 
+		// quotes before the portfolio was funded are warm-up
+		if date.Before(p.StartTime) {
+			valueOverTime = append(valueOverTime, PerformanceMeasurement{
+				Time:   date.Unix(),
+				WarmUp: true,
+			})
+			perf.WarmUpPeriods++
+			continue
+		}
+
 		// check if this is the current year
 		if date.Year() == currYear && currYearStartValue == -1.0 {
 			currYearStartValue = prevVal
 		}
 
+		// deposits and withdrawals made after the first measurement are not
+		// part of the period's return
+		var netFlow float64
+
+		// pay interest on cash held since the last measurement
+		if p.CashInterest && prevVal != -1 && holdings["$CASH"] > 1.0e-5 {
+			trx := p.accrueInterest(holdings["$CASH"], prevDate, date)
+			if trx.TotalValue != 0 {
+				holdings["$CASH"] += trx.TotalValue
+				accruals = append(accruals, trx)
+			}
+		}
+
+		// charge interest on cash borrowed since the last measurement
+		if leveraged && prevVal != -1 && holdings["$CASH"] < -1.0e-5 {
+			trx, err := p.chargeMarginInterest(-holdings["$CASH"], prevDate, date)
+			if err != nil {
+				return Performance{}, err
+			}
+			if trx.TotalValue != 0 {
+				holdings["$CASH"] -= trx.TotalValue
+				accruals = append(accruals, trx)
+			}
+		}
+
+		// charge the borrow fee on short positions held since the last measurement
+		if borrowFees && prevVal != -1 {
+			// charge in symbol order so the ledger is the same on every run
+			shorts := []string{}
+			for symbol, qty := range holdings {
+				if symbol != "$CASH" && qty < -1.0e-5 {
+					shorts = append(shorts, symbol)
+				}
+			}
+			sort.Strings(shorts)
+			for _, symbol := range shorts {
+				qty := holdings[symbol]
+				val, ok := quotes[symbol]
+				if !ok {
+					return Performance{}, fmt.Errorf("no quote for symbol: %s", symbol)
+				}
+				trx := p.chargeBorrowFee(symbol, -qty, val.(float64), prevDate, date)
+				holdings["$CASH"] -= trx.TotalValue
+				accruals = append(accruals, trx)
+			}
+		}
+
+		// deduct the advisory fee for each billing period that has ended
+		if advisoryFees {
+			if prevVal == -1 {
+				lastFeeDate = date
+			} else if p.feePeriod(date) != p.feePeriod(lastFeeDate) {
+				if prevVal > 0 {
+					trx := p.chargeAdvisoryFee(prevVal, lastFeeDate, date)
+					holdings["$CASH"] -= trx.TotalValue
+					accruals = append(accruals, trx)
+				}
+				lastFeeDate = date
+			}
+		}
+		prevDate = date
+
 		// update holdings?
 		for ; trxIdx < numTrxs; trxIdx++ {
 			trx := p.Transactions[trxIdx]
@@ -331,14 +670,33 @@ func (p *Portfolio) CalculatePerformance(through time.Time) (Performance, error)
 				continue
 			}
 
+			if trx.Kind == InterestTransaction {
+				holdings["$CASH"] += trx.TotalValue
+				continue
+			}
+
+			if trx.Kind == MarginInterestTransaction || trx.Kind == BorrowFeeTransaction || trx.Kind == AdvisoryFeeTransaction {
+				holdings["$CASH"] -= trx.TotalValue
+				continue
+			}
+
+			if trx.Kind == DividendTransaction {
+				holdings["$CASH"] += trx.TotalValue
+				continue
+			}
+
 			if trx.Kind == DepositTransaction || trx.Kind == WithdrawTransaction {
 				switch trx.Kind {
 				case DepositTransaction:
 					perf.TotalDeposited += trx.TotalValue
 					riskFreeValue += trx.TotalValue
+					holdings["$CASH"] += trx.TotalValue
+					netFlow += trx.TotalValue
 				case WithdrawTransaction:
 					perf.TotalWithdrawn += trx.TotalValue
 					riskFreeValue -= trx.TotalValue
+					holdings["$CASH"] -= trx.TotalValue
+					netFlow -= trx.TotalValue
 				}
 				continue
 			}
@@ -350,16 +708,21 @@ func (p *Portfolio) CalculatePerformance(through time.Time) (Performance, error)
 			switch trx.Kind {
 			case BuyTransaction:
 				shares += trx.Shares
+				holdings["$CASH"] -= trx.TotalValue + trx.Fees
 				log.Debugf("on %s buy %.2f shares of %s for %.2f @ %.2f per share\n", trx.Date, trx.Shares, trx.Ticker, trx.TotalValue, trx.PricePerShare)
 			case SellTransaction:
 				shares -= trx.Shares
+				holdings["$CASH"] += trx.TotalValue - trx.Fees
 				log.Debugf("on %s sell %.2f shares of %s for %.2f @ %.2f per share\n", trx.Date, trx.Shares, trx.Ticker, trx.TotalValue, trx.PricePerShare)
+			case SplitTransaction:
+				shares += trx.Shares
+				log.Debugf("on %s split adjusted %s by %.2f shares\n", trx.Date, trx.Ticker, trx.Shares)
 			default:
 				return Performance{}, errors.New("unrecognized transaction type")
 			}
 
 			// Protect against floating point noise
-			if shares <= 1.0e-5 {
+			if math.Abs(shares) <= 1.0e-5 {
 				shares = 0
 			}
 
@@ -368,6 +731,7 @@ func (p *Portfolio) CalculatePerformance(through time.Time) (Performance, error)
 
 		// iterate through each holding and add value to get total return
 		totalVal = 0.0
+		grossExposure := 0.0
 		var tickers []string
 		for symbol, qty := range holdings {
 			if symbol == "$CASH" {
@@ -375,7 +739,8 @@ func (p *Portfolio) CalculatePerformance(through time.Time) (Performance, error)
 			} else if val, ok := quotes[symbol]; ok {
 				price := val.(float64)
 				totalVal += price * qty
-				if qty > 1.0e-5 {
+				grossExposure += math.Abs(price * qty)
+				if math.Abs(qty) > 1.0e-5 {
 					tickers = append(tickers, symbol)
 				}
 			} else {
@@ -386,26 +751,40 @@ func (p *Portfolio) CalculatePerformance(through time.Time) (Performance, error)
 		if prevVal == -1 {
 			prevVal = totalVal
 			startVal = totalVal
+			if date.After(p.StartTime) && netFlow > 0 {
+				// the portfolio was funded part way through the period so
+				// its first return is measured from the capital deposited
+				prevVal = netFlow
+				startVal = netFlow
+			}
+			netFlow = 0
+			perf.EffectiveStart = date.Unix()
 		} else {
 			// update riskFreeValue
 			rawRate := p.dataProxy.RiskFreeRate(date)
-			riskFreeRate := rawRate / 100.0 / 12.0
+			riskFreeRate := rawRate / 100.0 / perf.annualPeriods()
 			riskFreeValue *= (1 + riskFreeRate)
 		}
 
 		sort.Strings(tickers)
 		holdingStr := strings.Join(tickers, " ")
-		ret := totalVal/prevVal - 1
+		ret := (totalVal-netFlow)/prevVal - 1
 		duration := date.Sub(p.StartTime).Hours() / (24 * 365.25)
 		cagrSinceInception = math.Pow(totalVal/startVal, 1.0/duration) - 1
 		prevVal = totalVal
 
+		var leverage float64
+		if leveraged && totalVal > 0 {
+			leverage = grossExposure / totalVal
+		}
+
 		valueOverTime = append(valueOverTime, PerformanceMeasurement{
 			Time:          date.Unix(),
 			Value:         totalVal,
 			RiskFreeValue: riskFreeValue,
 			Holdings:      holdingStr,
 			PercentReturn: ret,
+			Leverage:      leverage,
 			Justification: lastJustification,
 		})
 
@@ -414,8 +793,28 @@ func (p *Portfolio) CalculatePerformance(through time.Time) (Performance, error)
 		}
 	}
 
+	if len(accruals) > 0 {
+		p.Transactions = append(p.Transactions, accruals...)
+		SortTransactions(p.Transactions)
+		perf.Transactions = p.Transactions
+	}
+
+	// value of each position as of the last measurement
+	perf.CurrentHoldings = make(map[string]float64)
+	for symbol, qty := range holdings {
+		if math.Abs(qty) <= 1.0e-5 {
+			continue
+		}
+		if symbol == "$CASH" {
+			perf.CurrentHoldings[symbol] = qty
+		} else if val, ok := lastQuotes[symbol]; ok {
+			perf.CurrentHoldings[symbol] = qty * val.(float64)
+		}
+	}
+
 	perf.Measurements = valueOverTime
 	perf.CagrSinceInception = cagrSinceInception
+	perf.markPartialPeriods(p.StartTime, through)
 
 	if currYearStartValue <= 0 {
 		perf.YTDReturn = 0.0
@@ -423,9 +822,201 @@ func (p *Portfolio) CalculatePerformance(through time.Time) (Performance, error)
 		perf.YTDReturn = totalVal/currYearStartValue - 1.0
 	}
 
+	// after the prices above so their snapshots are included
+	perf.Provenance = p.provenance()
+
+	if p.Normalize {
+		perf.normalize()
+	}
+
 	return perf, nil
 }
 
+// quotes download the prices symbols are valued at over the period of the
+// data manager: the unadjusted close for ledgers in actual shares and the
+// manager's metric otherwise
+func (p *Portfolio) quotes(symbols ...string) (map[string]*dataframe.DataFrame, []error) {
+	if !p.ActualShares {
+		return p.dataProxy.GetMultipleData(symbols...)
+	}
+
+	metric := p.dataProxy.Metric
+	defer func() { p.dataProxy.Metric = metric }()
+	p.dataProxy.Metric = data.MetricClose
+	return p.dataProxy.GetMultipleData(symbols...)
+}
+
+// initialCapital the amount of the portfolio's first deposit
+func (p *Portfolio) initialCapital() float64 {
+	for _, trx := range p.Transactions {
+		if trx.Kind == DepositTransaction {
+			return trx.TotalValue
+		}
+	}
+	return 0
+}
+
+// normalize express the dollar amounts of perf relative to its initial
+// capital so the portfolio starts with a value of 1.0. Transactions are
+// copied since they are shared with the portfolio
+func (perf *Performance) normalize() {
+	if perf.InitialCapital <= 0 || perf.Normalized {
+		return
+	}
+	scale := 1.0 / perf.InitialCapital
+
+	for ii := range perf.Measurements {
+		perf.Measurements[ii].Value *= scale
+		perf.Measurements[ii].RiskFreeValue *= scale
+		perf.Measurements[ii].AfterTaxValue *= scale
+	}
+
+	trxs := make([]Transaction, len(perf.Transactions))
+	for ii, trx := range perf.Transactions {
+		// prices are unchanged so positions hold proportionally fewer shares
+		trx.Shares *= scale
+		trx.TotalValue *= scale
+		trx.Fees *= scale
+		trxs[ii] = trx
+	}
+	perf.Transactions = trxs
+
+	for symbol := range perf.CurrentHoldings {
+		perf.CurrentHoldings[symbol] *= scale
+	}
+
+	perf.TotalDeposited *= scale
+	perf.TotalWithdrawn *= scale
+	perf.TaxesPaid *= scale
+	perf.InitialCapital = 1.0
+	perf.Normalized = true
+}
+
+// accrueInterest compute the interest earned on cash between start and end
+func (p *Portfolio) accrueInterest(cash float64, start time.Time, end time.Time) Transaction {
+	rate := p.CashInterestRate
+	if rate == 0 {
+		rate = p.dataProxy.RiskFreeRate(end)
+	}
+
+	years := end.Sub(start).Hours() / (24 * 365.25)
+	amount := cash * (math.Pow(1+rate/100.0, years) - 1)
+	return Transaction{
+		Date:          end,
+		Ticker:        "$CASH",
+		Kind:          InterestTransaction,
+		PricePerShare: 1.0,
+		Shares:        amount,
+		TotalValue:    amount,
+		Justification: map[string]interface{}{
+			"rate": rate,
+		},
+	}
+}
+
+// chargeMarginInterest compute the interest owed on borrowed cash between
+// start and end
+func (p *Portfolio) chargeMarginInterest(borrowed float64, start time.Time, end time.Time) (Transaction, error) {
+	rate := p.MarginRate
+	if rate == 0 {
+		benchmark, err := p.marginBenchmarkRate(end)
+		if err != nil {
+			return Transaction{}, err
+		}
+		rate = benchmark + p.MarginSpread
+	}
+
+	years := end.Sub(start).Hours() / (24 * 365.25)
+	amount := borrowed * (math.Pow(1+rate/100.0, years) - 1)
+	return Transaction{
+		Date:          end,
+		Ticker:        "$CASH",
+		Kind:          MarginInterestTransaction,
+		PricePerShare: 1.0,
+		Shares:        amount,
+		TotalValue:    amount,
+		Justification: map[string]interface{}{
+			"rate":     rate,
+			"borrowed": borrowed,
+		},
+	}, nil
+}
+
+// chargeBorrowFee compute the fee owed for borrowing shares of a shorted
+// security between start and end
+func (p *Portfolio) chargeBorrowFee(ticker string, shares float64, price float64, start time.Time, end time.Time) Transaction {
+	years := end.Sub(start).Hours() / (24 * 365.25)
+	amount := shares * price * (math.Pow(1+p.BorrowRate/100.0, years) - 1)
+	return Transaction{
+		Date:          end,
+		Ticker:        ticker,
+		Kind:          BorrowFeeTransaction,
+		PricePerShare: price,
+		Shares:        shares,
+		TotalValue:    amount,
+		Justification: map[string]interface{}{
+			"rate": p.BorrowRate,
+		},
+	}
+}
+
+// chargeAdvisoryFee compute the advisory fee owed on value for the billing
+// period between start and end; the fee accrues linearly over the year
+func (p *Portfolio) chargeAdvisoryFee(value float64, start time.Time, end time.Time) Transaction {
+	years := end.Sub(start).Hours() / (24 * 365.25)
+	amount := value * p.AdvisoryFee / 10000.0 * years
+	return Transaction{
+		Date:          end,
+		Ticker:        "$CASH",
+		Kind:          AdvisoryFeeTransaction,
+		PricePerShare: 1.0,
+		Shares:        amount,
+		TotalValue:    amount,
+		Justification: map[string]interface{}{
+			"bps":   p.AdvisoryFee,
+			"value": value,
+		},
+	}
+}
+
+// feePeriod number identifying the advisory fee billing period of date
+func (p *Portfolio) feePeriod(date time.Time) int {
+	if p.AdvisoryFeeFrequency == FeeQuarterly {
+		return date.Year()*4 + (int(date.Month())-1)/3
+	}
+	return date.Year()*12 + int(date.Month()) - 1
+}
+
+// marginBenchmarkRate return the federal funds rate in effect on date
+func (p *Portfolio) marginBenchmarkRate(date time.Time) (float64, error) {
+	if p.marginRates == nil {
+		rates, err := p.dataProxy.GetData("$RATE.FEDFUNDS")
+		if err != nil {
+			return 0, err
+		}
+		p.marginRates = rates
+	}
+
+	var rate float64
+	iterator := p.marginRates.ValuesIterator(dataframe.ValuesOptions{InitialRow: 0, Step: 1, DontReadLock: true})
+	for {
+		row, vals, _ := iterator(dataframe.SeriesName)
+		if row == nil {
+			break
+		}
+
+		if vals[data.DateIdx].(time.Time).After(date) {
+			break
+		}
+
+		if val, ok := vals["FEDFUNDS"].(float64); ok && !math.IsNaN(val) {
+			rate = val
+		}
+	}
+
+	return rate, nil
+}
+
 // RebalanceTo rebalance the portfolio to the target percentages
 // Assumptions: can only rebalance current holdings
 func (p *Portfolio) RebalanceTo(date time.Time, target map[string]float64, justification map[string]interface{}) error {
@@ -443,9 +1034,14 @@ func (p *Portfolio) RebalanceTo(date time.Time, target map[string]float64, justi
 		total += v
 	}
 
-	// Allow for floating point error
+	// Allow for floating point error; leveraged portfolios may invest more
+	// than 100% of their equity
+	maxTotal := math.Max(1.0, p.Leverage)
 	diff := math.Abs(1.0 - total)
-	if diff > 1.0e-11 {
+	if diff > 1.0e-11 && (total < 1.0 || total-maxTotal > 1.0e-11) {
+		if maxTotal > 1.0 {
+			return fmt.Errorf("Rebalance percent total must be between 1.0 and %.2f, it is %.2f", maxTotal, total)
+		}
 		return fmt.Errorf("Rebalance percent total does not equal 1.0, it is %.2f", total)
 	}
 
@@ -462,16 +1058,8 @@ func (p *Portfolio) RebalanceTo(date time.Time, target map[string]float64, justi
 	}
 	for k, v := range p.Holdings {
 		if k != "$CASH" {
-			eod := p.priceData[k]
-			res, err := dfextras.FindTime(context.TODO(), eod, date, data.DateIdx)
-			if err != nil {
-				return err
-			}
-
-			var price float64
-			if tmp, ok := res[k]; ok {
-				price = tmp.(float64)
-			} else {
+			price, ok := p.priceOn(k, date)
+			if !ok {
 				log.WithFields(log.Fields{
 					"Symbol": k,
 					"Date":   date,
@@ -487,16 +1075,8 @@ func (p *Portfolio) RebalanceTo(date time.Time, target map[string]float64, justi
 	// get any prices that we haven't already loaded
 	for k := range target {
 		if _, ok := priceMap[k]; !ok {
-			eod := p.priceData[k]
-			res, err := dfextras.FindTime(context.TODO(), eod, date, data.DateIdx)
-			if err != nil {
-				return err
-			}
-
-			var price float64
-			if tmp, ok := res[k]; ok {
-				price = tmp.(float64)
-			} else {
+			price, ok := p.priceOn(k, date)
+			if !ok {
 				log.WithFields(log.Fields{
 					"Symbol": k,
 					"Date":   date,
@@ -510,6 +1090,14 @@ func (p *Portfolio) RebalanceTo(date time.Time, target map[string]float64, justi
 
 	investable := cash + securityValue
 
+	if p.Execution.active() {
+		newHoldings, sells, buys := p.constrainedRebalance(date, target, priceMap, investable, justification)
+		p.Transactions = append(p.Transactions, sells...)
+		p.Transactions = append(p.Transactions, buys...)
+		p.Holdings = newHoldings
+		return nil
+	}
+
 	// process all targets
 	sells := []Transaction{}
 	buys := []Transaction{}
@@ -529,12 +1117,25 @@ func (p *Portfolio) RebalanceTo(date time.Time, target map[string]float64, justi
 				TotalValue:    v * priceMap[k],
 				Justification: justification,
 			}
+			if v < 0 {
+				// cover the short position
+				t.Kind = BuyTransaction
+				t.Shares = -v
+				t.TotalValue = -v * priceMap[k]
+				buys = append(buys, t)
+				continue
+			}
 			sells = append(sells, t)
 		}
 	}
 
 	newHoldings := make(map[string]float64)
 	for k, v := range target {
+		// cash is held directly and does not need to be traded
+		if k == "$CASH" {
+			continue
+		}
+
 		// is this security currently held and should we sell it?
 		if holding, ok := p.Holdings[k]; ok {
 			targetDollars := investable * v
@@ -584,9 +1185,33 @@ func (p *Portfolio) RebalanceTo(date time.Time, target map[string]float64, justi
 				TotalValue:    value,
 				Justification: justification,
 			}
+			if v < 0 {
+				// negative weights open a short position
+				t.Kind = SellTransaction
+				t.Shares = -shares
+				t.TotalValue = -value
+				sells = append(sells, t)
+				continue
+			}
 			buys = append(buys, t)
 		}
 	}
+
+	// whatever is not invested is held as cash; borrowed cash is negative
+	invested := 0.0
+	for k, v := range target {
+		if k != "$CASH" {
+			invested += v
+		}
+	}
+	if _, ok := target["$CASH"]; ok || math.Abs(1.0-invested) > 1.0e-11 {
+		newHoldings["$CASH"] = investable * (1.0 - invested)
+	}
+
+	// order trades by ticker so results do not depend on map iteration order
+	sort.SliceStable(sells, func(i, j int) bool { return sells[i].Ticker < sells[j].Ticker })
+	sort.SliceStable(buys, func(i, j int) bool { return buys[i].Ticker < buys[j].Ticker })
+
 	p.Transactions = append(p.Transactions, sells...)
 	p.Transactions = append(p.Transactions, buys...)
 	p.Holdings = newHoldings
@@ -594,9 +1219,12 @@ func (p *Portfolio) RebalanceTo(date time.Time, target map[string]float64, justi
 	return nil
 }
 
-// TargetPortfolio invest target portfolio
+// TargetPortfolio invest target portfolio. Prices are loaded at the data
+// manager's frequency so the dates of target must be trading days at that
+// frequency, e.g. the last trading day of each month or every trading day
 func (p *Portfolio) TargetPortfolio(initial float64, target *dataframe.DataFrame) error {
 	p.Transactions = []Transaction{}
+	p.Signals = []Signal{}
 	timeIdx, err := target.NameToColumn(data.DateIdx)
 	if err != nil {
 		return err
@@ -634,7 +1262,9 @@ func (p *Portfolio) TargetPortfolio(initial float64, target *dataframe.DataFrame
 		} else {
 			// it's multi-asset which means a map of tickers
 			for ticker := range val.(map[string]float64) {
-				p.securities[ticker] = true
+				if ticker != "$CASH" {
+					p.securities[ticker] = true
+				}
 			}
 		}
 	}
@@ -651,14 +1281,16 @@ func (p *Portfolio) TargetPortfolio(initial float64, target *dataframe.DataFrame
 			errorMsgs[ii] = xx.Error()
 		}
 		log.WithFields(log.Fields{
-			"Error": strings.Join(errorMsgs, ", "),
+			logging.FieldError: strings.Join(errorMsgs, ", "),
 		}).Warn("Failed to load data for tickers")
 		return errors.New("Failed loading data for tickers")
 	}
 	p.priceData = prices
+	p.priceIndex = make(map[string]map[int64]float64, len(prices))
 
 	// Create transactions
 	targetIter := target.ValuesIterator(dataframe.ValuesOptions{InitialRow: 0, Step: 1, DontReadLock: false})
+	nrows := target.NRows(dataframe.DontLock)
 	var first bool = true
 	for {
 		row, val, _ := targetIter(dataframe.SeriesName)
@@ -708,16 +1340,126 @@ func (p *Portfolio) TargetPortfolio(initial float64, target *dataframe.DataFrame
 			rebalance = symbol.(map[string]float64)
 		}
 
-		p.Transactions = append(p.Transactions, Transaction{
+		p.Signals = append(p.Signals, Signal{
 			Date:          date,
-			Kind:          MarkerTransaction,
+			Target:        rebalance,
 			Justification: justification,
 		})
-		err = p.RebalanceTo(date, rebalance, justification)
-		if err != nil {
-			return err
+
+		unchanged := p.RebalanceOnChange && len(p.Signals) > 1 &&
+			sameTarget(p.Signals[len(p.Signals)-2].Target, rebalance)
+
+		if p.Leverage > 1.0 {
+			rebalance = leverTarget(rebalance, p.Leverage)
+			if exposure := grossExposure(rebalance); exposure-p.Leverage > 1.0e-11 {
+				return fmt.Errorf("Target portfolio on %s invests %.2f times equity, more than the leverage of %.2f", date.Format("2006-01-02"), exposure, p.Leverage)
+			}
+		}
+
+		// leave holdings that drifted less than the bands alone
+		if !unchanged && p.RebalanceBands.active() && p.withinBands(date, rebalance) {
+			unchanged = true
+		}
+
+		if !unchanged {
+			p.Transactions = append(p.Transactions, Transaction{
+				Date:          date,
+				Kind:          MarkerTransaction,
+				Justification: justification,
+			})
+			err = p.RebalanceTo(date, rebalance, justification)
+			if err != nil {
+				return err
+			}
+		}
+
+		if p.Progress != nil {
+			p.Progress.Progress(float64(*row+1)/float64(nrows)*100, date)
 		}
 	}
 
 	return nil
 }
+
+// priceOn the price of symbol on date in the price data loaded by
+// TargetPortfolio; each symbol's prices are indexed by date the first time
+// they are needed so daily targets don't search the prices on every date
+func (p *Portfolio) priceOn(symbol string, date time.Time) (float64, bool) {
+	index, ok := p.priceIndex[symbol]
+	if !ok {
+		index = make(map[int64]float64)
+		if eod, ok := p.priceData[symbol]; ok && eod != nil {
+			iterator := eod.ValuesIterator(dataframe.ValuesOptions{InitialRow: 0, Step: 1, DontReadLock: false})
+			for {
+				row, val, _ := iterator(dataframe.SeriesName)
+				if row == nil {
+					break
+				}
+				t, ok := val[data.DateIdx].(time.Time)
+				if !ok {
+					continue
+				}
+				if price, ok := val[symbol].(float64); ok {
+					index[t.Unix()] = price
+				}
+			}
+		}
+		if p.priceIndex == nil {
+			p.priceIndex = make(map[string]map[int64]float64)
+		}
+		p.priceIndex[symbol] = index
+	}
+
+	price, ok := index[date.Unix()]
+	return price, ok
+}
+
+// sameTarget check if two target allocations hold the same securities at
+// the same weights
+func sameTarget(a, b map[string]float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || math.Abs(v-w) > 1.0e-11 {
+			return false
+		}
+	}
+	return true
+}
+
+// leverTarget scale the risky weights of target by leverage; the remainder
+// (negative when borrowing) is held in cash. Targets that do not sum to 1
+// already choose their own exposure and are returned unchanged.
+func leverTarget(target map[string]float64, leverage float64) map[string]float64 {
+	var total float64
+	for _, v := range target {
+		total += v
+	}
+	if math.Abs(1.0-total) > 1.0e-11 {
+		return target
+	}
+
+	levered := make(map[string]float64, len(target)+1)
+	invested := 0.0
+	for k, v := range target {
+		if k == "$CASH" {
+			continue
+		}
+		levered[k] = v * leverage
+		invested += v * leverage
+	}
+	levered["$CASH"] = 1.0 - invested
+	return levered
+}
+
+// grossExposure sum of the absolute risky weights of target
+func grossExposure(target map[string]float64) float64 {
+	var exposure float64
+	for k, v := range target {
+		if k != "$CASH" {
+			exposure += math.Abs(v)
+		}
+	}
+	return exposure
+}
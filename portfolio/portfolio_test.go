@@ -3,6 +3,7 @@ package portfolio_test
 import (
 	"fmt"
 	"io/ioutil"
+	"math"
 	"time"
 
 	"github.com/jarcoal/httpmock"
@@ -167,6 +168,21 @@ var _ = Describe("Portfolio", func() {
 				Expect(p.Transactions[8].TotalValue).Should(BeNumerically("~", 11126.33, 1e-2))
 
 			})
+			It("should report its progress", func() {
+				percents := []float64{}
+				dates := []time.Time{}
+				p.Progress = portfolio.ProgressFunc(func(percent float64, date time.Time) {
+					percents = append(percents, percent)
+					dates = append(dates, date)
+				})
+
+				err := p.TargetPortfolio(10000, df1)
+				Expect(err).To(BeNil())
+				Expect(percents).To(HaveLen(3))
+				Expect(percents[0]).Should(BeNumerically("~", 33.33, 1e-2))
+				Expect(percents[2]).To(Equal(100.0))
+				Expect(dates[2]).To(Equal(p.EndTime))
+			})
 			It("should have valid performance", func() {
 				err := p.TargetPortfolio(10000, df1)
 				perf, err := p.CalculatePerformance(time.Date(2020, time.November, 30, 0, 0, 0, 0, time.UTC))
@@ -204,6 +220,16 @@ var _ = Describe("Portfolio", func() {
 
 	Describe("When given a target portfolio", func() {
 		Context("with multiple holdings at a time", func() {
+			It("should record a signal for each date", func() {
+				err := p.TargetPortfolio(10000, dfMulti)
+				Expect(err).To(BeNil())
+				Expect(p.Signals).To(HaveLen(3))
+				Expect(p.Signals[0].Date).To(Equal(time.Date(2018, 01, 31, 0, 0, 0, 0, time.UTC)))
+				Expect(p.Signals[0].Target).To(Equal(map[string]float64{"VFINX": 1.0}))
+				Expect(p.Signals[1].Target).To(Equal(map[string]float64{"VFINX": 0.25, "PRIDX": 0.5, "VUSTX": 0.25}))
+				Expect(p.Signals[2].Date).To(Equal(time.Date(2020, 01, 31, 0, 0, 0, 0, time.UTC)))
+			})
+
 			It("should have transactions", func() {
 				err := p.TargetPortfolio(10000, dfMulti)
 				Expect(err).To(BeNil())
@@ -321,4 +347,518 @@ var _ = Describe("Portfolio", func() {
 		})
 	})
 
+	Describe("When given a target portfolio", func() {
+		Context("with an allocation history", func() {
+			It("should weight each holding on every date", func() {
+				err := p.TargetPortfolio(10000, dfMulti)
+				Expect(err).To(BeNil())
+
+				allocations, err := p.AllocationHistory(time.Date(2018, time.January, 31, 0, 0, 0, 0, time.UTC),
+					time.Date(2020, time.November, 30, 0, 0, 0, 0, time.UTC), data.FrequencyMonthly)
+				Expect(err).To(BeNil())
+				Expect(allocations).Should(HaveLen(35))
+
+				Expect(allocations[0].Date).To(Equal(time.Date(2018, time.January, 31, 0, 0, 0, 0, time.UTC)))
+				Expect(allocations[0].Weights).To(HaveLen(1))
+				Expect(allocations[0].Weights["VFINX"]).Should(BeNumerically("~", 1.0, 1e-9))
+
+				Expect(allocations[12].Date).To(Equal(time.Date(2019, time.January, 31, 0, 0, 0, 0, time.UTC)))
+				Expect(allocations[12].Weights).To(HaveLen(3))
+				Expect(allocations[12].Weights["VFINX"]).Should(BeNumerically("~", 0.25, 1e-6))
+				Expect(allocations[12].Weights["PRIDX"]).Should(BeNumerically("~", 0.5, 1e-6))
+				Expect(allocations[12].Weights["VUSTX"]).Should(BeNumerically("~", 0.25, 1e-6))
+
+				Expect(allocations[24].Weights).To(HaveLen(1))
+				Expect(allocations[24].Weights["PRIDX"]).Should(BeNumerically("~", 1.0, 1e-6))
+
+				for _, allocation := range allocations {
+					total := 0.0
+					for _, weight := range allocation.Weights {
+						total += weight
+					}
+					Expect(total).Should(BeNumerically("~", 1.0, 1e-9))
+				}
+			})
+		})
+	})
+
+	Describe("When given a portfolio with an initial capital", func() {
+		Context("of $25,000", func() {
+			It("should scale its values by the initial deposit", func() {
+				err := p.TargetPortfolio(25000, df1)
+				Expect(err).To(BeNil())
+				perf, err := p.CalculatePerformance(time.Date(2020, time.November, 30, 0, 0, 0, 0, time.UTC))
+				Expect(err).To(BeNil())
+				Expect(perf.InitialCapital).To(Equal(25000.0))
+				Expect(perf.TotalDeposited).To(Equal(25000.0))
+				Expect(perf.Measurements[0].Value).Should(BeNumerically("~", 25000, 1e-6))
+				Expect(perf.Measurements[34].Value).Should(BeNumerically("~", 12676.603580175803*2.5, 1e-6))
+				Expect(perf.NetProfit()).Should(BeNumerically("~", (12676.603580175803-10000)*2.5, 1e-6))
+			})
+		})
+
+		Context("normalized to 1.0", func() {
+			It("should report the growth of 1.0", func() {
+				err := p.TargetPortfolio(10000, df1)
+				Expect(err).To(BeNil())
+				p.Normalize = true
+				perf, err := p.CalculatePerformance(time.Date(2020, time.November, 30, 0, 0, 0, 0, time.UTC))
+				Expect(err).To(BeNil())
+				Expect(perf.Normalized).To(BeTrue())
+				Expect(perf.InitialCapital).To(Equal(1.0))
+				Expect(perf.TotalDeposited).To(Equal(1.0))
+				Expect(perf.Measurements[0].Value).Should(BeNumerically("~", 1.0, 1e-12))
+				Expect(perf.Measurements[0].RiskFreeValue).Should(BeNumerically("~", 1.0, 1e-12))
+				Expect(perf.Measurements[34].Value).Should(BeNumerically("~", 1.2676603580175803, 1e-12))
+				Expect(perf.Measurements[34].PercentReturn).To(Equal(0.10935637663885389))
+				Expect(perf.Transactions[2].TotalValue).Should(BeNumerically("~", 1.0, 1e-12))
+
+				// the portfolio keeps its dollar transactions
+				Expect(p.Transactions[2].TotalValue).Should(BeNumerically("~", 10000, 1e-6))
+			})
+		})
+	})
+
+	Describe("When given a target portfolio with execution constraints", func() {
+		Context("trading whole shares", func() {
+			It("should only trade whole shares", func() {
+				p.Execution.WholeShares = true
+				err := p.TargetPortfolio(10000, dfMulti)
+				Expect(err).To(BeNil())
+
+				Expect(p.Transactions[2].Kind).To(Equal(portfolio.BuyTransaction))
+				Expect(p.Transactions[2].Ticker).To(Equal("VFINX"))
+				Expect(p.Transactions[2].Shares).To(Equal(40.0))
+				for _, trx := range p.Transactions {
+					if trx.Kind == portfolio.BuyTransaction || trx.Kind == portfolio.SellTransaction {
+						Expect(trx.Shares).To(Equal(math.Trunc(trx.Shares)), "%s %s on %s", trx.Kind, trx.Ticker, trx.Date)
+					}
+				}
+				Expect(p.Holdings["$CASH"]).Should(BeNumerically(">=", 0))
+			})
+		})
+
+		Context("with a minimum trade size", func() {
+			It("should skip small rebalance trades", func() {
+				tickerSeries := dataframe.NewSeriesMixed(portfolio.TickerName,
+					&dataframe.SeriesInit{Size: 3},
+					map[string]float64{"VFINX": 0.5, "VUSTX": 0.5},
+					map[string]float64{"VFINX": 0.5, "VUSTX": 0.5},
+					map[string]float64{"VFINX": 0.5, "VUSTX": 0.5},
+				)
+				target := dataframe.NewDataFrame(df1.Series[0].Copy(), tickerSeries)
+
+				p.Execution.MinTradePercent = 5
+				err := p.TargetPortfolio(10000, target)
+				Expect(err).To(BeNil())
+
+				trades := 0
+				for _, trx := range p.Transactions {
+					if trx.Kind == portfolio.BuyTransaction || trx.Kind == portfolio.SellTransaction {
+						trades++
+						Expect(trx.Date).To(Equal(time.Date(2018, time.January, 31, 0, 0, 0, 0, time.UTC)))
+					}
+				}
+				Expect(trades).To(Equal(2))
+			})
+
+			It("should always sell positions that are no longer held", func() {
+				err := p.TargetPortfolio(10000, df1)
+				Expect(err).To(BeNil())
+
+				p.Execution.MinTradeValue = 100000
+				err = p.RebalanceTo(p.EndTime, map[string]float64{"PRIDX": 1.0}, map[string]interface{}{})
+				Expect(err).To(BeNil())
+				Expect(p.Holdings).NotTo(HaveKey("VFINX"))
+				Expect(p.Holdings).NotTo(HaveKey("PRIDX"))
+				Expect(p.Holdings["$CASH"]).Should(BeNumerically("~", 11126.33, 1e-2))
+			})
+		})
+
+		Context("with a cash buffer", func() {
+			It("should keep part of the portfolio in cash", func() {
+				p.Execution.CashBuffer = 10
+				err := p.TargetPortfolio(10000, df1)
+				Expect(err).To(BeNil())
+				Expect(p.Transactions[2].Ticker).To(Equal("VFINX"))
+				Expect(p.Transactions[2].TotalValue).Should(BeNumerically("~", 9000, 1e-6))
+				Expect(p.Transactions[3].Kind).To(Equal(portfolio.MarkerTransaction))
+
+				perf, err := p.CalculatePerformance(time.Date(2020, time.November, 30, 0, 0, 0, 0, time.UTC))
+				Expect(err).To(BeNil())
+				last := perf.Measurements[len(perf.Measurements)-1]
+				Expect(perf.CurrentHoldings["$CASH"] / last.Value).Should(BeNumerically("~", 0.1, 0.02))
+			})
+		})
+	})
+
+	Describe("When given a static target portfolio with rebalance bands", func() {
+		var target *dataframe.DataFrame
+
+		BeforeEach(func() {
+			tickerSeries := dataframe.NewSeriesMixed(portfolio.TickerName,
+				&dataframe.SeriesInit{Size: 3},
+				map[string]float64{"VFINX": 0.5, "VUSTX": 0.5},
+				map[string]float64{"VFINX": 0.5, "VUSTX": 0.5},
+				map[string]float64{"VFINX": 0.5, "VUSTX": 0.5},
+			)
+			target = dataframe.NewDataFrame(df1.Series[0].Copy(), tickerSeries)
+		})
+
+		trades := func() []portfolio.Transaction {
+			trxs := []portfolio.Transaction{}
+			for _, trx := range p.Transactions {
+				if trx.Kind == portfolio.BuyTransaction || trx.Kind == portfolio.SellTransaction {
+					trxs = append(trxs, trx)
+				}
+			}
+			return trxs
+		}
+
+		Context("that holdings drift within", func() {
+			It("should not rebalance", func() {
+				p.RebalanceBands = portfolio.RebalanceBands{Absolute: 5, Relative: 25}
+				err := p.TargetPortfolio(10000, target)
+				Expect(err).To(BeNil())
+				Expect(p.Signals).To(HaveLen(3))
+				Expect(trades()).To(HaveLen(2))
+			})
+		})
+
+		Context("that holdings drift outside", func() {
+			It("should rebalance back to the target", func() {
+				p.RebalanceBands = portfolio.RebalanceBands{Relative: 0.1}
+				err := p.TargetPortfolio(10000, target)
+				Expect(err).To(BeNil())
+				Expect(len(trades())).To(BeNumerically(">", 2))
+				Expect(trades()[2].Date).To(Equal(time.Date(2019, time.January, 31, 0, 0, 0, 0, time.UTC)))
+			})
+		})
+	})
+
+	Describe("When given a target portfolio that only rebalances on change", func() {
+		Context("with a repeated target", func() {
+			It("should only trade when the target changes", func() {
+				tickerSeries := dataframe.NewSeriesString(portfolio.TickerName, &dataframe.SeriesInit{Size: 3}, []string{
+					"VFINX",
+					"VFINX",
+					"PRIDX",
+				})
+				target := dataframe.NewDataFrame(df1.Series[0].Copy(), tickerSeries)
+
+				p.RebalanceOnChange = true
+				err := p.TargetPortfolio(10000, target)
+				Expect(err).To(BeNil())
+				Expect(p.Signals).To(HaveLen(3))
+				Expect(p.Transactions).To(HaveLen(6))
+
+				Expect(p.Transactions[2].Kind).To(Equal(portfolio.BuyTransaction))
+				Expect(p.Transactions[2].Ticker).To(Equal("VFINX"))
+				Expect(p.Transactions[3].Kind).To(Equal(portfolio.MarkerTransaction))
+				Expect(p.Transactions[3].Date).To(Equal(time.Date(2020, time.January, 31, 0, 0, 0, 0, time.UTC)))
+				Expect(p.Transactions[4].Kind).To(Equal(portfolio.SellTransaction))
+				Expect(p.Transactions[4].Ticker).To(Equal("VFINX"))
+				Expect(p.Transactions[5].Kind).To(Equal(portfolio.BuyTransaction))
+				Expect(p.Transactions[5].Ticker).To(Equal("PRIDX"))
+			})
+		})
+	})
+
+	Describe("When given a target portfolio holding cash", func() {
+		Context("with cash interest accrual", func() {
+			It("should pay interest on the cash balance", func() {
+				timeSeries := dataframe.NewSeriesTime(data.DateIdx, &dataframe.SeriesInit{Size: 3}, []time.Time{
+					time.Date(2018, time.January, 31, 0, 0, 0, 0, time.UTC),
+					time.Date(2019, time.January, 31, 0, 0, 0, 0, time.UTC),
+					time.Date(2020, time.January, 31, 0, 0, 0, 0, time.UTC),
+				})
+				tickerSeries := dataframe.NewSeriesMixed(portfolio.TickerName,
+					&dataframe.SeriesInit{Size: 3},
+					map[string]float64{"VFINX": 0.5, "$CASH": 0.5},
+					map[string]float64{"VFINX": 0.5, "$CASH": 0.5},
+					map[string]float64{"VFINX": 0.5, "$CASH": 0.5},
+				)
+
+				err := p.TargetPortfolio(10000, dataframe.NewDataFrame(timeSeries, tickerSeries))
+				Expect(err).To(BeNil())
+				Expect(p.Transactions).To(HaveLen(7))
+
+				p.CashInterest = true
+				p.CashInterestRate = 12.0
+				perf, err := p.CalculatePerformance(time.Date(2020, time.November, 30, 0, 0, 0, 0, time.UTC))
+				Expect(err).To(BeNil())
+				Expect(perf.Measurements).Should(HaveLen(35))
+
+				interest := []portfolio.Transaction{}
+				for _, trx := range perf.Transactions {
+					if trx.Kind == portfolio.InterestTransaction {
+						interest = append(interest, trx)
+					}
+				}
+				Expect(interest).To(HaveLen(34))
+				Expect(interest[0].Date).To(Equal(time.Date(2018, time.February, 28, 0, 0, 0, 0, time.UTC)))
+				Expect(interest[0].TotalValue).Should(BeNumerically("~", 43.628, 1e-2))
+
+				// recalculating performance does not duplicate interest
+				perf, err = p.CalculatePerformance(time.Date(2020, time.November, 30, 0, 0, 0, 0, time.UTC))
+				Expect(err).To(BeNil())
+				count := 0
+				for _, trx := range perf.Transactions {
+					if trx.Kind == portfolio.InterestTransaction {
+						count++
+					}
+				}
+				Expect(count).To(Equal(34))
+			})
+		})
+	})
+
+	Describe("When given a long/short target portfolio", func() {
+		Context("with a borrow fee", func() {
+			It("should short securities with negative weights", func() {
+				timeSeries := dataframe.NewSeriesTime(data.DateIdx, &dataframe.SeriesInit{Size: 3}, []time.Time{
+					time.Date(2018, time.January, 31, 0, 0, 0, 0, time.UTC),
+					time.Date(2019, time.January, 31, 0, 0, 0, 0, time.UTC),
+					time.Date(2020, time.January, 31, 0, 0, 0, 0, time.UTC),
+				})
+				tickerSeries := dataframe.NewSeriesMixed(portfolio.TickerName,
+					&dataframe.SeriesInit{Size: 3},
+					map[string]float64{"VFINX": 1.5, "PRIDX": -0.5},
+					map[string]float64{"VFINX": 1.5, "PRIDX": -0.5},
+					map[string]float64{"VFINX": 1.0},
+				)
+
+				p.BorrowRate = 2.0
+				err := p.TargetPortfolio(10000, dataframe.NewDataFrame(timeSeries, tickerSeries))
+				Expect(err).To(BeNil())
+
+				// short sale of PRIDX
+				Expect(p.Transactions[2].Kind).To(Equal(portfolio.SellTransaction))
+				Expect(p.Transactions[2].Ticker).To(Equal("PRIDX"))
+				Expect(p.Transactions[2].Shares).Should(BeNumerically(">", 0))
+				Expect(p.Transactions[2].TotalValue).Should(BeNumerically("~", 5000.00, 1e-2))
+
+				// the short is covered when it is no longer in the target
+				last := p.Transactions[len(p.Transactions)-1]
+				Expect(last.Kind).To(Equal(portfolio.BuyTransaction))
+				Expect(last.Ticker).To(Equal("PRIDX"))
+				_, ok := p.Holdings["PRIDX"]
+				Expect(ok).To(BeFalse())
+
+				perf, err := p.CalculatePerformance(time.Date(2020, time.November, 30, 0, 0, 0, 0, time.UTC))
+				Expect(err).To(BeNil())
+				Expect(perf.Measurements).Should(HaveLen(35))
+				Expect(perf.Measurements[0].Value).Should(BeNumerically("~", 10000.00, 1e-2))
+				Expect(perf.Measurements[0].Holdings).To(Equal("PRIDX VFINX"))
+
+				fees := 0
+				for _, trx := range perf.Transactions {
+					if trx.Kind == portfolio.BorrowFeeTransaction {
+						Expect(trx.Ticker).To(Equal("PRIDX"))
+						Expect(trx.TotalValue).Should(BeNumerically(">", 0))
+						fees++
+					}
+				}
+				Expect(fees).To(Equal(24))
+			})
+
+			It("should charge borrow fees in symbol order", func() {
+				timeSeries := dataframe.NewSeriesTime(data.DateIdx, &dataframe.SeriesInit{Size: 3}, []time.Time{
+					time.Date(2018, time.January, 31, 0, 0, 0, 0, time.UTC),
+					time.Date(2019, time.January, 31, 0, 0, 0, 0, time.UTC),
+					time.Date(2020, time.January, 31, 0, 0, 0, 0, time.UTC),
+				})
+				tickerSeries := dataframe.NewSeriesMixed(portfolio.TickerName,
+					&dataframe.SeriesInit{Size: 3},
+					map[string]float64{"VFINX": 2.0, "VUSTX": -0.5, "PRIDX": -0.5},
+					map[string]float64{"VFINX": 2.0, "VUSTX": -0.5, "PRIDX": -0.5},
+					map[string]float64{"VFINX": 2.0, "VUSTX": -0.5, "PRIDX": -0.5},
+				)
+
+				p.BorrowRate = 2.0
+				err := p.TargetPortfolio(10000, dataframe.NewDataFrame(timeSeries, tickerSeries))
+				Expect(err).To(BeNil())
+
+				for run := 0; run < 5; run++ {
+					perf, err := p.CalculatePerformance(time.Date(2020, time.November, 30, 0, 0, 0, 0, time.UTC))
+					Expect(err).To(BeNil())
+
+					tickers := []string{}
+					for _, trx := range perf.Transactions {
+						if trx.Kind == portfolio.BorrowFeeTransaction {
+							tickers = append(tickers, trx.Ticker)
+						}
+					}
+					Expect(tickers).ToNot(BeEmpty())
+					for ii, ticker := range tickers {
+						Expect(ticker).To(Equal([]string{"PRIDX", "VUSTX"}[ii%2]))
+					}
+				}
+			})
+		})
+	})
+
+	Describe("When given a leveraged target portfolio", func() {
+		Context("with a fixed margin rate", func() {
+			It("should borrow cash and charge margin interest", func() {
+				p.Leverage = 1.5
+				p.MarginRate = 6.0
+				err := p.TargetPortfolio(10000, df1)
+				Expect(err).To(BeNil())
+
+				Expect(p.Transactions[2].Kind).To(Equal(portfolio.BuyTransaction))
+				Expect(p.Transactions[2].TotalValue).Should(BeNumerically("~", 15000.00, 1e-2))
+
+				perf, err := p.CalculatePerformance(time.Date(2020, time.November, 30, 0, 0, 0, 0, time.UTC))
+				Expect(err).To(BeNil())
+				Expect(perf.Measurements).Should(HaveLen(35))
+				Expect(perf.Measurements[0].Value).Should(BeNumerically("~", 10000.00, 1e-2))
+				Expect(perf.Measurements[0].Leverage).Should(BeNumerically("~", 1.5, 1e-6))
+
+				interest := []portfolio.Transaction{}
+				for _, trx := range perf.Transactions {
+					if trx.Kind == portfolio.MarginInterestTransaction {
+						interest = append(interest, trx)
+					}
+				}
+				Expect(interest).To(HaveLen(34))
+				Expect(interest[0].Date).To(Equal(time.Date(2018, time.February, 28, 0, 0, 0, 0, time.UTC)))
+				Expect(interest[0].TotalValue).Should(BeNumerically("~", 22.384, 1e-2))
+
+				stats := perf.LeverageStats()
+				Expect(stats).ToNot(BeNil())
+				Expect(stats.Max).Should(BeNumerically(">=", 1.5))
+				Expect(stats.PercentTime).Should(BeNumerically("~", 1.0, 1e-6))
+				Expect(stats.MarginInterest).Should(BeNumerically(">", 0))
+			})
+		})
+
+		Context("with targets that invest more than 100%", func() {
+			var (
+				targets func(weight float64) *dataframe.DataFrame
+			)
+
+			BeforeEach(func() {
+				targets = func(weight float64) *dataframe.DataFrame {
+					timeSeries := dataframe.NewSeriesTime(data.DateIdx, &dataframe.SeriesInit{Size: 1}, []time.Time{
+						time.Date(2018, time.January, 31, 0, 0, 0, 0, time.UTC),
+					})
+					tickerSeries := dataframe.NewSeriesMixed(portfolio.TickerName,
+						&dataframe.SeriesInit{Size: 1},
+						map[string]float64{"VFINX": weight},
+					)
+					return dataframe.NewDataFrame(timeSeries, tickerSeries)
+				}
+				p.Leverage = 1.5
+				p.MarginRate = 6.0
+			})
+
+			It("should not lever them again", func() {
+				err := p.TargetPortfolio(10000, targets(1.5))
+				Expect(err).To(BeNil())
+
+				Expect(p.Transactions[2].Kind).To(Equal(portfolio.BuyTransaction))
+				Expect(p.Transactions[2].TotalValue).Should(BeNumerically("~", 15000.00, 1e-2))
+			})
+
+			It("should reject exposure above the leverage", func() {
+				err := p.TargetPortfolio(10000, targets(2.0))
+				Expect(err).NotTo(BeNil())
+				Expect(err.Error()).To(ContainSubstring("leverage"))
+			})
+		})
+	})
+
+	Describe("When given a target portfolio with an advisory fee", func() {
+		Context("deducted monthly", func() {
+			It("should charge the fee each month", func() {
+				err := p.TargetPortfolio(10000, df1)
+				Expect(err).To(BeNil())
+				p.AdvisoryFee = 100
+				perf, err := p.CalculatePerformance(time.Date(2020, time.November, 30, 0, 0, 0, 0, time.UTC))
+				Expect(err).To(BeNil())
+				Expect(perf.Measurements).Should(HaveLen(35))
+
+				fees := []portfolio.Transaction{}
+				for _, trx := range perf.Transactions {
+					if trx.Kind == portfolio.AdvisoryFeeTransaction {
+						fees = append(fees, trx)
+					}
+				}
+				Expect(fees).To(HaveLen(34))
+				Expect(fees[0].Date).To(Equal(time.Date(2018, time.February, 28, 0, 0, 0, 0, time.UTC)))
+				Expect(fees[0].TotalValue).Should(BeNumerically("~", 10000*0.01*28/365.25, 1e-6))
+				Expect(perf.AdvisoryFees()).Should(BeNumerically(">", 0))
+
+				// fees reduce the value of the portfolio
+				Expect(perf.Measurements[34].Value).Should(BeNumerically("<", 12676.603580175803-perf.AdvisoryFees()))
+
+				// recalculating performance does not duplicate the fees
+				perf, err = p.CalculatePerformance(time.Date(2020, time.November, 30, 0, 0, 0, 0, time.UTC))
+				Expect(err).To(BeNil())
+				n := 0
+				for _, trx := range perf.Transactions {
+					if trx.Kind == portfolio.AdvisoryFeeTransaction {
+						n++
+					}
+				}
+				Expect(n).To(Equal(34))
+			})
+		})
+
+		Context("deducted quarterly", func() {
+			It("should charge the fee when each quarter ends", func() {
+				err := p.TargetPortfolio(10000, df1)
+				Expect(err).To(BeNil())
+				p.AdvisoryFee = 100
+				p.AdvisoryFeeFrequency = portfolio.FeeQuarterly
+				perf, err := p.CalculatePerformance(time.Date(2020, time.November, 30, 0, 0, 0, 0, time.UTC))
+				Expect(err).To(BeNil())
+
+				fees := []portfolio.Transaction{}
+				for _, trx := range perf.Transactions {
+					if trx.Kind == portfolio.AdvisoryFeeTransaction {
+						fees = append(fees, trx)
+					}
+				}
+				Expect(fees).To(HaveLen(11))
+				Expect(fees[0].Date).To(Equal(time.Date(2018, time.April, 30, 0, 0, 0, 0, time.UTC)))
+			})
+		})
+	})
+
+	Describe("When given a portfolio funded mid-month", func() {
+		Context("and measured through mid-month", func() {
+			It("should prorate the first and last periods", func() {
+				csv := "date,close,high,low,open,volume,adjClose,adjHigh,adjLow,adjOpen,adjVolume,divCash,splitFactor\n" +
+					"2018-01-31,260,260,260,260,0,260,260,260,260,0,0.0,1.0\n" +
+					"2018-02-28,247,247,247,247,0,247,247,247,247,0,0.0,1.0\n" +
+					"2018-03-15,252,252,252,252,0,252,252,252,252,0,0.0,1.0\n"
+				httpmock.RegisterResponder("GET", "https://api.tiingo.com/tiingo/daily/VFINX/prices?startDate=2018-01-15&endDate=2018-03-15&format=csv&resampleFreq=Monthly&token=TEST",
+					httpmock.NewStringResponder(200, csv))
+
+				start := time.Date(2018, time.January, 15, 0, 0, 0, 0, time.UTC)
+				p, err := portfolio.NewPortfolioFromTransactions("Mid-month", &dataProxy, []portfolio.Transaction{
+					{Date: start, Ticker: "$CASH", Kind: portfolio.DepositTransaction, TotalValue: 10000},
+					{Date: start, Ticker: "VFINX", Kind: portfolio.BuyTransaction, PricePerShare: 250, Shares: 40, TotalValue: 10000},
+				})
+				Expect(err).To(BeNil())
+
+				perf, err := p.CalculatePerformance(time.Date(2018, time.March, 15, 0, 0, 0, 0, time.UTC))
+				Expect(err).To(BeNil())
+				Expect(perf.Measurements).To(HaveLen(3))
+				Expect(perf.WarmUpPeriods).To(Equal(0))
+				Expect(perf.EffectiveStart).To(Equal(time.Date(2018, time.January, 31, 0, 0, 0, 0, time.UTC).Unix()))
+				Expect(perf.PartialFirstPeriod).To(BeTrue())
+				Expect(perf.PartialLastPeriod).To(BeTrue())
+
+				// the first return is measured from the capital deposited on the 15th
+				Expect(perf.Measurements[0].PercentReturn).Should(BeNumerically("~", 0.04, 1e-9))
+				Expect(perf.Measurements[0].PeriodFraction).Should(BeNumerically("~", 16.0/31.0, 1e-9))
+				Expect(perf.Measurements[1].PeriodFraction).To(Equal(0.0))
+				Expect(perf.Measurements[2].PeriodFraction).Should(BeNumerically("~", 15.0/31.0, 1e-9))
+			})
+		})
+	})
+
 })
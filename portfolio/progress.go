@@ -0,0 +1,17 @@
+package portfolio
+
+import "time"
+
+// ProgressReporter notified of the progress of a simulation: the percent of
+// the simulation that is complete and the date it has reached
+type ProgressReporter interface {
+	Progress(percent float64, date time.Time)
+}
+
+// ProgressFunc adapter to use an ordinary function as a ProgressReporter
+type ProgressFunc func(percent float64, date time.Time)
+
+// Progress call f(percent, date)
+func (f ProgressFunc) Progress(percent float64, date time.Time) {
+	f(percent, date)
+}
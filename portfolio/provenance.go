@@ -0,0 +1,45 @@
+package portfolio
+
+import (
+	"encoding/json"
+	"main/util"
+	"time"
+)
+
+// Provenance what a result was computed from so users can tell why two runs
+// of the "same" backtest differ. Simulations have no random components; the
+// code, the strategy and its arguments, and the data determine the result.
+type Provenance struct {
+	// CodeVersion version of the code that computed the result
+	CodeVersion string `json:"codeVersion"`
+
+	// Strategy shortcode and version of the strategy and the arguments it
+	// was run with; empty for portfolios not created by a strategy
+	Strategy        string                     `json:"strategy,omitempty"`
+	StrategyVersion string                     `json:"strategyVersion,omitempty"`
+	Parameters      map[string]json.RawMessage `json:"parameters,omitempty"`
+
+	// DataSnapshots when the data of each ticker was retrieved from its
+	// provider
+	DataSnapshots map[string]time.Time `json:"dataSnapshots"`
+
+	// Proxies tickers whose history before they were listed is that of
+	// another ticker, e.g. VOO extended with VFINX
+	Proxies map[string]string `json:"proxies,omitempty"`
+}
+
+// provenance the provenance of the portfolio's performance: the strategy
+// that set p.Provenance and the current code and data
+func (p *Portfolio) provenance() Provenance {
+	provenance := p.Provenance
+	provenance.CodeVersion = util.Version
+	if p.dataProxy != nil {
+		provenance.DataSnapshots = p.dataProxy.Snapshots()
+		if len(p.dataProxy.Proxies) > 0 {
+			provenance.Proxies = p.dataProxy.Proxies
+		}
+	} else {
+		provenance.DataSnapshots = map[string]time.Time{}
+	}
+	return provenance
+}
@@ -0,0 +1,58 @@
+package portfolio
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Metrics a comparison may be ranked by
+const (
+	RankCagr        = "cagr"
+	RankSharpe      = "sharpe"
+	RankSortino     = "sortino"
+	RankCalmar      = "calmar"
+	RankMaxDrawDown = "maxDrawDown"
+	RankUlcerIndex  = "ulcerIndex"
+	RankMartin      = "martin"
+	RankPainRatio   = "painRatio"
+)
+
+// rankScores the score of each ranking metric; higher scores rank first
+var rankScores = map[string]func(m *ComparisonMember) float64{
+	RankCagr:        func(m *ComparisonMember) float64 { return m.Cagr },
+	RankSharpe:      func(m *ComparisonMember) float64 { return m.Metrics.SharpeRatio },
+	RankSortino:     func(m *ComparisonMember) float64 { return m.Metrics.SortinoRatio },
+	RankCalmar:      func(m *ComparisonMember) float64 { return m.Metrics.CalmarRatio },
+	RankMaxDrawDown: func(m *ComparisonMember) float64 { return m.MaxDrawDown },
+	RankUlcerIndex:  func(m *ComparisonMember) float64 { return -m.Metrics.UlcerIndex },
+	RankMartin:      func(m *ComparisonMember) float64 { return m.Metrics.MartinRatio },
+	RankPainRatio:   func(m *ComparisonMember) float64 { return m.Metrics.PainRatio },
+}
+
+// RankedMember a portfolio of a comparison and its position in the ranking,
+// starting at 1
+type RankedMember struct {
+	Rank int `json:"rank"`
+	ComparisonMember
+}
+
+// Rank order the portfolios of the comparison from best to worst by metric;
+// portfolios that score the same keep their order
+func (comparison *Comparison) Rank(metric string) ([]RankedMember, error) {
+	score, ok := rankScores[metric]
+	if !ok {
+		return nil, fmt.Errorf("cannot rank by '%s'", metric)
+	}
+
+	ranked := make([]RankedMember, len(comparison.Portfolios))
+	for ii := range comparison.Portfolios {
+		ranked[ii].ComparisonMember = comparison.Portfolios[ii]
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return score(&ranked[i].ComparisonMember) > score(&ranked[j].ComparisonMember)
+	})
+	for ii := range ranked {
+		ranked[ii].Rank = ii + 1
+	}
+	return ranked, nil
+}
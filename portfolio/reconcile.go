@@ -0,0 +1,134 @@
+package portfolio
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Position number of shares of a security held in a brokerage account
+type Position struct {
+	Ticker string  `json:"ticker"`
+	Shares float64 `json:"shares"`
+}
+
+// LedgerEntry a transaction and the running balance of the affected
+// security (or cash) after it was applied
+type LedgerEntry struct {
+	Transaction Transaction `json:"transaction"`
+	Balance     float64     `json:"balance"`
+}
+
+// Discrepancy difference between the ledger and the broker for a single
+// security. Ledger contains the transactions that contributed to the
+// expected balance so that users can drill down to the source of the problem
+type Discrepancy struct {
+	Ticker     string        `json:"ticker"`
+	Expected   float64       `json:"expected"`
+	Actual     float64       `json:"actual"`
+	Difference float64       `json:"difference"`
+	Ledger     []LedgerEntry `json:"ledger"`
+}
+
+// ReconciliationReport result of comparing ledger holdings with broker positions
+type ReconciliationReport struct {
+	AsOf          int64         `json:"asOf"`
+	Reconciled    bool          `json:"reconciled"`
+	Matched       []Position    `json:"matched"`
+	Discrepancies []Discrepancy `json:"discrepancies"`
+}
+
+const (
+	// ShareTolerance differences in share counts smaller than this are ignored
+	ShareTolerance = 1.0e-4
+
+	// CashTolerance differences in cash balance smaller than this are ignored
+	CashTolerance = 0.01
+)
+
+// Reconcile compare the holdings implied by the transaction ledger as of
+// asOf to the positions reported by the broker. Cash should be reported by
+// the broker as a position with the ticker $CASH.
+func Reconcile(trxs []Transaction, positions []Position, asOf time.Time) ReconciliationReport {
+	sorted := make([]Transaction, len(trxs))
+	copy(sorted, trxs)
+	SortTransactions(sorted)
+
+	expected := LedgerHoldings(sorted, asOf)
+	actual := make(map[string]float64)
+	for _, pos := range positions {
+		actual[pos.Ticker] += pos.Shares
+	}
+
+	tickerSet := make(map[string]bool)
+	for k := range expected {
+		tickerSet[k] = true
+	}
+	for k := range actual {
+		tickerSet[k] = true
+	}
+	tickerSet["$CASH"] = true
+
+	tickers := make([]string, 0, len(tickerSet))
+	for k := range tickerSet {
+		tickers = append(tickers, k)
+	}
+	sort.Strings(tickers)
+
+	report := ReconciliationReport{
+		AsOf:          asOf.Unix(),
+		Matched:       []Position{},
+		Discrepancies: []Discrepancy{},
+	}
+
+	for _, ticker := range tickers {
+		diff := actual[ticker] - expected[ticker]
+		tolerance := ShareTolerance
+		if ticker == "$CASH" {
+			tolerance = CashTolerance
+		}
+
+		if math.Abs(diff) <= tolerance {
+			report.Matched = append(report.Matched, Position{
+				Ticker: ticker,
+				Shares: actual[ticker],
+			})
+			continue
+		}
+
+		report.Discrepancies = append(report.Discrepancies, Discrepancy{
+			Ticker:     ticker,
+			Expected:   expected[ticker],
+			Actual:     actual[ticker],
+			Difference: diff,
+			Ledger:     tickerLedger(sorted, ticker, asOf),
+		})
+	}
+
+	report.Reconciled = len(report.Discrepancies) == 0
+	return report
+}
+
+// tickerLedger list transactions that affected ticker along with the
+// running balance
+func tickerLedger(trxs []Transaction, ticker string, asOf time.Time) []LedgerEntry {
+	entries := []LedgerEntry{}
+	holdings := make(map[string]float64)
+	for _, trx := range trxs {
+		if trx.Date.After(asOf) {
+			break
+		}
+
+		affectsCash := trx.Kind != MarkerTransaction && trx.Kind != SplitTransaction
+		if trx.Ticker != ticker && !(ticker == "$CASH" && affectsCash) {
+			continue
+		}
+
+		applyTransaction(holdings, &trx)
+		entries = append(entries, LedgerEntry{
+			Transaction: trx,
+			Balance:     holdings[ticker],
+		})
+	}
+	return entries
+}
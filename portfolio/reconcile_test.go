@@ -0,0 +1,70 @@
+package portfolio_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"main/portfolio"
+)
+
+var _ = Describe("Reconcile", func() {
+	var (
+		trxs []portfolio.Transaction
+		asOf time.Time
+	)
+
+	BeforeEach(func() {
+		d1 := time.Date(2021, time.January, 29, 0, 0, 0, 0, time.UTC)
+		d2 := time.Date(2021, time.February, 26, 0, 0, 0, 0, time.UTC)
+		asOf = time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)
+		trxs = []portfolio.Transaction{
+			{Date: d1, Ticker: "$CASH", Kind: portfolio.DepositTransaction, TotalValue: 10000},
+			{Date: d1, Ticker: "VFINX", Kind: portfolio.BuyTransaction, Shares: 30, PricePerShare: 330, TotalValue: 9900},
+			{Date: d2, Ticker: "VFINX", Kind: portfolio.SellTransaction, Shares: 30, PricePerShare: 340, TotalValue: 10200},
+			{Date: d2, Ticker: "VUSTX", Kind: portfolio.BuyTransaction, Shares: 700, PricePerShare: 14.5, TotalValue: 10150},
+		}
+	})
+
+	Describe("When broker positions match the ledger", func() {
+		It("should be reconciled", func() {
+			report := portfolio.Reconcile(trxs, []portfolio.Position{
+				{Ticker: "VUSTX", Shares: 700},
+				{Ticker: "$CASH", Shares: 150},
+			}, asOf)
+			Expect(report.Reconciled).To(BeTrue())
+			Expect(report.Discrepancies).To(HaveLen(0))
+			Expect(report.Matched).To(HaveLen(2))
+		})
+	})
+
+	Describe("When broker positions differ from the ledger", func() {
+		It("should flag cash discrepancies with a drill-down ledger", func() {
+			report := portfolio.Reconcile(trxs, []portfolio.Position{
+				{Ticker: "VUSTX", Shares: 700},
+				{Ticker: "$CASH", Shares: 206.62},
+			}, asOf)
+			Expect(report.Reconciled).To(BeFalse())
+			Expect(report.Discrepancies).To(HaveLen(1))
+
+			d := report.Discrepancies[0]
+			Expect(d.Ticker).To(Equal("$CASH"))
+			Expect(d.Expected).Should(BeNumerically("~", 150, 1e-9))
+			Expect(d.Difference).Should(BeNumerically("~", 56.62, 1e-9))
+			Expect(d.Ledger).To(HaveLen(4))
+			Expect(d.Ledger[3].Balance).Should(BeNumerically("~", 150, 1e-9))
+		})
+
+		It("should flag securities missing from the broker", func() {
+			report := portfolio.Reconcile(trxs, []portfolio.Position{
+				{Ticker: "$CASH", Shares: 150},
+			}, asOf)
+			Expect(report.Reconciled).To(BeFalse())
+			Expect(report.Discrepancies).To(HaveLen(1))
+			Expect(report.Discrepancies[0].Ticker).To(Equal("VUSTX"))
+			Expect(report.Discrepancies[0].Difference).Should(BeNumerically("~", -700, 1e-9))
+			Expect(report.Discrepancies[0].Ledger).To(HaveLen(1))
+		})
+	})
+})
@@ -0,0 +1,249 @@
+package portfolio
+
+import (
+	"main/data"
+	"math"
+	"time"
+
+	"github.com/rocketlaunchr/dataframe-go"
+	"gonum.org/v1/gonum/stat"
+)
+
+// Dimensions along which market regimes are classified
+const (
+	// RegimeTrend bull or bear market by the 200-day moving average
+	RegimeTrend = "trend"
+
+	// RegimeVolatility high or low VIX
+	RegimeVolatility = "volatility"
+
+	// RegimeRates rising or falling interest rates
+	RegimeRates = "rates"
+)
+
+// Market regimes
+const (
+	RegimeBull           = "bull"
+	RegimeBear           = "bear"
+	RegimeHighVolatility = "high"
+	RegimeLowVolatility  = "low"
+	RegimeRising         = "rising"
+	RegimeFalling        = "falling"
+)
+
+const (
+	// RegimeSMADays days in the moving average separating bull and bear
+	// markets
+	RegimeSMADays = 200
+
+	// RegimeVIXThreshold VIX level at and above which volatility is high
+	RegimeVIXThreshold = 20.0
+
+	// RegimeRateLookback observations over which the change in interest
+	// rates is measured; about 3 months of daily rates
+	RegimeRateLookback = 63
+)
+
+// RegimeIndicators the regime of each dimension at each measurement of a
+// performance; empty if the regime is unknown, e.g. before there is enough
+// history to classify it
+type RegimeIndicators struct {
+	Trend      []string
+	Volatility []string
+	Rates      []string
+}
+
+// RegimeStats performance of a portfolio during the measurement periods
+// that started in a regime
+type RegimeStats struct {
+	Dimension        string  `json:"dimension"`
+	Regime           string  `json:"regime"`
+	Periods          int     `json:"periods"`
+	TotalReturn      float64 `json:"totalReturn"`
+	AverageReturn    float64 `json:"averageReturn"`
+	AnnualizedReturn float64 `json:"annualizedReturn"`
+	Volatility       float64 `json:"volatility"`
+	HitRate          float64 `json:"hitRate"`
+	BestReturn       float64 `json:"bestReturn"`
+	WorstReturn      float64 `json:"worstReturn"`
+}
+
+// NewRegimeIndicators classify the regimes at each measurement of perf from
+// daily market prices (the marketColumn of market), VIX levels (the
+// vixColumn of vix), and interest rates (the rateColumn of rates). Any of
+// the dataframes may be nil, in which case its dimension is unknown.
+func NewRegimeIndicators(perf *Performance, market *dataframe.DataFrame, marketColumn string, vix *dataframe.DataFrame, vixColumn string, rates *dataframe.DataFrame, rateColumn string) RegimeIndicators {
+	return RegimeIndicators{
+		Trend:      regimesAsOf(perf, trendRegimes(market, marketColumn)),
+		Volatility: regimesAsOf(perf, volatilityRegimes(vix, vixColumn)),
+		Rates:      regimesAsOf(perf, rateRegimes(rates, rateColumn)),
+	}
+}
+
+// datedRegime regime classified on a date
+type datedRegime struct {
+	date   time.Time
+	regime string
+}
+
+// columnObservations dates and non-NaN values of column in df
+func columnObservations(df *dataframe.DataFrame, column string) ([]time.Time, []float64) {
+	if df == nil {
+		return nil, nil
+	}
+
+	dates := []time.Time{}
+	vals := []float64{}
+	iterator := df.ValuesIterator(dataframe.ValuesOptions{InitialRow: 0, Step: 1, DontReadLock: true})
+	for {
+		row, rowVals, _ := iterator(dataframe.SeriesName)
+		if row == nil {
+			break
+		}
+		date, ok := rowVals[data.DateIdx].(time.Time)
+		if !ok {
+			continue
+		}
+		if val, ok := rowVals[column].(float64); ok && !math.IsNaN(val) {
+			dates = append(dates, date)
+			vals = append(vals, val)
+		}
+	}
+	return dates, vals
+}
+
+// trendRegimes bull when the price is above its moving average, bear
+// otherwise
+func trendRegimes(market *dataframe.DataFrame, column string) []datedRegime {
+	dates, prices := columnObservations(market, column)
+	regimes := []datedRegime{}
+	sum := 0.0
+	for ii := range prices {
+		sum += prices[ii]
+		if ii >= RegimeSMADays {
+			sum -= prices[ii-RegimeSMADays]
+		}
+		if ii < RegimeSMADays-1 {
+			continue
+		}
+
+		regime := RegimeBear
+		if prices[ii] > sum/RegimeSMADays {
+			regime = RegimeBull
+		}
+		regimes = append(regimes, datedRegime{date: dates[ii], regime: regime})
+	}
+	return regimes
+}
+
+// volatilityRegimes high when the VIX is at or above RegimeVIXThreshold
+func volatilityRegimes(vix *dataframe.DataFrame, column string) []datedRegime {
+	dates, levels := columnObservations(vix, column)
+	regimes := make([]datedRegime, len(levels))
+	for ii := range levels {
+		regime := RegimeLowVolatility
+		if levels[ii] >= RegimeVIXThreshold {
+			regime = RegimeHighVolatility
+		}
+		regimes[ii] = datedRegime{date: dates[ii], regime: regime}
+	}
+	return regimes
+}
+
+// rateRegimes rising when rates are higher than RegimeRateLookback
+// observations ago, falling otherwise
+func rateRegimes(rates *dataframe.DataFrame, column string) []datedRegime {
+	dates, levels := columnObservations(rates, column)
+	regimes := []datedRegime{}
+	for ii := RegimeRateLookback; ii < len(levels); ii++ {
+		regime := RegimeFalling
+		if levels[ii] > levels[ii-RegimeRateLookback] {
+			regime = RegimeRising
+		}
+		regimes = append(regimes, datedRegime{date: dates[ii], regime: regime})
+	}
+	return regimes
+}
+
+// regimesAsOf the last regime classified on or before each measurement
+func regimesAsOf(perf *Performance, regimes []datedRegime) []string {
+	res := make([]string, len(perf.Measurements))
+	jj := -1
+	for ii, m := range perf.Measurements {
+		for jj+1 < len(regimes) && regimes[jj+1].date.Unix() <= m.Time {
+			jj++
+		}
+		if jj >= 0 {
+			res[ii] = regimes[jj].regime
+		}
+	}
+	return res
+}
+
+// RegimeAnalysis split the returns of the portfolio by the regime each
+// measurement period started in, so only what was known at the start of
+// the period is used, and summarize each regime. Regimes without any
+// periods are omitted.
+func (perf *Performance) RegimeAnalysis(indicators RegimeIndicators) []RegimeStats {
+	dimensions := []struct {
+		name    string
+		regimes []string
+		labels  []string
+	}{
+		{RegimeTrend, []string{RegimeBull, RegimeBear}, indicators.Trend},
+		{RegimeVolatility, []string{RegimeLowVolatility, RegimeHighVolatility}, indicators.Volatility},
+		{RegimeRates, []string{RegimeFalling, RegimeRising}, indicators.Rates},
+	}
+
+	ppy := perf.annualPeriods()
+	results := []RegimeStats{}
+	for _, dimension := range dimensions {
+		if len(dimension.labels) != len(perf.Measurements) {
+			continue
+		}
+
+		for _, regime := range dimension.regimes {
+			rets := []float64{}
+			for ii := 1; ii < len(perf.Measurements); ii++ {
+				if dimension.labels[ii-1] == regime {
+					rets = append(rets, perf.Measurements[ii].PercentReturn)
+				}
+			}
+			if len(rets) == 0 {
+				continue
+			}
+			results = append(results, regimeStats(dimension.name, regime, rets, ppy))
+		}
+	}
+	return results
+}
+
+// regimeStats summarize the returns earned in a regime
+func regimeStats(dimension string, regime string, rets []float64, ppy float64) RegimeStats {
+	stats := RegimeStats{
+		Dimension:     dimension,
+		Regime:        regime,
+		Periods:       len(rets),
+		AverageReturn: stat.Mean(rets, nil),
+		BestReturn:    rets[0],
+		WorstReturn:   rets[0],
+	}
+
+	growth := 1.0
+	positive := 0
+	for _, r := range rets {
+		growth *= 1.0 + r
+		if r > 0 {
+			positive++
+		}
+		stats.BestReturn = math.Max(stats.BestReturn, r)
+		stats.WorstReturn = math.Min(stats.WorstReturn, r)
+	}
+	stats.TotalReturn = growth - 1.0
+	stats.AnnualizedReturn = annualizedReturn(rets, ppy)
+	stats.HitRate = float64(positive) / float64(len(rets))
+	if len(rets) > 1 {
+		stats.Volatility = stat.StdDev(rets, nil) * math.Sqrt(ppy)
+	}
+	return stats
+}
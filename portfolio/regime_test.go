@@ -0,0 +1,107 @@
+package portfolio_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/rocketlaunchr/dataframe-go"
+
+	"main/data"
+	"main/portfolio"
+)
+
+var _ = Describe("Regime", func() {
+	var (
+		perf   portfolio.Performance
+		market *dataframe.DataFrame
+		vix    *dataframe.DataFrame
+	)
+
+	// the VIX is high from March through May 2020
+	highVolatility := func(d time.Time) bool {
+		return d.Year() == 2020 && d.Month() >= time.March && d.Month() <= time.May
+	}
+
+	BeforeEach(func() {
+		// daily market prices that always rise and VIX levels
+		dates := []time.Time{}
+		prices := []float64{}
+		levels := []float64{}
+		for d := time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC); d.Year() < 2021; d = d.AddDate(0, 0, 1) {
+			dates = append(dates, d)
+			prices = append(prices, 100+float64(len(prices)))
+			if highVolatility(d) {
+				levels = append(levels, 30)
+			} else {
+				levels = append(levels, 15)
+			}
+		}
+		market = dataframe.NewDataFrame(
+			dataframe.NewSeriesTime(data.DateIdx, &dataframe.SeriesInit{Size: len(dates)}, dates),
+			dataframe.NewSeriesFloat64("SPY", &dataframe.SeriesInit{Size: len(prices)}, prices),
+		)
+		vix = dataframe.NewDataFrame(
+			dataframe.NewSeriesTime(data.DateIdx, &dataframe.SeriesInit{Size: len(dates)}, dates),
+			dataframe.NewSeriesFloat64("VIXCLS", &dataframe.SeriesInit{Size: len(levels)}, levels),
+		)
+
+		// monthly measurements through 2020 that lose 2% in periods starting
+		// with a high VIX and gain 1% otherwise
+		perf = portfolio.Performance{}
+		value := 1000.0
+		for ii := 0; ii <= 12; ii++ {
+			t := time.Date(2020, time.January+time.Month(ii), 1, 0, 0, 0, 0, time.UTC)
+			var ret float64
+			if ii > 0 {
+				ret = 0.01
+				if highVolatility(t.AddDate(0, -1, 0)) {
+					ret = -0.02
+				}
+			}
+			value *= 1 + ret
+			perf.Measurements = append(perf.Measurements, portfolio.PerformanceMeasurement{Time: t.Unix(), Value: value, PercentReturn: ret})
+		}
+	})
+
+	It("should classify each period by the regime it started in", func() {
+		indicators := portfolio.NewRegimeIndicators(&perf, market, "SPY", vix, "VIXCLS", nil, "DGS10")
+		stats := perf.RegimeAnalysis(indicators)
+
+		byRegime := map[string]portfolio.RegimeStats{}
+		for _, s := range stats {
+			byRegime[s.Dimension+"/"+s.Regime] = s
+		}
+		Expect(byRegime).To(HaveLen(3))
+
+		Expect(byRegime["trend/bull"].Periods).To(Equal(12))
+
+		high := byRegime["volatility/high"]
+		Expect(high.Periods).To(Equal(3))
+		Expect(high.HitRate).To(Equal(0.0))
+		Expect(high.TotalReturn).To(BeNumerically("~", 0.98*0.98*0.98-1, 1e-9))
+		Expect(high.WorstReturn).To(BeNumerically("~", -0.02, 1e-9))
+
+		low := byRegime["volatility/low"]
+		Expect(low.Periods).To(Equal(9))
+		Expect(low.HitRate).To(Equal(1.0))
+		Expect(low.AverageReturn).To(BeNumerically("~", 0.01, 1e-9))
+	})
+
+	It("should treat the market as bearish once it falls below its moving average", func() {
+		prices := make([]float64, market.NRows())
+		for ii := range prices {
+			prices[ii] = 1000 - float64(ii)
+		}
+		dateIdx, _ := market.NameToColumn(data.DateIdx)
+		falling := dataframe.NewDataFrame(
+			market.Series[dateIdx].Copy(),
+			dataframe.NewSeriesFloat64("SPY", &dataframe.SeriesInit{Size: len(prices)}, prices),
+		)
+
+		indicators := portfolio.NewRegimeIndicators(&perf, falling, "SPY", nil, "VIXCLS", nil, "DGS10")
+		stats := perf.RegimeAnalysis(indicators)
+		Expect(stats).To(HaveLen(1))
+		Expect(stats[0].Regime).To(Equal(portfolio.RegimeBear))
+	})
+})
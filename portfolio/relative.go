@@ -0,0 +1,195 @@
+package portfolio
+
+import (
+	"math"
+	"time"
+
+	"gonum.org/v1/gonum/stat"
+)
+
+// RelativeMetrics performance of a portfolio relative to a benchmark
+type RelativeMetrics struct {
+	BenchmarkID      string  `json:"benchmarkId"`
+	BenchmarkName    string  `json:"benchmarkName"`
+	BenchmarkCagr    float64 `json:"benchmarkCagr"`
+	ExcessReturn     float64 `json:"excessReturn"`
+	Alpha            float64 `json:"alpha"`
+	Beta             float64 `json:"beta"`
+	Correlation      float64 `json:"correlation"`
+	TrackingError    float64 `json:"trackingError"`
+	InformationRatio float64 `json:"informationRatio"`
+	UpCapture        float64 `json:"upCapture"`
+	DownCapture      float64 `json:"downCapture"`
+}
+
+// alignedReturns returns of the portfolio, benchmark, and risk-free rate for
+// periods measured by both performances. The first measurement of each
+// performance and warm-up measurements are skipped since they do not have
+// a return.
+func alignedReturns(perf *Performance, benchmark *Performance) ([]float64, []float64, []float64, []int64) {
+	benchIdx := make(map[int64]int, len(benchmark.Measurements))
+	for ii, m := range benchmark.Measurements {
+		if ii > 0 && !m.WarmUp && !benchmark.Measurements[ii-1].WarmUp {
+			benchIdx[m.Time] = ii
+		}
+	}
+
+	var rp, rb, rf []float64
+	var times []int64
+	for ii, m := range perf.Measurements {
+		if ii == 0 || m.WarmUp || perf.Measurements[ii-1].WarmUp {
+			continue
+		}
+		jj, ok := benchIdx[m.Time]
+		if !ok {
+			continue
+		}
+
+		var riskFree float64
+		if prev := perf.Measurements[ii-1].RiskFreeValue; prev != 0 {
+			riskFree = m.RiskFreeValue/prev - 1.0
+		}
+
+		rp = append(rp, m.PercentReturn)
+		rb = append(rb, benchmark.Measurements[jj].PercentReturn)
+		rf = append(rf, riskFree)
+		times = append(times, m.Time)
+	}
+
+	return rp, rb, rf, times
+}
+
+// periodsPerYear estimate the number of measurements per year from the
+// average spacing of times
+func periodsPerYear(times []int64) float64 {
+	if len(times) < 2 {
+		return 12
+	}
+	days := float64(times[len(times)-1]-times[0]) / 86400.0 / float64(len(times)-1)
+	if days <= 0 {
+		return 12
+	}
+	return 365.25 / days
+}
+
+// annualizedReturn compound the returns and annualize the result
+func annualizedReturn(rets []float64, ppy float64) float64 {
+	growth := 1.0
+	for _, r := range rets {
+		growth *= 1.0 + r
+	}
+	return math.Pow(growth, ppy/float64(len(rets))) - 1.0
+}
+
+// alphaBeta annualized Jensen's alpha and beta of the portfolio returns rp
+// against the benchmark returns rb
+func alphaBeta(rp []float64, rb []float64, rf []float64, ppy float64) (float64, float64) {
+	var beta float64
+	if variance := stat.Variance(rb, nil); variance != 0 {
+		beta = stat.Covariance(rp, rb, nil) / variance
+	}
+
+	excessP := make([]float64, len(rp))
+	excessB := make([]float64, len(rp))
+	for ii := range rp {
+		excessP[ii] = rp[ii] - rf[ii]
+		excessB[ii] = rb[ii] - rf[ii]
+	}
+	alpha := (stat.Mean(excessP, nil) - beta*stat.Mean(excessB, nil)) * ppy
+
+	return alpha, beta
+}
+
+// RelativeTo compare the performance to a benchmark over the periods that
+// both were measured
+func (perf *Performance) RelativeTo(benchmark *Performance) RelativeMetrics {
+	metrics := RelativeMetrics{}
+	rp, rb, rf, times := alignedReturns(perf, benchmark)
+	if len(rp) < 2 {
+		return metrics
+	}
+
+	ppy := periodsPerYear(times)
+	metrics.BenchmarkCagr = annualizedReturn(rb, ppy)
+	metrics.ExcessReturn = annualizedReturn(rp, ppy) - metrics.BenchmarkCagr
+
+	metrics.Alpha, metrics.Beta = alphaBeta(rp, rb, rf, ppy)
+	metrics.Correlation = stat.Correlation(rp, rb, nil)
+	if math.IsNaN(metrics.Correlation) {
+		metrics.Correlation = 0
+	}
+
+	metrics.TrackingError = perf.TrackingError(benchmark)
+	metrics.InformationRatio = perf.InformationRatio(benchmark)
+
+	// capture ratios compare the average return in up and down periods
+	var upP, upB, downP, downB float64
+	for ii := range rb {
+		if rb[ii] > 0 {
+			upP += rp[ii]
+			upB += rb[ii]
+		} else if rb[ii] < 0 {
+			downP += rp[ii]
+			downB += rb[ii]
+		}
+	}
+	if upB != 0 {
+		metrics.UpCapture = upP / upB
+	}
+	if downB != 0 {
+		metrics.DownCapture = downP / downB
+	}
+
+	return metrics
+}
+
+// RollingPoint alpha and beta of a portfolio over the window ending at Time
+type RollingPoint struct {
+	Time  int64   `json:"time"`
+	Alpha float64 `json:"alpha"`
+	Beta  float64 `json:"beta"`
+}
+
+// RollingAlphaBeta alpha and beta relative to benchmark over a trailing
+// window of months, computed at each measurement that has a full window of
+// history. Alpha is annualized.
+func (perf *Performance) RollingAlphaBeta(benchmark *Performance, months int) []RollingPoint {
+	rp, rb, rf, times := alignedReturns(perf, benchmark)
+	points := []RollingPoint{}
+	if len(times) == 0 {
+		return points
+	}
+
+	// the first return covers the period since the measurement before it
+	var first time.Time
+	for ii := 1; ii < len(perf.Measurements); ii++ {
+		if perf.Measurements[ii].Time == times[0] {
+			first = time.Unix(perf.Measurements[ii-1].Time, 0).UTC()
+			break
+		}
+	}
+
+	start := 0
+	for end := range times {
+		windowEnd := time.Unix(times[end], 0).UTC()
+		windowStart := windowEnd.AddDate(0, -months, 0)
+		if windowStart.Before(first) {
+			continue
+		}
+		for !time.Unix(times[start], 0).UTC().After(windowStart) {
+			start++
+		}
+		if end-start < 1 {
+			continue
+		}
+
+		alpha, beta := alphaBeta(rp[start:end+1], rb[start:end+1], rf[start:end+1], periodsPerYear(times[start:end+1]))
+		points = append(points, RollingPoint{
+			Time:  times[end],
+			Alpha: alpha,
+			Beta:  beta,
+		})
+	}
+
+	return points
+}
@@ -0,0 +1,128 @@
+package portfolio_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"main/portfolio"
+)
+
+var _ = Describe("RelativeMetrics", func() {
+	var (
+		perf  portfolio.Performance
+		bench portfolio.Performance
+	)
+
+	BeforeEach(func() {
+		perf = portfolio.Performance{
+			Measurements: []portfolio.PerformanceMeasurement{
+				{Time: 0, Value: 1000, RiskFreeValue: 1000},
+				{Time: 2629800, Value: 1040, RiskFreeValue: 1000, PercentReturn: 0.04},
+				{Time: 5259600, Value: 1019.2, RiskFreeValue: 1000, PercentReturn: -0.02},
+				{Time: 7889400, Value: 1080.352, RiskFreeValue: 1000, PercentReturn: 0.06},
+			},
+		}
+		bench = portfolio.Performance{
+			Measurements: []portfolio.PerformanceMeasurement{
+				{Time: 0, Value: 1000, RiskFreeValue: 1000},
+				{Time: 2629800, Value: 1020, RiskFreeValue: 1000, PercentReturn: 0.02},
+				{Time: 5259600, Value: 1009.8, RiskFreeValue: 1000, PercentReturn: -0.01},
+				{Time: 7889400, Value: 1040.094, RiskFreeValue: 1000, PercentReturn: 0.03},
+			},
+		}
+	})
+
+	Describe("When the portfolio is a leveraged benchmark", func() {
+		It("should have a beta of 2", func() {
+			metrics := perf.RelativeTo(&bench)
+			Expect(metrics.Beta).Should(BeNumerically("~", 2.0, 1e-9))
+			Expect(metrics.Correlation).Should(BeNumerically("~", 1.0, 1e-9))
+			Expect(metrics.Alpha).Should(BeNumerically("~", 0.0, 1e-9))
+			Expect(metrics.UpCapture).Should(BeNumerically("~", 2.0, 1e-9))
+			Expect(metrics.DownCapture).Should(BeNumerically("~", 2.0, 1e-9))
+			Expect(metrics.ExcessReturn).Should(BeNumerically(">", 0))
+		})
+
+		It("should have a tracking error", func() {
+			Expect(perf.TrackingError(&bench)).Should(BeNumerically("~", 0.072111, 1e-5))
+			Expect(perf.InformationRatio(&bench)).Should(BeNumerically("~", 2.218801, 1e-5))
+		})
+	})
+
+	Describe("When measuring systematic risk", func() {
+		It("should have a beta and treynor ratio", func() {
+			Expect(perf.Beta(&bench)).Should(BeNumerically("~", 2.0, 1e-9))
+
+			// mean return of 2.6667% a month, annualized, per unit of beta
+			Expect(perf.TreynorRatio(&bench)).Should(BeNumerically("~", 0.16, 1e-9))
+		})
+
+		It("should skip warm-up measurements", func() {
+			warmUp := portfolio.PerformanceMeasurement{Time: -2629800, Value: 0, RiskFreeValue: 1000, WarmUp: true}
+			perf.Measurements = append([]portfolio.PerformanceMeasurement{warmUp}, perf.Measurements...)
+			perf.Measurements[1].PercentReturn = 0.5
+
+			Expect(perf.Return()).To(Equal([]float64{0.04, -0.02, 0.06}))
+			Expect(perf.Beta(&bench)).Should(BeNumerically("~", 2.0, 1e-9))
+			Expect(perf.TreynorRatio(&bench)).Should(BeNumerically("~", 0.16, 1e-9))
+		})
+	})
+
+	Describe("When compared to itself", func() {
+		It("should have no tracking error", func() {
+			metrics := bench.RelativeTo(&bench)
+			Expect(metrics.TrackingError).Should(BeNumerically("~", 0.0, 1e-9))
+			Expect(metrics.InformationRatio).Should(BeNumerically("~", 0.0, 1e-9))
+			Expect(metrics.ExcessReturn).Should(BeNumerically("~", 0.0, 1e-9))
+		})
+	})
+})
+
+var _ = Describe("RollingAlphaBeta", func() {
+	var (
+		perf  portfolio.Performance
+		bench portfolio.Performance
+	)
+
+	// monthly measurements; the portfolio is the benchmark at 2x leverage
+	// for the first two years and unlevered after
+	BeforeEach(func() {
+		perf = portfolio.Performance{}
+		bench = portfolio.Performance{}
+		perfValue, benchValue := 1000.0, 1000.0
+		for ii := 0; ii <= 48; ii++ {
+			t := time.Date(2010, time.January+time.Month(ii), 1, 0, 0, 0, 0, time.UTC).Unix()
+			var rb, rp float64
+			if ii > 0 {
+				rb = 0.01 * float64(ii%5-2)
+				rp = rb
+				if ii <= 24 {
+					rp = 2 * rb
+				}
+			}
+			perfValue *= 1 + rp
+			benchValue *= 1 + rb
+			perf.Measurements = append(perf.Measurements, portfolio.PerformanceMeasurement{Time: t, Value: perfValue, RiskFreeValue: 1000, PercentReturn: rp})
+			bench.Measurements = append(bench.Measurements, portfolio.PerformanceMeasurement{Time: t, Value: benchValue, RiskFreeValue: 1000, PercentReturn: rb})
+		}
+	})
+
+	It("should start once a full window of history is available", func() {
+		points := perf.RollingAlphaBeta(&bench, 36)
+		Expect(points).To(HaveLen(13))
+		Expect(time.Unix(points[0].Time, 0).UTC()).To(Equal(time.Date(2013, time.January, 1, 0, 0, 0, 0, time.UTC)))
+	})
+
+	It("should follow the change in market sensitivity", func() {
+		points := perf.RollingAlphaBeta(&bench, 12)
+		Expect(points[0].Beta).Should(BeNumerically("~", 2.0, 1e-9))
+		Expect(points[0].Alpha).Should(BeNumerically("~", 0.0, 1e-9))
+		Expect(points[len(points)-1].Beta).Should(BeNumerically("~", 1.0, 1e-9))
+	})
+
+	It("should be empty without enough history", func() {
+		Expect(perf.RollingAlphaBeta(&bench, 60)).To(BeEmpty())
+	})
+})
@@ -0,0 +1,159 @@
+package portfolio
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Kinds of change to a stored transaction
+const (
+	HistoryChanged = "changed"
+	HistoryAdded   = "added"
+	HistoryRemoved = "removed"
+)
+
+// historyTolerance relative difference below which recomputed values are
+// considered unchanged; larger differences come from restated prices,
+// dividends, or splits rather than floating point noise
+const historyTolerance = 1e-6
+
+// HistoryChange a stored transaction that differs when the portfolio is
+// recomputed. Old values are zero for added transactions and new values are
+// zero for removed ones.
+type HistoryChange struct {
+	Date   time.Time `json:"date"`
+	Ticker string    `json:"ticker"`
+	Kind   string    `json:"kind"`
+	Change string    `json:"change"`
+
+	OldShares        float64 `json:"oldShares"`
+	NewShares        float64 `json:"newShares"`
+	OldPricePerShare float64 `json:"oldPricePerShare"`
+	NewPricePerShare float64 `json:"newPricePerShare"`
+	OldTotalValue    float64 `json:"oldTotalValue"`
+	NewTotalValue    float64 `json:"newTotalValue"`
+}
+
+// HistoryRevision differences between the stored history of a portfolio and
+// its recomputed history, e.g. after the data provider restated dividends or
+// splits. Changes are ordered by date and ticker.
+type HistoryRevision struct {
+	Changes []HistoryChange `json:"changes"`
+
+	// Dates the dates that have changes
+	Dates []time.Time `json:"dates"`
+}
+
+// Empty true if the recomputed history matches the stored history
+func (r *HistoryRevision) Empty() bool {
+	return len(r.Changes) == 0
+}
+
+type historyKey struct {
+	date   int64
+	ticker string
+	kind   string
+}
+
+// DiffHistory compare the stored transactions of a portfolio with the
+// recomputed transactions through the last stored date. Transactions after
+// the last stored date are new history rather than revisions and are not
+// compared.
+func DiffHistory(stored []Transaction, recomputed []Transaction) HistoryRevision {
+	revision := HistoryRevision{
+		Changes: []HistoryChange{},
+		Dates:   []time.Time{},
+	}
+	if len(stored) == 0 {
+		return revision
+	}
+
+	last := stored[0].Date
+	old := make(map[historyKey]Transaction, len(stored))
+	for _, trx := range stored {
+		if trx.Date.After(last) {
+			last = trx.Date
+		}
+		old[historyKey{trx.Date.Unix(), trx.Ticker, trx.Kind}] = trx
+	}
+
+	for _, trx := range recomputed {
+		if trx.Kind == MarkerTransaction || trx.Date.After(last) {
+			continue
+		}
+
+		key := historyKey{trx.Date.Unix(), trx.Ticker, trx.Kind}
+		prev, ok := old[key]
+		if !ok {
+			revision.Changes = append(revision.Changes, HistoryChange{
+				Date:             trx.Date,
+				Ticker:           trx.Ticker,
+				Kind:             trx.Kind,
+				Change:           HistoryAdded,
+				NewShares:        trx.Shares,
+				NewPricePerShare: trx.PricePerShare,
+				NewTotalValue:    trx.TotalValue,
+			})
+			continue
+		}
+		delete(old, key)
+
+		if historyEqual(prev.Shares, trx.Shares) && historyEqual(prev.PricePerShare, trx.PricePerShare) && historyEqual(prev.TotalValue, trx.TotalValue) {
+			continue
+		}
+		revision.Changes = append(revision.Changes, HistoryChange{
+			Date:             trx.Date,
+			Ticker:           trx.Ticker,
+			Kind:             trx.Kind,
+			Change:           HistoryChanged,
+			OldShares:        prev.Shares,
+			NewShares:        trx.Shares,
+			OldPricePerShare: prev.PricePerShare,
+			NewPricePerShare: trx.PricePerShare,
+			OldTotalValue:    prev.TotalValue,
+			NewTotalValue:    trx.TotalValue,
+		})
+	}
+
+	for _, trx := range old {
+		revision.Changes = append(revision.Changes, HistoryChange{
+			Date:             trx.Date,
+			Ticker:           trx.Ticker,
+			Kind:             trx.Kind,
+			Change:           HistoryRemoved,
+			OldShares:        trx.Shares,
+			OldPricePerShare: trx.PricePerShare,
+			OldTotalValue:    trx.TotalValue,
+		})
+	}
+
+	sort.Slice(revision.Changes, func(i, j int) bool {
+		a, b := revision.Changes[i], revision.Changes[j]
+		if !a.Date.Equal(b.Date) {
+			return a.Date.Before(b.Date)
+		}
+		if a.Ticker != b.Ticker {
+			return a.Ticker < b.Ticker
+		}
+		return a.Kind < b.Kind
+	})
+
+	for _, change := range revision.Changes {
+		n := len(revision.Dates)
+		if n == 0 || !revision.Dates[n-1].Equal(change.Date) {
+			revision.Dates = append(revision.Dates, change.Date)
+		}
+	}
+
+	return revision
+}
+
+// historyEqual true if a and b differ by less than historyTolerance
+// relative to the larger of them
+func historyEqual(a float64, b float64) bool {
+	if a == b {
+		return true
+	}
+	return math.Abs(a-b) <= historyTolerance*math.Max(math.Abs(a), math.Abs(b))
+}
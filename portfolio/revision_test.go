@@ -0,0 +1,76 @@
+package portfolio_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"main/portfolio"
+)
+
+var _ = Describe("DiffHistory", func() {
+	var (
+		d1, d2, d3 time.Time
+		stored     []portfolio.Transaction
+		recomputed []portfolio.Transaction
+	)
+
+	BeforeEach(func() {
+		d1 = time.Date(2021, time.January, 29, 0, 0, 0, 0, time.UTC)
+		d2 = time.Date(2021, time.February, 26, 0, 0, 0, 0, time.UTC)
+		d3 = time.Date(2021, time.March, 31, 0, 0, 0, 0, time.UTC)
+		stored = []portfolio.Transaction{
+			{Date: d1, Ticker: "$CASH", Kind: portfolio.DepositTransaction, TotalValue: 10000},
+			{Date: d1, Ticker: "VFINX", Kind: portfolio.BuyTransaction, Shares: 30, PricePerShare: 330, TotalValue: 9900},
+			{Date: d2, Ticker: "VFINX", Kind: portfolio.SellTransaction, Shares: 30, PricePerShare: 340, TotalValue: 10200},
+		}
+		recomputed = []portfolio.Transaction{
+			{Date: d1, Ticker: "$CASH", Kind: portfolio.DepositTransaction, TotalValue: 10000},
+			{Date: d1, Kind: portfolio.MarkerTransaction},
+			{Date: d1, Ticker: "VFINX", Kind: portfolio.BuyTransaction, Shares: 30, PricePerShare: 330, TotalValue: 9900},
+			{Date: d2, Ticker: "VFINX", Kind: portfolio.SellTransaction, Shares: 30, PricePerShare: 340 * (1 + 1e-9), TotalValue: 10200},
+			{Date: d3, Ticker: "VUSTX", Kind: portfolio.BuyTransaction, Shares: 700, PricePerShare: 14.5, TotalValue: 10150},
+		}
+	})
+
+	It("should ignore new history and floating point noise", func() {
+		revision := portfolio.DiffHistory(stored, recomputed)
+		Expect(revision.Empty()).To(BeTrue())
+	})
+
+	It("should report nothing for a portfolio without stored history", func() {
+		revision := portfolio.DiffHistory(nil, recomputed)
+		Expect(revision.Empty()).To(BeTrue())
+	})
+
+	It("should report restated values", func() {
+		recomputed[3].PricePerShare = 335
+		recomputed[3].TotalValue = 10050
+
+		revision := portfolio.DiffHistory(stored, recomputed)
+		Expect(revision.Changes).To(HaveLen(1))
+		change := revision.Changes[0]
+		Expect(change.Date).To(Equal(d2))
+		Expect(change.Ticker).To(Equal("VFINX"))
+		Expect(change.Change).To(Equal(portfolio.HistoryChanged))
+		Expect(change.OldTotalValue).To(Equal(10200.0))
+		Expect(change.NewTotalValue).To(Equal(10050.0))
+		Expect(revision.Dates).To(Equal([]time.Time{d2}))
+	})
+
+	It("should report added and removed transactions", func() {
+		recomputed = append(recomputed, portfolio.Transaction{Date: d2, Ticker: "VFINX", Kind: portfolio.DividendTransaction, TotalValue: 12})
+		recomputed = append(recomputed[:2], recomputed[3:]...)
+
+		revision := portfolio.DiffHistory(stored, recomputed)
+		Expect(revision.Changes).To(HaveLen(2))
+		Expect(revision.Changes[0].Date).To(Equal(d1))
+		Expect(revision.Changes[0].Change).To(Equal(portfolio.HistoryRemoved))
+		Expect(revision.Changes[0].OldShares).To(Equal(30.0))
+		Expect(revision.Changes[1].Date).To(Equal(d2))
+		Expect(revision.Changes[1].Change).To(Equal(portfolio.HistoryAdded))
+		Expect(revision.Changes[1].NewTotalValue).To(Equal(12.0))
+		Expect(revision.Dates).To(Equal([]time.Time{d1, d2}))
+	})
+})
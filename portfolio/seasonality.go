@@ -0,0 +1,97 @@
+package portfolio
+
+import (
+	"main/data"
+	"time"
+)
+
+// SeasonalPeriod returns earned in a calendar month or on a day of the week
+type SeasonalPeriod struct {
+	Period        string  `json:"period"`
+	Observations  int     `json:"observations"`
+	AverageReturn float64 `json:"averageReturn"`
+	HitRate       float64 `json:"hitRate"`
+}
+
+// Seasonality average returns by calendar month and, for daily
+// measurements, by day of the week
+type Seasonality struct {
+	Months   []SeasonalPeriod `json:"months"`
+	Weekdays []SeasonalPeriod `json:"weekdays,omitempty"`
+}
+
+// seasonalAccumulator sums the returns of a seasonal period
+type seasonalAccumulator struct {
+	observations int
+	total        float64
+	positive     int
+}
+
+func (acc *seasonalAccumulator) add(ret float64) {
+	acc.observations++
+	acc.total += ret
+	if ret > 0 {
+		acc.positive++
+	}
+}
+
+func (acc *seasonalAccumulator) period(name string) SeasonalPeriod {
+	period := SeasonalPeriod{
+		Period:       name,
+		Observations: acc.observations,
+	}
+	if acc.observations > 0 {
+		period.AverageReturn = acc.total / float64(acc.observations)
+		period.HitRate = float64(acc.positive) / float64(acc.observations)
+	}
+	return period
+}
+
+// Seasonality average return and hit rate, the fraction of positive
+// returns, of each calendar month and, when measured daily, of each weekday.
+// Measurements within a month are compounded into the return of the month;
+// the first and last months may be partial.
+func (perf *Performance) Seasonality() Seasonality {
+	type yearMonth struct {
+		year  int
+		month time.Month
+	}
+
+	monthly := map[yearMonth]float64{}
+	var weekdays [7]seasonalAccumulator
+	for ii := 1; ii < len(perf.Measurements); ii++ {
+		m := perf.Measurements[ii]
+		date := time.Unix(m.Time, 0).UTC()
+
+		key := yearMonth{year: date.Year(), month: date.Month()}
+		growth, ok := monthly[key]
+		if !ok {
+			growth = 1.0
+		}
+		monthly[key] = growth * (1.0 + m.PercentReturn)
+
+		if perf.Resolution == data.FrequencyDaily {
+			weekdays[date.Weekday()].add(m.PercentReturn)
+		}
+	}
+
+	var months [12]seasonalAccumulator
+	for key, growth := range monthly {
+		months[key.month-1].add(growth - 1.0)
+	}
+
+	seasonality := Seasonality{
+		Months: make([]SeasonalPeriod, 0, 12),
+	}
+	for ii := range months {
+		seasonality.Months = append(seasonality.Months, months[ii].period(time.Month(ii+1).String()))
+	}
+
+	if perf.Resolution == data.FrequencyDaily {
+		for day := time.Monday; day <= time.Friday; day++ {
+			seasonality.Weekdays = append(seasonality.Weekdays, weekdays[day].period(day.String()))
+		}
+	}
+
+	return seasonality
+}
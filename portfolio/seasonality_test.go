@@ -0,0 +1,87 @@
+package portfolio_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"main/data"
+	"main/portfolio"
+)
+
+var _ = Describe("Seasonality", func() {
+	// measurements at the given times with the given returns
+	measure := func(resolution string, times []time.Time, rets []float64) portfolio.Performance {
+		perf := portfolio.Performance{Resolution: resolution}
+		value := 1000.0
+		for ii := range times {
+			value *= 1 + rets[ii]
+			perf.Measurements = append(perf.Measurements, portfolio.PerformanceMeasurement{Time: times[ii].Unix(), Value: value, PercentReturn: rets[ii]})
+		}
+		return perf
+	}
+
+	Describe("When measured monthly", func() {
+		It("should average each calendar month across years", func() {
+			times := []time.Time{}
+			rets := []float64{}
+			for ii := 0; ii <= 24; ii++ {
+				times = append(times, time.Date(2019, time.January+time.Month(ii), 28, 0, 0, 0, 0, time.UTC))
+				ret := 0.01
+				if ii == 0 {
+					ret = 0
+				} else if times[ii].Month() == time.September {
+					ret = -0.02 * float64(times[ii].Year()-2018)
+				}
+				rets = append(rets, ret)
+			}
+
+			perf := measure(data.FrequencyMonthly, times, rets)
+			seasonality := perf.Seasonality()
+			Expect(seasonality.Months).To(HaveLen(12))
+			Expect(seasonality.Weekdays).To(BeEmpty())
+
+			september := seasonality.Months[time.September-1]
+			Expect(september.Period).To(Equal("September"))
+			Expect(september.Observations).To(Equal(2))
+			Expect(september.AverageReturn).To(BeNumerically("~", -0.03, 1e-9))
+			Expect(september.HitRate).To(Equal(0.0))
+
+			january := seasonality.Months[time.January-1]
+			Expect(january.Observations).To(Equal(2))
+			Expect(january.HitRate).To(Equal(1.0))
+		})
+	})
+
+	Describe("When measured daily", func() {
+		It("should compound days into months and average each weekday", func() {
+			// Monday 2021-03-01 through Friday 2021-03-12
+			times := []time.Time{time.Date(2021, time.February, 26, 0, 0, 0, 0, time.UTC)}
+			rets := []float64{0}
+			for d := time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC); d.Day() <= 12; d = d.AddDate(0, 0, 1) {
+				if d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+					continue
+				}
+				times = append(times, d)
+				if d.Weekday() == time.Monday {
+					rets = append(rets, -0.01)
+				} else {
+					rets = append(rets, 0.01)
+				}
+			}
+
+			perf := measure(data.FrequencyDaily, times, rets)
+			seasonality := perf.Seasonality()
+			Expect(seasonality.Weekdays).To(HaveLen(5))
+			Expect(seasonality.Weekdays[0].Period).To(Equal("Monday"))
+			Expect(seasonality.Weekdays[0].Observations).To(Equal(2))
+			Expect(seasonality.Weekdays[0].AverageReturn).To(BeNumerically("~", -0.01, 1e-9))
+			Expect(seasonality.Weekdays[4].HitRate).To(Equal(1.0))
+
+			march := seasonality.Months[time.March-1]
+			Expect(march.Observations).To(Equal(1))
+			Expect(march.AverageReturn).To(BeNumerically("~", 0.99*0.99*1.01*1.01*1.01*1.01*1.01*1.01*1.01*1.01-1, 1e-9))
+		})
+	})
+})
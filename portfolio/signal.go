@@ -0,0 +1,11 @@
+package portfolio
+
+import "time"
+
+// Signal the allocation a strategy selected on a date, as fractions of the
+// portfolio keyed by ticker, and the scores that justified it
+type Signal struct {
+	Date          time.Time              `json:"date"`
+	Target        map[string]float64     `json:"target"`
+	Justification map[string]interface{} `json:"justification"`
+}
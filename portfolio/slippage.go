@@ -0,0 +1,80 @@
+package portfolio
+
+// SlippageMeasurement comparison of ideal and executed performance for a
+// single period
+type SlippageMeasurement struct {
+	Time               int64   `json:"time"`
+	IdealValue         float64 `json:"idealValue"`
+	ExecutedValue      float64 `json:"executedValue"`
+	IdealReturn        float64 `json:"idealReturn"`
+	ExecutedReturn     float64 `json:"executedReturn"`
+	Slippage           float64 `json:"slippage"`
+	CumulativeSlippage float64 `json:"cumulativeSlippage"`
+}
+
+// SlippageReport quantifies how much the as-executed portfolio deviated from
+// the ideal strategy portfolio
+type SlippageReport struct {
+	PeriodStart    int64                 `json:"periodStart"`
+	PeriodEnd      int64                 `json:"periodEnd"`
+	Measurements   []SlippageMeasurement `json:"measurements"`
+	IdealGrowth    float64               `json:"idealGrowth"`
+	ExecutedGrowth float64               `json:"executedGrowth"`
+	TotalSlippage  float64               `json:"totalSlippage"`
+	TotalFees      float64               `json:"totalFees"`
+}
+
+// Slippage compare the executed performance to the ideal performance. Only
+// periods present in both performance results are compared; the first common
+// period is used as the starting point for both.
+func Slippage(ideal *Performance, executed *Performance) SlippageReport {
+	report := SlippageReport{
+		Measurements: []SlippageMeasurement{},
+	}
+
+	for _, trx := range executed.Transactions {
+		report.TotalFees += trx.Fees
+	}
+
+	idealMap := make(map[int64]PerformanceMeasurement, len(ideal.Measurements))
+	for _, m := range ideal.Measurements {
+		idealMap[m.Time] = m
+	}
+
+	idealGrowth := 1.0
+	executedGrowth := 1.0
+	first := true
+	for _, em := range executed.Measurements {
+		im, ok := idealMap[em.Time]
+		if !ok {
+			continue
+		}
+
+		meas := SlippageMeasurement{
+			Time:          em.Time,
+			IdealValue:    im.Value,
+			ExecutedValue: em.Value,
+		}
+
+		if first {
+			first = false
+			report.PeriodStart = em.Time
+		} else {
+			meas.IdealReturn = im.PercentReturn
+			meas.ExecutedReturn = em.PercentReturn
+			meas.Slippage = em.PercentReturn - im.PercentReturn
+			idealGrowth *= 1 + im.PercentReturn
+			executedGrowth *= 1 + em.PercentReturn
+		}
+
+		meas.CumulativeSlippage = executedGrowth - idealGrowth
+		report.PeriodEnd = em.Time
+		report.Measurements = append(report.Measurements, meas)
+	}
+
+	report.IdealGrowth = idealGrowth - 1
+	report.ExecutedGrowth = executedGrowth - 1
+	report.TotalSlippage = executedGrowth - idealGrowth
+
+	return report
+}
@@ -0,0 +1,148 @@
+package portfolio_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"main/data"
+	"main/portfolio"
+)
+
+var _ = Describe("Slippage", func() {
+	var (
+		ideal    portfolio.Performance
+		executed portfolio.Performance
+	)
+
+	BeforeEach(func() {
+		ideal = portfolio.Performance{
+			Measurements: []portfolio.PerformanceMeasurement{
+				{Time: 100, Value: 10000},
+				{Time: 200, Value: 11000, PercentReturn: 0.10},
+				{Time: 300, Value: 12100, PercentReturn: 0.10},
+			},
+		}
+
+		executed = portfolio.Performance{
+			Measurements: []portfolio.PerformanceMeasurement{
+				{Time: 200, Value: 5000},
+				{Time: 300, Value: 5400, PercentReturn: 0.08},
+			},
+			Transactions: []portfolio.Transaction{
+				{Kind: portfolio.BuyTransaction, Fees: 4.95},
+				{Kind: portfolio.SellTransaction, Fees: 4.95},
+			},
+		}
+	})
+
+	Describe("When comparing executed to ideal performance", func() {
+		It("should only compare overlapping periods", func() {
+			report := portfolio.Slippage(&ideal, &executed)
+			Expect(report.Measurements).To(HaveLen(2))
+			Expect(report.PeriodStart).To(BeEquivalentTo(200))
+			Expect(report.PeriodEnd).To(BeEquivalentTo(300))
+		})
+
+		It("should compute per-period and total slippage", func() {
+			report := portfolio.Slippage(&ideal, &executed)
+			Expect(report.Measurements[1].Slippage).Should(BeNumerically("~", -0.02, 1e-9))
+			Expect(report.TotalSlippage).Should(BeNumerically("~", -0.02, 1e-9))
+			Expect(report.IdealGrowth).Should(BeNumerically("~", 0.10, 1e-9))
+			Expect(report.ExecutedGrowth).Should(BeNumerically("~", 0.08, 1e-9))
+		})
+
+		It("should total the fees paid", func() {
+			report := portfolio.Slippage(&ideal, &executed)
+			Expect(report.TotalFees).Should(BeNumerically("~", 9.90, 1e-9))
+		})
+	})
+
+	Describe("When creating a portfolio from executed transactions", func() {
+		It("should compute holdings including cash", func() {
+			d1 := time.Date(2021, time.January, 4, 0, 0, 0, 0, time.UTC)
+			d2 := time.Date(2021, time.February, 1, 0, 0, 0, 0, time.UTC)
+			p, err := portfolio.NewPortfolioFromTransactions("Executed", nil, []portfolio.Transaction{
+				{Date: d2, Ticker: "VFINX", Kind: portfolio.SellTransaction, Shares: 10, PricePerShare: 110, TotalValue: 1100},
+				{Date: d1, Ticker: "VFINX", Kind: portfolio.BuyTransaction, Shares: 20, PricePerShare: 100, TotalValue: 2000, Fees: 5},
+				{Date: d1, Ticker: "$CASH", Kind: portfolio.DepositTransaction, TotalValue: 2500},
+			})
+			Expect(err).To(BeNil())
+			Expect(p.StartTime).To(Equal(d1))
+			Expect(p.Transactions[0].Kind).To(Equal(portfolio.DepositTransaction))
+			Expect(p.Holdings["VFINX"]).Should(BeNumerically("~", 10, 1e-9))
+			Expect(p.Holdings["$CASH"]).Should(BeNumerically("~", 1595, 1e-9))
+		})
+	})
+
+	Describe("When executed fills match the close of a dividend paying security", func() {
+		var (
+			manager data.Manager
+			d1      time.Time
+			through time.Time
+		)
+
+		BeforeEach(func() {
+			// DIVT pays $2 on its Aug 5 ex-date; its adjusted close before the
+			// ex-date is reduced by the distribution
+			prices := `date,close,high,low,open,volume,adjClose,adjHigh,adjLow,adjOpen,adjVolume,divCash,splitFactor
+2020-08-03,100.0,100.0,100.0,100.0,0,98.0,98.0,98.0,98.0,0,0.0,1.0
+2020-08-04,100.0,100.0,100.0,100.0,0,98.0,98.0,98.0,98.0,0,0.0,1.0
+2020-08-05,98.0,98.0,98.0,98.0,0,98.0,98.0,98.0,98.0,0,2.0,1.0
+2020-08-06,98.0,98.0,98.0,98.0,0,98.0,98.0,98.0,98.0,0,0.0,1.0
+`
+			httpmock.RegisterResponder("GET", `=~^https://api\.tiingo\.com/tiingo/daily/DIVT/prices`,
+				httpmock.NewStringResponder(200, prices))
+
+			content, err := ioutil.ReadFile("testdata/riskfree.csv")
+			Expect(err).To(BeNil())
+			today := time.Now()
+			url := fmt.Sprintf("https://fred.stlouisfed.org/graph/fredgraph.csv?mode=fred&id=DTB3&cosd=1970-01-01&coed=%d-%02d-%02d&fq=Daily&fam=avg", today.Year(), today.Month(), today.Day())
+			httpmock.RegisterResponder("GET", url, httpmock.NewBytesResponder(200, content))
+			data.InitializeDataManager()
+
+			manager = data.NewManager(map[string]string{
+				"tiingo": "TEST",
+			})
+			d1 = time.Date(2020, time.August, 3, 0, 0, 0, 0, time.UTC)
+			through = time.Date(2020, time.August, 6, 0, 0, 0, 0, time.UTC)
+		})
+
+		It("should have no slippage", func() {
+			// the strategy buys at the adjusted close
+			ideal, err := portfolio.NewPortfolioFromTransactions("Ideal", &manager, []portfolio.Transaction{
+				{Date: d1, Ticker: "$CASH", Kind: portfolio.DepositTransaction, TotalValue: 1000},
+				{Date: d1, Ticker: "DIVT", Kind: portfolio.BuyTransaction, Shares: 1000.0 / 98.0, PricePerShare: 98, TotalValue: 1000},
+			})
+			Expect(err).To(BeNil())
+			ideal.Resolution = data.FrequencyDaily
+			idealPerf, err := ideal.CalculatePerformance(through)
+			Expect(err).To(BeNil())
+
+			// and the user at the close
+			executed, err := portfolio.NewPortfolioFromTransactions("Executed", &manager, []portfolio.Transaction{
+				{Date: d1, Ticker: "$CASH", Kind: portfolio.DepositTransaction, TotalValue: 1000},
+				{Date: d1, Ticker: "DIVT", Kind: portfolio.BuyTransaction, Shares: 10, PricePerShare: 100, TotalValue: 1000},
+			})
+			Expect(err).To(BeNil())
+			Expect(executed.ApplySplits()).To(Succeed())
+			Expect(executed.ApplyDividends()).To(Succeed())
+			Expect(executed.Holdings["$CASH"]).Should(BeNumerically("~", 20, 1e-9))
+
+			executed.Resolution = data.FrequencyDaily
+			executedPerf, err := executed.CalculatePerformance(through)
+			Expect(err).To(BeNil())
+
+			report := portfolio.Slippage(&idealPerf, &executedPerf)
+			Expect(report.Measurements).To(HaveLen(4))
+			Expect(report.TotalSlippage).Should(BeNumerically("~", 0, 1e-9))
+			for _, m := range report.Measurements {
+				Expect(m.Slippage).Should(BeNumerically("~", 0, 1e-9))
+			}
+		})
+	})
+})
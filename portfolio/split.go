@@ -0,0 +1,175 @@
+package portfolio
+
+import (
+	"errors"
+	"fmt"
+	"main/data"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+// Split a stock split; Factor is the number of new shares received for each
+// share held (e.g. 2 for a 2-for-1 split, 0.1 for a 1-for-10 reverse split)
+type Split struct {
+	Date   time.Time
+	Factor float64
+}
+
+// SplitsFromDataFrame extract the splits from a dataframe containing the
+// split factor of symbol for each trading day
+func SplitsFromDataFrame(df *dataframe.DataFrame, symbol string) ([]Split, error) {
+	splits := []Split{}
+	iterator := df.ValuesIterator(dataframe.ValuesOptions{InitialRow: 0, Step: 1, DontReadLock: false})
+	for {
+		row, vals, _ := iterator(dataframe.SeriesName)
+		if row == nil {
+			break
+		}
+
+		date, ok := vals[data.DateIdx].(time.Time)
+		if !ok {
+			return nil, errors.New("split factor dataframe is missing the date column")
+		}
+
+		factor, ok := vals[symbol].(float64)
+		if !ok {
+			return nil, fmt.Errorf("split factor dataframe is missing the %s column", symbol)
+		}
+
+		if math.IsNaN(factor) || factor <= 0 || math.Abs(factor-1.0) < 1.0e-9 {
+			continue
+		}
+
+		splits = append(splits, Split{
+			Date:   date,
+			Factor: factor,
+		})
+	}
+
+	return splits, nil
+}
+
+// InsertSplitTransactions replay the (sorted) transactions and insert a split
+// transaction for each split of a security that is held at the time of the
+// split. The Shares of a split transaction is the number of shares added
+// (negative for reverse splits). Existing split transactions are replaced.
+func InsertSplitTransactions(trxs []Transaction, splits map[string][]Split) []Transaction {
+	result := make([]Transaction, 0, len(trxs))
+	for _, trx := range trxs {
+		if trx.Kind != SplitTransaction {
+			result = append(result, trx)
+		}
+	}
+
+	type pendingSplit struct {
+		Ticker string
+		Split
+	}
+
+	pending := []pendingSplit{}
+	for ticker, tickerSplits := range splits {
+		for _, split := range tickerSplits {
+			pending = append(pending, pendingSplit{Ticker: ticker, Split: split})
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].Date.Before(pending[j].Date)
+	})
+
+	final := make([]Transaction, 0, len(result)+len(pending))
+	holdings := make(map[string]float64)
+	splitIdx := 0
+	emitSplits := func(before time.Time, inclusive bool) {
+		for ; splitIdx < len(pending); splitIdx++ {
+			split := pending[splitIdx]
+			if split.Date.After(before) || (!inclusive && split.Date.Equal(before)) {
+				return
+			}
+
+			held := holdings[split.Ticker]
+			if math.Abs(held) <= 1.0e-5 {
+				continue
+			}
+
+			trx := Transaction{
+				Date:   split.Date,
+				Ticker: split.Ticker,
+				Kind:   SplitTransaction,
+				Shares: held * (split.Factor - 1.0),
+				Justification: map[string]interface{}{
+					"splitFactor": split.Factor,
+				},
+			}
+			applyTransaction(holdings, &trx)
+			final = append(final, trx)
+		}
+	}
+
+	for _, trx := range result {
+		// splits take effect at the open so they are applied before any
+		// trades made on the same day
+		emitSplits(trx.Date, false)
+		applyTransaction(holdings, &trx)
+		final = append(final, trx)
+	}
+	emitSplits(time.Now(), true)
+
+	return final
+}
+
+// ApplySplits download the split history of every security held by the
+// portfolio and add split transactions adjusting the share counts. This is
+// needed for ledgers recorded in actual (unadjusted) shares, such as
+// executed transactions; the portfolio is then valued at the unadjusted
+// close.
+func (p *Portfolio) ApplySplits() error {
+	tickerSet := make(map[string]bool)
+	for _, trx := range p.Transactions {
+		if trx.Kind == BuyTransaction || trx.Kind == SellTransaction {
+			tickerSet[trx.Ticker] = true
+		}
+	}
+
+	if len(tickerSet) == 0 {
+		return nil
+	}
+
+	symbols := make([]string, 0, len(tickerSet))
+	for k := range tickerSet {
+		symbols = append(symbols, k)
+	}
+
+	origBegin, origEnd := p.dataProxy.Begin, p.dataProxy.End
+	origFrequency, origMetric := p.dataProxy.Frequency, p.dataProxy.Metric
+	defer func() {
+		p.dataProxy.Begin, p.dataProxy.End = origBegin, origEnd
+		p.dataProxy.Frequency, p.dataProxy.Metric = origFrequency, origMetric
+	}()
+
+	p.dataProxy.Begin = p.StartTime
+	p.dataProxy.End = time.Now()
+	p.dataProxy.Frequency = data.FrequencyDaily
+	p.dataProxy.Metric = data.MetricSplitFactor
+
+	factors, errs := p.dataProxy.GetMultipleData(symbols...)
+	if len(errs) > 0 {
+		return errors.New("Failed to download split data for tickers")
+	}
+
+	splits := make(map[string][]Split)
+	for symbol, df := range factors {
+		tickerSplits, err := SplitsFromDataFrame(df, symbol)
+		if err != nil {
+			return err
+		}
+		splits[symbol] = tickerSplits
+	}
+
+	p.Transactions = InsertSplitTransactions(p.Transactions, splits)
+	p.Holdings = LedgerHoldings(p.Transactions, time.Now())
+	p.ActualShares = true
+	return nil
+}
@@ -0,0 +1,123 @@
+package portfolio_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/rocketlaunchr/dataframe-go"
+
+	"main/data"
+	"main/portfolio"
+)
+
+var _ = Describe("Split", func() {
+	var (
+		trxs []portfolio.Transaction
+		d1   time.Time
+		d2   time.Time
+		d3   time.Time
+	)
+
+	BeforeEach(func() {
+		d1 = time.Date(2020, time.August, 3, 0, 0, 0, 0, time.UTC)
+		d2 = time.Date(2020, time.August, 31, 0, 0, 0, 0, time.UTC)
+		d3 = time.Date(2020, time.September, 15, 0, 0, 0, 0, time.UTC)
+		trxs = []portfolio.Transaction{
+			{Date: d1, Ticker: "$CASH", Kind: portfolio.DepositTransaction, TotalValue: 10000},
+			{Date: d1, Ticker: "AAPL", Kind: portfolio.BuyTransaction, Shares: 20, PricePerShare: 435, TotalValue: 8700},
+			{Date: d3, Ticker: "AAPL", Kind: portfolio.SellTransaction, Shares: 80, PricePerShare: 115, TotalValue: 9200},
+		}
+	})
+
+	Describe("When given split factors", func() {
+		It("should extract splits from a dataframe", func() {
+			dates := dataframe.NewSeriesTime(data.DateIdx, &dataframe.SeriesInit{Size: 3}, []time.Time{
+				time.Date(2020, time.August, 28, 0, 0, 0, 0, time.UTC),
+				d2,
+				time.Date(2020, time.September, 1, 0, 0, 0, 0, time.UTC),
+			})
+			factors := dataframe.NewSeriesFloat64("AAPL", &dataframe.SeriesInit{Size: 3}, []float64{1.0, 4.0, 1.0})
+			splits, err := portfolio.SplitsFromDataFrame(dataframe.NewDataFrame(dates, factors), "AAPL")
+			Expect(err).To(BeNil())
+			Expect(splits).To(HaveLen(1))
+			Expect(splits[0].Date).To(Equal(d2))
+			Expect(splits[0].Factor).To(Equal(4.0))
+		})
+
+		It("should insert split transactions for held securities", func() {
+			res := portfolio.InsertSplitTransactions(trxs, map[string][]portfolio.Split{
+				"AAPL":  {{Date: d2, Factor: 4.0}},
+				"VFINX": {{Date: d2, Factor: 2.0}},
+			})
+			Expect(res).To(HaveLen(4))
+			Expect(res[2].Kind).To(Equal(portfolio.SplitTransaction))
+			Expect(res[2].Ticker).To(Equal("AAPL"))
+			Expect(res[2].Shares).Should(BeNumerically("~", 60, 1e-9))
+
+			holdings := portfolio.LedgerHoldings(res, d3)
+			_, ok := holdings["AAPL"]
+			Expect(ok).To(BeFalse())
+			Expect(holdings["$CASH"]).Should(BeNumerically("~", 10500, 1e-9))
+		})
+
+		It("should not duplicate existing split transactions", func() {
+			splits := map[string][]portfolio.Split{
+				"AAPL": {{Date: d2, Factor: 4.0}},
+			}
+			res := portfolio.InsertSplitTransactions(trxs, splits)
+			res = portfolio.InsertSplitTransactions(res, splits)
+			Expect(res).To(HaveLen(4))
+		})
+	})
+
+	Describe("When valuing a ledger in actual shares", func() {
+		var manager data.Manager
+
+		BeforeEach(func() {
+			// SPLT splits 2:1 on Aug 5; its adjusted close is halved before
+			// the split and the close is not
+			prices := `date,close,high,low,open,volume,adjClose,adjHigh,adjLow,adjOpen,adjVolume,divCash,splitFactor
+2020-08-03,100.0,100.0,100.0,100.0,0,50.0,50.0,50.0,50.0,0,0.0,1.0
+2020-08-04,100.0,100.0,100.0,100.0,0,50.0,50.0,50.0,50.0,0,0.0,1.0
+2020-08-05,50.0,50.0,50.0,50.0,0,50.0,50.0,50.0,50.0,0,0.0,2.0
+2020-08-06,50.0,50.0,50.0,50.0,0,50.0,50.0,50.0,50.0,0,0.0,1.0
+`
+			httpmock.RegisterResponder("GET", `=~^https://api\.tiingo\.com/tiingo/daily/SPLT/prices`,
+				httpmock.NewStringResponder(200, prices))
+
+			content, err := ioutil.ReadFile("testdata/riskfree.csv")
+			Expect(err).To(BeNil())
+			today := time.Now()
+			url := fmt.Sprintf("https://fred.stlouisfed.org/graph/fredgraph.csv?mode=fred&id=DTB3&cosd=1970-01-01&coed=%d-%02d-%02d&fq=Daily&fam=avg", today.Year(), today.Month(), today.Day())
+			httpmock.RegisterResponder("GET", url, httpmock.NewBytesResponder(200, content))
+			data.InitializeDataManager()
+
+			manager = data.NewManager(map[string]string{
+				"tiingo": "TEST",
+			})
+		})
+
+		It("should not change in value across a split", func() {
+			d1 := time.Date(2020, time.August, 3, 0, 0, 0, 0, time.UTC)
+			p, err := portfolio.NewPortfolioFromTransactions("Executed", &manager, []portfolio.Transaction{
+				{Date: d1, Ticker: "$CASH", Kind: portfolio.DepositTransaction, TotalValue: 1000},
+				{Date: d1, Ticker: "SPLT", Kind: portfolio.BuyTransaction, Shares: 10, PricePerShare: 100, TotalValue: 1000},
+			})
+			Expect(err).To(BeNil())
+			Expect(p.ApplySplits()).To(Succeed())
+			Expect(p.Holdings["SPLT"]).Should(BeNumerically("~", 20, 1e-9))
+
+			p.Resolution = data.FrequencyDaily
+			perf, err := p.CalculatePerformance(time.Date(2020, time.August, 6, 0, 0, 0, 0, time.UTC))
+			Expect(err).To(BeNil())
+			Expect(perf.Measurements).To(HaveLen(4))
+			for _, m := range perf.Measurements {
+				Expect(m.Value).Should(BeNumerically("~", 1000, 1e-9))
+			}
+		})
+	})
+})
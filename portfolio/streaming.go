@@ -0,0 +1,186 @@
+package portfolio
+
+import (
+	"math"
+	"time"
+)
+
+// AnnualAggregate value of the portfolio at the start and end of a calendar
+// year
+type AnnualAggregate struct {
+	StartValue float64 `json:"startValue"`
+	EndValue   float64 `json:"endValue"`
+}
+
+// StreamingMetrics running statistics of a portfolio's measurements that can
+// be persisted and updated with only the measurements added since the last
+// update, avoiding a recomputation over the full history. Results match the
+// corresponding batch metrics of Performance.
+type StreamingMetrics struct {
+	Resolution string  `json:"resolution"`
+	LastTime   int64   `json:"lastTime"`
+	LastValue  float64 `json:"lastValue"`
+	Count      int     `json:"count"`
+
+	// running mean and sum of squared deviations (Welford's algorithm) of
+	// the period returns and of the returns in excess of the risk-free rate
+	Mean       float64 `json:"mean"`
+	M2         float64 `json:"m2"`
+	ExcessMean float64 `json:"excessMean"`
+	ExcessM2   float64 `json:"excessM2"`
+
+	// DownsideSumSq sum of squared negative excess returns
+	DownsideSumSq     float64 `json:"downsideSumSq"`
+	PrevRiskFreeValue float64 `json:"prevRiskFreeValue"`
+
+	Peak        float64 `json:"peak"`
+	MaxDrawDown float64 `json:"maxDrawDown"`
+
+	PositivePeriods int     `json:"positivePeriods"`
+	GainSum         float64 `json:"gainSum"`
+	LossSum         float64 `json:"lossSum"`
+	Gains           int     `json:"gains"`
+	Losses          int     `json:"losses"`
+
+	Annual map[int]*AnnualAggregate `json:"annual"`
+}
+
+// NewStreamingMetrics create an empty set of running statistics for
+// measurements taken at the given resolution
+func NewStreamingMetrics(resolution string) *StreamingMetrics {
+	return &StreamingMetrics{
+		Resolution: resolution,
+		Annual:     make(map[int]*AnnualAggregate),
+	}
+}
+
+// Update add a single measurement to the running statistics. Measurements at
+// or before the last measurement seen are ignored so updates are idempotent.
+func (s *StreamingMetrics) Update(m PerformanceMeasurement) {
+	if s.Count > 0 && m.Time <= s.LastTime {
+		return
+	}
+	if s.Annual == nil {
+		s.Annual = make(map[int]*AnnualAggregate)
+	}
+
+	if s.Count == 0 {
+		s.PrevRiskFreeValue = m.RiskFreeValue
+	}
+	riskFreeRate := m.RiskFreeValue/s.PrevRiskFreeValue - 1.0
+	s.PrevRiskFreeValue = m.RiskFreeValue
+	excess := m.PercentReturn - riskFreeRate
+
+	s.Count++
+	n := float64(s.Count)
+
+	delta := m.PercentReturn - s.Mean
+	s.Mean += delta / n
+	s.M2 += delta * (m.PercentReturn - s.Mean)
+
+	delta = excess - s.ExcessMean
+	s.ExcessMean += delta / n
+	s.ExcessM2 += delta * (excess - s.ExcessMean)
+
+	if excess < 0 {
+		s.DownsideSumSq += excess * excess
+	}
+
+	// draw downs
+	s.Peak = math.Max(s.Peak, m.Value)
+	if s.Peak > 0 {
+		s.MaxDrawDown = math.Min(s.MaxDrawDown, m.Value/s.Peak-1.0)
+	}
+
+	// gains and losses
+	if m.PercentReturn > 0 {
+		s.PositivePeriods++
+		s.GainSum += m.PercentReturn
+		s.Gains++
+	} else if m.PercentReturn < 0 {
+		s.LossSum += m.PercentReturn
+		s.Losses++
+	}
+
+	// a year starts at the value the previous year ended with
+	year := time.Unix(m.Time, 0).UTC().Year()
+	agg, ok := s.Annual[year]
+	if !ok {
+		agg = &AnnualAggregate{StartValue: s.LastValue}
+		if s.Count == 1 {
+			agg.StartValue = m.Value
+		}
+		s.Annual[year] = agg
+	}
+	agg.EndValue = m.Value
+
+	s.LastTime = m.Time
+	s.LastValue = m.Value
+}
+
+// UpdateFrom add the measurements of perf taken after the last update;
+// returns the number of measurements added
+func (s *StreamingMetrics) UpdateFrom(perf *Performance) int {
+	added := 0
+	for _, m := range perf.Measurements {
+		if s.Count > 0 && m.Time <= s.LastTime {
+			continue
+		}
+		s.Update(m)
+		added++
+	}
+	return added
+}
+
+// annualPeriods number of measurements per year at the resolution
+func (s *StreamingMetrics) annualPeriods() float64 {
+	perf := Performance{Resolution: s.Resolution}
+	return perf.annualPeriods()
+}
+
+// StdDev annualized population standard deviation of returns
+func (s *StreamingMetrics) StdDev() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return math.Sqrt(s.M2/float64(s.Count)) * math.Sqrt(s.annualPeriods())
+}
+
+// SharpeRatio annualized ratio of the mean excess return to its standard
+// deviation
+func (s *StreamingMetrics) SharpeRatio() float64 {
+	if s.Count < 2 || s.ExcessM2 == 0 {
+		return 0
+	}
+	stddev := math.Sqrt(s.ExcessM2 / float64(s.Count-1))
+	return s.ExcessMean / stddev * math.Sqrt(s.annualPeriods())
+}
+
+// SortinoRatio annualized ratio of the mean excess return to the downside
+// deviation
+func (s *StreamingMetrics) SortinoRatio() float64 {
+	if s.Count == 0 || s.DownsideSumSq == 0 {
+		return 0
+	}
+	downside := math.Sqrt(s.DownsideSumSq / float64(s.Count))
+	return s.ExcessMean / downside * math.Sqrt(s.annualPeriods())
+}
+
+// GainLossRatio average gain divided by the magnitude of the average loss
+func (s *StreamingMetrics) GainLossRatio() float64 {
+	if s.Gains == 0 || s.Losses == 0 {
+		return 0
+	}
+	return (s.GainSum / float64(s.Gains)) / math.Abs(s.LossSum/float64(s.Losses))
+}
+
+// AnnualReturns return of each calendar year
+func (s *StreamingMetrics) AnnualReturns() map[int]float64 {
+	rets := make(map[int]float64, len(s.Annual))
+	for year, agg := range s.Annual {
+		if agg.StartValue != 0 {
+			rets[year] = agg.EndValue/agg.StartValue - 1.0
+		}
+	}
+	return rets
+}
@@ -0,0 +1,75 @@
+package portfolio_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"main/portfolio"
+)
+
+var _ = Describe("StreamingMetrics", func() {
+	var (
+		perf portfolio.Performance
+	)
+
+	BeforeEach(func() {
+		jsonBlob, err := ioutil.ReadFile("testdata/adm-vfinx_pridx_vustx.json")
+		if err != nil {
+			panic(err)
+		}
+		err = json.Unmarshal(jsonBlob, &perf)
+		if err != nil {
+			panic(err)
+		}
+	})
+
+	Describe("When all measurements are streamed", func() {
+		It("should match the batch metrics", func() {
+			s := portfolio.NewStreamingMetrics(perf.Resolution)
+			Expect(s.UpdateFrom(&perf)).To(Equal(len(perf.Measurements)))
+
+			Expect(s.StdDev()).Should(BeNumerically("~", perf.StdDev(), 1e-9))
+			Expect(s.SharpeRatio()).Should(BeNumerically("~", perf.SharpeRatio(), 1e-9))
+			Expect(s.SortinoRatio()).Should(BeNumerically("~", perf.SortinoRatio(), 1e-9))
+			Expect(s.GainLossRatio()).Should(BeNumerically("~", perf.GainLossRatio(), 1e-9))
+			Expect(s.PositivePeriods).To(Equal(perf.NPositivePeriods()))
+			Expect(s.MaxDrawDown).Should(BeNumerically("~", perf.DrawDowns()[0].LossPercent, 1e-9))
+		})
+	})
+
+	Describe("When measurements are added incrementally", func() {
+		It("should match a single update over the full history", func() {
+			full := portfolio.NewStreamingMetrics(perf.Resolution)
+			full.UpdateFrom(&perf)
+
+			partial := perf
+			partial.Measurements = perf.Measurements[:200]
+			s := portfolio.NewStreamingMetrics(perf.Resolution)
+			Expect(s.UpdateFrom(&partial)).To(Equal(200))
+
+			// round-trip through JSON as when persisted between updates
+			state, err := json.Marshal(s)
+			Expect(err).NotTo(HaveOccurred())
+			restored := &portfolio.StreamingMetrics{}
+			Expect(json.Unmarshal(state, restored)).To(Succeed())
+
+			Expect(restored.UpdateFrom(&perf)).To(Equal(len(perf.Measurements) - 200))
+			Expect(restored.StdDev()).Should(BeNumerically("~", full.StdDev(), 1e-9))
+			Expect(restored.SharpeRatio()).Should(BeNumerically("~", full.SharpeRatio(), 1e-9))
+			Expect(restored.AnnualReturns()).To(HaveLen(len(full.AnnualReturns())))
+			for year, ret := range full.AnnualReturns() {
+				Expect(restored.AnnualReturns()[year]).Should(BeNumerically("~", ret, 1e-9))
+			}
+		})
+
+		It("should ignore measurements that were already added", func() {
+			s := portfolio.NewStreamingMetrics(perf.Resolution)
+			s.UpdateFrom(&perf)
+			Expect(s.UpdateFrom(&perf)).To(Equal(0))
+			Expect(s.Count).To(Equal(len(perf.Measurements)))
+		})
+	})
+})
@@ -0,0 +1,340 @@
+package portfolio
+
+import (
+	"main/data"
+	"math"
+	"time"
+
+	"github.com/rocketlaunchr/dataframe-go"
+	"gonum.org/v1/gonum/stat"
+)
+
+const (
+	// ScenarioHistorical replay of the portfolio through a historical window
+	ScenarioHistorical = "historical"
+
+	// ScenarioShock instantaneous hypothetical shock to equities and rates
+	ScenarioShock = "shock"
+)
+
+// Scenario a stress test applied to a portfolio
+type Scenario struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Kind        string    `json:"kind"`
+	Begin       time.Time `json:"-"`
+	End         time.Time `json:"-"`
+
+	// EquityShock fractional change in the price of equities, e.g. -0.30
+	EquityShock float64 `json:"equityShock,omitempty"`
+
+	// RateShock change in interest rates in percentage points, e.g. 2.0 for
+	// +200bps
+	RateShock float64 `json:"rateShock,omitempty"`
+}
+
+// Scenarios standard set of stress tests
+var Scenarios = []Scenario{
+	{
+		ID:          "gfc-2008",
+		Name:        "2008 Global Financial Crisis",
+		Description: "October 2007 market peak through the March 2009 bottom",
+		Kind:        ScenarioHistorical,
+		Begin:       time.Date(2007, time.October, 1, 0, 0, 0, 0, time.UTC),
+		End:         time.Date(2009, time.March, 31, 0, 0, 0, 0, time.UTC),
+	},
+	{
+		ID:          "covid-2020",
+		Name:        "2020 COVID Crash",
+		Description: "February 2020 market peak through the end of the second quarter",
+		Kind:        ScenarioHistorical,
+		Begin:       time.Date(2020, time.February, 1, 0, 0, 0, 0, time.UTC),
+		End:         time.Date(2020, time.June, 30, 0, 0, 0, 0, time.UTC),
+	},
+	{
+		ID:          "rate-shock-2022",
+		Name:        "2022 Rate Shock",
+		Description: "Simultaneous decline of stocks and bonds as the Federal Reserve raised rates in 2022",
+		Kind:        ScenarioHistorical,
+		Begin:       time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC),
+		End:         time.Date(2022, time.December, 31, 0, 0, 0, 0, time.UTC),
+	},
+	{
+		ID:          "equities-30",
+		Name:        "Equities -30%",
+		Description: "Instantaneous 30% decline in the US stock market",
+		Kind:        ScenarioShock,
+		EquityShock: -0.30,
+	},
+	{
+		ID:          "rates-200bps",
+		Name:        "Rates +200bps",
+		Description: "Instantaneous 2 percentage point rise in the 10-year treasury yield",
+		Kind:        ScenarioShock,
+		RateShock:   2.0,
+	},
+}
+
+// ScenarioResult outcome of a stress test
+type ScenarioResult struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+
+	// Available false if the portfolio has no measurements for the scenario
+	Available bool `json:"available"`
+
+	Begin       int64   `json:"begin,omitempty"`
+	End         int64   `json:"end,omitempty"`
+	TotalReturn float64 `json:"totalReturn"`
+	MaxDrawDown float64 `json:"maxDrawDown"`
+	Peak        int64   `json:"peak,omitempty"`
+	Trough      int64   `json:"trough,omitempty"`
+
+	// Recovery time the portfolio regained its prior peak; 0 if it has not
+	Recovery       int64   `json:"recovery,omitempty"`
+	Recovered      bool    `json:"recovered"`
+	RecoveryMonths float64 `json:"recoveryMonths"`
+
+	// sensitivities used to estimate hypothetical shocks
+	EquityBeta      float64 `json:"equityBeta,omitempty"`
+	RateSensitivity float64 `json:"rateSensitivity,omitempty"`
+}
+
+// StressFactors market factors aligned with the measurements of a
+// performance; used to estimate the portfolio's sensitivity to shocks
+type StressFactors struct {
+	// EquityReturns return of the equity market over each measurement period
+	EquityReturns []float64
+
+	// RateChanges change in interest rates (percentage points) over each
+	// measurement period
+	RateChanges []float64
+}
+
+// monthsBetween number of months between two unix times
+func monthsBetween(begin, end int64) float64 {
+	return float64(end-begin) / (86400.0 * 365.25 / 12.0)
+}
+
+// HistoricalStress replay the portfolio through the scenario's window and
+// measure its draw down. Recovery from the draw down is searched for in the
+// measurements after the window.
+func (perf *Performance) HistoricalStress(scenario Scenario) ScenarioResult {
+	result := ScenarioResult{
+		ID:   scenario.ID,
+		Name: scenario.Name,
+		Kind: scenario.Kind,
+	}
+
+	begin := scenario.Begin.Unix()
+	end := scenario.End.Unix()
+	if len(perf.Measurements) == 0 || perf.Measurements[0].Time > begin || perf.Measurements[len(perf.Measurements)-1].Time < end {
+		return result
+	}
+
+	// the window starts at the last measurement on or before its beginning
+	first := 0
+	for ii, m := range perf.Measurements {
+		if m.Time > begin {
+			break
+		}
+		first = ii
+	}
+
+	// index the growth of the portfolio so deposits and withdrawals do not
+	// look like gains or losses
+	result.Available = true
+	result.Begin = perf.Measurements[first].Time
+	growth := 1.0
+	peak := growth
+	peakTime := result.Begin
+	for _, m := range perf.Measurements[first+1:] {
+		if m.Time > end {
+			break
+		}
+
+		growth *= 1.0 + m.PercentReturn
+		result.End = m.Time
+		result.TotalReturn = growth - 1.0
+		if growth > peak {
+			peak = growth
+			peakTime = m.Time
+		}
+		if dd := growth/peak - 1.0; dd < result.MaxDrawDown {
+			result.MaxDrawDown = dd
+			result.Peak = peakTime
+			result.Trough = m.Time
+		}
+	}
+
+	result.Recovered, result.Recovery = perf.recoveryAfter(result.Peak, result.Trough)
+	if result.Recovered {
+		result.RecoveryMonths = monthsBetween(result.Trough, result.Recovery)
+	}
+
+	return result
+}
+
+// recoveryAfter time the growth of the portfolio first regained its value at
+// peak after trough
+func (perf *Performance) recoveryAfter(peak, trough int64) (bool, int64) {
+	if peak == 0 || trough == 0 {
+		return false, 0
+	}
+
+	growth := 1.0
+	var peakGrowth float64
+	for ii, m := range perf.Measurements {
+		if ii > 0 {
+			growth *= 1.0 + m.PercentReturn
+		}
+		if m.Time == peak {
+			peakGrowth = growth
+		}
+		if m.Time > trough && peakGrowth > 0 && growth >= peakGrowth {
+			return true, m.Time
+		}
+	}
+	return false, 0
+}
+
+// ShockStress estimate the immediate loss of the portfolio from a
+// hypothetical shock using its historical sensitivity to equity returns and
+// interest rate changes. The recovery time assumes the portfolio continues
+// to earn its average historical return.
+func (perf *Performance) ShockStress(scenario Scenario, factors StressFactors) ScenarioResult {
+	result := ScenarioResult{
+		ID:   scenario.ID,
+		Name: scenario.Name,
+		Kind: scenario.Kind,
+	}
+
+	n := len(perf.Measurements)
+	if n < 3 || len(factors.EquityReturns) != n || len(factors.RateChanges) != n {
+		return result
+	}
+
+	// the first measurement does not have a return
+	rets := make([]float64, n-1)
+	for ii, m := range perf.Measurements[1:] {
+		rets[ii] = m.PercentReturn
+	}
+	equity := factors.EquityReturns[1:]
+	rates := factors.RateChanges[1:]
+
+	// ordinary least squares with two regressors
+	meanY := stat.Mean(rets, nil)
+	meanE := stat.Mean(equity, nil)
+	meanR := stat.Mean(rates, nil)
+	var see, srr, ser, sey, sry float64
+	for ii := range rets {
+		e := equity[ii] - meanE
+		r := rates[ii] - meanR
+		y := rets[ii] - meanY
+		see += e * e
+		srr += r * r
+		ser += e * r
+		sey += e * y
+		sry += r * y
+	}
+
+	det := see*srr - ser*ser
+	switch {
+	case det != 0:
+		result.EquityBeta = (srr*sey - ser*sry) / det
+		result.RateSensitivity = (see*sry - ser*sey) / det
+	case see != 0:
+		result.EquityBeta = sey / see
+	case srr != 0:
+		result.RateSensitivity = sry / srr
+	}
+
+	result.Available = true
+	result.TotalReturn = math.Max(-1.0, result.EquityBeta*scenario.EquityShock+result.RateSensitivity*scenario.RateShock)
+	result.MaxDrawDown = math.Min(0, result.TotalReturn)
+
+	// months to recover at the average historical return
+	if result.MaxDrawDown < 0 && result.MaxDrawDown > -1.0 && meanY > 0 {
+		periods := math.Log(1.0/(1.0+result.MaxDrawDown)) / math.Log(1.0+meanY)
+		result.RecoveryMonths = periods * 12.0 / perf.annualPeriods()
+	}
+
+	return result
+}
+
+// StressTest run the standard scenarios against the portfolio
+func (perf *Performance) StressTest(factors StressFactors) []ScenarioResult {
+	results := make([]ScenarioResult, 0, len(Scenarios))
+	for _, scenario := range Scenarios {
+		switch scenario.Kind {
+		case ScenarioHistorical:
+			results = append(results, perf.HistoricalStress(scenario))
+		case ScenarioShock:
+			results = append(results, perf.ShockStress(scenario, factors))
+		}
+	}
+	return results
+}
+
+// NewStressFactors align equity prices and interest rates with the
+// measurements of perf. Equity prices are read from the equityColumn of
+// equity and rates from the rateColumn of rates; the last value on or
+// before each measurement is used.
+func NewStressFactors(perf *Performance, equity *dataframe.DataFrame, equityColumn string, rates *dataframe.DataFrame, rateColumn string) StressFactors {
+	prices := valuesAsOf(perf, equity, equityColumn)
+	levels := valuesAsOf(perf, rates, rateColumn)
+
+	factors := StressFactors{
+		EquityReturns: make([]float64, len(perf.Measurements)),
+		RateChanges:   make([]float64, len(perf.Measurements)),
+	}
+	for ii := 1; ii < len(perf.Measurements); ii++ {
+		if prices[ii-1] > 0 && prices[ii] > 0 {
+			factors.EquityReturns[ii] = prices[ii]/prices[ii-1] - 1.0
+		}
+		if !math.IsNaN(levels[ii-1]) && !math.IsNaN(levels[ii]) {
+			factors.RateChanges[ii] = levels[ii] - levels[ii-1]
+		}
+	}
+	return factors
+}
+
+// valuesAsOf last non-NaN value of column on or before each measurement
+func valuesAsOf(perf *Performance, df *dataframe.DataFrame, column string) []float64 {
+	values := make([]float64, len(perf.Measurements))
+	for ii := range values {
+		values[ii] = math.NaN()
+	}
+	if df == nil {
+		return values
+	}
+
+	iterator := df.ValuesIterator(dataframe.ValuesOptions{InitialRow: 0, Step: 1, DontReadLock: true})
+	idx := 0
+	last := math.NaN()
+	for {
+		row, vals, _ := iterator(dataframe.SeriesName)
+		if row == nil {
+			break
+		}
+
+		date, ok := vals[data.DateIdx].(time.Time)
+		if !ok {
+			continue
+		}
+		for idx < len(values) && perf.Measurements[idx].Time < date.Unix() {
+			values[idx] = last
+			idx++
+		}
+		if val, ok := vals[column].(float64); ok && !math.IsNaN(val) {
+			last = val
+		}
+	}
+	for ; idx < len(values); idx++ {
+		values[idx] = last
+	}
+
+	return values
+}
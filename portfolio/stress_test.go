@@ -0,0 +1,93 @@
+package portfolio_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"main/portfolio"
+)
+
+var _ = Describe("Stress", func() {
+	var (
+		perf portfolio.Performance
+	)
+
+	monthEnd := func(year int, month time.Month) int64 {
+		return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Unix()
+	}
+
+	BeforeEach(func() {
+		rets := []float64{0, 0.05, -0.10, -0.20, 0.10, 0.10, 0.10, 0.05}
+		months := []time.Month{time.January, time.February, time.March, time.April, time.May, time.June, time.July, time.August}
+		value := 1000.0
+		perf = portfolio.Performance{}
+		for ii, ret := range rets {
+			value *= 1.0 + ret
+			perf.Measurements = append(perf.Measurements, portfolio.PerformanceMeasurement{
+				Time:          monthEnd(2020, months[ii]),
+				Value:         value,
+				RiskFreeValue: 1000,
+				PercentReturn: ret,
+			})
+		}
+	})
+
+	Describe("When replaying a historical window", func() {
+		It("should measure the draw down and recovery", func() {
+			scenario := portfolio.Scenario{
+				ID:    "test",
+				Kind:  portfolio.ScenarioHistorical,
+				Begin: time.Date(2020, time.February, 1, 0, 0, 0, 0, time.UTC),
+				End:   time.Date(2020, time.April, 30, 0, 0, 0, 0, time.UTC),
+			}
+
+			result := perf.HistoricalStress(scenario)
+			Expect(result.Available).To(BeTrue())
+			Expect(result.Begin).To(Equal(monthEnd(2020, time.January)))
+			Expect(result.TotalReturn).Should(BeNumerically("~", 1.05*0.9*0.8-1.0, 1e-9))
+			Expect(result.MaxDrawDown).Should(BeNumerically("~", 0.9*0.8-1.0, 1e-9))
+			Expect(result.Peak).To(Equal(monthEnd(2020, time.February)))
+			Expect(result.Trough).To(Equal(monthEnd(2020, time.April)))
+
+			// 0.72 * 1.1^4 = 1.054 is the first value above the peak
+			Expect(result.Recovered).To(BeTrue())
+			Expect(result.Recovery).To(Equal(monthEnd(2020, time.August)))
+		})
+
+		It("should not be available if the portfolio did not exist", func() {
+			scenario := portfolio.Scenario{
+				Kind:  portfolio.ScenarioHistorical,
+				Begin: time.Date(2008, time.January, 1, 0, 0, 0, 0, time.UTC),
+				End:   time.Date(2008, time.December, 31, 0, 0, 0, 0, time.UTC),
+			}
+			Expect(perf.HistoricalStress(scenario).Available).To(BeFalse())
+		})
+	})
+
+	Describe("When applying a hypothetical shock", func() {
+		It("should estimate the loss from the equity beta", func() {
+			equity := []float64{0}
+			rates := []float64{0, 0.1, -0.2, 0.1, 0.0, 0.3, -0.1, 0.2}
+			for _, m := range perf.Measurements[1:] {
+				equity = append(equity, m.PercentReturn/2.0)
+			}
+
+			scenario := portfolio.Scenario{
+				Kind:        portfolio.ScenarioShock,
+				EquityShock: -0.30,
+			}
+			result := perf.ShockStress(scenario, portfolio.StressFactors{
+				EquityReturns: equity,
+				RateChanges:   rates,
+			})
+
+			Expect(result.Available).To(BeTrue())
+			Expect(result.EquityBeta).Should(BeNumerically("~", 2.0, 1e-9))
+			Expect(result.RateSensitivity).Should(BeNumerically("~", 0.0, 1e-9))
+			Expect(result.MaxDrawDown).Should(BeNumerically("~", -0.60, 1e-9))
+			Expect(result.RecoveryMonths).Should(BeNumerically(">", 0))
+		})
+	})
+})
@@ -0,0 +1,182 @@
+package portfolio
+
+import (
+	"main/data"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Fund a mutual fund or ETF and its annual expense ratio in percent
+type Fund struct {
+	Ticker       string  `json:"ticker"`
+	ExpenseRatio float64 `json:"expenseRatio"`
+}
+
+// Substitution a cheaper share class or ETF that tracks the same index as a
+// fund
+type Substitution struct {
+	From Fund `json:"from"`
+	To   Fund `json:"to"`
+}
+
+// Substitutions built-in mapping of funds to cheaper equivalents, keyed by
+// the ticker of the fund that is replaced
+var Substitutions = map[string]Substitution{
+	"VFINX": {From: Fund{"VFINX", 0.14}, To: Fund{"VOO", 0.03}},
+	"VTSMX": {From: Fund{"VTSMX", 0.14}, To: Fund{"VTI", 0.03}},
+	"VIMSX": {From: Fund{"VIMSX", 0.17}, To: Fund{"VO", 0.04}},
+	"NAESX": {From: Fund{"NAESX", 0.17}, To: Fund{"VB", 0.05}},
+	"VGTSX": {From: Fund{"VGTSX", 0.17}, To: Fund{"VXUS", 0.07}},
+	"VEIEX": {From: Fund{"VEIEX", 0.29}, To: Fund{"VWO", 0.08}},
+	"VGSIX": {From: Fund{"VGSIX", 0.25}, To: Fund{"VNQ", 0.12}},
+	"VBMFX": {From: Fund{"VBMFX", 0.15}, To: Fund{"BND", 0.03}},
+	"VUSTX": {From: Fund{"VUSTX", 0.20}, To: Fund{"VGLT", 0.04}},
+	"VFITX": {From: Fund{"VFITX", 0.20}, To: Fund{"VGIT", 0.04}},
+	"VFISX": {From: Fund{"VFISX", 0.20}, To: Fund{"VGSH", 0.04}},
+}
+
+// ReplacementTickers map of each fund in Substitutions to the ticker of its
+// cheaper equivalent
+func ReplacementTickers() map[string]string {
+	tickers := make(map[string]string, len(Substitutions))
+	for ticker, s := range Substitutions {
+		tickers[ticker] = s.To.Ticker
+	}
+	return tickers
+}
+
+// ETFProxies ETF equivalents of the mutual funds among tickers that have one
+// in Substitutions, sorted by the ticker of the fund. Users whose broker
+// does not offer a fund can hold the ETF instead.
+func ETFProxies(tickers []string) []Substitution {
+	seen := map[string]bool{}
+	proxies := []Substitution{}
+	for _, ticker := range tickers {
+		ticker = strings.ToUpper(ticker)
+		if seen[ticker] || data.AssetType(ticker) != data.AssetTypeMutualFund {
+			continue
+		}
+		seen[ticker] = true
+
+		s, ok := Substitutions[ticker]
+		if !ok || data.AssetType(s.To.Ticker) != data.AssetTypeStock {
+			continue
+		}
+		proxies = append(proxies, s)
+	}
+
+	sort.Slice(proxies, func(i, j int) bool {
+		return proxies[i].From.Ticker < proxies[j].From.Ticker
+	})
+	return proxies
+}
+
+// ProxyHistory map of the ticker each substitution is replaced with to the
+// ticker it replaces, for data.Manager.Proxies, so the replacement's
+// history is extended with that of the fund from before it was listed
+func ProxyHistory(substitutions []Substitution) map[string]string {
+	proxies := make(map[string]string, len(substitutions))
+	for _, s := range substitutions {
+		proxies[s.To.Ticker] = s.From.Ticker
+	}
+	return proxies
+}
+
+// ExpenseRatios annual expense ratio in percent of each fund in
+// Substitutions and of their equivalents
+func ExpenseRatios() map[string]float64 {
+	ratios := make(map[string]float64, 2*len(Substitutions))
+	for _, s := range Substitutions {
+		ratios[s.From.Ticker] = s.From.ExpenseRatio
+		ratios[s.To.Ticker] = s.To.ExpenseRatio
+	}
+	return ratios
+}
+
+// ExpenseDrag cost of the funds held by a portfolio
+type ExpenseDrag struct {
+	// Value of the portfolio at the end of the period
+	Value float64 `json:"value"`
+	Cagr  float64 `json:"cagr"`
+
+	// ExpenseRatio time weighted average expense ratio of the portfolio in
+	// percent
+	ExpenseRatio float64 `json:"expenseRatio"`
+
+	// Expenses estimated fund expenses paid over the period
+	Expenses float64 `json:"expenses"`
+}
+
+// SubstitutionReport difference between a portfolio and the same portfolio
+// holding cheaper share classes or ETF equivalents of its funds
+type SubstitutionReport struct {
+	PeriodStart   int64          `json:"periodStart"`
+	PeriodEnd     int64          `json:"periodEnd"`
+	Substitutions []Substitution `json:"substitutions"`
+	Original      ExpenseDrag    `json:"original"`
+	Substituted   ExpenseDrag    `json:"substituted"`
+
+	// FeeDrag value of the substituted portfolio less the value of the
+	// original at the end of the period
+	FeeDrag float64 `json:"feeDrag"`
+
+	// CagrDifference CAGR of the substituted portfolio less the CAGR of the
+	// original
+	CagrDifference float64 `json:"cagrDifference"`
+}
+
+// NewExpenseDrag estimate the expenses of the funds held by a portfolio from
+// its performance and allocation history. Expenses accrue between
+// measurements on the value at the start of each interval using the
+// allocation in effect at that time. Funds without a known expense ratio
+// are treated as free.
+func NewExpenseDrag(perf *Performance, allocations []Allocation, ratios map[string]float64) ExpenseDrag {
+	drag := ExpenseDrag{Cagr: perf.CagrSinceInception}
+	if len(perf.Measurements) == 0 {
+		return drag
+	}
+	drag.Value = perf.Measurements[len(perf.Measurements)-1].Value
+
+	var weightedRatio float64
+	var totalYears float64
+	next := 0
+	ratio := 0.0
+	for ii := 0; ii < len(perf.Measurements)-1; ii++ {
+		start := time.Unix(perf.Measurements[ii].Time, 0).UTC()
+		end := time.Unix(perf.Measurements[ii+1].Time, 0).UTC()
+
+		for next < len(allocations) && !allocations[next].Date.After(start) {
+			ratio = 0
+			for ticker, weight := range allocations[next].Weights {
+				ratio += weight * ratios[ticker]
+			}
+			next++
+		}
+
+		years := end.Sub(start).Hours() / (24 * 365.25)
+		drag.Expenses += perf.Measurements[ii].Value * ratio / 100.0 * years
+		weightedRatio += ratio * years
+		totalYears += years
+	}
+
+	if totalYears > 0 {
+		drag.ExpenseRatio = weightedRatio / totalYears
+	}
+
+	return drag
+}
+
+// NewSubstitutionReport compare the expense drag of a portfolio to the same
+// portfolio after substitutions were applied
+func NewSubstitutionReport(substitutions []Substitution, original ExpenseDrag, substituted ExpenseDrag, begin time.Time, end time.Time) SubstitutionReport {
+	return SubstitutionReport{
+		PeriodStart:    begin.Unix(),
+		PeriodEnd:      end.Unix(),
+		Substitutions:  substitutions,
+		Original:       original,
+		Substituted:    substituted,
+		FeeDrag:        substituted.Value - original.Value,
+		CagrDifference: substituted.Cagr - original.Cagr,
+	}
+}
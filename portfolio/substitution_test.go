@@ -0,0 +1,92 @@
+package portfolio_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"main/portfolio"
+)
+
+var _ = Describe("Substitution", func() {
+	var (
+		perf        portfolio.Performance
+		allocations []portfolio.Allocation
+		d1          time.Time
+		d2          time.Time
+		d3          time.Time
+	)
+
+	BeforeEach(func() {
+		d1 = time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)
+		d2 = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+		d3 = time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+		perf = portfolio.Performance{
+			CagrSinceInception: 0.05,
+			Measurements: []portfolio.PerformanceMeasurement{
+				{Time: d1.Unix(), Value: 10000},
+				{Time: d2.Unix(), Value: 11000},
+				{Time: d3.Unix(), Value: 12000},
+			},
+		}
+
+		allocations = []portfolio.Allocation{
+			{Date: d1, Weights: map[string]float64{"VFINX": 1.0}},
+			{Date: d2, Weights: map[string]float64{"VFINX": 0.5, "VUSTX": 0.5}},
+		}
+	})
+
+	Describe("When mapping funds to equivalents", func() {
+		It("should replace funds with cheaper equivalents", func() {
+			tickers := portfolio.ReplacementTickers()
+			Expect(tickers["VFINX"]).To(Equal("VOO"))
+			Expect(tickers["VUSTX"]).To(Equal("VGLT"))
+
+			ratios := portfolio.ExpenseRatios()
+			for from, to := range tickers {
+				Expect(ratios[to]).Should(BeNumerically("<", ratios[from]))
+			}
+		})
+
+		It("should suggest ETF proxies of mutual funds", func() {
+			proxies := portfolio.ETFProxies([]string{"vustx", "SPY", "VFINX", "VFINX", "PRIDX"})
+			Expect(proxies).To(HaveLen(2))
+			Expect(proxies[0].From.Ticker).To(Equal("VFINX"))
+			Expect(proxies[0].To.Ticker).To(Equal("VOO"))
+			Expect(proxies[1].From.Ticker).To(Equal("VUSTX"))
+
+			history := portfolio.ProxyHistory(proxies)
+			Expect(history).To(Equal(map[string]string{"VOO": "VFINX", "VGLT": "VUSTX"}))
+		})
+	})
+
+	Describe("When estimating expense drag", func() {
+		It("should weight expense ratios by allocation and time", func() {
+			drag := portfolio.NewExpenseDrag(&perf, allocations, portfolio.ExpenseRatios())
+			Expect(drag.Value).Should(BeNumerically("~", 12000, 1e-9))
+			Expect(drag.Cagr).Should(BeNumerically("~", 0.05, 1e-9))
+
+			year1 := 365.0 / 365.25
+			year2 := 366.0 / 365.25
+			Expect(drag.Expenses).Should(BeNumerically("~", 10000*0.0014*year1+11000*0.0017*year2, 1e-6))
+			Expect(drag.ExpenseRatio).Should(BeNumerically("~", (0.14*year1+0.17*year2)/(year1+year2), 1e-9))
+		})
+
+		It("should treat unknown funds as free", func() {
+			allocations[0].Weights = map[string]float64{"PRIDX": 1.0}
+			drag := portfolio.NewExpenseDrag(&perf, allocations[:1], portfolio.ExpenseRatios())
+			Expect(drag.Expenses).To(BeZero())
+		})
+
+		It("should report the difference between portfolios", func() {
+			original := portfolio.ExpenseDrag{Value: 12000, Cagr: 0.05}
+			substituted := portfolio.ExpenseDrag{Value: 12050, Cagr: 0.052}
+			report := portfolio.NewSubstitutionReport(nil, original, substituted, d1, d3)
+			Expect(report.FeeDrag).Should(BeNumerically("~", 50, 1e-9))
+			Expect(report.CagrDifference).Should(BeNumerically("~", 0.002, 1e-9))
+			Expect(report.PeriodStart).To(Equal(d1.Unix()))
+		})
+	})
+})
@@ -0,0 +1,274 @@
+package portfolio
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+const (
+	// AccountTaxable a brokerage account where realized gains and dividends
+	// are taxed each year
+	AccountTaxable = "taxable"
+
+	// AccountIRA an individual retirement account; taxes are deferred
+	AccountIRA = "ira"
+
+	// Account401k an employer sponsored retirement account; taxes are deferred
+	Account401k = "401k"
+)
+
+// TaxRates tax rates (in percent) applied to returns of a taxable account
+type TaxRates struct {
+	ShortTermCapitalGains float64 `json:"shortTermCapitalGains"`
+	LongTermCapitalGains  float64 `json:"longTermCapitalGains"`
+	Dividends             float64 `json:"dividends"`
+
+	// LongTermHoldingDays days a position must be held for its gains to be
+	// long-term; positions held longer than a year qualify if 0
+	LongTermHoldingDays int `json:"longTermHoldingDays,omitempty"`
+}
+
+// DefaultTaxRates rates used when none are configured
+var DefaultTaxRates = TaxRates{
+	ShortTermCapitalGains: 24.0,
+	LongTermCapitalGains:  15.0,
+	Dividends:             15.0,
+}
+
+// ValidAccountType returns true if accountType is a known account type
+func ValidAccountType(accountType string) bool {
+	switch accountType {
+	case AccountTaxable, AccountIRA, Account401k:
+		return true
+	}
+	return false
+}
+
+// taxLot shares acquired (or sold short if negative) at the same time and price
+type taxLot struct {
+	Date   time.Time
+	Shares float64
+	Price  float64
+}
+
+// realizedGains capital gains realized by a single transaction
+type realizedGains struct {
+	ShortTerm float64
+	LongTerm  float64
+}
+
+// taxLedger tracks cost basis of each security using first-in first-out
+// accounting
+type taxLedger struct {
+	lots                map[string][]taxLot
+	longTermHoldingDays int
+}
+
+func newTaxLedger(rates TaxRates) *taxLedger {
+	return &taxLedger{
+		lots:                make(map[string][]taxLot),
+		longTermHoldingDays: rates.LongTermHoldingDays,
+	}
+}
+
+// isLongTerm returns true if a position opened on acquired and closed on
+// closed qualifies for long-term capital gains treatment
+func (l *taxLedger) isLongTerm(acquired time.Time, closed time.Time) bool {
+	if l.longTermHoldingDays > 0 {
+		return closed.After(acquired.AddDate(0, 0, l.longTermHoldingDays))
+	}
+	return closed.After(acquired.AddDate(1, 0, 0))
+}
+
+// apply update the lots with trx and return the gains it realized
+func (l *taxLedger) apply(trx *Transaction) realizedGains {
+	gains := realizedGains{}
+	switch trx.Kind {
+	case BuyTransaction, SellTransaction:
+		if trx.Shares <= 0 {
+			return gains
+		}
+
+		// cost basis includes fees; proceeds are net of fees
+		direction := 1.0
+		price := (trx.TotalValue + trx.Fees) / trx.Shares
+		if trx.Kind == SellTransaction {
+			direction = -1.0
+			price = (trx.TotalValue - trx.Fees) / trx.Shares
+		}
+
+		remaining := trx.Shares
+		lots := l.lots[trx.Ticker]
+
+		// close lots held in the opposite direction
+		for len(lots) > 0 && remaining > 1.0e-9 && lots[0].Shares*direction < 0 {
+			lot := &lots[0]
+			qty := math.Min(remaining, math.Abs(lot.Shares))
+
+			// long positions gain when sold above cost, shorts gain when
+			// covered below the short sale price
+			gain := (price - lot.Price) * qty * -direction
+			if direction > 0 || !l.isLongTerm(lot.Date, trx.Date) {
+				gains.ShortTerm += gain
+			} else {
+				gains.LongTerm += gain
+			}
+
+			lot.Shares += qty * direction
+			remaining -= qty
+			if math.Abs(lot.Shares) <= 1.0e-9 {
+				lots = lots[1:]
+			}
+		}
+
+		// anything left opens a new position
+		if remaining > 1.0e-9 {
+			lots = append(lots, taxLot{
+				Date:   trx.Date,
+				Shares: remaining * direction,
+				Price:  price,
+			})
+		}
+		l.lots[trx.Ticker] = lots
+
+	case SplitTransaction:
+		lots := l.lots[trx.Ticker]
+		var held float64
+		for _, lot := range lots {
+			held += lot.Shares
+		}
+		if math.Abs(held) <= 1.0e-9 {
+			return gains
+		}
+		factor := (held + trx.Shares) / held
+		for ii := range lots {
+			lots[ii].Shares *= factor
+			lots[ii].Price /= factor
+		}
+	}
+
+	return gains
+}
+
+// CalculateAfterTax add the after-tax value to each measurement. Taxes
+// on realized capital gains and dividends are paid when they are realized
+// by selling a proportional share of the portfolio. Losses offset short-term
+// gains first and are carried forward indefinitely. Tax-deferred accounts
+// are not taxed.
+func (perf *Performance) CalculateAfterTax(accountType string, rates TaxRates) error {
+	if !ValidAccountType(accountType) {
+		return errors.New("unknown account type")
+	}
+
+	perf.AccountType = accountType
+	perf.TaxesPaid = 0
+	perf.taxRates = &rates
+	if accountType != AccountTaxable {
+		for ii := range perf.Measurements {
+			perf.Measurements[ii].AfterTaxValue = perf.Measurements[ii].Value
+		}
+		return nil
+	}
+
+	factors, taxesPaid := perf.afterTaxFactors(rates)
+	perf.TaxesPaid = taxesPaid
+	for ii := range perf.Measurements {
+		perf.Measurements[ii].AfterTaxValue = perf.Measurements[ii].Value * factors[ii]
+	}
+
+	return nil
+}
+
+// afterTaxFactors fraction of the portfolio left at each measurement after
+// paying the taxes realized through it in a taxable account, and the total
+// taxes paid
+func (perf *Performance) afterTaxFactors(rates TaxRates) ([]float64, float64) {
+	factors := make([]float64, len(perf.Measurements))
+	ledger := newTaxLedger(rates)
+	trxIdx := 0
+	factor := 1.0
+	var taxesPaid float64
+	var carryForward float64
+	for ii := range perf.Measurements {
+		m := &perf.Measurements[ii]
+		date := time.Unix(m.Time, 0)
+
+		var shortTerm, longTerm, dividends float64
+		for ; trxIdx < len(perf.Transactions); trxIdx++ {
+			trx := &perf.Transactions[trxIdx]
+			if trx.Date.After(date) {
+				break
+			}
+
+			if trx.Kind == DividendTransaction {
+				if trx.TotalValue > 0 {
+					dividends += trx.TotalValue
+				}
+				continue
+			}
+
+			gains := ledger.apply(trx)
+			shortTerm += gains.ShortTerm
+			longTerm += gains.LongTerm
+		}
+
+		// net losses against gains; losses offset short-term gains first
+		shortTerm -= carryForward
+		carryForward = 0
+		if shortTerm < 0 {
+			longTerm += shortTerm
+			shortTerm = 0
+		}
+		if longTerm < 0 {
+			shortTerm += longTerm
+			longTerm = 0
+			if shortTerm < 0 {
+				carryForward = -shortTerm
+				shortTerm = 0
+			}
+		}
+
+		tax := shortTerm*rates.ShortTermCapitalGains/100.0 +
+			longTerm*rates.LongTermCapitalGains/100.0 +
+			dividends*rates.Dividends/100.0
+
+		if tax > 0 && m.Value > 0 {
+			taxesPaid += tax * factor
+			factor *= math.Max(0, 1.0-tax/m.Value)
+		}
+
+		factors[ii] = factor
+	}
+
+	return factors, taxesPaid
+}
+
+// TaxCostRatio estimated fraction of the annualized return lost to taxes if
+// the portfolio were held in a taxable account and taxed at rates:
+// 1 - (1 + after-tax return) / (1 + pre-tax return)
+func (perf *Performance) TaxCostRatio(rates TaxRates) float64 {
+	n := len(perf.Measurements)
+	if n < 2 {
+		return 0
+	}
+	years := float64(perf.Measurements[n-1].Time-perf.Measurements[0].Time) / (86400.0 * 365.25)
+	if years <= 0 {
+		return 0
+	}
+
+	// returns are time-weighted so deposits and withdrawals are not taxed
+	growth := 1.0
+	for _, m := range perf.Measurements[1:] {
+		growth *= 1.0 + m.PercentReturn
+	}
+	factors, _ := perf.afterTaxFactors(rates)
+	afterTaxGrowth := growth * factors[n-1]
+	if growth <= 0 || afterTaxGrowth <= 0 {
+		return 0
+	}
+
+	preTax := math.Pow(growth, 1.0/years) - 1.0
+	afterTax := math.Pow(afterTaxGrowth, 1.0/years) - 1.0
+	return 1.0 - (1.0+afterTax)/(1.0+preTax)
+}
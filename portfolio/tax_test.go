@@ -0,0 +1,113 @@
+package portfolio_test
+
+import (
+	"math"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"main/portfolio"
+)
+
+var _ = Describe("Tax", func() {
+	var (
+		perf  portfolio.Performance
+		rates portfolio.TaxRates
+	)
+
+	BeforeEach(func() {
+		d1 := time.Date(2019, time.January, 31, 0, 0, 0, 0, time.UTC)
+		d2 := time.Date(2019, time.June, 28, 0, 0, 0, 0, time.UTC)
+		d3 := time.Date(2020, time.March, 31, 0, 0, 0, 0, time.UTC)
+		perf = portfolio.Performance{
+			Measurements: []portfolio.PerformanceMeasurement{
+				{Time: d1.Unix(), Value: 1000},
+				{Time: d2.Unix(), Value: 2000},
+				{Time: d3.Unix(), Value: 2500},
+			},
+			Transactions: []portfolio.Transaction{
+				{Date: d1, Ticker: "$CASH", Kind: portfolio.DepositTransaction, TotalValue: 1000},
+				{Date: d1, Ticker: "VFINX", Kind: portfolio.BuyTransaction, Shares: 100, PricePerShare: 10, TotalValue: 1000},
+				{Date: d2, Ticker: "VFINX", Kind: portfolio.SellTransaction, Shares: 50, PricePerShare: 20, TotalValue: 1000},
+				{Date: d3, Ticker: "VFINX", Kind: portfolio.SellTransaction, Shares: 50, PricePerShare: 30, TotalValue: 1500},
+			},
+		}
+		rates = portfolio.TaxRates{
+			ShortTermCapitalGains: 24,
+			LongTermCapitalGains:  15,
+			Dividends:             15,
+		}
+	})
+
+	Describe("When calculating after-tax performance", func() {
+		It("should not tax retirement accounts", func() {
+			err := perf.CalculateAfterTax(portfolio.AccountIRA, rates)
+			Expect(err).To(BeNil())
+			for _, m := range perf.Measurements {
+				Expect(m.AfterTaxValue).To(Equal(m.Value))
+			}
+			Expect(perf.TaxesPaid).To(Equal(0.0))
+		})
+
+		It("should tax short and long-term gains in taxable accounts", func() {
+			err := perf.CalculateAfterTax(portfolio.AccountTaxable, rates)
+			Expect(err).To(BeNil())
+			Expect(perf.Measurements[0].AfterTaxValue).Should(BeNumerically("~", 1000, 1e-9))
+
+			// short-term gain of 500 taxed at 24%
+			Expect(perf.Measurements[1].AfterTaxValue).Should(BeNumerically("~", 1880, 1e-9))
+
+			// long-term gain of 1000 taxed at 15%
+			Expect(perf.Measurements[2].AfterTaxValue).Should(BeNumerically("~", 2500*0.94*0.94, 1e-9))
+			Expect(perf.TaxesPaid).Should(BeNumerically("~", 120+150*0.94, 1e-9))
+		})
+
+		It("should carry losses forward", func() {
+			perf.Transactions[2].TotalValue = 250
+			err := perf.CalculateAfterTax(portfolio.AccountTaxable, rates)
+			Expect(err).To(BeNil())
+
+			// a 250 loss offsets the following 1000 long-term gain
+			Expect(perf.Measurements[1].AfterTaxValue).Should(BeNumerically("~", 2000, 1e-9))
+			Expect(perf.Measurements[2].AfterTaxValue).Should(BeNumerically("~", 2500*(1-750*0.15/2500), 1e-9))
+		})
+
+		It("should reject unknown account types", func() {
+			err := perf.CalculateAfterTax("hsa", rates)
+			Expect(err).ToNot(BeNil())
+		})
+
+		It("should use the configured long-term holding period", func() {
+			rates.LongTermHoldingDays = 500
+			err := perf.CalculateAfterTax(portfolio.AccountTaxable, rates)
+			Expect(err).To(BeNil())
+
+			// the gain of 1000 after 425 days is short-term
+			Expect(perf.Measurements[2].AfterTaxValue).Should(BeNumerically("~", 2500*0.94*(1-240.0/2500), 1e-9))
+		})
+	})
+
+	Describe("When estimating the tax cost ratio", func() {
+		BeforeEach(func() {
+			perf.Measurements[1].PercentReturn = 1.0
+			perf.Measurements[2].PercentReturn = 0.25
+		})
+
+		It("should be the fraction of the annualized return lost to taxes", func() {
+			years := 425.0 / 365.25
+			Expect(perf.TaxCostRatio(rates)).Should(BeNumerically("~", 1-math.Pow(0.94*0.94, 1/years), 1e-9))
+		})
+
+		It("should be estimated for tax-deferred accounts", func() {
+			err := perf.CalculateAfterTax(portfolio.AccountIRA, rates)
+			Expect(err).To(BeNil())
+			perf.BuildMetricsBundle()
+			Expect(perf.MetricsBundle.TaxCostRatio).Should(BeNumerically(">", 0))
+		})
+
+		It("should be 0 without taxes", func() {
+			Expect(perf.TaxCostRatio(portfolio.TaxRates{})).Should(BeNumerically("~", 0, 1e-9))
+		})
+	})
+})
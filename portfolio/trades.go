@@ -0,0 +1,254 @@
+package portfolio
+
+import (
+	"math"
+	"time"
+)
+
+// Directions of a trade
+const (
+	TradeLong  = "long"
+	TradeShort = "short"
+)
+
+// Trade a round trip in a security: opened when the position leaves zero
+// and closed when it returns to zero. Shares bought or sold while the
+// position is open are part of the same trade.
+type Trade struct {
+	Ticker    string     `json:"ticker"`
+	Direction string     `json:"direction"`
+	Entry     time.Time  `json:"entry"`
+	Exit      *time.Time `json:"exit,omitempty"`
+
+	// Invested cost of the shares bought by a long trade, or proceeds of the
+	// shares sold short by a short trade, including fees
+	Invested float64 `json:"invested"`
+
+	// Profit proceeds less cost including fees and dividends received, or
+	// paid by shorts, while the trade was open; 0 for open trades
+	Profit float64 `json:"profit"`
+
+	// Return profit as a fraction of the amount invested
+	Return float64 `json:"return"`
+
+	// HoldingDays calendar days between entry and exit
+	HoldingDays float64 `json:"holdingDays"`
+
+	bought float64
+	sold   float64
+	income float64
+}
+
+// Closed true if the position was exited
+func (t *Trade) Closed() bool {
+	return t.Exit != nil
+}
+
+// invested amount put at risk by the trade so far
+func (t *Trade) invested() float64 {
+	if t.Direction == TradeLong {
+		return t.bought
+	}
+	return t.sold
+}
+
+// close exit the trade on date and realize its profit
+func (t *Trade) close(date time.Time) {
+	exit := date
+	t.Exit = &exit
+	t.HoldingDays = date.Sub(t.Entry).Hours() / 24
+	t.Profit = t.sold - t.bought + t.income
+	t.Invested = t.invested()
+	if t.Invested != 0 {
+		t.Return = t.Profit / t.Invested
+	}
+}
+
+// TradeStats summary of the closed trades of a portfolio
+type TradeStats struct {
+	NumTrades  int `json:"numTrades"`
+	OpenTrades int `json:"openTrades"`
+
+	// WinRate fraction of closed trades with a profit
+	WinRate float64 `json:"winRate"`
+
+	// AverageWin and AverageLoss average return of the winning and losing
+	// trades; AverageLoss is negative
+	AverageWin  float64 `json:"averageWin"`
+	AverageLoss float64 `json:"averageLoss"`
+
+	// ProfitFactor gross profit of winning trades divided by the gross loss
+	// of losing trades; 0 if there were no losing trades
+	ProfitFactor float64 `json:"profitFactor"`
+
+	AverageHoldingDays float64 `json:"averageHoldingDays"`
+
+	// Turnover fraction of the portfolio replaced each year
+	Turnover float64 `json:"turnover"`
+}
+
+// Trades round trips derived from the transactions of the performance, in
+// the order they were entered. A transaction that reverses a position, e.g.
+// sells more shares than are held, closes the trade and opens one in the
+// other direction. Positions still held are returned as open trades.
+func (perf *Performance) Trades() []Trade {
+	trades := []*Trade{}
+	open := map[string]*Trade{}
+	shares := map[string]float64{}
+
+	for _, trx := range perf.Transactions {
+		if trx.Ticker == "$CASH" {
+			continue
+		}
+
+		switch trx.Kind {
+		case SplitTransaction:
+			shares[trx.Ticker] += trx.Shares
+			continue
+		case DividendTransaction:
+			if t, ok := open[trx.Ticker]; ok {
+				t.income += trx.TotalValue
+			}
+			continue
+		case BuyTransaction, SellTransaction:
+		default:
+			continue
+		}
+		if trx.Shares <= 0 {
+			continue
+		}
+
+		direction := 1.0
+		if trx.Kind == SellTransaction {
+			direction = -1.0
+		}
+
+		held := shares[trx.Ticker]
+		remaining := trx.Shares
+		for remaining > 1.0e-9 {
+			t, ok := open[trx.Ticker]
+			if !ok {
+				t = &Trade{
+					Ticker:    trx.Ticker,
+					Direction: TradeLong,
+					Entry:     trx.Date,
+				}
+				if direction < 0 {
+					t.Direction = TradeShort
+				}
+				open[trx.Ticker] = t
+				trades = append(trades, t)
+			}
+
+			// a transaction larger than the position closes it first
+			qty := remaining
+			if held*direction < 0 {
+				qty = math.Min(remaining, math.Abs(held))
+			}
+
+			fraction := qty / trx.Shares
+			if direction > 0 {
+				t.bought += (trx.TotalValue + trx.Fees) * fraction
+			} else {
+				t.sold += (trx.TotalValue - trx.Fees) * fraction
+			}
+
+			held += qty * direction
+			remaining -= qty
+			if math.Abs(held) <= 1.0e-9 {
+				held = 0
+				t.close(trx.Date)
+				delete(open, trx.Ticker)
+			}
+		}
+		shares[trx.Ticker] = held
+	}
+
+	res := make([]Trade, len(trades))
+	for ii, t := range trades {
+		if !t.Closed() {
+			t.Invested = t.invested()
+		}
+		res[ii] = *t
+	}
+	return res
+}
+
+// Turnover average fraction of the portfolio replaced each year: the lesser
+// of the securities bought and sold divided by the average value of the
+// portfolio, per year measured
+func (perf *Performance) Turnover() float64 {
+	n := len(perf.Measurements)
+	if n < 2 {
+		return 0
+	}
+
+	var bought, sold float64
+	for _, trx := range perf.Transactions {
+		if trx.Ticker == "$CASH" {
+			continue
+		}
+		switch trx.Kind {
+		case BuyTransaction:
+			bought += trx.TotalValue
+		case SellTransaction:
+			sold += trx.TotalValue
+		}
+	}
+
+	var total float64
+	for _, m := range perf.Measurements {
+		total += m.Value
+	}
+	average := total / float64(n)
+	years := float64(perf.Measurements[n-1].Time-perf.Measurements[0].Time) / (86400.0 * 365.25)
+	if average <= 0 || years <= 0 {
+		return 0
+	}
+
+	return math.Min(bought, sold) / average / years
+}
+
+// TradeStats summarize the closed trades of the portfolio
+func (perf *Performance) TradeStats() TradeStats {
+	stats := TradeStats{
+		Turnover: perf.Turnover(),
+	}
+
+	var wins, losses int
+	var winReturns, lossReturns, grossProfit, grossLoss, holdingDays float64
+	for _, t := range perf.Trades() {
+		if !t.Closed() {
+			stats.OpenTrades++
+			continue
+		}
+
+		stats.NumTrades++
+		holdingDays += t.HoldingDays
+		if t.Profit > 0 {
+			wins++
+			winReturns += t.Return
+			grossProfit += t.Profit
+		} else if t.Profit < 0 {
+			losses++
+			lossReturns += t.Return
+			grossLoss -= t.Profit
+		}
+	}
+
+	if stats.NumTrades == 0 {
+		return stats
+	}
+	stats.WinRate = float64(wins) / float64(stats.NumTrades)
+	stats.AverageHoldingDays = holdingDays / float64(stats.NumTrades)
+	if wins > 0 {
+		stats.AverageWin = winReturns / float64(wins)
+	}
+	if losses > 0 {
+		stats.AverageLoss = lossReturns / float64(losses)
+	}
+	if grossLoss > 0 {
+		stats.ProfitFactor = grossProfit / grossLoss
+	}
+	return stats
+}
@@ -0,0 +1,99 @@
+package portfolio_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"main/portfolio"
+)
+
+var _ = Describe("Trades", func() {
+	var (
+		perf portfolio.Performance
+	)
+
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2020, month, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	trade := func(date time.Time, kind string, ticker string, shares float64, price float64) portfolio.Transaction {
+		return portfolio.Transaction{
+			Date:          date,
+			Ticker:        ticker,
+			Kind:          kind,
+			PricePerShare: price,
+			Shares:        shares,
+			TotalValue:    shares * price,
+		}
+	}
+
+	BeforeEach(func() {
+		perf = portfolio.Performance{
+			Transactions: []portfolio.Transaction{
+				{Date: day(time.January, 1), Ticker: "$CASH", Kind: portfolio.DepositTransaction, TotalValue: 10000},
+				trade(day(time.January, 1), portfolio.BuyTransaction, "VFINX", 50, 100),
+				trade(day(time.January, 1), portfolio.BuyTransaction, "PRIDX", 50, 100),
+
+				// VFINX is added to and then sold for a gain
+				trade(day(time.February, 1), portfolio.BuyTransaction, "VFINX", 10, 100),
+				{Date: day(time.March, 1), Ticker: "VFINX", Kind: portfolio.DividendTransaction, TotalValue: 60},
+				trade(day(time.March, 31), portfolio.SellTransaction, "VFINX", 60, 110),
+
+				// PRIDX is sold at a loss
+				trade(day(time.March, 31), portfolio.SellTransaction, "PRIDX", 50, 90),
+
+				// VUSTX is still held
+				trade(day(time.April, 1), portfolio.BuyTransaction, "VUSTX", 100, 50),
+			},
+			Measurements: []portfolio.PerformanceMeasurement{
+				{Time: day(time.January, 1).Unix(), Value: 10000},
+				{Time: day(time.January, 1).AddDate(1, 0, 0).Unix(), Value: 10000},
+			},
+		}
+	})
+
+	It("should pair the entry and exit of each position", func() {
+		trades := perf.Trades()
+		Expect(trades).To(HaveLen(3))
+
+		Expect(trades[0].Ticker).To(Equal("VFINX"))
+		Expect(trades[0].Closed()).To(BeTrue())
+		Expect(trades[0].Invested).To(BeNumerically("~", 6000, 1e-9))
+		Expect(trades[0].Profit).To(BeNumerically("~", 660, 1e-9))
+		Expect(trades[0].Return).To(BeNumerically("~", 0.11, 1e-9))
+		Expect(trades[0].HoldingDays).To(BeNumerically("~", 90, 1e-9))
+
+		Expect(trades[1].Ticker).To(Equal("PRIDX"))
+		Expect(trades[1].Profit).To(BeNumerically("~", -500, 1e-9))
+
+		Expect(trades[2].Ticker).To(Equal("VUSTX"))
+		Expect(trades[2].Closed()).To(BeFalse())
+		Expect(trades[2].Invested).To(BeNumerically("~", 5000, 1e-9))
+	})
+
+	It("should split a transaction that reverses a position", func() {
+		perf.Transactions = append(perf.Transactions, trade(day(time.May, 1), portfolio.SellTransaction, "VUSTX", 150, 60))
+
+		trades := perf.Trades()
+		Expect(trades).To(HaveLen(4))
+		Expect(trades[2].Profit).To(BeNumerically("~", 1000, 1e-9))
+		Expect(trades[3].Direction).To(Equal(portfolio.TradeShort))
+		Expect(trades[3].Invested).To(BeNumerically("~", 3000, 1e-9))
+		Expect(trades[3].Closed()).To(BeFalse())
+	})
+
+	It("should summarize the closed trades", func() {
+		stats := perf.TradeStats()
+		Expect(stats.NumTrades).To(Equal(2))
+		Expect(stats.OpenTrades).To(Equal(1))
+		Expect(stats.WinRate).To(BeNumerically("~", 0.5, 1e-9))
+		Expect(stats.AverageWin).To(BeNumerically("~", 0.11, 1e-9))
+		Expect(stats.AverageLoss).To(BeNumerically("~", -0.1, 1e-9))
+		Expect(stats.ProfitFactor).To(BeNumerically("~", 660.0/500.0, 1e-9))
+
+		// 11,100 sold of an average value of 10,000 over the 366 days of 2020
+		Expect(stats.Turnover).To(BeNumerically("~", 1.11*365.25/366, 1e-9))
+	})
+})
@@ -0,0 +1,165 @@
+package portfolio
+
+import (
+	"errors"
+	"fmt"
+	"main/data"
+	"math"
+	"time"
+)
+
+// SpliceTransactions build the ledger of a counterfactual portfolio that
+// followed history until pivot and then switched to alternative. Holdings at
+// pivot are liquidated at the given prices and the proceeds are invested in
+// alternative, whose transactions are scaled from its initial deposit to the
+// value of the portfolio at pivot. Transactions of history on or after pivot
+// and of alternative before pivot are discarded.
+func SpliceTransactions(history []Transaction, alternative []Transaction, pivot time.Time, prices map[string]float64) ([]Transaction, error) {
+	spliced := make([]Transaction, 0, len(history)+len(alternative))
+	for _, trx := range history {
+		if !trx.Date.Before(pivot) {
+			continue
+		}
+		spliced = append(spliced, trx)
+	}
+	if len(spliced) == 0 {
+		return nil, errors.New("no history before pivot date")
+	}
+	SortTransactions(spliced)
+
+	// liquidate the holdings at pivot
+	holdings := LedgerHoldings(spliced, pivot)
+	value := holdings["$CASH"]
+	for ticker, shares := range holdings {
+		if ticker == "$CASH" {
+			continue
+		}
+		price, ok := prices[ticker]
+		if !ok || math.IsNaN(price) {
+			return nil, fmt.Errorf("no price for %s on %s", ticker, pivot.Format("2006-01-02"))
+		}
+
+		kind := SellTransaction
+		if shares < 0 {
+			kind = BuyTransaction
+		}
+		spliced = append(spliced, Transaction{
+			Date:          pivot,
+			Ticker:        ticker,
+			Kind:          kind,
+			PricePerShare: price,
+			Shares:        math.Abs(shares),
+			TotalValue:    math.Abs(shares) * price,
+			Justification: map[string]interface{}{"whatIf": "liquidate at pivot"},
+		})
+		value += shares * price
+	}
+	if value <= 0 {
+		return nil, fmt.Errorf("portfolio has no value on %s", pivot.Format("2006-01-02"))
+	}
+
+	// the initial deposit of alternative is replaced by the liquidated value
+	initial := 0.0
+	for _, trx := range alternative {
+		if !trx.Date.Before(pivot) && trx.Kind == DepositTransaction {
+			initial = trx.TotalValue
+			break
+		}
+	}
+	if initial == 0 {
+		return nil, errors.New("alternative portfolio has no initial deposit after pivot date")
+	}
+
+	scale := value / initial
+	deposited := false
+	for _, trx := range alternative {
+		if trx.Date.Before(pivot) {
+			continue
+		}
+		if !deposited && trx.Kind == DepositTransaction {
+			deposited = true
+			continue
+		}
+
+		trx.Shares *= scale
+		trx.TotalValue *= scale
+		trx.Fees *= scale
+		spliced = append(spliced, trx)
+	}
+
+	SortTransactions(spliced)
+	return spliced, nil
+}
+
+// WhatIf create a counterfactual portfolio that followed history until pivot
+// and then switched to alternative, a portfolio computed from pivot onward,
+// e.g. the same strategy with different arguments
+func WhatIf(name string, manager *data.Manager, history []Transaction, alternative *Portfolio, pivot time.Time) (Portfolio, error) {
+	tickers := make(map[string]bool)
+	for _, trx := range history {
+		if trx.Date.Before(pivot) && (trx.Kind == BuyTransaction || trx.Kind == SellTransaction) {
+			tickers[trx.Ticker] = true
+		}
+	}
+
+	prices, err := pricesAsOf(manager, tickers, pivot)
+	if err != nil {
+		return Portfolio{}, err
+	}
+
+	trxs, err := SpliceTransactions(history, alternative.Transactions, pivot, prices)
+	if err != nil {
+		return Portfolio{}, err
+	}
+
+	p, err := NewPortfolioFromTransactions(name, manager, trxs)
+	if err != nil {
+		return p, err
+	}
+	p.Resolution = alternative.Resolution
+	return p, nil
+}
+
+// pricesAsOf last available price of each ticker on or before date
+func pricesAsOf(manager *data.Manager, tickers map[string]bool, date time.Time) (map[string]float64, error) {
+	prices := make(map[string]float64, len(tickers))
+	if len(tickers) == 0 {
+		return prices, nil
+	}
+
+	symbols := make([]string, 0, len(tickers))
+	for k := range tickers {
+		symbols = append(symbols, k)
+	}
+
+	origBegin, origEnd, origFrequency := manager.Begin, manager.End, manager.Frequency
+	defer func() {
+		manager.Begin, manager.End, manager.Frequency = origBegin, origEnd, origFrequency
+	}()
+
+	// look back a week in case date is not a trading day
+	manager.Begin = date.AddDate(0, 0, -7)
+	manager.End = date
+	manager.Frequency = data.FrequencyDaily
+
+	quotes, errs := manager.GetMultipleData(symbols...)
+	if len(errs) > 0 {
+		return nil, errors.New("Failed to download data for tickers")
+	}
+
+	for symbol, df := range quotes {
+		colIdx, err := df.NameToColumn(symbol)
+		if err != nil {
+			return nil, err
+		}
+		series := df.Series[colIdx]
+		for row := series.NRows() - 1; row >= 0; row-- {
+			if val, ok := series.Value(row).(float64); ok && !math.IsNaN(val) {
+				prices[symbol] = val
+				break
+			}
+		}
+	}
+
+	return prices, nil
+}
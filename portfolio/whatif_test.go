@@ -0,0 +1,89 @@
+package portfolio_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"main/portfolio"
+)
+
+var _ = Describe("WhatIf", func() {
+	var (
+		history     []portfolio.Transaction
+		alternative []portfolio.Transaction
+		d1          time.Time
+		d2          time.Time
+		pivot       time.Time
+		d3          time.Time
+	)
+
+	BeforeEach(func() {
+		d1 = time.Date(2019, time.January, 31, 0, 0, 0, 0, time.UTC)
+		d2 = time.Date(2019, time.June, 28, 0, 0, 0, 0, time.UTC)
+		pivot = time.Date(2020, time.January, 2, 0, 0, 0, 0, time.UTC)
+		d3 = time.Date(2020, time.February, 28, 0, 0, 0, 0, time.UTC)
+
+		history = []portfolio.Transaction{
+			{Date: d1, Ticker: "$CASH", Kind: portfolio.DepositTransaction, TotalValue: 10000},
+			{Date: d1, Ticker: "VFINX", Kind: portfolio.BuyTransaction, Shares: 100, PricePerShare: 100, TotalValue: 10000},
+			{Date: d2, Ticker: "VFINX", Kind: portfolio.SellTransaction, Shares: 100, PricePerShare: 110, TotalValue: 11000},
+			{Date: d2, Ticker: "VUSTX", Kind: portfolio.BuyTransaction, Shares: 1000, PricePerShare: 11, TotalValue: 11000},
+			{Date: d3, Ticker: "VUSTX", Kind: portfolio.SellTransaction, Shares: 1000, PricePerShare: 13, TotalValue: 13000},
+		}
+
+		alternative = []portfolio.Transaction{
+			{Date: d1, Ticker: "$CASH", Kind: portfolio.DepositTransaction, TotalValue: 10000},
+			{Date: pivot, Ticker: "$CASH", Kind: portfolio.DepositTransaction, TotalValue: 10000},
+			{Date: pivot, Ticker: "PRIDX", Kind: portfolio.BuyTransaction, Shares: 200, PricePerShare: 50, TotalValue: 10000},
+			{Date: d3, Ticker: "PRIDX", Kind: portfolio.SellTransaction, Shares: 200, PricePerShare: 60, TotalValue: 12000},
+		}
+	})
+
+	Describe("When splicing an alternative at a pivot date", func() {
+		It("should keep history before the pivot", func() {
+			trxs, err := portfolio.SpliceTransactions(history, alternative, pivot, map[string]float64{"VUSTX": 12})
+			Expect(err).To(BeNil())
+			Expect(trxs[:4]).To(Equal(history[:4]))
+		})
+
+		It("should liquidate holdings at the pivot", func() {
+			trxs, err := portfolio.SpliceTransactions(history, alternative, pivot, map[string]float64{"VUSTX": 12})
+			Expect(err).To(BeNil())
+			Expect(trxs[4].Date).To(Equal(pivot))
+			Expect(trxs[4].Kind).To(Equal(portfolio.SellTransaction))
+			Expect(trxs[4].Ticker).To(Equal("VUSTX"))
+			Expect(trxs[4].TotalValue).Should(BeNumerically("~", 12000, 1e-9))
+		})
+
+		It("should scale the alternative to the value at the pivot", func() {
+			trxs, err := portfolio.SpliceTransactions(history, alternative, pivot, map[string]float64{"VUSTX": 12})
+			Expect(err).To(BeNil())
+			Expect(trxs).To(HaveLen(7))
+			Expect(trxs[5].Ticker).To(Equal("PRIDX"))
+			Expect(trxs[5].Shares).Should(BeNumerically("~", 240, 1e-9))
+			Expect(trxs[6].TotalValue).Should(BeNumerically("~", 14400, 1e-9))
+
+			for _, trx := range trxs {
+				if trx.Kind == portfolio.DepositTransaction {
+					Expect(trx.Date).To(Equal(d1))
+				}
+			}
+
+			holdings := portfolio.LedgerHoldings(trxs, d3)
+			Expect(holdings).To(HaveLen(1))
+			Expect(holdings["$CASH"]).Should(BeNumerically("~", 14400, 1e-9))
+		})
+
+		It("should fail without a price for a held security", func() {
+			_, err := portfolio.SpliceTransactions(history, alternative, pivot, map[string]float64{})
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("should fail without history before the pivot", func() {
+			_, err := portfolio.SpliceTransactions(history, alternative, d1.AddDate(0, 0, -1), map[string]float64{})
+			Expect(err).NotTo(BeNil())
+		})
+	})
+})
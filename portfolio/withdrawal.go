@@ -0,0 +1,339 @@
+package portfolio
+
+import (
+	"errors"
+	"fmt"
+	"main/data"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+const (
+	// WithdrawalFixedReal withdraw a percent of the initial balance each
+	// year, increased with inflation
+	WithdrawalFixedReal = "fixed"
+
+	// WithdrawalFixedPercent withdraw a percent of the current balance each
+	// year
+	WithdrawalFixedPercent = "percent"
+
+	// WithdrawalGuardrails Guyton-Klinger decision rules; inflation
+	// adjusted withdrawals that are cut or raised when the withdrawal rate
+	// drifts outside a band around the initial rate
+	WithdrawalGuardrails = "guardrails"
+
+	// WithdrawalVPW variable percentage withdrawal; the balance is
+	// amortized over the remaining years at an expected return
+	WithdrawalVPW = "vpw"
+)
+
+// Defaults of the withdrawal plan
+const (
+	DefaultWithdrawalYears     = 30
+	DefaultWithdrawalRate      = 4.0
+	DefaultGuardrailBand       = 20.0
+	DefaultGuardrailAdjustment = 10.0
+	DefaultVPWReturn           = 5.0
+	DefaultMonteCarloPaths     = 1000
+)
+
+// WithdrawalPlan a retirement spending plan drawn from a portfolio. Rates
+// are percentages, e.g. 4 for the 4% rule. Zero values use the defaults.
+type WithdrawalPlan struct {
+	Mode           string  `json:"mode"`
+	InitialBalance float64 `json:"initialBalance"`
+	Years          int     `json:"years"`
+
+	// Rate the first year's withdrawal as a percent of InitialBalance; not
+	// used by WithdrawalVPW
+	Rate float64 `json:"rate"`
+
+	// Inflation annual percent increase of fixed real and guardrail
+	// withdrawals; terminal values are reported in today's dollars. Unlike
+	// the other fields it has no default.
+	Inflation float64 `json:"inflation"`
+
+	// GuardrailBand percent the withdrawal rate may drift from the initial
+	// rate before the withdrawal is adjusted by GuardrailAdjustment percent
+	GuardrailBand       float64 `json:"guardrailBand"`
+	GuardrailAdjustment float64 `json:"guardrailAdjustment"`
+
+	// ExpectedReturn annual return the balance is amortized at by
+	// WithdrawalVPW
+	ExpectedReturn float64 `json:"expectedReturn"`
+
+	// Paths number of Monte Carlo paths; Seed makes them reproducible
+	Paths int   `json:"paths"`
+	Seed  int64 `json:"seed"`
+}
+
+// WithdrawalSummary outcome of a set of withdrawal simulations
+type WithdrawalSummary struct {
+	Simulations int `json:"simulations"`
+
+	// SuccessRate fraction of simulations that never ran out of money
+	SuccessRate float64 `json:"successRate"`
+
+	// TerminalValues percentiles of the ending balance in today's dollars,
+	// keyed by percentile
+	TerminalValues map[string]float64 `json:"terminalValues"`
+
+	// MedianWithdrawn median of the total withdrawn in today's dollars
+	MedianWithdrawn float64 `json:"medianWithdrawn"`
+
+	// WorstStart start of the historical sequence with the lowest terminal
+	// value
+	WorstStart *time.Time `json:"worstStart,omitempty"`
+}
+
+// WithdrawalResult outcome of a withdrawal plan over the historical
+// sequences of the portfolio's returns and Monte Carlo paths resampled from
+// them
+type WithdrawalResult struct {
+	Plan       WithdrawalPlan    `json:"plan"`
+	Historical WithdrawalSummary `json:"historical"`
+	MonteCarlo WithdrawalSummary `json:"monteCarlo"`
+}
+
+// withdrawalPercentiles percentiles of terminal values reported
+var withdrawalPercentiles = []float64{5, 10, 25, 50, 75, 90, 95}
+
+// Validate fill in the defaults of the plan and check that it is valid
+func (plan *WithdrawalPlan) Validate() error {
+	switch plan.Mode {
+	case "":
+		plan.Mode = WithdrawalFixedReal
+	case WithdrawalFixedReal, WithdrawalFixedPercent, WithdrawalGuardrails, WithdrawalVPW:
+	default:
+		return fmt.Errorf("unknown withdrawal mode '%s'", plan.Mode)
+	}
+
+	if plan.InitialBalance == 0 {
+		plan.InitialBalance = DefaultInitialCapital
+	}
+	if plan.Years == 0 {
+		plan.Years = DefaultWithdrawalYears
+	}
+	if plan.Rate == 0 {
+		plan.Rate = DefaultWithdrawalRate
+	}
+	if plan.GuardrailBand == 0 {
+		plan.GuardrailBand = DefaultGuardrailBand
+	}
+	if plan.GuardrailAdjustment == 0 {
+		plan.GuardrailAdjustment = DefaultGuardrailAdjustment
+	}
+	if plan.ExpectedReturn == 0 {
+		plan.ExpectedReturn = DefaultVPWReturn
+	}
+	if plan.Paths == 0 {
+		plan.Paths = DefaultMonteCarloPaths
+	}
+
+	switch {
+	case plan.InitialBalance < 0:
+		return errors.New("initialBalance must be positive")
+	case plan.Years < 1 || plan.Years > 100:
+		return errors.New("years must be between 1 and 100")
+	case plan.Rate < 0 || plan.Rate > 100:
+		return errors.New("rate must be between 0 and 100")
+	case plan.Inflation < 0:
+		return errors.New("inflation must not be negative")
+	case plan.GuardrailBand < 0 || plan.GuardrailAdjustment < 0 || plan.GuardrailAdjustment >= 100:
+		return errors.New("guardrailBand and guardrailAdjustment must be positive percentages")
+	case plan.ExpectedReturn <= -100:
+		return errors.New("expectedReturn must be greater than -100")
+	case plan.Paths < 1 || plan.Paths > 100000:
+		return errors.New("paths must be between 1 and 100000")
+	}
+
+	return nil
+}
+
+// withdrawal amount withdrawn at the start of year (counting from 0) of
+// the plan given the balance and the prior year's withdrawal and return
+func (plan *WithdrawalPlan) withdrawal(year int, balance float64, prior float64, priorReturn float64) float64 {
+	initial := plan.InitialBalance * plan.Rate / 100.0
+	inflation := plan.Inflation / 100.0
+
+	switch plan.Mode {
+	case WithdrawalFixedPercent:
+		return balance * plan.Rate / 100.0
+	case WithdrawalVPW:
+		remaining := float64(plan.Years - year)
+		r := plan.ExpectedReturn / 100.0
+		if math.Abs(r) < 1.0e-9 {
+			return balance / remaining
+		}
+		// the whole balance is withdrawn in the last year
+		return math.Min(balance, balance*r/(1-math.Pow(1+r, -remaining)))
+	case WithdrawalGuardrails:
+		if year == 0 {
+			return initial
+		}
+		amount := prior
+		rate := amount / balance
+		initialRate := plan.Rate / 100.0
+		// no inflation increase after a losing year when the withdrawal
+		// rate is above the initial rate
+		if !(priorReturn < 0 && rate > initialRate) {
+			amount *= 1 + inflation
+		}
+		rate = amount / balance
+		band := plan.GuardrailBand / 100.0
+		adjust := plan.GuardrailAdjustment / 100.0
+		if rate > initialRate*(1+band) && plan.Years-year > 15 {
+			// capital preservation rule, not applied in the last 15 years
+			amount *= 1 - adjust
+		} else if rate < initialRate*(1-band) {
+			// prosperity rule
+			amount *= 1 + adjust
+		}
+		return amount
+	}
+
+	return initial * math.Pow(1+inflation, float64(year))
+}
+
+// simulate run the plan over monthly returns, which must cover every year
+// of the plan. Returns whether the plan did not run out of money, the
+// terminal value, and the total withdrawn, both in today's dollars.
+func (plan *WithdrawalPlan) simulate(returns []float64) (bool, float64, float64) {
+	balance := plan.InitialBalance
+	inflation := plan.Inflation / 100.0
+	var withdrawn, prior, priorReturn float64
+	for year := 0; year < plan.Years; year++ {
+		amount := plan.withdrawal(year, balance, prior, priorReturn)
+		deflator := math.Pow(1+inflation, float64(year))
+		if amount > balance+1.0e-9 {
+			withdrawn += balance / deflator
+			return false, 0, withdrawn
+		}
+		balance -= amount
+		withdrawn += amount / deflator
+		prior = amount
+
+		start := balance
+		for _, ret := range returns[year*12 : (year+1)*12] {
+			balance *= 1 + ret
+		}
+		if start > 0 {
+			priorReturn = balance/start - 1
+		}
+	}
+	return true, balance / math.Pow(1+inflation, float64(plan.Years)), withdrawn
+}
+
+// monthlyReturns the returns of each monthly measurement after the
+// portfolio was funded and the date each was measured
+func (perf *Performance) monthlyReturns() ([]float64, []time.Time) {
+	returns := []float64{}
+	dates := []time.Time{}
+	started := false
+	for _, meas := range perf.Measurements {
+		if meas.WarmUp {
+			continue
+		}
+		if !started {
+			// the first measurement is the starting value
+			started = true
+			continue
+		}
+		returns = append(returns, meas.PercentReturn)
+		dates = append(dates, time.Unix(meas.Time, 0).UTC())
+	}
+	return returns, dates
+}
+
+// summarizeWithdrawals percentiles of the terminal values and the median
+// total withdrawn of simulations
+func summarizeWithdrawals(successes int, terminal []float64, withdrawn []float64) WithdrawalSummary {
+	summary := WithdrawalSummary{
+		Simulations:    len(terminal),
+		TerminalValues: make(map[string]float64, len(withdrawalPercentiles)),
+	}
+	if len(terminal) == 0 {
+		return summary
+	}
+
+	summary.SuccessRate = float64(successes) / float64(len(terminal))
+
+	sorted := make([]float64, len(terminal))
+	copy(sorted, terminal)
+	sort.Float64s(sorted)
+	for _, pct := range withdrawalPercentiles {
+		idx := int(math.Round(pct / 100.0 * float64(len(sorted)-1)))
+		summary.TerminalValues[fmt.Sprintf("p%.0f", pct)] = sorted[idx]
+	}
+
+	sortedWithdrawn := make([]float64, len(withdrawn))
+	copy(sortedWithdrawn, withdrawn)
+	sort.Float64s(sortedWithdrawn)
+	summary.MedianWithdrawn = sortedWithdrawn[len(sortedWithdrawn)/2]
+
+	return summary
+}
+
+// SimulateWithdrawals run a withdrawal plan over every historical sequence
+// of the portfolio's monthly returns long enough to cover the plan, and
+// over Monte Carlo paths that resample those returns with replacement. The
+// performance must be measured monthly.
+func (perf *Performance) SimulateWithdrawals(plan WithdrawalPlan) (WithdrawalResult, error) {
+	if err := plan.Validate(); err != nil {
+		return WithdrawalResult{}, err
+	}
+	if perf.Resolution != "" && perf.Resolution != data.FrequencyMonthly {
+		return WithdrawalResult{}, errors.New("withdrawals can only be simulated from monthly performance")
+	}
+
+	returns, dates := perf.monthlyReturns()
+	months := plan.Years * 12
+	if len(returns) < months {
+		return WithdrawalResult{}, fmt.Errorf("portfolio has %d months of history but the plan requires %d", len(returns), months)
+	}
+
+	result := WithdrawalResult{Plan: plan}
+
+	// historical sequences starting in each month
+	successes := 0
+	terminal := []float64{}
+	withdrawn := []float64{}
+	worst := -1
+	for start := 0; start+months <= len(returns); start++ {
+		ok, value, total := plan.simulate(returns[start : start+months])
+		if ok {
+			successes++
+		}
+		if worst == -1 || value < terminal[worst] {
+			worst = len(terminal)
+		}
+		terminal = append(terminal, value)
+		withdrawn = append(withdrawn, total)
+	}
+	result.Historical = summarizeWithdrawals(successes, terminal, withdrawn)
+	worstStart := dates[worst]
+	result.Historical.WorstStart = &worstStart
+
+	// Monte Carlo paths
+	rng := rand.New(rand.NewSource(plan.Seed))
+	path := make([]float64, months)
+	successes = 0
+	terminal = make([]float64, 0, plan.Paths)
+	withdrawn = make([]float64, 0, plan.Paths)
+	for ii := 0; ii < plan.Paths; ii++ {
+		for jj := range path {
+			path[jj] = returns[rng.Intn(len(returns))]
+		}
+		ok, value, total := plan.simulate(path)
+		if ok {
+			successes++
+		}
+		terminal = append(terminal, value)
+		withdrawn = append(withdrawn, total)
+	}
+	result.MonteCarlo = summarizeWithdrawals(successes, terminal, withdrawn)
+
+	return result, nil
+}
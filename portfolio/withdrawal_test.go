@@ -0,0 +1,125 @@
+package portfolio_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"main/data"
+	"main/portfolio"
+)
+
+var _ = Describe("Withdrawal", func() {
+	var (
+		perf portfolio.Performance
+	)
+
+	// monthly performance with the same return every month
+	constantReturns := func(months int, ret float64) portfolio.Performance {
+		perf := portfolio.Performance{Resolution: data.FrequencyMonthly}
+		start := time.Date(1990, time.January, 31, 0, 0, 0, 0, time.UTC)
+		for ii := 0; ii <= months; ii++ {
+			meas := portfolio.PerformanceMeasurement{
+				Time: start.AddDate(0, ii, 0).Unix(),
+			}
+			if ii > 0 {
+				meas.PercentReturn = ret
+			}
+			perf.Measurements = append(perf.Measurements, meas)
+		}
+		return perf
+	}
+
+	BeforeEach(func() {
+		perf = constantReturns(40*12, 0)
+	})
+
+	Describe("When given a withdrawal plan", func() {
+		It("should fill in the defaults", func() {
+			plan := portfolio.WithdrawalPlan{}
+			Expect(plan.Validate()).To(Succeed())
+			Expect(plan.Mode).To(Equal(portfolio.WithdrawalFixedReal))
+			Expect(plan.Years).To(Equal(portfolio.DefaultWithdrawalYears))
+			Expect(plan.Rate).To(Equal(portfolio.DefaultWithdrawalRate))
+			Expect(plan.Inflation).To(BeZero())
+		})
+
+		It("should reject invalid plans", func() {
+			plan := portfolio.WithdrawalPlan{Mode: "annuity"}
+			Expect(plan.Validate()).NotTo(Succeed())
+
+			plan = portfolio.WithdrawalPlan{Rate: 120}
+			Expect(plan.Validate()).NotTo(Succeed())
+		})
+
+		It("should require enough history", func() {
+			perf = constantReturns(10*12, 0)
+			_, err := perf.SimulateWithdrawals(portfolio.WithdrawalPlan{Years: 30})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("When simulating fixed real withdrawals", func() {
+		It("should run out of money when withdrawals exceed the balance", func() {
+			result, err := perf.SimulateWithdrawals(portfolio.WithdrawalPlan{Rate: 4, Years: 30, Paths: 10})
+			Expect(err).To(BeNil())
+			Expect(result.Historical.Simulations).To(Equal(121))
+			Expect(result.Historical.SuccessRate).To(BeZero())
+			Expect(result.MonteCarlo.Simulations).To(Equal(10))
+			Expect(result.MonteCarlo.SuccessRate).To(BeZero())
+		})
+
+		It("should report the terminal value in today's dollars", func() {
+			result, err := perf.SimulateWithdrawals(portfolio.WithdrawalPlan{Rate: 3, Years: 30, Paths: 10})
+			Expect(err).To(BeNil())
+			Expect(result.Historical.SuccessRate).To(Equal(1.0))
+			Expect(result.Historical.TerminalValues["p50"]).Should(BeNumerically("~", 1000, 1e-6))
+			Expect(result.Historical.MedianWithdrawn).Should(BeNumerically("~", 9000, 1e-6))
+			Expect(result.Historical.WorstStart).NotTo(BeNil())
+		})
+	})
+
+	Describe("When simulating other withdrawal modes", func() {
+		It("should never run out of money withdrawing a fixed percent", func() {
+			result, err := perf.SimulateWithdrawals(portfolio.WithdrawalPlan{Mode: portfolio.WithdrawalFixedPercent, Rate: 10, Years: 30, Paths: 10})
+			Expect(err).To(BeNil())
+			Expect(result.Historical.SuccessRate).To(Equal(1.0))
+			Expect(result.Historical.TerminalValues["p50"]).Should(BeNumerically(">", 0))
+		})
+
+		It("should spend the whole balance with variable percentage withdrawals", func() {
+			perf = constantReturns(40*12, 0.004)
+			result, err := perf.SimulateWithdrawals(portfolio.WithdrawalPlan{Mode: portfolio.WithdrawalVPW, Years: 30, Paths: 10})
+			Expect(err).To(BeNil())
+			Expect(result.Historical.SuccessRate).To(Equal(1.0))
+			Expect(result.Historical.TerminalValues["p95"]).Should(BeNumerically("<", 1))
+		})
+
+		It("should cut guardrail withdrawals to preserve capital", func() {
+			fixed, err := perf.SimulateWithdrawals(portfolio.WithdrawalPlan{Rate: 5, Years: 30, Paths: 10})
+			Expect(err).To(BeNil())
+			guardrails, err := perf.SimulateWithdrawals(portfolio.WithdrawalPlan{Mode: portfolio.WithdrawalGuardrails, Rate: 5, Years: 30, Paths: 10})
+			Expect(err).To(BeNil())
+			Expect(fixed.Historical.SuccessRate).To(BeZero())
+			Expect(guardrails.Historical.SuccessRate).To(Equal(1.0))
+		})
+	})
+
+	Describe("When simulating Monte Carlo paths", func() {
+		It("should be reproducible with a seed", func() {
+			perf = constantReturns(40*12, 0)
+			for ii := range perf.Measurements {
+				if ii > 0 {
+					perf.Measurements[ii].PercentReturn = 0.02 * float64(ii%5-2)
+				}
+			}
+			plan := portfolio.WithdrawalPlan{Years: 30, Paths: 50, Seed: 42}
+			first, err := perf.SimulateWithdrawals(plan)
+			Expect(err).To(BeNil())
+			second, err := perf.SimulateWithdrawals(plan)
+			Expect(err).To(BeNil())
+			Expect(second.MonteCarlo).To(Equal(first.MonteCarlo))
+		})
+	})
+})
@@ -0,0 +1,120 @@
+package preferences
+
+import (
+	"errors"
+	"fmt"
+	"main/benchmark"
+	"main/locale"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// DigestPortfolio send a separate notification for each portfolio
+	DigestPortfolio = "portfolio"
+
+	// DigestHousehold send a single digest combining all of the user's
+	// portfolios instead of per-portfolio notifications
+	DigestHousehold = "household"
+
+	// ChannelEmail deliver notifications by email
+	ChannelEmail = "email"
+)
+
+// NumberFormats supported number formats, written as the number 1234.56. An
+// empty number format uses the format of the user's locale.
+var NumberFormats = []string{"1,234.56", "1.234,56", "1 234,56", "1'234.56"}
+
+// Channels supported notification channels
+var Channels = []string{ChannelEmail}
+
+var currencyRegexp = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Preferences settings a user has chosen for display and notifications
+type Preferences struct {
+	UserID               string   `json:"-"`
+	BaseCurrency         string   `json:"baseCurrency"`
+	Digest               string   `json:"digest"`
+	NotificationChannels []string `json:"notificationChannels"`
+	DefaultBenchmark     string   `json:"defaultBenchmark"`
+	Timezone             string   `json:"timezone"`
+	Locale               string   `json:"locale"`
+	NumberFormat         string   `json:"numberFormat"`
+
+	// ShareStats opt in to contributing the metrics of the user's
+	// portfolios to the anonymized community statistics
+	ShareStats bool `json:"shareStats"`
+}
+
+// Default preferences used for users that have not saved any
+func Default() Preferences {
+	return Preferences{
+		BaseCurrency:         "USD",
+		Digest:               DigestPortfolio,
+		NotificationChannels: []string{ChannelEmail},
+		DefaultBenchmark:     benchmark.DefaultID,
+		Timezone:             "America/New_York",
+		Locale:               locale.DefaultTag,
+		NumberFormat:         "",
+	}
+}
+
+// Validate check that the preferences are well formed. Currency codes are
+// normalized to upper case.
+func (p *Preferences) Validate() error {
+	p.BaseCurrency = strings.ToUpper(p.BaseCurrency)
+	if !currencyRegexp.MatchString(p.BaseCurrency) {
+		return fmt.Errorf("base currency '%s' must be a 3 letter ISO 4217 code", p.BaseCurrency)
+	}
+
+	if p.Digest != DigestPortfolio && p.Digest != DigestHousehold {
+		return fmt.Errorf("digest must be '%s' or '%s'", DigestPortfolio, DigestHousehold)
+	}
+
+	if p.NotificationChannels == nil {
+		p.NotificationChannels = []string{}
+	}
+	for _, channel := range p.NotificationChannels {
+		if !contains(Channels, channel) {
+			return fmt.Errorf("unknown notification channel '%s'", channel)
+		}
+	}
+
+	if p.DefaultBenchmark == "" {
+		return errors.New("default benchmark is required")
+	}
+
+	if _, err := time.LoadLocation(p.Timezone); err != nil || p.Timezone == "" {
+		return fmt.Errorf("unknown timezone '%s'", p.Timezone)
+	}
+
+	if !locale.IsSupported(p.Locale) {
+		return fmt.Errorf("locale must be one of %s", strings.Join(locale.Supported(), ", "))
+	}
+
+	if p.NumberFormat != "" && !contains(NumberFormats, p.NumberFormat) {
+		return fmt.Errorf("number format must be one of %s", strings.Join(NumberFormats, ", "))
+	}
+
+	return nil
+}
+
+// Localization the user's locale with their preferred number format
+func (p *Preferences) Localization() *locale.Locale {
+	return locale.Get(p.Locale).WithNumberFormat(p.NumberFormat)
+}
+
+// Notify true if notifications should be sent on channel
+func (p *Preferences) Notify(channel string) bool {
+	return contains(p.NotificationChannels, channel)
+}
+
+func contains(list []string, val string) bool {
+	for _, item := range list {
+		if item == val {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,13 @@
+package preferences_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestPreferences(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Preferences Suite")
+}
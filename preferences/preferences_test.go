@@ -0,0 +1,79 @@
+package preferences_test
+
+import (
+	"main/preferences"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Preferences", func() {
+	var prefs preferences.Preferences
+
+	BeforeEach(func() {
+		prefs = preferences.Default()
+	})
+
+	Describe("When validating preferences", func() {
+		It("should accept the defaults", func() {
+			Expect(prefs.Validate()).To(Succeed())
+			Expect(prefs.Notify(preferences.ChannelEmail)).To(BeTrue())
+		})
+
+		It("should normalize the base currency", func() {
+			prefs.BaseCurrency = "eur"
+			Expect(prefs.Validate()).To(Succeed())
+			Expect(prefs.BaseCurrency).To(Equal("EUR"))
+		})
+
+		It("should reject malformed currencies", func() {
+			prefs.BaseCurrency = "EURO"
+			Expect(prefs.Validate()).To(MatchError("base currency 'EURO' must be a 3 letter ISO 4217 code"))
+		})
+
+		It("should reject unknown digests", func() {
+			prefs.Digest = "weekly"
+			Expect(prefs.Validate()).To(MatchError("digest must be 'portfolio' or 'household'"))
+		})
+
+		It("should reject unknown channels", func() {
+			prefs.NotificationChannels = []string{"email", "pager"}
+			Expect(prefs.Validate()).To(MatchError("unknown notification channel 'pager'"))
+		})
+
+		It("should allow disabling all channels", func() {
+			prefs.NotificationChannels = nil
+			Expect(prefs.Validate()).To(Succeed())
+			Expect(prefs.Notify(preferences.ChannelEmail)).To(BeFalse())
+		})
+
+		It("should reject unknown timezones", func() {
+			prefs.Timezone = "Mars/Olympus_Mons"
+			Expect(prefs.Validate()).To(MatchError("unknown timezone 'Mars/Olympus_Mons'"))
+
+			prefs.Timezone = ""
+			Expect(prefs.Validate()).NotTo(Succeed())
+		})
+
+		It("should reject unsupported locales", func() {
+			prefs.Locale = "fr-FR"
+			Expect(prefs.Validate()).To(MatchError("locale must be one of en-US, de-DE"))
+		})
+
+		It("should format with the locale unless a number format is chosen", func() {
+			prefs.Locale = "de-DE"
+			Expect(prefs.Validate()).To(Succeed())
+			Expect(prefs.Localization().FormatNumber(1234.5, 2)).To(Equal("1.234,50"))
+
+			prefs.NumberFormat = "1,234.56"
+			Expect(prefs.Validate()).To(Succeed())
+			Expect(prefs.Localization().FormatNumber(1234.5, 2)).To(Equal("1,234.50"))
+			Expect(prefs.Localization().T("Monthly")).To(Equal("Monatliche"))
+		})
+
+		It("should reject unknown number formats", func() {
+			prefs.NumberFormat = "1234.56"
+			Expect(prefs.Validate()).To(MatchError("number format must be one of 1,234.56, 1.234,56, 1 234,56, 1'234.56"))
+		})
+	})
+})
@@ -0,0 +1,174 @@
+package queue
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MemoryQueue an in-process queue for tests and running the API and a worker
+// in the same process
+type MemoryQueue struct {
+	mu    sync.Mutex
+	jobs  map[uuid.UUID]*Job
+	order []uuid.UUID
+
+	// Now returns the current time; defaults to time.Now
+	Now func() time.Time
+}
+
+// NewMemoryQueue create an empty in-process queue
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{
+		jobs: make(map[uuid.UUID]*Job),
+		Now:  time.Now,
+	}
+}
+
+// Enqueue add a job of kind with the JSON encoded payload
+func (q *MemoryQueue) Enqueue(kind string, userID string, payload interface{}) (*Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job := &Job{
+		ID:      uuid.New(),
+		Kind:    kind,
+		UserID:  userID,
+		Payload: data,
+		Status:  StatusQueued,
+		Created: q.Now(),
+	}
+	q.jobs[job.ID] = job
+	q.order = append(q.order, job.ID)
+
+	copied := *job
+	return &copied, nil
+}
+
+// Claim mark the oldest queued job as running on worker and return it
+func (q *MemoryQueue) Claim(worker string) (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, id := range q.order {
+		job := q.jobs[id]
+		if job.Status != StatusQueued {
+			continue
+		}
+		now := q.Now()
+		job.Status = StatusRunning
+		job.Attempts++
+		job.Worker = worker
+		job.Started = &now
+
+		copied := *job
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+// Complete store the JSON encoded result of a running job
+func (q *MemoryQueue) Complete(id uuid.UUID, result interface{}) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return q.finish(id, StatusSucceeded, data, "")
+}
+
+// Fail record why a running job failed
+func (q *MemoryQueue) Fail(id uuid.UUID, reason error) error {
+	return q.finish(id, StatusFailed, nil, reason.Error())
+}
+
+// Progress record the percent of a running job that is complete
+func (q *MemoryQueue) Progress(id uuid.UUID, percent float64, date time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.jobs[id]
+	if !ok || job.Status != StatusRunning {
+		return ErrNotFound
+	}
+	job.Progress = percent
+	job.ProgressDate = &date
+	return nil
+}
+
+func (q *MemoryQueue) finish(id uuid.UUID, status string, result json.RawMessage, reason string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.jobs[id]
+	if !ok || job.Status != StatusRunning {
+		return ErrNotFound
+	}
+	now := q.Now()
+	job.Status = status
+	job.Result = result
+	job.Error = reason
+	job.Finished = &now
+	return nil
+}
+
+// Requeue return jobs that have been running since before cutoff to the queue
+func (q *MemoryQueue) Requeue(cutoff time.Time) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	requeued := 0
+	for _, job := range q.jobs {
+		if job.Status != StatusRunning || !job.Started.Before(cutoff) {
+			continue
+		}
+		if job.Attempts >= MaxAttempts {
+			now := q.Now()
+			job.Status = StatusFailed
+			job.Error = ErrAbandoned.Error()
+			job.Finished = &now
+			continue
+		}
+		job.Status = StatusQueued
+		job.Worker = ""
+		job.Started = nil
+		job.Progress = 0
+		job.ProgressDate = nil
+		requeued++
+	}
+	return requeued, nil
+}
+
+// Usage the number of active jobs of userID and the time spent running its
+// jobs that finished since since
+func (q *MemoryQueue) Usage(userID string, since time.Time) (Usage, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	usage := Usage{}
+	for _, job := range q.jobs {
+		if job.UserID == userID {
+			usage.add(job.Status, job.Started, job.Finished, since)
+		}
+	}
+	return usage, nil
+}
+
+// Get retrieve a job
+func (q *MemoryQueue) Get(id uuid.UUID, userID string) (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.jobs[id]
+	if !ok || (userID != "" && job.UserID != userID) {
+		return nil, ErrNotFound
+	}
+	copied := *job
+	return &copied, nil
+}
@@ -0,0 +1,174 @@
+package queue_test
+
+import (
+	"errors"
+	"main/queue"
+	"time"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MemoryQueue", func() {
+	var (
+		q   *queue.MemoryQueue
+		now time.Time
+	)
+
+	BeforeEach(func() {
+		now = time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+		q = queue.NewMemoryQueue()
+		q.Now = func() time.Time { return now }
+	})
+
+	Describe("When claiming jobs", func() {
+		It("should claim jobs oldest first", func() {
+			first, err := q.Enqueue(queue.KindRunStrategy, "auth0|1", &queue.RunStrategy{Shortcode: "adm"})
+			Expect(err).NotTo(HaveOccurred())
+			now = now.Add(time.Second)
+			_, err = q.Enqueue(queue.KindRunStrategy, "auth0|1", &queue.RunStrategy{Shortcode: "daa"})
+			Expect(err).NotTo(HaveOccurred())
+
+			job, err := q.Claim("worker-1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.ID).To(Equal(first.ID))
+			Expect(job.Status).To(Equal(queue.StatusRunning))
+			Expect(job.Attempts).To(Equal(1))
+
+			req := queue.RunStrategy{}
+			Expect(job.Decode(&req)).To(Succeed())
+			Expect(req.Shortcode).To(Equal("adm"))
+		})
+
+		It("should claim each job once", func() {
+			_, err := q.Enqueue(queue.KindRunStrategy, "auth0|1", &queue.RunStrategy{})
+			Expect(err).NotTo(HaveOccurred())
+
+			job, err := q.Claim("worker-1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job).NotTo(BeNil())
+
+			job, err = q.Claim("worker-2")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job).To(BeNil())
+		})
+	})
+
+	Describe("When finishing jobs", func() {
+		It("should store the result of a completed job", func() {
+			enqueued, _ := q.Enqueue(queue.KindRunStrategy, "auth0|1", &queue.RunStrategy{})
+			q.Claim("worker-1")
+			Expect(q.Complete(enqueued.ID, map[string]float64{"ytdReturn": 0.1})).To(Succeed())
+
+			job, err := q.Get(enqueued.ID, "auth0|1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.Done()).To(BeTrue())
+			Expect(job.Status).To(Equal(queue.StatusSucceeded))
+			Expect(job.Result).To(MatchJSON(`{"ytdReturn": 0.1}`))
+		})
+
+		It("should record why a job failed", func() {
+			enqueued, _ := q.Enqueue(queue.KindRunStrategy, "auth0|1", &queue.RunStrategy{})
+			q.Claim("worker-1")
+			Expect(q.Fail(enqueued.ID, errors.New("no prices"))).To(Succeed())
+
+			job, err := q.Get(enqueued.ID, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.Status).To(Equal(queue.StatusFailed))
+			Expect(job.Error).To(Equal("no prices"))
+		})
+
+		It("should not finish a job that is not running", func() {
+			enqueued, _ := q.Enqueue(queue.KindRunStrategy, "auth0|1", &queue.RunStrategy{})
+			Expect(q.Complete(enqueued.ID, nil)).To(MatchError(queue.ErrNotFound))
+		})
+	})
+
+	Describe("When reporting progress", func() {
+		It("should record the progress of a running job", func() {
+			enqueued, _ := q.Enqueue(queue.KindRunStrategy, "auth0|1", &queue.RunStrategy{})
+			q.Claim("worker-1")
+			date := time.Date(2015, time.June, 30, 0, 0, 0, 0, time.UTC)
+			Expect(q.Progress(enqueued.ID, 42, date)).To(Succeed())
+
+			job, err := q.Get(enqueued.ID, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.Progress).To(Equal(42.0))
+			Expect(*job.ProgressDate).To(Equal(date))
+		})
+
+		It("should not record the progress of a queued job", func() {
+			enqueued, _ := q.Enqueue(queue.KindRunStrategy, "auth0|1", &queue.RunStrategy{})
+			Expect(q.Progress(enqueued.ID, 42, now)).To(MatchError(queue.ErrNotFound))
+		})
+	})
+
+	Describe("When getting jobs", func() {
+		It("should hide jobs of other users", func() {
+			enqueued, _ := q.Enqueue(queue.KindRunStrategy, "auth0|1", &queue.RunStrategy{})
+			_, err := q.Get(enqueued.ID, "auth0|2")
+			Expect(err).To(MatchError(queue.ErrNotFound))
+		})
+
+		It("should report unknown jobs", func() {
+			_, err := q.Get(uuid.New(), "")
+			Expect(err).To(MatchError(queue.ErrNotFound))
+		})
+	})
+
+	Describe("When requeueing stale jobs", func() {
+		It("should requeue jobs running since before the cutoff", func() {
+			enqueued, _ := q.Enqueue(queue.KindRunStrategy, "auth0|1", &queue.RunStrategy{})
+			q.Claim("worker-1")
+			q.Progress(enqueued.ID, 50, now)
+
+			n, err := q.Requeue(now.Add(-time.Minute))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(n).To(Equal(0))
+
+			n, err = q.Requeue(now.Add(time.Minute))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(n).To(Equal(1))
+
+			job, _ := q.Claim("worker-2")
+			Expect(job.ID).To(Equal(enqueued.ID))
+			Expect(job.Attempts).To(Equal(2))
+			Expect(job.Progress).To(Equal(0.0))
+			Expect(job.ProgressDate).To(BeNil())
+		})
+
+		It("should fail jobs abandoned too many times", func() {
+			enqueued, _ := q.Enqueue(queue.KindRunStrategy, "auth0|1", &queue.RunStrategy{})
+			for ii := 0; ii < queue.MaxAttempts; ii++ {
+				q.Claim("worker-1")
+				q.Requeue(now.Add(time.Minute))
+			}
+
+			job, _ := q.Get(enqueued.ID, "")
+			Expect(job.Status).To(Equal(queue.StatusFailed))
+			Expect(job.Error).To(Equal(queue.ErrAbandoned.Error()))
+		})
+	})
+
+	Describe("When reporting usage", func() {
+		It("should count active jobs and the time spent on finished jobs", func() {
+			done, _ := q.Enqueue(queue.KindRunStrategy, "auth0|1", &queue.RunStrategy{})
+			q.Claim("worker-1")
+			now = now.Add(90 * time.Second)
+			q.Complete(done.ID, "ok")
+
+			q.Enqueue(queue.KindRunStrategy, "auth0|1", &queue.RunStrategy{})
+			q.Enqueue(queue.KindRunStrategy, "auth0|2", &queue.RunStrategy{})
+
+			usage, err := q.Usage("auth0|1", now.Add(-time.Hour))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(usage.Active).To(Equal(1))
+			Expect(usage.Elapsed).To(Equal(90 * time.Second))
+
+			usage, err = q.Usage("auth0|1", now.Add(time.Second))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(usage.Elapsed).To(Equal(time.Duration(0)))
+		})
+	})
+})
@@ -0,0 +1,207 @@
+package queue
+
+import (
+	"database/sql"
+	"encoding/json"
+	"main/database"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresQueue stores jobs in the job table. Workers in any number of
+// processes claim jobs concurrently; a queued job is claimed by exactly one
+// of them.
+type PostgresQueue struct {
+	db *sqlx.DB
+
+	// Now returns the current time; defaults to time.Now
+	Now func() time.Time
+}
+
+// NewPostgresQueue create a queue stored in db
+func NewPostgresQueue(db *sqlx.DB) *PostgresQueue {
+	return &PostgresQueue{
+		db:  db,
+		Now: time.Now,
+	}
+}
+
+const jobColumns = `id, kind, userid, payload, status, result, error, attempts, worker, created, started, finished, progress, progress_date`
+
+func scanJob(row interface{ Scan(...interface{}) error }) (*Job, error) {
+	job := &Job{}
+	var payload, result []byte
+	var reason, worker sql.NullString
+	err := row.Scan(&job.ID, &job.Kind, &job.UserID, &payload, &job.Status, &result, &reason, &job.Attempts, &worker, &job.Created, &job.Started, &job.Finished, &job.Progress, &job.ProgressDate)
+	if err != nil {
+		return nil, err
+	}
+	job.Payload = payload
+	if len(result) > 0 {
+		job.Result = result
+	}
+	job.Error = reason.String
+	job.Worker = worker.String
+	return job, nil
+}
+
+// Enqueue add a job of kind with the JSON encoded payload
+func (q *PostgresQueue) Enqueue(kind string, userID string, payload interface{}) (*Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &Job{
+		ID:      uuid.New(),
+		Kind:    kind,
+		UserID:  userID,
+		Payload: data,
+		Status:  StatusQueued,
+		Created: q.Now().UTC(),
+	}
+	_, err = q.db.Exec(`INSERT INTO job ("id", "kind", "userid", "payload", "status", "created") VALUES ($1, $2, $3, $4, $5, $6)`,
+		job.ID, job.Kind, job.UserID, string(job.Payload), job.Status, job.Created)
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Claim mark the oldest queued job as running on worker and return it. Jobs
+// locked by workers claiming concurrently are skipped.
+func (q *PostgresQueue) Claim(worker string) (*Job, error) {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	var id uuid.UUID
+	err = tx.QueryRow(`SELECT id FROM job WHERE status=$1 ORDER BY created LIMIT 1`+database.Current.SkipLocked, StatusQueued).Scan(&id)
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		return nil, nil
+	}
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	_, err = tx.Exec(`UPDATE job SET status=$1, attempts=attempts+1, worker=$2, started=$3 WHERE id=$4`,
+		StatusRunning, worker, q.Now().UTC(), id)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	job, err := scanJob(tx.QueryRow(`SELECT `+jobColumns+` FROM job WHERE id=$1`, id))
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return job, tx.Commit()
+}
+
+// Complete store the JSON encoded result of a running job
+func (q *PostgresQueue) Complete(id uuid.UUID, result interface{}) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return q.finish(id, StatusSucceeded, data, nil)
+}
+
+// Fail record why a running job failed
+func (q *PostgresQueue) Fail(id uuid.UUID, reason error) error {
+	msg := reason.Error()
+	return q.finish(id, StatusFailed, nil, &msg)
+}
+
+// Progress record the percent of a running job that is complete
+func (q *PostgresQueue) Progress(id uuid.UUID, percent float64, date time.Time) error {
+	res, err := q.db.Exec(`UPDATE job SET progress=$1, progress_date=$2 WHERE id=$3 AND status=$4`,
+		percent, date.UTC(), id, StatusRunning)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (q *PostgresQueue) finish(id uuid.UUID, status string, result []byte, reason *string) error {
+	var resultText *string
+	if result != nil {
+		text := string(result)
+		resultText = &text
+	}
+
+	res, err := q.db.Exec(`UPDATE job SET status=$1, result=$2, error=$3, finished=$4 WHERE id=$5 AND status=$6`,
+		status, resultText, reason, q.Now().UTC(), id, StatusRunning)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return err
+}
+
+// Requeue return jobs that have been running since before cutoff to the
+// queue, failing those already claimed MaxAttempts times
+func (q *PostgresQueue) Requeue(cutoff time.Time) (int, error) {
+	cutoff = cutoff.UTC()
+	_, err := q.db.Exec(`UPDATE job SET status=$1, error=$2, finished=$3 WHERE status=$4 AND started < $5 AND attempts >= $6`,
+		StatusFailed, ErrAbandoned.Error(), q.Now().UTC(), StatusRunning, cutoff, MaxAttempts)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := q.db.Exec(`UPDATE job SET status=$1, worker=NULL, started=NULL, progress=0, progress_date=NULL WHERE status=$2 AND started < $3`,
+		StatusQueued, StatusRunning, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// Get retrieve a job
+func (q *PostgresQueue) Get(id uuid.UUID, userID string) (*Job, error) {
+	job, err := scanJob(q.db.QueryRow(`SELECT `+jobColumns+` FROM job WHERE id=$1`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if userID != "" && job.UserID != userID {
+		return nil, ErrNotFound
+	}
+	return job, nil
+}
+
+// Usage the number of active jobs of userID and the time spent running its
+// jobs that finished since since
+func (q *PostgresQueue) Usage(userID string, since time.Time) (Usage, error) {
+	rows, err := q.db.Query(`SELECT status, started, finished FROM job WHERE userid=$1 AND (status IN ($2, $3) OR finished >= $4)`,
+		userID, StatusQueued, StatusRunning, since.UTC())
+	if err != nil {
+		return Usage{}, err
+	}
+	defer rows.Close()
+
+	usage := Usage{}
+	for rows.Next() {
+		var status string
+		var started, finished *time.Time
+		if err := rows.Scan(&status, &started, &finished); err != nil {
+			return Usage{}, err
+		}
+		usage.add(status, started, finished, since)
+	}
+	return usage, rows.Err()
+}
@@ -0,0 +1,156 @@
+// Package queue is a work queue of strategy computations. The API and the
+// notifier enqueue jobs; cmd/worker claims and runs them so backtests scale
+// independently of the web tier and a panic in a computation cannot take
+// down the process that requested it.
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"main/portfolio"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Kinds of jobs
+const (
+	// KindRunStrategy backtest a strategy; the payload is a RunStrategy and
+	// the result a portfolio.Performance
+	KindRunStrategy = "strategy.run"
+
+	// KindComputePortfolio compute a saved portfolio through a date; the
+	// payload is a ComputePortfolio and the result a ComputedPortfolio
+	KindComputePortfolio = "portfolio.compute"
+)
+
+// Job statuses
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// MaxAttempts number of times a job is claimed before it is failed; jobs are
+// only claimed again when the worker running them stops responding
+const MaxAttempts = 3
+
+// ErrNotFound the job does not exist or belongs to another user
+var ErrNotFound = errors.New("job not found")
+
+// ErrAbandoned reason recorded for jobs whose worker stopped responding
+// MaxAttempts times
+var ErrAbandoned = errors.New("job abandoned by its workers")
+
+// Job a unit of work in the queue. UserID is the user who requested the job,
+// or empty for jobs enqueued by the notifier.
+type Job struct {
+	ID       uuid.UUID       `json:"id"`
+	Kind     string          `json:"kind"`
+	UserID   string          `json:"-"`
+	Payload  json.RawMessage `json:"-"`
+	Status   string          `json:"status"`
+	Result   json.RawMessage `json:"result,omitempty"`
+	Error    string          `json:"error,omitempty"`
+	Attempts int             `json:"attempts"`
+	Worker   string          `json:"-"`
+	Created  time.Time       `json:"created"`
+	Started  *time.Time      `json:"started,omitempty"`
+	Finished *time.Time      `json:"finished,omitempty"`
+
+	// Progress percent of a running job that is complete and the date its
+	// simulation has reached, as reported by the job's handler
+	Progress     float64    `json:"progress"`
+	ProgressDate *time.Time `json:"progressDate,omitempty"`
+}
+
+// Done whether the job succeeded or failed
+func (job *Job) Done() bool {
+	return job.Status == StatusSucceeded || job.Status == StatusFailed
+}
+
+// Decode unmarshal the job's payload into v
+func (job *Job) Decode(v interface{}) error {
+	return json.Unmarshal(job.Payload, v)
+}
+
+// Queue stores jobs until a worker claims them
+type Queue interface {
+	// Enqueue add a job of kind with the JSON encoded payload
+	Enqueue(kind string, userID string, payload interface{}) (*Job, error)
+
+	// Claim mark the oldest queued job as running on worker and return it;
+	// returns nil if no job is queued
+	Claim(worker string) (*Job, error)
+
+	// Complete store the JSON encoded result of a running job
+	Complete(id uuid.UUID, result interface{}) error
+
+	// Fail record why a running job failed
+	Fail(id uuid.UUID, reason error) error
+
+	// Progress record the percent of a running job that is complete and the
+	// date its simulation has reached
+	Progress(id uuid.UUID, percent float64, date time.Time) error
+
+	// Requeue return jobs that have been running since before cutoff to the
+	// queue, failing those already claimed MaxAttempts times. Returns the
+	// number of jobs requeued.
+	Requeue(cutoff time.Time) (int, error)
+
+	// Get retrieve a job; if userID is not empty the job must belong to the
+	// user. Returns ErrNotFound if it does not exist.
+	Get(id uuid.UUID, userID string) (*Job, error)
+
+	// Usage the number of queued and running jobs of userID and the time
+	// spent running its jobs that finished since since
+	Usage(userID string, since time.Time) (Usage, error)
+}
+
+// Usage the compute a user's jobs are consuming
+type Usage struct {
+	Active  int
+	Elapsed time.Duration
+}
+
+// add count job towards the usage of jobs that finished since since
+func (u *Usage) add(status string, started *time.Time, finished *time.Time, since time.Time) {
+	switch {
+	case status == StatusQueued || status == StatusRunning:
+		u.Active++
+	case started != nil && finished != nil && !finished.Before(since):
+		u.Elapsed += finished.Sub(*started)
+	}
+}
+
+// RunStrategy payload of KindRunStrategy; the arguments accepted by
+// POST /v1/strategy/:id. Prices are downloaded with the tiingo token of the
+// user who enqueued the job.
+type RunStrategy struct {
+	Shortcode   string          `json:"shortcode"`
+	Arguments   json.RawMessage `json:"arguments"`
+	StartDate   time.Time       `json:"startDate"`
+	EndDate     time.Time       `json:"endDate"`
+	Resolution  string          `json:"resolution"`
+	AccountType string          `json:"accountType,omitempty"`
+
+	// CheckHistory whether the tickers must have history before StartDate
+	CheckHistory bool `json:"checkHistory"`
+}
+
+// ComputePortfolio payload of KindComputePortfolio
+type ComputePortfolio struct {
+	PortfolioID uuid.UUID `json:"portfolioId"`
+	UserID      string    `json:"userId"`
+	Through     time.Time `json:"through"`
+}
+
+// ComputedPortfolio result of KindComputePortfolio: the transactions and
+// signals of the computed portfolio, from which the notifier rebuilds it, and
+// its performance
+type ComputedPortfolio struct {
+	Transactions []portfolio.Transaction `json:"transactions"`
+	Signals      []portfolio.Signal      `json:"signals"`
+	Performance  portfolio.Performance   `json:"performance"`
+}
@@ -0,0 +1,205 @@
+// Package rebalance confirms the orders suggested for a portfolio before
+// they are recorded. Orders are created pending; the user confirms them,
+// recording them as executed transactions, or cancels them. Pending orders
+// expire once prices for the next trading day are available.
+package rebalance
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"main/notification"
+	"main/portfolio"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Statuses of a batch of orders
+const (
+	StatusPending   = "pending"
+	StatusConfirmed = "confirmed"
+	StatusCancelled = "cancelled"
+	StatusExpired   = "expired"
+)
+
+var (
+	// ErrNotPending the orders were already confirmed, cancelled, or
+	// expired
+	ErrNotPending = errors.New("orders are no longer pending")
+
+	// ErrNoSecret ORDER_CONFIRMATION_SECRET is not set
+	ErrNoSecret = errors.New("order confirmation secret is not configured")
+)
+
+// Batch orders suggested for a portfolio on a date that are confirmed or
+// cancelled together
+type Batch struct {
+	ID          uuid.UUID         `json:"id"`
+	PortfolioID uuid.UUID         `json:"portfolioId"`
+	UserID      string            `json:"-"`
+	Status      string            `json:"status"`
+	SignalDate  int64             `json:"signalDate"`
+	Orders      []portfolio.Order `json:"orders"`
+	Created     int64             `json:"created"`
+
+	// Expires when pending orders expire
+	Expires int64 `json:"expires"`
+
+	// Resolved when the orders were confirmed, cancelled, or expired
+	Resolved int64 `json:"resolved,omitempty"`
+}
+
+// Fill the actual execution of an order. Zero shares or price use the
+// order's; the ticker must be one of the batch's orders.
+type Fill struct {
+	Ticker string  `json:"ticker"`
+	Shares float64 `json:"shares"`
+	Price  float64 `json:"price"`
+	Fees   float64 `json:"fees"`
+}
+
+// NewBatch a pending batch of orders created at now, expiring when prices
+// for the next trading day in tz are available
+func NewBatch(portfolioID uuid.UUID, userID string, orders *portfolio.OrderList, now time.Time, tz *time.Location) *Batch {
+	return &Batch{
+		ID:          uuid.New(),
+		PortfolioID: portfolioID,
+		UserID:      userID,
+		Status:      StatusPending,
+		SignalDate:  orders.SignalDate.Unix(),
+		Orders:      orders.Orders,
+		Created:     now.Unix(),
+		Expires:     notification.NextPricesAvailable(now, tz).Unix(),
+	}
+}
+
+// Expire mark a pending batch expired if now is past its expiry. Returns
+// true if the status changed.
+func (b *Batch) Expire(now time.Time) bool {
+	if b.Status != StatusPending || now.Unix() < b.Expires {
+		return false
+	}
+	b.Status = StatusExpired
+	b.Resolved = b.Expires
+	return true
+}
+
+// Confirm mark the batch confirmed and return its orders as transactions
+// traded on tradeDate, using the shares, price, and fees of fills where
+// given
+func (b *Batch) Confirm(now time.Time, tradeDate time.Time, fills []Fill) ([]portfolio.Transaction, error) {
+	b.Expire(now)
+	if b.Status != StatusPending {
+		return nil, ErrNotPending
+	}
+
+	byTicker := make(map[string]Fill, len(fills))
+	for _, fill := range fills {
+		ticker := strings.ToUpper(fill.Ticker)
+		if fill.Shares < 0 || fill.Price < 0 || fill.Fees < 0 {
+			return nil, fmt.Errorf("fill of %s must not be negative", ticker)
+		}
+		byTicker[ticker] = fill
+	}
+
+	trxs := make([]portfolio.Transaction, 0, len(b.Orders))
+	for _, order := range b.Orders {
+		fill, ok := byTicker[order.Ticker]
+		delete(byTicker, order.Ticker)
+		shares, price := order.Shares, order.EstimatedPrice
+		if ok && fill.Shares > 0 {
+			shares = fill.Shares
+		}
+		if ok && fill.Price > 0 {
+			price = fill.Price
+		}
+
+		kind := portfolio.BuyTransaction
+		if order.Side == portfolio.OrderSell {
+			kind = portfolio.SellTransaction
+		}
+		trxs = append(trxs, portfolio.Transaction{
+			Date:          tradeDate,
+			Ticker:        order.Ticker,
+			Kind:          kind,
+			PricePerShare: price,
+			Shares:        shares,
+			TotalValue:    shares * price,
+			Fees:          fill.Fees,
+		})
+	}
+	for ticker := range byTicker {
+		return nil, fmt.Errorf("%s is not one of the orders", ticker)
+	}
+
+	b.Status = StatusConfirmed
+	b.Resolved = now.Unix()
+	return trxs, nil
+}
+
+// Cancel mark a pending batch cancelled
+func (b *Batch) Cancel(now time.Time) error {
+	b.Expire(now)
+	if b.Status != StatusPending {
+		return ErrNotPending
+	}
+	b.Status = StatusCancelled
+	b.Resolved = now.Unix()
+	return nil
+}
+
+// Token sign the ID of the batch with secret so it can be embedded in a link
+// and confirmed without the user logging in
+func (b *Batch) Token(secret string) (string, error) {
+	if secret == "" {
+		return "", ErrNoSecret
+	}
+	payload := base64.RawURLEncoding.EncodeToString([]byte(b.ID.String()))
+	return payload + "." + sign(payload, secret), nil
+}
+
+// URL link to base that authorizes the batch's confirmation
+func (b *Batch) URL(base string, secret string) (string, error) {
+	token, err := b.Token(secret)
+	if err != nil {
+		return "", err
+	}
+	return base + "?token=" + url.QueryEscape(token), nil
+}
+
+// ParseToken verify the signature of a confirmation token and return the ID
+// of the batch it authorizes
+func ParseToken(token string, secret string) (uuid.UUID, error) {
+	if secret == "" {
+		return uuid.Nil, ErrNoSecret
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return uuid.Nil, errors.New("malformed confirmation token")
+	}
+	if !hmac.Equal([]byte(parts[1]), []byte(sign(parts[0], secret))) {
+		return uuid.Nil, errors.New("invalid confirmation token signature")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return uuid.Nil, errors.New("malformed confirmation token")
+	}
+	id, err := uuid.Parse(string(payload))
+	if err != nil {
+		return uuid.Nil, errors.New("malformed confirmation token")
+	}
+	return id, nil
+}
+
+func sign(payload string, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
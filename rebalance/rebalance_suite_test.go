@@ -0,0 +1,13 @@
+package rebalance_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestRebalance(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Rebalance Suite")
+}
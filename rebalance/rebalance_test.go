@@ -0,0 +1,115 @@
+package rebalance_test
+
+import (
+	"main/portfolio"
+	"main/rebalance"
+	"time"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Rebalance", func() {
+	var (
+		batch     *rebalance.Batch
+		tz        *time.Location
+		created   time.Time
+		tradeDate time.Time
+	)
+
+	BeforeEach(func() {
+		var err error
+		tz, err = time.LoadLocation("America/New_York")
+		Expect(err).To(BeNil())
+
+		// a Friday evening, after prices for the day are available
+		created = time.Date(2021, time.January, 29, 19, 0, 0, 0, tz)
+		tradeDate = time.Date(2021, time.January, 29, 0, 0, 0, 0, time.UTC)
+
+		orders := portfolio.OrderList{
+			SignalDate: tradeDate,
+			Orders: []portfolio.Order{
+				{Ticker: "VWO", Side: portfolio.OrderSell, Shares: 100, EstimatedPrice: 50, EstimatedValue: 5000},
+				{Ticker: "VOO", Side: portfolio.OrderBuy, Shares: 20, EstimatedPrice: 300, EstimatedValue: 6000},
+			},
+		}
+		batch = rebalance.NewBatch(uuid.New(), "user", &orders, created, tz)
+	})
+
+	Describe("When creating a batch", func() {
+		It("should be pending until prices for the next trading day are available", func() {
+			Expect(batch.Status).To(Equal(rebalance.StatusPending))
+			Expect(time.Unix(batch.Expires, 0).In(tz)).To(Equal(time.Date(2021, time.February, 1, 18, 0, 0, 0, tz)))
+
+			Expect(batch.Expire(created.Add(time.Hour))).To(BeFalse())
+			Expect(batch.Expire(time.Date(2021, time.February, 1, 18, 0, 0, 0, tz))).To(BeTrue())
+			Expect(batch.Status).To(Equal(rebalance.StatusExpired))
+		})
+	})
+
+	Describe("When confirming a batch", func() {
+		It("should record the orders as transactions", func() {
+			trxs, err := batch.Confirm(created, tradeDate, nil)
+			Expect(err).To(BeNil())
+			Expect(batch.Status).To(Equal(rebalance.StatusConfirmed))
+			Expect(trxs).To(HaveLen(2))
+			Expect(trxs[0].Kind).To(Equal(portfolio.SellTransaction))
+			Expect(trxs[0].TotalValue).Should(BeNumerically("~", 5000.0, 1e-9))
+			Expect(trxs[1].Kind).To(Equal(portfolio.BuyTransaction))
+			Expect(trxs[1].Date).To(Equal(tradeDate))
+		})
+
+		It("should use the actual fills", func() {
+			trxs, err := batch.Confirm(created, tradeDate, []rebalance.Fill{{Ticker: "voo", Shares: 19, Price: 301, Fees: 1}})
+			Expect(err).To(BeNil())
+			Expect(trxs[1].Shares).To(Equal(19.0))
+			Expect(trxs[1].PricePerShare).To(Equal(301.0))
+			Expect(trxs[1].TotalValue).Should(BeNumerically("~", 5719.0, 1e-9))
+			Expect(trxs[1].Fees).To(Equal(1.0))
+			Expect(trxs[0].Shares).To(Equal(100.0))
+		})
+
+		It("should reject fills of other securities", func() {
+			_, err := batch.Confirm(created, tradeDate, []rebalance.Fill{{Ticker: "SPY", Shares: 1}})
+			Expect(err).To(HaveOccurred())
+			Expect(batch.Status).To(Equal(rebalance.StatusPending))
+		})
+
+		It("should not confirm expired or cancelled batches", func() {
+			_, err := batch.Confirm(created.AddDate(0, 0, 7), tradeDate, nil)
+			Expect(err).To(Equal(rebalance.ErrNotPending))
+			Expect(batch.Status).To(Equal(rebalance.StatusExpired))
+
+			batch.Status = rebalance.StatusPending
+			Expect(batch.Cancel(created)).To(Succeed())
+			Expect(batch.Cancel(created)).To(Equal(rebalance.ErrNotPending))
+			_, err = batch.Confirm(created, tradeDate, nil)
+			Expect(err).To(Equal(rebalance.ErrNotPending))
+		})
+	})
+
+	Describe("When signing confirmation links", func() {
+		It("should round trip the batch ID", func() {
+			link, err := batch.URL("https://api.example.com/v1/orders", "secret")
+			Expect(err).To(BeNil())
+			Expect(link).To(HavePrefix("https://api.example.com/v1/orders?token="))
+
+			token, err := batch.Token("secret")
+			Expect(err).To(BeNil())
+			id, err := rebalance.ParseToken(token, "secret")
+			Expect(err).To(BeNil())
+			Expect(id).To(Equal(batch.ID))
+		})
+
+		It("should reject tokens signed with another secret", func() {
+			token, err := batch.Token("secret")
+			Expect(err).To(BeNil())
+			_, err = rebalance.ParseToken(token, "other")
+			Expect(err).To(HaveOccurred())
+
+			_, err = batch.Token("")
+			Expect(err).To(Equal(rebalance.ErrNoSecret))
+		})
+	})
+})
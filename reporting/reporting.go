@@ -0,0 +1,176 @@
+// Package reporting sends errors that need a developer's attention, such as
+// panics in strategy computations, to an error tracker with their stack
+// trace and the user and portfolio they happened to. Reports are
+// discarded unless a tracker is configured with Configure.
+package reporting
+
+import (
+	"errors"
+	"main/util"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Context what the reported error happened to. Empty fields are omitted.
+type Context struct {
+	UserID      string
+	PortfolioID string
+	Strategy    string
+
+	// Stage the part of the system that failed, e.g. http, compute, or job
+	Stage string
+
+	// Tags additional searchable values, e.g. the route or job kind
+	Tags map[string]string
+}
+
+// Frame a function call of a stack trace
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"filename"`
+	Line     int    `json:"lineno"`
+}
+
+// Reporter sends errors to an error tracker
+type Reporter interface {
+	// Report send err with its context and stack trace, outermost call first
+	Report(err error, ctx Context, stack []Frame)
+
+	// Flush wait until sent reports are delivered or timeout expires;
+	// returns false if reports are still pending
+	Flush(timeout time.Duration) bool
+}
+
+// discard a reporter that drops every report
+type discard struct{}
+
+func (discard) Report(err error, ctx Context, stack []Frame) {}
+
+func (discard) Flush(timeout time.Duration) bool { return true }
+
+var (
+	mu       sync.RWMutex
+	reporter Reporter = discard{}
+)
+
+// SetReporter replace the reporter errors are sent to; nil discards reports
+func SetReporter(r Reporter) {
+	if r == nil {
+		r = discard{}
+	}
+	mu.Lock()
+	reporter = r
+	mu.Unlock()
+}
+
+// Configure report errors to Sentry if SENTRY_DSN is set
+func Configure() error {
+	dsn := os.Getenv("SENTRY_DSN")
+	if dsn == "" {
+		return nil
+	}
+	environment, ok := os.LookupEnv("EXECUTION_ENVIRONMENT")
+	if !ok {
+		environment = "test"
+	}
+	sentry, err := NewSentry(dsn, environment, util.Version)
+	if err != nil {
+		return err
+	}
+	SetReporter(sentry)
+	return nil
+}
+
+// Report send err to the configured reporter. The stack trace of a
+// util.PanicError is the stack of the goroutine that panicked; other errors
+// are reported with the stack of the caller.
+func Report(err error, ctx Context) {
+	if err == nil {
+		return
+	}
+
+	var stack []Frame
+	var pe *util.PanicError
+	if errors.As(err, &pe) {
+		stack = ParseStack(pe.Stack)
+		if ctx.Stage == "" {
+			ctx.Stage = pe.Stage
+		}
+	} else {
+		stack = callers(1)
+	}
+
+	mu.RLock()
+	r := reporter
+	mu.RUnlock()
+	r.Report(err, ctx, stack)
+}
+
+// Flush wait for reports to be delivered, e.g. before the process exits
+func Flush(timeout time.Duration) bool {
+	mu.RLock()
+	r := reporter
+	mu.RUnlock()
+	return r.Flush(timeout)
+}
+
+// callers the stack starting skip frames above the caller of callers,
+// outermost call first
+func callers(skip int) []Frame {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip+2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	stack := []Frame{}
+	for {
+		f, more := frames.Next()
+		stack = append(stack, Frame{Function: f.Function, File: f.File, Line: f.Line})
+		if !more {
+			break
+		}
+	}
+	reverse(stack)
+	return stack
+}
+
+// ParseStack read the frames of a stack trace formatted by debug.Stack,
+// outermost call first
+func ParseStack(trace string) []Frame {
+	lines := strings.Split(trace, "\n")
+	stack := []Frame{}
+	for ii := 1; ii+1 < len(lines); ii++ {
+		function := lines[ii]
+		location := strings.TrimSpace(lines[ii+1])
+		if function == "" || strings.HasPrefix(function, "\t") || !strings.HasPrefix(lines[ii+1], "\t") {
+			continue
+		}
+		ii++
+
+		// main/strategies.(*Adm).Compute(0xc000...)
+		if paren := strings.LastIndex(function, "("); paren > 0 {
+			function = function[:paren]
+		}
+		// /app/strategies/adm.go:154 +0x1d
+		if space := strings.Index(location, " "); space > 0 {
+			location = location[:space]
+		}
+		frame := Frame{Function: function, File: location}
+		if colon := strings.LastIndex(location, ":"); colon > 0 {
+			frame.File = location[:colon]
+			frame.Line, _ = strconv.Atoi(location[colon+1:])
+		}
+		stack = append(stack, frame)
+	}
+	reverse(stack)
+	return stack
+}
+
+func reverse(stack []Frame) {
+	for ii, jj := 0, len(stack)-1; ii < jj; ii, jj = ii+1, jj-1 {
+		stack[ii], stack[jj] = stack[jj], stack[ii]
+	}
+}
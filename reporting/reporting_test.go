@@ -0,0 +1,149 @@
+package reporting_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"main/reporting"
+	"main/util"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type recorder struct {
+	errs   []error
+	ctxs   []reporting.Context
+	stacks [][]reporting.Frame
+}
+
+func (r *recorder) Report(err error, ctx reporting.Context, stack []reporting.Frame) {
+	r.errs = append(r.errs, err)
+	r.ctxs = append(r.ctxs, ctx)
+	r.stacks = append(r.stacks, stack)
+}
+
+func (r *recorder) Flush(timeout time.Duration) bool { return true }
+
+func computePanics() error {
+	var values []float64
+	_ = values[3]
+	return nil
+}
+
+var _ = Describe("Reporting", func() {
+	var (
+		rec *recorder
+	)
+
+	BeforeEach(func() {
+		rec = &recorder{}
+		reporting.SetReporter(rec)
+	})
+
+	AfterEach(func() {
+		reporting.SetReporter(nil)
+	})
+
+	Describe("When an error is reported", func() {
+		It("should include the stack of the caller", func() {
+			reporting.Report(errors.New("save failed"), reporting.Context{UserID: "user"})
+			Expect(rec.errs).To(HaveLen(1))
+			Expect(rec.ctxs[0].UserID).To(Equal("user"))
+			stack := rec.stacks[0]
+			Expect(stack).ToNot(BeEmpty())
+			Expect(stack[len(stack)-1].Function).To(ContainSubstring("reporting_test"))
+			Expect(stack[len(stack)-1].File).To(HaveSuffix("reporting_test.go"))
+		})
+
+		It("should ignore nil errors", func() {
+			reporting.Report(nil, reporting.Context{})
+			Expect(rec.errs).To(BeEmpty())
+		})
+	})
+
+	Describe("When a panic is reported", func() {
+		It("should include the stack of the panic", func() {
+			err := util.Recover("compute", computePanics)
+			Expect(err).To(HaveOccurred())
+			reporting.Report(err, reporting.Context{PortfolioID: "portfolio"})
+
+			Expect(rec.ctxs[0].Stage).To(Equal("compute"))
+			Expect(rec.ctxs[0].PortfolioID).To(Equal("portfolio"))
+			functions := []string{}
+			for _, frame := range rec.stacks[0] {
+				functions = append(functions, frame.Function)
+			}
+			Expect(functions).To(ContainElement("main/reporting_test.computePanics"))
+		})
+	})
+
+	Describe("When a stack trace is parsed", func() {
+		It("should list frames outermost first", func() {
+			trace := "goroutine 1 [running]:\n" +
+				"main/strategies.(*AcceleratingDualMomentum).Compute(0xc000196000, 0x1)\n" +
+				"\t/app/strategies/adm.go:154 +0x1d\n" +
+				"main.main()\n" +
+				"\t/app/cmd/pvapi/main.go:12 +0x25\n"
+			stack := reporting.ParseStack(trace)
+			Expect(stack).To(Equal([]reporting.Frame{
+				{Function: "main.main", File: "/app/cmd/pvapi/main.go", Line: 12},
+				{Function: "main/strategies.(*AcceleratingDualMomentum).Compute", File: "/app/strategies/adm.go", Line: 154},
+			}))
+		})
+	})
+
+	Describe("When errors are sent to Sentry", func() {
+		var (
+			server *httptest.Server
+			events chan map[string]interface{}
+			auth   chan string
+		)
+
+		BeforeEach(func() {
+			events = make(chan map[string]interface{}, 1)
+			auth = make(chan string, 1)
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Path).To(Equal("/api/42/store/"))
+				body, _ := ioutil.ReadAll(r.Body)
+				event := map[string]interface{}{}
+				Expect(json.Unmarshal(body, &event)).To(Succeed())
+				auth <- r.Header.Get("X-Sentry-Auth")
+				events <- event
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should post the error with its context", func() {
+			sentry, err := reporting.NewSentry("http://secret@"+server.Listener.Addr().String()+"/42", "test", "1.0.0")
+			Expect(err).NotTo(HaveOccurred())
+			sentry.Report(errors.New("index out of range"), reporting.Context{
+				UserID:      "user",
+				PortfolioID: "portfolio",
+				Tags:        map[string]string{"route": "/v1/strategy/:id/run"},
+			}, []reporting.Frame{{Function: "main.main", File: "main.go", Line: 3}})
+			Expect(sentry.Flush(5 * time.Second)).To(BeTrue())
+
+			Expect(<-auth).To(ContainSubstring("sentry_key=secret"))
+			event := <-events
+			Expect(event["environment"]).To(Equal("test"))
+			Expect(event["release"]).To(Equal("1.0.0"))
+			Expect(event["user"]).To(Equal(map[string]interface{}{"id": "user"}))
+			Expect(event["tags"]).To(HaveKeyWithValue("portfolio", "portfolio"))
+			Expect(event["tags"]).To(HaveKeyWithValue("route", "/v1/strategy/:id/run"))
+			exception := event["exception"].(map[string]interface{})["values"].([]interface{})[0].(map[string]interface{})
+			Expect(exception["value"]).To(Equal("index out of range"))
+		})
+
+		It("should reject a DSN without a key or project", func() {
+			_, err := reporting.NewSentry("https://sentry.io/", "test", "1.0.0")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,176 @@
+package reporting
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// sentryBuffer reports queued for delivery; reports made while the queue is
+// full are dropped
+const sentryBuffer = 100
+
+// Sentry a reporter that sends errors to the Sentry project of a DSN. Reports
+// are delivered in the background so reporting never blocks a request.
+type Sentry struct {
+	endpoint    string
+	auth        string
+	environment string
+	release     string
+	serverName  string
+	client      *http.Client
+
+	events  chan *sentryEvent
+	pending sync.WaitGroup
+}
+
+type sentryFrames struct {
+	Frames []Frame `json:"frames"`
+}
+
+type sentryException struct {
+	Type       string       `json:"type"`
+	Value      string       `json:"value"`
+	Stacktrace sentryFrames `json:"stacktrace"`
+}
+
+type sentryUser struct {
+	ID string `json:"id"`
+}
+
+type sentryEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	Release     string            `json:"release,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	ServerName  string            `json:"server_name,omitempty"`
+	User        *sentryUser       `json:"user,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Exception   struct {
+		Values []sentryException `json:"values"`
+	} `json:"exception"`
+}
+
+// NewSentry create a reporter for the project of dsn, e.g.
+// https://<key>@o0.ingest.sentry.io/<project>
+func NewSentry(dsn string, environment string, release string) (*Sentry, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, err
+	}
+	project := strings.Trim(u.Path, "/")
+	if u.User == nil || u.User.Username() == "" || project == "" {
+		return nil, fmt.Errorf("invalid sentry DSN '%s'", dsn)
+	}
+
+	hostname, _ := os.Hostname()
+	s := &Sentry{
+		endpoint:    fmt.Sprintf("%s://%s/api/%s/store/", u.Scheme, u.Host, project),
+		auth:        fmt.Sprintf("Sentry sentry_version=7, sentry_client=pv-api/%s, sentry_key=%s", release, u.User.Username()),
+		environment: environment,
+		release:     release,
+		serverName:  hostname,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		events:      make(chan *sentryEvent, sentryBuffer),
+	}
+	go s.run()
+	return s, nil
+}
+
+// Report queue err to be sent to Sentry
+func (s *Sentry) Report(err error, ctx Context, stack []Frame) {
+	e := &sentryEvent{
+		EventID:     strings.ReplaceAll(uuid.New().String(), "-", ""),
+		Timestamp:   time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+		Level:       "error",
+		Platform:    "go",
+		Release:     s.release,
+		Environment: s.environment,
+		ServerName:  s.serverName,
+		Tags:        map[string]string{},
+	}
+	if ctx.UserID != "" {
+		e.User = &sentryUser{ID: ctx.UserID}
+	}
+	for key, val := range ctx.Tags {
+		e.Tags[key] = val
+	}
+	for key, val := range map[string]string{"portfolio": ctx.PortfolioID, "strategy": ctx.Strategy, "stage": ctx.Stage} {
+		if val != "" {
+			e.Tags[key] = val
+		}
+	}
+	e.Exception.Values = []sentryException{{
+		Type:       fmt.Sprintf("%T", err),
+		Value:      err.Error(),
+		Stacktrace: sentryFrames{Frames: stack},
+	}}
+
+	s.pending.Add(1)
+	select {
+	case s.events <- e:
+	default:
+		s.pending.Done()
+	}
+}
+
+// Flush wait until queued reports are sent or timeout expires
+func (s *Sentry) Flush(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		s.pending.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func (s *Sentry) run() {
+	for e := range s.events {
+		if err := s.send(e); err != nil {
+			// not logged, as error logs may themselves be reported
+			fmt.Fprintf(os.Stderr, "%v ERROR: could not send error report to sentry: %v\n", time.Now(), err)
+		}
+		s.pending.Done()
+	}
+}
+
+func (s *Sentry) send(e *sentryEvent) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", s.auth)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("sentry returned HTTP status %s", resp.Status)
+	}
+	return nil
+}
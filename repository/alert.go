@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"main/alert"
+
+	"github.com/google/uuid"
+)
+
+// AlertRepo the alert rules users set on tickers and portfolios
+type AlertRepo interface {
+	// List the alerts of userID in the order they were created
+	List(ctx context.Context, userID string) ([]*alert.Rule, error)
+
+	// Get an alert of userID; returns sql.ErrNoRows if userID has no such
+	// alert
+	Get(ctx context.Context, id string, userID string) (*alert.Rule, error)
+
+	// Create save a new alert of userID, assigning its ID
+	Create(ctx context.Context, a *alert.Rule, userID string) error
+
+	// Update save the ticker, threshold, lookback, and active status of an
+	// alert of userID and clear its triggered state; returns sql.ErrNoRows
+	// if userID has no such alert
+	Update(ctx context.Context, a *alert.Rule, userID string) error
+
+	// Delete remove an alert of userID
+	Delete(ctx context.Context, id string, userID string) error
+
+	// Active the active alerts of every user
+	Active(ctx context.Context) ([]*alert.Rule, error)
+
+	// SetState save whether an alert is triggered and when it last was
+	SetState(ctx context.Context, a *alert.Rule) error
+}
+
+type alertRepo struct {
+	q *querier
+}
+
+const alertColumns = `id, userid, portfolio_id, kind, coalesce(ticker, ''), threshold, lookback_days, active, triggered, last_triggered`
+
+// scanAlert read an alert selected with alertColumns
+func scanAlert(row rowScanner) (*alert.Rule, error) {
+	a := &alert.Rule{}
+	err := row.Scan(&a.ID, &a.UserID, &a.PortfolioID, &a.Kind, &a.Ticker, &a.Threshold, &a.LookbackDays, &a.Active, &a.Triggered, &a.LastTriggered)
+	return a, err
+}
+
+// queryAlerts the alerts selected by a query of alertColumns
+func (repo *alertRepo) queryAlerts(ctx context.Context, query string, args ...interface{}) ([]*alert.Rule, error) {
+	rows, err := repo.q.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts := []*alert.Rule{}
+	for rows.Next() {
+		a, err := scanAlert(rows)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, a)
+	}
+	return alerts, rows.Err()
+}
+
+func (repo *alertRepo) List(ctx context.Context, userID string) ([]*alert.Rule, error) {
+	return repo.queryAlerts(ctx, `SELECT `+alertColumns+` FROM alert WHERE userid=$1 ORDER BY created`, userID)
+}
+
+func (repo *alertRepo) Get(ctx context.Context, id string, userID string) (*alert.Rule, error) {
+	return scanAlert(repo.q.queryRow(ctx, `SELECT `+alertColumns+` FROM alert WHERE id=$1 AND userid=$2`, id, userID))
+}
+
+func (repo *alertRepo) Create(ctx context.Context, a *alert.Rule, userID string) error {
+	a.ID = uuid.New()
+	a.UserID = userID
+	_, err := repo.q.exec(ctx, `INSERT INTO alert ("id", "userid", "portfolio_id", "kind", "ticker", "threshold", "lookback_days", "active") VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		a.ID, userID, a.PortfolioID, a.Kind, a.Ticker, a.Threshold, a.LookbackDays, a.Active)
+	return err
+}
+
+func (repo *alertRepo) Update(ctx context.Context, a *alert.Rule, userID string) error {
+	res, err := repo.q.exec(ctx, `UPDATE alert SET ticker=$1, threshold=$2, lookback_days=$3, active=$4, triggered=FALSE WHERE id=$5 AND userid=$6`,
+		a.Ticker, a.Threshold, a.LookbackDays, a.Active, a.ID, userID)
+	if err := requireRow(res, err); err != nil {
+		return err
+	}
+	a.Triggered = false
+	return nil
+}
+
+func (repo *alertRepo) Delete(ctx context.Context, id string, userID string) error {
+	_, err := repo.q.exec(ctx, `DELETE FROM alert WHERE id=$1 AND userid=$2`, id, userID)
+	return err
+}
+
+func (repo *alertRepo) Active(ctx context.Context) ([]*alert.Rule, error) {
+	return repo.queryAlerts(ctx, `SELECT `+alertColumns+` FROM alert WHERE active=TRUE`)
+}
+
+func (repo *alertRepo) SetState(ctx context.Context, a *alert.Rule) error {
+	_, err := repo.q.exec(ctx, `UPDATE alert SET triggered=$1, last_triggered=$2 WHERE id=$3`, a.Triggered, a.LastTriggered, a.ID)
+	return err
+}
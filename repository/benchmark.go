@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"main/benchmark"
+	"main/portfolio"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx/types"
+)
+
+// BenchmarkRepo the custom benchmarks of users and the benchmarks attached
+// to saved portfolios. Built-in benchmarks are not stored.
+type BenchmarkRepo interface {
+	// Get a custom benchmark of userID; returns sql.ErrNoRows if userID has
+	// no such benchmark
+	Get(ctx context.Context, id string, userID string) (benchmark.Benchmark, error)
+
+	// List the custom benchmarks of userID ordered by name
+	List(ctx context.Context, userID string) ([]benchmark.Benchmark, error)
+
+	// Create save a new custom benchmark of userID, assigning its ID. Only
+	// the fields used by the benchmark's kind are stored; the others are
+	// cleared.
+	Create(ctx context.Context, b *benchmark.Benchmark, userID string) error
+
+	// Update save a custom benchmark of userID, clearing the fields its kind
+	// does not use
+	Update(ctx context.Context, b *benchmark.Benchmark, userID string) error
+
+	// Delete remove a custom benchmark of userID, detach it from every
+	// portfolio, and restore the built-in default benchmark for userID if it
+	// was their default. Returns sql.ErrNoRows if userID has no such
+	// benchmark. Must be called inside Transaction.
+	Delete(ctx context.Context, id string, userID string) error
+
+	// Attached the ids of the benchmarks attached to a portfolio
+	Attached(ctx context.Context, portfolioID string) ([]string, error)
+
+	// SetAttached replace the benchmarks attached to a portfolio. Must be
+	// called inside Transaction.
+	SetAttached(ctx context.Context, portfolioID string, ids []string) error
+}
+
+type benchmarkRepo struct {
+	q *querier
+}
+
+const benchmarkSQL = `SELECT id, name, description, kind, coalesce(allocation, '{}'), coalesce(strategy_shortcode, ''), coalesce(arguments, '{}') FROM benchmark`
+
+// staticBenchmarkOptions how a static benchmark is rebalanced; stored in the
+// arguments column, which is otherwise only used by strategy benchmarks
+type staticBenchmarkOptions struct {
+	RebalanceBands *portfolio.RebalanceBands `json:"rebalanceBands,omitempty"`
+}
+
+// scanBenchmark read a benchmark selected with benchmarkSQL
+func scanBenchmark(row rowScanner) (benchmark.Benchmark, error) {
+	b := benchmark.Benchmark{}
+	var allocation types.JSONText
+	var arguments types.JSONText
+	if err := row.Scan(&b.ID, &b.Name, &b.Description, &b.Kind, &allocation, &b.Strategy, &arguments); err != nil {
+		return b, err
+	}
+	if err := allocation.Unmarshal(&b.Allocation); err != nil {
+		return b, err
+	}
+	if len(b.Allocation) == 0 {
+		b.Allocation = nil
+	}
+	switch b.Kind {
+	case benchmark.KindStrategy:
+		b.Arguments = json.RawMessage(arguments)
+	case benchmark.KindStatic:
+		options := staticBenchmarkOptions{}
+		if err := arguments.Unmarshal(&options); err != nil {
+			return b, err
+		}
+		b.RebalanceBands = options.RebalanceBands
+	}
+	return b, nil
+}
+
+// benchmarkColumns serialize the allocation and arguments of a benchmark for
+// storage; only the fields used by the benchmark's kind are stored
+func benchmarkColumns(b *benchmark.Benchmark) (interface{}, interface{}, error) {
+	switch b.Kind {
+	case benchmark.KindStatic:
+		b.Strategy = ""
+		b.Arguments = nil
+		allocation, err := json.Marshal(b.Allocation)
+		if err != nil {
+			return nil, nil, err
+		}
+		if b.RebalanceBands == nil {
+			return string(allocation), nil, nil
+		}
+		options, err := json.Marshal(staticBenchmarkOptions{RebalanceBands: b.RebalanceBands})
+		if err != nil {
+			return nil, nil, err
+		}
+		return string(allocation), string(options), nil
+	default:
+		b.Allocation = nil
+		b.RebalanceBands = nil
+		if len(b.Arguments) == 0 {
+			return nil, nil, nil
+		}
+		if !json.Valid(b.Arguments) {
+			return nil, nil, errors.New("arguments must be valid JSON")
+		}
+		return nil, string(b.Arguments), nil
+	}
+}
+
+func (repo *benchmarkRepo) Get(ctx context.Context, id string, userID string) (benchmark.Benchmark, error) {
+	return scanBenchmark(repo.q.queryRow(ctx, benchmarkSQL+` WHERE id=$1 AND userid=$2`, id, userID))
+}
+
+func (repo *benchmarkRepo) List(ctx context.Context, userID string) ([]benchmark.Benchmark, error) {
+	rows, err := repo.q.query(ctx, benchmarkSQL+` WHERE userid=$1 ORDER BY name, created`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	benchmarks := []benchmark.Benchmark{}
+	for rows.Next() {
+		b, err := scanBenchmark(rows)
+		if err != nil {
+			return nil, err
+		}
+		benchmarks = append(benchmarks, b)
+	}
+	return benchmarks, rows.Err()
+}
+
+func (repo *benchmarkRepo) Create(ctx context.Context, b *benchmark.Benchmark, userID string) error {
+	allocation, arguments, err := benchmarkColumns(b)
+	if err != nil {
+		return err
+	}
+
+	b.ID = uuid.New().String()
+	b.BuiltIn = false
+	_, err = repo.q.exec(ctx, `INSERT INTO benchmark ("id", "userid", "name", "description", "kind", "allocation", "strategy_shortcode", "arguments") VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		b.ID, userID, b.Name, b.Description, b.Kind, allocation, b.Strategy, arguments)
+	return err
+}
+
+func (repo *benchmarkRepo) Update(ctx context.Context, b *benchmark.Benchmark, userID string) error {
+	allocation, arguments, err := benchmarkColumns(b)
+	if err != nil {
+		return err
+	}
+
+	b.BuiltIn = false
+	_, err = repo.q.exec(ctx, `UPDATE benchmark SET name=$1, description=$2, kind=$3, allocation=$4, strategy_shortcode=$5, arguments=$6 WHERE id=$7 AND userid=$8`,
+		b.Name, b.Description, b.Kind, allocation, b.Strategy, arguments, b.ID, userID)
+	return err
+}
+
+func (repo *benchmarkRepo) Delete(ctx context.Context, id string, userID string) error {
+	res, err := repo.q.exec(ctx, `DELETE FROM benchmark WHERE id=$1 AND userid=$2`, id, userID)
+	if err := requireRow(res, err); err != nil {
+		return err
+	}
+
+	if _, err := repo.q.exec(ctx, `DELETE FROM portfolio_benchmark WHERE benchmark_id=$1`, id); err != nil {
+		return err
+	}
+
+	_, err = repo.q.exec(ctx, `UPDATE user_settings SET default_benchmark=$1 WHERE userid=$2 AND default_benchmark=$3`, benchmark.DefaultID, userID, id)
+	return err
+}
+
+func (repo *benchmarkRepo) Attached(ctx context.Context, portfolioID string) ([]string, error) {
+	return queryStrings(ctx, repo.q, `SELECT benchmark_id FROM portfolio_benchmark WHERE portfolio_id=$1 ORDER BY benchmark_id`, portfolioID)
+}
+
+func (repo *benchmarkRepo) SetAttached(ctx context.Context, portfolioID string, ids []string) error {
+	if _, err := repo.q.exec(ctx, `DELETE FROM portfolio_benchmark WHERE portfolio_id=$1`, portfolioID); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		_, err := repo.q.exec(ctx, `INSERT INTO portfolio_benchmark ("portfolio_id", "benchmark_id") VALUES ($1, $2) ON CONFLICT DO NOTHING`, portfolioID, id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
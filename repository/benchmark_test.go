@@ -0,0 +1,94 @@
+//go:build sqlite
+// +build sqlite
+
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"main/benchmark"
+	"main/preferences"
+	"main/repository"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BenchmarkRepo", func() {
+	var (
+		ctx         context.Context
+		userID      string
+		portfolioID string
+		b           benchmark.Benchmark
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		userID = uuid.New().String()[:32]
+		portfolioID = uuid.New().String()
+
+		b = benchmark.Benchmark{
+			Name:       "Total Market",
+			Kind:       benchmark.KindStatic,
+			Allocation: map[string]float64{"VTI": 1.0},
+		}
+		Expect(repository.Benchmarks.Create(ctx, &b, userID)).To(Succeed())
+
+		err := repository.Transaction(ctx, func(r *repository.Repositories) error {
+			return r.Benchmarks.SetAttached(ctx, portfolioID, []string{b.ID})
+		})
+		Expect(err).To(BeNil())
+
+		prefs := preferences.Default()
+		prefs.UserID = userID
+		prefs.DefaultBenchmark = b.ID
+		Expect(repository.Users.SavePreferences(ctx, &prefs)).To(Succeed())
+	})
+
+	defaultBenchmark := func() string {
+		prefs, err := repository.Users.Preferences(ctx, userID)
+		Expect(err).To(BeNil())
+		return prefs.DefaultBenchmark
+	}
+
+	Context("when a benchmark is deleted", func() {
+		It("should detach it and restore the default benchmark", func() {
+			err := repository.Transaction(ctx, func(r *repository.Repositories) error {
+				return r.Benchmarks.Delete(ctx, b.ID, userID)
+			})
+			Expect(err).To(BeNil())
+
+			_, err = repository.Benchmarks.Get(ctx, b.ID, userID)
+			Expect(errors.Is(err, sql.ErrNoRows)).To(BeTrue())
+			Expect(repository.Benchmarks.Attached(ctx, portfolioID)).To(BeEmpty())
+			Expect(defaultBenchmark()).To(Equal(benchmark.DefaultID))
+		})
+
+		It("should keep it attached if the transaction fails", func() {
+			err := repository.Transaction(ctx, func(r *repository.Repositories) error {
+				if err := r.Benchmarks.Delete(ctx, b.ID, userID); err != nil {
+					return err
+				}
+				return errors.New("failed after delete")
+			})
+			Expect(err).NotTo(BeNil())
+
+			_, err = repository.Benchmarks.Get(ctx, b.ID, userID)
+			Expect(err).To(BeNil())
+			Expect(repository.Benchmarks.Attached(ctx, portfolioID)).To(Equal([]string{b.ID}))
+			Expect(defaultBenchmark()).To(Equal(b.ID))
+		})
+	})
+
+	Context("when another user deletes a benchmark", func() {
+		It("should not change it", func() {
+			err := repository.Transaction(ctx, func(r *repository.Repositories) error {
+				return r.Benchmarks.Delete(ctx, b.ID, "other")
+			})
+			Expect(errors.Is(err, sql.ErrNoRows)).To(BeTrue())
+			Expect(repository.Benchmarks.Attached(ctx, portfolioID)).To(Equal([]string{b.ID}))
+		})
+	})
+})
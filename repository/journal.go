@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"main/database"
+	"main/journal"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// JournalRepo the decision journals of saved portfolios. Callers check that
+// the user can view the portfolio; notes are only changed by their author.
+type JournalRepo interface {
+	// List the notes of a portfolio dated between begin and end, inclusive,
+	// ordered by date
+	List(ctx context.Context, portfolioID uuid.UUID, begin time.Time, end time.Time) ([]*journal.Note, error)
+
+	// Create save a new note, assigning its ID
+	Create(ctx context.Context, n *journal.Note) error
+
+	// Update save the date, text, ticker, and chart setting of a note;
+	// returns sql.ErrNoRows if n.UserID did not write it
+	Update(ctx context.Context, n *journal.Note) error
+
+	// Delete remove a note; returns sql.ErrNoRows if userID did not write it
+	Delete(ctx context.Context, id string, portfolioID uuid.UUID, userID string) error
+}
+
+type journalRepo struct {
+	q *querier
+}
+
+func (repo *journalRepo) List(ctx context.Context, portfolioID uuid.UUID, begin time.Time, end time.Time) ([]*journal.Note, error) {
+	d := database.Current
+	rows, err := repo.q.query(ctx, `SELECT id, portfolio_id, userid, `+d.Epoch("note_date")+`, text, ticker, show_on_chart, `+d.Epoch("created")+`, `+d.Epoch("lastchanged")+`
+		FROM portfolio_note WHERE portfolio_id=$1 AND note_date >= $2 AND note_date <= $3 ORDER BY note_date, created`, portfolioID, begin, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notes := []*journal.Note{}
+	for rows.Next() {
+		n := &journal.Note{}
+		if err := rows.Scan(&n.ID, &n.PortfolioID, &n.UserID, &n.Date, &n.Text, &n.Ticker, &n.ShowOnChart, &n.Created, &n.LastChanged); err != nil {
+			return nil, err
+		}
+		notes = append(notes, n)
+	}
+	return notes, rows.Err()
+}
+
+func (repo *journalRepo) Create(ctx context.Context, n *journal.Note) error {
+	n.ID = uuid.New()
+	_, err := repo.q.exec(ctx, `INSERT INTO portfolio_note ("id", "portfolio_id", "userid", "note_date", "text", "ticker", "show_on_chart") VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		n.ID, n.PortfolioID, n.UserID, time.Unix(n.Date, 0), n.Text, n.Ticker, n.ShowOnChart)
+	return err
+}
+
+func (repo *journalRepo) Update(ctx context.Context, n *journal.Note) error {
+	res, err := repo.q.exec(ctx, `UPDATE portfolio_note SET note_date=$1, text=$2, ticker=$3, show_on_chart=$4 WHERE id=$5 AND portfolio_id=$6 AND userid=$7`,
+		time.Unix(n.Date, 0), n.Text, n.Ticker, n.ShowOnChart, n.ID, n.PortfolioID, n.UserID)
+	return requireRow(res, err)
+}
+
+func (repo *journalRepo) Delete(ctx context.Context, id string, portfolioID uuid.UUID, userID string) error {
+	res, err := repo.q.exec(ctx, `DELETE FROM portfolio_note WHERE id=$1 AND portfolio_id=$2 AND userid=$3`, id, portfolioID, userID)
+	return requireRow(res, err)
+}
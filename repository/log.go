@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"main/database"
+	"main/logging"
+	"time"
+
+	"github.com/jmoiron/sqlx/types"
+	"github.com/sirupsen/logrus"
+)
+
+// LogRepo warning and error log events persisted by logging.Sink; implements
+// logging.Store
+type LogRepo interface {
+	// SaveEvents store events
+	SaveEvents(ctx context.Context, events []*logging.Event) error
+
+	// ListEvents the stored events matching q, newest first
+	ListEvents(ctx context.Context, q *logging.Query) ([]*logging.Event, error)
+}
+
+type logRepo struct {
+	q *querier
+}
+
+func (repo *logRepo) SaveEvents(ctx context.Context, events []*logging.Event) error {
+	for _, e := range events {
+		level, err := logrus.ParseLevel(e.Level)
+		if err != nil {
+			return err
+		}
+		fields, err := json.Marshal(e.Fields)
+		if err != nil {
+			return err
+		}
+		_, err = repo.q.exec(ctx, `INSERT INTO log_event ("logged", "level", "severity", "message", "function", "userid", "portfolio_id", "strategy", "error", "fields") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+			time.Unix(e.Time, 0).UTC(), e.Level, int(level), e.Message, e.Function, e.UserID, e.PortfolioID, e.Strategy, e.Error, string(fields))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (repo *logRepo) ListEvents(ctx context.Context, q *logging.Query) ([]*logging.Event, error) {
+	rows, err := repo.q.query(ctx, `SELECT id, `+database.Current.Epoch("logged")+`, level, message, function, userid, portfolio_id, strategy, error, fields FROM log_event
+		WHERE ($1='' OR userid=$1) AND ($2='' OR portfolio_id=$2) AND severity <= $3 AND logged >= $4 ORDER BY logged DESC, id DESC LIMIT $5`,
+		q.UserID, q.PortfolioID, int(q.Level), q.Since.UTC(), q.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*logging.Event{}
+	for rows.Next() {
+		e := &logging.Event{}
+		var fields types.JSONText
+		if err := rows.Scan(&e.ID, &e.Time, &e.Level, &e.Message, &e.Function, &e.UserID, &e.PortfolioID, &e.Strategy, &e.Error, &fields); err != nil {
+			return nil, err
+		}
+		if err := fields.Unmarshal(&e.Fields); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,353 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"main/database"
+	"main/portfolio"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Status of a portfolio update in the portfolio_update ledger
+const (
+	UpdateRunning   = "running"
+	UpdateCompleted = "completed"
+)
+
+// UpdateFailure a portfolio the notifier could not update. Stack is the stack
+// trace of a computation that panicked and is empty for other errors.
+type UpdateFailure struct {
+	PortfolioID uuid.UUID
+	Through     time.Time
+	Stage       string
+	Error       string
+	Stack       string
+}
+
+// Revision a change to the stored history of a portfolio found by the
+// update through Through
+type Revision struct {
+	Through time.Time `json:"through"`
+	portfolio.HistoryRevision
+}
+
+// MeasurementRepo the performance metrics and transactions persisted for
+// saved portfolios by the notifier, the archive of the signals of their
+// strategies, the portfolio_update ledger that records each update, the
+// revisions of their history, and the failures of updates. Methods that lock
+// rows must be called inside Transaction.
+type MeasurementRepo interface {
+	// LockUpdate lock the ledger entry of the update of portfolioID through
+	// the given date, creating it as running if it does not exist. Returns
+	// the status of an existing entry or "" if it was created.
+	LockUpdate(ctx context.Context, portfolioID uuid.UUID, through time.Time) (string, error)
+
+	// CompleteUpdate mark the ledger entry of the update completed and store
+	// the provenance of its performance
+	CompleteUpdate(ctx context.Context, portfolioID uuid.UUID, through time.Time, perf *portfolio.Performance, numTransactions int) error
+
+	// MetricsState lock the portfolio and retrieve its serialized streaming
+	// metrics; empty if none have been saved
+	MetricsState(ctx context.Context, portfolioID uuid.UUID) ([]byte, error)
+
+	// SaveMetrics save the performance metrics and streaming metrics state
+	SaveMetrics(ctx context.Context, portfolioID uuid.UUID, perf *portfolio.Performance, metrics *portfolio.StreamingMetrics) error
+
+	// SaveTransactions replace the stored transactions of a portfolio with
+	// those made on or before through. Must be called inside Transaction.
+	// Returns the number stored.
+	SaveTransactions(ctx context.Context, portfolioID uuid.UUID, transactions []portfolio.Transaction, through time.Time) (int, error)
+
+	// ListTransactions the stored transactions of a portfolio ordered by date
+	ListTransactions(ctx context.Context, portfolioID uuid.UUID) ([]portfolio.Transaction, error)
+
+	// TransactionsBefore the stored transactions of a portfolio dated before
+	// the given date, with their justifications, ordered by date. None are
+	// returned unless an update through that date completed.
+	TransactionsBefore(ctx context.Context, portfolioID uuid.UUID, before time.Time) ([]portfolio.Transaction, error)
+
+	// SaveRevision store the changes to the history of a portfolio found by
+	// the update through the given date
+	SaveRevision(ctx context.Context, portfolioID uuid.UUID, through time.Time, revision *portfolio.HistoryRevision) error
+
+	// ListRevisions the stored revisions of a portfolio, newest first
+	ListRevisions(ctx context.Context, portfolioID uuid.UUID) ([]Revision, error)
+
+	// RecordFailure store why a portfolio could not be updated
+	RecordFailure(ctx context.Context, failure *UpdateFailure) error
+
+	// SaveSignals store the signals on or before through, remove stored
+	// signals after it, and record the last date the signal changed on the
+	// portfolio. Returns the number stored.
+	SaveSignals(ctx context.Context, portfolioID uuid.UUID, signals []portfolio.Signal, through time.Time) (int, error)
+
+	// ListSignals the stored signals of a portfolio between begin and end,
+	// inclusive, ordered by date
+	ListSignals(ctx context.Context, portfolioID uuid.UUID, begin time.Time, end time.Time) ([]portfolio.Signal, error)
+
+	// CopyHistory copy the metrics, transactions, signals, and completed
+	// updates of portfolio from to portfolio to, e.g. when a portfolio is
+	// cloned, so the notifier continues the history rather than recomputing
+	// it
+	CopyHistory(ctx context.Context, from uuid.UUID, to uuid.UUID) error
+}
+
+type measurementRepo struct {
+	q *querier
+}
+
+func (repo *measurementRepo) LockUpdate(ctx context.Context, portfolioID uuid.UUID, through time.Time) (string, error) {
+	throughDate := through.Format("2006-01-02")
+
+	var status string
+	err := repo.q.queryRow(ctx, `SELECT status FROM portfolio_update WHERE portfolio_id=$1 AND through_date=$2`+database.Current.ForUpdate, portfolioID, throughDate).Scan(&status)
+	if err == sql.ErrNoRows {
+		_, err = repo.q.exec(ctx, `INSERT INTO portfolio_update ("portfolio_id", "through_date", "status") VALUES ($1, $2, $3)`, portfolioID, throughDate, UpdateRunning)
+		return "", err
+	}
+	return status, err
+}
+
+func (repo *measurementRepo) CompleteUpdate(ctx context.Context, portfolioID uuid.UUID, through time.Time, perf *portfolio.Performance, numTransactions int) error {
+	provenance, err := json.Marshal(perf.Provenance)
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.q.exec(ctx, `UPDATE portfolio_update SET status=$1, ytd_return=$2, cagr_since_inception=$3, num_transactions=$4, provenance=$5, completed=CURRENT_TIMESTAMP WHERE portfolio_id=$6 AND through_date=$7`,
+		UpdateCompleted, perf.YTDReturn, perf.CagrSinceInception, numTransactions, string(provenance), portfolioID, through.Format("2006-01-02"))
+	return err
+}
+
+func (repo *measurementRepo) MetricsState(ctx context.Context, portfolioID uuid.UUID) ([]byte, error) {
+	var state []byte
+	err := repo.q.queryRow(ctx, `SELECT metrics_state FROM portfolio WHERE id=$1`+database.Current.ForUpdate, portfolioID).Scan(&state)
+	return state, err
+}
+
+func (repo *measurementRepo) SaveMetrics(ctx context.Context, portfolioID uuid.UUID, perf *portfolio.Performance, metrics *portfolio.StreamingMetrics) error {
+	state, err := json.Marshal(metrics)
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.q.exec(ctx, `UPDATE portfolio SET ytd_return=$1, cagr_since_inception=$2, metrics_state=$3, std_dev=$4, sharpe_ratio=$5, sortino_ratio=$6, max_draw_down=$7 WHERE id=$8`,
+		perf.YTDReturn, perf.CagrSinceInception, string(state), metrics.StdDev(), metrics.SharpeRatio(), metrics.SortinoRatio(), metrics.MaxDrawDown, portfolioID)
+	return err
+}
+
+func (repo *measurementRepo) SaveTransactions(ctx context.Context, portfolioID uuid.UUID, transactions []portfolio.Transaction, through time.Time) (int, error) {
+	// the recomputed history replaces the stored one, including transactions
+	// before through that the strategy no longer makes
+	if _, err := repo.q.exec(ctx, `DELETE FROM portfolio_transaction WHERE portfolio_id=$1`, portfolioID); err != nil {
+		return 0, err
+	}
+
+	numTransactions := 0
+	for _, trx := range transactions {
+		if trx.Kind == portfolio.MarkerTransaction || trx.Date.After(through) {
+			continue
+		}
+
+		var justification interface{}
+		if trx.Justification != nil {
+			if js, err := json.Marshal(trx.Justification); err == nil {
+				justification = string(js)
+			}
+		}
+
+		_, err := repo.q.exec(ctx, `INSERT INTO portfolio_transaction ("portfolio_id", "trade_date", "ticker", "kind", "shares", "price_per_share", "fees", "total_value", "justification") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			ON CONFLICT (portfolio_id, trade_date, ticker, kind) DO UPDATE SET shares=EXCLUDED.shares, price_per_share=EXCLUDED.price_per_share, fees=EXCLUDED.fees, total_value=EXCLUDED.total_value, justification=EXCLUDED.justification`,
+			portfolioID, trx.Date, trx.Ticker, trx.Kind, trx.Shares, trx.PricePerShare, trx.Fees, trx.TotalValue, justification)
+		if err != nil {
+			return numTransactions, err
+		}
+		numTransactions++
+	}
+
+	return numTransactions, nil
+}
+
+func (repo *measurementRepo) ListTransactions(ctx context.Context, portfolioID uuid.UUID) ([]portfolio.Transaction, error) {
+	rows, err := repo.q.query(ctx, `SELECT trade_date, ticker, kind, shares, price_per_share, fees, total_value FROM portfolio_transaction WHERE portfolio_id=$1 ORDER BY trade_date`, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	trxs := []portfolio.Transaction{}
+	for rows.Next() {
+		var trx portfolio.Transaction
+		if err := rows.Scan(&trx.Date, &trx.Ticker, &trx.Kind, &trx.Shares, &trx.PricePerShare, &trx.Fees, &trx.TotalValue); err != nil {
+			return nil, err
+		}
+		trxs = append(trxs, trx)
+	}
+	return trxs, rows.Err()
+}
+
+func (repo *measurementRepo) TransactionsBefore(ctx context.Context, portfolioID uuid.UUID, before time.Time) ([]portfolio.Transaction, error) {
+	var persisted int
+	err := repo.q.queryRow(ctx, `SELECT count(*) FROM portfolio_update WHERE portfolio_id=$1 AND status='completed' AND through_date >= $2`, portfolioID, before).Scan(&persisted)
+	if err != nil || persisted == 0 {
+		return []portfolio.Transaction{}, err
+	}
+
+	rows, err := repo.q.query(ctx, `SELECT trade_date, ticker, kind, shares, price_per_share, fees, total_value, justification FROM portfolio_transaction WHERE portfolio_id=$1 AND trade_date < $2 ORDER BY trade_date`, portfolioID, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	trxs := []portfolio.Transaction{}
+	for rows.Next() {
+		trx := portfolio.Transaction{}
+		var justification []byte
+		if err := rows.Scan(&trx.Date, &trx.Ticker, &trx.Kind, &trx.Shares, &trx.PricePerShare, &trx.Fees, &trx.TotalValue, &justification); err != nil {
+			return nil, err
+		}
+		trx.Date = trx.Date.UTC()
+		if len(justification) > 0 {
+			if err := json.Unmarshal(justification, &trx.Justification); err != nil {
+				return nil, err
+			}
+		}
+		trxs = append(trxs, trx)
+	}
+	return trxs, rows.Err()
+}
+
+func (repo *measurementRepo) SaveRevision(ctx context.Context, portfolioID uuid.UUID, through time.Time, revision *portfolio.HistoryRevision) error {
+	report, err := json.Marshal(revision)
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.q.exec(ctx, `INSERT INTO portfolio_revision ("portfolio_id", "through_date", "num_changes", "report") VALUES ($1, $2, $3, $4)
+		ON CONFLICT (portfolio_id, through_date) DO UPDATE SET num_changes=EXCLUDED.num_changes, report=EXCLUDED.report`,
+		portfolioID, through.Format("2006-01-02"), len(revision.Changes), string(report))
+	return err
+}
+
+func (repo *measurementRepo) ListRevisions(ctx context.Context, portfolioID uuid.UUID) ([]Revision, error) {
+	rows, err := repo.q.query(ctx, `SELECT through_date, report FROM portfolio_revision WHERE portfolio_id=$1 ORDER BY through_date DESC`, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []Revision{}
+	for rows.Next() {
+		var revision Revision
+		var report []byte
+		if err := rows.Scan(&revision.Through, &report); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(report, &revision.HistoryRevision); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, rows.Err()
+}
+
+func (repo *measurementRepo) RecordFailure(ctx context.Context, failure *UpdateFailure) error {
+	var stack interface{}
+	if failure.Stack != "" {
+		stack = failure.Stack
+	}
+	_, err := repo.q.exec(ctx, `INSERT INTO portfolio_failure ("portfolio_id", "through_date", "stage", "error", "stack") VALUES ($1, $2, $3, $4, $5)`,
+		failure.PortfolioID, failure.Through.Format("2006-01-02"), failure.Stage, failure.Error, stack)
+	return err
+}
+
+func (repo *measurementRepo) SaveSignals(ctx context.Context, portfolioID uuid.UUID, signals []portfolio.Signal, through time.Time) (int, error) {
+	numSignals := 0
+	for _, signal := range signals {
+		if signal.Date.After(through) {
+			continue
+		}
+
+		target, err := json.Marshal(signal.Target)
+		if err != nil {
+			return numSignals, err
+		}
+
+		// scores that cannot be encoded, e.g. NaN, are not archived
+		var justification interface{}
+		if signal.Justification != nil {
+			if js, err := json.Marshal(signal.Justification); err == nil {
+				justification = string(js)
+			}
+		}
+
+		_, err = repo.q.exec(ctx, `INSERT INTO portfolio_signal ("portfolio_id", "signal_date", "target", "justification") VALUES ($1, $2, $3, $4)
+			ON CONFLICT (portfolio_id, signal_date) DO UPDATE SET target=EXCLUDED.target, justification=EXCLUDED.justification`,
+			portfolioID, signal.Date.Format("2006-01-02"), string(target), justification)
+		if err != nil {
+			return numSignals, err
+		}
+		numSignals++
+	}
+
+	if _, err := repo.q.exec(ctx, `DELETE FROM portfolio_signal WHERE portfolio_id=$1 AND signal_date > $2`, portfolioID, through.Format("2006-01-02")); err != nil {
+		return numSignals, err
+	}
+
+	// the last signal that differs from the one before it
+	_, err := repo.q.exec(ctx, `UPDATE portfolio SET last_signal_change=(
+		SELECT MAX(s.signal_date) FROM portfolio_signal s WHERE s.portfolio_id=portfolio.id AND NOT EXISTS (
+			SELECT 1 FROM portfolio_signal p WHERE p.portfolio_id=s.portfolio_id AND p.target=s.target AND p.signal_date=(
+				SELECT MAX(signal_date) FROM portfolio_signal WHERE portfolio_id=s.portfolio_id AND signal_date < s.signal_date))) WHERE id=$1`, portfolioID)
+	return numSignals, err
+}
+
+func (repo *measurementRepo) ListSignals(ctx context.Context, portfolioID uuid.UUID, begin time.Time, end time.Time) ([]portfolio.Signal, error) {
+	rows, err := repo.q.query(ctx, `SELECT signal_date, target, justification FROM portfolio_signal WHERE portfolio_id=$1 AND signal_date >= $2 AND signal_date <= $3 ORDER BY signal_date`,
+		portfolioID, begin.Format("2006-01-02"), end.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	signals := []portfolio.Signal{}
+	for rows.Next() {
+		var signal portfolio.Signal
+		var target, justification []byte
+		if err := rows.Scan(&signal.Date, &target, &justification); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(target, &signal.Target); err != nil {
+			return nil, err
+		}
+		if len(justification) > 0 {
+			if err := json.Unmarshal(justification, &signal.Justification); err != nil {
+				return nil, err
+			}
+		}
+		signals = append(signals, signal)
+	}
+	return signals, rows.Err()
+}
+
+func (repo *measurementRepo) CopyHistory(ctx context.Context, from uuid.UUID, to uuid.UUID) error {
+	statements := []string{
+		`UPDATE portfolio SET (ytd_return, cagr_since_inception, std_dev, sharpe_ratio, sortino_ratio, max_draw_down, metrics_state, last_signal_change) =
+			(SELECT ytd_return, cagr_since_inception, std_dev, sharpe_ratio, sortino_ratio, max_draw_down, metrics_state, last_signal_change FROM portfolio WHERE id=$2) WHERE id=$1`,
+		`INSERT INTO portfolio_transaction ("portfolio_id", "trade_date", "ticker", "kind", "shares", "price_per_share", "fees", "total_value", "justification")
+			SELECT $1, trade_date, ticker, kind, shares, price_per_share, fees, total_value, justification FROM portfolio_transaction WHERE portfolio_id=$2`,
+		`INSERT INTO portfolio_signal ("portfolio_id", "signal_date", "target", "justification")
+			SELECT $1, signal_date, target, justification FROM portfolio_signal WHERE portfolio_id=$2`,
+		`INSERT INTO portfolio_update ("portfolio_id", "through_date", "status", "ytd_return", "cagr_since_inception", "num_transactions", "provenance", "started", "completed")
+			SELECT $1, through_date, status, ytd_return, cagr_since_inception, num_transactions, provenance, started, completed FROM portfolio_update WHERE portfolio_id=$2 AND status='` + UpdateCompleted + `'`,
+	}
+	for _, stmt := range statements {
+		if _, err := repo.q.exec(ctx, stmt, to, from); err != nil {
+			return err
+		}
+	}
+	return nil
+}
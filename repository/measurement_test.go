@@ -0,0 +1,73 @@
+//go:build sqlite
+// +build sqlite
+
+package repository_test
+
+import (
+	"context"
+	"main/portfolio"
+	"main/repository"
+	"time"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MeasurementRepo", func() {
+	var (
+		ctx         context.Context
+		portfolioID uuid.UUID
+		through     time.Time
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		portfolioID = uuid.New()
+		through = time.Date(2021, time.March, 31, 0, 0, 0, 0, time.UTC)
+	})
+
+	trade := func(month time.Month, ticker string) portfolio.Transaction {
+		return portfolio.Transaction{
+			Date:          time.Date(2021, month, 1, 0, 0, 0, 0, time.UTC),
+			Ticker:        ticker,
+			Kind:          portfolio.BuyTransaction,
+			Shares:        10,
+			PricePerShare: 100,
+			TotalValue:    1000,
+		}
+	}
+
+	save := func(transactions ...portfolio.Transaction) {
+		err := repository.Transaction(ctx, func(r *repository.Repositories) error {
+			_, err := r.Measurements.SaveTransactions(ctx, portfolioID, transactions, through)
+			return err
+		})
+		Expect(err).To(BeNil())
+	}
+
+	tickers := func() []string {
+		stored, err := repository.Measurements.ListTransactions(ctx, portfolioID)
+		Expect(err).To(BeNil())
+		tickers := []string{}
+		for _, trx := range stored {
+			tickers = append(tickers, trx.Ticker)
+		}
+		return tickers
+	}
+
+	Context("when the recomputed history no longer has a past trade", func() {
+		It("should remove the trade", func() {
+			save(trade(time.January, "VFINX"), trade(time.February, "PRIDX"), trade(time.March, "VUSTX"))
+			Expect(tickers()).To(Equal([]string{"VFINX", "PRIDX", "VUSTX"}))
+
+			save(trade(time.January, "VFINX"), trade(time.March, "VUSTX"))
+			Expect(tickers()).To(Equal([]string{"VFINX", "VUSTX"}))
+		})
+	})
+
+	It("should not store transactions after through", func() {
+		save(trade(time.March, "VFINX"), trade(time.April, "PRIDX"))
+		Expect(tickers()).To(Equal([]string{"VFINX"}))
+	})
+})
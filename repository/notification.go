@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"main/notification"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// SentNotification an email accepted by SendGrid. PortfolioID is nil for
+// notifications that are not about a single portfolio.
+type SentNotification struct {
+	MessageID   string
+	UserID      string
+	Email       string
+	PortfolioID *uuid.UUID
+	Kind        string
+	Frequency   string
+}
+
+// NotificationRepo the history of sent notifications and the delivery events
+// SendGrid reports for them
+type NotificationRepo interface {
+	// RecordSent add a sent email to the notification history; recording the
+	// same message twice has no effect
+	RecordSent(ctx context.Context, sent *SentNotification) error
+
+	// RecordEvent store a delivery event and set the status of the message
+	// it is about; events received more than once are stored once
+	RecordEvent(ctx context.Context, event *notification.Event) error
+
+	// Templates the notification templates configured in the database
+	// ordered by kind, channel, frequency, and locale
+	Templates(ctx context.Context) ([]notification.Template, error)
+
+	// Template a notification template; returns sql.ErrNoRows if it does
+	// not exist
+	Template(ctx context.Context, id string) (notification.Template, error)
+
+	// CreateTemplate save a new notification template, assigning its ID;
+	// fails if a template exists for the same kind, channel, frequency, and
+	// locale
+	CreateTemplate(ctx context.Context, t *notification.Template) error
+
+	// UpdateTemplate save a notification template
+	UpdateTemplate(ctx context.Context, t *notification.Template) error
+
+	// DeleteTemplate remove a notification template
+	DeleteTemplate(ctx context.Context, id string) error
+}
+
+const templateSQL = `SELECT id, kind, channel, frequency, locale, sendgrid_template_id, subject, body FROM notification_templates`
+
+// scanTemplate read a template selected with templateSQL
+func scanTemplate(row rowScanner) (notification.Template, error) {
+	t := notification.Template{}
+	err := row.Scan(&t.ID, &t.Kind, &t.Channel, &t.Frequency, &t.Locale, &t.SendGridTemplateID, &t.Subject, &t.Body)
+	return t, err
+}
+
+type notificationRepo struct {
+	q *querier
+}
+
+func (repo *notificationRepo) RecordSent(ctx context.Context, sent *SentNotification) error {
+	_, err := repo.q.exec(ctx, `INSERT INTO notification_history ("message_id", "userid", "portfolio_id", "kind", "frequency", "email") VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT DO NOTHING`,
+		sent.MessageID, sent.UserID, sent.PortfolioID, sent.Kind, strings.ToLower(sent.Frequency), strings.ToLower(sent.Email))
+	return err
+}
+
+func (repo *notificationRepo) RecordEvent(ctx context.Context, event *notification.Event) error {
+	messageID := event.SendMessageID()
+	if event.EventID != "" {
+		_, err := repo.q.exec(ctx, `INSERT INTO notification_event ("event_id", "message_id", "email", "event", "reason", "occurred") VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT DO NOTHING`,
+			event.EventID, messageID, strings.ToLower(event.Email), event.Event, event.Reason, event.Time())
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := repo.q.exec(ctx, `UPDATE notification_history SET status=$1 WHERE message_id=$2`, event.Event, messageID)
+	return err
+}
+
+func (repo *notificationRepo) Templates(ctx context.Context) ([]notification.Template, error) {
+	rows, err := repo.q.query(ctx, templateSQL+` ORDER BY kind, channel, frequency, locale`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []notification.Template{}
+	for rows.Next() {
+		t, err := scanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+	return templates, rows.Err()
+}
+
+func (repo *notificationRepo) Template(ctx context.Context, id string) (notification.Template, error) {
+	return scanTemplate(repo.q.queryRow(ctx, templateSQL+` WHERE id=$1`, id))
+}
+
+func (repo *notificationRepo) CreateTemplate(ctx context.Context, t *notification.Template) error {
+	t.ID = uuid.New()
+	_, err := repo.q.exec(ctx, `INSERT INTO notification_templates ("id", "kind", "channel", "frequency", "locale", "sendgrid_template_id", "subject", "body") VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		t.ID, t.Kind, t.Channel, t.Frequency, t.Locale, t.SendGridTemplateID, t.Subject, t.Body)
+	return err
+}
+
+func (repo *notificationRepo) UpdateTemplate(ctx context.Context, t *notification.Template) error {
+	_, err := repo.q.exec(ctx, `UPDATE notification_templates SET kind=$1, channel=$2, frequency=$3, locale=$4, sendgrid_template_id=$5, subject=$6, body=$7 WHERE id=$8`,
+		t.Kind, t.Channel, t.Frequency, t.Locale, t.SendGridTemplateID, t.Subject, t.Body, t.ID)
+	return err
+}
+
+func (repo *notificationRepo) DeleteTemplate(ctx context.Context, id string) error {
+	_, err := repo.q.exec(ctx, `DELETE FROM notification_templates WHERE id=$1`, id)
+	return err
+}
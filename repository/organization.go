@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"main/database"
+	"main/organization"
+
+	"github.com/google/uuid"
+)
+
+// OrganizationRepo organizations and their members. Organizations are only
+// returned to their members.
+type OrganizationRepo interface {
+	// Create save a new organization, assigning its ID if not set, with
+	// userID as its admin. Must be called inside Transaction.
+	Create(ctx context.Context, o *organization.Organization, userID string) error
+
+	// Get retrieve an organization userID belongs to with their role;
+	// returns sql.ErrNoRows if it does not exist or userID is not a member
+	Get(ctx context.Context, id string, userID string) (*organization.Organization, error)
+
+	// ListByUser organizations userID belongs to ordered by name
+	ListByUser(ctx context.Context, userID string) ([]*organization.Organization, error)
+
+	// Rename change the name of an organization
+	Rename(ctx context.Context, id uuid.UUID, name string) error
+
+	// Delete remove an organization and its memberships; its portfolios
+	// return to the users that created them
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// Members the members of an organization ordered by name
+	Members(ctx context.Context, id uuid.UUID) ([]*organization.Member, error)
+
+	// SaveMember add a member or change their role and notifications
+	SaveMember(ctx context.Context, m *organization.Member) error
+
+	// RemoveMember remove userID from an organization; returns
+	// sql.ErrNoRows if they are not a member
+	RemoveMember(ctx context.Context, id uuid.UUID, userID string) error
+}
+
+type organizationRepo struct {
+	q *querier
+}
+
+func (repo *organizationRepo) Create(ctx context.Context, o *organization.Organization, userID string) error {
+	if o.ID == uuid.Nil {
+		o.ID = uuid.New()
+	}
+	if _, err := repo.q.exec(ctx, `INSERT INTO organization ("id", "name") VALUES ($1, $2)`, o.ID, o.Name); err != nil {
+		return err
+	}
+	_, err := repo.q.exec(ctx, `INSERT INTO organization_member ("org_id", "user_id", "role") VALUES ($1, $2, $3)`, o.ID, userID, organization.RoleAdmin)
+	return err
+}
+
+// organizationSelectSQL select the columns read by scanOrganization for the
+// organizations of the user in $1
+func organizationSelectSQL() string {
+	return `SELECT id, name, ` + database.Current.Epoch("created") + `, (SELECT role FROM organization_member WHERE org_id=organization.id AND user_id=$1) AS role
+		FROM organization WHERE id IN (SELECT org_id FROM organization_member WHERE user_id=$1)`
+}
+
+func scanOrganization(row rowScanner) (*organization.Organization, error) {
+	o := &organization.Organization{}
+	if err := row.Scan(&o.ID, &o.Name, &o.Created, &o.Role); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func (repo *organizationRepo) Get(ctx context.Context, id string, userID string) (*organization.Organization, error) {
+	return scanOrganization(repo.q.queryRow(ctx, organizationSelectSQL()+` AND id=$2`, userID, id))
+}
+
+func (repo *organizationRepo) ListByUser(ctx context.Context, userID string) ([]*organization.Organization, error) {
+	rows, err := repo.q.query(ctx, organizationSelectSQL()+` ORDER BY name`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orgs := []*organization.Organization{}
+	for rows.Next() {
+		o, err := scanOrganization(rows)
+		if err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, o)
+	}
+	return orgs, rows.Err()
+}
+
+func (repo *organizationRepo) Rename(ctx context.Context, id uuid.UUID, name string) error {
+	res, err := repo.q.exec(ctx, `UPDATE organization SET name=$1 WHERE id=$2`, name, id)
+	return requireRow(res, err)
+}
+
+func (repo *organizationRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	// portfolios and members are released explicitly as SQLite only enforces
+	// foreign keys when they are enabled
+	if _, err := repo.q.exec(ctx, `UPDATE portfolio SET org_id=NULL WHERE org_id=$1`, id); err != nil {
+		return err
+	}
+	if _, err := repo.q.exec(ctx, `DELETE FROM organization_member WHERE org_id=$1`, id); err != nil {
+		return err
+	}
+	res, err := repo.q.exec(ctx, `DELETE FROM organization WHERE id=$1`, id)
+	return requireRow(res, err)
+}
+
+func (repo *organizationRepo) Members(ctx context.Context, id uuid.UUID) ([]*organization.Member, error) {
+	rows, err := repo.q.query(ctx, `SELECT org_id, user_id, COALESCE((SELECT name FROM users WHERE userid=organization_member.user_id), '') AS name,
+		COALESCE((SELECT email FROM users WHERE userid=organization_member.user_id), '') AS email, role, notify, `+database.Current.Epoch("created")+`
+		FROM organization_member WHERE org_id=$1 ORDER BY name, user_id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []*organization.Member{}
+	for rows.Next() {
+		m := &organization.Member{}
+		if err := rows.Scan(&m.OrgID, &m.UserID, &m.Name, &m.Email, &m.Role, &m.Notify, &m.Created); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+func (repo *organizationRepo) SaveMember(ctx context.Context, m *organization.Member) error {
+	_, err := repo.q.exec(ctx, `INSERT INTO organization_member ("org_id", "user_id", "role", "notify") VALUES ($1, $2, $3, $4)
+		ON CONFLICT (org_id, user_id) DO UPDATE SET role=EXCLUDED.role, notify=EXCLUDED.notify`,
+		m.OrgID, m.UserID, m.Role, m.Notify)
+	return err
+}
+
+func (repo *organizationRepo) RemoveMember(ctx context.Context, id uuid.UUID, userID string) error {
+	res, err := repo.q.exec(ctx, `DELETE FROM organization_member WHERE org_id=$1 AND user_id=$2`, id, userID)
+	return requireRow(res, err)
+}
@@ -0,0 +1,415 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"main/community"
+	"main/database"
+	"main/organization"
+	"main/portfolio"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx/types"
+)
+
+// Portfolio a saved portfolio
+type Portfolio struct {
+	ID                 uuid.UUID       `json:"id"`
+	UserID             string          `json:"-"`
+	Name               string          `json:"name"`
+	Strategy           string          `json:"strategy"`
+	Arguments          types.JSONText  `json:"arguments"`
+	StrategyVersion    string          `json:"strategy_version"`
+	StartDate          int64           `json:"start_date"`
+	YTDReturn          sql.NullFloat64 `json:"ytd_return"`
+	CAGRSinceInception sql.NullFloat64 `json:"cagr_since_inception"`
+	StdDev             sql.NullFloat64 `json:"std_dev"`
+	SharpeRatio        sql.NullFloat64 `json:"sharpe_ratio"`
+	SortinoRatio       sql.NullFloat64 `json:"sortino_ratio"`
+	MaxDrawDown        sql.NullFloat64 `json:"max_draw_down"`
+	Notifications      int             `json:"notifications"`
+	AccountType        string          `json:"account_type"`
+	ShortTermTaxRate   float64         `json:"short_term_tax_rate"`
+	LongTermTaxRate    float64         `json:"long_term_tax_rate"`
+	DividendTaxRate    float64         `json:"dividend_tax_rate"`
+	Created            int64           `json:"created"`
+	LastChanged        int64           `json:"lastchanged"`
+	DeletedAt          *int64          `json:"deleted_at,omitempty"`
+
+	// OrgID the organization that owns the portfolio; UserID is then the
+	// member that created it
+	OrgID *uuid.UUID `json:"org_id,omitempty"`
+
+	// Folder path of the folder the portfolio is filed in, with parts
+	// separated by "/"; empty for the top level
+	Folder string `json:"folder"`
+
+	// Tags the portfolio is labeled with; read with PortfolioRepo.Tags
+	Tags []string `json:"tags,omitempty"`
+
+	// LastSignalChange the last date the strategy's signal changed
+	LastSignalChange *int64 `json:"last_signal_change"`
+}
+
+// TrashRetention how long a deleted portfolio stays in the trash before it is
+// permanently removed
+const TrashRetention = 30 * 24 * time.Hour
+
+// TaxRates tax rates configured for the portfolio
+func (p *Portfolio) TaxRates() portfolio.TaxRates {
+	return portfolio.TaxRates{
+		ShortTermCapitalGains: p.ShortTermTaxRate,
+		LongTermCapitalGains:  p.LongTermTaxRate,
+		Dividends:             p.DividendTaxRate,
+	}
+}
+
+// PortfolioRepo saved portfolios. Methods that take a userID only operate on
+// portfolios owned by that user or shared with them by an organization: Get
+// and ListShared return portfolios of every organization the user belongs
+// to, while changes require the editor or admin role. Deleted portfolios are
+// moved to the trash and are only returned by ListTrash.
+type PortfolioRepo interface {
+	// Get retrieve a portfolio; returns sql.ErrNoRows if it does not exist
+	Get(ctx context.Context, id string, userID string) (*Portfolio, error)
+
+	// ListByUser portfolios created by userID ordered by name, including
+	// those they created for an organization
+	ListByUser(ctx context.Context, userID string) ([]*Portfolio, error)
+
+	// ListShared portfolios of the organizations userID belongs to that
+	// other members created, ordered by name
+	ListShared(ctx context.Context, userID string) ([]*Portfolio, error)
+
+	// ListStartedBy portfolios of all users with a start date on or before date
+	ListStartedBy(ctx context.Context, date time.Time) ([]*Portfolio, error)
+
+	// Create save a new portfolio, assigning its ID if not set
+	Create(ctx context.Context, p *Portfolio) error
+
+	// Update save the name, notifications, account type, and tax rates
+	Update(ctx context.Context, p *Portfolio) error
+
+	// SetTags file a portfolio in folder and replace its tags. Must be called
+	// inside Transaction.
+	SetTags(ctx context.Context, id uuid.UUID, folder string, tags []string) error
+
+	// Tags the tags of the portfolios userID owns or that are shared with
+	// them, by portfolio id; portfolios without tags are omitted
+	Tags(ctx context.Context, userID string) (map[uuid.UUID][]string, error)
+
+	// CommunitySamples the strategy and metrics of every portfolio whose
+	// creator opted in to sharing their statistics
+	CommunitySamples(ctx context.Context) ([]community.Sample, error)
+
+	// SetOrganization transfer a portfolio created by userID to the
+	// organization orgID, or back to userID if orgID is nil; returns
+	// sql.ErrNoRows if userID did not create it
+	SetOrganization(ctx context.Context, id string, userID string, orgID *uuid.UUID) error
+
+	// Delete move a portfolio to the trash; returns sql.ErrNoRows if it does
+	// not exist or is already in the trash
+	Delete(ctx context.Context, id string, userID string) error
+
+	// ListTrash deleted portfolios owned by userID or editable by them, most
+	// recently deleted first
+	ListTrash(ctx context.Context, userID string) ([]*Portfolio, error)
+
+	// Restore move a portfolio out of the trash; returns sql.ErrNoRows if it
+	// is not in the trash
+	Restore(ctx context.Context, id string, userID string) error
+
+	// Purge permanently remove portfolios deleted before cutoff; returns the
+	// number removed
+	Purge(ctx context.Context, cutoff time.Time) (int64, error)
+
+	// OwnerIDs the ids of all users that own a portfolio, including
+	// portfolios in the trash
+	OwnerIDs(ctx context.Context) ([]string, error)
+
+	// DeleteByUser permanently remove every portfolio owned by userID,
+	// including portfolios in the trash; returns the number removed
+	DeleteByUser(ctx context.Context, userID string) (int64, error)
+
+	// Notifications the name and notifications bitmask of a portfolio of any
+	// user; used by signed unsubscribe links, which need no login
+	Notifications(ctx context.Context, id uuid.UUID) (string, int, error)
+
+	// SetNotifications save the notifications bitmask of a portfolio of any
+	// user
+	SetNotifications(ctx context.Context, id uuid.UUID, notifications int) error
+
+	// Migrations the upgrades of a portfolio's saved arguments to new
+	// versions of its strategy, oldest first
+	Migrations(ctx context.Context, id uuid.UUID) ([]StrategyMigration, error)
+
+	// Migrate save the upgraded arguments and strategy version of a portfolio
+	// and record the migration. Must be called inside Transaction.
+	Migrate(ctx context.Context, m *StrategyMigration) error
+
+	// SaveStatement store the positions of a broker statement imported by
+	// userID
+	SaveStatement(ctx context.Context, id string, userID string, asOf time.Time, positions []portfolio.Position) error
+
+	// LatestStatement the date and positions of the most recent broker
+	// statement userID imported; returns sql.ErrNoRows if there is none
+	LatestStatement(ctx context.Context, id string, userID string) (int64, []portfolio.Position, error)
+}
+
+// StrategyMigration saved arguments upgraded to a new version of the
+// portfolio's strategy
+type StrategyMigration struct {
+	PortfolioID  uuid.UUID      `json:"-"`
+	Strategy     string         `json:"-"`
+	FromVersion  string         `json:"from_version"`
+	ToVersion    string         `json:"to_version"`
+	OldArguments types.JSONText `json:"old_arguments"`
+	NewArguments types.JSONText `json:"new_arguments"`
+	Migrated     int64          `json:"migrated"`
+}
+
+// portfolioSelectSQL select the columns read by scanPortfolio
+func portfolioSelectSQL() string {
+	d := database.Current
+	return `SELECT id, userid, name, strategy_shortcode, arguments, strategy_version, ` + d.Epoch("start_date") + `, ytd_return, cagr_since_inception, std_dev, sharpe_ratio, sortino_ratio, max_draw_down, notifications, account_type, short_term_tax_rate, long_term_tax_rate, dividend_tax_rate, ` + d.Epoch("created") + `, ` + d.Epoch("lastchanged") + `, ` + d.Epoch("deleted_at") + `, org_id, folder, ` + d.Epoch("last_signal_change") + ` FROM portfolio`
+}
+
+// memberOf condition matching portfolios owned by the user in the query
+// parameter param or by an organization they belong to
+func memberOf(param string) string {
+	return `(userid=` + param + ` OR org_id IN (SELECT org_id FROM organization_member WHERE user_id=` + param + `))`
+}
+
+// editableBy condition matching portfolios owned by the user in the query
+// parameter param or by an organization they edit
+func editableBy(param string) string {
+	return `(userid=` + param + ` OR org_id IN (SELECT org_id FROM organization_member WHERE user_id=` + param + ` AND role IN ('` + organization.RoleEditor + `', '` + organization.RoleAdmin + `')))`
+}
+
+type portfolioRepo struct {
+	q *querier
+}
+
+// scanPortfolio read a portfolio selected with portfolioSelectSQL
+func scanPortfolio(row rowScanner) (*Portfolio, error) {
+	p := &Portfolio{}
+	err := row.Scan(&p.ID, &p.UserID, &p.Name, &p.Strategy, &p.Arguments, &p.StrategyVersion, &p.StartDate, &p.YTDReturn, &p.CAGRSinceInception,
+		&p.StdDev, &p.SharpeRatio, &p.SortinoRatio, &p.MaxDrawDown, &p.Notifications,
+		&p.AccountType, &p.ShortTermTaxRate, &p.LongTermTaxRate, &p.DividendTaxRate, &p.Created, &p.LastChanged, &p.DeletedAt, &p.OrgID, &p.Folder, &p.LastSignalChange)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (repo *portfolioRepo) list(ctx context.Context, query string, args ...interface{}) ([]*Portfolio, error) {
+	rows, err := repo.q.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	portfolios := []*Portfolio{}
+	for rows.Next() {
+		p, err := scanPortfolio(rows)
+		if err != nil {
+			return nil, err
+		}
+		portfolios = append(portfolios, p)
+	}
+
+	return portfolios, rows.Err()
+}
+
+func (repo *portfolioRepo) Get(ctx context.Context, id string, userID string) (*Portfolio, error) {
+	return scanPortfolio(repo.q.queryRow(ctx, portfolioSelectSQL()+` WHERE id=$1 AND `+memberOf("$2")+` AND deleted_at IS NULL`, id, userID))
+}
+
+func (repo *portfolioRepo) ListByUser(ctx context.Context, userID string) ([]*Portfolio, error) {
+	return repo.list(ctx, portfolioSelectSQL()+` WHERE userid=$1 AND deleted_at IS NULL ORDER BY name, created`, userID)
+}
+
+func (repo *portfolioRepo) ListShared(ctx context.Context, userID string) ([]*Portfolio, error) {
+	return repo.list(ctx, portfolioSelectSQL()+` WHERE org_id IN (SELECT org_id FROM organization_member WHERE user_id=$1) AND userid<>$1 AND deleted_at IS NULL ORDER BY name, created`, userID)
+}
+
+func (repo *portfolioRepo) ListStartedBy(ctx context.Context, date time.Time) ([]*Portfolio, error) {
+	return repo.list(ctx, portfolioSelectSQL()+` WHERE start_date <= $1 AND deleted_at IS NULL`, date)
+}
+
+func (repo *portfolioRepo) Create(ctx context.Context, p *Portfolio) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	_, err := repo.q.exec(ctx, `INSERT INTO portfolio ("id", "userid", "name", "strategy_shortcode", "arguments", "strategy_version", "start_date", "account_type", "short_term_tax_rate", "long_term_tax_rate", "dividend_tax_rate", "org_id", "folder") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+		p.ID, p.UserID, p.Name, p.Strategy, p.Arguments, p.StrategyVersion, time.Unix(p.StartDate, 0),
+		p.AccountType, p.ShortTermTaxRate, p.LongTermTaxRate, p.DividendTaxRate, p.OrgID, p.Folder)
+	return err
+}
+
+func (repo *portfolioRepo) Update(ctx context.Context, p *Portfolio) error {
+	_, err := repo.q.exec(ctx, `UPDATE portfolio SET name=$1, notifications=$2, account_type=$3, short_term_tax_rate=$4, long_term_tax_rate=$5, dividend_tax_rate=$6 WHERE id=$7 AND `+editableBy("$8"),
+		p.Name, p.Notifications, p.AccountType, p.ShortTermTaxRate, p.LongTermTaxRate, p.DividendTaxRate, p.ID, p.UserID)
+	return err
+}
+
+func (repo *portfolioRepo) SetTags(ctx context.Context, id uuid.UUID, folder string, tags []string) error {
+	res, err := repo.q.exec(ctx, `UPDATE portfolio SET folder=$1 WHERE id=$2`, folder, id)
+	if err := requireRow(res, err); err != nil {
+		return err
+	}
+	if _, err := repo.q.exec(ctx, `DELETE FROM portfolio_tag WHERE portfolio_id=$1`, id); err != nil {
+		return err
+	}
+	for _, t := range tags {
+		if _, err := repo.q.exec(ctx, `INSERT INTO portfolio_tag ("portfolio_id", "tag") VALUES ($1, $2)`, id, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (repo *portfolioRepo) Tags(ctx context.Context, userID string) (map[uuid.UUID][]string, error) {
+	rows, err := repo.q.query(ctx, `SELECT portfolio_id, tag FROM portfolio_tag WHERE portfolio_id IN (SELECT id FROM portfolio WHERE `+memberOf("$1")+`) ORDER BY portfolio_id, tag`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make(map[uuid.UUID][]string)
+	for rows.Next() {
+		var id uuid.UUID
+		var t string
+		if err := rows.Scan(&id, &t); err != nil {
+			return nil, err
+		}
+		tags[id] = append(tags[id], t)
+	}
+	return tags, rows.Err()
+}
+
+func (repo *portfolioRepo) CommunitySamples(ctx context.Context) ([]community.Sample, error) {
+	rows, err := repo.q.query(ctx, `SELECT strategy_shortcode, ytd_return, cagr_since_inception FROM portfolio
+		WHERE deleted_at IS NULL AND userid IN (SELECT userid FROM user_settings WHERE share_stats)`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	samples := []community.Sample{}
+	for rows.Next() {
+		var s community.Sample
+		if err := rows.Scan(&s.Strategy, &s.YTDReturn, &s.CAGRSinceInception); err != nil {
+			return nil, err
+		}
+		samples = append(samples, s)
+	}
+	return samples, rows.Err()
+}
+
+func (repo *portfolioRepo) SetOrganization(ctx context.Context, id string, userID string, orgID *uuid.UUID) error {
+	res, err := repo.q.exec(ctx, `UPDATE portfolio SET org_id=$1 WHERE id=$2 AND userid=$3 AND deleted_at IS NULL`, orgID, id, userID)
+	return requireRow(res, err)
+}
+
+func (repo *portfolioRepo) Delete(ctx context.Context, id string, userID string) error {
+	res, err := repo.q.exec(ctx, `UPDATE portfolio SET deleted_at=CURRENT_TIMESTAMP WHERE id=$1 AND `+editableBy("$2")+` AND deleted_at IS NULL`, id, userID)
+	return requireRow(res, err)
+}
+
+func (repo *portfolioRepo) ListTrash(ctx context.Context, userID string) ([]*Portfolio, error) {
+	return repo.list(ctx, portfolioSelectSQL()+` WHERE `+editableBy("$1")+` AND deleted_at IS NOT NULL ORDER BY deleted_at DESC`, userID)
+}
+
+func (repo *portfolioRepo) Restore(ctx context.Context, id string, userID string) error {
+	res, err := repo.q.exec(ctx, `UPDATE portfolio SET deleted_at=NULL WHERE id=$1 AND `+editableBy("$2")+` AND deleted_at IS NOT NULL`, id, userID)
+	return requireRow(res, err)
+}
+
+func (repo *portfolioRepo) Purge(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := repo.q.exec(ctx, `DELETE FROM portfolio WHERE deleted_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (repo *portfolioRepo) OwnerIDs(ctx context.Context) ([]string, error) {
+	return queryStrings(ctx, repo.q, `SELECT DISTINCT userid FROM portfolio ORDER BY userid`)
+}
+
+func (repo *portfolioRepo) DeleteByUser(ctx context.Context, userID string) (int64, error) {
+	res, err := repo.q.exec(ctx, `DELETE FROM portfolio WHERE userid=$1`, userID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (repo *portfolioRepo) Notifications(ctx context.Context, id uuid.UUID) (string, int, error) {
+	var name string
+	var notifications int
+	err := repo.q.queryRow(ctx, `SELECT name, notifications FROM portfolio WHERE id=$1`, id).Scan(&name, &notifications)
+	return name, notifications, err
+}
+
+func (repo *portfolioRepo) SetNotifications(ctx context.Context, id uuid.UUID, notifications int) error {
+	_, err := repo.q.exec(ctx, `UPDATE portfolio SET notifications=$1 WHERE id=$2`, notifications, id)
+	return err
+}
+
+func (repo *portfolioRepo) Migrations(ctx context.Context, id uuid.UUID) ([]StrategyMigration, error) {
+	rows, err := repo.q.query(ctx, `SELECT from_version, to_version, old_arguments, new_arguments, `+database.Current.Epoch("migrated")+` FROM strategy_migration WHERE portfolio_id=$1 ORDER BY migrated`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	migrations := []StrategyMigration{}
+	for rows.Next() {
+		m := StrategyMigration{PortfolioID: id}
+		if err := rows.Scan(&m.FromVersion, &m.ToVersion, &m.OldArguments, &m.NewArguments, &m.Migrated); err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, m)
+	}
+	return migrations, rows.Err()
+}
+
+func (repo *portfolioRepo) Migrate(ctx context.Context, m *StrategyMigration) error {
+	_, err := repo.q.exec(ctx, `UPDATE portfolio SET arguments=$1, strategy_version=$2 WHERE id=$3`, []byte(m.NewArguments), m.ToVersion, m.PortfolioID)
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.q.exec(ctx, `INSERT INTO strategy_migration (portfolio_id, strategy_shortcode, from_version, to_version, old_arguments, new_arguments) VALUES ($1, $2, $3, $4, $5, $6)`,
+		m.PortfolioID, m.Strategy, m.FromVersion, m.ToVersion, []byte(m.OldArguments), []byte(m.NewArguments))
+	return err
+}
+
+func (repo *portfolioRepo) SaveStatement(ctx context.Context, id string, userID string, asOf time.Time, positions []portfolio.Position) error {
+	encoded, err := json.Marshal(positions)
+	if err != nil {
+		return err
+	}
+	_, err = repo.q.exec(ctx, `INSERT INTO broker_statement ("id", "portfolio_id", "userid", "as_of", "positions") VALUES ($1, $2, $3, $4, $5)`,
+		uuid.New(), id, userID, asOf, string(encoded))
+	return err
+}
+
+func (repo *portfolioRepo) LatestStatement(ctx context.Context, id string, userID string) (int64, []portfolio.Position, error) {
+	var asOf int64
+	var encoded []byte
+	err := repo.q.queryRow(ctx, `SELECT `+database.Current.Epoch("as_of")+`, positions FROM broker_statement WHERE portfolio_id=$1 AND userid=$2 ORDER BY as_of DESC, created DESC LIMIT 1`, id, userID).Scan(&asOf, &encoded)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	positions := []portfolio.Position{}
+	if err := json.Unmarshal(encoded, &positions); err != nil {
+		return 0, nil, err
+	}
+	return asOf, positions, nil
+}
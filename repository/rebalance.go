@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"main/database"
+	"main/portfolio"
+	"main/rebalance"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RebalanceRepo batches of orders awaiting confirmation and the executed
+// transactions they are recorded as once confirmed
+type RebalanceRepo interface {
+	// List the batches of a portfolio created by userID, newest first
+	List(ctx context.Context, portfolioID uuid.UUID, userID string) ([]*rebalance.Batch, error)
+
+	// Get a batch by ID; returns sql.ErrNoRows if it does not exist
+	Get(ctx context.Context, id uuid.UUID) (*rebalance.Batch, error)
+
+	// Create save a new batch
+	Create(ctx context.Context, b *rebalance.Batch) error
+
+	// UpdateStatus save the status and resolution time of a batch
+	UpdateStatus(ctx context.Context, b *rebalance.Batch) error
+
+	// RecordTransactions save trxs as executed transactions of a portfolio
+	RecordTransactions(ctx context.Context, portfolioID uuid.UUID, userID string, trxs []portfolio.Transaction) error
+}
+
+type rebalanceRepo struct {
+	q *querier
+}
+
+// scanBatch read a batch from a row selected with batchColumns
+func scanBatch(row rowScanner) (*rebalance.Batch, error) {
+	b := &rebalance.Batch{}
+	var orders []byte
+	var resolved sql.NullInt64
+	if err := row.Scan(&b.ID, &b.PortfolioID, &b.UserID, &b.Status, &b.SignalDate, &orders, &b.Expires, &resolved, &b.Created); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(orders, &b.Orders); err != nil {
+		return nil, err
+	}
+	b.Resolved = resolved.Int64
+	return b, nil
+}
+
+// batchColumns columns read by scanBatch
+func batchColumns() string {
+	d := database.Current
+	return `id, portfolio_id, userid, status, ` + d.Epoch("signal_date") + `, orders, ` + d.Epoch("expires") + `, ` + d.Epoch("resolved") + `, ` + d.Epoch("created")
+}
+
+func (repo *rebalanceRepo) List(ctx context.Context, portfolioID uuid.UUID, userID string) ([]*rebalance.Batch, error) {
+	rows, err := repo.q.query(ctx, `SELECT `+batchColumns()+` FROM order_batch WHERE portfolio_id=$1 AND userid=$2 ORDER BY created DESC`, portfolioID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	batches := []*rebalance.Batch{}
+	for rows.Next() {
+		b, err := scanBatch(rows)
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, b)
+	}
+	return batches, rows.Err()
+}
+
+func (repo *rebalanceRepo) Get(ctx context.Context, id uuid.UUID) (*rebalance.Batch, error) {
+	return scanBatch(repo.q.queryRow(ctx, `SELECT `+batchColumns()+` FROM order_batch WHERE id=$1`, id))
+}
+
+func (repo *rebalanceRepo) Create(ctx context.Context, b *rebalance.Batch) error {
+	orders, err := json.Marshal(b.Orders)
+	if err != nil {
+		return err
+	}
+	_, err = repo.q.exec(ctx, `INSERT INTO order_batch ("id", "portfolio_id", "userid", "status", "signal_date", "orders", "expires", "created") VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		b.ID, b.PortfolioID, b.UserID, b.Status, time.Unix(b.SignalDate, 0), string(orders), time.Unix(b.Expires, 0), time.Unix(b.Created, 0))
+	return err
+}
+
+func (repo *rebalanceRepo) UpdateStatus(ctx context.Context, b *rebalance.Batch) error {
+	var resolved interface{}
+	if b.Resolved != 0 {
+		resolved = time.Unix(b.Resolved, 0)
+	}
+	res, err := repo.q.exec(ctx, `UPDATE order_batch SET status=$1, resolved=$2 WHERE id=$3`, b.Status, resolved, b.ID)
+	return requireRow(res, err)
+}
+
+func (repo *rebalanceRepo) RecordTransactions(ctx context.Context, portfolioID uuid.UUID, userID string, trxs []portfolio.Transaction) error {
+	for _, trx := range trxs {
+		_, err := repo.q.exec(ctx, `INSERT INTO executed_transaction ("id", "portfolio_id", "userid", "trade_date", "ticker", "kind", "shares", "price_per_share", "fees", "total_value") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+			uuid.New(), portfolioID, userID, trx.Date, trx.Ticker, trx.Kind, trx.Shares, trx.PricePerShare, trx.Fees, trx.TotalValue)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
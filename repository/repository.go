@@ -0,0 +1,281 @@
+// Package repository provides context-aware access to the tables of the
+// database. Handlers and the notifier use the package level repositories,
+// which are set by Initialize and may be replaced in tests. Queries are
+// prepared once and shared by all repositories; Transaction runs a group of
+// queries in a single database transaction.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"main/queue"
+	"main/telemetry"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Repositories the repositories that share a database handle or transaction
+type Repositories struct {
+	Portfolios    PortfolioRepo
+	Users         UserRepo
+	Measurements  MeasurementRepo
+	Notifications NotificationRepo
+	Usage         UsageRepo
+	Organizations OrganizationRepo
+	Journals      JournalRepo
+	Strategies    StrategyRepo
+	Runs          RunRepo
+	Logs          LogRepo
+	Rebalances    RebalanceRepo
+	Subscriptions SubscriptionRepo
+	Transactions  TransactionRepo
+	Alerts        AlertRepo
+	Benchmarks    BenchmarkRepo
+}
+
+var (
+	// Portfolios saved portfolios
+	Portfolios PortfolioRepo
+
+	// Users user accounts, settings, and email suppressions
+	Users UserRepo
+
+	// Measurements persisted portfolio performance and transactions
+	Measurements MeasurementRepo
+
+	// Notifications sent notifications and their delivery events
+	Notifications NotificationRepo
+
+	// Usage API calls made to data providers
+	Usage UsageRepo
+
+	// Organizations groups of users that share portfolios
+	Organizations OrganizationRepo
+
+	// Journals dated notes users attach to portfolios
+	Journals JournalRepo
+
+	// Strategies marketplace registrations of strategies
+	Strategies StrategyRepo
+
+	// Runs reports of the nightly runs of the notifier
+	Runs RunRepo
+
+	// Logs persisted warning and error log events
+	Logs LogRepo
+
+	// Rebalances orders awaiting confirmation
+	Rebalances RebalanceRepo
+
+	// Subscriptions the plans users subscribe to
+	Subscriptions SubscriptionRepo
+
+	// Transactions trades users executed against their portfolios
+	Transactions TransactionRepo
+
+	// Alerts alert rules on tickers and portfolios
+	Alerts AlertRepo
+
+	// Benchmarks custom benchmarks and those attached to portfolios
+	Benchmarks BenchmarkRepo
+
+	// Jobs the work queue processed by cmd/worker; jobs are not part of
+	// Transaction
+	Jobs queue.Queue
+)
+
+var conn *sql.DB
+var stmts = &statements{cache: make(map[string]*sql.Stmt)}
+
+// Initialize create the repositories using db; called after the database
+// connection is established
+func Initialize(db *sqlx.DB) {
+	conn = db.DB
+	r := newRepositories(&querier{db: conn})
+	Portfolios = r.Portfolios
+	Users = r.Users
+	Measurements = r.Measurements
+	Notifications = r.Notifications
+	Usage = r.Usage
+	Organizations = r.Organizations
+	Journals = r.Journals
+	Strategies = r.Strategies
+	Runs = r.Runs
+	Logs = r.Logs
+	Rebalances = r.Rebalances
+	Subscriptions = r.Subscriptions
+	Transactions = r.Transactions
+	Alerts = r.Alerts
+	Benchmarks = r.Benchmarks
+	Jobs = queue.NewPostgresQueue(db)
+}
+
+func newRepositories(q *querier) *Repositories {
+	return &Repositories{
+		Portfolios:    &portfolioRepo{q: q},
+		Users:         &userRepo{q: q},
+		Measurements:  &measurementRepo{q: q},
+		Notifications: &notificationRepo{q: q},
+		Usage:         &usageRepo{q: q},
+		Organizations: &organizationRepo{q: q},
+		Journals:      &journalRepo{q: q},
+		Strategies:    &strategyRepo{q: q},
+		Runs:          &runRepo{q: q},
+		Logs:          &logRepo{q: q},
+		Rebalances:    &rebalanceRepo{q: q},
+		Subscriptions: &subscriptionRepo{q: q},
+		Transactions:  &transactionRepo{q: q},
+		Alerts:        &alertRepo{q: q},
+		Benchmarks:    &benchmarkRepo{q: q},
+	}
+}
+
+// Transaction run fn with repositories that share a database transaction.
+// The transaction is committed if fn returns nil and rolled back otherwise.
+func Transaction(ctx context.Context, fn func(r *Repositories) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(newRepositories(&querier{db: conn, tx: tx})); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// rowScanner a single row of a query result
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// errRow a row whose query could not be prepared
+type errRow struct {
+	err error
+}
+
+func (r errRow) Scan(dest ...interface{}) error {
+	return r.err
+}
+
+// queryStrings the values of a query selecting a single text column
+func queryStrings(ctx context.Context, q *querier, query string, args ...interface{}) ([]string, error) {
+	rows, err := q.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []string{}
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	return values, rows.Err()
+}
+
+// requireRow the error of an exec that must change at least one row;
+// sql.ErrNoRows if it changed none
+func requireRow(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// statements prepared statements keyed by query
+type statements struct {
+	mu    sync.Mutex
+	cache map[string]*sql.Stmt
+}
+
+func (s *statements) prepare(ctx context.Context, db *sql.DB, query string) (*sql.Stmt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if stmt, ok := s.cache[query]; ok {
+		return stmt, nil
+	}
+
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	s.cache[query] = stmt
+	return stmt, nil
+}
+
+// querier run prepared statements on the database or, if tx is set, inside
+// the transaction
+type querier struct {
+	db *sql.DB
+	tx *sql.Tx
+}
+
+func (q *querier) stmt(ctx context.Context, query string) (*sql.Stmt, error) {
+	stmt, err := stmts.prepare(ctx, q.db, query)
+	if err != nil {
+		return nil, err
+	}
+	if q.tx != nil {
+		return q.tx.StmtContext(ctx, stmt), nil
+	}
+	return stmt, nil
+}
+
+func (q *querier) exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	defer recordQuery(query, time.Now())
+	stmt, err := q.stmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.ExecContext(ctx, args...)
+}
+
+func (q *querier) query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	defer recordQuery(query, time.Now())
+	stmt, err := q.stmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.QueryContext(ctx, args...)
+}
+
+func (q *querier) queryRow(ctx context.Context, query string, args ...interface{}) rowScanner {
+	defer recordQuery(query, time.Now())
+	stmt, err := q.stmt(ctx, query)
+	if err != nil {
+		return errRow{err: err}
+	}
+	return stmt.QueryRowContext(ctx, args...)
+}
+
+var queryDuration = telemetry.NewHistogram("pvapi.db.duration", "ms",
+	"Time the database took to run a statement, by operation", telemetry.DurationBuckets)
+
+// recordQuery record the time since started a statement took to run. Rows
+// of a query are read after it returns, so only the time until the first
+// row is available is recorded.
+func recordQuery(query string, started time.Time) {
+	operation := "OTHER"
+	if fields := strings.Fields(query); len(fields) > 0 {
+		operation = strings.ToUpper(fields[0])
+	}
+	queryDuration.Record(telemetry.Milliseconds(time.Since(started)), telemetry.Attributes{"db.operation": operation})
+}
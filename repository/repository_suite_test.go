@@ -0,0 +1,49 @@
+//go:build sqlite
+// +build sqlite
+
+package repository_test
+
+import (
+	"io/ioutil"
+	"main/database"
+	"main/repository"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/jmoiron/sqlx"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// Repository tests run against a migrated SQLite database and are only built
+// with -tags sqlite
+func TestRepository(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Repository Suite")
+}
+
+var dir string
+
+var _ = BeforeSuite(func() {
+	var err error
+	dir, err = ioutil.TempDir("", "repository")
+	Expect(err).To(BeNil())
+	path := filepath.Join(dir, "pvapi.db")
+
+	m, err := migrate.New("file://../database/migrations/sqlite", "sqlite3://"+path)
+	Expect(err).To(BeNil())
+	Expect(m.Up()).To(Succeed())
+
+	database.Current = database.SQLite
+	db, err := sqlx.Open(database.SQLite.Driver, path)
+	Expect(err).To(BeNil())
+	repository.Initialize(db)
+})
+
+var _ = AfterSuite(func() {
+	os.RemoveAll(dir)
+})
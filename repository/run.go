@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"main/database"
+	"main/run"
+	"time"
+
+	"github.com/jmoiron/sqlx/types"
+)
+
+// RunRepo reports of the nightly runs of the notifier and the counts the
+// admin dashboard shows alongside them
+type RunRepo interface {
+	// SaveRun create or replace the report of a run; the notifier saves the
+	// report when the run starts and again when it completes
+	SaveRun(ctx context.Context, report *run.Report) error
+
+	// ListRuns the reports of the most recent runs, newest first
+	ListRuns(ctx context.Context, limit int) ([]*run.Report, error)
+
+	// GetRun the report of a run; returns sql.ErrNoRows if there is none
+	GetRun(ctx context.Context, id string) (*run.Report, error)
+
+	// Stats counts of users, portfolios, jobs, and recent failures
+	Stats(ctx context.Context) (*run.Stats, error)
+}
+
+type runRepo struct {
+	q *querier
+}
+
+func runSelectSQL() string {
+	d := database.Current
+	return `SELECT id, job, ` + d.Epoch("for_date") + `, status, ` + d.Epoch("started") + `, ` + d.Epoch("completed") + `, portfolios, succeeded, failed, deferred, emails_sent, provider_calls, failures, reasons FROM nightly_run`
+}
+
+// scanRun read a report selected with runSelectSQL
+func scanRun(row rowScanner) (*run.Report, error) {
+	r := &run.Report{}
+	var completed sql.NullInt64
+	var providerCalls, failures, reasons types.JSONText
+	err := row.Scan(&r.ID, &r.Job, &r.ForDate, &r.Status, &r.Started, &completed, &r.Portfolios, &r.Succeeded, &r.Failed, &r.Deferred, &r.EmailsSent,
+		&providerCalls, &failures, &reasons)
+	if err != nil {
+		return nil, err
+	}
+	if completed.Valid {
+		r.Completed = completed.Int64
+		r.Duration = r.Completed - r.Started
+	}
+	if err := providerCalls.Unmarshal(&r.ProviderCalls); err != nil {
+		return nil, err
+	}
+	if err := failures.Unmarshal(&r.Failures); err != nil {
+		return nil, err
+	}
+	if err := reasons.Unmarshal(&r.Reasons); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (repo *runRepo) SaveRun(ctx context.Context, report *run.Report) error {
+	providerCalls, err := json.Marshal(report.ProviderCalls)
+	if err != nil {
+		return err
+	}
+	failures, err := json.Marshal(report.Failures)
+	if err != nil {
+		return err
+	}
+	reasons, err := json.Marshal(report.Reasons)
+	if err != nil {
+		return err
+	}
+
+	var completed interface{}
+	if report.Completed != 0 {
+		completed = time.Unix(report.Completed, 0).UTC()
+	}
+
+	_, err = repo.q.exec(ctx, `INSERT INTO nightly_run ("id", "job", "for_date", "status", "started", "completed", "portfolios", "succeeded", "failed", "deferred", "emails_sent", "provider_calls", "failures", "reasons")
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	ON CONFLICT (id) DO UPDATE SET status=EXCLUDED.status, completed=EXCLUDED.completed, portfolios=EXCLUDED.portfolios, succeeded=EXCLUDED.succeeded,
+	failed=EXCLUDED.failed, deferred=EXCLUDED.deferred, emails_sent=EXCLUDED.emails_sent, provider_calls=EXCLUDED.provider_calls, failures=EXCLUDED.failures, reasons=EXCLUDED.reasons`,
+		report.ID, report.Job, time.Unix(report.ForDate, 0).UTC(), report.Status, time.Unix(report.Started, 0).UTC(), completed, report.Portfolios,
+		report.Succeeded, report.Failed, report.Deferred, report.EmailsSent, string(providerCalls), string(failures), string(reasons))
+	return err
+}
+
+func (repo *runRepo) ListRuns(ctx context.Context, limit int) ([]*run.Report, error) {
+	rows, err := repo.q.query(ctx, runSelectSQL()+` ORDER BY started DESC LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := []*run.Report{}
+	for rows.Next() {
+		r, err := scanRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, r)
+	}
+	return reports, rows.Err()
+}
+
+func (repo *runRepo) GetRun(ctx context.Context, id string) (*run.Report, error) {
+	return scanRun(repo.q.queryRow(ctx, runSelectSQL()+` WHERE id=$1`, id))
+}
+
+func (repo *runRepo) Stats(ctx context.Context) (*run.Stats, error) {
+	stats := &run.Stats{}
+	row := repo.q.queryRow(ctx, `SELECT
+		(SELECT COUNT(*) FROM users),
+		(SELECT COUNT(*) FROM portfolio WHERE deleted_at IS NULL),
+		(SELECT COUNT(*) FROM portfolio WHERE deleted_at IS NOT NULL),
+		(SELECT COUNT(*) FROM job WHERE status='queued'),
+		(SELECT COUNT(*) FROM job WHERE status='running'),
+		(SELECT COUNT(*) FROM portfolio_failure WHERE created >= $1)`, time.Now().UTC().Add(-24*time.Hour))
+	err := row.Scan(&stats.Users, &stats.Portfolios, &stats.TrashedPortfolios, &stats.QueuedJobs, &stats.RunningJobs, &stats.FailuresLastDay)
+	if err != nil {
+		return nil, err
+	}
+
+	runs, err := repo.ListRuns(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(runs) > 0 {
+		stats.LastRun = runs[0]
+	}
+	return stats, nil
+}
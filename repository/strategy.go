@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"main/database"
+	"main/strategies"
+)
+
+// StrategyRepo the marketplace registrations of strategies; implements
+// strategies.RegistrationStore
+type StrategyRepo interface {
+	// Registrations every stored registration, ordered by shortcode
+	Registrations(ctx context.Context) ([]strategies.Registration, error)
+
+	// SaveRegistration create or replace the registration of r.Shortcode
+	SaveRegistration(ctx context.Context, r *strategies.Registration) error
+
+	// DeleteRegistration remove the registration of shortcode so the strategy
+	// is enabled for everyone; returns sql.ErrNoRows if it has none
+	DeleteRegistration(ctx context.Context, shortcode string) error
+}
+
+type strategyRepo struct {
+	q *querier
+}
+
+func (repo *strategyRepo) Registrations(ctx context.Context) ([]strategies.Registration, error) {
+	rows, err := repo.q.query(ctx, `SELECT shortcode, enabled, deprecated, deprecation_note, replacement, rollout_percent, `+database.Current.Epoch("lastchanged")+`
+		FROM strategy_registration ORDER BY shortcode`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	regs := []strategies.Registration{}
+	for rows.Next() {
+		r := strategies.Registration{}
+		if err := rows.Scan(&r.Shortcode, &r.Enabled, &r.Deprecated, &r.DeprecationNote, &r.Replacement, &r.RolloutPercent, &r.LastChanged); err != nil {
+			return nil, err
+		}
+		regs = append(regs, r)
+	}
+	return regs, rows.Err()
+}
+
+func (repo *strategyRepo) SaveRegistration(ctx context.Context, r *strategies.Registration) error {
+	_, err := repo.q.exec(ctx, `INSERT INTO strategy_registration ("shortcode", "enabled", "deprecated", "deprecation_note", "replacement", "rollout_percent") VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (shortcode) DO UPDATE SET enabled=EXCLUDED.enabled, deprecated=EXCLUDED.deprecated, deprecation_note=EXCLUDED.deprecation_note,
+	replacement=EXCLUDED.replacement, rollout_percent=EXCLUDED.rollout_percent`,
+		r.Shortcode, r.Enabled, r.Deprecated, r.DeprecationNote, r.Replacement, r.RolloutPercent)
+	return err
+}
+
+func (repo *strategyRepo) DeleteRegistration(ctx context.Context, shortcode string) error {
+	res, err := repo.q.exec(ctx, `DELETE FROM strategy_registration WHERE shortcode=$1`, shortcode)
+	return requireRow(res, err)
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"main/billing"
+	"main/database"
+	"time"
+)
+
+// SubscriptionRepo the Stripe subscriptions that set the plan of each user
+type SubscriptionRepo interface {
+	// Get the subscription of userID; returns sql.ErrNoRows if the user has
+	// never subscribed
+	Get(ctx context.Context, userID string) (*billing.Subscription, error)
+
+	// Save create or replace the subscription of sub.UserID unless the stored
+	// subscription was updated from a newer event
+	Save(ctx context.Context, sub *billing.Subscription) error
+}
+
+type subscriptionRepo struct {
+	q *querier
+}
+
+func (repo *subscriptionRepo) Get(ctx context.Context, userID string) (*billing.Subscription, error) {
+	d := database.Current
+	sub := &billing.Subscription{}
+	var trialEnds, periodEnd sql.NullInt64
+	row := repo.q.queryRow(ctx, `SELECT userid, plan, status, `+d.Epoch("trial_ends")+`, `+d.Epoch("current_period_end")+`, stripe_customer_id, stripe_subscription_id, `+d.Epoch("event_created")+` FROM subscription WHERE userid=$1`, userID)
+	if err := row.Scan(&sub.UserID, &sub.Plan, &sub.Status, &trialEnds, &periodEnd, &sub.CustomerID, &sub.SubscriptionID, &sub.EventCreated); err != nil {
+		return nil, err
+	}
+	sub.TrialEnds = trialEnds.Int64
+	sub.CurrentPeriodEnd = periodEnd.Int64
+	return sub, nil
+}
+
+func (repo *subscriptionRepo) Save(ctx context.Context, sub *billing.Subscription) error {
+	var trialEnds, periodEnd interface{}
+	if sub.TrialEnds != 0 {
+		trialEnds = time.Unix(sub.TrialEnds, 0)
+	}
+	if sub.CurrentPeriodEnd != 0 {
+		periodEnd = time.Unix(sub.CurrentPeriodEnd, 0)
+	}
+
+	_, err := repo.q.exec(ctx, `INSERT INTO subscription ("userid", "plan", "status", "trial_ends", "current_period_end", "stripe_customer_id", "stripe_subscription_id", "event_created") VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	ON CONFLICT (userid) DO UPDATE SET plan=EXCLUDED.plan, status=EXCLUDED.status, trial_ends=EXCLUDED.trial_ends, current_period_end=EXCLUDED.current_period_end,
+	stripe_customer_id=EXCLUDED.stripe_customer_id, stripe_subscription_id=EXCLUDED.stripe_subscription_id, event_created=EXCLUDED.event_created
+	WHERE subscription.event_created <= EXCLUDED.event_created`,
+		sub.UserID, sub.Plan, sub.Status, trialEnds, periodEnd, sub.CustomerID, sub.SubscriptionID, time.Unix(sub.EventCreated, 0))
+	return err
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"main/database"
+	"main/portfolio"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExecutedTransaction a trade that was actually executed by the user
+type ExecutedTransaction struct {
+	ID            uuid.UUID `json:"id"`
+	PortfolioID   uuid.UUID `json:"portfolioId"`
+	Date          int64     `json:"date"`
+	Ticker        string    `json:"ticker"`
+	Kind          string    `json:"kind"`
+	Shares        float64   `json:"shares"`
+	PricePerShare float64   `json:"pricePerShare"`
+	Fees          float64   `json:"fees"`
+	TotalValue    float64   `json:"totalValue"`
+}
+
+// Transaction convert to a portfolio transaction
+func (t *ExecutedTransaction) Transaction() portfolio.Transaction {
+	return portfolio.Transaction{
+		Date:          time.Unix(t.Date, 0).UTC(),
+		Ticker:        t.Ticker,
+		Kind:          t.Kind,
+		PricePerShare: t.PricePerShare,
+		Shares:        t.Shares,
+		TotalValue:    t.TotalValue,
+		Fees:          t.Fees,
+	}
+}
+
+// TransactionRepo the trades users record as executed against their
+// portfolios
+type TransactionRepo interface {
+	// List the executed transactions userID recorded against a portfolio,
+	// ordered by trade date
+	List(ctx context.Context, portfolioID string, userID string) ([]ExecutedTransaction, error)
+
+	// Create save a new executed transaction recorded by userID, assigning
+	// its ID
+	Create(ctx context.Context, t *ExecutedTransaction, userID string) error
+
+	// Delete remove an executed transaction; returns sql.ErrNoRows if it
+	// does not exist or userID did not record it against the portfolio
+	Delete(ctx context.Context, id string, portfolioID string, userID string) error
+}
+
+type transactionRepo struct {
+	q *querier
+}
+
+func (repo *transactionRepo) List(ctx context.Context, portfolioID string, userID string) ([]ExecutedTransaction, error) {
+	rows, err := repo.q.query(ctx, `SELECT id, portfolio_id, `+database.Current.Epoch("trade_date")+`, ticker, kind, shares, price_per_share, fees, total_value
+		FROM executed_transaction WHERE portfolio_id=$1 AND userid=$2 ORDER BY trade_date, created`, portfolioID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	trxs := []ExecutedTransaction{}
+	for rows.Next() {
+		t := ExecutedTransaction{}
+		if err := rows.Scan(&t.ID, &t.PortfolioID, &t.Date, &t.Ticker, &t.Kind, &t.Shares, &t.PricePerShare, &t.Fees, &t.TotalValue); err != nil {
+			return nil, err
+		}
+		trxs = append(trxs, t)
+	}
+	return trxs, rows.Err()
+}
+
+func (repo *transactionRepo) Create(ctx context.Context, t *ExecutedTransaction, userID string) error {
+	t.ID = uuid.New()
+	_, err := repo.q.exec(ctx, `INSERT INTO executed_transaction ("id", "portfolio_id", "userid", "trade_date", "ticker", "kind", "shares", "price_per_share", "fees", "total_value") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		t.ID, t.PortfolioID, userID, time.Unix(t.Date, 0), t.Ticker, t.Kind, t.Shares, t.PricePerShare, t.Fees, t.TotalValue)
+	return err
+}
+
+func (repo *transactionRepo) Delete(ctx context.Context, id string, portfolioID string, userID string) error {
+	res, err := repo.q.exec(ctx, `DELETE FROM executed_transaction WHERE id=$1 AND portfolio_id=$2 AND userid=$3`, id, portfolioID, userID)
+	return requireRow(res, err)
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"main/data"
+	"time"
+)
+
+// UsageRepo API calls made to data providers per token and hour; implements
+// data.UsageStore
+type UsageRepo interface {
+	// AddUsage add the calls of each record to the stored totals
+	AddUsage(ctx context.Context, records []data.UsageRecord) error
+
+	// UsageSince stored usage of tokenHash in the hours starting at or after
+	// since
+	UsageSince(ctx context.Context, tokenHash string, since time.Time) ([]data.UsageRecord, error)
+}
+
+type usageRepo struct {
+	q *querier
+}
+
+func (repo *usageRepo) AddUsage(ctx context.Context, records []data.UsageRecord) error {
+	for _, r := range records {
+		_, err := repo.q.exec(ctx, `INSERT INTO provider_usage ("token_hash", "provider", "hour", "calls") VALUES ($1, $2, $3, $4)
+			ON CONFLICT (token_hash, provider, hour) DO UPDATE SET calls=provider_usage.calls + EXCLUDED.calls`,
+			r.TokenHash, r.Provider, r.Hour.UTC(), r.Calls)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (repo *usageRepo) UsageSince(ctx context.Context, tokenHash string, since time.Time) ([]data.UsageRecord, error) {
+	rows, err := repo.q.query(ctx, `SELECT provider, hour, calls FROM provider_usage WHERE token_hash=$1 AND hour >= $2 ORDER BY hour`,
+		tokenHash, since.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []data.UsageRecord{}
+	for rows.Next() {
+		r := data.UsageRecord{TokenHash: tokenHash}
+		if err := rows.Scan(&r.Provider, &r.Hour, &r.Calls); err != nil {
+			return nil, err
+		}
+		r.Hour = r.Hour.UTC()
+		records = append(records, r)
+	}
+
+	return records, rows.Err()
+}
@@ -0,0 +1,216 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"main/database"
+	"main/preferences"
+	"main/secret"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx/types"
+)
+
+// User the account of a user synchronized from Auth0. Verified is Auth0's
+// flag; VerifiedEmail is the address the user last verified with the service,
+// which Auth0 synchronization does not change.
+type User struct {
+	ID          string
+	Name        string
+	Email       string
+	Verified    bool
+	TiingoToken string
+	Synced      time.Time
+
+	VerifiedEmail    string
+	VerifiedAt       int64
+	VerificationSent int64
+}
+
+// EmailVerified true if Auth0 verified the user's email address or the user
+// verified the current address with the service
+func (u *User) EmailVerified() bool {
+	return u.Verified || (u.VerifiedEmail != "" && strings.EqualFold(u.VerifiedEmail, u.Email))
+}
+
+// UserRepo accounts synchronized from Auth0, settings of users, and the email
+// addresses that must not be sent messages
+type UserRepo interface {
+	// Get retrieve a user; returns sql.ErrNoRows if the user has not been
+	// synchronized
+	Get(ctx context.Context, userID string) (*User, error)
+
+	// SaveUser create or replace a user. The Tiingo token is encrypted with
+	// the key in USER_TOKEN_KEY.
+	SaveUser(ctx context.Context, u *User) error
+
+	// MarkVerified record that userID verified email at verified
+	MarkVerified(ctx context.Context, userID string, email string, verified time.Time) error
+
+	// SetVerificationSent record when a verification email was last sent to
+	// userID
+	SetVerificationSent(ctx context.Context, userID string, sent time.Time) error
+
+	// IDByEmail the id of the user with email, ignoring case; returns
+	// sql.ErrNoRows if no stored user has it
+	IDByEmail(ctx context.Context, email string) (string, error)
+
+	// ListIDs the ids of all stored users
+	ListIDs(ctx context.Context) ([]string, error)
+
+	// Delete remove a stored user, their preferences, and their
+	// organization memberships
+	Delete(ctx context.Context, userID string) error
+
+	// Audit record a change made to a user; detail is stored as JSON
+	Audit(ctx context.Context, userID string, action string, detail interface{}) error
+
+	// Preferences retrieve the preferences of userID; users that have not
+	// saved any preferences receive the defaults
+	Preferences(ctx context.Context, userID string) (*preferences.Preferences, error)
+
+	// SavePreferences create or replace the preferences of prefs.UserID
+	SavePreferences(ctx context.Context, prefs *preferences.Preferences) error
+
+	// SuppressEmail record that email must not receive further messages
+	SuppressEmail(ctx context.Context, email string, reason string, detail string, created time.Time) error
+
+	// SuppressedEmails the lower case addresses that must not receive messages
+	SuppressedEmails(ctx context.Context) ([]string, error)
+}
+
+type userRepo struct {
+	q *querier
+}
+
+func (repo *userRepo) Get(ctx context.Context, userID string) (*User, error) {
+	u := &User{}
+	var token string
+	var verifiedAt, verificationSent sql.NullInt64
+	d := database.Current
+	row := repo.q.queryRow(ctx, `SELECT userid, name, email, email_verified, tiingo_token, synced, verified_email, `+d.Epoch("email_verified_at")+`, `+d.Epoch("verification_sent")+` FROM users WHERE userid=$1`, userID)
+	if err := row.Scan(&u.ID, &u.Name, &u.Email, &u.Verified, &token, &u.Synced, &u.VerifiedEmail, &verifiedAt, &verificationSent); err != nil {
+		return nil, err
+	}
+	u.VerifiedAt = verifiedAt.Int64
+	u.VerificationSent = verificationSent.Int64
+
+	if token != "" {
+		key, err := secret.Key()
+		if err != nil {
+			return nil, err
+		}
+		if u.TiingoToken, err = secret.Open(key, token); err != nil {
+			return nil, err
+		}
+	}
+
+	return u, nil
+}
+
+func (repo *userRepo) SaveUser(ctx context.Context, u *User) error {
+	var token string
+	if u.TiingoToken != "" {
+		key, err := secret.Key()
+		if err != nil {
+			return err
+		}
+		if token, err = secret.Seal(key, u.TiingoToken); err != nil {
+			return err
+		}
+	}
+
+	if u.Synced.IsZero() {
+		u.Synced = time.Now()
+	}
+
+	_, err := repo.q.exec(ctx, `INSERT INTO users ("userid", "name", "email", "email_verified", "tiingo_token", "synced") VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (userid) DO UPDATE SET name=EXCLUDED.name, email=EXCLUDED.email, email_verified=EXCLUDED.email_verified, tiingo_token=EXCLUDED.tiingo_token, synced=EXCLUDED.synced`,
+		u.ID, u.Name, u.Email, u.Verified, token, u.Synced)
+	return err
+}
+
+func (repo *userRepo) MarkVerified(ctx context.Context, userID string, email string, verified time.Time) error {
+	res, err := repo.q.exec(ctx, `UPDATE users SET verified_email=$1, email_verified_at=$2 WHERE userid=$3`, email, verified, userID)
+	return requireRow(res, err)
+}
+
+func (repo *userRepo) SetVerificationSent(ctx context.Context, userID string, sent time.Time) error {
+	res, err := repo.q.exec(ctx, `UPDATE users SET verification_sent=$1 WHERE userid=$2`, sent, userID)
+	return requireRow(res, err)
+}
+
+func (repo *userRepo) IDByEmail(ctx context.Context, email string) (string, error) {
+	var userID string
+	err := repo.q.queryRow(ctx, `SELECT userid FROM users WHERE lower(email)=lower($1) ORDER BY synced DESC LIMIT 1`, email).Scan(&userID)
+	return userID, err
+}
+
+func (repo *userRepo) ListIDs(ctx context.Context) ([]string, error) {
+	return queryStrings(ctx, repo.q, `SELECT userid FROM users ORDER BY userid`)
+}
+
+func (repo *userRepo) Delete(ctx context.Context, userID string) error {
+	if _, err := repo.q.exec(ctx, `DELETE FROM user_settings WHERE userid=$1`, userID); err != nil {
+		return err
+	}
+	if _, err := repo.q.exec(ctx, `DELETE FROM organization_member WHERE user_id=$1`, userID); err != nil {
+		return err
+	}
+	_, err := repo.q.exec(ctx, `DELETE FROM users WHERE userid=$1`, userID)
+	return err
+}
+
+func (repo *userRepo) Audit(ctx context.Context, userID string, action string, detail interface{}) error {
+	js, err := json.Marshal(detail)
+	if err != nil {
+		return err
+	}
+	_, err = repo.q.exec(ctx, `INSERT INTO user_audit ("userid", "action", "detail") VALUES ($1, $2, $3)`, userID, action, string(js))
+	return err
+}
+
+func (repo *userRepo) Preferences(ctx context.Context, userID string) (*preferences.Preferences, error) {
+	prefs := preferences.Default()
+	prefs.UserID = userID
+
+	var channels types.JSONText
+	row := repo.q.queryRow(ctx, `SELECT base_currency, digest, notification_channels, default_benchmark, timezone, locale, number_format, share_stats FROM user_settings WHERE userid=$1`, userID)
+	err := row.Scan(&prefs.BaseCurrency, &prefs.Digest, &channels, &prefs.DefaultBenchmark, &prefs.Timezone, &prefs.Locale, &prefs.NumberFormat, &prefs.ShareStats)
+	if err == sql.ErrNoRows {
+		return &prefs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := channels.Unmarshal(&prefs.NotificationChannels); err != nil {
+		return nil, err
+	}
+	return &prefs, nil
+}
+
+func (repo *userRepo) SavePreferences(ctx context.Context, prefs *preferences.Preferences) error {
+	channels, err := json.Marshal(prefs.NotificationChannels)
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.q.exec(ctx, `INSERT INTO user_settings ("userid", "base_currency", "digest", "notification_channels", "default_benchmark", "timezone", "locale", "number_format", "share_stats") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	ON CONFLICT (userid) DO UPDATE SET base_currency=EXCLUDED.base_currency, digest=EXCLUDED.digest, notification_channels=EXCLUDED.notification_channels,
+	default_benchmark=EXCLUDED.default_benchmark, timezone=EXCLUDED.timezone, locale=EXCLUDED.locale, number_format=EXCLUDED.number_format, share_stats=EXCLUDED.share_stats`,
+		prefs.UserID, prefs.BaseCurrency, prefs.Digest, string(channels), prefs.DefaultBenchmark, prefs.Timezone, prefs.Locale, prefs.NumberFormat, prefs.ShareStats)
+	return err
+}
+
+func (repo *userRepo) SuppressEmail(ctx context.Context, email string, reason string, detail string, created time.Time) error {
+	_, err := repo.q.exec(ctx, `INSERT INTO email_suppression ("email", "reason", "detail", "created") VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING`,
+		strings.ToLower(email), reason, detail, created)
+	return err
+}
+
+func (repo *userRepo) SuppressedEmails(ctx context.Context) ([]string, error) {
+	return queryStrings(ctx, repo.q, `SELECT email FROM email_suppression`)
+}
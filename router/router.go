@@ -12,20 +12,181 @@ import (
 func SetupRoutes(app *fiber.App, jwks map[string]interface{}) {
 	// Middleware
 	api := app.Group("/v1", logger.New())
+
+	// compute-heavy routes share a per-user quota
+	compute := middleware.RateLimit(middleware.ComputeRateLimit())
+	// and a per-user budget of concurrent work and compute time, counting
+	// background jobs
+	budgetConfig := middleware.ComputeBudgetLimits()
+	budgetConfig.Background = handler.JobUsage
+	budget := middleware.NewComputeBudget(budgetConfig).Limit()
+
+	// GET responses are cached per user and invalidated when the user changes
+	// a portfolio or benchmark; changes to a portfolio shared by an
+	// organization invalidate the responses of every member
+	responses := middleware.NewResponseCache(middleware.ResponseCacheConfig{})
+	invalidate := responses.Invalidate()
+	invalidatePortfolio := responses.InvalidateFor(handler.PortfolioAudience)
+	invalidateOrganization := responses.InvalidateFor(handler.OrganizationAudience)
+
 	api.Get("/", handler.Ping)
-	api.Post("/benchmark", middleware.JWTAuth(jwks), handler.Benchmark)
+	api.Post("/benchmark", middleware.JWTAuth(jwks), compute, budget, handler.Benchmark)
+	api.Post("/compare", middleware.JWTAuth(jwks), compute, budget, handler.ComparePortfolios)
 
 	// Strategy
 	strategy := api.Group("/strategy")
 	strategy.Get("/:id", middleware.JWTAuth(jwks), handler.GetStrategy)
-	strategy.Get("/", middleware.JWTAuth(jwks), handler.ListStrategies)
-	strategy.Post("/:id", middleware.JWTAuth(jwks), handler.RunStrategy)
+	strategy.Get("/", middleware.JWTAuth(jwks), responses.Cache(handler.StrategyListExpiration), handler.ListStrategies)
+	strategy.Post("/screen", middleware.JWTAuth(jwks), compute, budget, handler.ScreenStrategies)
+	strategy.Post("/batch", middleware.JWTAuth(jwks), compute, budget, handler.RunStrategyBatch)
+	strategy.Post("/:id", middleware.JWTAuth(jwks), compute, budget, handler.RunStrategy)
+	strategy.Post("/:id/jobs", middleware.JWTAuth(jwks), compute, budget, handler.EnqueueStrategy)
+	strategy.Get("/:id/explain", middleware.JWTAuth(jwks), compute, budget, handler.ExplainStrategy)
+	strategy.Post("/:id/proxies", middleware.JWTAuth(jwks), handler.SuggestETFProxies)
+
+	// Jobs run by cmd/worker
+	api.Get("/jobs/:id", middleware.JWTAuth(jwks), handler.GetJob)
 
 	// Portfolio
 	portfolio := api.Group("/portfolio")
+	portfolio.Get("/aggregate", middleware.JWTAuth(jwks), handler.AggregatePortfolios)
+	portfolio.Get("/trash", middleware.JWTAuth(jwks), handler.ListTrashedPortfolios)
+	portfolio.Get("/tags", middleware.JWTAuth(jwks), handler.ListPortfolioTags)
 	portfolio.Get("/:id", middleware.JWTAuth(jwks), handler.GetPortfolio)
+	portfolio.Get("/:id/performance", middleware.JWTAuth(jwks), responses.Cache(handler.PerformanceExpiration), handler.GetPortfolioPerformance)
+	portfolio.Put("/:id/benchmarks", middleware.JWTAuth(jwks), invalidatePortfolio, handler.SetPortfolioBenchmarks)
+	portfolio.Get("/:id/rolling", middleware.JWTAuth(jwks), compute, budget, handler.GetRollingAlphaBeta)
 	portfolio.Get("/", middleware.JWTAuth(jwks), handler.ListPortfolios)
 	portfolio.Post("/", middleware.JWTAuth(jwks), handler.CreatePortfolio)
-	portfolio.Patch("/:id", middleware.JWTAuth(jwks), handler.UpdatePortfolio)
-	portfolio.Delete("/:id", middleware.JWTAuth(jwks), handler.DeletePortfolio)
+	portfolio.Patch("/:id", middleware.JWTAuth(jwks), invalidatePortfolio, handler.UpdatePortfolio)
+	portfolio.Delete("/:id", middleware.JWTAuth(jwks), invalidatePortfolio, handler.DeletePortfolio)
+	portfolio.Post("/:id/restore", middleware.JWTAuth(jwks), invalidatePortfolio, handler.RestorePortfolio)
+	portfolio.Post("/:id/clone", middleware.JWTAuth(jwks), handler.ClonePortfolio)
+	portfolio.Put("/:id/organization", middleware.JWTAuth(jwks), invalidatePortfolio, handler.SetPortfolioOrganization)
+	portfolio.Put("/:id/tags", middleware.JWTAuth(jwks), handler.SetPortfolioTags)
+	portfolio.Get("/:id/transactions", middleware.JWTAuth(jwks), handler.ListExecutedTransactions)
+	portfolio.Post("/:id/transactions", middleware.JWTAuth(jwks), handler.CreateExecutedTransaction)
+	portfolio.Delete("/:id/transactions/:trxId", middleware.JWTAuth(jwks), handler.DeleteExecutedTransaction)
+	portfolio.Get("/:id/slippage", middleware.JWTAuth(jwks), handler.SlippageReport)
+	portfolio.Get("/:id/reconcile", middleware.JWTAuth(jwks), handler.ReconcilePortfolio)
+	portfolio.Get("/:id/orders", middleware.JWTAuth(jwks), compute, budget, handler.GenerateOrders)
+	portfolio.Get("/:id/orders/pending", middleware.JWTAuth(jwks), handler.ListPendingOrders)
+	portfolio.Post("/:id/orders/pending", middleware.JWTAuth(jwks), compute, budget, handler.CreatePendingOrders)
+	portfolio.Post("/:id/orders/pending/:batchId/confirm", middleware.JWTAuth(jwks), handler.ConfirmPendingOrders)
+	portfolio.Post("/:id/orders/pending/:batchId/cancel", middleware.JWTAuth(jwks), handler.CancelPendingOrders)
+	portfolio.Get("/:id/stress", middleware.JWTAuth(jwks), compute, budget, handler.StressTestPortfolio)
+	portfolio.Get("/:id/regimes", middleware.JWTAuth(jwks), compute, budget, handler.AnalyzeRegimes)
+	portfolio.Get("/:id/factors", middleware.JWTAuth(jwks), compute, budget, handler.FactorRegression)
+	portfolio.Get("/:id/seasonality", middleware.JWTAuth(jwks), compute, budget, handler.GetSeasonality)
+	portfolio.Get("/:id/trades", middleware.JWTAuth(jwks), compute, budget, handler.ListTrades)
+	portfolio.Post("/:id/whatif", middleware.JWTAuth(jwks), compute, budget, handler.WhatIfPortfolio)
+	portfolio.Post("/:id/withdrawals", middleware.JWTAuth(jwks), compute, budget, handler.SimulateWithdrawals)
+	portfolio.Get("/:id/migrations", middleware.JWTAuth(jwks), handler.ListStrategyMigrations)
+	portfolio.Get("/:id/signals", middleware.JWTAuth(jwks), handler.ListSignals)
+	portfolio.Get("/:id/revisions", middleware.JWTAuth(jwks), handler.ListRevisions)
+	portfolio.Get("/:id/community", middleware.JWTAuth(jwks), handler.GetPortfolioCommunity)
+	portfolio.Get("/:id/notes", middleware.JWTAuth(jwks), handler.ListNotes)
+	portfolio.Post("/:id/notes", middleware.JWTAuth(jwks), invalidatePortfolio, handler.CreateNote)
+	portfolio.Put("/:id/notes/:noteId", middleware.JWTAuth(jwks), invalidatePortfolio, handler.UpdateNote)
+	portfolio.Delete("/:id/notes/:noteId", middleware.JWTAuth(jwks), invalidatePortfolio, handler.DeleteNote)
+	portfolio.Get("/:id/next-signal", middleware.JWTAuth(jwks), compute, budget, handler.NextSignal)
+	portfolio.Get("/:id/holdings", middleware.JWTAuth(jwks), compute, budget, handler.GetHoldings)
+	portfolio.Get("/:id/allocations", middleware.JWTAuth(jwks), compute, budget, handler.GetAllocationHistory)
+	portfolio.Get("/:id/income", middleware.JWTAuth(jwks), handler.GetIncomeReport)
+	portfolio.Get("/:id/dividends", middleware.JWTAuth(jwks), compute, budget, handler.GetDividendCalendar)
+	portfolio.Get("/:id/substitution", middleware.JWTAuth(jwks), compute, budget, handler.SubstitutionAnalysis)
+	portfolio.Get("/:id/estimate", middleware.JWTAuth(jwks), compute, budget, handler.EstimatePortfolio)
+	portfolio.Get("/:id/export", middleware.JWTAuth(jwks), compute, budget, handler.ExportPortfolio)
+	portfolio.Post("/:id/reconcile", middleware.JWTAuth(jwks), handler.ImportBrokerStatement)
+
+	// Gallery of strategy templates is public; creating a portfolio from one
+	// is not
+	gallery := api.Group("/gallery")
+	gallery.Get("/", handler.ListGallery)
+	gallery.Post("/:id", middleware.JWTAuth(jwks), handler.CreateFromTemplate)
+
+	// Organizations share portfolios between their members
+	org := api.Group("/org")
+	org.Get("/", middleware.JWTAuth(jwks), handler.ListOrganizations)
+	org.Post("/", middleware.JWTAuth(jwks), handler.CreateOrganization)
+	org.Get("/:id", middleware.JWTAuth(jwks), handler.GetOrganization)
+	org.Patch("/:id", middleware.JWTAuth(jwks), handler.UpdateOrganization)
+	org.Delete("/:id", middleware.JWTAuth(jwks), invalidateOrganization, handler.DeleteOrganization)
+	org.Post("/:id/members", middleware.JWTAuth(jwks), handler.AddMember)
+	org.Patch("/:id/members/:userId", middleware.JWTAuth(jwks), handler.UpdateMember)
+	org.Delete("/:id/members/:userId", middleware.JWTAuth(jwks), handler.RemoveMember)
+
+	// Anonymized statistics of the portfolios of users that opted in
+	api.Get("/community", middleware.JWTAuth(jwks), handler.ListCommunityStats)
+
+	// Prices
+	api.Get("/prices/export", middleware.JWTAuth(jwks), compute, budget, handler.ExportPrices)
+
+	// Data provider usage of the user's token
+	api.Get("/usage", middleware.JWTAuth(jwks), handler.GetUsage)
+
+	// Plan and limits of the user's subscription
+	api.Get("/billing", middleware.JWTAuth(jwks), handler.GetEntitlements)
+
+	// Benchmark catalogue
+	benchmarks := api.Group("/benchmarks")
+	benchmarks.Get("/", middleware.JWTAuth(jwks), handler.ListBenchmarks)
+	benchmarks.Post("/", middleware.JWTAuth(jwks), handler.CreateBenchmark)
+	benchmarks.Patch("/:id", middleware.JWTAuth(jwks), invalidate, handler.UpdateBenchmark)
+	benchmarks.Delete("/:id", middleware.JWTAuth(jwks), invalidate, handler.DeleteBenchmark)
+
+	// Preferences
+	prefs := api.Group("/preferences")
+	prefs.Get("/", middleware.JWTAuth(jwks), handler.GetPreferences)
+	prefs.Patch("/", middleware.JWTAuth(jwks), handler.UpdatePreferences)
+
+	// Unsubscribe links in notification emails are authorized by their signature
+	unsubscribe := api.Group("/unsubscribe")
+	unsubscribe.Get("/", handler.Unsubscribe)
+	unsubscribe.Get("/preferences", handler.GetNotificationPreferences)
+	unsubscribe.Put("/preferences", handler.UpdateNotificationPreferences)
+
+	// Order confirmation links are authorized by their signature
+	orders := api.Group("/orders")
+	orders.Get("/", handler.GetLinkedOrders)
+	orders.Post("/confirm", handler.ConfirmLinkedOrders)
+	orders.Post("/cancel", handler.CancelLinkedOrders)
+
+	// Email verification; verification links are authorized by their signature
+	verification := api.Group("/verification")
+	verification.Get("/", middleware.JWTAuth(jwks), handler.GetEmailVerification)
+	verification.Post("/resend", middleware.JWTAuth(jwks), handler.ResendVerification)
+	verification.Get("/confirm", handler.VerifyEmail)
+
+	// SendGrid event webhook is authorized by its signature
+	api.Post("/sendgrid/events", handler.SendGridEvents)
+
+	// Stripe event webhook is authorized by its signature
+	api.Post("/stripe/events", handler.StripeEvents)
+
+	// Auth0 actions are authorized by a shared secret
+	api.Post("/auth0/hook", handler.Auth0Hook)
+
+	// Administration
+	admin := api.Group("/admin", middleware.JWTAuth(jwks), middleware.Admin())
+	admin.Get("/templates", handler.ListTemplates)
+	admin.Post("/templates", handler.CreateTemplate)
+	admin.Patch("/templates/:id", handler.UpdateTemplate)
+	admin.Delete("/templates/:id", handler.DeleteTemplate)
+	admin.Post("/templates/:id/preview", handler.PreviewTemplate)
+	admin.Post("/users/sync", handler.SyncUsers)
+	admin.Get("/strategies", handler.ListRegistrations)
+	admin.Put("/strategies/:id", handler.UpdateRegistration)
+	admin.Delete("/strategies/:id", handler.DeleteRegistration)
+	admin.Get("/stats", handler.GetSystemStats)
+	admin.Get("/runs", handler.ListRuns)
+	admin.Get("/runs/:id", handler.GetRun)
+	admin.Get("/logs", handler.ListLogEvents)
+
+	// Alert
+	alert := api.Group("/alert")
+	alert.Get("/", middleware.JWTAuth(jwks), handler.ListAlerts)
+	alert.Post("/", middleware.JWTAuth(jwks), handler.CreateAlert)
+	alert.Patch("/:id", middleware.JWTAuth(jwks), handler.UpdateAlert)
+	alert.Delete("/:id", middleware.JWTAuth(jwks), handler.DeleteAlert)
 }
@@ -0,0 +1,323 @@
+// Package rpc serves the backtest engine over gRPC so internal services can
+// run strategies without the HTTP/JSON overhead of the public API. The
+// service is defined in pvapi.proto; pvapi.pb.go and pvapi_grpc.pb.go are
+// generated from it with `make proto`.
+package rpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"main/data"
+	"main/logging"
+	"main/portfolio"
+	"main/repository"
+	"main/strategies"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// StrategyServer implements StrategyServiceServer
+type StrategyServer struct {
+	UnimplementedStrategyServiceServer
+}
+
+// NewServer create a gRPC server with the strategy service registered. Every
+// call must present token as a bearer token in its authorization metadata.
+func NewServer(token string) *grpc.Server {
+	server := grpc.NewServer(grpc.UnaryInterceptor(Authenticate(token)))
+	RegisterStrategyServiceServer(server, &StrategyServer{})
+	return server
+}
+
+// Authenticate reject calls that do not carry token in their authorization
+// metadata as "Bearer <token>"
+func Authenticate(token string) grpc.UnaryServerInterceptor {
+	expected := []byte("Bearer " + token)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		auth := md.Get("authorization")
+		if token == "" || len(auth) != 1 || subtle.ConstantTimeCompare([]byte(auth[0]), expected) != 1 {
+			return nil, status.Error(codes.Unauthenticated, "invalid authorization token")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// parseResolution measurement resolution; daily, weekly, or monthly (the
+// default)
+func parseResolution(resolution string) (string, error) {
+	switch strings.ToLower(resolution) {
+	case "daily":
+		return data.FrequencyDaily, nil
+	case "weekly":
+		return data.FrequencyWeekly, nil
+	case "monthly", "":
+		return data.FrequencyMonthly, nil
+	}
+	return "", fmt.Errorf("invalid resolution '%s'", resolution)
+}
+
+// today midnight UTC of the current date
+func today() time.Time {
+	year, month, day := time.Now().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// ListStrategies strategies that can be run
+func (s *StrategyServer) ListStrategies(ctx context.Context, req *ListStrategiesRequest) (*ListStrategiesResponse, error) {
+	resp := &ListStrategiesResponse{
+		Strategies: make([]*Strategy, 0, len(strategies.StrategyList)),
+	}
+	for _, info := range strategies.StrategyList {
+		strat := &Strategy{
+			Shortcode:   info.Shortcode,
+			Name:        info.Name,
+			Description: info.Description,
+			Source:      info.Source,
+			Version:     info.Version,
+			Arguments:   make(map[string]*Argument, len(info.Arguments)),
+		}
+		for key, arg := range info.Arguments {
+			strat.Arguments[key] = &Argument{
+				Name:         arg.Name,
+				Description:  arg.Description,
+				Typecode:     arg.Typecode,
+				DefaultValue: arg.DefaultVal,
+				Options:      arg.Options,
+				Tickers:      arg.Tickers,
+			}
+		}
+		resp.Strategies = append(resp.Strategies, strat)
+	}
+	return resp, nil
+}
+
+// RunStrategy backtest a strategy with the given arguments
+func (s *StrategyServer) RunStrategy(ctx context.Context, req *RunStrategyRequest) (resp *Performance, err error) {
+	strat, ok := strategies.StrategyMap[req.Shortcode]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "strategy '%s' not found", req.Shortcode)
+	}
+
+	startDate := time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+	if req.StartDate != "" {
+		if startDate, err = time.Parse("2006-01-02", req.StartDate); err != nil {
+			return nil, status.Error(codes.InvalidArgument, "start_date must be formatted YYYY-MM-DD")
+		}
+	}
+
+	endDate := today()
+	if req.EndDate != "" {
+		if endDate, err = time.Parse("2006-01-02", req.EndDate); err != nil {
+			return nil, status.Error(codes.InvalidArgument, "end_date must be formatted YYYY-MM-DD")
+		}
+	}
+
+	resolution, err := parseResolution(req.Resolution)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	params := map[string]json.RawMessage{}
+	if err := json.Unmarshal([]byte(req.Arguments), &params); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "arguments must be a JSON object: %s", err)
+	}
+
+	stratObject, err := strat.Factory(params)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	manager := data.NewManager(map[string]string{
+		"tiingo": req.TiingoToken,
+	})
+	manager.Begin = startDate
+	manager.End = endDate
+
+	// history is only required when an explicit start date is given
+	historyFrom := time.Time{}
+	if req.StartDate != "" {
+		historyFrom = startDate
+	}
+	if err := strat.CheckConstraints(params, historyFrom, &manager); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.WithFields(log.Fields{
+				logging.FieldFunction: "rpc/server.go:RunStrategy",
+				logging.FieldStrategy: req.Shortcode,
+				logging.FieldError:    r,
+			}).Error("Strategy panicked")
+			debug.PrintStack()
+			resp = nil
+			err = status.Error(codes.Internal, "strategy computation failed")
+		}
+	}()
+
+	p, err := stratObject.Compute(&manager)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	p.Resolution = resolution
+	perf, err := p.CalculatePerformance(manager.End)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if req.AccountType != "" {
+		if err := perf.CalculateAfterTax(req.AccountType, portfolio.DefaultTaxRates); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	perf.BuildMetricsBundle()
+	return PerformanceFromPortfolio(&perf), nil
+}
+
+// GetPerformance performance of a saved portfolio from its start date through
+// today, including after-tax values for its account type
+func (s *StrategyServer) GetPerformance(ctx context.Context, req *GetPerformanceRequest) (*Performance, error) {
+	resolution, err := parseResolution(req.Resolution)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	p, err := repository.Portfolios.Get(ctx, req.PortfolioId, req.UserId)
+	if err != nil {
+		log.WithFields(log.Fields{
+			logging.FieldFunction:  "rpc/server.go:GetPerformance",
+			logging.FieldPortfolio: req.PortfolioId,
+			logging.FieldError:     err,
+		}).Warn("Cannot load portfolio")
+		return nil, status.Errorf(codes.NotFound, "portfolio '%s' not found", req.PortfolioId)
+	}
+
+	strat, ok := strategies.StrategyMap[p.Strategy]
+	if !ok {
+		return nil, status.Errorf(codes.FailedPrecondition, "strategy '%s' not found", p.Strategy)
+	}
+
+	params := map[string]json.RawMessage{}
+	if err := json.Unmarshal(p.Arguments, &params); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	// arguments saved for an earlier version of the strategy are migrated in
+	// memory; the notifier persists the migration
+	params, _, err = strat.MigrateArguments(p.StrategyVersion, params)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	stratObject, err := strat.Factory(params)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	endDate := today()
+	manager := data.NewManager(map[string]string{
+		"tiingo": req.TiingoToken,
+	})
+	manager.Begin = time.Unix(p.StartDate, 0)
+	manager.End = endDate
+
+	computed, err := stratObject.Compute(&manager)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	computed.Resolution = resolution
+	perf, err := computed.CalculatePerformance(endDate)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	if err := perf.CalculateAfterTax(p.AccountType, p.TaxRates()); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	perf.BuildMetricsBundle()
+	return PerformanceFromPortfolio(&perf), nil
+}
+
+// PerformanceFromPortfolio convert a calculated performance to its protobuf
+// message; measurements are encoded as columns
+func PerformanceFromPortfolio(perf *portfolio.Performance) *Performance {
+	cols := portfolio.Columns(perf.Measurements)
+	msg := &Performance{
+		PeriodStart: perf.PeriodStart,
+		PeriodEnd:   perf.PeriodEnd,
+		ComputedOn:  perf.ComputedOn,
+		Measurements: &Measurements{
+			Time:          cols.Time,
+			Value:         cols.Value,
+			RiskFreeValue: cols.RiskFreeValue,
+			Holdings:      cols.Holdings,
+			PercentReturn: cols.PercentReturn,
+			Leverage:      cols.Leverage,
+			AfterTaxValue: cols.AfterTaxValue,
+		},
+		Transactions:       make([]*Transaction, 0, len(perf.Transactions)),
+		CagrSinceInception: perf.CagrSinceInception,
+		YtdReturn:          perf.YTDReturn,
+		CurrentAsset:       perf.CurrentAsset,
+		TotalDeposited:     perf.TotalDeposited,
+		TotalWithdrawn:     perf.TotalWithdrawn,
+		AccountType:        perf.AccountType,
+		TaxesPaid:          perf.TaxesPaid,
+		CurrentHoldings:    perf.CurrentHoldings,
+	}
+
+	for _, trx := range perf.Transactions {
+		msg.Transactions = append(msg.Transactions, &Transaction{
+			Date:          trx.Date.Unix(),
+			Ticker:        trx.Ticker,
+			Kind:          trx.Kind,
+			PricePerShare: trx.PricePerShare,
+			Shares:        trx.Shares,
+			TotalValue:    trx.TotalValue,
+			Fees:          trx.Fees,
+		})
+	}
+
+	metrics := perf.MetricsBundle
+	msg.Metrics = &Metrics{
+		Cagr_1Yr:         metrics.CAGRS.OneYear,
+		Cagr_3Yr:         metrics.CAGRS.ThreeYear,
+		Cagr_5Yr:         metrics.CAGRS.FiveYear,
+		Cagr_10Yr:        metrics.CAGRS.TenYear,
+		DrawDowns:        make([]*DrawDown, 0, len(metrics.DrawDowns)),
+		SharpeRatio:      metrics.SharpeRatio,
+		SortinoRatio:     metrics.SortinoRatio,
+		StdDev:           metrics.StdDev,
+		UlcerIndexAvg:    metrics.UlcerIndexAvg,
+		CalmarRatio:      metrics.CalmarRatio,
+		KRatio:           metrics.KRatio,
+		Skewness:         metrics.Skewness,
+		ExcessKurtosis:   metrics.ExcessKurtosis,
+		GainLossRatio:    metrics.GainLossRatio,
+		NPositivePeriods: int64(metrics.NPositivePeriods),
+	}
+	for _, dd := range metrics.DrawDowns {
+		msg.Metrics.DrawDowns = append(msg.Metrics.DrawDowns, &DrawDown{
+			Begin:       dd.Begin,
+			End:         dd.End,
+			Recovery:    dd.Recovery,
+			LossPercent: dd.LossPercent,
+		})
+	}
+
+	return msg
+}
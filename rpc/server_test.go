@@ -0,0 +1,133 @@
+package rpc_test
+
+import (
+	"context"
+	"main/portfolio"
+	"main/rpc"
+	"main/strategies"
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+var _ = Describe("StrategyService", func() {
+	var (
+		server *grpc.Server
+		conn   *grpc.ClientConn
+		client rpc.StrategyServiceClient
+		ctx    context.Context
+	)
+
+	BeforeEach(func() {
+		strategies.IntializeStrategyMap()
+
+		lis := bufconn.Listen(1024 * 1024)
+		server = rpc.NewServer("secret")
+		go server.Serve(lis)
+
+		var err error
+		conn, err = grpc.Dial("bufnet",
+			grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+			grpc.WithInsecure())
+		Expect(err).NotTo(HaveOccurred())
+		client = rpc.NewStrategyServiceClient(conn)
+		ctx = metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")
+	})
+
+	AfterEach(func() {
+		conn.Close()
+		server.Stop()
+	})
+
+	Describe("When authenticating", func() {
+		It("should reject calls without a token", func() {
+			_, err := client.ListStrategies(context.Background(), &rpc.ListStrategiesRequest{})
+			Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+		})
+
+		It("should reject calls with the wrong token", func() {
+			wrong := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer guess")
+			_, err := client.ListStrategies(wrong, &rpc.ListStrategiesRequest{})
+			Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+		})
+	})
+
+	Describe("When listing strategies", func() {
+		It("should return every strategy with its arguments", func() {
+			resp, err := client.ListStrategies(ctx, &rpc.ListStrategiesRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Strategies).To(HaveLen(len(strategies.StrategyList)))
+
+			info := strategies.AcceleratingDualMomentumInfo()
+			var adm *rpc.Strategy
+			for _, strat := range resp.Strategies {
+				if strat.Shortcode == info.Shortcode {
+					adm = strat
+				}
+			}
+			Expect(adm).NotTo(BeNil())
+			Expect(adm.Name).To(Equal(info.Name))
+			Expect(adm.Arguments).To(HaveLen(len(info.Arguments)))
+			Expect(adm.Arguments["inTickers"].Tickers).To(BeTrue())
+		})
+	})
+
+	Describe("When running a strategy", func() {
+		It("should return not found for an unknown strategy", func() {
+			_, err := client.RunStrategy(ctx, &rpc.RunStrategyRequest{Shortcode: "nope"})
+			Expect(status.Code(err)).To(Equal(codes.NotFound))
+		})
+
+		It("should reject arguments that are not JSON", func() {
+			_, err := client.RunStrategy(ctx, &rpc.RunStrategyRequest{Shortcode: "adm", Arguments: "{"})
+			Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+		})
+
+		It("should reject malformed dates", func() {
+			_, err := client.RunStrategy(ctx, &rpc.RunStrategyRequest{Shortcode: "adm", StartDate: "01/01/2000"})
+			Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+			Expect(status.Convert(err).Message()).To(Equal("start_date must be formatted YYYY-MM-DD"))
+		})
+
+		It("should reject an unknown resolution", func() {
+			_, err := client.RunStrategy(ctx, &rpc.RunStrategyRequest{Shortcode: "adm", Arguments: "{}", Resolution: "hourly"})
+			Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+		})
+	})
+
+	Describe("When converting performance", func() {
+		It("should encode measurements as columns", func() {
+			date := time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)
+			perf := portfolio.Performance{
+				PeriodStart: date.Unix(),
+				Measurements: []portfolio.PerformanceMeasurement{
+					{Time: date.Unix(), Value: 10000, Holdings: "VFINX"},
+					{Time: date.AddDate(0, 1, 0).Unix(), Value: 10500, Holdings: "VFINX", PercentReturn: 0.05},
+				},
+				Transactions: []portfolio.Transaction{
+					{Date: date, Ticker: "VFINX", Kind: portfolio.BuyTransaction, Shares: 100, TotalValue: 10000},
+				},
+				CurrentHoldings: map[string]float64{"VFINX": 100},
+			}
+			perf.MetricsBundle.CAGRS.OneYear = 0.12
+			perf.MetricsBundle.NPositivePeriods = 1
+
+			msg := rpc.PerformanceFromPortfolio(&perf)
+			Expect(msg.Measurements.Value).To(Equal([]float64{10000, 10500}))
+			Expect(msg.Measurements.PercentReturn).To(Equal([]float64{0, 0.05}))
+			Expect(msg.Measurements.Leverage).To(BeEmpty())
+			Expect(msg.Transactions).To(HaveLen(1))
+			Expect(msg.Transactions[0].Date).To(Equal(date.Unix()))
+			Expect(msg.CurrentHoldings).To(HaveKeyWithValue("VFINX", 100.0))
+			Expect(msg.Metrics.Cagr_1Yr).To(Equal(0.12))
+			Expect(msg.Metrics.NPositivePeriods).To(Equal(int64(1)))
+		})
+	})
+})
@@ -0,0 +1,182 @@
+// Package run reports what each nightly run of the notifier did: how many
+// portfolios it updated, which failed and why, how many emails it sent, how
+// long it took, and how many calls it made to data providers
+package run
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Status of a run
+const (
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+)
+
+// maxFailures most failures kept in a report; the rest are only counted so a
+// run that fails every portfolio does not store an enormous report
+const maxFailures = 500
+
+// Failure a portfolio the run could not update
+type Failure struct {
+	PortfolioID uuid.UUID `json:"portfolioId"`
+	Strategy    string    `json:"strategy"`
+	Stage       string    `json:"stage"`
+	Error       string    `json:"error"`
+}
+
+// Reason failures of a run grouped by stage and error
+type Reason struct {
+	Stage string `json:"stage"`
+	Error string `json:"error"`
+	Count int    `json:"count"`
+}
+
+// Report summary of a run. A report that is still running after the run
+// should have finished belongs to a run that crashed.
+type Report struct {
+	ID        uuid.UUID `json:"id"`
+	Job       string    `json:"job"`
+	ForDate   int64     `json:"forDate"`
+	Status    string    `json:"status"`
+	Started   int64     `json:"started"`
+	Completed int64     `json:"completed,omitempty"`
+
+	// Duration seconds from the start to the completion of the run
+	Duration int64 `json:"duration"`
+
+	Portfolios int `json:"portfolios"`
+	Succeeded  int `json:"succeeded"`
+	Failed     int `json:"failed"`
+	Deferred   int `json:"deferred"`
+	EmailsSent int `json:"emailsSent"`
+
+	// ProviderCalls calls made to each data provider during the run
+	ProviderCalls map[string]int `json:"providerCalls"`
+
+	// Failures the first failures of the run; Reasons groups all of them
+	Failures []Failure `json:"failures"`
+	Reasons  []Reason  `json:"reasons"`
+}
+
+// Recorder collects the report of a run as it progresses; safe for
+// concurrent use
+type Recorder struct {
+	mu      sync.Mutex
+	report  Report
+	reasons map[Reason]int
+}
+
+// NewRecorder start the report of job processing portfolios for forDate
+func NewRecorder(job string, forDate time.Time, started time.Time, portfolios int) *Recorder {
+	return &Recorder{
+		report: Report{
+			ID:            uuid.New(),
+			Job:           job,
+			ForDate:       forDate.Unix(),
+			Status:        StatusRunning,
+			Started:       started.Unix(),
+			Portfolios:    portfolios,
+			ProviderCalls: map[string]int{},
+			Failures:      []Failure{},
+			Reasons:       []Reason{},
+		},
+		reasons: make(map[Reason]int),
+	}
+}
+
+// Succeeded count a portfolio that was updated
+func (r *Recorder) Succeeded() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.report.Succeeded++
+}
+
+// Deferred count a portfolio left for the next run
+func (r *Recorder) Deferred() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.report.Deferred++
+}
+
+// Failed count a portfolio that could not be updated
+func (r *Recorder) Failed(f Failure) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.report.Failed++
+	if len(r.report.Failures) < maxFailures {
+		r.report.Failures = append(r.report.Failures, f)
+	}
+	r.reasons[Reason{Stage: f.Stage, Error: f.Error}]++
+}
+
+// EmailsSent count emails sent by the run
+func (r *Recorder) EmailsSent(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.report.EmailsSent += n
+}
+
+// Report a copy of the report so far
+func (r *Recorder) Report() *Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report := r.report
+	report.Failures = append([]Failure{}, r.report.Failures...)
+	report.ProviderCalls = make(map[string]int, len(r.report.ProviderCalls))
+	for provider, calls := range r.report.ProviderCalls {
+		report.ProviderCalls[provider] = calls
+	}
+
+	report.Reasons = make([]Reason, 0, len(r.reasons))
+	for reason, count := range r.reasons {
+		reason.Count = count
+		report.Reasons = append(report.Reasons, reason)
+	}
+	sort.Slice(report.Reasons, func(i, j int) bool {
+		a, b := report.Reasons[i], report.Reasons[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Stage != b.Stage {
+			return a.Stage < b.Stage
+		}
+		return a.Error < b.Error
+	})
+	return &report
+}
+
+// Complete mark the run completed at completed with the calls it made to each
+// provider and return its final report
+func (r *Recorder) Complete(completed time.Time, providerCalls map[string]int) *Report {
+	r.mu.Lock()
+	r.report.Status = StatusCompleted
+	r.report.Completed = completed.Unix()
+	r.report.Duration = r.report.Completed - r.report.Started
+	for provider, calls := range providerCalls {
+		r.report.ProviderCalls[provider] = calls
+	}
+	r.mu.Unlock()
+
+	return r.Report()
+}
+
+// Stats counts describing the state of the system
+type Stats struct {
+	Users             int `json:"users"`
+	Portfolios        int `json:"portfolios"`
+	TrashedPortfolios int `json:"trashedPortfolios"`
+	QueuedJobs        int `json:"queuedJobs"`
+	RunningJobs       int `json:"runningJobs"`
+
+	// FailuresLastDay portfolio updates that failed in the last 24 hours
+	FailuresLastDay int `json:"failuresLastDay"`
+
+	// LastRun report of the most recent run of the notifier, if any
+	LastRun *Report `json:"lastRun"`
+}
@@ -0,0 +1,66 @@
+package run_test
+
+import (
+	"errors"
+	"main/run"
+	"time"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Run", func() {
+	var (
+		recorder *run.Recorder
+		started  time.Time
+	)
+
+	BeforeEach(func() {
+		started = time.Date(2021, 3, 2, 1, 0, 0, 0, time.UTC)
+		recorder = run.NewRecorder("notifier", time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC), started, 4)
+	})
+
+	Describe("When a run is in progress", func() {
+		It("should report it as running", func() {
+			recorder.Succeeded()
+			report := recorder.Report()
+			Expect(report.Status).To(Equal(run.StatusRunning))
+			Expect(report.Portfolios).To(Equal(4))
+			Expect(report.Succeeded).To(Equal(1))
+			Expect(report.Completed).To(BeZero())
+		})
+	})
+
+	Describe("When a run completes", func() {
+		It("should summarize what it did", func() {
+			recorder.Succeeded()
+			recorder.Deferred()
+			recorder.EmailsSent(2)
+			recorder.Failed(run.Failure{PortfolioID: uuid.New(), Strategy: "adm", Stage: "compute", Error: "no data"})
+			recorder.Failed(run.Failure{PortfolioID: uuid.New(), Strategy: "adm", Stage: "compute", Error: "no data"})
+			recorder.Failed(run.Failure{PortfolioID: uuid.New(), Strategy: "daa", Stage: "update", Error: errors.New("deadlock").Error()})
+
+			report := recorder.Complete(started.Add(90*time.Second), map[string]int{"tiingo": 120})
+			Expect(report.Status).To(Equal(run.StatusCompleted))
+			Expect(report.Duration).To(Equal(int64(90)))
+			Expect(report.Succeeded).To(Equal(1))
+			Expect(report.Deferred).To(Equal(1))
+			Expect(report.Failed).To(Equal(3))
+			Expect(report.EmailsSent).To(Equal(2))
+			Expect(report.ProviderCalls).To(HaveKeyWithValue("tiingo", 120))
+			Expect(report.Failures).To(HaveLen(3))
+
+			Expect(report.Reasons).To(Equal([]run.Reason{
+				{Stage: "compute", Error: "no data", Count: 2},
+				{Stage: "update", Error: "deadlock", Count: 1},
+			}))
+		})
+
+		It("should not share state with the recorder", func() {
+			report := recorder.Report()
+			report.ProviderCalls["tiingo"] = 5
+			Expect(recorder.Report().ProviderCalls).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,198 @@
+// Package scaffold generates the files of a new strategy: its StrategyInfo,
+// factory, and a Compute skeleton that holds a single ticker, a test wired to
+// the price fixtures in strategies/testdata, and its registration in
+// StrategyList and the golden test cases.
+package scaffold
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// Strategy describes the strategy to generate
+type Strategy struct {
+	Name        string
+	Shortcode   string
+	Description string
+	Source      string
+
+	// Type name of the generated strategy type, e.g. SectorRotation
+	Type string
+}
+
+var (
+	// ErrInvalidStrategy the name or shortcode cannot be used for a strategy
+	ErrInvalidStrategy = errors.New("invalid strategy")
+
+	// ErrExists a strategy with the same shortcode or type already exists
+	ErrExists = errors.New("strategy already exists")
+)
+
+var shortcodePattern = regexp.MustCompile(`^[a-z][a-z0-9]{1,15}$`)
+
+// reservedShortcodes names the generated receiver cannot shadow: the
+// packages the generated files import
+var reservedShortcodes = map[string]bool{
+	"data":       true,
+	"dataframe":  true,
+	"errors":     true,
+	"json":       true,
+	"math":       true,
+	"portfolio":  true,
+	"strategies": true,
+	"time":       true,
+}
+
+// New describe a strategy named name. The shortcode identifies it in the
+// API and names its files and receivers, so it must be a short lowercase
+// identifier such as "sr".
+func New(name string, shortcode string, description string, source string) (*Strategy, error) {
+	if !shortcodePattern.MatchString(shortcode) || token.IsKeyword(shortcode) || reservedShortcodes[shortcode] {
+		return nil, fmt.Errorf("%w: shortcode '%s' must be 2 to 16 lowercase letters and digits, start with a letter, and not be a Go keyword or package name", ErrInvalidStrategy, shortcode)
+	}
+
+	typeName := TypeName(name)
+	if typeName == "" {
+		return nil, fmt.Errorf("%w: name '%s' must contain a letter", ErrInvalidStrategy, name)
+	}
+
+	if description == "" {
+		description = "TODO: describe the strategy in a sentence"
+	}
+
+	return &Strategy{
+		Name:        strings.TrimSpace(name),
+		Shortcode:   shortcode,
+		Description: description,
+		Source:      source,
+		Type:        typeName,
+	}, nil
+}
+
+// TypeName the exported Go identifier of name, e.g. "Keller's sector
+// rotation" is KellersSectorRotation
+func TypeName(name string) string {
+	var sb strings.Builder
+	upper := true
+	for _, r := range name {
+		switch {
+		case r == '\'':
+			continue
+		case unicode.IsLetter(r) && r < unicode.MaxASCII, unicode.IsDigit(r) && sb.Len() > 0:
+			if upper {
+				r = unicode.ToUpper(r)
+			}
+			sb.WriteRune(r)
+			upper = false
+		default:
+			upper = true
+		}
+	}
+	return sb.String()
+}
+
+// Generate write the strategy and its test to dir, the strategies package,
+// and register it. Returns the files written or changed. Existing files are
+// never overwritten.
+func Generate(dir string, s *Strategy) ([]string, error) {
+	strategyFile := filepath.Join(dir, s.Shortcode+".go")
+	testFile := filepath.Join(dir, s.Shortcode+"_test.go")
+	discoverFile := filepath.Join(dir, "discover.go")
+	goldenFile := filepath.Join(dir, "golden_test.go")
+
+	for _, fn := range []string{strategyFile, testFile} {
+		if _, err := os.Stat(fn); err == nil {
+			return nil, fmt.Errorf("%w: %s", ErrExists, fn)
+		}
+	}
+
+	discover, err := ioutil.ReadFile(discoverFile)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Contains(discover, []byte(s.Type+"Info()")) {
+		return nil, fmt.Errorf("%w: %s is already registered", ErrExists, s.Type)
+	}
+	discover, err = insertBefore(discover, "var StrategyList = []StrategyInfo{", "\t"+s.Type+"Info(),\n")
+	if err != nil {
+		return nil, fmt.Errorf("cannot register %s in %s: %w", s.Type, discoverFile, err)
+	}
+
+	golden, err := ioutil.ReadFile(goldenFile)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Contains(golden, []byte(fmt.Sprintf("\t%q:", s.Shortcode))) {
+		return nil, fmt.Errorf("%w: %s has a golden case", ErrExists, s.Shortcode)
+	}
+	golden, err = insertBefore(golden, "var goldenCases = map[string]string{", fmt.Sprintf("\t%q: `{\"ticker\": \"VFINX\"}`,\n", s.Shortcode))
+	if err != nil {
+		return nil, fmt.Errorf("cannot add a golden case for %s to %s: %w", s.Shortcode, goldenFile, err)
+	}
+
+	files := []struct {
+		name    string
+		tmpl    *template.Template
+		content []byte
+	}{
+		{name: strategyFile, tmpl: strategyTemplate},
+		{name: testFile, tmpl: testTemplate},
+		{name: discoverFile, content: discover},
+		{name: goldenFile, content: golden},
+	}
+
+	for ii := range files {
+		f := &files[ii]
+		if f.tmpl != nil {
+			var buf bytes.Buffer
+			if err := f.tmpl.Execute(&buf, s); err != nil {
+				return nil, err
+			}
+			f.content = buf.Bytes()
+		}
+		formatted, err := format.Source(f.content)
+		if err != nil {
+			return nil, fmt.Errorf("generated %s is invalid: %w", f.name, err)
+		}
+		f.content = formatted
+	}
+
+	written := make([]string, 0, len(files))
+	for _, f := range files {
+		if err := ioutil.WriteFile(f.name, f.content, 0644); err != nil {
+			return written, err
+		}
+		written = append(written, f.name)
+	}
+	return written, nil
+}
+
+// insertBefore add line as the last element of the composite literal that
+// starts with opening
+func insertBefore(src []byte, opening string, line string) ([]byte, error) {
+	start := bytes.Index(src, []byte(opening))
+	if start < 0 {
+		return nil, fmt.Errorf("'%s' not found", opening)
+	}
+	end := bytes.Index(src[start:], []byte("\n}\n"))
+	if end < 0 {
+		return nil, fmt.Errorf("end of '%s' not found", opening)
+	}
+	end += start + 1
+
+	out := make([]byte, 0, len(src)+len(line))
+	out = append(out, src[:end]...)
+	out = append(out, line...)
+	out = append(out, src[end:]...)
+	return out, nil
+}
@@ -0,0 +1,123 @@
+package scaffold_test
+
+import (
+	"errors"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"main/scaffold"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const discoverSrc = `package strategies
+
+// StrategyList List of all strategies
+var StrategyList = []StrategyInfo{
+	AcceleratingDualMomentumInfo(),
+}
+`
+
+const goldenSrc = "package strategies_test\n\nvar goldenCases = map[string]string{\n\t\"adm\": `{}`,\n}\n"
+
+var _ = Describe("Scaffold", func() {
+	var (
+		dir string
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "scaffold")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "discover.go"), []byte(discoverSrc), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "golden_test.go"), []byte(goldenSrc), 0644)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	read := func(fn string) string {
+		content, err := ioutil.ReadFile(filepath.Join(dir, fn))
+		Expect(err).NotTo(HaveOccurred())
+		return string(content)
+	}
+
+	Describe("When a strategy is described", func() {
+		It("should derive its type from its name", func() {
+			Expect(scaffold.TypeName("Keller's sector rotation")).To(Equal("KellersSectorRotation"))
+			Expect(scaffold.TypeName("60/40 glide-path")).To(Equal("GlidePath"))
+			Expect(scaffold.TypeName("top 3 momentum")).To(Equal("Top3Momentum"))
+		})
+
+		It("should reject shortcodes that are not identifiers", func() {
+			for _, shortcode := range []string{"", "a", "Sr", "1sr", "s-r", "func", "data"} {
+				_, err := scaffold.New("Sector Rotation", shortcode, "", "")
+				Expect(errors.Is(err, scaffold.ErrInvalidStrategy)).To(BeTrue(), "shortcode %q", shortcode)
+			}
+		})
+	})
+
+	Describe("When a strategy is generated", func() {
+		var (
+			s *scaffold.Strategy
+		)
+
+		BeforeEach(func() {
+			var err error
+			s, err = scaffold.New("Sector Rotation", "sr", `Rotates into the "strongest" sector`, "https://example.com/sr")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should write the strategy and its test", func() {
+			files, err := scaffold.Generate(dir, s)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(ConsistOf(
+				filepath.Join(dir, "sr.go"),
+				filepath.Join(dir, "sr_test.go"),
+				filepath.Join(dir, "discover.go"),
+				filepath.Join(dir, "golden_test.go"),
+			))
+
+			fset := token.NewFileSet()
+			for _, fn := range files {
+				_, err := parser.ParseFile(fset, fn, nil, 0)
+				Expect(err).NotTo(HaveOccurred(), fn)
+			}
+
+			strategy := read("sr.go")
+			Expect(strategy).To(ContainSubstring("func SectorRotationInfo() StrategyInfo {"))
+			Expect(strategy).To(ContainSubstring(`Description: "Rotates into the \"strongest\" sector",`))
+			Expect(strategy).To(ContainSubstring("func (sr *SectorRotation) Compute(manager *data.Manager) (*portfolio.Portfolio, error) {"))
+			Expect(read("sr_test.go")).To(ContainSubstring("registerFixtures()"))
+		})
+
+		It("should register the strategy and its golden case", func() {
+			_, err := scaffold.Generate(dir, s)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(read("discover.go")).To(ContainSubstring("\tAcceleratingDualMomentumInfo(),\n\tSectorRotationInfo(),\n}\n"))
+			Expect(read("golden_test.go")).To(ContainSubstring("\t\"sr\":  `{\"ticker\": \"VFINX\"}`,\n}\n"))
+		})
+
+		It("should not overwrite an existing strategy", func() {
+			Expect(ioutil.WriteFile(filepath.Join(dir, "sr.go"), []byte("package strategies\n"), 0644)).To(Succeed())
+			_, err := scaffold.Generate(dir, s)
+			Expect(errors.Is(err, scaffold.ErrExists)).To(BeTrue())
+			Expect(read("sr.go")).To(Equal("package strategies\n"))
+			Expect(read("discover.go")).To(Equal(discoverSrc))
+		})
+
+		It("should not register a strategy twice", func() {
+			_, err := scaffold.Generate(dir, s)
+			Expect(err).NotTo(HaveOccurred())
+			os.Remove(filepath.Join(dir, "sr.go"))
+			os.Remove(filepath.Join(dir, "sr_test.go"))
+
+			_, err = scaffold.Generate(dir, s)
+			Expect(errors.Is(err, scaffold.ErrExists)).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,248 @@
+package scaffold
+
+import "text/template"
+
+var strategyTemplate = template.Must(template.New("strategy").Parse(`/*
+ * {{.Name}}
+{{- if .Source}}
+ * {{.Source}}
+{{- end}}
+ *
+ * TODO: describe the assets the strategy holds and the signal it trades on.
+ * The generated strategy holds a single ticker; replace buildTargetPortfolio
+ * with the strategy's signal.
+ */
+
+package strategies
+
+import (
+	"encoding/json"
+	"errors"
+	"main/data"
+	"main/portfolio"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+// {{.Type}}Info information describing this strategy
+func {{.Type}}Info() StrategyInfo {
+	return StrategyInfo{
+		Name:        {{printf "%q" .Name}},
+		Shortcode:   {{printf "%q" .Shortcode}},
+		Description: {{printf "%q" .Description}},
+		Source:      {{printf "%q" .Source}},
+		Version:     "1.0.0",
+		Arguments: map[string]Argument{
+			"ticker": {
+				Name:        "Ticker",
+				Description: "ETF, Mutual Fund, or Stock ticker to hold",
+				Typecode:    "string",
+				DefaultVal:  "VFINX",
+				Tickers:     true,
+			},
+		},
+		SuggestedParameters: map[string]map[string]string{
+			"S&P 500": {
+				"ticker": "VFINX",
+			},
+		},
+		Constraints: Constraints{
+			RebalanceFrequencies: []string{data.FrequencyMonthly},
+			AssetTypes:           []string{data.AssetTypeStock, data.AssetTypeMutualFund},
+		},
+		Factory: New{{.Type}},
+	}
+}
+
+// {{.Type}} strategy type
+type {{.Type}} struct {
+	info            StrategyInfo
+	ticker          string
+	prices          *dataframe.DataFrame
+	targetPortfolio *dataframe.DataFrame
+	options         portfolioOptions
+
+	// Public
+	CurrentSymbol string
+}
+
+// New{{.Type}} Construct a new {{.Name}} strategy
+func New{{.Type}}(args map[string]json.RawMessage) (Strategy, error) {
+	var ticker string
+	if err := json.Unmarshal(args["ticker"], &ticker); err != nil {
+		return nil, err
+	}
+	if ticker == "" {
+		return nil, errors.New("ticker is required")
+	}
+
+	options, err := parsePortfolioOptions(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var {{.Shortcode}} Strategy
+	{{.Shortcode}} = &{{.Type}}{
+		info:    {{.Type}}Info(),
+		ticker:  strings.ToUpper(ticker),
+		options: options,
+	}
+
+	return {{.Shortcode}}, nil
+}
+
+// GetInfo get information about this strategy
+func ({{.Shortcode}} *{{.Type}}) GetInfo() StrategyInfo {
+	return {{.Shortcode}}.info
+}
+
+// SetProgress report the progress of Compute to progress
+func ({{.Shortcode}} *{{.Type}}) SetProgress(progress portfolio.ProgressReporter) {
+	{{.Shortcode}}.options.progress = progress
+}
+
+func ({{.Shortcode}} *{{.Type}}) downloadPriceData(manager *data.Manager) error {
+	// Load EOD quotes for in tickers
+	manager.Frequency = data.FrequencyMonthly
+
+	prices, errs := manager.GetMultipleData({{.Shortcode}}.ticker)
+	if len(errs) > 0 {
+		return errors.New("Failed to download data for tickers")
+	}
+
+	{{.Shortcode}}.prices = prices[{{.Shortcode}}.ticker]
+	return nil
+}
+
+// buildTargetPortfolio hold the ticker every month starting at begin
+//
+// TODO: replace with the strategy's signal. Each row holds the weight of
+// every ticker to hold on its date; additional float64 columns are shown as
+// the justification of the holdings.
+func ({{.Shortcode}} *{{.Type}}) buildTargetPortfolio(begin time.Time) error {
+	dates := []interface{}{}
+	targets := []interface{}{}
+
+	iterator := {{.Shortcode}}.prices.ValuesIterator(dataframe.ValuesOptions{InitialRow: 0, Step: 1, DontReadLock: true})
+	for {
+		row, vals, _ := iterator(dataframe.SeriesName)
+		if row == nil {
+			break
+		}
+
+		date := vals[data.DateIdx].(time.Time)
+		price, ok := vals[{{.Shortcode}}.ticker].(float64)
+		if !ok || math.IsNaN(price) || date.Before(begin) {
+			continue
+		}
+
+		dates = append(dates, date)
+		targets = append(targets, map[string]float64{ {{- .Shortcode}}.ticker: 1.0})
+	}
+
+	if len(dates) == 0 {
+		return errors.New("not enough data to compute the signal")
+	}
+
+	{{.Shortcode}}.targetPortfolio = dataframe.NewDataFrame(
+		dataframe.NewSeriesTime(data.DateIdx, &dataframe.SeriesInit{Size: len(dates)}, dates...),
+		dataframe.NewSeriesMixed(portfolio.TickerName, &dataframe.SeriesInit{Size: len(targets)}, targets...),
+	)
+
+	return nil
+}
+
+// Compute signal
+func ({{.Shortcode}} *{{.Type}}) Compute(manager *data.Manager) (*portfolio.Portfolio, error) {
+	// Ensure time range is valid
+	nullTime := time.Time{}
+	if manager.End == nullTime {
+		manager.End = time.Now()
+	}
+	begin := manager.Begin
+	if manager.Begin == nullTime {
+		// Default computes things 50 years into the past
+		manager.Begin = manager.End.AddDate(-50, 0, 0)
+	}
+
+	if err := {{.Shortcode}}.downloadPriceData(manager); err != nil {
+		return nil, err
+	}
+
+	if err := {{.Shortcode}}.buildTargetPortfolio(begin); err != nil {
+		return nil, err
+	}
+	{{.Shortcode}}.CurrentSymbol = {{.Shortcode}}.ticker
+
+	p := portfolio.NewPortfolio({{printf "%q" (print .Name " Portfolio")}}, manager)
+	{{.Shortcode}}.options.apply(&p, {{.Shortcode}}.info)
+	if err := p.TargetPortfolio({{.Shortcode}}.options.initialCapital(), {{.Shortcode}}.targetPortfolio); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+`))
+
+var testTemplate = template.Must(template.New("test").Parse(`package strategies_test
+
+import (
+	"encoding/json"
+	"main/data"
+	"main/strategies"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe({{printf "%q" .Type}}, func() {
+	var (
+		{{.Shortcode}}     *strategies.{{.Type}}
+		manager data.Manager
+	)
+
+	BeforeEach(func() {
+		params := map[string]json.RawMessage{}
+		Expect(json.Unmarshal([]byte(` + "`" + `{"ticker": "VFINX"}` + "`" + `), &params)).To(Succeed())
+
+		tmp, err := strategies.New{{.Type}}(params)
+		Expect(err).NotTo(HaveOccurred())
+		{{.Shortcode}} = tmp.(*strategies.{{.Type}})
+
+		manager = data.NewManager(map[string]string{
+			"tiingo": "TEST",
+		})
+
+		// serve prices from the fixtures in testdata
+		registerFixtures()
+		data.InitializeDataManager()
+	})
+
+	Describe("When given invalid arguments", func() {
+		It("should require a ticker", func() {
+			params := map[string]json.RawMessage{}
+			Expect(json.Unmarshal([]byte(` + "`" + `{"ticker": ""}` + "`" + `), &params)).To(Succeed())
+			_, err := strategies.New{{.Type}}(params)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Compute signal", func() {
+		It("should hold the ticker", func() {
+			manager.Begin = time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC)
+			manager.End = time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+			p, err := {{.Shortcode}}.Compute(&manager)
+			Expect(err).NotTo(HaveOccurred())
+
+			perf, err := p.CalculatePerformance(manager.End)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(perf.Measurements).NotTo(BeEmpty())
+			Expect({{.Shortcode}}.CurrentSymbol).To(Equal("VFINX"))
+		})
+	})
+})
+`))
@@ -0,0 +1,91 @@
+// Package secret encrypts values, such as users' API tokens, before they are
+// stored in the database
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"os"
+)
+
+var (
+	// ErrNoKey USER_TOKEN_KEY is not set
+	ErrNoKey = errors.New("USER_TOKEN_KEY is not set")
+
+	// ErrInvalidKey the key is not 32 bytes encoded as base64
+	ErrInvalidKey = errors.New("key must be 32 bytes encoded as base64")
+
+	// ErrInvalidCiphertext the value was not sealed with the key or was
+	// modified
+	ErrInvalidCiphertext = errors.New("ciphertext is invalid")
+)
+
+// Key the AES-256 key in USER_TOKEN_KEY, 32 bytes encoded as base64
+func Key() ([]byte, error) {
+	encoded := os.Getenv("USER_TOKEN_KEY")
+	if encoded == "" {
+		return nil, ErrNoKey
+	}
+	return ParseKey(encoded)
+}
+
+// ParseKey decode a base64 encoded AES-256 key
+func ParseKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != 32 {
+		return nil, ErrInvalidKey
+	}
+	return key, nil
+}
+
+// Seal encrypt and authenticate plaintext with AES-256-GCM. The result is
+// the random nonce followed by the ciphertext, encoded as base64.
+func Seal(key []byte, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypt a value sealed with Seal
+func Open(key []byte, ciphertext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return "", ErrInvalidCiphertext
+	}
+
+	nonce, sealed := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+	return string(plaintext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, ErrInvalidKey
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
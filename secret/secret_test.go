@@ -0,0 +1,73 @@
+package secret_test
+
+import (
+	"encoding/base64"
+	"main/secret"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Secret", func() {
+	var (
+		key []byte
+	)
+
+	BeforeEach(func() {
+		var err error
+		key, err = secret.ParseKey(base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32))))
+		Expect(err).To(BeNil())
+	})
+
+	Describe("When sealing a value", func() {
+		It("should open to the original value", func() {
+			sealed, err := secret.Seal(key, "tiingo-token")
+			Expect(err).To(BeNil())
+			Expect(sealed).NotTo(ContainSubstring("tiingo-token"))
+
+			opened, err := secret.Open(key, sealed)
+			Expect(err).To(BeNil())
+			Expect(opened).To(Equal("tiingo-token"))
+		})
+
+		It("should use a different nonce each time", func() {
+			first, err := secret.Seal(key, "tiingo-token")
+			Expect(err).To(BeNil())
+			second, err := secret.Seal(key, "tiingo-token")
+			Expect(err).To(BeNil())
+			Expect(first).NotTo(Equal(second))
+		})
+
+		It("should not open with a different key", func() {
+			sealed, err := secret.Seal(key, "tiingo-token")
+			Expect(err).To(BeNil())
+
+			other, err := secret.ParseKey(base64.StdEncoding.EncodeToString([]byte(strings.Repeat("o", 32))))
+			Expect(err).To(BeNil())
+			_, err = secret.Open(other, sealed)
+			Expect(err).To(MatchError(secret.ErrInvalidCiphertext))
+		})
+
+		It("should not open a modified value", func() {
+			sealed, err := secret.Seal(key, "tiingo-token")
+			Expect(err).To(BeNil())
+
+			raw, err := base64.StdEncoding.DecodeString(sealed)
+			Expect(err).To(BeNil())
+			raw[len(raw)-1] ^= 0xff
+			_, err = secret.Open(key, base64.StdEncoding.EncodeToString(raw))
+			Expect(err).To(MatchError(secret.ErrInvalidCiphertext))
+
+			_, err = secret.Open(key, "not base64!")
+			Expect(err).To(MatchError(secret.ErrInvalidCiphertext))
+		})
+	})
+
+	Describe("When parsing a key", func() {
+		It("should require 32 bytes", func() {
+			_, err := secret.ParseKey(base64.StdEncoding.EncodeToString([]byte("short")))
+			Expect(err).To(MatchError(secret.ErrInvalidKey))
+		})
+	})
+})
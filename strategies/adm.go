@@ -39,12 +39,14 @@ func AcceleratingDualMomentumInfo() StrategyInfo {
 				Description: "List of ETF, Mutual Fund, or Stock tickers to invest in",
 				Typecode:    "[]string",
 				DefaultVal:  `["VFINX", "PRIDX"]`,
+				Tickers:     true,
 			},
 			"outTicker": Argument{
 				Name:        "Out-of-Market Ticker",
 				Description: "Ticker to use when model scores are all below 0",
 				Typecode:    "string",
 				DefaultVal:  "VUSTX",
+				Tickers:     true,
 			},
 		},
 		SuggestedParameters: map[string]map[string]string{
@@ -61,6 +63,11 @@ func AcceleratingDualMomentumInfo() StrategyInfo {
 				"outTicker": `TLT`,
 			},
 		},
+		Constraints: Constraints{
+			MinHistoryMonths:     map[string]int{"inTickers": 6},
+			RebalanceFrequencies: []string{data.FrequencyMonthly},
+			AssetTypes:           []string{data.AssetTypeStock, data.AssetTypeMutualFund},
+		},
 		Factory: NewAcceleratingDualMomentum,
 	}
 }
@@ -72,8 +79,10 @@ type AcceleratingDualMomentum struct {
 	outTicker     string
 	riskFreeRate  *dataframe.DataFrame
 	momentum      *dataframe.DataFrame
+	selections    *dataframe.DataFrame
 	dataStartTime time.Time
 	dataEndTime   time.Time
+	options       portfolioOptions
 
 	// Public
 	CurrentSymbol string
@@ -95,11 +104,17 @@ func NewAcceleratingDualMomentum(args map[string]json.RawMessage) (Strategy, err
 
 	outTicker = strings.ToUpper(outTicker)
 
+	options, err := parsePortfolioOptions(args)
+	if err != nil {
+		return nil, err
+	}
+
 	var adm Strategy
 	adm = &AcceleratingDualMomentum{
 		info:      AcceleratingDualMomentumInfo(),
 		inTickers: inTickers,
 		outTicker: outTicker,
+		options:   options,
 	}
 
 	return adm, nil
@@ -110,6 +125,11 @@ func (adm *AcceleratingDualMomentum) GetInfo() StrategyInfo {
 	return adm.info
 }
 
+// SetProgress report the progress of Compute to progress
+func (adm *AcceleratingDualMomentum) SetProgress(progress portfolio.ProgressReporter) {
+	adm.options.progress = progress
+}
+
 func (adm *AcceleratingDualMomentum) downloadPriceData(manager *data.Manager) error {
 	// Load EOD quotes for in tickers
 	manager.Frequency = data.FrequencyMonthly
@@ -183,9 +203,12 @@ func (adm *AcceleratingDualMomentum) downloadPriceData(manager *data.Manager) er
 	return nil
 }
 
+// admPeriods lookback periods, in months, of the momentum scores
+var admPeriods = []int{1, 3, 6}
+
 func (adm *AcceleratingDualMomentum) computeScores() error {
 	nrows := adm.prices.NRows(dataframe.Options{})
-	periods := []int{1, 3, 6}
+	periods := admPeriods
 	series := []dataframe.Series{}
 
 	rfr := adm.riskFreeRate.Series[1]
@@ -328,13 +351,104 @@ func (adm *AcceleratingDualMomentum) Compute(manager *data.Manager) (*portfolio.
 		}
 	}
 	targetPortfolio := dataframe.NewDataFrame(targetPortfolioSeries...)
+	adm.selections = targetPortfolio
 	adm.CurrentSymbol = targetPortfolio.Series[1].Value(targetPortfolio.NRows() - 1).(string)
 
 	p := portfolio.NewPortfolio("Accelerating Dual Momentum", manager)
-	err = p.TargetPortfolio(10000, targetPortfolio)
+	adm.options.apply(&p, adm.info)
+	err = p.TargetPortfolio(adm.options.initialCapital(), targetPortfolio)
 	if err != nil {
 		return nil, err
 	}
 
 	return &p, nil
 }
+
+// Explain the momentum scores behind the asset selected on the last date on
+// or before date
+func (adm *AcceleratingDualMomentum) Explain(date time.Time) (*Explanation, error) {
+	if adm.momentum == nil || adm.selections == nil {
+		return nil, ErrNotComputed
+	}
+
+	// the selected ticker is only computed for dates with complete scores
+	selected := adm.selections.Series[0]
+	row := -1
+	for ii := 0; ii < selected.NRows(); ii++ {
+		if selected.Value(ii).(time.Time).After(date) {
+			break
+		}
+		row = ii
+	}
+	if row < 0 {
+		return nil, ErrNoExplanation
+	}
+	computedOn := selected.Value(row).(time.Time)
+
+	dateIdx, err := adm.momentum.NameToColumn(data.DateIdx)
+	if err != nil {
+		return nil, err
+	}
+	dates := adm.momentum.Series[dateIdx]
+	momentumRow := -1
+	for ii := 0; ii < dates.NRows(); ii++ {
+		if dates.Value(ii).(time.Time).Equal(computedOn) {
+			momentumRow = ii
+			break
+		}
+	}
+	if momentumRow < 0 {
+		return nil, ErrNoExplanation
+	}
+
+	value := func(name string) (float64, error) {
+		idx, err := adm.momentum.NameToColumn(name)
+		if err != nil {
+			return 0, err
+		}
+		if val, ok := adm.momentum.Series[idx].Value(momentumRow).(float64); ok {
+			return val, nil
+		}
+		return 0, ErrNoExplanation
+	}
+
+	explanation := &Explanation{
+		Date:        computedOn,
+		RiskFree:    make(map[int]float64, len(admPeriods)),
+		Tickers:     make(map[string]*TickerExplanation, len(adm.inTickers)),
+		OutOfMarket: adm.outTicker,
+		Chosen:      adm.selections.Series[1].Value(row).(string),
+	}
+
+	for _, period := range admPeriods {
+		riskFree, err := value(fmt.Sprintf("RISKFREE%d", period))
+		if err != nil {
+			return nil, err
+		}
+		explanation.RiskFree[period] = riskFree / 12
+	}
+
+	for _, ticker := range adm.inTickers {
+		tickerExplanation := &TickerExplanation{
+			Lags:     make(map[int]float64, len(admPeriods)),
+			Momentum: make(map[int]float64, len(admPeriods)),
+		}
+		if tickerExplanation.Price, err = value(ticker); err != nil {
+			return nil, err
+		}
+		if tickerExplanation.Score, err = value(fmt.Sprintf("%sSCORE", ticker)); err != nil {
+			return nil, err
+		}
+		for _, period := range admPeriods {
+			if tickerExplanation.Lags[period], err = value(fmt.Sprintf("%sLAG%d", ticker, period)); err != nil {
+				return nil, err
+			}
+			if tickerExplanation.Momentum[period], err = value(fmt.Sprintf("%sMOM%d", ticker, period)); err != nil {
+				return nil, err
+			}
+		}
+		explanation.Tickers[ticker] = tickerExplanation
+	}
+
+	return explanation, nil
+}
@@ -182,4 +182,55 @@ var _ = Describe("Adm", func() {
 			})
 		})
 	})
+
+	Describe("Explain momentum scores", func() {
+		It("should require the strategy to be computed", func() {
+			_, err := adm.Explain(time.Date(2006, time.March, 31, 0, 0, 0, 0, time.UTC))
+			Expect(err).To(Equal(strategies.ErrNotComputed))
+		})
+
+		Context("with full stock history", func() {
+			BeforeEach(func() {
+				manager.Begin = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+				manager.End = time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+				_, err := adm.Compute(&manager)
+				Expect(err).To(BeNil())
+			})
+
+			It("should explain the selected asset", func() {
+				explanation, err := adm.Explain(time.Date(2006, time.April, 15, 0, 0, 0, 0, time.UTC))
+				Expect(err).To(BeNil())
+				Expect(explanation.Date).To(Equal(time.Date(2006, time.March, 31, 0, 0, 0, 0, time.UTC)))
+				Expect(explanation.Chosen).To(Equal("PRIDX"))
+				Expect(explanation.OutOfMarket).To(Equal("VUSTX"))
+				Expect(explanation.RiskFree).To(HaveLen(3))
+				Expect(explanation.Tickers).To(HaveLen(2))
+				Expect(explanation.Tickers["VFINX"].Score).To(BeNumerically("~", 2.734696874619626, 1e-9))
+				Expect(explanation.Tickers["PRIDX"].Score).To(BeNumerically("~", 13.388751105525918, 1e-9))
+
+				pridx := explanation.Tickers["PRIDX"]
+				for _, period := range []int{1, 3, 6} {
+					momentum := ((pridx.Price/pridx.Lags[period])-1)*100 - explanation.RiskFree[period]
+					Expect(pridx.Momentum[period]).To(BeNumerically("~", momentum, 1e-9))
+				}
+				Expect(pridx.Score).To(BeNumerically("~", (pridx.Momentum[1]+pridx.Momentum[3]+pridx.Momentum[6])/3, 1e-9))
+			})
+
+			It("should measure the distance to flipping the selection", func() {
+				explanation, err := adm.Explain(time.Date(2006, time.April, 15, 0, 0, 0, 0, time.UTC))
+				Expect(err).To(BeNil())
+
+				distances := explanation.FlipDistances()
+				Expect(distances).To(HaveLen(2))
+				Expect(distances).NotTo(HaveKey("PRIDX"))
+				Expect(distances["VFINX"]).To(BeNumerically("~", 13.388751105525918-2.734696874619626, 1e-9))
+				Expect(distances["VUSTX"]).To(BeNumerically("~", 13.388751105525918, 1e-9))
+			})
+
+			It("should not explain dates before the first score", func() {
+				_, err := adm.Explain(time.Date(1985, time.January, 1, 0, 0, 0, 0, time.UTC))
+				Expect(err).To(Equal(strategies.ErrNoExplanation))
+			})
+		})
+	})
 })
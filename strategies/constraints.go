@@ -0,0 +1,166 @@
+package strategies
+
+import (
+	"encoding/json"
+	"fmt"
+	"main/data"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+// tickerArgument parse an argument that is either a single ticker or a list
+// of tickers
+func tickerArgument(raw json.RawMessage) ([]string, error) {
+	tickers := []string{}
+	if err := json.Unmarshal(raw, &tickers); err == nil {
+		return tickers, nil
+	}
+
+	var ticker string
+	if err := json.Unmarshal(raw, &ticker); err != nil {
+		return nil, err
+	}
+	return []string{ticker}, nil
+}
+
+// Tickers symbols named by the ticker arguments of the strategy in args,
+// ordered by argument name. Arguments missing from args are skipped.
+func (info *StrategyInfo) Tickers(args map[string]json.RawMessage) ([]string, error) {
+	names := make([]string, 0, len(info.Arguments))
+	for name, arg := range info.Arguments {
+		if arg.Tickers {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	tickers := []string{}
+	for _, name := range names {
+		raw, ok := args[name]
+		if !ok {
+			continue
+		}
+		argTickers, err := tickerArgument(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a ticker or list of tickers", name)
+		}
+		tickers = append(tickers, argTickers...)
+	}
+
+	return tickers, nil
+}
+
+// CheckConstraints verify the tickers in args are of an allowed asset type
+// and have enough price history before begin. Errors describe how to fix the
+// arguments, e.g. "inTickers require 6 months of history before start date".
+// History is not checked if begin is zero.
+func (info *StrategyInfo) CheckConstraints(args map[string]json.RawMessage, begin time.Time, manager *data.Manager) error {
+	names := make([]string, 0, len(info.Arguments))
+	for name, arg := range info.Arguments {
+		if arg.Tickers {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		raw, ok := args[name]
+		if !ok {
+			return fmt.Errorf("%s is required", name)
+		}
+
+		tickers, err := tickerArgument(raw)
+		if err != nil {
+			return fmt.Errorf("%s must be a ticker or list of tickers", name)
+		}
+
+		for _, ticker := range tickers {
+			if _, err := data.ResolveSymbol(ticker); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+
+		if err := info.checkAssetTypes(name, tickers); err != nil {
+			return err
+		}
+
+		months := info.Constraints.MinHistoryMonths[name]
+		if months > 0 && !begin.IsZero() {
+			if err := checkHistory(name, tickers, months, begin, manager); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkAssetTypes verify each ticker is an allowed asset type
+func (info *StrategyInfo) checkAssetTypes(name string, tickers []string) error {
+	if len(info.Constraints.AssetTypes) == 0 {
+		return nil
+	}
+
+	for _, ticker := range tickers {
+		assetType := data.AssetType(ticker)
+		allowed := false
+		for _, t := range info.Constraints.AssetTypes {
+			if t == assetType {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("%s must contain only %s assets; %s is a %s", name, strings.Join(info.Constraints.AssetTypes, " or "), strings.ToUpper(ticker), assetType)
+		}
+	}
+
+	return nil
+}
+
+// checkHistory verify each ticker has price data at least months before begin
+func checkHistory(name string, tickers []string, months int, begin time.Time, manager *data.Manager) error {
+	origBegin, origEnd, origFrequency := manager.Begin, manager.End, manager.Frequency
+	defer func() {
+		manager.Begin, manager.End, manager.Frequency = origBegin, origEnd, origFrequency
+	}()
+
+	required := begin.AddDate(0, -months, 0)
+	manager.Begin = required
+	manager.End = begin
+	manager.Frequency = data.FrequencyMonthly
+
+	for _, ticker := range tickers {
+		ticker = strings.ToUpper(ticker)
+		df, err := manager.GetData(ticker)
+		first, ok := firstDate(df)
+		if err != nil || !ok {
+			return fmt.Errorf("%s require %d months of history before start date; no history is available for %s before %s",
+				name, months, ticker, begin.Format("2006-01-02"))
+		}
+
+		// monthly prices are dated at the end of the month
+		if first.After(required.AddDate(0, 1, 0)) {
+			return fmt.Errorf("%s require %d months of history before start date; %s history begins %s, choose a start date on or after %s",
+				name, months, ticker, first.Format("2006-01-02"), first.AddDate(0, months, 0).Format("2006-01-02"))
+		}
+	}
+
+	return nil
+}
+
+// firstDate date of the first row of df
+func firstDate(df *dataframe.DataFrame) (time.Time, bool) {
+	if df == nil || df.NRows() == 0 {
+		return time.Time{}, false
+	}
+	idx, err := df.NameToColumn(data.DateIdx)
+	if err != nil {
+		return time.Time{}, false
+	}
+	first, ok := df.Series[idx].Value(0).(time.Time)
+	return first, ok
+}
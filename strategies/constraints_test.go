@@ -0,0 +1,100 @@
+package strategies_test
+
+import (
+	"encoding/json"
+	"main/data"
+	"main/strategies"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Constraints", func() {
+	var (
+		info    strategies.StrategyInfo
+		manager data.Manager
+	)
+
+	parseArgs := func(args string) map[string]json.RawMessage {
+		params := map[string]json.RawMessage{}
+		Expect(json.Unmarshal([]byte(args), &params)).To(Succeed())
+		return params
+	}
+
+	BeforeEach(func() {
+		registerFixtures()
+		info = strategies.AcceleratingDualMomentumInfo()
+		manager = data.NewManager(map[string]string{
+			"tiingo": "TEST",
+		})
+	})
+
+	Describe("When checking asset types", func() {
+		It("should classify symbols", func() {
+			Expect(data.AssetType("VFINX")).To(Equal(data.AssetTypeMutualFund))
+			Expect(data.AssetType("SPY")).To(Equal(data.AssetTypeStock))
+			Expect(data.AssetType("$RATE.TB3MS")).To(Equal(data.AssetTypeRate))
+			Expect(data.AssetType("$CRYPTO.BTCUSD")).To(Equal(data.AssetTypeCrypto))
+			Expect(data.AssetType("$FUT.ES")).To(Equal(data.AssetTypeFutures))
+			Expect(data.AssetType("$CASH")).To(Equal(data.AssetTypeCash))
+		})
+
+		It("should reject tickers of a disallowed type", func() {
+			err := info.CheckConstraints(parseArgs(`{"inTickers": ["VFINX", "PRIDX"], "outTicker": "$RATE.TB3MS"}`), time.Time{}, &manager)
+			Expect(err).To(MatchError("outTicker must contain only stock or mutualFund assets; $RATE.TB3MS is a rate"))
+		})
+
+		It("should reject symbols in an unknown namespace", func() {
+			err := info.CheckConstraints(parseArgs(`{"inTickers": ["VFINX", "$RTE.TB3MS"], "outTicker": "VUSTX"}`), time.Time{}, &manager)
+			Expect(err).To(MatchError("inTickers: invalid symbol: unknown namespace $RTE in $RTE.TB3MS; expected one of $CASH, $CRYPTO, $FF, $FRED, $FUT, $RATE, $SYN"))
+		})
+
+		It("should require ticker arguments", func() {
+			err := info.CheckConstraints(parseArgs(`{"inTickers": ["VFINX", "PRIDX"]}`), time.Time{}, &manager)
+			Expect(err).To(MatchError("outTicker is required"))
+		})
+	})
+
+	Describe("When listing tickers", func() {
+		It("should list the tickers of every ticker argument", func() {
+			tickers, err := info.Tickers(parseArgs(`{"inTickers": ["VFINX", "PRIDX"], "outTicker": "VUSTX"}`))
+			Expect(err).To(BeNil())
+			Expect(tickers).To(Equal([]string{"VFINX", "PRIDX", "VUSTX"}))
+		})
+
+		It("should skip missing arguments", func() {
+			tickers, err := info.Tickers(parseArgs(`{"outTicker": "VUSTX"}`))
+			Expect(err).To(BeNil())
+			Expect(tickers).To(Equal([]string{"VUSTX"}))
+		})
+	})
+
+	Describe("When checking history", func() {
+		It("should accept tickers with enough history", func() {
+			err := info.CheckConstraints(parseArgs(`{"inTickers": ["VFINX", "PRIDX"], "outTicker": "VUSTX"}`), time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC), &manager)
+			Expect(err).To(BeNil())
+		})
+
+		It("should explain which tickers lack history", func() {
+			err := info.CheckConstraints(parseArgs(`{"inTickers": ["VFINX", "PRIDX"], "outTicker": "VUSTX"}`), time.Date(1989, time.March, 1, 0, 0, 0, 0, time.UTC), &manager)
+			Expect(err).To(MatchError("inTickers require 6 months of history before start date; PRIDX history begins 1989-01-31, choose a start date on or after 1989-07-31"))
+		})
+
+		It("should skip the history check without a start date", func() {
+			err := info.CheckConstraints(parseArgs(`{"inTickers": ["VFINX", "PRIDX"], "outTicker": "VUSTX"}`), time.Time{}, &manager)
+			Expect(err).To(BeNil())
+		})
+
+		It("should publish constraints for every strategy", func() {
+			for _, strat := range strategies.StrategyList {
+				Expect(strat.Constraints.RebalanceFrequencies).NotTo(BeEmpty(), strat.Shortcode)
+				Expect(strat.Constraints.AssetTypes).NotTo(BeEmpty(), strat.Shortcode)
+				for name := range strat.Constraints.MinHistoryMonths {
+					Expect(strat.Arguments).To(HaveKey(name), strat.Shortcode)
+					Expect(strat.Arguments[name].Tickers).To(BeTrue(), strat.Shortcode)
+				}
+			}
+		})
+	})
+})
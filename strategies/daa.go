@@ -45,18 +45,21 @@ func KellersDefensiveAssetAllocationInfo() StrategyInfo {
 				Description: "List of ETF, Mutual Fund, or Stock tickers in the 'risk' universe",
 				Typecode:    "[]string",
 				DefaultVal:  `["SPY", "IWM", "QQQ", "VGK", "EWJ", "VWO", "VNQ", "GSG", "GLD", "TLT", "HYG", "LQD"]`,
+				Tickers:     true,
 			},
 			"protectiveUniverse": {
 				Name:        "Protective Universe",
 				Description: "List of ETF, Mutual Fund, or Stock tickers in the 'protective' universe to use as canary assets, signaling when to invest in risk vs cash",
 				Typecode:    "[]string",
 				DefaultVal:  `["VWO", "AGG"]`,
+				Tickers:     true,
 			},
 			"cashUniverse": {
 				Name:        "Cash Universe",
 				Description: "List of ETF, Mutual Fund, or Stock tickers in the 'cash' universe",
 				Typecode:    "[]string",
 				DefaultVal:  `["SHY", "IEF", "LQD"]`,
+				Tickers:     true,
 			},
 			"breadth": {
 				Name:        "Breadth",
@@ -108,6 +111,11 @@ func KellersDefensiveAssetAllocationInfo() StrategyInfo {
 				"topT":               "1",
 			},
 		},
+		Constraints: Constraints{
+			MinHistoryMonths:     map[string]int{"cashUniverse": 12, "protectiveUniverse": 12, "riskUniverse": 12},
+			RebalanceFrequencies: []string{data.FrequencyMonthly},
+			AssetTypes:           []string{data.AssetTypeStock, data.AssetTypeMutualFund},
+		},
 		Factory: NewKellersDefensiveAssetAllocation,
 	}
 }
@@ -125,6 +133,7 @@ type KellersDefensiveAssetAllocation struct {
 	momentum           *dataframe.DataFrame
 	dataStartTime      time.Time
 	dataEndTime        time.Time
+	options            portfolioOptions
 
 	// Public
 	CurrentSymbol string
@@ -171,6 +180,11 @@ func NewKellersDefensiveAssetAllocation(args map[string]json.RawMessage) (Strate
 		return nil, err
 	}
 
+	options, err := parsePortfolioOptions(args)
+	if err != nil {
+		return nil, err
+	}
+
 	var daa Strategy
 	daa = &KellersDefensiveAssetAllocation{
 		info:               KellersDefensiveAssetAllocationInfo(),
@@ -179,6 +193,7 @@ func NewKellersDefensiveAssetAllocation(args map[string]json.RawMessage) (Strate
 		riskUniverse:       riskUniverse,
 		breadth:            breadth,
 		topT:               topT,
+		options:            options,
 	}
 
 	return daa, nil
@@ -340,6 +355,11 @@ func (daa *KellersDefensiveAssetAllocation) GetInfo() StrategyInfo {
 	return daa.info
 }
 
+// SetProgress report the progress of Compute to progress
+func (daa *KellersDefensiveAssetAllocation) SetProgress(progress portfolio.ProgressReporter) {
+	daa.options.progress = progress
+}
+
 func (daa *KellersDefensiveAssetAllocation) downloadPriceData(manager *data.Manager) error {
 	// Load EOD quotes for in tickers
 	manager.Frequency = data.FrequencyMonthly
@@ -420,7 +440,8 @@ func (daa *KellersDefensiveAssetAllocation) Compute(manager *data.Manager) (*por
 	sort.Strings(symbols)
 	daa.CurrentSymbol = strings.Join(symbols, " ")
 	p := portfolio.NewPortfolio("Defensive Asset Allocation Portfolio", manager)
-	err = p.TargetPortfolio(10000, daa.targetPortfolio)
+	daa.options.apply(&p, daa.info)
+	err = p.TargetPortfolio(daa.options.initialCapital(), daa.targetPortfolio)
 	if err != nil {
 		return nil, err
 	}
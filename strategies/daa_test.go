@@ -2,9 +2,8 @@ package strategies_test
 
 import (
 	"encoding/json"
-	"fmt"
-	"io/ioutil"
 	"main/data"
+	"main/portfolio"
 	"main/strategies"
 	"time"
 
@@ -36,71 +35,9 @@ var _ = Describe("Daa", func() {
 			"tiingo": "TEST",
 		})
 
-		content, err := ioutil.ReadFile("testdata/TB3MS.csv")
-		if err != nil {
-			panic(err)
-		}
-
-		httpmock.RegisterResponder("GET", "https://fred.stlouisfed.org/graph/fredgraph.csv?mode=fred&id=TB3MS&cosd=1979-07-01&coed=2021-01-01&fq=AdjustedClose&fam=avg",
-			httpmock.NewBytesResponder(200, content))
-
-		content, err = ioutil.ReadFile("testdata/VUSTX.csv")
-		if err != nil {
-			panic(err)
-		}
-
-		httpmock.RegisterResponder("GET", "https://api.tiingo.com/tiingo/daily/VUSTX/prices?startDate=1979-01-01&endDate=2021-01-01&format=csv&resampleFreq=Monthly&token=TEST",
-			httpmock.NewBytesResponder(200, content))
-
-		content, err = ioutil.ReadFile("testdata/VUSTX_2.csv")
-		if err != nil {
-			panic(err)
-		}
-
-		httpmock.RegisterResponder("GET", "https://api.tiingo.com/tiingo/daily/VUSTX/prices?startDate=1990-01-31&endDate=2021-01-01&format=csv&resampleFreq=Monthly&token=TEST",
-			httpmock.NewBytesResponder(200, content))
-
-		content, err = ioutil.ReadFile("testdata/VFINX.csv")
-		if err != nil {
-			panic(err)
-		}
-
-		httpmock.RegisterResponder("GET", "https://api.tiingo.com/tiingo/daily/VFINX/prices?startDate=1979-01-01&endDate=2021-01-01&format=csv&resampleFreq=Monthly&token=TEST",
-			httpmock.NewBytesResponder(200, content))
-
-		content, err = ioutil.ReadFile("testdata/VFINX_2.csv")
-		if err != nil {
-			panic(err)
-		}
-
-		httpmock.RegisterResponder("GET", "https://api.tiingo.com/tiingo/daily/VFINX/prices?startDate=1990-01-31&endDate=2021-01-01&format=csv&resampleFreq=Monthly&token=TEST",
-			httpmock.NewBytesResponder(200, content))
-
-		content, err = ioutil.ReadFile("testdata/PRIDX.csv")
-		if err != nil {
-			panic(err)
-		}
-
-		httpmock.RegisterResponder("GET", "https://api.tiingo.com/tiingo/daily/PRIDX/prices?startDate=1979-01-01&endDate=2021-01-01&format=csv&resampleFreq=Monthly&token=TEST",
-			httpmock.NewBytesResponder(200, content))
-
-		content, err = ioutil.ReadFile("testdata/PRIDX_2.csv")
-		if err != nil {
-			panic(err)
-		}
-
-		httpmock.RegisterResponder("GET", "https://api.tiingo.com/tiingo/daily/PRIDX/prices?startDate=1990-01-31&endDate=2021-01-01&format=csv&resampleFreq=Monthly&token=TEST",
-			httpmock.NewBytesResponder(200, content))
-
-		content, err = ioutil.ReadFile("testdata/riskfree.csv")
-		if err != nil {
-			panic(err)
-		}
-
-		today := time.Now()
-		url := fmt.Sprintf("https://fred.stlouisfed.org/graph/fredgraph.csv?mode=fred&id=DTB3&cosd=1970-01-01&coed=%d-%02d-%02d&fq=Daily&fam=avg", today.Year(), today.Month(), today.Day())
-		httpmock.RegisterResponder("GET", url,
-			httpmock.NewBytesResponder(200, content))
+		// the risk free rate is requested through today
+		fixtures := data.NewFixtureTransport("testdata/fixtures", "coed")
+		httpmock.RegisterNoResponder(fixtures.RoundTrip)
 
 		data.InitializeDataManager()
 	})
@@ -128,8 +65,13 @@ var _ = Describe("Daa", func() {
 				Expect(perf.PeriodEnd).To(Equal(end))
 				Expect(perf.Measurements).Should(HaveLen(379))
 
-				// Note: perf starts earlier than it should just because the test data starts earlier
-				// So we adjust here and ignore the first 6 entries
+				// the test data starts before the portfolio is funded
+				Expect(perf.WarmUpPeriods).To(Equal(6))
+				for _, meas := range perf.Measurements[:6] {
+					Expect(meas.WarmUp).To(BeTrue())
+				}
+				Expect(perf.Measurements[6].WarmUp).To(BeFalse())
+				Expect(perf.EffectiveStart).To(BeNumerically("==", 633744000))
 				Expect(perf.Measurements[6].Time).To(BeNumerically("==", 633744000))
 				Expect(perf.Measurements[6].Value).To(BeNumerically("==", 10000))
 				Expect(perf.Measurements[6].Holdings).To(Equal("VUSTX"))
@@ -154,6 +96,41 @@ var _ = Describe("Daa", func() {
 				Expect(perf.Measurements[378].Holdings).To(Equal("VUSTX"))
 				Expect(perf.Measurements[378].PercentReturn).Should(BeNumerically("~", -0.0299, 1e-4))
 			})
+
+			It("should record its provenance", func() {
+				manager.Begin = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+				manager.End = time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+				p, err := daa.Compute(&manager)
+				Expect(err).To(BeNil())
+
+				perf, err := p.CalculatePerformance(manager.End)
+				Expect(err).To(BeNil())
+				Expect(perf.Provenance.CodeVersion).NotTo(BeEmpty())
+				Expect(perf.Provenance.Strategy).To(Equal("daa"))
+				Expect(perf.Provenance.StrategyVersion).To(Equal("1.0.0"))
+				Expect(perf.Provenance.Parameters).To(HaveKey("riskUniverse"))
+				Expect(perf.Provenance.DataSnapshots).To(HaveKey("VFINX"))
+				Expect(perf.Provenance.DataSnapshots).To(HaveKey("PRIDX"))
+				Expect(perf.Provenance.DataSnapshots).To(HaveKey("VUSTX"))
+			})
+
+			It("should report its progress", func() {
+				manager.Begin = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+				manager.End = time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+				last := 0.0
+				var lastDate time.Time
+				Expect(strategies.SetProgress(daa, portfolio.ProgressFunc(func(percent float64, date time.Time) {
+					Expect(percent).To(BeNumerically(">", last))
+					last = percent
+					lastDate = date
+				}))).To(BeTrue())
+
+				p, err := daa.Compute(&manager)
+				Expect(err).To(BeNil())
+				Expect(last).To(Equal(100.0))
+				Expect(lastDate).To(Equal(p.EndTime))
+			})
 		})
 	})
 })
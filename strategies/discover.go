@@ -4,6 +4,10 @@ package strategies
 var StrategyList = []StrategyInfo{
 	AcceleratingDualMomentumInfo(),
 	KellersDefensiveAssetAllocationInfo(),
+	KellersLethargicAssetAllocationInfo(),
+	GlidepathInfo(),
+	ScriptedStrategyInfo(),
+	TrendFollowingInfo(),
 }
 
 // StrategyMap Map of strategies
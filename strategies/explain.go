@@ -0,0 +1,74 @@
+package strategies
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNotComputed the strategy must be computed before it can be explained
+var ErrNotComputed = errors.New("strategy has not been computed")
+
+// ErrNoExplanation no date on or before the requested date has a complete
+// set of scores
+var ErrNoExplanation = errors.New("no scores computed on or before date")
+
+// Explainer a strategy that exposes the intermediate values it used to select
+// assets, for transparency. Explain is called after Compute.
+type Explainer interface {
+	Explain(date time.Time) (*Explanation, error)
+}
+
+// Explanation how a momentum strategy selected its asset on a date
+type Explanation struct {
+	// Date last date on or before the requested date the strategy computed
+	Date time.Time `json:"date"`
+
+	// RiskFree risk-free return subtracted from each momentum, in percent,
+	// keyed by lookback period in months
+	RiskFree map[int]float64 `json:"riskFree"`
+
+	// Tickers intermediate values of each candidate ticker
+	Tickers map[string]*TickerExplanation `json:"tickers"`
+
+	// OutOfMarket ticker held when no candidate has a positive score
+	OutOfMarket string `json:"outOfMarket"`
+
+	// Chosen ticker the strategy selected
+	Chosen string `json:"chosen"`
+}
+
+// TickerExplanation intermediate values computed for a ticker on a date
+type TickerExplanation struct {
+	Price float64 `json:"price"`
+
+	// Lags price of the ticker keyed by lookback period in months
+	Lags map[int]float64 `json:"lags"`
+
+	// Momentum percent return over each lookback period less the risk-free
+	// return, keyed by lookback period in months
+	Momentum map[int]float64 `json:"momentum"`
+
+	// Score average of the momentum over all lookback periods
+	Score float64 `json:"score"`
+}
+
+// FlipDistances how far the selection is from flipping to each alternative,
+// keyed by ticker. The distance is the score of the chosen ticker less the
+// score of the alternative, in percentage points; the out-of-market ticker
+// scores zero.
+func (e *Explanation) FlipDistances() map[string]float64 {
+	scores := make(map[string]float64, len(e.Tickers)+1)
+	scores[e.OutOfMarket] = 0
+	for ticker, t := range e.Tickers {
+		scores[ticker] = t.Score
+	}
+
+	chosen := scores[e.Chosen]
+	distances := make(map[string]float64, len(scores)-1)
+	for ticker, score := range scores {
+		if ticker != e.Chosen {
+			distances[ticker] = chosen - score
+		}
+	}
+	return distances
+}
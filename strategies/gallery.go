@@ -0,0 +1,105 @@
+package strategies
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Template a curated configuration of a strategy that users can instantiate
+// as a portfolio
+type Template struct {
+	ID           string                     `json:"id"`
+	Name         string                     `json:"name"`
+	Strategy     string                     `json:"strategy"`
+	StrategyName string                     `json:"strategyName"`
+	Description  string                     `json:"description"`
+	Arguments    map[string]json.RawMessage `json:"arguments"`
+}
+
+// Templates configurations of the strategy seeded from its suggested
+// parameters, ordered by name. Arguments the suggested parameters leave out
+// take their default value.
+func (info *StrategyInfo) Templates() []Template {
+	names := make([]string, 0, len(info.SuggestedParameters))
+	for name := range info.SuggestedParameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	templates := make([]Template, 0, len(names))
+	for _, name := range names {
+		suggested := info.SuggestedParameters[name]
+		args := make(map[string]json.RawMessage, len(info.Arguments))
+		for argName, arg := range info.Arguments {
+			val, ok := suggested[argName]
+			if !ok {
+				val = arg.DefaultVal
+			}
+			args[argName] = argumentJSON(arg, val)
+		}
+
+		templates = append(templates, Template{
+			ID:           info.Shortcode + "-" + slug(name),
+			Name:         name,
+			Strategy:     info.Shortcode,
+			StrategyName: info.Name,
+			Description:  info.Description,
+			Arguments:    args,
+		})
+	}
+	return templates
+}
+
+// Gallery templates of every public strategy in StrategyList
+func Gallery() []Template {
+	gallery := []Template{}
+	for ii := range StrategyList {
+		if reg := Registered(StrategyList[ii].Shortcode); !reg.Public() {
+			continue
+		}
+		gallery = append(gallery, StrategyList[ii].Templates()...)
+	}
+	return gallery
+}
+
+// FindTemplate the template in the gallery with id
+func FindTemplate(id string) (Template, bool) {
+	for _, template := range Gallery() {
+		if template.ID == id {
+			return template, true
+		}
+	}
+	return Template{}, false
+}
+
+// argumentJSON the JSON value of a suggested or default argument value.
+// Values of string arguments are written unquoted, e.g. VUSTX, and are
+// quoted here; other values are already JSON.
+func argumentJSON(arg Argument, val string) json.RawMessage {
+	if arg.Typecode == "string" && !strings.HasPrefix(val, `"`) {
+		quoted, _ := json.Marshal(val)
+		return quoted
+	}
+	return json.RawMessage(val)
+}
+
+// slug lower case name with runs of other characters replaced by a dash,
+// e.g. "All ETF" becomes all-etf
+func slug(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteRune('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	return b.String()
+}
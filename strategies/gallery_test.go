@@ -0,0 +1,72 @@
+package strategies_test
+
+import (
+	"main/strategies"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Gallery", func() {
+	Describe("When listing the templates of a strategy", func() {
+		It("should seed them from the suggested parameters", func() {
+			info := strategies.AcceleratingDualMomentumInfo()
+			templates := info.Templates()
+			Expect(templates).To(HaveLen(3))
+
+			Expect(templates[0].ID).To(Equal("adm-all-etf"))
+			Expect(templates[0].Name).To(Equal("All ETF"))
+			Expect(templates[0].Strategy).To(Equal("adm"))
+			Expect(templates[0].StrategyName).To(Equal("Accelerating Dual Momentum"))
+			Expect(string(templates[0].Arguments["inTickers"])).To(Equal(`["SPY", "SCZ"]`))
+			Expect(string(templates[0].Arguments["outTicker"])).To(Equal(`"TLT"`))
+
+			Expect(templates[1].ID).To(Equal("adm-engineered-portfolio"))
+			Expect(templates[2].ID).To(Equal("adm-pridx"))
+		})
+
+		It("should use default values for arguments without a suggestion", func() {
+			info := strategies.AcceleratingDualMomentumInfo()
+			info.SuggestedParameters = map[string]map[string]string{
+				"Bonds Only": {
+					"outTicker": "VBMFX",
+				},
+			}
+			templates := info.Templates()
+			Expect(templates).To(HaveLen(1))
+			Expect(string(templates[0].Arguments["inTickers"])).To(Equal(`["VFINX", "PRIDX"]`))
+			Expect(string(templates[0].Arguments["outTicker"])).To(Equal(`"VBMFX"`))
+		})
+	})
+
+	Describe("When listing the gallery", func() {
+		It("should have templates every strategy accepts", func() {
+			gallery := strategies.Gallery()
+			Expect(gallery).NotTo(BeEmpty())
+
+			ids := map[string]bool{}
+			for _, template := range gallery {
+				Expect(ids).NotTo(HaveKey(template.ID))
+				ids[template.ID] = true
+
+				var info strategies.StrategyInfo
+				for _, strat := range strategies.StrategyList {
+					if strat.Shortcode == template.Strategy {
+						info = strat
+					}
+				}
+				_, err := info.Factory(template.Arguments)
+				Expect(err).To(BeNil(), template.ID)
+			}
+		})
+
+		It("should find templates by id", func() {
+			template, ok := strategies.FindTemplate("adm-pridx")
+			Expect(ok).To(BeTrue())
+			Expect(template.Name).To(Equal("PRIDX"))
+
+			_, ok = strategies.FindTemplate("missing")
+			Expect(ok).To(BeFalse())
+		})
+	})
+})
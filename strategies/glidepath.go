@@ -0,0 +1,323 @@
+/*
+ * Target-Date Glidepath v1.0
+ *
+ * A strategic allocation for retirement accounts modeled after target-date
+ * funds. The portfolio holds a stock fund and a bond fund and shifts from
+ * stocks to bonds along a glidepath as the target date (e.g. retirement)
+ * approaches. The stock allocation is held at its starting percentage until
+ * the glide begins, declines linearly to its ending percentage at the target
+ * date, and is held constant thereafter. The portfolio is rebalanced annually.
+ */
+
+package strategies
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"main/data"
+	"main/dfextras"
+	"main/portfolio"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+// GlidepathInfo information describing this strategy
+func GlidepathInfo() StrategyInfo {
+	return StrategyInfo{
+		Name:        "Target-Date Glidepath",
+		Shortcode:   "tdg",
+		Description: "A retirement strategy that shifts from stocks to bonds along a glidepath as the target date approaches, rebalanced annually.",
+		Source:      "https://investor.vanguard.com/investment-products/mutual-funds/target-retirement-funds",
+		Version:     "1.0.0",
+		Arguments: map[string]Argument{
+			"stockTicker": {
+				Name:        "Stock Fund",
+				Description: "ETF or Mutual Fund ticker used for the stock allocation",
+				Typecode:    "string",
+				DefaultVal:  "VTSMX",
+				Tickers:     true,
+			},
+			"bondTicker": {
+				Name:        "Bond Fund",
+				Description: "ETF or Mutual Fund ticker used for the bond allocation",
+				Typecode:    "string",
+				DefaultVal:  "VBMFX",
+				Tickers:     true,
+			},
+			"targetYear": {
+				Name:        "Target Year",
+				Description: "Year the glidepath reaches its ending allocation, e.g. the year of retirement",
+				Typecode:    "number",
+				DefaultVal:  "2050",
+			},
+			"glideYears": {
+				Name:        "Glide Years",
+				Description: "Number of years before the target year that the stock allocation begins to decline",
+				Typecode:    "number",
+				DefaultVal:  "25",
+			},
+			"startStockPercent": {
+				Name:        "Starting Stock Percent",
+				Description: "Percent of the portfolio invested in stocks before the glide begins",
+				Typecode:    "number",
+				DefaultVal:  "90",
+			},
+			"endStockPercent": {
+				Name:        "Ending Stock Percent",
+				Description: "Percent of the portfolio invested in stocks on and after the target year",
+				Typecode:    "number",
+				DefaultVal:  "40",
+			},
+		},
+		SuggestedParameters: map[string]map[string]string{
+			"Target 2050": {
+				"stockTicker":       `VTSMX`,
+				"bondTicker":        `VBMFX`,
+				"targetYear":        "2050",
+				"glideYears":        "25",
+				"startStockPercent": "90",
+				"endStockPercent":   "40",
+			},
+			"Target 2030": {
+				"stockTicker":       `VTSMX`,
+				"bondTicker":        `VBMFX`,
+				"targetYear":        "2030",
+				"glideYears":        "25",
+				"startStockPercent": "90",
+				"endStockPercent":   "40",
+			},
+			"All ETF 2040": {
+				"stockTicker":       `VTI`,
+				"bondTicker":        `BND`,
+				"targetYear":        "2040",
+				"glideYears":        "25",
+				"startStockPercent": "90",
+				"endStockPercent":   "40",
+			},
+		},
+		Constraints: Constraints{
+			MinHistoryMonths:     map[string]int{},
+			RebalanceFrequencies: []string{data.FrequencyAnnualy},
+			AssetTypes:           []string{data.AssetTypeStock, data.AssetTypeMutualFund},
+		},
+		Factory: NewGlidepath,
+	}
+}
+
+// Glidepath strategy type
+type Glidepath struct {
+	info              StrategyInfo
+	stockTicker       string
+	bondTicker        string
+	targetYear        int
+	glideYears        int
+	startStockPercent float64
+	endStockPercent   float64
+	prices            *dataframe.DataFrame
+	targetPortfolio   *dataframe.DataFrame
+	options           portfolioOptions
+
+	// Public
+	CurrentSymbol string
+}
+
+// NewGlidepath Construct a new target-date glidepath strategy
+func NewGlidepath(args map[string]json.RawMessage) (Strategy, error) {
+	var stockTicker string
+	if err := json.Unmarshal(args["stockTicker"], &stockTicker); err != nil {
+		return nil, err
+	}
+
+	var bondTicker string
+	if err := json.Unmarshal(args["bondTicker"], &bondTicker); err != nil {
+		return nil, err
+	}
+
+	var targetYear int
+	if err := json.Unmarshal(args["targetYear"], &targetYear); err != nil {
+		return nil, err
+	}
+
+	var glideYears int
+	if err := json.Unmarshal(args["glideYears"], &glideYears); err != nil {
+		return nil, err
+	}
+	if glideYears < 0 {
+		return nil, errors.New("glideYears must not be negative")
+	}
+
+	var startStockPercent float64
+	if err := json.Unmarshal(args["startStockPercent"], &startStockPercent); err != nil {
+		return nil, err
+	}
+
+	var endStockPercent float64
+	if err := json.Unmarshal(args["endStockPercent"], &endStockPercent); err != nil {
+		return nil, err
+	}
+
+	if startStockPercent < 0 || startStockPercent > 100 || endStockPercent < 0 || endStockPercent > 100 {
+		return nil, errors.New("stock percents must be between 0 and 100")
+	}
+
+	options, err := parsePortfolioOptions(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var glidepath Strategy
+	glidepath = &Glidepath{
+		info:              GlidepathInfo(),
+		stockTicker:       strings.ToUpper(stockTicker),
+		bondTicker:        strings.ToUpper(bondTicker),
+		targetYear:        targetYear,
+		glideYears:        glideYears,
+		startStockPercent: startStockPercent,
+		endStockPercent:   endStockPercent,
+		options:           options,
+	}
+
+	return glidepath, nil
+}
+
+// GetInfo get information about this strategy
+func (glidepath *Glidepath) GetInfo() StrategyInfo {
+	return glidepath.info
+}
+
+// SetProgress report the progress of Compute to progress
+func (glidepath *Glidepath) SetProgress(progress portfolio.ProgressReporter) {
+	glidepath.options.progress = progress
+}
+
+// StockAllocation fraction of the portfolio invested in stocks on date
+func (glidepath *Glidepath) StockAllocation(date time.Time) float64 {
+	target := time.Date(glidepath.targetYear, time.January, 1, 0, 0, 0, 0, time.UTC)
+	glideStart := target.AddDate(-glidepath.glideYears, 0, 0)
+
+	switch {
+	case !date.After(glideStart):
+		return glidepath.startStockPercent / 100.0
+	case !date.Before(target):
+		return glidepath.endStockPercent / 100.0
+	}
+
+	elapsed := date.Sub(glideStart).Hours() / target.Sub(glideStart).Hours()
+	pct := glidepath.startStockPercent + (glidepath.endStockPercent-glidepath.startStockPercent)*elapsed
+	return pct / 100.0
+}
+
+func (glidepath *Glidepath) downloadPriceData(manager *data.Manager) error {
+	// Load EOD quotes for in tickers
+	manager.Frequency = data.FrequencyMonthly
+
+	tickers := []string{glidepath.stockTicker}
+	if glidepath.bondTicker != glidepath.stockTicker {
+		tickers = append(tickers, glidepath.bondTicker)
+	}
+
+	prices, errs := manager.GetMultipleData(tickers...)
+	if len(errs) > 0 {
+		return errors.New("Failed to download data for tickers")
+	}
+
+	var eod = []*dataframe.DataFrame{}
+	for _, ticker := range tickers {
+		eod = append(eod, prices[ticker])
+	}
+
+	mergedEod, err := dfextras.MergeAndTimeAlign(context.TODO(), data.DateIdx, eod...)
+	glidepath.prices = mergedEod
+	return err
+}
+
+// buildTargetPortfolio invest on the first available date and rebalance at
+// the end of each year
+func (glidepath *Glidepath) buildTargetPortfolio() error {
+	dates := []interface{}{}
+	targets := []interface{}{}
+	stockPercents := []interface{}{}
+
+	timeIdx, err := glidepath.prices.NameToColumn(data.DateIdx)
+	if err != nil {
+		return err
+	}
+	timeSeries := glidepath.prices.Series[timeIdx]
+	nrows := timeSeries.NRows()
+
+	for row := 0; row < nrows; row++ {
+		date := timeSeries.Value(row).(time.Time)
+		if row != 0 && date.Month() != time.December {
+			continue
+		}
+
+		stocks := glidepath.StockAllocation(date)
+		target := map[string]float64{}
+		if stocks > 0 {
+			target[glidepath.stockTicker] += stocks
+		}
+		if stocks < 1 {
+			target[glidepath.bondTicker] += 1.0 - stocks
+		}
+
+		dates = append(dates, date)
+		targets = append(targets, target)
+		stockPercents = append(stockPercents, math.Round(stocks*10000.0)/100.0)
+	}
+
+	if len(dates) == 0 {
+		return errors.New("no price data available")
+	}
+
+	glidepath.targetPortfolio = dataframe.NewDataFrame(
+		dataframe.NewSeriesTime(data.DateIdx, &dataframe.SeriesInit{Size: len(dates)}, dates...),
+		dataframe.NewSeriesMixed(portfolio.TickerName, &dataframe.SeriesInit{Size: len(targets)}, targets...),
+		dataframe.NewSeriesFloat64("Stock Percent", &dataframe.SeriesInit{Size: len(dates)}, stockPercents...),
+	)
+
+	return nil
+}
+
+// Compute signal
+func (glidepath *Glidepath) Compute(manager *data.Manager) (*portfolio.Portfolio, error) {
+	nullTime := time.Time{}
+	if manager.End == nullTime {
+		manager.End = time.Now()
+	}
+	if manager.Begin == nullTime {
+		// Default computes things 50 years into the past
+		manager.Begin = manager.End.AddDate(-50, 0, 0)
+	}
+
+	err := glidepath.downloadPriceData(manager)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := glidepath.buildTargetPortfolio(); err != nil {
+		return nil, err
+	}
+
+	symbols := []string{}
+	tickerIdx, _ := glidepath.targetPortfolio.NameToColumn(portfolio.TickerName)
+	lastTarget := glidepath.targetPortfolio.Series[tickerIdx].Value(glidepath.targetPortfolio.NRows() - 1).(map[string]float64)
+	for kk := range lastTarget {
+		symbols = append(symbols, kk)
+	}
+	sort.Strings(symbols)
+	glidepath.CurrentSymbol = strings.Join(symbols, " ")
+
+	p := portfolio.NewPortfolio("Target-Date Glidepath Portfolio", manager)
+	glidepath.options.apply(&p, glidepath.info)
+	err = p.TargetPortfolio(glidepath.options.initialCapital(), glidepath.targetPortfolio)
+	if err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
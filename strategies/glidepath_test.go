@@ -0,0 +1,93 @@
+package strategies_test
+
+import (
+	"encoding/json"
+	"main/data"
+	"main/portfolio"
+	"main/strategies"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Glidepath", func() {
+	var (
+		glidepath *strategies.Glidepath
+		manager   data.Manager
+	)
+
+	BeforeEach(func() {
+		jsonParams := `{"stockTicker": "VFINX", "bondTicker": "VUSTX", "targetYear": 2010, "glideYears": 15, "startStockPercent": 90, "endStockPercent": 40}`
+		params := map[string]json.RawMessage{}
+		if err := json.Unmarshal([]byte(jsonParams), &params); err != nil {
+			panic(err)
+		}
+
+		tmp, err := strategies.NewGlidepath(params)
+		if err != nil {
+			panic(err)
+		}
+		glidepath = tmp.(*strategies.Glidepath)
+
+		manager = data.NewManager(map[string]string{
+			"tiingo": "TEST",
+		})
+
+		registerFixtures()
+		data.InitializeDataManager()
+	})
+
+	Describe("When following the glidepath", func() {
+		It("should hold the starting allocation before the glide begins", func() {
+			Expect(glidepath.StockAllocation(time.Date(1990, time.June, 30, 0, 0, 0, 0, time.UTC))).Should(BeNumerically("~", 0.9, 1e-9))
+			Expect(glidepath.StockAllocation(time.Date(1995, time.January, 1, 0, 0, 0, 0, time.UTC))).Should(BeNumerically("~", 0.9, 1e-9))
+		})
+
+		It("should decline linearly to the target year", func() {
+			Expect(glidepath.StockAllocation(time.Date(2002, time.July, 2, 12, 0, 0, 0, time.UTC))).Should(BeNumerically("~", 0.65, 1e-4))
+		})
+
+		It("should hold the ending allocation after the target year", func() {
+			Expect(glidepath.StockAllocation(time.Date(2010, time.January, 1, 0, 0, 0, 0, time.UTC))).Should(BeNumerically("~", 0.4, 1e-9))
+			Expect(glidepath.StockAllocation(time.Date(2020, time.December, 31, 0, 0, 0, 0, time.UTC))).Should(BeNumerically("~", 0.4, 1e-9))
+		})
+
+		It("should reject invalid percents", func() {
+			params := map[string]json.RawMessage{}
+			Expect(json.Unmarshal([]byte(`{"stockTicker": "VFINX", "bondTicker": "VUSTX", "targetYear": 2010, "glideYears": 15, "startStockPercent": 120, "endStockPercent": 40}`), &params)).To(Succeed())
+			_, err := strategies.NewGlidepath(params)
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	Describe("Compute allocations", func() {
+		Context("with full stock history", func() {
+			It("should rebalance annually", func() {
+				manager.Begin = time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC)
+				manager.End = time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+				p, err := glidepath.Compute(&manager)
+				Expect(err).To(BeNil())
+				Expect(glidepath.CurrentSymbol).To(Equal("VFINX VUSTX"))
+
+				markers := []portfolio.Transaction{}
+				for _, trx := range p.Transactions {
+					if trx.Kind == portfolio.MarkerTransaction {
+						markers = append(markers, trx)
+					}
+				}
+				Expect(markers).To(HaveLen(32))
+				for _, trx := range markers[1:] {
+					Expect(trx.Date.Month()).To(Equal(time.December))
+				}
+				Expect(markers[0].Justification["Stock Percent"]).Should(BeNumerically("~", 90, 1e-9))
+				Expect(markers[31].Justification["Stock Percent"]).Should(BeNumerically("~", 40, 1e-9))
+
+				perf, err := p.CalculatePerformance(manager.End)
+				Expect(err).To(BeNil())
+				Expect(perf.Measurements).Should(HaveLen(372))
+				Expect(perf.Measurements[371].Value).Should(BeNumerically("~", 192960.7794, 1e-4))
+			})
+		})
+	})
+})
@@ -0,0 +1,226 @@
+package strategies_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"main/data"
+	"main/portfolio"
+	"main/strategies"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// run `go test ./strategies -update` to regenerate the golden files after an
+// intentional change to a strategy or the portfolio calculations
+var updateGolden = flag.Bool("update", false, "update strategy golden files")
+
+// goldenCases arguments each registered strategy is run with; every strategy
+// must have a case and may only use securities that have a fixture in testdata
+var goldenCases = map[string]string{
+	"adm":    `{"inTickers": ["VFINX", "PRIDX"], "outTicker": "VUSTX"}`,
+	"daa":    `{"riskUniverse": ["VFINX", "PRIDX"], "cashUniverse": ["VUSTX"], "protectiveUniverse": ["VUSTX"], "breadth": 1, "topT": 1}`,
+	"laa":    `{"fixedAssets": ["VFINX", "VUSTX"], "riskAsset": "PRIDX", "safeAsset": "VUSTX", "indicator": "VFINX"}`,
+	"script": `{"tickers": ["VFINX", "PRIDX", "VUSTX"], "script": "def rebalance(date):\n    m = {t: momentum(t, 6) for t in TICKERS}\n    if None in m.values():\n        return None\n    return {sorted(TICKERS, key = lambda t: m[t])[-1]: 1.0}\n"}`,
+	"tdg":    `{"stockTicker": "VFINX", "bondTicker": "VUSTX", "targetYear": 2010, "glideYears": 15, "startStockPercent": 90, "endStockPercent": 40}`,
+	"trend":  `{"riskTicker": "VFINX", "safeTicker": "VUSTX", "smaDays": 10, "momentumMonths": 12}`,
+}
+
+// goldenTolerance maximum relative difference between a computed and golden
+// value; only allows for floating point noise
+const goldenTolerance = 1e-9
+
+// goldenResult transactions and measurements of a strategy backtest
+type goldenResult struct {
+	Strategy     string                             `json:"strategy"`
+	Arguments    json.RawMessage                    `json:"arguments"`
+	Transactions []portfolio.Transaction            `json:"transactions"`
+	Measurements []portfolio.PerformanceMeasurement `json:"measurements"`
+}
+
+// fixtureResponder serve the rows of a CSV fixture between the dates given by
+// the beginParam and endParam query parameters
+func fixtureResponder(fixture func(symbol string) string, beginParam, endParam string) httpmock.Responder {
+	return func(req *http.Request) (*http.Response, error) {
+		symbol, err := httpmock.GetSubmatch(req, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := ioutil.ReadFile(fixture(symbol))
+		if err != nil {
+			return httpmock.NewStringResponse(404, err.Error()), nil
+		}
+
+		begin := req.URL.Query().Get(beginParam)
+		end := req.URL.Query().Get(endParam)
+
+		var filtered bytes.Buffer
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		header := true
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !header && len(line) >= 10 {
+				date := line[:10]
+				if (begin != "" && date < begin) || (end != "" && date > end) {
+					continue
+				}
+			}
+			header = false
+			filtered.WriteString(line)
+			filtered.WriteString("\n")
+		}
+
+		return httpmock.NewBytesResponse(200, filtered.Bytes()), nil
+	}
+}
+
+// registerFixtures serve all price and rate requests from testdata
+func registerFixtures() {
+	httpmock.RegisterRegexpResponder("GET", regexp.MustCompile(`^https://api\.tiingo\.com/tiingo/daily/(\w+)/prices\?`),
+		fixtureResponder(func(symbol string) string {
+			return filepath.Join("testdata", symbol+".csv")
+		}, "startDate", "endDate"))
+
+	httpmock.RegisterRegexpResponder("GET", regexp.MustCompile(`^https://fred\.stlouisfed\.org/graph/fredgraph\.csv\?mode=fred&id=(\w+)&`),
+		fixtureResponder(func(symbol string) string {
+			if symbol == "DTB3" {
+				return filepath.Join("testdata", "riskfree.csv")
+			}
+			return filepath.Join("testdata", symbol+".csv")
+		}, "cosd", "coed"))
+}
+
+// runGoldenCase backtest a strategy against the fixtures
+func runGoldenCase(shortcode string, args string) goldenResult {
+	params := map[string]json.RawMessage{}
+	Expect(json.Unmarshal([]byte(args), &params)).To(Succeed())
+
+	strat, err := strategies.StrategyMap[shortcode].Factory(params)
+	Expect(err).NotTo(HaveOccurred())
+
+	manager := data.NewManager(map[string]string{
+		"tiingo": "TEST",
+	})
+	manager.Begin = time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC)
+	manager.End = time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	p, err := strat.Compute(&manager)
+	Expect(err).NotTo(HaveOccurred())
+
+	perf, err := p.CalculatePerformance(manager.End)
+	Expect(err).NotTo(HaveOccurred())
+
+	return goldenResult{
+		Strategy:     shortcode,
+		Arguments:    json.RawMessage(args),
+		Transactions: perf.Transactions,
+		Measurements: perf.Measurements,
+	}
+}
+
+// closeEnough compare two values within goldenTolerance
+func closeEnough(a, b float64) bool {
+	if a == b {
+		return true
+	}
+	scale := math.Max(1.0, math.Max(math.Abs(a), math.Abs(b)))
+	return math.Abs(a-b) <= goldenTolerance*scale
+}
+
+// goldenDiff describe every difference between the computed and golden
+// results
+func goldenDiff(actual, expected goldenResult) []string {
+	diffs := []string{}
+	if len(actual.Transactions) != len(expected.Transactions) {
+		diffs = append(diffs, fmt.Sprintf("expected %d transactions, got %d", len(expected.Transactions), len(actual.Transactions)))
+	}
+	for ii := 0; ii < len(actual.Transactions) && ii < len(expected.Transactions); ii++ {
+		a := actual.Transactions[ii]
+		e := expected.Transactions[ii]
+		if !a.Date.Equal(e.Date) || a.Ticker != e.Ticker || a.Kind != e.Kind {
+			diffs = append(diffs, fmt.Sprintf("transaction %d: expected %s %s on %s, got %s %s on %s", ii, e.Kind, e.Ticker, e.Date, a.Kind, a.Ticker, a.Date))
+			continue
+		}
+		if !closeEnough(a.Shares, e.Shares) || !closeEnough(a.PricePerShare, e.PricePerShare) ||
+			!closeEnough(a.TotalValue, e.TotalValue) || !closeEnough(a.Fees, e.Fees) {
+			diffs = append(diffs, fmt.Sprintf("transaction %d (%s %s on %s): expected %.10f shares @ %.10f = %.10f, got %.10f shares @ %.10f = %.10f",
+				ii, e.Kind, e.Ticker, e.Date, e.Shares, e.PricePerShare, e.TotalValue, a.Shares, a.PricePerShare, a.TotalValue))
+		}
+	}
+
+	if len(actual.Measurements) != len(expected.Measurements) {
+		diffs = append(diffs, fmt.Sprintf("expected %d measurements, got %d", len(expected.Measurements), len(actual.Measurements)))
+	}
+	for ii := 0; ii < len(actual.Measurements) && ii < len(expected.Measurements); ii++ {
+		a := actual.Measurements[ii]
+		e := expected.Measurements[ii]
+		if a.Time != e.Time || a.Holdings != e.Holdings {
+			diffs = append(diffs, fmt.Sprintf("measurement %d: expected %s at %d, got %s at %d", ii, e.Holdings, e.Time, a.Holdings, a.Time))
+			continue
+		}
+		if !closeEnough(a.Value, e.Value) || !closeEnough(a.RiskFreeValue, e.RiskFreeValue) || !closeEnough(a.PercentReturn, e.PercentReturn) {
+			diffs = append(diffs, fmt.Sprintf("measurement %d (%d): expected value %.10f return %.10f risk free %.10f, got value %.10f return %.10f risk free %.10f",
+				ii, e.Time, e.Value, e.PercentReturn, e.RiskFreeValue, a.Value, a.PercentReturn, a.RiskFreeValue))
+		}
+	}
+
+	return diffs
+}
+
+var _ = Describe("Golden", func() {
+	BeforeEach(func() {
+		registerFixtures()
+		data.InitializeDataManager()
+		strategies.IntializeStrategyMap()
+	})
+
+	Describe("When running every registered strategy against fixtures", func() {
+		It("should have a golden case for each strategy", func() {
+			for _, info := range strategies.StrategyList {
+				Expect(goldenCases).To(HaveKey(info.Shortcode), "strategy %s has no golden case", info.Shortcode)
+			}
+		})
+
+		for shortcode, args := range goldenCases {
+			shortcode := shortcode
+			args := args
+
+			It(fmt.Sprintf("should reproduce the golden results of %s", shortcode), func() {
+				actual := runGoldenCase(shortcode, args)
+				goldenFile := filepath.Join("testdata", "golden", shortcode+".json")
+
+				if *updateGolden {
+					content, err := json.MarshalIndent(actual, "", "  ")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(os.MkdirAll(filepath.Dir(goldenFile), 0755)).To(Succeed())
+					Expect(ioutil.WriteFile(goldenFile, append(content, '\n'), 0644)).To(Succeed())
+				}
+
+				content, err := ioutil.ReadFile(goldenFile)
+				Expect(err).NotTo(HaveOccurred(), "missing golden file; run go test ./strategies -update")
+
+				expected := goldenResult{}
+				Expect(json.Unmarshal(content, &expected)).To(Succeed())
+				Expect(goldenDiff(actual, expected)).To(BeEmpty())
+			})
+
+			It(fmt.Sprintf("should be deterministic for %s", shortcode), func() {
+				first := runGoldenCase(shortcode, args)
+				second := runGoldenCase(shortcode, args)
+				Expect(goldenDiff(second, first)).To(BeEmpty())
+			})
+		}
+	})
+})
@@ -0,0 +1,372 @@
+/*
+ * Keller's Lethargic Asset Allocation v1.0
+ * https://indexswingtrader.blogspot.com/2020/01/lethargic-asset-allocation-laa.html
+ * https://papers.ssrn.com/sol3/papers.cfm?abstract_id=3498092
+ *
+ * Lethargic Asset Allocation (LAA) is a hybrid of a static permanent portfolio
+ * and market timing. Three quarters of the portfolio are held in fixed 25%
+ * sleeves of value stocks, gold, and intermediate treasuries. The remaining
+ * 25% timing sleeve is invested in the Nasdaq 100 unless the economy looks
+ * like it is entering a recession -- the unemployment rate is above its
+ * 12-month moving average and the S&P 500 is below its 10-month moving
+ * average -- in which case it moves to short-term treasuries.
+ */
+
+package strategies
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"main/data"
+	"main/dfextras"
+	"main/portfolio"
+	"main/util"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+const (
+	laaUnemploymentSymbol = "$RATE.UNRATE"
+	laaUnemploymentSMA    = 12
+	laaIndicatorSMA       = 10
+)
+
+// KellersLethargicAssetAllocationInfo information describing this strategy
+func KellersLethargicAssetAllocationInfo() StrategyInfo {
+	return StrategyInfo{
+		Name:        "Kellers Lethargic Asset Allocation",
+		Shortcode:   "laa",
+		Description: "A permanent portfolio with a timing sleeve that moves to cash when the unemployment rate and stock market trends signal a recession.",
+		Source:      "https://indexswingtrader.blogspot.com/2020/01/lethargic-asset-allocation-laa.html",
+		Version:     "1.0.0",
+		Arguments: map[string]Argument{
+			"fixedAssets": {
+				Name:        "Fixed Assets",
+				Description: "List of ETF, Mutual Fund, or Stock tickers that are always held in equal weight sleeves",
+				Typecode:    "[]string",
+				DefaultVal:  `["IWD", "GLD", "IEF"]`,
+				Tickers:     true,
+			},
+			"riskAsset": {
+				Name:        "Risk Asset",
+				Description: "Ticker the timing sleeve invests in when the economy is healthy",
+				Typecode:    "string",
+				DefaultVal:  "QQQ",
+				Tickers:     true,
+			},
+			"safeAsset": {
+				Name:        "Safe Asset",
+				Description: "Ticker the timing sleeve invests in when a recession is signaled",
+				Typecode:    "string",
+				DefaultVal:  "SHY",
+				Tickers:     true,
+			},
+			"indicator": {
+				Name:        "Market Indicator",
+				Description: "Ticker whose price is compared to its 10-month moving average to determine the market trend",
+				Typecode:    "string",
+				DefaultVal:  "SPY",
+				Tickers:     true,
+			},
+		},
+		SuggestedParameters: map[string]map[string]string{
+			"LAA": {
+				"fixedAssets": `["IWD", "GLD", "IEF"]`,
+				"riskAsset":   `QQQ`,
+				"safeAsset":   `SHY`,
+				"indicator":   `SPY`,
+			},
+			"Mutual Funds": {
+				"fixedAssets": `["VIVAX", "OPGSX", "VFITX"]`,
+				"riskAsset":   `RYOCX`,
+				"safeAsset":   `VFISX`,
+				"indicator":   `VFINX`,
+			},
+		},
+		Constraints: Constraints{
+			MinHistoryMonths:     map[string]int{"indicator": 10},
+			RebalanceFrequencies: []string{data.FrequencyMonthly},
+			AssetTypes:           []string{data.AssetTypeStock, data.AssetTypeMutualFund},
+		},
+		Factory: NewKellersLethargicAssetAllocation,
+	}
+}
+
+// KellersLethargicAssetAllocation strategy type
+type KellersLethargicAssetAllocation struct {
+	info            StrategyInfo
+	fixedAssets     []string
+	riskAsset       string
+	safeAsset       string
+	indicator       string
+	prices          *dataframe.DataFrame
+	unemployment    *dataframe.DataFrame
+	targetPortfolio *dataframe.DataFrame
+	options         portfolioOptions
+
+	// Public
+	CurrentSymbol string
+}
+
+// NewKellersLethargicAssetAllocation Construct a new Kellers LAA strategy
+func NewKellersLethargicAssetAllocation(args map[string]json.RawMessage) (Strategy, error) {
+	fixedAssets := []string{}
+	if err := json.Unmarshal(args["fixedAssets"], &fixedAssets); err != nil {
+		return nil, err
+	}
+	if len(fixedAssets) == 0 {
+		return nil, errors.New("fixedAssets must contain at least one ticker")
+	}
+	util.ArrToUpper(fixedAssets)
+
+	var riskAsset string
+	if err := json.Unmarshal(args["riskAsset"], &riskAsset); err != nil {
+		return nil, err
+	}
+
+	var safeAsset string
+	if err := json.Unmarshal(args["safeAsset"], &safeAsset); err != nil {
+		return nil, err
+	}
+
+	var indicator string
+	if err := json.Unmarshal(args["indicator"], &indicator); err != nil {
+		return nil, err
+	}
+
+	options, err := parsePortfolioOptions(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var laa Strategy
+	laa = &KellersLethargicAssetAllocation{
+		info:        KellersLethargicAssetAllocationInfo(),
+		fixedAssets: fixedAssets,
+		riskAsset:   strings.ToUpper(riskAsset),
+		safeAsset:   strings.ToUpper(safeAsset),
+		indicator:   strings.ToUpper(indicator),
+		options:     options,
+	}
+
+	return laa, nil
+}
+
+// GetInfo get information about this strategy
+func (laa *KellersLethargicAssetAllocation) GetInfo() StrategyInfo {
+	return laa.info
+}
+
+// SetProgress report the progress of Compute to progress
+func (laa *KellersLethargicAssetAllocation) SetProgress(progress portfolio.ProgressReporter) {
+	laa.options.progress = progress
+}
+
+func (laa *KellersLethargicAssetAllocation) downloadPriceData(manager *data.Manager) error {
+	// Load EOD quotes for in tickers
+	manager.Frequency = data.FrequencyMonthly
+
+	tickerSet := map[string]bool{
+		laa.riskAsset: true,
+		laa.safeAsset: true,
+		laa.indicator: true,
+	}
+	for _, ticker := range laa.fixedAssets {
+		tickerSet[ticker] = true
+	}
+
+	tickers := make([]string, 0, len(tickerSet)+1)
+	for ticker := range tickerSet {
+		tickers = append(tickers, ticker)
+	}
+	sort.Strings(tickers)
+	tickers = append(tickers, laaUnemploymentSymbol)
+
+	prices, errs := manager.GetMultipleData(tickers...)
+	if len(errs) > 0 {
+		return errors.New("Failed to download data for tickers")
+	}
+
+	laa.unemployment = prices[laaUnemploymentSymbol]
+	delete(prices, laaUnemploymentSymbol)
+
+	var eod = []*dataframe.DataFrame{}
+	for _, ticker := range tickers[:len(tickers)-1] {
+		eod = append(eod, prices[ticker])
+	}
+
+	mergedEod, err := dfextras.MergeAndTimeAlign(context.TODO(), data.DateIdx, eod...)
+	laa.prices = mergedEod
+	return err
+}
+
+// unemploymentAsOf unemployment rate and its moving average as they were
+// known on date. The rate for a month is published early the following month
+// so only observations at least a month old are used.
+func (laa *KellersLethargicAssetAllocation) unemploymentAsOf(date time.Time) (float64, float64, bool) {
+	known := date.AddDate(0, -1, 0)
+	window := make([]float64, 0, laaUnemploymentSMA)
+
+	iterator := laa.unemployment.ValuesIterator(dataframe.ValuesOptions{InitialRow: 0, Step: 1, DontReadLock: true})
+	for {
+		row, vals, _ := iterator(dataframe.SeriesName)
+		if row == nil {
+			break
+		}
+
+		dt := vals[data.DateIdx].(time.Time)
+		if dt.After(known) {
+			break
+		}
+
+		val, ok := vals["UNRATE"].(float64)
+		if !ok || math.IsNaN(val) {
+			continue
+		}
+		window = append(window, val)
+		if len(window) > laaUnemploymentSMA {
+			window = window[1:]
+		}
+	}
+
+	if len(window) < laaUnemploymentSMA {
+		return 0, 0, false
+	}
+
+	var sum float64
+	for _, val := range window {
+		sum += val
+	}
+	return window[len(window)-1], sum / float64(len(window)), true
+}
+
+// buildTargetPortfolio hold the fixed assets and the timing sleeve in equal
+// weight; the timing sleeve switches to the safe asset when both the
+// unemployment rate and market trend are unfavorable
+func (laa *KellersLethargicAssetAllocation) buildTargetPortfolio(begin time.Time) error {
+	dates := []interface{}{}
+	targets := []interface{}{}
+	unemployment := []interface{}{}
+	unemploymentSMA := []interface{}{}
+	indicatorPrice := []interface{}{}
+	indicatorSMA := []interface{}{}
+
+	weight := 1.0 / float64(len(laa.fixedAssets)+1)
+	window := make([]float64, 0, laaIndicatorSMA)
+
+	iterator := laa.prices.ValuesIterator(dataframe.ValuesOptions{InitialRow: 0, Step: 1, DontReadLock: true})
+	for {
+		row, vals, _ := iterator(dataframe.SeriesName)
+		if row == nil {
+			break
+		}
+
+		date := vals[data.DateIdx].(time.Time)
+		price, ok := vals[laa.indicator].(float64)
+		if !ok || math.IsNaN(price) {
+			continue
+		}
+		window = append(window, price)
+		if len(window) > laaIndicatorSMA {
+			window = window[1:]
+		}
+
+		if len(window) < laaIndicatorSMA || date.Before(begin) {
+			continue
+		}
+
+		ue, ueSMA, ok := laa.unemploymentAsOf(date)
+		if !ok {
+			continue
+		}
+
+		var sum float64
+		for _, val := range window {
+			sum += val
+		}
+		sma := sum / float64(len(window))
+
+		timing := laa.riskAsset
+		if ue > ueSMA && price < sma {
+			timing = laa.safeAsset
+		}
+
+		target := make(map[string]float64)
+		for _, ticker := range laa.fixedAssets {
+			target[ticker] += weight
+		}
+		target[timing] += weight
+
+		dates = append(dates, date)
+		targets = append(targets, target)
+		unemployment = append(unemployment, ue)
+		unemploymentSMA = append(unemploymentSMA, ueSMA)
+		indicatorPrice = append(indicatorPrice, price)
+		indicatorSMA = append(indicatorSMA, sma)
+	}
+
+	if len(dates) == 0 {
+		return errors.New("not enough data to compute the timing signal")
+	}
+
+	laa.targetPortfolio = dataframe.NewDataFrame(
+		dataframe.NewSeriesTime(data.DateIdx, &dataframe.SeriesInit{Size: len(dates)}, dates...),
+		dataframe.NewSeriesMixed(portfolio.TickerName, &dataframe.SeriesInit{Size: len(targets)}, targets...),
+		dataframe.NewSeriesFloat64("Unemployment Rate", &dataframe.SeriesInit{Size: len(dates)}, unemployment...),
+		dataframe.NewSeriesFloat64("Unemployment SMA", &dataframe.SeriesInit{Size: len(dates)}, unemploymentSMA...),
+		dataframe.NewSeriesFloat64(fmt.Sprintf("%s Price", laa.indicator), &dataframe.SeriesInit{Size: len(dates)}, indicatorPrice...),
+		dataframe.NewSeriesFloat64(fmt.Sprintf("%s SMA", laa.indicator), &dataframe.SeriesInit{Size: len(dates)}, indicatorSMA...),
+	)
+
+	return nil
+}
+
+// Compute signal
+func (laa *KellersLethargicAssetAllocation) Compute(manager *data.Manager) (*portfolio.Portfolio, error) {
+	// Ensure time range is valid (need at least 13 months of unemployment data)
+	nullTime := time.Time{}
+	if manager.End == nullTime {
+		manager.End = time.Now()
+	}
+	begin := manager.Begin
+	if manager.Begin == nullTime {
+		// Default computes things 50 years into the past
+		manager.Begin = manager.End.AddDate(-50, 0, 0)
+	} else {
+		// Set Begin 14 months in the past so we actually get the requested time range
+		manager.Begin = manager.Begin.AddDate(0, -14, 0)
+	}
+
+	err := laa.downloadPriceData(manager)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := laa.buildTargetPortfolio(begin); err != nil {
+		return nil, err
+	}
+
+	symbols := []string{}
+	tickerIdx, _ := laa.targetPortfolio.NameToColumn(portfolio.TickerName)
+	lastTarget := laa.targetPortfolio.Series[tickerIdx].Value(laa.targetPortfolio.NRows() - 1).(map[string]float64)
+	for kk := range lastTarget {
+		symbols = append(symbols, kk)
+	}
+	sort.Strings(symbols)
+	laa.CurrentSymbol = strings.Join(symbols, " ")
+
+	p := portfolio.NewPortfolio("Lethargic Asset Allocation Portfolio", manager)
+	laa.options.apply(&p, laa.info)
+	err = p.TargetPortfolio(laa.options.initialCapital(), laa.targetPortfolio)
+	if err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
@@ -0,0 +1,93 @@
+package strategies_test
+
+import (
+	"encoding/json"
+	"main/data"
+	"main/portfolio"
+	"main/strategies"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Laa", func() {
+	var (
+		laa     *strategies.KellersLethargicAssetAllocation
+		manager data.Manager
+	)
+
+	BeforeEach(func() {
+		jsonParams := `{"fixedAssets": ["VFINX", "VUSTX"], "riskAsset": "PRIDX", "safeAsset": "VUSTX", "indicator": "VFINX"}`
+		params := map[string]json.RawMessage{}
+		if err := json.Unmarshal([]byte(jsonParams), &params); err != nil {
+			panic(err)
+		}
+
+		tmp, err := strategies.NewKellersLethargicAssetAllocation(params)
+		if err != nil {
+			panic(err)
+		}
+		laa = tmp.(*strategies.KellersLethargicAssetAllocation)
+
+		manager = data.NewManager(map[string]string{
+			"tiingo": "TEST",
+		})
+
+		registerFixtures()
+		data.InitializeDataManager()
+	})
+
+	Describe("When given invalid arguments", func() {
+		It("should require fixed assets", func() {
+			params := map[string]json.RawMessage{}
+			Expect(json.Unmarshal([]byte(`{"fixedAssets": [], "riskAsset": "QQQ", "safeAsset": "SHY", "indicator": "SPY"}`), &params)).To(Succeed())
+			_, err := strategies.NewKellersLethargicAssetAllocation(params)
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	Describe("Compute timing signal", func() {
+		Context("with full stock history", func() {
+			var perf portfolio.Performance
+
+			BeforeEach(func() {
+				manager.Begin = time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC)
+				manager.End = time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+				p, err := laa.Compute(&manager)
+				Expect(err).To(BeNil())
+
+				perf, err = p.CalculatePerformance(manager.End)
+				Expect(err).To(BeNil())
+			})
+
+			It("should be invested in the risk asset", func() {
+				Expect(laa.CurrentSymbol).To(Equal("PRIDX VFINX VUSTX"))
+				Expect(perf.Measurements).Should(HaveLen(372))
+				Expect(perf.Measurements[371].Value).Should(BeNumerically("~", 293719.2648, 1e-4))
+			})
+
+			It("should move the timing sleeve to the safe asset during the COVID crash", func() {
+				m := perf.Measurements[362]
+				Expect(time.Unix(m.Time, 0).UTC()).To(Equal(time.Date(2020, time.March, 31, 0, 0, 0, 0, time.UTC)))
+				Expect(m.Holdings).To(Equal("VFINX VUSTX"))
+				Expect(m.Justification["Unemployment Rate"]).Should(BeNumerically(">", m.Justification["Unemployment SMA"]))
+				Expect(m.Justification["VFINX Price"]).Should(BeNumerically("<", m.Justification["VFINX SMA"]))
+			})
+
+			It("should hold each sleeve in equal weight", func() {
+				var buys []portfolio.Transaction
+				for _, trx := range perf.Transactions {
+					if trx.Kind == portfolio.BuyTransaction && trx.Date.Equal(perf.Transactions[0].Date) {
+						buys = append(buys, trx)
+					}
+				}
+				Expect(buys).To(HaveLen(2))
+				Expect(buys[0].Ticker).To(Equal("VFINX"))
+				Expect(buys[0].TotalValue).Should(BeNumerically("~", 10000.0/3.0, 1e-6))
+				Expect(buys[1].Ticker).To(Equal("VUSTX"))
+				Expect(buys[1].TotalValue).Should(BeNumerically("~", 20000.0/3.0, 1e-6))
+			})
+		})
+	})
+})
@@ -0,0 +1,51 @@
+package strategies
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ArgumentMigration transform the arguments saved for one version of a
+// strategy into the arguments of the next version
+type ArgumentMigration struct {
+	From    string
+	To      string
+	Migrate func(args map[string]json.RawMessage) (map[string]json.RawMessage, error)
+}
+
+// MigrateArguments upgrade arguments saved with version to the current
+// version of the strategy by applying its migrations in order. Returns the
+// migrated arguments and the versions that were applied; args are returned
+// unchanged if they are already current.
+func (info *StrategyInfo) MigrateArguments(version string, args map[string]json.RawMessage) (map[string]json.RawMessage, []string, error) {
+	applied := []string{}
+	seen := map[string]bool{version: true}
+	for version != info.Version {
+		var next *ArgumentMigration
+		for ii := range info.Migrations {
+			if info.Migrations[ii].From == version {
+				next = &info.Migrations[ii]
+				break
+			}
+		}
+		if next == nil {
+			return nil, nil, fmt.Errorf("no migration from version %s to %s of strategy '%s'", version, info.Version, info.Shortcode)
+		}
+
+		// guard against migrations that loop back to an earlier version
+		if seen[next.To] {
+			return nil, nil, fmt.Errorf("migrations of strategy '%s' form a cycle at version %s", info.Shortcode, next.To)
+		}
+		seen[next.To] = true
+
+		migrated, err := next.Migrate(args)
+		if err != nil {
+			return nil, nil, fmt.Errorf("migrating strategy '%s' from version %s to %s: %s", info.Shortcode, next.From, next.To, err)
+		}
+		args = migrated
+		version = next.To
+		applied = append(applied, version)
+	}
+
+	return args, applied, nil
+}
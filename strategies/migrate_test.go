@@ -0,0 +1,74 @@
+package strategies_test
+
+import (
+	"encoding/json"
+	"main/strategies"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Migrate", func() {
+	var (
+		info strategies.StrategyInfo
+		args map[string]json.RawMessage
+	)
+
+	BeforeEach(func() {
+		info = strategies.StrategyInfo{
+			Shortcode: "test",
+			Version:   "3.0.0",
+			Migrations: []strategies.ArgumentMigration{
+				{
+					From: "2.0.0",
+					To:   "3.0.0",
+					Migrate: func(args map[string]json.RawMessage) (map[string]json.RawMessage, error) {
+						args["lookback"] = json.RawMessage(`6`)
+						return args, nil
+					},
+				},
+				{
+					From: "1.0.0",
+					To:   "2.0.0",
+					Migrate: func(args map[string]json.RawMessage) (map[string]json.RawMessage, error) {
+						args["riskTickers"] = args["inTickers"]
+						delete(args, "inTickers")
+						return args, nil
+					},
+				},
+			},
+		}
+
+		args = map[string]json.RawMessage{}
+		Expect(json.Unmarshal([]byte(`{"inTickers": ["VFINX", "PRIDX"]}`), &args)).To(Succeed())
+	})
+
+	Describe("When migrating saved arguments", func() {
+		It("should apply each migration in order", func() {
+			migrated, applied, err := info.MigrateArguments("1.0.0", args)
+			Expect(err).To(BeNil())
+			Expect(applied).To(Equal([]string{"2.0.0", "3.0.0"}))
+			Expect(migrated).NotTo(HaveKey("inTickers"))
+			Expect(string(migrated["riskTickers"])).To(Equal(`["VFINX", "PRIDX"]`))
+			Expect(string(migrated["lookback"])).To(Equal(`6`))
+		})
+
+		It("should leave current arguments unchanged", func() {
+			migrated, applied, err := info.MigrateArguments("3.0.0", args)
+			Expect(err).To(BeNil())
+			Expect(applied).To(BeEmpty())
+			Expect(migrated).To(Equal(args))
+		})
+
+		It("should fail without a migration path", func() {
+			_, _, err := info.MigrateArguments("0.9.0", args)
+			Expect(err).To(MatchError("no migration from version 0.9.0 to 3.0.0 of strategy 'test'"))
+		})
+
+		It("should detect migration cycles", func() {
+			info.Migrations[0].To = "1.0.0"
+			_, _, err := info.MigrateArguments("1.0.0", args)
+			Expect(err).To(MatchError("migrations of strategy 'test' form a cycle at version 1.0.0"))
+		})
+	})
+})
@@ -0,0 +1,119 @@
+package strategies
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"main/logging"
+	"path/filepath"
+	"plugin"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// PluginSymbol name of the function a strategy plugin exports. It has the
+// type func() []StrategyInfo and returns the strategies of the plugin, e.g.
+//
+//	package main
+//
+//	import "main/strategies"
+//
+//	func Strategies() []strategies.StrategyInfo {
+//		return []strategies.StrategyInfo{SectorRotationInfo()}
+//	}
+//
+// Plugins are built from the pv-api source tree, with the same Go version
+// as the binary loading them, using go build -buildmode=plugin.
+const PluginSymbol = "Strategies"
+
+var (
+	// ErrDuplicateStrategy a strategy with the same shortcode is registered
+	ErrDuplicateStrategy = errors.New("strategy is already registered")
+
+	// ErrInvalidPlugin the plugin does not export PluginSymbol as a
+	// func() []StrategyInfo
+	ErrInvalidPlugin = errors.New("invalid strategy plugin")
+)
+
+// Register add a strategy to StrategyList and StrategyMap. Strategies are
+// registered at startup, before any are run.
+func Register(info StrategyInfo) error {
+	if info.Shortcode == "" || info.Factory == nil {
+		return fmt.Errorf("strategy '%s' must have a shortcode and factory", info.Name)
+	}
+	if _, ok := StrategyMap[info.Shortcode]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateStrategy, info.Shortcode)
+	}
+	for _, registered := range StrategyList {
+		if registered.Shortcode == info.Shortcode {
+			return fmt.Errorf("%w: %s", ErrDuplicateStrategy, info.Shortcode)
+		}
+	}
+
+	StrategyList = append(StrategyList, info)
+	StrategyMap[info.Shortcode] = info
+	return nil
+}
+
+// LoadPlugin open the Go plugin at path and register its strategies.
+// Returns the strategies registered.
+func LoadPlugin(path string) ([]StrategyInfo, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	sym, err := p.Lookup(PluginSymbol)
+	if err != nil {
+		return nil, fmt.Errorf("%w %s: %s", ErrInvalidPlugin, path, err)
+	}
+	provide, ok := sym.(func() []StrategyInfo)
+	if !ok {
+		return nil, fmt.Errorf("%w %s: %s is a %T, not a func() []strategies.StrategyInfo", ErrInvalidPlugin, path, PluginSymbol, sym)
+	}
+
+	registered := []StrategyInfo{}
+	for _, info := range provide() {
+		if err := Register(info); err != nil {
+			return registered, fmt.Errorf("plugin %s: %w", path, err)
+		}
+		registered = append(registered, info)
+	}
+	return registered, nil
+}
+
+// LoadPlugins register the strategies of every plugin (*.so file) in dir,
+// in order of their file names
+func LoadPlugins(dir string) error {
+	if dir == "" {
+		return nil
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	paths := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".so" {
+			paths = append(paths, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		registered, err := LoadPlugin(path)
+		if err != nil {
+			return err
+		}
+		for _, info := range registered {
+			log.WithFields(log.Fields{
+				logging.FieldStrategy: info.Shortcode,
+				"Plugin":              path,
+				"Version":             info.Version,
+			}).Info("Loaded strategy plugin")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package strategies_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"main/strategies"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Plugin", func() {
+	var (
+		list []strategies.StrategyInfo
+	)
+
+	BeforeEach(func() {
+		list = strategies.StrategyList
+		strategies.IntializeStrategyMap()
+	})
+
+	AfterEach(func() {
+		strategies.StrategyList = list
+		delete(strategies.StrategyMap, "adm2")
+	})
+
+	Describe("When a strategy is registered", func() {
+		It("should be listed and found by its shortcode", func() {
+			info := strategies.AcceleratingDualMomentumInfo()
+			info.Shortcode = "adm2"
+			Expect(strategies.Register(info)).To(Succeed())
+			Expect(strategies.StrategyList).To(HaveLen(len(list) + 1))
+			Expect(strategies.StrategyMap).To(HaveKey("adm2"))
+		})
+
+		It("should reject a shortcode that is already registered", func() {
+			err := strategies.Register(strategies.AcceleratingDualMomentumInfo())
+			Expect(errors.Is(err, strategies.ErrDuplicateStrategy)).To(BeTrue())
+			Expect(strategies.StrategyList).To(HaveLen(len(list)))
+		})
+
+		It("should require a factory", func() {
+			Expect(strategies.Register(strategies.StrategyInfo{Name: "No Factory", Shortcode: "adm2"})).NotTo(Succeed())
+		})
+	})
+
+	Describe("When plugins are loaded", func() {
+		It("should do nothing without a plugin directory", func() {
+			Expect(strategies.LoadPlugins("")).To(Succeed())
+			Expect(strategies.StrategyList).To(HaveLen(len(list)))
+		})
+
+		It("should ignore files that are not plugins", func() {
+			dir, err := ioutil.TempDir("", "plugins")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(dir)
+			Expect(ioutil.WriteFile(filepath.Join(dir, "README"), []byte("strategy plugins"), 0644)).To(Succeed())
+
+			Expect(strategies.LoadPlugins(dir)).To(Succeed())
+			Expect(strategies.StrategyList).To(HaveLen(len(list)))
+		})
+
+		It("should fail for a plugin that cannot be opened", func() {
+			_, err := strategies.LoadPlugin(filepath.Join("testdata", "missing.so"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,165 @@
+package strategies
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"main/logging"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// RegistrationInterval how often processes reload registrations changed by
+// other processes
+const RegistrationInterval = time.Minute
+
+// Registration the marketplace status of a strategy. Registrations are
+// stored in the database so strategies can be soft launched to a share of
+// users or disabled without a redeploy; strategies without one are enabled
+// for everyone.
+type Registration struct {
+	Shortcode string `json:"shortcode"`
+
+	// Enabled false to hide the strategy and stop running it, e.g. while it
+	// is broken
+	Enabled bool `json:"enabled"`
+
+	// Deprecated strategies keep running for existing portfolios but are not
+	// offered for new ones; Replacement is the shortcode suggested instead
+	Deprecated      bool   `json:"deprecated"`
+	DeprecationNote string `json:"deprecationNote"`
+	Replacement     string `json:"replacement"`
+
+	// RolloutPercent percentage of users the strategy is available to; each
+	// user is consistently in or out of the rollout of a strategy
+	RolloutPercent int `json:"rolloutPercent"`
+
+	LastChanged int64 `json:"lastchanged"`
+}
+
+// RegistrationStore persists registrations so they are shared by every
+// process running strategies
+type RegistrationStore interface {
+	// Registrations every stored registration
+	Registrations(ctx context.Context) ([]Registration, error)
+}
+
+var registry = struct {
+	sync.RWMutex
+	byShortcode map[string]Registration
+}{byShortcode: make(map[string]Registration)}
+
+// DefaultRegistration the registration of strategies that have none stored
+func DefaultRegistration(shortcode string) Registration {
+	return Registration{
+		Shortcode:      shortcode,
+		Enabled:        true,
+		RolloutPercent: 100,
+	}
+}
+
+// Validate check that the registration is well formed
+func (r *Registration) Validate() error {
+	if _, ok := StrategyMap[r.Shortcode]; !ok {
+		return fmt.Errorf("strategy '%s' not found", r.Shortcode)
+	}
+	if r.RolloutPercent < 0 || r.RolloutPercent > 100 {
+		return errors.New("rolloutPercent must be between 0 and 100")
+	}
+	r.DeprecationNote = strings.TrimSpace(r.DeprecationNote)
+	if r.Replacement != "" {
+		if r.Replacement == r.Shortcode {
+			return errors.New("a strategy cannot replace itself")
+		}
+		if _, ok := StrategyMap[r.Replacement]; !ok {
+			return fmt.Errorf("replacement strategy '%s' not found", r.Replacement)
+		}
+	}
+	return nil
+}
+
+// Includes true if userID is in the rollout of the strategy
+func (r *Registration) Includes(userID string) bool {
+	if r.RolloutPercent >= 100 {
+		return true
+	}
+	h := fnv.New32a()
+	h.Write([]byte(r.Shortcode + "\x00" + userID))
+	return int(h.Sum32()%100) < r.RolloutPercent
+}
+
+// Available true if userID may view and run the strategy
+func (r *Registration) Available(userID string) bool {
+	return r.Enabled && r.Includes(userID)
+}
+
+// Offered true if userID may create new portfolios with the strategy
+func (r *Registration) Offered(userID string) bool {
+	return r.Available(userID) && !r.Deprecated
+}
+
+// Public true if the strategy is offered to everyone, including visitors that
+// are not logged in
+func (r *Registration) Public() bool {
+	return r.Enabled && !r.Deprecated && r.RolloutPercent >= 100
+}
+
+// SetRegistrations replace the registrations strategies are made available
+// with
+func SetRegistrations(regs []Registration) {
+	byShortcode := make(map[string]Registration, len(regs))
+	for _, r := range regs {
+		byShortcode[r.Shortcode] = r
+	}
+
+	registry.Lock()
+	registry.byShortcode = byShortcode
+	registry.Unlock()
+}
+
+// Registered the registration of the strategy shortcode
+func Registered(shortcode string) Registration {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	if r, ok := registry.byShortcode[shortcode]; ok {
+		return r
+	}
+	return DefaultRegistration(shortcode)
+}
+
+// LoadRegistrations replace the registrations with those in store
+func LoadRegistrations(ctx context.Context, store RegistrationStore) error {
+	regs, err := store.Registrations(ctx)
+	if err != nil {
+		return err
+	}
+	SetRegistrations(regs)
+	return nil
+}
+
+// LoadRegistrationsEvery reload the registrations from store at interval until
+// ctx is done, so changes made by other processes are picked up
+func LoadRegistrationsEvery(ctx context.Context, store RegistrationStore, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := LoadRegistrations(ctx, store); err != nil {
+			log.WithFields(log.Fields{
+				logging.FieldFunction: "strategies/registration.go:LoadRegistrationsEvery",
+				logging.FieldError:    err,
+			}).Warn("Could not load strategy registrations")
+		}
+	}
+}
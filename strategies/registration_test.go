@@ -0,0 +1,122 @@
+package strategies_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"main/strategies"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type registrationStore struct {
+	regs []strategies.Registration
+	err  error
+}
+
+func (s *registrationStore) Registrations(ctx context.Context) ([]strategies.Registration, error) {
+	return s.regs, s.err
+}
+
+var _ = Describe("Registration", func() {
+	BeforeEach(func() {
+		strategies.IntializeStrategyMap()
+	})
+
+	AfterEach(func() {
+		strategies.SetRegistrations(nil)
+	})
+
+	Describe("When a strategy has no registration", func() {
+		It("should be offered to everyone", func() {
+			reg := strategies.Registered("adm")
+			Expect(reg.Shortcode).To(Equal("adm"))
+			Expect(reg.Offered("user-1")).To(BeTrue())
+			Expect(reg.Public()).To(BeTrue())
+		})
+	})
+
+	Describe("When loading registrations", func() {
+		It("should replace the registrations with those in the store", func() {
+			store := &registrationStore{regs: []strategies.Registration{
+				{Shortcode: "adm", Enabled: false, RolloutPercent: 100},
+			}}
+			Expect(strategies.LoadRegistrations(context.Background(), store)).To(Succeed())
+			reg := strategies.Registered("adm")
+			Expect(reg.Available("user-1")).To(BeFalse())
+			reg = strategies.Registered("daa")
+			Expect(reg.Available("user-1")).To(BeTrue())
+		})
+
+		It("should keep the current registrations if the store fails", func() {
+			strategies.SetRegistrations([]strategies.Registration{{Shortcode: "adm", Enabled: false}})
+			store := &registrationStore{err: errors.New("connection refused")}
+			Expect(strategies.LoadRegistrations(context.Background(), store)).NotTo(Succeed())
+			reg := strategies.Registered("adm")
+			Expect(reg.Enabled).To(BeFalse())
+		})
+	})
+
+	Describe("When a strategy is deprecated", func() {
+		It("should be available but not offered", func() {
+			reg := strategies.Registration{Shortcode: "adm", Enabled: true, Deprecated: true, RolloutPercent: 100}
+			Expect(reg.Available("user-1")).To(BeTrue())
+			Expect(reg.Offered("user-1")).To(BeFalse())
+			Expect(reg.Public()).To(BeFalse())
+		})
+	})
+
+	Describe("When a strategy is rolled out to a share of users", func() {
+		It("should include about that share of users consistently", func() {
+			reg := strategies.Registration{Shortcode: "adm", Enabled: true, RolloutPercent: 30}
+			included := 0
+			for ii := 0; ii < 1000; ii++ {
+				userID := fmt.Sprintf("user-%d", ii)
+				if reg.Includes(userID) {
+					included++
+				}
+				Expect(reg.Includes(userID)).To(Equal(reg.Includes(userID)))
+			}
+			Expect(included).To(BeNumerically("~", 300, 60))
+			Expect(reg.Public()).To(BeFalse())
+		})
+
+		It("should include nobody at 0 percent", func() {
+			reg := strategies.Registration{Shortcode: "adm", Enabled: true, RolloutPercent: 0}
+			Expect(reg.Includes("user-1")).To(BeFalse())
+		})
+
+		It("should leave strategies that are not public out of the gallery", func() {
+			strategies.SetRegistrations([]strategies.Registration{{Shortcode: "adm", Enabled: true, RolloutPercent: 50}})
+			for _, template := range strategies.Gallery() {
+				Expect(template.Strategy).NotTo(Equal("adm"))
+			}
+			_, ok := strategies.FindTemplate("adm-all-etf")
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Describe("When validating a registration", func() {
+		It("should reject unknown strategies", func() {
+			reg := strategies.Registration{Shortcode: "nope", RolloutPercent: 100}
+			Expect(reg.Validate()).NotTo(Succeed())
+		})
+
+		It("should reject rollout percentages outside 0 to 100", func() {
+			reg := strategies.Registration{Shortcode: "adm", RolloutPercent: 101}
+			Expect(reg.Validate()).NotTo(Succeed())
+		})
+
+		It("should reject a strategy replacing itself", func() {
+			reg := strategies.Registration{Shortcode: "adm", RolloutPercent: 100, Deprecated: true, Replacement: "adm"}
+			Expect(reg.Validate()).NotTo(Succeed())
+		})
+
+		It("should accept a known replacement", func() {
+			reg := strategies.Registration{Shortcode: "adm", RolloutPercent: 100, Deprecated: true, Replacement: "daa", DeprecationNote: "  use daa  "}
+			Expect(reg.Validate()).To(Succeed())
+			Expect(reg.DeprecationNote).To(Equal("use daa"))
+		})
+	})
+})
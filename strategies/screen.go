@@ -0,0 +1,62 @@
+package strategies
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// UniverseArgument the argument holding the list of tickers the strategy
+// chooses among; the list argument with the most default tickers, e.g. the
+// risk universe of DAA rather than its canary assets. False if the strategy
+// has no ticker list argument.
+func (info *StrategyInfo) UniverseArgument() (string, bool) {
+	names := make([]string, 0, len(info.Arguments))
+	for name := range info.Arguments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	universe := ""
+	most := -1
+	for _, name := range names {
+		arg := info.Arguments[name]
+		if !arg.Tickers || arg.Typecode != "[]string" {
+			continue
+		}
+		defaults := []string{}
+		if err := json.Unmarshal([]byte(arg.DefaultVal), &defaults); err != nil {
+			defaults = nil
+		}
+		if len(defaults) > most {
+			universe = name
+			most = len(defaults)
+		}
+	}
+	return universe, universe != ""
+}
+
+// ScreeningArguments the default arguments of the strategy with its
+// universe argument replaced by universe. Without a universe the strategy
+// runs with its defaults; strategies without a universe argument cannot
+// screen one and return an error.
+func (info *StrategyInfo) ScreeningArguments(universe []string) (map[string]json.RawMessage, error) {
+	args := make(map[string]json.RawMessage, len(info.Arguments))
+	for name, arg := range info.Arguments {
+		args[name] = argumentJSON(arg, arg.DefaultVal)
+	}
+	if len(universe) == 0 {
+		return args, nil
+	}
+
+	name, ok := info.UniverseArgument()
+	if !ok {
+		return nil, fmt.Errorf("strategy '%s' does not choose among a list of tickers", info.Shortcode)
+	}
+	encoded, err := json.Marshal(universe)
+	if err != nil {
+		return nil, err
+	}
+	args[name] = encoded
+	return args, nil
+}
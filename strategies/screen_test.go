@@ -0,0 +1,51 @@
+package strategies_test
+
+import (
+	"main/strategies"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Screening", func() {
+	Describe("When finding the universe of a strategy", func() {
+		It("should choose the ticker list with the most defaults", func() {
+			info := strategies.KellersDefensiveAssetAllocationInfo()
+			name, ok := info.UniverseArgument()
+			Expect(ok).To(BeTrue())
+			Expect(name).To(Equal("riskUniverse"))
+		})
+
+		It("should not find one in strategies of single tickers", func() {
+			info := strategies.GlidepathInfo()
+			_, ok := info.UniverseArgument()
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Describe("When building screening arguments", func() {
+		It("should replace the universe and default the rest", func() {
+			info := strategies.AcceleratingDualMomentumInfo()
+			args, err := info.ScreeningArguments([]string{"SPY", "EFA"})
+			Expect(err).To(BeNil())
+			Expect(string(args["inTickers"])).To(Equal(`["SPY","EFA"]`))
+			Expect(string(args["outTicker"])).To(Equal(`"VUSTX"`))
+
+			_, err = info.Factory(args)
+			Expect(err).To(BeNil())
+		})
+
+		It("should use the defaults without a universe", func() {
+			info := strategies.GlidepathInfo()
+			args, err := info.ScreeningArguments(nil)
+			Expect(err).To(BeNil())
+			Expect(string(args["stockTicker"])).To(Equal(`"VTSMX"`))
+		})
+
+		It("should reject a universe the strategy cannot use", func() {
+			info := strategies.GlidepathInfo()
+			_, err := info.ScreeningArguments([]string{"SPY"})
+			Expect(err).NotTo(BeNil())
+		})
+	})
+})
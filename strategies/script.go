@@ -0,0 +1,540 @@
+/*
+ * Scripted Strategy
+ *
+ * A strategy written by the user in Starlark, a dialect of Python. The script
+ * defines rebalance(date), which is called at the end of every month with
+ * the date as YYYY-MM-DD and returns the weight of each ticker to hold, e.g.
+ *
+ *   def rebalance(date):
+ *       best = max(TICKERS, key = lambda t: momentum(t, 6) or -1)
+ *       return {best: 1.0}
+ *
+ * The script can only read prices of its tickers up to the date being
+ * rebalanced through these functions:
+ *
+ *   TICKERS                    the tickers argument
+ *   price(ticker, monthsAgo=0) month end price
+ *   history(ticker, months)    the last months prices, oldest first
+ *   sma(ticker, months)        average of the last months prices
+ *   momentum(ticker, months)   return over the last months
+ *   justify(name, value)       show value with the holdings of the date
+ *
+ * Prices are None until enough history is available; returning None from
+ * rebalance skips the date. Scripts cannot read files or the network and
+ * are stopped when they exceed ScriptMaxSteps or ScriptTimeout. Each script
+ * runs in its own process limited to ScriptMaxMemory so it cannot exhaust
+ * the memory of the API.
+ */
+
+package strategies
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"main/data"
+	"main/dfextras"
+	"main/portfolio"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rocketlaunchr/dataframe-go"
+	"go.starlark.net/starlark"
+)
+
+var (
+	// ScriptMaxSize largest script accepted, in bytes
+	ScriptMaxSize = 64 * 1024
+
+	// ScriptMaxSteps Starlark computation steps a script may take to
+	// compute a portfolio
+	ScriptMaxSteps uint64 = 50000000
+
+	// ScriptTimeout time a script may take to compute a portfolio
+	ScriptTimeout = 30 * time.Second
+
+	// ScriptMaxMemory memory, in bytes, the process running a script may
+	// allocate
+	ScriptMaxMemory uint64 = 512 << 20
+
+	// ErrScript the script is invalid or failed
+	ErrScript = errors.New("script error")
+)
+
+const scriptDefault = `# hold the ticker with the best 6 month momentum
+def rebalance(date):
+    scores = {t: momentum(t, 6) for t in TICKERS}
+    if None in scores.values():
+        return None
+    best = sorted(TICKERS, key = lambda t: scores[t])[-1]
+    justify("Momentum", scores[best])
+    return {best: 1.0}
+`
+
+// ScriptedStrategyInfo information describing this strategy
+func ScriptedStrategyInfo() StrategyInfo {
+	return StrategyInfo{
+		Name:        "Scripted Strategy",
+		Shortcode:   "script",
+		Description: "A strategy written in Starlark, a dialect of Python, that chooses the holdings of each month from the prices of its tickers.",
+		Source:      "https://github.com/bazelbuild/starlark/blob/master/spec.md",
+		Version:     "1.0.0",
+		Arguments: map[string]Argument{
+			"tickers": {
+				Name:        "Tickers",
+				Description: "List of ETF, Mutual Fund, or Stock tickers the script may hold",
+				Typecode:    "[]string",
+				DefaultVal:  `["VFINX", "PRIDX", "VUSTX"]`,
+				Tickers:     true,
+			},
+			"script": {
+				Name:        "Script",
+				Description: "Starlark script defining rebalance(date), which returns the weight of each ticker to hold",
+				Typecode:    "string",
+				DefaultVal:  scriptDefault,
+			},
+		},
+		SuggestedParameters: map[string]map[string]string{
+			"Momentum": {
+				"tickers": `["VFINX", "PRIDX", "VUSTX"]`,
+				"script":  scriptDefault,
+			},
+		},
+		Constraints: Constraints{
+			MinHistoryMonths:     map[string]int{"tickers": 1},
+			RebalanceFrequencies: []string{data.FrequencyMonthly},
+			AssetTypes:           []string{data.AssetTypeStock, data.AssetTypeMutualFund},
+		},
+		Factory: NewScriptedStrategy,
+	}
+}
+
+// ScriptedStrategy strategy type
+type ScriptedStrategy struct {
+	info            StrategyInfo
+	tickers         []string
+	script          string
+	prices          *dataframe.DataFrame
+	targetPortfolio *dataframe.DataFrame
+	options         portfolioOptions
+
+	// Public
+	CurrentSymbol string
+}
+
+// NewScriptedStrategy Construct a new scripted strategy; the script is
+// checked to define rebalance
+func NewScriptedStrategy(args map[string]json.RawMessage) (Strategy, error) {
+	tickers := []string{}
+	if err := json.Unmarshal(args["tickers"], &tickers); err != nil {
+		return nil, err
+	}
+	if len(tickers) == 0 {
+		return nil, errors.New("tickers must contain at least one ticker")
+	}
+	for ii := range tickers {
+		tickers[ii] = strings.ToUpper(tickers[ii])
+	}
+
+	var script string
+	if err := json.Unmarshal(args["script"], &script); err != nil {
+		return nil, err
+	}
+	if len(script) > ScriptMaxSize {
+		return nil, fmt.Errorf("%w: script must be at most %d bytes", ErrScript, ScriptMaxSize)
+	}
+
+	options, err := parsePortfolioOptions(args)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &ScriptedStrategy{
+		info:    ScriptedStrategyInfo(),
+		tickers: tickers,
+		script:  script,
+		options: options,
+	}
+
+	// compile the script and run its top level so errors are reported
+	// when the portfolio is created
+	if _, err := s.runScript(newScriptEnv(nil, nil), time.Time{}); err != nil {
+		return nil, err
+	}
+
+	var strat Strategy = s
+	return strat, nil
+}
+
+// GetInfo get information about this strategy
+func (s *ScriptedStrategy) GetInfo() StrategyInfo {
+	return s.info
+}
+
+// SetProgress report the progress of Compute to progress
+func (s *ScriptedStrategy) SetProgress(progress portfolio.ProgressReporter) {
+	s.options.progress = progress
+}
+
+// scriptEnv the prices visible to the script and the justification it
+// recorded while rebalancing a date
+type scriptEnv struct {
+	dates  []time.Time
+	prices map[string][]float64
+
+	// row index of the date being rebalanced; later prices are hidden
+	row int
+
+	justification map[string]float64
+}
+
+func newScriptEnv(dates []time.Time, prices map[string][]float64) *scriptEnv {
+	return &scriptEnv{dates: dates, prices: prices}
+}
+
+// history the last n prices of ticker, oldest first; false if fewer are
+// available
+func (env *scriptEnv) history(ticker string, n int) ([]float64, bool, error) {
+	series, ok := env.prices[strings.ToUpper(ticker)]
+	if !ok {
+		return nil, false, fmt.Errorf("%s is not one of the tickers", ticker)
+	}
+	if n < 1 {
+		return nil, false, errors.New("months must be at least 1")
+	}
+	first := env.row - n + 1
+	if first < 0 {
+		return nil, false, nil
+	}
+	window := series[first : env.row+1]
+	for _, val := range window {
+		if math.IsNaN(val) {
+			return nil, false, nil
+		}
+	}
+	return window, true, nil
+}
+
+func floatOrNone(val float64, ok bool) starlark.Value {
+	if !ok {
+		return starlark.None
+	}
+	return starlark.Float(val)
+}
+
+// builtins the API available to scripts
+func (env *scriptEnv) builtins(tickers []string) starlark.StringDict {
+	tickerValues := make([]starlark.Value, len(tickers))
+	for ii, ticker := range tickers {
+		tickerValues[ii] = starlark.String(ticker)
+	}
+
+	return starlark.StringDict{
+		"TICKERS": starlark.Tuple(tickerValues),
+
+		"price": starlark.NewBuiltin("price", func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var ticker string
+			var monthsAgo int
+			if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "ticker", &ticker, "monthsAgo?", &monthsAgo); err != nil {
+				return nil, err
+			}
+			if monthsAgo < 0 {
+				return nil, errors.New("price: monthsAgo must not be negative")
+			}
+			window, ok, err := env.history(ticker, monthsAgo+1)
+			if err != nil {
+				return nil, fmt.Errorf("price: %w", err)
+			}
+			if !ok {
+				return starlark.None, nil
+			}
+			return starlark.Float(window[0]), nil
+		}),
+
+		"history": starlark.NewBuiltin("history", func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var ticker string
+			var months int
+			if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "ticker", &ticker, "months", &months); err != nil {
+				return nil, err
+			}
+			window, ok, err := env.history(ticker, months)
+			if err != nil {
+				return nil, fmt.Errorf("history: %w", err)
+			}
+			if !ok {
+				return starlark.None, nil
+			}
+			values := make([]starlark.Value, len(window))
+			for ii, val := range window {
+				values[ii] = starlark.Float(val)
+			}
+			return starlark.NewList(values), nil
+		}),
+
+		"sma": starlark.NewBuiltin("sma", func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var ticker string
+			var months int
+			if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "ticker", &ticker, "months", &months); err != nil {
+				return nil, err
+			}
+			window, ok, err := env.history(ticker, months)
+			if err != nil {
+				return nil, fmt.Errorf("sma: %w", err)
+			}
+			var sum float64
+			for _, val := range window {
+				sum += val
+			}
+			return floatOrNone(sum/float64(len(window)), ok), nil
+		}),
+
+		"momentum": starlark.NewBuiltin("momentum", func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var ticker string
+			var months int
+			if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "ticker", &ticker, "months", &months); err != nil {
+				return nil, err
+			}
+			window, ok, err := env.history(ticker, months+1)
+			if err != nil {
+				return nil, fmt.Errorf("momentum: %w", err)
+			}
+			if !ok {
+				return starlark.None, nil
+			}
+			return starlark.Float(window[len(window)-1]/window[0] - 1.0), nil
+		}),
+
+		"justify": starlark.NewBuiltin("justify", func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var name string
+			var value float64
+			if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "name", &name, "value", &value); err != nil {
+				return nil, err
+			}
+			if env.justification == nil {
+				env.justification = map[string]float64{}
+			}
+			env.justification[name] = value
+			return starlark.None, nil
+		}),
+	}
+}
+
+// load compile the script and run its top level with a new thread limited
+// by ScriptMaxSteps. Returns the thread and the rebalance function.
+func (s *ScriptedStrategy) load(env *scriptEnv) (*starlark.Thread, starlark.Callable, error) {
+	thread := &starlark.Thread{
+		Name: "script",
+		Load: func(thread *starlark.Thread, module string) (starlark.StringDict, error) {
+			return nil, errors.New("load is not allowed")
+		},
+		Print: func(thread *starlark.Thread, msg string) {},
+	}
+	thread.SetMaxExecutionSteps(ScriptMaxSteps)
+
+	globals, err := starlark.ExecFile(thread, "script", s.script, env.builtins(s.tickers))
+	if err != nil {
+		return nil, nil, scriptError(err)
+	}
+
+	rebalance, ok := globals["rebalance"].(starlark.Callable)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: the script must define rebalance(date)", ErrScript)
+	}
+	return thread, rebalance, nil
+}
+
+// scriptError describe an error of the script including where it happened
+func scriptError(err error) error {
+	var evalErr *starlark.EvalError
+	if errors.As(err, &evalErr) {
+		return fmt.Errorf("%w: %s", ErrScript, evalErr.Backtrace())
+	}
+	return fmt.Errorf("%w: %s", ErrScript, err)
+}
+
+// limitScript cancel thread when it runs longer than ScriptTimeout; call the
+// returned function when the script is done
+func limitScript(thread *starlark.Thread) func() {
+	timeout := time.AfterFunc(ScriptTimeout, func() {
+		thread.Cancel(fmt.Sprintf("script took longer than %s", ScriptTimeout))
+	})
+	return func() { timeout.Stop() }
+}
+
+// allocation the weights returned by rebalance; nil if it returned None
+func (s *ScriptedStrategy) allocation(result starlark.Value) (map[string]float64, error) {
+	if result == starlark.None {
+		return nil, nil
+	}
+	dict, ok := result.(*starlark.Dict)
+	if !ok {
+		return nil, fmt.Errorf("rebalance must return a dict of ticker weights or None, not %s", result.Type())
+	}
+	if dict.Len() == 0 {
+		return nil, errors.New("rebalance must allocate at least one ticker")
+	}
+
+	allowed := map[string]bool{data.CashSymbol: true}
+	for _, ticker := range s.tickers {
+		allowed[ticker] = true
+	}
+
+	weights := make(map[string]float64, dict.Len())
+	for _, item := range dict.Items() {
+		ticker, ok := starlark.AsString(item[0])
+		if !ok {
+			return nil, fmt.Errorf("rebalance returned a %s key; keys must be tickers", item[0].Type())
+		}
+		ticker = strings.ToUpper(ticker)
+		if !allowed[ticker] {
+			return nil, fmt.Errorf("rebalance allocated %s, which is not one of the tickers", ticker)
+		}
+		weight, ok := starlark.AsFloat(item[1])
+		if !ok || math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return nil, fmt.Errorf("the weight of %s must be a number", ticker)
+		}
+		weights[ticker] += weight
+	}
+	return weights, nil
+}
+
+func (s *ScriptedStrategy) downloadPriceData(manager *data.Manager) error {
+	// Load EOD quotes for in tickers
+	manager.Frequency = data.FrequencyMonthly
+
+	tickers := append([]string{}, s.tickers...)
+	sort.Strings(tickers)
+	prices, errs := manager.GetMultipleData(tickers...)
+	if len(errs) > 0 {
+		return errors.New("Failed to download data for tickers")
+	}
+
+	var eod = []*dataframe.DataFrame{}
+	for _, ticker := range tickers {
+		eod = append(eod, prices[ticker])
+	}
+
+	mergedEod, err := dfextras.MergeAndTimeAlign(context.TODO(), data.DateIdx, eod...)
+	s.prices = mergedEod
+	return err
+}
+
+// buildTargetPortfolio call the script's rebalance for each month starting
+// at begin
+func (s *ScriptedStrategy) buildTargetPortfolio(begin time.Time) error {
+	env := newScriptEnv(nil, map[string][]float64{})
+	for _, ticker := range s.tickers {
+		env.prices[ticker] = []float64{}
+	}
+
+	iterator := s.prices.ValuesIterator(dataframe.ValuesOptions{InitialRow: 0, Step: 1, DontReadLock: true})
+	for {
+		row, vals, _ := iterator(dataframe.SeriesName)
+		if row == nil {
+			break
+		}
+		env.dates = append(env.dates, vals[data.DateIdx].(time.Time))
+		for _, ticker := range s.tickers {
+			price, ok := vals[ticker].(float64)
+			if !ok {
+				price = math.NaN()
+			}
+			env.prices[ticker] = append(env.prices[ticker], price)
+		}
+	}
+
+	rebalances, err := s.runScript(env, begin)
+	if err != nil {
+		return err
+	}
+
+	dates := []interface{}{}
+	targets := []interface{}{}
+	justifications := []map[string]float64{}
+	for _, rebalance := range rebalances {
+		dates = append(dates, rebalance.Date)
+		targets = append(targets, rebalance.Weights)
+		justifications = append(justifications, rebalance.Justification)
+	}
+
+	if len(dates) == 0 {
+		return errors.New("the script did not allocate any month")
+	}
+
+	names := []string{}
+	seen := map[string]bool{}
+	for _, justification := range justifications {
+		for name := range justification {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+
+	series := []dataframe.Series{
+		dataframe.NewSeriesTime(data.DateIdx, &dataframe.SeriesInit{Size: len(dates)}, dates...),
+		dataframe.NewSeriesMixed(portfolio.TickerName, &dataframe.SeriesInit{Size: len(targets)}, targets...),
+	}
+	for _, name := range names {
+		values := make([]interface{}, len(justifications))
+		for ii, justification := range justifications {
+			val, ok := justification[name]
+			if !ok {
+				val = math.NaN()
+			}
+			values[ii] = val
+		}
+		series = append(series, dataframe.NewSeriesFloat64(name, &dataframe.SeriesInit{Size: len(values)}, values...))
+	}
+	s.targetPortfolio = dataframe.NewDataFrame(series...)
+
+	return nil
+}
+
+// Compute signal
+func (s *ScriptedStrategy) Compute(manager *data.Manager) (*portfolio.Portfolio, error) {
+	// Ensure time range is valid
+	nullTime := time.Time{}
+	if manager.End == nullTime {
+		manager.End = time.Now()
+	}
+	begin := manager.Begin
+	if manager.Begin == nullTime {
+		// Default computes things 50 years into the past
+		manager.Begin = manager.End.AddDate(-50, 0, 0)
+	} else {
+		// Set Begin 13 months in the past so a year of history is
+		// available to the script on the start date
+		manager.Begin = manager.Begin.AddDate(0, -13, 0)
+	}
+
+	if err := s.downloadPriceData(manager); err != nil {
+		return nil, err
+	}
+
+	if err := s.buildTargetPortfolio(begin); err != nil {
+		return nil, err
+	}
+
+	tickerIdx, _ := s.targetPortfolio.NameToColumn(portfolio.TickerName)
+	lastTarget := s.targetPortfolio.Series[tickerIdx].Value(s.targetPortfolio.NRows() - 1).(map[string]float64)
+	symbols := []string{}
+	for ticker := range lastTarget {
+		symbols = append(symbols, ticker)
+	}
+	sort.Strings(symbols)
+	s.CurrentSymbol = strings.Join(symbols, " ")
+
+	p := portfolio.NewPortfolio("Scripted Strategy Portfolio", manager)
+	s.options.apply(&p, s.info)
+	if err := p.TargetPortfolio(s.options.initialCapital(), s.targetPortfolio); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
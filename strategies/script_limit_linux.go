@@ -0,0 +1,13 @@
+//go:build linux
+// +build linux
+
+package strategies
+
+import "syscall"
+
+// limitScriptMemory limit the memory the process may allocate to bytes.
+// RLIMIT_DATA is used since the Go runtime reserves far more address space
+// than it allocates.
+func limitScriptMemory(bytes uint64) error {
+	return syscall.Setrlimit(syscall.RLIMIT_DATA, &syscall.Rlimit{Cur: bytes, Max: bytes})
+}
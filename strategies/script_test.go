@@ -0,0 +1,130 @@
+package strategies_test
+
+import (
+	"encoding/json"
+	"errors"
+	"main/data"
+	"main/portfolio"
+	"main/strategies"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Script", func() {
+	var (
+		manager data.Manager
+	)
+
+	newScript := func(script string) (*strategies.ScriptedStrategy, error) {
+		encoded, err := json.Marshal(script)
+		Expect(err).NotTo(HaveOccurred())
+		params := map[string]json.RawMessage{
+			"tickers": json.RawMessage(`["VFINX", "PRIDX", "VUSTX"]`),
+			"script":  encoded,
+		}
+		strat, err := strategies.NewScriptedStrategy(params)
+		if err != nil {
+			return nil, err
+		}
+		return strat.(*strategies.ScriptedStrategy), nil
+	}
+
+	compute := func(script string) (*strategies.ScriptedStrategy, portfolio.Performance, error) {
+		strat, err := newScript(script)
+		Expect(err).NotTo(HaveOccurred())
+
+		manager.Begin = time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC)
+		manager.End = time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+		p, err := strat.Compute(&manager)
+		if err != nil {
+			return strat, portfolio.Performance{}, err
+		}
+		perf, err := p.CalculatePerformance(manager.End)
+		Expect(err).NotTo(HaveOccurred())
+		return strat, perf, nil
+	}
+
+	BeforeEach(func() {
+		manager = data.NewManager(map[string]string{
+			"tiingo": "TEST",
+		})
+
+		registerFixtures()
+		data.InitializeDataManager()
+	})
+
+	Describe("When given invalid scripts", func() {
+		It("should report syntax errors", func() {
+			_, err := newScript("def rebalance(date)\n    return {}\n")
+			Expect(errors.Is(err, strategies.ErrScript)).To(BeTrue())
+		})
+
+		It("should require a rebalance function", func() {
+			_, err := newScript("x = 1\n")
+			Expect(errors.Is(err, strategies.ErrScript)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("rebalance"))
+		})
+
+		It("should not allow loading modules", func() {
+			_, err := newScript("load('os', 'system')\ndef rebalance(date):\n    return {}\n")
+			Expect(errors.Is(err, strategies.ErrScript)).To(BeTrue())
+		})
+
+		It("should stop scripts that exceed their memory limit", func() {
+			_, err := newScript("big = ['a' * (1 << 29) for ii in range(4)]\ndef rebalance(date):\n    return {'VFINX': 1.0}\n")
+			Expect(errors.Is(err, strategies.ErrScript)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("memory"))
+		})
+	})
+
+	Describe("When computing a portfolio", func() {
+		It("should hold the tickers returned by rebalance", func() {
+			strat, perf, err := compute("def rebalance(date):\n    justify('VFINX Price', price('VFINX'))\n    return {'vfinx': 0.6, 'VUSTX': 0.4}\n")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(strat.CurrentSymbol).To(Equal("VFINX VUSTX"))
+			Expect(perf.Measurements).NotTo(BeEmpty())
+			Expect(perf.Measurements[len(perf.Measurements)-1].Holdings).To(Equal("VFINX VUSTX"))
+			Expect(perf.Measurements[len(perf.Measurements)-1].Justification).To(HaveKey("VFINX Price"))
+		})
+
+		It("should only show prices up to the date being rebalanced", func() {
+			_, perf, err := compute(`
+def rebalance(date):
+    p = price("VFINX")
+    if p == None or price("VFINX", 1) == None:
+        return None
+    justify("Latest", p)
+    justify("Previous", price("VFINX", 1))
+    return {"VFINX": 1.0}
+`)
+			Expect(err).NotTo(HaveOccurred())
+			last := perf.Measurements[len(perf.Measurements)-1]
+			prev := perf.Measurements[len(perf.Measurements)-2]
+			Expect(last.Justification["Previous"]).To(Equal(prev.Justification["Latest"]))
+		})
+
+		It("should reject tickers that are not arguments", func() {
+			_, _, err := compute("def rebalance(date):\n    return {'SPY': 1.0}\n")
+			Expect(errors.Is(err, strategies.ErrScript)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("SPY"))
+		})
+
+		It("should stop scripts that exceed their computation limit", func() {
+			maxSteps := strategies.ScriptMaxSteps
+			strategies.ScriptMaxSteps = 100000
+			defer func() { strategies.ScriptMaxSteps = maxSteps }()
+
+			_, _, err := compute("def rebalance(date):\n    total = 0\n    for ii in range(1000000):\n        total += ii\n    return {'VFINX': 1.0}\n")
+			Expect(errors.Is(err, strategies.ErrScript)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("too many steps"))
+		})
+
+		It("should stop scripts that exceed their memory limit", func() {
+			_, _, err := compute("def rebalance(date):\n    big = ['a' * (1 << 29) for ii in range(4)]\n    return {'VFINX': 1.0}\n")
+			Expect(errors.Is(err, strategies.ErrScript)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("memory"))
+		})
+	})
+})
@@ -0,0 +1,202 @@
+package strategies
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.starlark.net/starlark"
+)
+
+// scriptWorkerEnv set in the environment of the process started to run a
+// script; the process runs the script read from stdin and exits
+const scriptWorkerEnv = "PVAPI_SCRIPT_WORKER"
+
+// scriptWorkerGrace time given to a worker past ScriptTimeout to report
+// that its script took too long before it is killed
+const scriptWorkerGrace = 5 * time.Second
+
+// scriptRequest everything a worker needs to run a script
+type scriptRequest struct {
+	Script    string
+	Tickers   []string
+	Dates     []time.Time
+	Prices    map[string][]float64
+	Begin     time.Time
+	MaxSteps  uint64
+	MaxMemory uint64
+	Timeout   time.Duration
+}
+
+// scriptRebalance what rebalance returned for a date
+type scriptRebalance struct {
+	Date          time.Time
+	Weights       map[string]float64
+	Justification map[string]float64
+}
+
+// scriptResponse the result of a worker; Error is set if the script failed
+type scriptResponse struct {
+	Rebalances []scriptRebalance
+	Error      string
+}
+
+// workerError a script error reported by a worker
+type workerError string
+
+func (e workerError) Error() string {
+	return string(e)
+}
+
+func (e workerError) Is(target error) bool {
+	return target == ErrScript
+}
+
+func init() {
+	if os.Getenv(scriptWorkerEnv) != "" {
+		os.Exit(serveScript())
+	}
+}
+
+// serveScript run the script requested on stdin in this process and write
+// the response to stdout. Returns the exit code of the process.
+func serveScript() int {
+	var req scriptRequest
+	if err := gob.NewDecoder(os.Stdin).Decode(&req); err != nil {
+		fmt.Fprintf(os.Stderr, "could not read script request: %s\n", err)
+		return 1
+	}
+	if err := limitScriptMemory(req.MaxMemory); err != nil {
+		fmt.Fprintf(os.Stderr, "could not limit script memory: %s\n", err)
+		return 1
+	}
+
+	ScriptMaxSteps = req.MaxSteps
+	ScriptTimeout = req.Timeout
+	s := &ScriptedStrategy{
+		tickers: req.Tickers,
+		script:  req.Script,
+	}
+	env := newScriptEnv(req.Dates, req.Prices)
+
+	var resp scriptResponse
+	rebalances, err := s.rebalance(env, req.Begin)
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	resp.Rebalances = rebalances
+
+	if err := gob.NewEncoder(os.Stdout).Encode(&resp); err != nil {
+		fmt.Fprintf(os.Stderr, "could not write script response: %s\n", err)
+		return 1
+	}
+	return 0
+}
+
+// rebalance call the script's rebalance for each date of env starting at
+// begin. Only called by the worker.
+func (s *ScriptedStrategy) rebalance(env *scriptEnv, begin time.Time) ([]scriptRebalance, error) {
+	thread, rebalance, err := s.load(env)
+	if err != nil {
+		return nil, err
+	}
+	stop := limitScript(thread)
+	defer stop()
+
+	rebalances := []scriptRebalance{}
+	for ii, date := range env.dates {
+		if date.Before(begin) {
+			continue
+		}
+
+		env.row = ii
+		env.justification = nil
+		result, err := starlark.Call(thread, rebalance, starlark.Tuple{starlark.String(date.Format("2006-01-02"))}, nil)
+		if err != nil {
+			return nil, scriptError(err)
+		}
+		weights, err := s.allocation(result)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s on %s", ErrScript, err, date.Format("2006-01-02"))
+		}
+		if weights == nil {
+			continue
+		}
+
+		rebalances = append(rebalances, scriptRebalance{
+			Date:          date,
+			Weights:       weights,
+			Justification: env.justification,
+		})
+	}
+	return rebalances, nil
+}
+
+// runScript run the script in a new process limited to ScriptMaxMemory so
+// a script cannot exhaust the memory shared by every other request.
+// Rebalances dates of env starting at begin; with no dates the script is
+// only loaded.
+func (s *ScriptedStrategy) runScript(env *scriptEnv, begin time.Time) ([]scriptRebalance, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	var stdin bytes.Buffer
+	req := scriptRequest{
+		Script:    s.script,
+		Tickers:   s.tickers,
+		Dates:     env.dates,
+		Prices:    env.prices,
+		Begin:     begin,
+		MaxSteps:  ScriptMaxSteps,
+		MaxMemory: ScriptMaxMemory,
+		Timeout:   ScriptTimeout,
+	}
+	if err := gob.NewEncoder(&stdin).Encode(&req); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ScriptTimeout+scriptWorkerGrace)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, executable)
+	cmd.Env = append(os.Environ(), scriptWorkerEnv+"=1")
+	cmd.Stdin = &stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("%w: script took longer than %s", ErrScript, ScriptTimeout)
+		}
+		// the runtime of the worker crashes when an allocation exceeds its
+		// memory limit
+		log.WithFields(log.Fields{
+			"Error":  err,
+			"Stderr": firstLine(stderr.String()),
+		}).Warn("script worker failed")
+		return nil, fmt.Errorf("%w: script stopped; scripts may use at most %d MB of memory", ErrScript, ScriptMaxMemory>>20)
+	}
+
+	var resp scriptResponse
+	if err := gob.NewDecoder(&stdout).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("could not read script worker response: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, workerError(resp.Error)
+	}
+	return resp.Rebalances, nil
+}
+
+// firstLine the first line of text, which describes why a process crashed
+func firstLine(text string) string {
+	return strings.SplitN(strings.TrimSpace(text), "\n", 2)[0]
+}
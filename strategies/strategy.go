@@ -2,8 +2,10 @@ package strategies
 
 import (
 	"encoding/json"
+	"errors"
 	"main/data"
 	"main/portfolio"
+	"strings"
 )
 
 // StrategyFactory factory method to create strategy
@@ -16,6 +18,23 @@ type Argument struct {
 	Typecode    string   `json:"typecode"`
 	DefaultVal  string   `json:"default"`
 	Options     []string `json:"options"`
+
+	// Tickers the argument is a ticker or list of tickers
+	Tickers bool `json:"tickers,omitempty"`
+}
+
+// Constraints machine-readable requirements a strategy places on its
+// arguments; checked before a portfolio is created or run
+type Constraints struct {
+	// MinHistoryMonths months of price history the tickers of each argument
+	// need before the start date, keyed by argument name
+	MinHistoryMonths map[string]int `json:"minHistoryMonths"`
+
+	// RebalanceFrequencies frequencies the strategy rebalances at
+	RebalanceFrequencies []string `json:"rebalanceFrequencies"`
+
+	// AssetTypes kinds of assets (data.AssetType) ticker arguments may use
+	AssetTypes []string `json:"assetTypes"`
 }
 
 // StrategyInfo information about a strategy
@@ -28,6 +47,8 @@ type StrategyInfo struct {
 	YTDGain             float64                      `json:"ytd_gain"`
 	Arguments           map[string]Argument          `json:"arguments"`
 	SuggestedParameters map[string]map[string]string `json:"suggestedParams"`
+	Constraints         Constraints                  `json:"constraints"`
+	Migrations          []ArgumentMigration          `json:"-"`
 	Factory             StrategyFactory              `json:"-"`
 }
 
@@ -36,3 +57,165 @@ type Strategy interface {
 	GetInfo() StrategyInfo
 	Compute(manager *data.Manager) (*portfolio.Portfolio, error)
 }
+
+// ProgressStrategy a strategy that reports the progress of Compute
+type ProgressStrategy interface {
+	Strategy
+	SetProgress(progress portfolio.ProgressReporter)
+}
+
+// SetProgress report the progress of strat's Compute to progress. Returns
+// false if the strategy does not report its progress.
+func SetProgress(strat Strategy, progress portfolio.ProgressReporter) bool {
+	reporting, ok := strat.(ProgressStrategy)
+	if ok {
+		reporting.SetProgress(progress)
+	}
+	return ok
+}
+
+// portfolioOptions trading options common to all strategies; they are
+// optional arguments passed alongside the strategy specific arguments
+type portfolioOptions struct {
+	Leverage     float64
+	MarginRate   float64
+	MarginSpread float64
+	BorrowRate   float64
+
+	// AdvisoryFee annual fee in basis points deducted every
+	// AdvisoryFeeFrequency (monthly or quarterly)
+	AdvisoryFee          float64
+	AdvisoryFeeFrequency string
+
+	// InitialCapital amount deposited when the portfolio is created;
+	// portfolio.DefaultInitialCapital if 0. Normalize reports performance
+	// as the growth of 1.0 instead
+	InitialCapital float64
+	Normalize      bool
+
+	// Execution constraints on the trades of each rebalance
+	Execution portfolio.ExecutionConstraints
+
+	// RebalanceBands drift from the target weights tolerated before the
+	// portfolio is rebalanced, in percentage points (rebalanceBand) and
+	// percent of the target weight (relativeRebalanceBand)
+	RebalanceBands portfolio.RebalanceBands
+
+	// progress notified as the portfolio is simulated; set by SetProgress
+	// rather than an argument
+	progress portfolio.ProgressReporter
+
+	// arguments all arguments of the strategy, recorded in the provenance of
+	// its portfolios
+	arguments map[string]json.RawMessage
+}
+
+// parsePortfolioOptions read the common portfolio options from args
+func parsePortfolioOptions(args map[string]json.RawMessage) (portfolioOptions, error) {
+	opts := portfolioOptions{
+		arguments: make(map[string]json.RawMessage, len(args)),
+	}
+	for name, val := range args {
+		opts.arguments[name] = val
+	}
+	fields := map[string]*float64{
+		"leverage":              &opts.Leverage,
+		"marginRate":            &opts.MarginRate,
+		"marginSpread":          &opts.MarginSpread,
+		"borrowRate":            &opts.BorrowRate,
+		"advisoryFee":           &opts.AdvisoryFee,
+		"initialCapital":        &opts.InitialCapital,
+		"minTradeValue":         &opts.Execution.MinTradeValue,
+		"minTradePercent":       &opts.Execution.MinTradePercent,
+		"cashBuffer":            &opts.Execution.CashBuffer,
+		"rebalanceBand":         &opts.RebalanceBands.Absolute,
+		"relativeRebalanceBand": &opts.RebalanceBands.Relative,
+	}
+	for name, field := range fields {
+		if val, ok := args[name]; ok {
+			if err := json.Unmarshal(val, field); err != nil {
+				return opts, err
+			}
+		}
+	}
+
+	if opts.Leverage != 0 && opts.Leverage < 1.0 {
+		return opts, errors.New("leverage must be at least 1.0")
+	}
+
+	if opts.MarginRate < 0 || opts.BorrowRate < 0 {
+		return opts, errors.New("margin and borrow rates must not be negative")
+	}
+
+	if opts.AdvisoryFee < 0 {
+		return opts, errors.New("advisoryFee must not be negative")
+	}
+
+	if _, ok := args["initialCapital"]; ok && opts.InitialCapital <= 0 {
+		return opts, errors.New("initialCapital must be greater than 0")
+	}
+
+	if val, ok := args["normalize"]; ok {
+		if err := json.Unmarshal(val, &opts.Normalize); err != nil {
+			return opts, errors.New("normalize must be true or false")
+		}
+	}
+
+	if val, ok := args["wholeShares"]; ok {
+		if err := json.Unmarshal(val, &opts.Execution.WholeShares); err != nil {
+			return opts, errors.New("wholeShares must be true or false")
+		}
+	}
+
+	if opts.Execution.MinTradeValue < 0 || opts.Execution.MinTradePercent < 0 {
+		return opts, errors.New("minimum trade sizes must not be negative")
+	}
+
+	if opts.Execution.CashBuffer < 0 || opts.Execution.CashBuffer >= 100 {
+		return opts, errors.New("cashBuffer must be at least 0 and less than 100")
+	}
+
+	if opts.RebalanceBands.Absolute < 0 || opts.RebalanceBands.Relative < 0 {
+		return opts, errors.New("rebalance bands must not be negative")
+	}
+
+	if val, ok := args["advisoryFeeFrequency"]; ok {
+		if err := json.Unmarshal(val, &opts.AdvisoryFeeFrequency); err != nil {
+			return opts, err
+		}
+		opts.AdvisoryFeeFrequency = strings.ToLower(opts.AdvisoryFeeFrequency)
+		if opts.AdvisoryFeeFrequency != portfolio.FeeMonthly && opts.AdvisoryFeeFrequency != portfolio.FeeQuarterly {
+			return opts, errors.New("advisoryFeeFrequency must be monthly or quarterly")
+		}
+	}
+
+	return opts, nil
+}
+
+// apply configure the portfolio with the options and record that it was
+// computed by the strategy described by info
+func (opts portfolioOptions) apply(p *portfolio.Portfolio, info StrategyInfo) {
+	p.Leverage = opts.Leverage
+	p.MarginRate = opts.MarginRate
+	p.MarginSpread = opts.MarginSpread
+	p.BorrowRate = opts.BorrowRate
+	p.AdvisoryFee = opts.AdvisoryFee
+	p.AdvisoryFeeFrequency = opts.AdvisoryFeeFrequency
+	p.Normalize = opts.Normalize
+	p.Execution = opts.Execution
+	p.RebalanceBands = opts.RebalanceBands
+	p.Progress = opts.progress
+	p.Provenance = portfolio.Provenance{
+		Strategy:        info.Shortcode,
+		StrategyVersion: info.Version,
+		Parameters:      opts.arguments,
+	}
+}
+
+// initialCapital amount the portfolio is created with
+func (opts portfolioOptions) initialCapital() float64 {
+	if opts.InitialCapital > 0 {
+		return opts.InitialCapital
+	}
+	return portfolio.DefaultInitialCapital
+}
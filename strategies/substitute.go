@@ -0,0 +1,61 @@
+package strategies
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SubstituteTickers replace the tickers of each ticker argument in args that
+// have an entry in replacements. Returns a copy of args with the
+// substitutions applied and the sorted tickers that were replaced.
+func (info *StrategyInfo) SubstituteTickers(args map[string]json.RawMessage, replacements map[string]string) (map[string]json.RawMessage, []string, error) {
+	substituted := make(map[string]json.RawMessage, len(args))
+	for name, raw := range args {
+		substituted[name] = raw
+	}
+
+	replaced := map[string]bool{}
+	for name, arg := range info.Arguments {
+		raw, ok := args[name]
+		if !arg.Tickers || !ok {
+			continue
+		}
+
+		tickers, err := tickerArgument(raw)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s must be a ticker or list of tickers", name)
+		}
+
+		changed := false
+		for ii, ticker := range tickers {
+			if replacement, ok := replacements[strings.ToUpper(ticker)]; ok {
+				replaced[strings.ToUpper(ticker)] = true
+				tickers[ii] = replacement
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+
+		// keep the shape of the argument: a single ticker or a list
+		var value interface{} = tickers
+		single := ""
+		if err := json.Unmarshal(raw, &single); err == nil {
+			value = tickers[0]
+		}
+		if substituted[name], err = json.Marshal(value); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	tickers := make([]string, 0, len(replaced))
+	for ticker := range replaced {
+		tickers = append(tickers, ticker)
+	}
+	sort.Strings(tickers)
+
+	return substituted, tickers, nil
+}
@@ -0,0 +1,47 @@
+package strategies_test
+
+import (
+	"encoding/json"
+	"main/strategies"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Substitute", func() {
+	var (
+		info strategies.StrategyInfo
+	)
+
+	parseArgs := func(args string) map[string]json.RawMessage {
+		params := map[string]json.RawMessage{}
+		Expect(json.Unmarshal([]byte(args), &params)).To(Succeed())
+		return params
+	}
+
+	BeforeEach(func() {
+		info = strategies.AcceleratingDualMomentumInfo()
+	})
+
+	Describe("When substituting tickers", func() {
+		It("should replace tickers in lists and single ticker arguments", func() {
+			args := parseArgs(`{"inTickers": ["vfinx", "PRIDX"], "outTicker": "VUSTX", "leverage": 1.5}`)
+			substituted, replaced, err := info.SubstituteTickers(args, map[string]string{"VFINX": "VOO", "VUSTX": "VGLT"})
+			Expect(err).To(BeNil())
+			Expect(replaced).To(Equal([]string{"VFINX", "VUSTX"}))
+			Expect(string(substituted["inTickers"])).To(Equal(`["VOO","PRIDX"]`))
+			Expect(string(substituted["outTicker"])).To(Equal(`"VGLT"`))
+			Expect(string(substituted["leverage"])).To(Equal(`1.5`))
+
+			// the original arguments are not modified
+			Expect(string(args["inTickers"])).To(Equal(`["vfinx", "PRIDX"]`))
+		})
+
+		It("should report when nothing was replaced", func() {
+			args := parseArgs(`{"inTickers": ["SPY", "PRIDX"], "outTicker": "TLT"}`)
+			_, replaced, err := info.SubstituteTickers(args, map[string]string{"VFINX": "VOO"})
+			Expect(err).To(BeNil())
+			Expect(replaced).To(BeEmpty())
+		})
+	})
+})
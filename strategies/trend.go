@@ -0,0 +1,343 @@
+/*
+ * Trend Following
+ * https://papers.ssrn.com/sol3/papers.cfm?abstract_id=962461
+ *
+ * A timing model in the spirit of Mebane Faber's "A Quantitative Approach to
+ * Tactical Asset Allocation". The risk asset is held while its price is above
+ * its simple moving average and the safe asset otherwise. Unlike the monthly
+ * strategies the signal is evaluated every trading day so the portfolio exits
+ * as soon as the trend breaks rather than at the end of the month. An optional
+ * absolute momentum filter, measured on month end prices, additionally
+ * requires the risk asset to have gained over the last momentumMonths.
+ */
+
+package strategies
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"main/data"
+	"main/dfextras"
+	"main/portfolio"
+	"strings"
+	"time"
+
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+// TrendFollowingInfo information describing this strategy
+func TrendFollowingInfo() StrategyInfo {
+	return StrategyInfo{
+		Name:        "Trend Following",
+		Shortcode:   "trend",
+		Description: "A daily timing strategy that holds the risk asset while it trades above its moving average and the safe asset otherwise.",
+		Source:      "https://papers.ssrn.com/sol3/papers.cfm?abstract_id=962461",
+		Version:     "1.0.0",
+		Arguments: map[string]Argument{
+			"riskTicker": {
+				Name:        "Risk Ticker",
+				Description: "ETF, Mutual Fund, or Stock ticker to hold while it is trending up",
+				Typecode:    "string",
+				DefaultVal:  "VFINX",
+				Tickers:     true,
+			},
+			"safeTicker": {
+				Name:        "Safe Ticker",
+				Description: "Ticker to hold while the risk asset is below its moving average",
+				Typecode:    "string",
+				DefaultVal:  "VUSTX",
+				Tickers:     true,
+			},
+			"smaDays": {
+				Name:        "Moving Average Days",
+				Description: "Number of trading days in the simple moving average of the risk asset",
+				Typecode:    "number",
+				DefaultVal:  "200",
+			},
+			"momentumMonths": {
+				Name:        "Momentum Months",
+				Description: "Also require the risk asset to have gained over this many months, measured at month end; 0 disables the filter",
+				Typecode:    "number",
+				DefaultVal:  "0",
+			},
+		},
+		SuggestedParameters: map[string]map[string]string{
+			"200-Day SMA": {
+				"riskTicker":     "SPY",
+				"safeTicker":     "IEF",
+				"smaDays":        "200",
+				"momentumMonths": "0",
+			},
+			"Dual Trend": {
+				"riskTicker":     "VFINX",
+				"safeTicker":     "VUSTX",
+				"smaDays":        "200",
+				"momentumMonths": "12",
+			},
+		},
+		Constraints: Constraints{
+			MinHistoryMonths:     map[string]int{"riskTicker": 10},
+			RebalanceFrequencies: []string{data.FrequencyDaily},
+			AssetTypes:           []string{data.AssetTypeStock, data.AssetTypeMutualFund},
+		},
+		Factory: NewTrendFollowing,
+	}
+}
+
+// TrendFollowing strategy type
+type TrendFollowing struct {
+	info            StrategyInfo
+	riskTicker      string
+	safeTicker      string
+	smaDays         int
+	momentumMonths  int
+	prices          *dataframe.DataFrame
+	targetPortfolio *dataframe.DataFrame
+	options         portfolioOptions
+
+	// Public
+	CurrentSymbol string
+}
+
+// NewTrendFollowing Construct a new Trend Following strategy
+func NewTrendFollowing(args map[string]json.RawMessage) (Strategy, error) {
+	var riskTicker string
+	if err := json.Unmarshal(args["riskTicker"], &riskTicker); err != nil {
+		return nil, err
+	}
+	riskTicker = strings.ToUpper(riskTicker)
+
+	var safeTicker string
+	if err := json.Unmarshal(args["safeTicker"], &safeTicker); err != nil {
+		return nil, err
+	}
+	safeTicker = strings.ToUpper(safeTicker)
+
+	if riskTicker == safeTicker {
+		return nil, errors.New("riskTicker and safeTicker must be different")
+	}
+
+	var smaDays int
+	if err := json.Unmarshal(args["smaDays"], &smaDays); err != nil {
+		return nil, err
+	}
+	if smaDays < 1 {
+		return nil, errors.New("smaDays must be at least 1")
+	}
+
+	var momentumMonths int
+	if val, ok := args["momentumMonths"]; ok {
+		if err := json.Unmarshal(val, &momentumMonths); err != nil {
+			return nil, err
+		}
+	}
+	if momentumMonths < 0 {
+		return nil, errors.New("momentumMonths must not be negative")
+	}
+
+	options, err := parsePortfolioOptions(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var trend Strategy
+	trend = &TrendFollowing{
+		info:           TrendFollowingInfo(),
+		riskTicker:     riskTicker,
+		safeTicker:     safeTicker,
+		smaDays:        smaDays,
+		momentumMonths: momentumMonths,
+		options:        options,
+	}
+
+	return trend, nil
+}
+
+// GetInfo get information about this strategy
+func (trend *TrendFollowing) GetInfo() StrategyInfo {
+	return trend.info
+}
+
+// SetProgress report the progress of Compute to progress
+func (trend *TrendFollowing) SetProgress(progress portfolio.ProgressReporter) {
+	trend.options.progress = progress
+}
+
+func (trend *TrendFollowing) downloadPriceData(manager *data.Manager) error {
+	// the signal is evaluated every trading day
+	manager.Frequency = data.FrequencyDaily
+
+	prices, errs := manager.GetMultipleData(trend.riskTicker, trend.safeTicker)
+	if len(errs) > 0 {
+		return errors.New("Failed to download data for tickers")
+	}
+
+	mergedEod, err := dfextras.MergeAndTimeAlign(context.TODO(), data.DateIdx, prices[trend.riskTicker], prices[trend.safeTicker])
+	trend.prices = mergedEod
+	return err
+}
+
+// monthlyMomentum the return of the risk asset over momentumMonths as of
+// each month end, keyed by the month end's date
+func (trend *TrendFollowing) monthlyMomentum() ([]time.Time, []float64, error) {
+	monthly, err := data.Resample(trend.prices, data.FrequencyMonthly)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dateIdx, err := monthly.NameToColumn(data.DateIdx)
+	if err != nil {
+		return nil, nil, err
+	}
+	riskIdx, err := monthly.NameToColumn(trend.riskTicker)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nrows := monthly.NRows()
+	dates := make([]time.Time, 0, nrows)
+	momentum := make([]float64, 0, nrows)
+	for row := trend.momentumMonths; row < nrows; row++ {
+		last, ok1 := monthly.Series[riskIdx].Value(row).(float64)
+		first, ok2 := monthly.Series[riskIdx].Value(row - trend.momentumMonths).(float64)
+		if !ok1 || !ok2 || first == 0 {
+			continue
+		}
+		dates = append(dates, monthly.Series[dateIdx].Value(row).(time.Time))
+		momentum = append(momentum, (last/first-1)*100)
+	}
+
+	return dates, momentum, nil
+}
+
+// buildTargetPortfolio compare the risk asset to its moving average on each
+// trading day starting at begin
+func (trend *TrendFollowing) buildTargetPortfolio(begin time.Time) error {
+	dateIdx, err := trend.prices.NameToColumn(data.DateIdx)
+	if err != nil {
+		return err
+	}
+	riskIdx, err := trend.prices.NameToColumn(trend.riskTicker)
+	if err != nil {
+		return err
+	}
+
+	var momentumDates []time.Time
+	var momentum []float64
+	if trend.momentumMonths > 0 {
+		momentumDates, momentum, err = trend.monthlyMomentum()
+		if err != nil {
+			return err
+		}
+	}
+
+	dates := []interface{}{}
+	targets := []interface{}{}
+	smas := []interface{}{}
+	moms := []interface{}{}
+
+	var sum float64
+	window := make([]float64, 0, trend.smaDays)
+	momRow := -1
+	nrows := trend.prices.NRows()
+	for row := 0; row < nrows; row++ {
+		date := trend.prices.Series[dateIdx].Value(row).(time.Time)
+		price, ok := trend.prices.Series[riskIdx].Value(row).(float64)
+		if !ok {
+			return fmt.Errorf("no price for %s on %s", trend.riskTicker, date.Format("2006-01-02"))
+		}
+
+		window = append(window, price)
+		sum += price
+		if len(window) > trend.smaDays {
+			sum -= window[0]
+			window = window[1:]
+		}
+
+		// momentum is known once its month has ended
+		for momRow+1 < len(momentumDates) && !momentumDates[momRow+1].After(date) {
+			momRow++
+		}
+
+		if date.Before(begin) || len(window) < trend.smaDays {
+			continue
+		}
+		if trend.momentumMonths > 0 && momRow < 0 {
+			continue
+		}
+
+		sma := sum / float64(trend.smaDays)
+		target := trend.safeTicker
+		if price > sma && (trend.momentumMonths == 0 || momentum[momRow] > 0) {
+			target = trend.riskTicker
+		}
+
+		dates = append(dates, date)
+		targets = append(targets, target)
+		smas = append(smas, sma)
+		if trend.momentumMonths > 0 {
+			moms = append(moms, momentum[momRow])
+		}
+	}
+
+	if len(dates) == 0 {
+		return fmt.Errorf("%s does not have %d days of history", trend.riskTicker, trend.smaDays)
+	}
+
+	series := []dataframe.Series{
+		dataframe.NewSeriesTime(data.DateIdx, &dataframe.SeriesInit{Size: len(dates)}, dates...),
+		dataframe.NewSeriesString(portfolio.TickerName, &dataframe.SeriesInit{Size: len(targets)}, targets...),
+		dataframe.NewSeriesFloat64(fmt.Sprintf("%d-Day SMA", trend.smaDays), &dataframe.SeriesInit{Size: len(smas)}, smas...),
+	}
+	if trend.momentumMonths > 0 {
+		series = append(series, dataframe.NewSeriesFloat64(fmt.Sprintf("%d-Month Momentum", trend.momentumMonths), &dataframe.SeriesInit{Size: len(moms)}, moms...))
+	}
+	trend.targetPortfolio = dataframe.NewDataFrame(series...)
+
+	return nil
+}
+
+// Compute signal
+func (trend *TrendFollowing) Compute(manager *data.Manager) (*portfolio.Portfolio, error) {
+	// Ensure time range is valid
+	nullTime := time.Time{}
+	if manager.End == nullTime {
+		manager.End = time.Now()
+	}
+	begin := manager.Begin
+	if manager.Begin == nullTime {
+		// Default computes things 50 years into the past
+		manager.Begin = manager.End.AddDate(-50, 0, 0)
+	} else {
+		// Set Begin far enough in the past that the moving average and
+		// momentum are available on the start date; a month has about 21
+		// trading days
+		months := trend.smaDays/21 + 2
+		if trend.momentumMonths+2 > months {
+			months = trend.momentumMonths + 2
+		}
+		manager.Begin = manager.Begin.AddDate(0, -months, 0)
+	}
+
+	if err := trend.downloadPriceData(manager); err != nil {
+		return nil, err
+	}
+
+	if err := trend.buildTargetPortfolio(begin); err != nil {
+		return nil, err
+	}
+
+	tickerIdx, _ := trend.targetPortfolio.NameToColumn(portfolio.TickerName)
+	trend.CurrentSymbol = trend.targetPortfolio.Series[tickerIdx].Value(trend.targetPortfolio.NRows() - 1).(string)
+
+	p := portfolio.NewPortfolio("Trend Following Portfolio", manager)
+	trend.options.apply(&p, trend.info)
+	p.RebalanceOnChange = true
+	if err := p.TargetPortfolio(trend.options.initialCapital(), trend.targetPortfolio); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
@@ -0,0 +1,150 @@
+package strategies_test
+
+import (
+	"encoding/json"
+	"main/data"
+	"main/portfolio"
+	"main/strategies"
+	"math"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Trend", func() {
+	var (
+		manager data.Manager
+	)
+
+	newTrend := func(args string) (strategies.Strategy, error) {
+		params := map[string]json.RawMessage{}
+		Expect(json.Unmarshal([]byte(args), &params)).To(Succeed())
+		return strategies.NewTrendFollowing(params)
+	}
+
+	BeforeEach(func() {
+		manager = data.NewManager(map[string]string{
+			"tiingo": "TEST",
+		})
+		manager.Begin = time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC)
+		manager.End = time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+		registerFixtures()
+		data.InitializeDataManager()
+	})
+
+	Describe("When given invalid arguments", func() {
+		It("should require different risk and safe tickers", func() {
+			_, err := newTrend(`{"riskTicker": "VFINX", "safeTicker": "vfinx", "smaDays": 200}`)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should require a moving average of at least one day", func() {
+			_, err := newTrend(`{"riskTicker": "VFINX", "safeTicker": "VUSTX", "smaDays": 0}`)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("When given an initial capital", func() {
+		It("should deposit the initial capital", func() {
+			strat, err := newTrend(`{"riskTicker": "VFINX", "safeTicker": "VUSTX", "smaDays": 10, "initialCapital": 250000}`)
+			Expect(err).NotTo(HaveOccurred())
+
+			p, err := strat.Compute(&manager)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(p.Transactions[0].Kind).To(Equal(portfolio.DepositTransaction))
+			Expect(p.Transactions[0].TotalValue).To(Equal(250000.0))
+
+			perf, err := p.CalculatePerformance(manager.End)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(perf.InitialCapital).To(Equal(250000.0))
+		})
+
+		It("should report the growth of 1.0 when normalized", func() {
+			strat, err := newTrend(`{"riskTicker": "VFINX", "safeTicker": "VUSTX", "smaDays": 10, "normalize": true}`)
+			Expect(err).NotTo(HaveOccurred())
+
+			p, err := strat.Compute(&manager)
+			Expect(err).NotTo(HaveOccurred())
+			perf, err := p.CalculatePerformance(manager.End)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(perf.Normalized).To(BeTrue())
+			Expect(perf.Measurements[0].Value).Should(BeNumerically("~", 1.0, 1e-9))
+		})
+
+		It("should require a positive initial capital", func() {
+			_, err := newTrend(`{"riskTicker": "VFINX", "safeTicker": "VUSTX", "smaDays": 10, "initialCapital": 0}`)
+			Expect(err).To(HaveOccurred())
+
+			_, err = newTrend(`{"riskTicker": "VFINX", "safeTicker": "VUSTX", "smaDays": 10, "normalize": "yes"}`)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("When given execution constraints", func() {
+		It("should only trade whole shares", func() {
+			strat, err := newTrend(`{"riskTicker": "VFINX", "safeTicker": "VUSTX", "smaDays": 10, "wholeShares": true, "cashBuffer": 2}`)
+			Expect(err).NotTo(HaveOccurred())
+
+			p, err := strat.Compute(&manager)
+			Expect(err).NotTo(HaveOccurred())
+			for _, trx := range p.Transactions {
+				if trx.Kind == portfolio.BuyTransaction || trx.Kind == portfolio.SellTransaction {
+					Expect(trx.Shares).To(Equal(math.Trunc(trx.Shares)))
+				}
+			}
+		})
+
+		It("should reject invalid constraints", func() {
+			_, err := newTrend(`{"riskTicker": "VFINX", "safeTicker": "VUSTX", "smaDays": 10, "cashBuffer": 100}`)
+			Expect(err).To(HaveOccurred())
+
+			_, err = newTrend(`{"riskTicker": "VFINX", "safeTicker": "VUSTX", "smaDays": 10, "minTradeValue": -1}`)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("When computing a portfolio", func() {
+		It("should only trade when the trend changes", func() {
+			strat, err := newTrend(`{"riskTicker": "VFINX", "safeTicker": "VUSTX", "smaDays": 10}`)
+			Expect(err).NotTo(HaveOccurred())
+
+			p, err := strat.Compute(&manager)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(strat.(*strategies.TrendFollowing).CurrentSymbol).To(BeElementOf("VFINX", "VUSTX"))
+
+			// a signal is recorded for every date but the portfolio is
+			// only rebalanced when the held asset changes
+			markers := 0
+			var held string
+			for _, trx := range p.Transactions {
+				switch trx.Kind {
+				case portfolio.MarkerTransaction:
+					markers++
+				case portfolio.BuyTransaction:
+					Expect(trx.Ticker).NotTo(Equal(held))
+					held = trx.Ticker
+				}
+			}
+			Expect(markers).To(BeNumerically(">", 1))
+			Expect(len(p.Signals)).To(BeNumerically(">", markers))
+			Expect(p.Signals[0].Date.Before(time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC))).To(BeFalse())
+			Expect(p.Signals[0].Justification).To(HaveKey("10-Day SMA"))
+		})
+
+		It("should stay out of the market until momentum is positive", func() {
+			strat, err := newTrend(`{"riskTicker": "VFINX", "safeTicker": "VUSTX", "smaDays": 10, "momentumMonths": 12}`)
+			Expect(err).NotTo(HaveOccurred())
+
+			p, err := strat.Compute(&manager)
+			Expect(err).NotTo(HaveOccurred())
+			for _, signal := range p.Signals {
+				momentum := signal.Justification["12-Month Momentum"].(float64)
+				if momentum <= 0 {
+					Expect(signal.Target).To(Equal(map[string]float64{"VUSTX": 1.0}))
+				}
+			}
+		})
+	})
+})
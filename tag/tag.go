@@ -0,0 +1,92 @@
+// Package tag normalizes the tags and folders users organize their saved
+// portfolios with
+package tag
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// maxTags most tags a portfolio may have
+	maxTags = 20
+
+	// maxTagLength longest tag accepted
+	maxTagLength = 32
+
+	// maxFolderLength longest folder path accepted
+	maxFolderLength = 100
+)
+
+// Normalize trim and lower case tags, dropping blanks and duplicates, and
+// sort them; returns an error if a tag is too long or there are too many
+func Normalize(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = strings.ToLower(strings.Join(strings.Fields(t), " "))
+		if t == "" || seen[t] {
+			continue
+		}
+		if len([]rune(t)) > maxTagLength {
+			return nil, fmt.Errorf("tag %q must be at most %d characters", t, maxTagLength)
+		}
+		seen[t] = true
+		normalized = append(normalized, t)
+	}
+	if len(normalized) > maxTags {
+		return nil, fmt.Errorf("a portfolio may have at most %d tags", maxTags)
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// Has true if tags includes every tag in want; want is normalized first
+func Has(tags []string, want []string) bool {
+	for _, w := range want {
+		w = strings.ToLower(strings.Join(strings.Fields(w), " "))
+		if w == "" {
+			continue
+		}
+		found := false
+		for _, t := range tags {
+			if t == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizeFolder trim the parts of a folder path separated by "/", e.g.
+// " Retirement / IRA " becomes "Retirement/IRA"; the empty path is the top
+// level
+func NormalizeFolder(folder string) (string, error) {
+	parts := []string{}
+	for _, part := range strings.Split(folder, "/") {
+		part = strings.Join(strings.Fields(part), " ")
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	folder = strings.Join(parts, "/")
+	if len([]rune(folder)) > maxFolderLength {
+		return "", errors.New("folder must be at most 100 characters")
+	}
+	return folder, nil
+}
+
+// InFolder true if folder is parent or one of its subfolders; every folder
+// is in the top level
+func InFolder(folder string, parent string) bool {
+	if parent == "" || folder == parent {
+		return true
+	}
+	return strings.HasPrefix(folder, parent+"/")
+}
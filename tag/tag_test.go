@@ -0,0 +1,68 @@
+package tag_test
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"main/tag"
+)
+
+var _ = Describe("Tag", func() {
+	Describe("When normalizing tags", func() {
+		It("should trim, lower case, de-duplicate, and sort tags", func() {
+			tags, err := tag.Normalize([]string{" Retirement", "experimental", "", "retirement ", "Long  Term"})
+			Expect(err).To(BeNil())
+			Expect(tags).To(Equal([]string{"experimental", "long term", "retirement"}))
+		})
+
+		It("should reject long tags", func() {
+			_, err := tag.Normalize([]string{strings.Repeat("a", 33)})
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("should reject too many tags", func() {
+			tags := []string{}
+			for ii := 0; ii < 21; ii++ {
+				tags = append(tags, fmt.Sprintf("tag %d", ii))
+			}
+			_, err := tag.Normalize(tags)
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	Describe("When matching tags", func() {
+		It("should require every wanted tag", func() {
+			tags := []string{"experimental", "retirement"}
+			Expect(tag.Has(tags, []string{"Retirement"})).To(BeTrue())
+			Expect(tag.Has(tags, []string{"retirement", "experimental"})).To(BeTrue())
+			Expect(tag.Has(tags, []string{"retirement", "taxable"})).To(BeFalse())
+			Expect(tag.Has(tags, nil)).To(BeTrue())
+		})
+	})
+
+	Describe("When normalizing folders", func() {
+		It("should trim each part of the path", func() {
+			folder, err := tag.NormalizeFolder(" Retirement /  Roth IRA/ ")
+			Expect(err).To(BeNil())
+			Expect(folder).To(Equal("Retirement/Roth IRA"))
+		})
+
+		It("should reject long paths", func() {
+			_, err := tag.NormalizeFolder(strings.Repeat("a", 101))
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	Describe("When checking folders", func() {
+		It("should include subfolders", func() {
+			Expect(tag.InFolder("Retirement/IRA", "Retirement")).To(BeTrue())
+			Expect(tag.InFolder("Retirement", "Retirement")).To(BeTrue())
+			Expect(tag.InFolder("Retirement Plans", "Retirement")).To(BeFalse())
+			Expect(tag.InFolder("", "Retirement")).To(BeFalse())
+			Expect(tag.InFolder("Taxable", "")).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,473 @@
+package telemetry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"main/util"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// spanBatch spans sent in a single export request
+	spanBatch = 512
+
+	// spanBuffer finished spans queued for export; spans finished while
+	// the queue is full are dropped
+	spanBuffer = 4096
+
+	// spanInterval how often queued spans are exported
+	spanInterval = 5 * time.Second
+)
+
+// Exporter sends spans and metrics to an OpenTelemetry collector with the
+// JSON encoding of OTLP/HTTP
+type Exporter struct {
+	Endpoint string
+	Headers  map[string]string
+	Service  string
+
+	// MetricInterval how often metrics are exported
+	MetricInterval time.Duration
+
+	client  *http.Client
+	spans   chan *Span
+	flushes chan chan struct{}
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+var (
+	mu       sync.RWMutex
+	exporter *Exporter
+)
+
+// NewExporter create an exporter sending to the collector at endpoint, e.g.
+// http://localhost:4318, identifying this process as service
+func NewExporter(endpoint string, service string) *Exporter {
+	return &Exporter{
+		Endpoint:       strings.TrimRight(endpoint, "/"),
+		Headers:        map[string]string{},
+		Service:        service,
+		MetricInterval: time.Minute,
+		client:         &http.Client{Timeout: 10 * time.Second},
+		spans:          make(chan *Span, spanBuffer),
+		flushes:        make(chan chan struct{}),
+		done:           make(chan struct{}),
+	}
+}
+
+// Configure export telemetry to the collector at OTEL_EXPORTER_OTLP_ENDPOINT,
+// if set. The service name defaults to service and is overridden by
+// OTEL_SERVICE_NAME; OTEL_EXPORTER_OTLP_HEADERS (key=value pairs separated
+// by commas) are sent with every request and OTEL_METRIC_EXPORT_INTERVAL
+// sets the milliseconds between metric exports.
+func Configure(service string) (*Exporter, error) {
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	if endpoint == "" {
+		return nil, nil
+	}
+	if name := os.Getenv("OTEL_SERVICE_NAME"); name != "" {
+		service = name
+	}
+
+	e := NewExporter(endpoint, service)
+	if headers := os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"); headers != "" {
+		for _, pair := range strings.Split(headers, ",") {
+			kv := strings.SplitN(pair, "=", 2)
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("OTEL_EXPORTER_OTLP_HEADERS: invalid header '%s'", pair)
+			}
+			e.Headers[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		}
+	}
+	if interval := os.Getenv("OTEL_METRIC_EXPORT_INTERVAL"); interval != "" {
+		ms, err := strconv.Atoi(interval)
+		if err != nil || ms <= 0 {
+			return nil, fmt.Errorf("OTEL_METRIC_EXPORT_INTERVAL must be a positive number of milliseconds")
+		}
+		e.MetricInterval = time.Duration(ms) * time.Millisecond
+	}
+
+	e.Start()
+	return e, nil
+}
+
+// Start export in the background until Shutdown is called
+func (e *Exporter) Start() {
+	mu.Lock()
+	exporter = e
+	mu.Unlock()
+
+	e.wg.Add(1)
+	go e.run()
+}
+
+// Flush export queued spans and the current metrics now
+func (e *Exporter) Flush() {
+	reply := make(chan struct{})
+	select {
+	case e.flushes <- reply:
+		<-reply
+	case <-e.done:
+	}
+}
+
+// Shutdown stop exporting after sending queued spans and the final metrics
+func (e *Exporter) Shutdown() {
+	mu.Lock()
+	if exporter == e {
+		exporter = nil
+	}
+	mu.Unlock()
+
+	close(e.done)
+	e.wg.Wait()
+}
+
+func (e *Exporter) queue(s *Span) {
+	select {
+	case e.spans <- s:
+	default:
+	}
+}
+
+func (e *Exporter) run() {
+	defer e.wg.Done()
+
+	spanTicker := time.NewTicker(spanInterval)
+	defer spanTicker.Stop()
+	metricTicker := time.NewTicker(e.MetricInterval)
+	defer metricTicker.Stop()
+
+	batch := []*Span{}
+	for {
+		select {
+		case s := <-e.spans:
+			batch = append(batch, s)
+			if len(batch) >= spanBatch {
+				e.exportSpans(batch)
+				batch = []*Span{}
+			}
+		case <-spanTicker.C:
+			e.exportSpans(batch)
+			batch = []*Span{}
+		case <-metricTicker.C:
+			e.exportMetrics()
+		case reply := <-e.flushes:
+			batch = e.drain(batch)
+			e.exportSpans(batch)
+			batch = []*Span{}
+			e.exportMetrics()
+			close(reply)
+		case <-e.done:
+			e.exportSpans(e.drain(batch))
+			e.exportMetrics()
+			return
+		}
+	}
+}
+
+// drain the queued spans into batch
+func (e *Exporter) drain(batch []*Span) []*Span {
+	for {
+		select {
+		case s := <-e.spans:
+			batch = append(batch, s)
+		default:
+			return batch
+		}
+	}
+}
+
+func (e *Exporter) exportSpans(batch []*Span) {
+	for len(batch) > 0 {
+		n := len(batch)
+		if n > spanBatch {
+			n = spanBatch
+		}
+		e.post("/v1/traces", e.traces(batch[:n]))
+		batch = batch[n:]
+	}
+}
+
+func (e *Exporter) exportMetrics() {
+	if metrics := collect(); len(metrics) > 0 {
+		e.post("/v1/metrics", e.metrics(metrics))
+	}
+}
+
+func (e *Exporter) post(path string, payload interface{}) {
+	if err := e.send(path, payload); err != nil {
+		// not logged, as logging is exported by other hooks that may fail too
+		fmt.Fprintf(os.Stderr, "%v ERROR: could not export telemetry to %s: %v\n", time.Now(), path, err)
+	}
+}
+
+func (e *Exporter) send(path string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, e.Endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, val := range e.Headers {
+		req.Header.Set(key, val)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("collector returned HTTP status %s", resp.Status)
+	}
+	return nil
+}
+
+// OTLP JSON encoding; 64-bit integers are encoded as strings
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpTraces struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpNumberPoint struct {
+	Attributes        []otlpAttribute `json:"attributes"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	TimeUnixNano      string          `json:"timeUnixNano"`
+	AsInt             string          `json:"asInt"`
+}
+
+type otlpSum struct {
+	AggregationTemporality int               `json:"aggregationTemporality"`
+	IsMonotonic            bool              `json:"isMonotonic"`
+	DataPoints             []otlpNumberPoint `json:"dataPoints"`
+}
+
+type otlpHistogramPoint struct {
+	Attributes        []otlpAttribute `json:"attributes"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	TimeUnixNano      string          `json:"timeUnixNano"`
+	Count             string          `json:"count"`
+	Sum               float64         `json:"sum"`
+	Min               float64         `json:"min"`
+	Max               float64         `json:"max"`
+	BucketCounts      []string        `json:"bucketCounts"`
+	ExplicitBounds    []float64       `json:"explicitBounds"`
+}
+
+type otlpHistogram struct {
+	AggregationTemporality int                  `json:"aggregationTemporality"`
+	DataPoints             []otlpHistogramPoint `json:"dataPoints"`
+}
+
+type otlpMetric struct {
+	Name        string         `json:"name"`
+	Unit        string         `json:"unit,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Sum         *otlpSum       `json:"sum,omitempty"`
+	Histogram   *otlpHistogram `json:"histogram,omitempty"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpMetrics struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+const (
+	spanKindClient        = 3
+	statusCodeOK          = 1
+	statusCodeError       = 2
+	temporalityCumulative = 2
+)
+
+func attributes(attrs Attributes) []otlpAttribute {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	encoded := make([]otlpAttribute, 0, len(keys))
+	for _, k := range keys {
+		encoded = append(encoded, otlpAttribute{Key: k, Value: otlpValue{StringValue: attrs[k]}})
+	}
+	return encoded
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func (e *Exporter) resource() otlpResource {
+	return otlpResource{Attributes: attributes(Attributes{
+		"service.name":    e.Service,
+		"service.version": util.Version,
+	})}
+}
+
+func scope() otlpScope {
+	return otlpScope{Name: "main/telemetry", Version: util.Version}
+}
+
+func (e *Exporter) traces(batch []*Span) otlpTraces {
+	spans := make([]otlpSpan, 0, len(batch))
+	for _, s := range batch {
+		status := otlpStatus{Code: statusCodeOK}
+		if s.Error != "" {
+			status = otlpStatus{Code: statusCodeError, Message: s.Error}
+		}
+		spans = append(spans, otlpSpan{
+			TraceID:           s.TraceID,
+			SpanID:            s.SpanID,
+			Name:              s.Name,
+			Kind:              spanKindClient,
+			StartTimeUnixNano: unixNano(s.Start),
+			EndTimeUnixNano:   unixNano(s.End),
+			Attributes:        attributes(s.Attributes),
+			Status:            status,
+		})
+	}
+	return otlpTraces{ResourceSpans: []otlpResourceSpans{{
+		Resource:   e.resource(),
+		ScopeSpans: []otlpScopeSpans{{Scope: scope(), Spans: spans}},
+	}}}
+}
+
+func (e *Exporter) metrics(metrics []otlpMetric) otlpMetrics {
+	return otlpMetrics{ResourceMetrics: []otlpResourceMetrics{{
+		Resource:     e.resource(),
+		ScopeMetrics: []otlpScopeMetrics{{Scope: scope(), Metrics: metrics}},
+	}}}
+}
+
+// collect the cumulative value of every metric with at least one data point
+func collect() []otlpMetric {
+	registry.Lock()
+	cs := append([]*Counter{}, counters...)
+	hs := append([]*Histogram{}, histograms...)
+	registry.Unlock()
+
+	start, now := unixNano(started), unixNano(time.Now())
+	metrics := []otlpMetric{}
+	for _, c := range cs {
+		c.mu.Lock()
+		points := make([]otlpNumberPoint, 0, len(c.points))
+		for _, p := range c.points {
+			points = append(points, otlpNumberPoint{
+				Attributes:        attributes(p.attrs),
+				StartTimeUnixNano: start,
+				TimeUnixNano:      now,
+				AsInt:             strconv.FormatInt(p.value, 10),
+			})
+		}
+		c.mu.Unlock()
+
+		if len(points) > 0 {
+			metrics = append(metrics, otlpMetric{
+				Name:        c.Name,
+				Unit:        c.Unit,
+				Description: c.Description,
+				Sum:         &otlpSum{AggregationTemporality: temporalityCumulative, IsMonotonic: true, DataPoints: points},
+			})
+		}
+	}
+
+	for _, h := range hs {
+		h.mu.Lock()
+		points := make([]otlpHistogramPoint, 0, len(h.points))
+		for _, p := range h.points {
+			counts := make([]string, len(p.counts))
+			for ii, n := range p.counts {
+				counts[ii] = strconv.FormatUint(n, 10)
+			}
+			points = append(points, otlpHistogramPoint{
+				Attributes:        attributes(p.attrs),
+				StartTimeUnixNano: start,
+				TimeUnixNano:      now,
+				Count:             strconv.FormatUint(p.count, 10),
+				Sum:               p.sum,
+				Min:               p.min,
+				Max:               p.max,
+				BucketCounts:      counts,
+				ExplicitBounds:    h.Bounds,
+			})
+		}
+		h.mu.Unlock()
+
+		if len(points) > 0 {
+			metrics = append(metrics, otlpMetric{
+				Name:        h.Name,
+				Unit:        h.Unit,
+				Description: h.Description,
+				Histogram:   &otlpHistogram{AggregationTemporality: temporalityCumulative, DataPoints: points},
+			})
+		}
+	}
+	return metrics
+}
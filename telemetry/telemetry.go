@@ -0,0 +1,226 @@
+// Package telemetry records spans and metrics of the calls pv-api makes to
+// data providers, its caches, and the database so slow runs can be
+// attributed to the service they waited on. Metrics are always aggregated
+// in memory; they and finished spans are exported over OTLP/HTTP once
+// Configure finds an OTLP endpoint.
+package telemetry
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Attributes describe a span or the data point of a metric, e.g. the
+// provider called
+type Attributes map[string]string
+
+// key a canonical string of the attributes used to find their data point
+func (a Attributes) key() string {
+	keys := make([]string, 0, len(a))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, k := range keys {
+		sb.WriteString(k)
+		sb.WriteByte('=')
+		sb.WriteString(a[k])
+		sb.WriteByte(0)
+	}
+	return sb.String()
+}
+
+func (a Attributes) copy() Attributes {
+	c := make(Attributes, len(a))
+	for k, v := range a {
+		c[k] = v
+	}
+	return c
+}
+
+// DurationBuckets histogram bounds, in milliseconds, for call latencies
+var DurationBuckets = []float64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000, 60000}
+
+// SizeBuckets histogram bounds, in bytes, for response sizes
+var SizeBuckets = []float64{1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20, 4 << 20, 16 << 20}
+
+// Counter a cumulative sum, e.g. the number of cache hits
+type Counter struct {
+	Name        string
+	Unit        string
+	Description string
+
+	mu     sync.Mutex
+	points map[string]*counterPoint
+}
+
+type counterPoint struct {
+	attrs Attributes
+	value int64
+}
+
+// Histogram a distribution of recorded values, e.g. call latencies
+type Histogram struct {
+	Name        string
+	Unit        string
+	Description string
+	Bounds      []float64
+
+	mu     sync.Mutex
+	points map[string]*histogramPoint
+}
+
+type histogramPoint struct {
+	attrs  Attributes
+	count  uint64
+	sum    float64
+	min    float64
+	max    float64
+	counts []uint64
+}
+
+var (
+	registry   sync.Mutex
+	counters   []*Counter
+	histograms []*Histogram
+	started    = time.Now()
+)
+
+// NewCounter create a counter that is exported with every other metric
+func NewCounter(name string, unit string, description string) *Counter {
+	c := &Counter{Name: name, Unit: unit, Description: description, points: map[string]*counterPoint{}}
+	registry.Lock()
+	counters = append(counters, c)
+	registry.Unlock()
+	return c
+}
+
+// Add increase the count of attrs by n
+func (c *Counter) Add(n int64, attrs Attributes) {
+	key := attrs.key()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.points[key]
+	if !ok {
+		p = &counterPoint{attrs: attrs.copy()}
+		c.points[key] = p
+	}
+	p.value += n
+}
+
+// Value the count of attrs
+func (c *Counter) Value(attrs Attributes) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if p, ok := c.points[attrs.key()]; ok {
+		return p.value
+	}
+	return 0
+}
+
+// NewHistogram create a histogram with buckets bounded by bounds that is
+// exported with every other metric
+func NewHistogram(name string, unit string, description string, bounds []float64) *Histogram {
+	h := &Histogram{Name: name, Unit: unit, Description: description, Bounds: bounds, points: map[string]*histogramPoint{}}
+	registry.Lock()
+	histograms = append(histograms, h)
+	registry.Unlock()
+	return h
+}
+
+// Record add value to the distribution of attrs
+func (h *Histogram) Record(value float64, attrs Attributes) {
+	key := attrs.key()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	p, ok := h.points[key]
+	if !ok {
+		p = &histogramPoint{attrs: attrs.copy(), min: value, max: value, counts: make([]uint64, len(h.Bounds)+1)}
+		h.points[key] = p
+	}
+	p.count++
+	p.sum += value
+	if value < p.min {
+		p.min = value
+	}
+	if value > p.max {
+		p.max = value
+	}
+	bucket := sort.SearchFloat64s(h.Bounds, value)
+	p.counts[bucket]++
+}
+
+// Count number of values recorded for attrs
+func (h *Histogram) Count(attrs Attributes) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if p, ok := h.points[attrs.key()]; ok {
+		return p.count
+	}
+	return 0
+}
+
+// Milliseconds d as the fractional milliseconds recorded by duration
+// histograms
+func Milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// Span a timed operation, e.g. a download from a data provider
+type Span struct {
+	Name       string
+	TraceID    string
+	SpanID     string
+	Start      time.Time
+	End        time.Time
+	Attributes Attributes
+
+	// Error message of the error the operation failed with
+	Error string
+}
+
+// StartSpan begin timing an operation; call Finish when it completes
+func StartSpan(name string, attrs Attributes) *Span {
+	return &Span{
+		Name:       name,
+		TraceID:    randomID(16),
+		SpanID:     randomID(8),
+		Start:      time.Now(),
+		Attributes: attrs.copy(),
+	}
+}
+
+// SetAttribute describe the span with key
+func (s *Span) SetAttribute(key string, value string) {
+	s.Attributes[key] = value
+}
+
+// Finish end the span, recording err if the operation failed, and queue it
+// for export
+func (s *Span) Finish(err error) {
+	s.End = time.Now()
+	if err != nil {
+		s.Error = err.Error()
+	}
+
+	mu.RLock()
+	e := exporter
+	mu.RUnlock()
+	if e != nil {
+		e.queue(s)
+	}
+}
+
+func randomID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
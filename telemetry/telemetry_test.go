@@ -0,0 +1,116 @@
+package telemetry_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"main/telemetry"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Telemetry", func() {
+	Describe("When values are recorded", func() {
+		It("should count each set of attributes separately", func() {
+			counter := telemetry.NewCounter("test.requests", "{request}", "")
+			counter.Add(1, telemetry.Attributes{"provider": "tiingo", "result": "hit"})
+			counter.Add(2, telemetry.Attributes{"result": "hit", "provider": "tiingo"})
+			counter.Add(1, telemetry.Attributes{"provider": "fred", "result": "hit"})
+
+			Expect(counter.Value(telemetry.Attributes{"provider": "tiingo", "result": "hit"})).To(Equal(int64(3)))
+			Expect(counter.Value(telemetry.Attributes{"provider": "fred", "result": "hit"})).To(Equal(int64(1)))
+			Expect(counter.Value(telemetry.Attributes{"provider": "fred"})).To(Equal(int64(0)))
+		})
+
+		It("should count values in a histogram", func() {
+			histogram := telemetry.NewHistogram("test.duration", "ms", "", []float64{10, 100})
+			for _, v := range []float64{5, 50, 500} {
+				histogram.Record(v, telemetry.Attributes{"provider": "tiingo"})
+			}
+			Expect(histogram.Count(telemetry.Attributes{"provider": "tiingo"})).To(Equal(uint64(3)))
+		})
+	})
+
+	Describe("When telemetry is exported", func() {
+		var (
+			server   *httptest.Server
+			exporter *telemetry.Exporter
+			mu       sync.Mutex
+			payloads map[string][]map[string]interface{}
+		)
+
+		BeforeEach(func() {
+			payloads = map[string][]map[string]interface{}{}
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+				Expect(r.Header.Get("Content-Type")).To(Equal("application/json"))
+				Expect(r.Header.Get("Authorization")).To(Equal("Bearer secret"))
+				body, _ := ioutil.ReadAll(r.Body)
+				payload := map[string]interface{}{}
+				Expect(json.Unmarshal(body, &payload)).To(Succeed())
+
+				mu.Lock()
+				payloads[r.URL.Path] = append(payloads[r.URL.Path], payload)
+				mu.Unlock()
+			}))
+
+			exporter = telemetry.NewExporter(server.URL+"/", "pvapi-test")
+			exporter.Headers["Authorization"] = "Bearer secret"
+			exporter.Start()
+		})
+
+		AfterEach(func() {
+			exporter.Shutdown()
+			server.Close()
+		})
+
+		It("should send finished spans", func() {
+			span := telemetry.StartSpan("data.provider.get", telemetry.Attributes{"provider": "fred"})
+			span.SetAttribute("http.status_code", "500")
+			span.Finish(errors.New("HTTP status 500"))
+			exporter.Flush()
+
+			mu.Lock()
+			defer mu.Unlock()
+			Expect(payloads["/v1/traces"]).To(HaveLen(1))
+			resourceSpans := payloads["/v1/traces"][0]["resourceSpans"].([]interface{})[0].(map[string]interface{})
+			resource := resourceSpans["resource"].(map[string]interface{})["attributes"].([]interface{})
+			Expect(resource).To(ContainElement(map[string]interface{}{"key": "service.name", "value": map[string]interface{}{"stringValue": "pvapi-test"}}))
+			spans := resourceSpans["scopeSpans"].([]interface{})[0].(map[string]interface{})["spans"].([]interface{})
+			Expect(spans).To(HaveLen(1))
+			exported := spans[0].(map[string]interface{})
+			Expect(exported["name"]).To(Equal("data.provider.get"))
+			Expect(exported["traceId"]).To(HaveLen(32))
+			Expect(exported["spanId"]).To(HaveLen(16))
+			Expect(exported["status"]).To(Equal(map[string]interface{}{"code": float64(2), "message": "HTTP status 500"}))
+		})
+
+		It("should send cumulative metrics", func() {
+			counter := telemetry.NewCounter("test.exported", "{request}", "Exported requests")
+			counter.Add(2, telemetry.Attributes{"provider": "tiingo"})
+			exporter.Flush()
+
+			mu.Lock()
+			defer mu.Unlock()
+			Expect(payloads["/v1/metrics"]).ToNot(BeEmpty())
+			resourceMetrics := payloads["/v1/metrics"][0]["resourceMetrics"].([]interface{})[0].(map[string]interface{})
+			metrics := resourceMetrics["scopeMetrics"].([]interface{})[0].(map[string]interface{})["metrics"].([]interface{})
+
+			var exported map[string]interface{}
+			for _, m := range metrics {
+				if m.(map[string]interface{})["name"] == "test.exported" {
+					exported = m.(map[string]interface{})
+				}
+			}
+			Expect(exported).ToNot(BeNil())
+			sum := exported["sum"].(map[string]interface{})
+			Expect(sum["isMonotonic"]).To(BeTrue())
+			point := sum["dataPoints"].([]interface{})[0].(map[string]interface{})
+			Expect(point["asInt"]).To(Equal("2"))
+		})
+	})
+})
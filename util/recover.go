@@ -0,0 +1,28 @@
+package util
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError a computation panicked. Stage names the computation and Stack
+// is the stack trace of the goroutine when it panicked.
+type PanicError struct {
+	Stage string
+	Value interface{}
+	Stack string
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%s panicked: %v", e.Stage, e.Value)
+}
+
+// Recover call fn, converting a panic into a *PanicError for stage
+func Recover(stage string, fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Stage: stage, Value: r, Stack: string(debug.Stack())}
+		}
+	}()
+	return fn()
+}
@@ -0,0 +1,32 @@
+package util_test
+
+import (
+	"errors"
+	"main/util"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Recover", func() {
+	It("should return the error of the function", func() {
+		err := util.Recover("compute", func() error {
+			return errors.New("no prices")
+		})
+		Expect(err).To(MatchError("no prices"))
+	})
+
+	It("should convert a panic into an error with a stack trace", func() {
+		err := util.Recover("compute", func() error {
+			var rows []float64
+			_ = rows[3]
+			return nil
+		})
+
+		var pe *util.PanicError
+		Expect(errors.As(err, &pe)).To(BeTrue())
+		Expect(pe.Stage).To(Equal("compute"))
+		Expect(err.Error()).To(HavePrefix("compute panicked: runtime error: index out of range"))
+		Expect(pe.Stack).To(ContainSubstring("recover_test.go"))
+	})
+})
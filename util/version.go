@@ -0,0 +1,5 @@
+package util
+
+// Version of the code, set when building with
+// -ldflags "-X main/util.Version=<version>"; see the Makefile
+var Version = "dev"
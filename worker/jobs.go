@@ -0,0 +1,159 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"main/data"
+	"main/portfolio"
+	"main/queue"
+	"main/repository"
+	"main/strategies"
+	"time"
+)
+
+// ErrNoToken the user who owns a job has no stored tiingo token
+var ErrNoToken = errors.New("user has no stored tiingo token")
+
+// RegisterStrategyHandlers register the handlers of the strategy computation
+// jobs enqueued by the API and the notifier
+func RegisterStrategyHandlers(w *Worker) {
+	w.Register(queue.KindRunStrategy, RunStrategy)
+	w.Register(queue.KindComputePortfolio, ComputePortfolio)
+}
+
+// newDataManager create a data manager using the stored tiingo token of
+// userID
+func newDataManager(ctx context.Context, userID string) (data.Manager, error) {
+	u, err := repository.Users.Get(ctx, userID)
+	if err != nil {
+		return data.Manager{}, fmt.Errorf("cannot load user %s: %w", userID, err)
+	}
+	if u.TiingoToken == "" {
+		return data.Manager{}, ErrNoToken
+	}
+
+	return data.NewManager(map[string]string{
+		"tiingo": u.TiingoToken,
+	}), nil
+}
+
+// RunStrategy backtest a strategy; handler of KindRunStrategy
+func RunStrategy(ctx context.Context, job *queue.Job) (interface{}, error) {
+	req := queue.RunStrategy{}
+	if err := job.Decode(&req); err != nil {
+		return nil, err
+	}
+
+	strat, ok := strategies.StrategyMap[req.Shortcode]
+	if !ok {
+		return nil, fmt.Errorf("strategy '%s' not found", req.Shortcode)
+	}
+
+	params := map[string]json.RawMessage{}
+	if err := json.Unmarshal(req.Arguments, &params); err != nil {
+		return nil, err
+	}
+
+	stratObject, err := strat.Factory(params)
+	if err != nil {
+		return nil, err
+	}
+
+	manager, err := newDataManager(ctx, job.UserID)
+	if err != nil {
+		return nil, err
+	}
+	manager.Begin = req.StartDate
+	manager.End = req.EndDate
+
+	historyFrom := time.Time{}
+	if req.CheckHistory {
+		historyFrom = req.StartDate
+	}
+	if err := strat.CheckConstraints(params, historyFrom, &manager); err != nil {
+		return nil, err
+	}
+
+	strategies.SetProgress(stratObject, Progress(ctx))
+	p, err := stratObject.Compute(&manager)
+	if err != nil {
+		return nil, err
+	}
+
+	p.Resolution = req.Resolution
+	perf, err := p.CalculatePerformance(manager.End)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.AccountType != "" {
+		if err := perf.CalculateAfterTax(req.AccountType, portfolio.DefaultTaxRates); err != nil {
+			return nil, err
+		}
+	}
+
+	perf.BuildMetricsBundle()
+	return &perf, nil
+}
+
+// ComputePortfolio compute a saved portfolio through a date; handler of
+// KindComputePortfolio
+func ComputePortfolio(ctx context.Context, job *queue.Job) (interface{}, error) {
+	req := queue.ComputePortfolio{}
+	if err := job.Decode(&req); err != nil {
+		return nil, err
+	}
+
+	saved, err := repository.Portfolios.Get(ctx, req.PortfolioID.String(), req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load portfolio %s: %w", req.PortfolioID, err)
+	}
+
+	strat, ok := strategies.StrategyMap[saved.Strategy]
+	if !ok {
+		return nil, fmt.Errorf("strategy '%s' not found", saved.Strategy)
+	}
+
+	params := map[string]json.RawMessage{}
+	if err := json.Unmarshal(saved.Arguments, &params); err != nil {
+		return nil, err
+	}
+
+	// the notifier persists migrated arguments before enqueueing the job
+	params, _, err = strat.MigrateArguments(saved.StrategyVersion, params)
+	if err != nil {
+		return nil, err
+	}
+
+	stratObject, err := strat.Factory(params)
+	if err != nil {
+		return nil, err
+	}
+
+	manager, err := newDataManager(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	manager.Begin = time.Unix(saved.StartDate, 0)
+	manager.End = req.Through
+	manager.Frequency = data.FrequencyMonthly
+
+	strategies.SetProgress(stratObject, Progress(ctx))
+	p, err := stratObject.Compute(&manager)
+	if err != nil {
+		return nil, err
+	}
+
+	perf, err := p.CalculatePerformance(req.Through)
+	if err != nil {
+		return nil, err
+	}
+
+	return &queue.ComputedPortfolio{
+		Transactions: p.Transactions,
+		Signals:      p.Signals,
+		Performance:  perf,
+	}, nil
+}
@@ -0,0 +1,58 @@
+package worker
+
+import (
+	"context"
+	"main/logging"
+	"main/portfolio"
+	"main/queue"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+type progressKey struct{}
+
+// jobProgress records the progress of a job in its queue. Only whole percent
+// changes are recorded so simulations with thousands of dates don't write to
+// the queue on every one of them.
+type jobProgress struct {
+	queue   queue.Queue
+	id      uuid.UUID
+	percent int
+}
+
+// Progress record percent and date if percent has reached the next whole
+// percent
+func (p *jobProgress) Progress(percent float64, date time.Time) {
+	whole := int(percent)
+	if whole <= p.percent {
+		return
+	}
+	p.percent = whole
+
+	if err := p.queue.Progress(p.id, percent, date); err != nil {
+		log.WithFields(log.Fields{
+			logging.FieldFunction: "worker/progress.go:Progress",
+			"JobID":               p.id,
+			logging.FieldError:    err,
+		}).Warn("Could not record job progress")
+	}
+}
+
+// withProgress ctx carrying a reporter that records the progress of job
+func withProgress(ctx context.Context, q queue.Queue, job *queue.Job) context.Context {
+	return context.WithValue(ctx, progressKey{}, &jobProgress{
+		queue: q,
+		id:    job.ID,
+	})
+}
+
+// Progress the reporter handlers notify of the progress of the job they are
+// running with ctx; a no-op outside of a job
+func Progress(ctx context.Context) portfolio.ProgressReporter {
+	if reporter, ok := ctx.Value(progressKey{}).(portfolio.ProgressReporter); ok {
+		return reporter
+	}
+	return portfolio.ProgressFunc(func(float64, time.Time) {})
+}
@@ -0,0 +1,140 @@
+// Package worker runs the jobs of a work queue. Each kind of job has a
+// handler; a handler that panics fails its job instead of the worker, so
+// errors in dataframe computations are isolated to the job that caused them.
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"main/logging"
+	"main/queue"
+	"main/reporting"
+	"main/util"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Handler run a job and return its result, which is stored JSON encoded
+type Handler func(ctx context.Context, job *queue.Job) (interface{}, error)
+
+// ErrUnknownKind no handler is registered for the kind of job
+var ErrUnknownKind = errors.New("no handler for job kind")
+
+// Worker claims jobs from a queue and runs their handlers
+type Worker struct {
+	Queue queue.Queue
+
+	// Name identifies the worker in the job table
+	Name string
+
+	// PollInterval how long to wait before polling an empty queue again;
+	// defaults to one second
+	PollInterval time.Duration
+
+	handlers map[string]Handler
+}
+
+// New create a worker named name that claims jobs from q
+func New(q queue.Queue, name string) *Worker {
+	return &Worker{
+		Queue:        q,
+		Name:         name,
+		PollInterval: time.Second,
+		handlers:     make(map[string]Handler),
+	}
+}
+
+// Register run handler for jobs of kind
+func (w *Worker) Register(kind string, handler Handler) {
+	w.handlers[kind] = handler
+}
+
+// Run process jobs until ctx is cancelled
+func (w *Worker) Run(ctx context.Context) {
+	for {
+		processed, err := w.ProcessOne(ctx)
+		if err != nil {
+			log.WithFields(log.Fields{
+				logging.FieldFunction: "worker/worker.go:Run",
+				"Worker":              w.Name,
+				logging.FieldError:    err,
+			}).Error("Could not process job")
+		}
+		if processed && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(w.PollInterval):
+		}
+	}
+}
+
+// ProcessOne claim and run a single job. Returns false if the queue was
+// empty. The returned error is an error of the queue; a job that fails is
+// recorded as failed and does not return an error.
+func (w *Worker) ProcessOne(ctx context.Context) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	job, err := w.Queue.Claim(w.Name)
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	logger := log.WithFields(log.Fields{
+		logging.FieldFunction: "worker/worker.go:ProcessOne",
+		"Worker":              w.Name,
+		"JobID":               job.ID,
+		"Kind":                job.Kind,
+		"Attempts":            job.Attempts,
+	})
+
+	started := time.Now()
+	result, err := w.run(ctx, job)
+	logger = logger.WithField("Duration", time.Since(started).Round(time.Millisecond))
+
+	if err != nil {
+		fields := log.Fields{logging.FieldError: err}
+		var panicErr *util.PanicError
+		if errors.As(err, &panicErr) {
+			fields["Stack"] = panicErr.Stack
+			reporting.Report(err, reporting.Context{
+				UserID: job.UserID,
+				Tags: map[string]string{
+					"job":    job.ID.String(),
+					"kind":   job.Kind,
+					"worker": w.Name,
+				},
+			})
+		}
+		logger.WithFields(fields).Warn("Job failed")
+		return true, w.Queue.Fail(job.ID, err)
+	}
+
+	logger.Info("Job succeeded")
+	return true, w.Queue.Complete(job.ID, result)
+}
+
+// run call the job's handler, converting a panic into a util.PanicError.
+// The handler reports its progress to Progress(ctx).
+func (w *Worker) run(ctx context.Context, job *queue.Job) (interface{}, error) {
+	handler, ok := w.handlers[job.Kind]
+	if !ok {
+		return nil, fmt.Errorf("%w '%s'", ErrUnknownKind, job.Kind)
+	}
+
+	ctx = withProgress(ctx, w.Queue, job)
+
+	var result interface{}
+	err := util.Recover("job", func() (err error) {
+		result, err = handler(ctx, job)
+		return err
+	})
+	return result, err
+}
@@ -0,0 +1,132 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"main/queue"
+	"main/worker"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Worker", func() {
+	var (
+		q   *queue.MemoryQueue
+		w   *worker.Worker
+		ctx context.Context
+	)
+
+	BeforeEach(func() {
+		q = queue.NewMemoryQueue()
+		w = worker.New(q, "test")
+		ctx = context.Background()
+	})
+
+	enqueue := func(kind string) *queue.Job {
+		job, err := q.Enqueue(kind, "auth0|1", &queue.RunStrategy{Shortcode: "adm"})
+		Expect(err).NotTo(HaveOccurred())
+		return job
+	}
+
+	get := func(enqueued *queue.Job) *queue.Job {
+		job, err := q.Get(enqueued.ID, "")
+		Expect(err).NotTo(HaveOccurred())
+		return job
+	}
+
+	It("should report an empty queue", func() {
+		processed, err := w.ProcessOne(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(processed).To(BeFalse())
+	})
+
+	It("should store the result of the handler", func() {
+		w.Register(queue.KindRunStrategy, func(ctx context.Context, job *queue.Job) (interface{}, error) {
+			req := queue.RunStrategy{}
+			if err := job.Decode(&req); err != nil {
+				return nil, err
+			}
+			return map[string]string{"ran": req.Shortcode}, nil
+		})
+		enqueued := enqueue(queue.KindRunStrategy)
+
+		processed, err := w.ProcessOne(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(processed).To(BeTrue())
+
+		job := get(enqueued)
+		Expect(job.Status).To(Equal(queue.StatusSucceeded))
+		Expect(job.Result).To(MatchJSON(`{"ran": "adm"}`))
+	})
+
+	It("should fail jobs whose handler returns an error", func() {
+		w.Register(queue.KindRunStrategy, func(ctx context.Context, job *queue.Job) (interface{}, error) {
+			return nil, errors.New("no prices")
+		})
+		enqueued := enqueue(queue.KindRunStrategy)
+
+		_, err := w.ProcessOne(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(get(enqueued).Error).To(Equal("no prices"))
+	})
+
+	It("should fail jobs whose handler panics and keep working", func() {
+		w.Register(queue.KindRunStrategy, func(ctx context.Context, job *queue.Job) (interface{}, error) {
+			panic("different number of rows in series")
+		})
+		w.Register(queue.KindComputePortfolio, func(ctx context.Context, job *queue.Job) (interface{}, error) {
+			return "ok", nil
+		})
+		panicked := enqueue(queue.KindRunStrategy)
+		next := enqueue(queue.KindComputePortfolio)
+
+		_, err := w.ProcessOne(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		job := get(panicked)
+		Expect(job.Status).To(Equal(queue.StatusFailed))
+		Expect(job.Error).To(Equal("job panicked: different number of rows in series"))
+
+		_, err = w.ProcessOne(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(get(next).Status).To(Equal(queue.StatusSucceeded))
+	})
+
+	It("should record the progress reported by the handler", func() {
+		var running *queue.Job
+		w.Register(queue.KindRunStrategy, func(ctx context.Context, job *queue.Job) (interface{}, error) {
+			progress := worker.Progress(ctx)
+			for ii := 1; ii <= 40; ii++ {
+				progress.Progress(float64(ii)*0.25, time.Date(2020, time.January, ii, 0, 0, 0, 0, time.UTC))
+			}
+			running = get(job)
+			return "ok", nil
+		})
+		enqueue(queue.KindRunStrategy)
+
+		_, err := w.ProcessOne(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(running.Progress).To(Equal(10.0))
+		Expect(*running.ProgressDate).To(Equal(time.Date(2020, time.February, 9, 0, 0, 0, 0, time.UTC)))
+	})
+
+	It("should fail jobs of an unknown kind", func() {
+		enqueued := enqueue("unknown.kind")
+
+		_, err := w.ProcessOne(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(get(enqueued).Error).To(Equal("no handler for job kind 'unknown.kind'"))
+	})
+
+	It("should stop when the context is cancelled", func() {
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		done := make(chan struct{})
+		go func() {
+			w.Run(cancelled)
+			close(done)
+		}()
+		Eventually(done).Should(BeClosed())
+	})
+})